@@ -1,26 +1,53 @@
 package main
 
 import (
-	"encoding/json"
+	"bufio"
 	"context"
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"slices"
 	"strings"
 	"syscall"
+	"text/tabwriter"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/term"
 
+	"jenkins-tui/internal/audit"
+	"jenkins-tui/internal/board"
+	"jenkins-tui/internal/cache"
 	"jenkins-tui/internal/config"
 	"jenkins-tui/internal/credentials"
+	"jenkins-tui/internal/cron"
+	"jenkins-tui/internal/executor"
+	"jenkins-tui/internal/gitrepo"
 	"jenkins-tui/internal/jenkins"
+	"jenkins-tui/internal/logging"
+	"jenkins-tui/internal/metrics"
 	"jenkins-tui/internal/models"
+	"jenkins-tui/internal/redact"
+	"jenkins-tui/internal/refresh"
+	"jenkins-tui/internal/report"
+	"jenkins-tui/internal/schedule"
+	"jenkins-tui/internal/search"
+	"jenkins-tui/internal/tracing"
 	"jenkins-tui/internal/tui"
+	"jenkins-tui/internal/ui"
+	"jenkins-tui/internal/validate"
 )
 
+// stderr is where errors and progress go. Errors can quote URLs and server
+// responses, so tokens are scrubbed from it.
+var stderr = redact.Writer(os.Stderr)
+
 var (
 	version   = "dev"
 	commit    = "none"
@@ -42,14 +69,49 @@ func main() {
 		case "params":
 			runParams(os.Args[2:])
 			return
+		case "board":
+			runBoard(os.Args[2:])
+			return
+		case "jobs":
+			runJobs(os.Args[2:])
+			return
+		case "profiles":
+			runProfiles()
+			return
+		case "import":
+			runImport(os.Args[2:])
+			return
+		case "config":
+			runConfig(os.Args[2:])
+			return
+		case "sync":
+			runSync(os.Args[2:])
+			return
+		case "schedule":
+			runSchedule(os.Args[2:])
+			return
 		}
 	}
 
 	configPathFlag := flag.String("config", "", "absolute path to jenkins config file (default: $JENKINS_TUI_CONFIG or XDG config path)")
 	cacheDirFlag := flag.String("cache-dir", "", "absolute path for jobs cache (default: $JENKINS_TUI_CACHE_DIR or XDG cache path)")
+	profileFlag := flag.String("profile", "", "use the config profile of this name, e.g. work or personal (default: $JENKINS_TUI_PROFILE)")
 	timeout := flag.Duration("timeout", 60*time.Second, "HTTP client timeout for Jenkins API requests")
 	showVersion := flag.Bool("v", false, "print version information and exit")
 	showVersionLong := flag.Bool("version", false, "print version information and exit")
+	daemon := flag.Bool("daemon", false, "refresh job caches and search index for all targets, then exit (for cron)")
+	daemonInterval := flag.Duration("daemon-interval", 0, "with -daemon, keep running and refresh on this interval instead of exiting")
+	startServer := flag.String("server", "", "start connected to this configured target id")
+	startJob := flag.String("job", "", "with -server, open this job's parameters (full name like infra/deploy, or job URL)")
+	debug := flag.Bool("debug", false, "write structured debug logs (HTTP requests, cache hits, screen transitions)")
+	logFile := flag.String("log-file", "", "debug log path (implies -debug; default: <cache-dir>/debug.log)")
+	plain := flag.Bool("plain", false, "no colors, no spinner animation, ASCII borders (also enabled by TERM=dumb; NO_COLOR disables colors only)")
+	accessible := flag.Bool("accessible", false, "screen-reader friendly output: -plain plus states spelled out as words and fewer redraws (also $JENKINS_TUI_ACCESSIBLE)")
+	metricsAddr := flag.String("metrics-addr", "", "with -daemon, serve Prometheus metrics on this address (e.g. :9464)")
+	junitPath := flag.String("junit", "", "on exit, write the run batches of the session as JUnit XML to this path")
+	branch := flag.String("branch", "", "pre-select this branch's job in multibranch projects and fill BRANCH parameters with it (default: the current git branch)")
+	var startParams triggerParams
+	flag.Var(&startParams, "params", "with -job, pre-fill a parameter in KEY=VALUE form (repeatable)")
 	flag.Parse()
 	if *showVersion || *showVersionLong {
 		fmt.Printf("jenkins-tui %s\ncommit: %s\nbuilt: %s\n", version, commit, buildDate)
@@ -59,35 +121,140 @@ func main() {
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 
-	configPath, err := config.ResolvePath(*configPathFlag)
+	profile, err := config.ResolveProfile(*profileFlag)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "config error: %v\n", err)
+		fmt.Fprintf(stderr, "config error: %v\n", err)
 		os.Exit(1)
 	}
-	cacheDir, err := config.ResolveCacheDir(*cacheDirFlag)
+	configPath, err := config.ResolvePath(*configPathFlag, profile)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "config error: %v\n", err)
+		fmt.Fprintf(stderr, "config error: %v\n", err)
+		os.Exit(1)
+	}
+	cacheDir, err := config.ResolveCacheDir(*cacheDirFlag, profile)
+	if err != nil {
+		fmt.Fprintf(stderr, "config error: %v\n", err)
 		os.Exit(1)
 	}
 
+	if *debug || strings.TrimSpace(*logFile) != "" {
+		path := strings.TrimSpace(*logFile)
+		if path == "" {
+			path = filepath.Join(cacheDir, "debug.log")
+		}
+		closer, err := logging.Setup(path)
+		if err != nil {
+			fmt.Fprintf(stderr, "debug log error: %v\n", err)
+			os.Exit(1)
+		}
+		defer closer.Close()
+		slog.Debug("starting", "version", version, "commit", commit, "config", configPath, "cache_dir", cacheDir)
+	}
+
+	shutdownTracing, err := tracing.Setup(version)
+	if err != nil {
+		fmt.Fprintf(stderr, "tracing error: %v\n", err)
+		os.Exit(1)
+	}
+	defer shutdownTracing(context.Background())
+
 	cfg, err := config.Load(configPath)
 	if err != nil && !errors.Is(err, os.ErrNotExist) {
-		fmt.Fprintf(os.Stderr, "config error: %v\n", err)
+		fmt.Fprintf(stderr, "config error: %v\n", err)
 		os.Exit(1)
 	}
 	if errors.Is(err, os.ErrNotExist) {
 		cfg = models.Config{}
 	}
+	if err := tui.ValidateKeybindings(cfg.Keybindings); err != nil {
+		fmt.Fprintf(stderr, "config error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := audit.Setup(cfg.AuditLog); err != nil {
+		fmt.Fprintf(stderr, "config error: %v\n", err)
+		os.Exit(1)
+	}
+	jenkins.SetMaxResponseSize(cfg.MaxResponseMB)
 	cfg.Timeout = *timeout
 	cfg.ConfigPath = configPath
 	cfg.CacheDir = cacheDir
 
+	if strings.TrimSpace(*startServer) != "" || strings.TrimSpace(*startJob) != "" {
+		link, err := startupLink(cfg, *startServer, *startJob, startParams)
+		if err != nil {
+			fmt.Fprintf(stderr, "startup error: %v\n", err)
+			os.Exit(1)
+		}
+		cfg.Startup = link
+	}
+
+	if *daemon {
+		if strings.TrimSpace(*metricsAddr) != "" {
+			if err := metrics.Serve(ctx, *metricsAddr); err != nil {
+				fmt.Fprintf(stderr, "metrics error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		code := runDaemon(ctx, cfg, *daemonInterval)
+		shutdownTracing(context.Background())
+		os.Exit(code)
+	}
+
+	cfg.Startup.Branch = strings.TrimSpace(*branch)
+	if cfg.Startup.Branch == "" {
+		cfg.Startup.Branch, _ = gitrepo.Branch(".")
+		cfg.Startup.BranchDetected = cfg.Startup.Branch != ""
+	}
+	ui.Configure(*plain, *accessible)
 	model := tui.NewModel(ctx, cfg)
 	p := tea.NewProgram(model, tea.WithAltScreen())
-	if _, err := p.Run(); err != nil {
-		fmt.Fprintf(os.Stderr, "runtime error: %v\n", err)
+	final, err := p.Run()
+	if err != nil {
+		fmt.Fprintf(stderr, "runtime error: %v\n", err)
 		os.Exit(1)
 	}
+	if summary := tui.ExitSummary(final); summary != "" {
+		fmt.Print(summary)
+	}
+	if path := strings.TrimSpace(*junitPath); path != "" {
+		if suites := tui.ExitSuites(final); len(suites) > 0 {
+			if err := writeJUnit(path, suites); err != nil {
+				fmt.Fprintf(stderr, "junit error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+	}
+}
+
+func writeJUnit(path string, suites []report.Suite) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := report.WriteJUnit(f, suites); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// jobNames collects a repeatable --job flag. Job names may contain commas,
+// so each flag names exactly one job.
+type jobNames []string
+
+func (j *jobNames) String() string {
+	return strings.Join(*j, " ")
+}
+
+func (j *jobNames) Set(value string) error {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return fmt.Errorf("job cannot be empty")
+	}
+	if !slices.Contains(*j, value) {
+		*j = append(*j, value)
+	}
+	return nil
 }
 
 type triggerParams []string
@@ -117,6 +284,7 @@ type triggerResult struct {
 	BuildNumber int               `json:"buildNumber,omitempty"`
 	State       string            `json:"state,omitempty"`
 	Result      string            `json:"result,omitempty"`
+	LogFile     string            `json:"logFile,omitempty"`
 }
 
 type searchResult struct {
@@ -133,20 +301,39 @@ type listResult struct {
 	Jobs         []models.JobNode `json:"jobs"`
 }
 
+type jobsResult struct {
+	Target string           `json:"target"`
+	Folder string           `json:"folder,omitempty"`
+	Query  string           `json:"query,omitempty"`
+	Source string           `json:"source"`
+	Jobs   []models.JobNode `json:"jobs"`
+}
+
+type repoJobsResult struct {
+	Target string           `json:"target"`
+	Repo   string           `json:"repo"`
+	Jobs   []models.JobNode `json:"jobs"`
+}
+
 type paramsResult struct {
 	Target string            `json:"target"`
 	Job    string            `json:"job"`
 	Params []models.ParamDef `json:"params"`
+	Error  string            `json:"error,omitempty"`
 }
 
 func runTrigger(args []string) {
 	fs := flag.NewFlagSet("trigger", flag.ExitOnError)
 	configPathFlag := fs.String("config", "", "absolute path to jenkins config file")
+	profileFlag := fs.String("profile", "", "config profile name (default: $JENKINS_TUI_PROFILE)")
 	timeout := fs.Duration("timeout", 60*time.Second, "HTTP client timeout for Jenkins API requests")
 	targetID := fs.String("target", "", "configured Jenkins target id")
-	jobURL := fs.String("job", "", "full Jenkins job URL")
+	jobURL := fs.String("job", "", "job URL, full name (infra/deploy), or alias from the config")
 	wait := fs.Bool("wait", false, "wait for build completion")
 	jsonOut := fs.Bool("json", true, "print JSON output")
+	metricsAddr := fs.String("metrics-addr", "", "serve Prometheus metrics on this address while running (e.g. :9464)")
+	junitPath := fs.String("junit", "", "write the outcome as a JUnit XML testcase to this path (use with --wait)")
+	saveLogs := fs.String("save-logs", "", "write the build's console log into this directory as <job>-<number>.log (use with --wait)")
 	var params triggerParams
 	fs.Var(&params, "param", "build parameter in KEY=VALUE form (repeatable)")
 	fs.Parse(args)
@@ -157,37 +344,76 @@ func runTrigger(args []string) {
 	if strings.TrimSpace(*jobURL) == "" {
 		fatalf("trigger: --job is required")
 	}
+	if strings.TrimSpace(*saveLogs) != "" && !*wait {
+		fatalf("trigger: --save-logs needs --wait")
+	}
 
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 
-	target, client := mustBuildClient(ctx, *configPathFlag, *timeout, *targetID)
+	if strings.TrimSpace(*metricsAddr) != "" {
+		if err := metrics.Serve(ctx, *metricsAddr); err != nil {
+			fatalf("metrics error: %v", err)
+		}
+	}
+
+	shutdownTracing, err := tracing.Setup(version)
+	if err != nil {
+		fatalf("tracing error: %v", err)
+	}
+	defer shutdownTracing(context.Background())
+	onFatal(func(error) { shutdownTracing(context.Background()) })
+
+	target, client := mustBuildClient(ctx, *configPathFlag, *profileFlag, *timeout, *targetID)
 	paramMap, err := parseParams(params)
 	if err != nil {
 		fatalf("param error: %v", err)
 	}
+	*jobURL = resolveJobURL(client.Host(), target.ResolveAlias(*jobURL))
 
+	ctx, span := tracing.Start(ctx, "jenkins.run", tracing.KindInternal, tracing.String("jenkins.job.url", *jobURL))
+	onFatal(span.End)
+	started := time.Now()
+	var gauge executor.RunGauge
 	queueURL, err := client.TriggerBuild(ctx, *jobURL, paramMap)
 	if err != nil {
+		gauge.Set(models.RunError)
 		fatalf("trigger error: %v", err)
 	}
+	gauge.Set(models.RunQueued)
+	onFatal(func(error) { gauge.Fail() })
 
+	// Password parameters are masked in the output and the JUnit report.
+	shown := models.JobSpec{Params: paramMap}
+	if len(paramMap) > 0 {
+		if defs, err := client.GetJobParams(ctx, *jobURL); err == nil {
+			shown.Secret = models.PasswordNames(defs)
+		}
+	}
 	result := triggerResult{
 		Target:   target.ID,
-		Job:      strings.TrimSpace(*jobURL),
-		Params:   paramMap,
+		Job:      *jobURL,
+		Params:   shown.ShownParams(),
 		QueueURL: queueURL,
 		State:    string(models.RunQueued),
 	}
 
 	if *wait {
+		ctx := jenkins.WithStallHandler(ctx, func(err error) {
+			if err != nil {
+				fmt.Fprintf(stderr, "stalled: Jenkins is unreachable (%v); retrying until it is back\n", err)
+				return
+			}
+			fmt.Fprintln(stderr, "resumed: Jenkins is reachable again")
+		})
 		buildURL, num, err := client.ResolveQueue(ctx, queueURL)
 		if err != nil {
 			fatalJSONOrText(*jsonOut, result, fmt.Errorf("queue resolve error: %w", err))
 		}
-		result.BuildURL = buildURL
+		result.BuildURL = client.ExternalURL(buildURL)
 		result.BuildNumber = num
 		result.State = string(models.RunRunning)
+		gauge.Set(models.RunRunning)
 
 		buildResult, err := client.PollBuild(ctx, buildURL)
 		if err != nil {
@@ -195,6 +421,26 @@ func runTrigger(args []string) {
 		}
 		result.Result = buildResult
 		result.State = buildResult
+		gauge.Set(executor.MapResult(buildResult))
+		span.SetAttr(tracing.String("jenkins.build.url", buildURL), tracing.String("jenkins.build.result", buildResult))
+
+		if dir := strings.TrimSpace(*saveLogs); dir != "" {
+			text, err := client.GetConsoleText(ctx, buildURL)
+			if err != nil {
+				fatalJSONOrText(*jsonOut, result, fmt.Errorf("console log error: %w", err))
+			}
+			result.LogFile, err = report.SaveLog(dir, jenkins.FullNameFromJobURL(*jobURL), num, text)
+			if err != nil {
+				fatalJSONOrText(*jsonOut, result, err)
+			}
+		}
+	}
+	span.End(nil)
+
+	if path := strings.TrimSpace(*junitPath); path != "" {
+		if err := writeJUnit(path, []report.Suite{triggerSuite(result, started)}); err != nil {
+			fatalf("junit error: %v", err)
+		}
 	}
 
 	if *jsonOut {
@@ -209,11 +455,44 @@ func runTrigger(args []string) {
 	if result.Result != "" {
 		fmt.Printf("result=%s\n", result.Result)
 	}
+	if result.LogFile != "" {
+		fmt.Printf("log=%s\n", result.LogFile)
+	}
+}
+
+// triggerSuite turns a trigger result into a one-run JUnit suite.
+func triggerSuite(result triggerResult, started time.Time) report.Suite {
+	name := jenkins.FullNameFromJobURL(result.Job)
+	if name == "" {
+		name = result.Job
+	}
+	run := models.RunRecord{
+		Spec:        models.JobSpec{Params: result.Params},
+		State:       models.RunState(result.State),
+		QueueURL:    result.QueueURL,
+		BuildURL:    result.BuildURL,
+		BuildNumber: result.BuildNumber,
+		Result:      result.Result,
+		StartedAt:   started,
+	}
+	if result.Result != "" {
+		run.EndedAt = time.Now()
+		switch result.Result {
+		case "SUCCESS":
+			run.State = models.RunSuccess
+		case "ABORTED":
+			run.State = models.RunAborted
+		default:
+			run.State = models.RunFailed
+		}
+	}
+	return report.Suite{Name: name, Started: started, Runs: []models.RunRecord{run}}
 }
 
 func runSearch(args []string) {
 	fs := flag.NewFlagSet("search", flag.ExitOnError)
 	configPathFlag := fs.String("config", "", "absolute path to jenkins config file")
+	profileFlag := fs.String("profile", "", "config profile name (default: $JENKINS_TUI_PROFILE)")
 	timeout := fs.Duration("timeout", 60*time.Second, "HTTP client timeout for Jenkins API requests")
 	targetID := fs.String("target", "", "configured Jenkins target id")
 	query := fs.String("query", "", "job search query")
@@ -231,8 +510,12 @@ func runSearch(args []string) {
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 
-	target, client := mustBuildClient(ctx, *configPathFlag, *timeout, *targetID)
-	jobs, err := client.SearchJobs(ctx, *query, *limit)
+	q, err := search.Parse(*query, "")
+	if err != nil {
+		fatalf("search: %v", err)
+	}
+	target, client := mustBuildClient(ctx, *configPathFlag, *profileFlag, *timeout, *targetID)
+	jobs, _, err := search.Jobs(ctx, client, nil, q, *limit)
 	if err != nil {
 		fatalf("search error: %v", err)
 	}
@@ -255,6 +538,7 @@ func runSearch(args []string) {
 func runList(args []string) {
 	fs := flag.NewFlagSet("list", flag.ExitOnError)
 	configPathFlag := fs.String("config", "", "absolute path to jenkins config file")
+	profileFlag := fs.String("profile", "", "config profile name (default: $JENKINS_TUI_PROFILE)")
 	timeout := fs.Duration("timeout", 60*time.Second, "HTTP client timeout for Jenkins API requests")
 	targetID := fs.String("target", "", "configured Jenkins target id")
 	containerURL := fs.String("url", "", "folder or Jenkins root URL to list (default: target host root)")
@@ -269,7 +553,7 @@ func runList(args []string) {
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 
-	target, client := mustBuildClient(ctx, *configPathFlag, *timeout, *targetID)
+	target, client := mustBuildClient(ctx, *configPathFlag, *profileFlag, *timeout, *targetID)
 	resolvedContainerURL := strings.TrimSpace(*containerURL)
 	if resolvedContainerURL == "" {
 		resolvedContainerURL = target.Host
@@ -295,8 +579,13 @@ func runList(args []string) {
 }
 
 func runParams(args []string) {
+	if len(args) > 0 && args[0] == "show" {
+		runParamsShow(args[1:])
+		return
+	}
 	fs := flag.NewFlagSet("params", flag.ExitOnError)
 	configPathFlag := fs.String("config", "", "absolute path to jenkins config file")
+	profileFlag := fs.String("profile", "", "config profile name (default: $JENKINS_TUI_PROFILE)")
 	timeout := fs.Duration("timeout", 60*time.Second, "HTTP client timeout for Jenkins API requests")
 	targetID := fs.String("target", "", "configured Jenkins target id")
 	jobURL := fs.String("job", "", "full Jenkins job URL")
@@ -313,7 +602,7 @@ func runParams(args []string) {
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 
-	target, client := mustBuildClient(ctx, *configPathFlag, *timeout, *targetID)
+	target, client := mustBuildClient(ctx, *configPathFlag, *profileFlag, *timeout, *targetID)
 	params, err := client.GetJobParams(ctx, *jobURL)
 	if err != nil {
 		fatalf("params error: %v", err)
@@ -333,6 +622,910 @@ func runParams(args []string) {
 	}
 }
 
+func runBoard(args []string) {
+	fs := flag.NewFlagSet("board", flag.ExitOnError)
+	configPathFlag := fs.String("config", "", "absolute path to jenkins config file")
+	profileFlag := fs.String("profile", "", "config profile name (default: $JENKINS_TUI_PROFILE)")
+	timeout := fs.Duration("timeout", 60*time.Second, "HTTP client timeout for Jenkins API requests")
+	targetID := fs.String("target", "", "configured Jenkins target id")
+	serverID := fs.String("server", "", "alias for --target")
+	jobsFlag := fs.String("jobs", "", "comma-separated job full names, URLs, or aliases (default: the target's watched jobs)")
+	grid := fs.Bool("grid", false, "radiator layout: one colored block per job, sized to fill the terminal")
+	interval := fs.Duration("interval", 30*time.Second, "refresh interval")
+	once := fs.Bool("once", false, "print the board once and exit")
+	plain := fs.Bool("plain", false, "no colors and ASCII glyphs")
+	accessible := fs.Bool("accessible", false, "states spelled out as words; frames are appended instead of redrawn in place")
+	fs.Parse(args)
+	ui.Configure(*plain, *accessible)
+
+	if strings.TrimSpace(*targetID) == "" {
+		*targetID = *serverID
+	}
+	if strings.TrimSpace(*targetID) == "" {
+		fatalf("board: --target is required")
+	}
+	if *interval < time.Second {
+		*interval = time.Second
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	target, client := mustBuildClient(ctx, *configPathFlag, *profileFlag, *timeout, *targetID)
+	jobs := board.ParseJobs(*jobsFlag)
+	if len(jobs) == 0 {
+		jobs = slices.Clone(target.Watches)
+	}
+	if len(jobs) == 0 {
+		fatalf("board: --jobs is required when %s has no watched jobs", target.ID)
+	}
+	for i, job := range jobs {
+		jobs[i] = target.ResolveAlias(job)
+	}
+	title := "Jenkins board: " + target.Name
+	// Accessible mode appends each frame, so a screen reader reads new
+	// frames instead of losing its place on every redraw.
+	interactive := !*once && !ui.Accessible && term.IsTerminal(os.Stdout.Fd())
+	if interactive {
+		// Alternate screen with a hidden cursor; restored on exit.
+		fmt.Print("\x1b[?1049h\x1b[?25l")
+		defer fmt.Print("\x1b[?25h\x1b[?1049l")
+	}
+	for {
+		rows := board.Fetch(ctx, client, jobs, 4)
+		if ctx.Err() != nil {
+			return
+		}
+		width, height := 100, 30
+		if w, h, err := term.GetSize(os.Stdout.Fd()); err == nil && w > 0 {
+			width, height = w, h
+		}
+		frame := board.Render(title, rows, time.Now(), width)
+		if *grid {
+			frame = board.RenderGrid(title, rows, time.Now(), width, height)
+		}
+		if !interactive {
+			fmt.Println(frame)
+			if *once {
+				return
+			}
+			fmt.Println()
+		} else {
+			fmt.Print("\x1b[H\x1b[2J" + frame)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(*interval):
+		}
+	}
+}
+
+func startupLink(cfg models.Config, server, job string, params triggerParams) (models.StartupLink, error) {
+	server = strings.TrimSpace(server)
+	if server == "" {
+		if len(cfg.Jenkins) != 1 {
+			return models.StartupLink{}, fmt.Errorf("-job needs -server when more than one target is configured")
+		}
+		server = cfg.Jenkins[0].ID
+	}
+	if _, err := findTarget(cfg, server); err != nil {
+		return models.StartupLink{}, err
+	}
+	if len(params) > 0 && strings.TrimSpace(job) == "" {
+		return models.StartupLink{}, fmt.Errorf("-params needs -job")
+	}
+	paramMap, err := parseParams(params)
+	if err != nil {
+		return models.StartupLink{}, err
+	}
+	return models.StartupLink{Server: server, Job: strings.TrimSpace(job), Params: paramMap}, nil
+}
+
+func runJobs(args []string) {
+	if len(args) > 0 && args[0] == "repo" {
+		runJobsRepo(args[1:])
+		return
+	}
+	if len(args) == 0 || args[0] != "list" {
+		fatalf("usage: jenkins-tui jobs list --server <id> [--folder path] [--recursive] [--query text] [--json]\n       jenkins-tui jobs repo --server <id> [--remote url] [--dir path] [--json]")
+	}
+	fs := flag.NewFlagSet("jobs list", flag.ExitOnError)
+	configPathFlag := fs.String("config", "", "absolute path to jenkins config file")
+	profileFlag := fs.String("profile", "", "config profile name (default: $JENKINS_TUI_PROFILE)")
+	cacheDirFlag := fs.String("cache-dir", "", "absolute path for jobs cache")
+	timeout := fs.Duration("timeout", 60*time.Second, "HTTP client timeout for Jenkins API requests")
+	targetID := fs.String("target", "", "configured Jenkins target id")
+	serverID := fs.String("server", "", "alias for --target")
+	folder := fs.String("folder", "", "folder full name to list, e.g. infra/tools (default: root)")
+	recursive := fs.Bool("recursive", false, "list the whole tree below --folder")
+	query := fs.String("query", "", "search jobs by name instead of listing a folder")
+	limit := fs.Int("limit", 50, "maximum number of search results")
+	refreshCache := fs.Bool("refresh", false, "ignore cached folder listings")
+	jsonOut := fs.Bool("json", true, "print JSON output")
+	fs.Parse(args[1:])
+
+	if strings.TrimSpace(*targetID) == "" {
+		*targetID = *serverID
+	}
+	if strings.TrimSpace(*targetID) == "" {
+		fatalf("jobs list: --server is required")
+	}
+	profile, err := config.ResolveProfile(*profileFlag)
+	if err != nil {
+		fatalf("config error: %v", err)
+	}
+	cacheDir, err := config.ResolveCacheDir(*cacheDirFlag, profile)
+	if err != nil {
+		fatalf("config error: %v", err)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	target, client := mustBuildClient(ctx, *configPathFlag, *profileFlag, *timeout, *targetID)
+	prefix := strings.Trim(strings.TrimSpace(*folder), "/")
+	result := jobsResult{Target: target.ID, Folder: prefix, Query: strings.TrimSpace(*query)}
+	if result.Query != "" {
+		result.Jobs, result.Source, err = searchJobsCached(ctx, client, cacheDir, result.Query, *limit)
+	} else {
+		result.Jobs, result.Source, err = listJobsCached(ctx, client, cacheDir, prefix, *recursive, *refreshCache)
+	}
+	if err != nil {
+		fatalf("jobs list error: %v", err)
+	}
+	if *jsonOut {
+		printJSON(result)
+		return
+	}
+	for _, job := range result.Jobs {
+		fmt.Printf("%s\t%s\t%s\n", job.Kind, job.FullName, job.URL)
+	}
+}
+
+// runJobsRepo finds the multibranch projects building a git repository,
+// given as --remote or read from the origin remote of the checkout at --dir.
+func runJobsRepo(args []string) {
+	fs := flag.NewFlagSet("jobs repo", flag.ExitOnError)
+	configPathFlag := fs.String("config", "", "absolute path to jenkins config file")
+	profileFlag := fs.String("profile", "", "config profile name (default: $JENKINS_TUI_PROFILE)")
+	cacheDirFlag := fs.String("cache-dir", "", "absolute path for jobs cache")
+	timeout := fs.Duration("timeout", 60*time.Second, "HTTP client timeout for Jenkins API requests")
+	serverID := fs.String("server", "", "configured Jenkins target id")
+	remote := fs.String("remote", "", "git remote URL, e.g. git@github.com:org/app.git (default: origin of the checkout at --dir)")
+	dir := fs.String("dir", ".", "git checkout to read the origin remote from")
+	jsonOut := fs.Bool("json", true, "print JSON output")
+	fs.Parse(args)
+
+	if strings.TrimSpace(*serverID) == "" {
+		fatalf("jobs repo: --server is required")
+	}
+	var repo gitrepo.Repo
+	if strings.TrimSpace(*remote) != "" {
+		var ok bool
+		if repo, ok = gitrepo.Parse(*remote); !ok {
+			fatalf("jobs repo: %s is not a hosted repository URL", *remote)
+		}
+	} else {
+		var err error
+		if repo, err = gitrepo.Detect(*dir); err != nil {
+			fatalf("jobs repo: %v (pass --remote)", err)
+		}
+	}
+	profile, err := config.ResolveProfile(*profileFlag)
+	if err != nil {
+		fatalf("config error: %v", err)
+	}
+	cacheDir, err := config.ResolveCacheDir(*cacheDirFlag, profile)
+	if err != nil {
+		fatalf("config error: %v", err)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	target, client := mustBuildClient(ctx, *configPathFlag, *profileFlag, *timeout, *serverID)
+	jobs, err := search.RepoJobs(ctx, client, cacheDir, repo)
+	if err != nil {
+		fatalf("jobs repo error: %v", err)
+	}
+	if *jsonOut {
+		printJSON(repoJobsResult{Target: target.ID, Repo: repo.String(), Jobs: jobs})
+		return
+	}
+	if len(jobs) == 0 {
+		fmt.Fprintf(stderr, "no multibranch project on %s builds %s\n", target.ID, repo)
+		os.Exit(1)
+	}
+	for _, job := range jobs {
+		fmt.Printf("%s\t%s\n", job.FullName, job.URL)
+	}
+}
+
+// runSync crawls one server's whole job tree into the folder cache and the
+// job index, like -daemon does for every server, printing progress as it goes.
+func runSync(args []string) {
+	fs := flag.NewFlagSet("sync", flag.ExitOnError)
+	configPathFlag := fs.String("config", "", "absolute path to jenkins config file")
+	profileFlag := fs.String("profile", "", "config profile name (default: $JENKINS_TUI_PROFILE)")
+	cacheDirFlag := fs.String("cache-dir", "", "absolute path for jobs cache")
+	timeout := fs.Duration("timeout", 60*time.Second, "HTTP client timeout for Jenkins API requests")
+	targetID := fs.String("target", "", "configured Jenkins target id")
+	serverID := fs.String("server", "", "alias for --target")
+	concurrency := fs.Int("concurrency", 4, "folders listed at once")
+	quiet := fs.Bool("quiet", false, "print only the summary")
+	fs.Parse(args)
+
+	if strings.TrimSpace(*targetID) == "" {
+		*targetID = *serverID
+	}
+	if strings.TrimSpace(*targetID) == "" {
+		fatalf("sync: --server is required")
+	}
+	profile, err := config.ResolveProfile(*profileFlag)
+	if err != nil {
+		fatalf("config error: %v", err)
+	}
+	cacheDir, err := config.ResolveCacheDir(*cacheDirFlag, profile)
+	if err != nil {
+		fatalf("config error: %v", err)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	target, client := mustBuildClient(ctx, *configPathFlag, *profileFlag, *timeout, *targetID)
+	result, err := refresh.TreeWithProgress(ctx, client, cacheDir, *concurrency, syncProgress(*quiet))
+	if !*quiet && term.IsTerminal(os.Stderr.Fd()) {
+		fmt.Fprint(stderr, "\r\x1b[K")
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: synced %d folders, %d jobs with %d error(s): %v\n", target.ID, result.Folders, result.Jobs, result.Errors, err)
+		os.Exit(1)
+	}
+	fmt.Printf("%s: synced %d folders, %d jobs in %s\n", target.ID, result.Folders, result.Jobs, result.Duration.Round(time.Millisecond))
+}
+
+func runSchedule(args []string) {
+	fs := flag.NewFlagSet("schedule", flag.ExitOnError)
+	configPathFlag := fs.String("config", "", "absolute path to jenkins config file")
+	profileFlag := fs.String("profile", "", "config profile name (default: $JENKINS_TUI_PROFILE)")
+	timeout := fs.Duration("timeout", 60*time.Second, "HTTP client timeout for Jenkins API requests")
+	list := fs.Bool("list", false, "print each schedule and when it next runs, then exit")
+	runNow := fs.String("run", "", "run the schedule of this name once now, then exit")
+	fs.Parse(args)
+
+	profile, err := config.ResolveProfile(*profileFlag)
+	if err != nil {
+		fatalf("config error: %v", err)
+	}
+	configPath, err := config.ResolvePath(*configPathFlag, profile)
+	if err != nil {
+		fatalf("config error: %v", err)
+	}
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		fatalf("config error: %v", err)
+	}
+	if len(cfg.Schedules) == 0 {
+		fatalf("schedule: no schedules in %s", configPath)
+	}
+	if err := audit.Setup(cfg.AuditLog); err != nil {
+		fatalf("config error: %v", err)
+	}
+	jenkins.SetMaxResponseSize(cfg.MaxResponseMB)
+	cfg.Timeout = *timeout
+	crons := make(map[string]cron.Cron, len(cfg.Schedules))
+	byName := make(map[string]models.Schedule, len(cfg.Schedules))
+	for _, sc := range cfg.Schedules {
+		// Load has already checked every expression.
+		crons[sc.Name], _ = cron.Parse(sc.Cron)
+		byName[sc.Name] = sc
+	}
+
+	if *list {
+		now := time.Now()
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tCRON\tTARGET\tJOB\tNEXT")
+		for _, sc := range cfg.Schedules {
+			next := "never"
+			if t := crons[sc.Name].Next(now); !t.IsZero() {
+				next = t.Format("2006-01-02 15:04")
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", sc.Name, sc.Cron, sc.Target, sc.Job, next)
+		}
+		w.Flush()
+		return
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	// One manager for every firing, so tokens are read (and auth_command
+	// run) once per credential_cache_ttl rather than on every firing.
+	creds := credentials.NewManager()
+	creds.SetCacheTTL(cfg.CredentialCacheTTL)
+
+	if name := strings.TrimSpace(*runNow); name != "" {
+		sc, ok := byName[name]
+		if !ok {
+			fatalf("schedule: no schedule named %q", name)
+		}
+		if !fireSchedule(ctx, cfg, creds, sc) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	err = schedule.Loop(ctx, crons, func(ctx context.Context, name string) {
+		fireSchedule(ctx, cfg, creds, byName[name])
+	}, func(name string) {
+		fmt.Fprintf(stderr, "%s: skipped, the previous run is still going\n", name)
+	})
+	if err != nil {
+		fatalf("schedule: %v", err)
+	}
+}
+
+// fireSchedule runs one schedule and prints what it did. It reports whether
+// every run succeeded.
+func fireSchedule(ctx context.Context, cfg models.Config, creds *credentials.Manager, sc models.Schedule) bool {
+	target, err := findTarget(cfg, sc.Target)
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %v\n", sc.Name, err)
+		return false
+	}
+	token, err := creds.Resolve(target)
+	if errors.Is(err, credentials.ErrAuthRequired) {
+		token, err = creds.RunAuthCommand(target)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: credential error: %v\n", sc.Name, err)
+		return false
+	}
+	client := newClient(creds, target, token, cfg.Timeout)
+	jobURL := resolveJobURL(client.Host(), target.ResolveAlias(sc.Job))
+	dir := sc.ReportDir
+	if strings.TrimSpace(dir) == "" {
+		dir = cfg.LogDir
+	}
+	out, err := schedule.Run(ctx, client, sc, jobURL, dir)
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %v\n", sc.Name, err)
+		return false
+	}
+	fmt.Printf("%s: %d run(s) of %s, %d failed, report %s\n", sc.Name, len(out.Runs), sc.Job, out.Failed(), out.Report)
+	for _, path := range out.Logs {
+		fmt.Printf("%s: saved log %s\n", sc.Name, path)
+	}
+	return out.Failed() == 0
+}
+
+// syncProgress reports crawl progress on stderr: one line redrawn in place
+// on a terminal, otherwise a line every few seconds for logs.
+func syncProgress(quiet bool) func(refresh.Progress) {
+	if quiet {
+		return nil
+	}
+	tty := term.IsTerminal(os.Stderr.Fd()) && !ui.Accessible
+	every := 5 * time.Second
+	if tty {
+		every = 100 * time.Millisecond
+	}
+	var last time.Time
+	return func(p refresh.Progress) {
+		if time.Since(last) < every && p.Queued > 0 {
+			return
+		}
+		last = time.Now()
+		line := fmt.Sprintf("%d folders, %d jobs, %d queued", p.Folders, p.Jobs, p.Queued)
+		if tty {
+			// Keep the line short enough not to wrap, or \r cannot redraw it.
+			folder := "/" + p.Folder
+			if len(folder) > 50 {
+				folder = "..." + folder[len(folder)-47:]
+			}
+			fmt.Fprintf(stderr, "\r\x1b[K%s  %s", line, folder)
+			return
+		}
+		fmt.Fprintln(stderr, line)
+	}
+}
+
+// searchJobsCached matches against the job index written by -daemon when it
+// exists and falls back to the Jenkins search endpoints otherwise.
+func searchJobsCached(ctx context.Context, client *jenkins.Client, cacheDir, query string, limit int) ([]models.JobNode, string, error) {
+	q, err := search.Parse(query, "")
+	if err != nil {
+		return nil, "", err
+	}
+	index, _, ok, err := cache.JobIndexInDir(cacheDir, client.CacheKey())
+	if err == nil && ok {
+		if index == nil {
+			index = []models.JobNode{}
+		}
+		jobs, _, err := search.Jobs(ctx, client, index, q, limit)
+		return jobs, "index", err
+	}
+	jobs, _, err := search.Jobs(ctx, client, nil, q, limit)
+	return jobs, "live", err
+}
+
+// listJobsCached lists a folder (or its whole subtree) through the same
+// 24h folder cache the TUI uses, reporting "cache" only if nothing was
+// fetched live.
+func listJobsCached(ctx context.Context, client *jenkins.Client, cacheDir, prefix string, recursive, refreshCache bool) ([]models.JobNode, string, error) {
+	source := "cache"
+	list := func(containerURL, prefix string) ([]models.JobNode, error) {
+		if !refreshCache {
+			if nodes, ok, err := cache.JobNodesInDir(cacheDir, client.CacheKey(), containerURL); err == nil && ok {
+				return nodes, nil
+			}
+		}
+		source = "live"
+		nodes, err := client.ListJobNodes(ctx, containerURL, prefix)
+		if err != nil {
+			return nil, err
+		}
+		_ = cache.SaveJobNodesInDir(cacheDir, client.CacheKey(), containerURL, nodes)
+		return nodes, nil
+	}
+
+	containerURL := client.Host()
+	if prefix != "" {
+		containerURL = jenkins.JobURL(client.Host(), prefix)
+	}
+	out := []models.JobNode{}
+	var walk func(containerURL, prefix string) error
+	walk = func(containerURL, prefix string) error {
+		nodes, err := list(containerURL, prefix)
+		if err != nil {
+			return fmt.Errorf("list %s: %w", "/"+prefix, err)
+		}
+		for _, n := range nodes {
+			out = append(out, n)
+			if recursive && n.Kind == models.JobNodeFolder {
+				if err := walk(n.URL, n.FullName); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := walk(containerURL, prefix); err != nil {
+		return nil, "", err
+	}
+	return out, source, nil
+}
+
+func runParamsShow(args []string) {
+	fs := flag.NewFlagSet("params show", flag.ExitOnError)
+	configPathFlag := fs.String("config", "", "absolute path to jenkins config file")
+	profileFlag := fs.String("profile", "", "config profile name (default: $JENKINS_TUI_PROFILE)")
+	timeout := fs.Duration("timeout", 60*time.Second, "HTTP client timeout for Jenkins API requests")
+	targetID := fs.String("target", "", "configured Jenkins target id")
+	serverID := fs.String("server", "", "alias for --target")
+	var jobs jobNames
+	fs.Var(&jobs, "job", "job full name (infra/deploy), job URL, or alias from the config (repeatable)")
+	jsonOut := fs.Bool("json", false, "print JSON instead of a table")
+	fs.Parse(args)
+
+	if strings.TrimSpace(*targetID) == "" {
+		*targetID = *serverID
+	}
+	if strings.TrimSpace(*targetID) == "" {
+		fatalf("params show: --server is required")
+	}
+	if len(jobs) == 0 {
+		fatalf("params show: --job is required")
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	target, client := mustBuildClient(ctx, *configPathFlag, *profileFlag, *timeout, *targetID)
+	urls := make([]string, len(jobs))
+	for i, job := range jobs {
+		urls[i] = resolveJobURL(client.Host(), target.ResolveAlias(job))
+	}
+	results := fetchParams(ctx, client, target.ID, urls, 4)
+	failed := false
+	for _, r := range results {
+		failed = failed || r.Error != ""
+	}
+	if len(results) == 1 && failed {
+		fatalf("params error: %s", results[0].Error)
+	}
+	if *jsonOut {
+		if len(results) == 1 {
+			printJSON(results[0])
+		} else {
+			printJSON(results)
+		}
+	} else {
+		for i, r := range results {
+			if len(results) > 1 {
+				if i > 0 {
+					fmt.Println()
+				}
+				fmt.Println("== " + r.Job)
+			}
+			printParamsTable(r)
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// fetchParams loads the parameter definitions of several jobs, at most
+// concurrency at a time, in the order given. A failing job is reported on
+// its result instead of aborting the others.
+func fetchParams(ctx context.Context, client *jenkins.Client, targetID string, urls []string, concurrency int) []paramsResult {
+	defs, errs := client.GetJobParamsAll(ctx, urls, concurrency)
+	results := make([]paramsResult, len(urls))
+	for i, url := range urls {
+		results[i] = paramsResult{Target: targetID, Job: url, Params: defs[i]}
+		if errs[i] != nil {
+			results[i].Error = errs[i].Error()
+		}
+	}
+	return results
+}
+
+func printParamsTable(r paramsResult) {
+	if r.Error != "" {
+		fmt.Println("error: " + redact.String(r.Error))
+		return
+	}
+	if len(r.Params) == 0 {
+		fmt.Println("job has no supported parameters")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tTYPE\tDEFAULT\tCHOICES")
+	for _, p := range r.Params {
+		def := p.Default
+		if p.Kind == models.ParamPassword {
+			def = "<hidden>"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", p.Name, p.Kind, def, strings.Join(p.Choices, ","))
+	}
+	_ = w.Flush()
+}
+
+// resolveJobURL accepts either a job URL or a slash-separated full name.
+func resolveJobURL(host, job string) string {
+	job = strings.TrimSpace(job)
+	if strings.HasPrefix(job, "http://") || strings.HasPrefix(job, "https://") {
+		return job
+	}
+	return jenkins.JobURL(host, job)
+}
+
+func runDaemon(ctx context.Context, cfg models.Config, interval time.Duration) int {
+	for {
+		failed := refreshAllTargets(ctx, cfg)
+		if interval <= 0 {
+			if failed > 0 {
+				return 1
+			}
+			return 0
+		}
+		select {
+		case <-ctx.Done():
+			return 0
+		case <-time.After(interval):
+		}
+	}
+}
+
+func refreshAllTargets(ctx context.Context, cfg models.Config) int {
+	creds := credentials.NewManager()
+	failed := 0
+	for _, target := range cfg.Jenkins {
+		if ctx.Err() != nil {
+			return failed
+		}
+		token, err := creds.Resolve(target)
+		if err != nil {
+			fmt.Fprintf(stderr, "%s: credential error: %v\n", target.ID, err)
+			failed++
+			continue
+		}
+		client := jenkins.NewClient(target, token, cfg.Timeout)
+		result, err := refresh.Tree(ctx, client, cfg.CacheDir, 4)
+		if err != nil {
+			fmt.Fprintf(stderr, "%s: refreshed %d folders, %d jobs with %d error(s): %v\n", target.ID, result.Folders, result.Jobs, result.Errors, err)
+			failed++
+			continue
+		}
+		fmt.Printf("%s: refreshed %d folders, %d jobs in %s\n", target.ID, result.Folders, result.Jobs, result.Duration.Round(time.Millisecond))
+	}
+	return failed
+}
+
+func runProfiles() {
+	names, err := config.ListProfiles()
+	if err != nil {
+		fatalf("profiles: %v", err)
+	}
+	active, _ := config.ResolveProfile("")
+	for _, name := range names {
+		marker := " "
+		if name == active {
+			marker = "*"
+		}
+		path, _ := config.ResolvePath("", name)
+		fmt.Printf("%s %s\t%s\n", marker, name, path)
+	}
+	if len(names) == 0 {
+		path, _ := config.ResolvePath("", "example")
+		fmt.Printf("no profiles yet; start one with jenkins-tui --profile <name> (stored as %s)\n", path)
+	}
+}
+
+func runImport(args []string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	configPathFlag := fs.String("config", "", "absolute path to jenkins config file")
+	profileFlag := fs.String("profile", "", "config profile name (default: $JENKINS_TUI_PROFILE)")
+	from := fs.String("from", config.ImportJenkinsCLI, "where to import from: netrc or jenkins-cli")
+	file := fs.String("file", "", "file to read (default: $NETRC or ~/.netrc, or ~/.jenkins-cli.yaml)")
+	only := fs.String("only", "", "comma-separated hosts or names to import (netrc needs this or --all)")
+	all := fs.Bool("all", false, "import every entry, even from netrc")
+	dryRun := fs.Bool("dry-run", false, "list what would be imported without changing anything")
+	allowHTTP := fs.Bool("allow-insecure-http", false, "also import plain http:// servers, whose tokens are sent unencrypted")
+	fs.Parse(args)
+
+	profile, err := config.ResolveProfile(*profileFlag)
+	if err != nil {
+		fatalf("import: %v", err)
+	}
+	configPath, err := config.ResolvePath(*configPathFlag, profile)
+	if err != nil {
+		fatalf("import: %v", err)
+	}
+	fingerprint, err := config.Fingerprint(configPath)
+	if err != nil {
+		fatalf("import: %v", err)
+	}
+	cfg, err := config.Load(configPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		fatalf("import: %v", err)
+	}
+	candidates, err := config.ReadImport(*from, *file)
+	if err != nil {
+		fatalf("import: %v", err)
+	}
+	planned, plainHTTP := config.PlanImport(cfg, filterImport(candidates, *only), *allowHTTP)
+	for _, c := range plainHTTP {
+		fmt.Fprintf(stderr, "skipped %s: plain http sends its token unencrypted; pass --allow-insecure-http to import it anyway\n", c.Target.Host)
+	}
+	if len(planned) == 0 {
+		if len(plainHTTP) == 0 {
+			fmt.Println("nothing to import; every server found is already configured")
+		}
+		return
+	}
+	if *from == config.ImportNetrc && strings.TrimSpace(*only) == "" && !*all {
+		*dryRun = true
+		defer fmt.Println("netrc usually holds more than Jenkins servers; pick them with --only host1,host2 or pass --all")
+	}
+	for _, c := range planned {
+		fmt.Printf("%s\t%s\t%s\n", c.Target.ID, c.Target.Host, c.Target.Username)
+		if c.Target.AllowInsecureHTTP {
+			fmt.Fprintf(stderr, "warning: %s uses plain http, so its token is sent unencrypted; it is imported with allow_insecure_http: true as --allow-insecure-http asked\n", c.Target.ID)
+		}
+	}
+	if *dryRun {
+		return
+	}
+
+	creds := credentials.NewManager()
+	var stored []string
+	// forget deletes the tokens stored so far, so a failed import leaves no
+	// keyring entry that no config references.
+	forget := func() {
+		for _, ref := range stored {
+			_ = creds.DeleteKeyring(ref)
+		}
+	}
+	for _, c := range planned {
+		if err := creds.SetKeyring(c.Target.Credential.Ref, c.Token); err != nil {
+			forget()
+			fatalf("import: store token for %s in the system password manager: %v", c.Target.ID, err)
+		}
+		stored = append(stored, c.Target.Credential.Ref)
+		cfg.Jenkins = append(cfg.Jenkins, c.Target)
+	}
+	if _, err := config.SaveIfUnchanged(configPath, cfg, fingerprint); err != nil {
+		forget()
+		if errors.Is(err, config.ErrChanged) {
+			fatalf("import: %s changed while importing; run the import again", configPath)
+		}
+		fatalf("import: %v", err)
+	}
+	fmt.Printf("imported %d server(s) into %s\n", len(planned), configPath)
+}
+
+func runConfig(args []string) {
+	if len(args) == 0 || (args[0] != "export" && args[0] != "import" && args[0] != "validate") {
+		fatalf("usage: jenkins-tui config export [--out file] | config import [--dry-run] <file> | config validate [--ping] [--json]")
+	}
+	fs := flag.NewFlagSet("config "+args[0], flag.ExitOnError)
+	configPathFlag := fs.String("config", "", "absolute path to jenkins config file")
+	profileFlag := fs.String("profile", "", "config profile name (default: $JENKINS_TUI_PROFILE)")
+	out := fs.String("out", "", "file to write the export to (default: stdout)")
+	dryRun := fs.Bool("dry-run", false, "list what would change without changing anything")
+	ping := fs.Bool("ping", false, "with validate, also make an authenticated request to every server")
+	timeout := fs.Duration("timeout", 15*time.Second, "with validate --ping, HTTP timeout per server")
+	jsonOut := fs.Bool("json", false, "with validate, print the report as JSON")
+	fs.Parse(args[1:])
+
+	profile, err := config.ResolveProfile(*profileFlag)
+	if err != nil {
+		fatalf("config %s: %v", args[0], err)
+	}
+	configPath, err := config.ResolvePath(*configPathFlag, profile)
+	if err != nil {
+		fatalf("config %s: %v", args[0], err)
+	}
+	if args[0] == "validate" {
+		os.Exit(runConfigValidate(configPath, *ping, *timeout, *jsonOut))
+	}
+	fingerprint, err := config.Fingerprint(configPath)
+	if err != nil {
+		fatalf("config %s: %v", args[0], err)
+	}
+	cfg, err := config.Load(configPath)
+	if err != nil && (args[0] == "export" || !errors.Is(err, os.ErrNotExist)) {
+		fatalf("config %s: %v", args[0], err)
+	}
+
+	if args[0] == "export" {
+		payload, err := config.Export(cfg)
+		if err != nil {
+			fatalf("config export: %v", err)
+		}
+		if strings.TrimSpace(*out) == "" {
+			os.Stdout.Write(payload)
+			return
+		}
+		if err := os.WriteFile(*out, payload, 0o644); err != nil {
+			fatalf("config export: %v", err)
+		}
+		fmt.Printf("exported %d server(s) to %s\n", len(cfg.Jenkins), *out)
+		return
+	}
+
+	if fs.NArg() != 1 {
+		fatalf("usage: jenkins-tui config import [--dry-run] <file>")
+	}
+	shared, err := config.Load(fs.Arg(0))
+	if err != nil {
+		fatalf("config import: %v", err)
+	}
+	merged, res := config.MergeShared(cfg, shared.Jenkins)
+	for _, t := range res.Added {
+		fmt.Printf("add\t%s\t%s\t%s\n", t.ID, t.Host, t.Username)
+	}
+	for _, id := range res.Updated {
+		fmt.Printf("update\t%s\n", id)
+	}
+	for _, id := range res.Skipped {
+		fmt.Printf("skip\t%s\t(host and username already configured)\n", id)
+	}
+	if len(res.Added) == 0 && len(res.Updated) == 0 {
+		fmt.Println("nothing to import; the config already matches")
+		return
+	}
+	if *dryRun {
+		return
+	}
+
+	creds := credentials.NewManager()
+	for _, t := range res.Added {
+		switch {
+		case t.Credential.Type == models.CredentialTypeEnv:
+			fmt.Printf("%s reads its token from $%s\n", t.ID, t.Credential.Ref)
+		case t.Credential.Type == models.CredentialTypeKeyring:
+			token, err := promptToken(fmt.Sprintf("API token for %s (%s), empty to skip: ", t.ID, t.Host))
+			if err != nil {
+				fatalf("config import: %v", err)
+			}
+			if token == "" {
+				fmt.Printf("no token stored for %s; add one later with t on the manage screen\n", t.ID)
+				continue
+			}
+			if err := creds.SetKeyring(t.Credential.Ref, token); err != nil {
+				fatalf("config import: store token for %s in the system password manager: %v", t.ID, err)
+			}
+		}
+	}
+	if _, err := config.SaveIfUnchanged(configPath, merged, fingerprint); err != nil {
+		if errors.Is(err, config.ErrChanged) {
+			fatalf("config import: %s changed while importing; run the import again", configPath)
+		}
+		fatalf("config import: %v", err)
+	}
+	fmt.Printf("added %d and updated %d server(s) in %s\n", len(res.Added), len(res.Updated), configPath)
+}
+
+// runConfigValidate prints a pass/fail line per check and returns the exit
+// code: 0 when nothing failed.
+func runConfigValidate(configPath string, ping bool, timeout time.Duration, jsonOut bool) int {
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	report := validate.Report{Config: configPath}
+	cfg, err := config.Load(configPath)
+	report.Add("config", err, fmt.Sprintf("%d target(s)", len(cfg.Jenkins)))
+	if err == nil {
+		report.Add("keybindings", tui.ValidateKeybindings(cfg.Keybindings), strings.Join(tui.KeybindingNotes(cfg.Keybindings), "; "))
+		report.Targets = validate.Targets(ctx, cfg, credentials.NewManager(), validate.Options{Ping: ping, Timeout: timeout})
+	}
+	report.Finish()
+	if jsonOut {
+		printJSON(report)
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "%s\n", configPath)
+		for _, c := range report.Checks {
+			fmt.Fprintf(w, "  %s\t%s\t%s\n", c.Name, c.Status, c.Detail)
+		}
+		for _, t := range report.Targets {
+			for _, c := range t.Checks {
+				fmt.Fprintf(w, "  %s %s\t%s\t%s\n", t.ID, c.Name, c.Status, c.Detail)
+			}
+		}
+		_ = w.Flush()
+		if report.Passed {
+			fmt.Println("PASS")
+		} else {
+			fmt.Println("FAIL")
+		}
+	}
+	if !report.Passed {
+		return 1
+	}
+	return 0
+}
+
+// promptToken reads a token from stdin, without echo on a terminal.
+func promptToken(prompt string) (string, error) {
+	fmt.Fprint(stderr, prompt)
+	if term.IsTerminal(os.Stdin.Fd()) {
+		b, err := term.ReadPassword(os.Stdin.Fd())
+		fmt.Fprintln(stderr)
+		return strings.TrimSpace(string(b)), err
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// filterImport keeps the candidates whose host or name matches one of the
+// comma-separated entries in only; an empty only keeps everything.
+func filterImport(candidates []config.ImportCandidate, only string) []config.ImportCandidate {
+	if strings.TrimSpace(only) == "" {
+		return candidates
+	}
+	var out []config.ImportCandidate
+	for _, c := range candidates {
+		for _, want := range strings.Split(only, ",") {
+			want = strings.TrimSpace(want)
+			host := strings.TrimPrefix(strings.TrimPrefix(c.Target.Host, "https://"), "http://")
+			if want != "" && (want == c.Target.Host || want == host || want == c.Target.Name) {
+				out = append(out, c)
+				break
+			}
+		}
+	}
+	return out
+}
+
 func findTarget(cfg models.Config, id string) (models.JenkinsTarget, error) {
 	for _, target := range cfg.Jenkins {
 		if target.ID == strings.TrimSpace(id) {
@@ -342,8 +1535,12 @@ func findTarget(cfg models.Config, id string) (models.JenkinsTarget, error) {
 	return models.JenkinsTarget{}, fmt.Errorf("target %q not found in config", id)
 }
 
-func mustBuildClient(ctx context.Context, configPathFlag string, timeout time.Duration, targetID string) (models.JenkinsTarget, *jenkins.Client) {
-	configPath, err := config.ResolvePath(configPathFlag)
+func mustBuildClient(ctx context.Context, configPathFlag, profileFlag string, timeout time.Duration, targetID string) (models.JenkinsTarget, *jenkins.Client) {
+	profile, err := config.ResolveProfile(profileFlag)
+	if err != nil {
+		fatalf("config error: %v", err)
+	}
+	configPath, err := config.ResolvePath(configPathFlag, profile)
 	if err != nil {
 		fatalf("config error: %v", err)
 	}
@@ -357,19 +1554,40 @@ func mustBuildClient(ctx context.Context, configPathFlag string, timeout time.Du
 	if err != nil {
 		fatalf("%v", err)
 	}
+	if err := audit.Setup(cfg.AuditLog); err != nil {
+		fatalf("config error: %v", err)
+	}
+	jenkins.SetMaxResponseSize(cfg.MaxResponseMB)
 
-	token, err := credentials.NewManager().Resolve(target)
+	creds := credentials.NewManager()
+	token, err := creds.Resolve(target)
+	if errors.Is(err, credentials.ErrAuthRequired) {
+		token, err = creds.RunAuthCommand(target)
+	}
 	if err != nil {
 		fatalf("credential error: %v", err)
 	}
 
-	client := jenkins.NewClient(target, token, timeout)
+	client := newClient(creds, target, token, timeout)
 	if err := client.ValidateConnection(ctx); err != nil {
 		fatalf("connection error: %v", err)
 	}
 	return target, client
 }
 
+// newClient builds the client for target. With an auth_command, a rejected
+// token runs it again and the request is retried with the new token.
+func newClient(creds *credentials.Manager, target models.JenkinsTarget, token string, timeout time.Duration) *jenkins.Client {
+	client := jenkins.NewClient(target, token, timeout)
+	if strings.TrimSpace(target.AuthCommand) != "" {
+		client.SetReauth(func(context.Context) (string, error) {
+			creds.Forget(target)
+			return creds.RunAuthCommand(target)
+		})
+	}
+	return client
+}
+
 func parseParams(values []string) (map[string]string, error) {
 	params := make(map[string]string, len(values))
 	for _, value := range values {
@@ -387,24 +1605,40 @@ func parseParams(values []string) (map[string]string, error) {
 }
 
 func printJSON(value any) {
-	enc := json.NewEncoder(os.Stdout)
+	enc := json.NewEncoder(redact.Writer(os.Stdout))
 	enc.SetIndent("", "  ")
 	_ = enc.Encode(value)
 }
 
-func fatalf(format string, args ...any) {
-	fmt.Fprintf(os.Stderr, format+"\n", args...)
+// exitHooks run, newest first, before fatalf and fatalJSONOrText exit, so
+// open spans are ended and exported even though deferred calls are skipped.
+var exitHooks []func(err error)
+
+func onFatal(hook func(err error)) {
+	exitHooks = append(exitHooks, hook)
+}
+
+func exit(err error) {
+	for i := len(exitHooks) - 1; i >= 0; i-- {
+		exitHooks[i](err)
+	}
 	os.Exit(1)
 }
 
+func fatalf(format string, args ...any) {
+	err := fmt.Errorf(format, args...)
+	fmt.Fprintln(stderr, err.Error())
+	exit(err)
+}
+
 func fatalJSONOrText(jsonOut bool, partial triggerResult, err error) {
 	if jsonOut {
 		payload := map[string]any{
-			"error": err.Error(),
+			"error":   err.Error(),
 			"partial": partial,
 		}
 		printJSON(payload)
-		os.Exit(1)
+		exit(err)
 	}
 	fatalf("%v", err)
 }
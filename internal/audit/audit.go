@@ -0,0 +1,128 @@
+// Package audit appends a line for every triggered build and destructive
+// action to a local file, so there is a record of who started what from
+// where. It is off until Setup is called with a path.
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entry is one audit line. Parameter values are hashed, not logged, since
+// they can hold secrets.
+type Entry struct {
+	Time        time.Time `json:"time"`
+	User        string    `json:"user"`
+	Machine     string    `json:"machine"`
+	Server      string    `json:"server"`
+	JenkinsUser string    `json:"jenkins_user,omitempty"`
+	Action      string    `json:"action"`
+	Job         string    `json:"job,omitempty"`
+	ParamsHash  string    `json:"params_sha256,omitempty"`
+	Result      string    `json:"result"`
+}
+
+var (
+	mu      sync.Mutex
+	logPath string
+	local   struct{ user, machine string }
+)
+
+// Setup enables the audit log at path; "" disables it. A leading ~/ (or ~\
+// on Windows) is the home directory. The file is created up front so a bad path fails at
+// startup instead of silently dropping records later.
+func Setup(path string) error {
+	path = strings.TrimSpace(path)
+	if strings.HasPrefix(path, "~/") || strings.HasPrefix(path, "~"+string(filepath.Separator)) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("resolve home dir: %w", err)
+		}
+		path = filepath.Join(home, path[2:])
+	}
+	if path != "" {
+		if !filepath.IsAbs(path) {
+			return fmt.Errorf("audit log path must be absolute: %s", path)
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			return fmt.Errorf("create audit log dir: %w", err)
+		}
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			return fmt.Errorf("open audit log: %w", err)
+		}
+		f.Close()
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	logPath = path
+	if u, err := user.Current(); err == nil {
+		local.user = u.Username
+	}
+	local.machine, _ = os.Hostname()
+	return nil
+}
+
+// Record appends e, filling in the time and the local user and machine.
+// It does nothing unless Setup enabled the log; write failures are logged
+// at warn level rather than failing the action that was already taken.
+func Record(e Entry) {
+	mu.Lock()
+	defer mu.Unlock()
+	if logPath == "" {
+		return
+	}
+	e.Time = time.Now().UTC()
+	e.User, e.Machine = local.user, local.machine
+	line, err := json.Marshal(e)
+	if err != nil {
+		slog.Warn("audit record", "err", err)
+		return
+	}
+	// Opening per record keeps appends from several instances whole.
+	f, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		slog.Warn("audit record", "err", err)
+		return
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		slog.Warn("audit record", "err", err)
+	}
+}
+
+// ParamsHash is a stable SHA-256 of the parameters, independent of order,
+// so two runs with the same values can be matched without logging them.
+func ParamsHash(params map[string]string) string {
+	if len(params) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	h := sha256.New()
+	for _, k := range keys {
+		fmt.Fprintf(h, "%s=%s\x00", k, params[k])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Result is "ok" for a nil error and the error text otherwise.
+func Result(err error) string {
+	if err != nil {
+		return "error: " + err.Error()
+	}
+	return "ok"
+}
@@ -0,0 +1,75 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRecordAppendsJSONLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit", "audit.log")
+	if err := Setup(path); err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	defer Setup("")
+
+	Record(Entry{Server: "https://jenkins.example.com", Action: "trigger", Job: "https://jenkins.example.com/job/deploy/", ParamsHash: ParamsHash(map[string]string{"ENV": "prod"}), Result: Result(nil)})
+	Record(Entry{Server: "https://jenkins.example.com", Action: "stop", Result: Result(errors.New("stop failed (403)"))})
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer f.Close()
+	var got []Entry
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var e Entry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			t.Fatalf("line %q: %v", sc.Text(), err)
+		}
+		got = append(got, e)
+	}
+	if len(got) != 2 || got[0].Action != "trigger" || got[0].Time.IsZero() || got[0].ParamsHash == "" {
+		t.Fatalf("unexpected entries %+v", got)
+	}
+	if got[1].Result != "error: stop failed (403)" {
+		t.Fatalf("expected the error as result, got %q", got[1].Result)
+	}
+
+	Setup("")
+	Record(Entry{Action: "trigger"})
+	if b, _ := os.ReadFile(path); len(b) == 0 || countLines(b) != 2 {
+		t.Fatalf("a disabled log should not be written")
+	}
+}
+
+func TestParamsHashIgnoresOrder(t *testing.T) {
+	a := ParamsHash(map[string]string{"A": "1", "B": "2"})
+	b := ParamsHash(map[string]string{"B": "2", "A": "1"})
+	if a != b || a == ParamsHash(map[string]string{"A": "1", "B": "3"}) {
+		t.Fatalf("expected an order-independent, value-sensitive hash")
+	}
+	if ParamsHash(nil) != "" {
+		t.Fatalf("no parameters should hash to empty")
+	}
+}
+
+func TestSetupRejectsRelativePath(t *testing.T) {
+	if err := Setup("audit.log"); err == nil {
+		t.Fatalf("expected a relative path to be rejected")
+	}
+}
+
+func countLines(b []byte) int {
+	n := 0
+	for _, c := range b {
+		if c == '\n' {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,220 @@
+package board
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+
+	"jenkins-tui/internal/jenkins"
+	"jenkins-tui/internal/models"
+	"jenkins-tui/internal/ui"
+)
+
+type Row struct {
+	Job    string
+	URL    string
+	Detail models.JobDetail
+	Err    error
+}
+
+// ParseJobs splits a --jobs value into trimmed, de-duplicated entries. Each
+// entry is either a full job URL or a slash-separated full name.
+func ParseJobs(value string) []string {
+	out := []string{}
+	seen := map[string]bool{}
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" || seen[part] {
+			continue
+		}
+		seen[part] = true
+		out = append(out, part)
+	}
+	return out
+}
+
+func jobURL(host, job string) string {
+	if strings.HasPrefix(job, "http://") || strings.HasPrefix(job, "https://") {
+		return jenkins.CanonicalJobURL(job)
+	}
+	return jenkins.JobURL(host, job)
+}
+
+// Fetch loads the last-build status of every job, in the order given.
+// Per-job failures are recorded on the row instead of aborting the board.
+func Fetch(ctx context.Context, client *jenkins.Client, jobs []string, concurrency int) []Row {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	rows := make([]Row, len(jobs))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, job := range jobs {
+		rows[i] = Row{Job: job, URL: jobURL(client.Host(), job)}
+		wg.Add(1)
+		go func(row *Row) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			row.Detail, row.Err = client.GetJobDetail(ctx, row.URL)
+		}(&rows[i])
+	}
+	wg.Wait()
+	return rows
+}
+
+// Render draws the board as plain lines sized to width; it does not clear
+// the screen so callers decide how the frame is presented.
+func Render(title string, rows []Row, updated time.Time, width int) string {
+	if width < 40 {
+		width = 40
+	}
+	nameWidth := 0
+	for _, row := range rows {
+		nameWidth = max(nameWidth, ansi.StringWidth(rowName(row)))
+	}
+	nameWidth = min(nameWidth, width/2)
+
+	lines := []string{
+		ui.Title.Render(title),
+		ui.Muted.Render("Updated " + updated.Format("15:04:05")),
+		"",
+	}
+	for _, row := range rows {
+		name := ansi.Truncate(rowName(row), nameWidth, "...")
+		name += strings.Repeat(" ", nameWidth-ansi.StringWidth(name))
+		if row.Err != nil {
+			lines = append(lines, ui.Danger.Render("!")+" "+name+"  "+ui.Danger.Render(ansi.Truncate(row.Err.Error(), max(10, width-nameWidth-4), "...")))
+			continue
+		}
+		status := ui.JobStatusLabel(row.Detail.Color)
+		if lb := row.Detail.LastBuild; lb != nil {
+			status = fmt.Sprintf("#%d %s", lb.Number, buildLabel(*lb))
+			if !lb.Timestamp.IsZero() {
+				status += "  " + ago(updated.Sub(lb.Timestamp))
+			}
+			if !lb.Building && lb.Duration > 0 {
+				status += "  took " + lb.Duration.Round(time.Second).String()
+			}
+		}
+		lines = append(lines, ui.JobStatusGlyph(row.Detail.Color)+" "+name+"  "+status)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// gridCellWidth is the narrowest a radiator cell gets before the grid drops
+// a column.
+const gridCellWidth = 26
+
+// RenderGrid draws the board as a radiator: one block per job, filled with
+// its state's color, laid out in as many columns as fit in width and sized
+// to fill height. Without colors the blocks are boxed and the state is only
+// spelled out.
+func RenderGrid(title string, rows []Row, updated time.Time, width, height int) string {
+	width, height = max(width, gridCellWidth), max(height, 8)
+	header := ui.Title.Render(title) + ui.Muted.Render("  updated "+updated.Format("15:04:05"))
+	if len(rows) == 0 {
+		return header + "\n\n" + ui.Muted.Render("No jobs to show")
+	}
+	cols := min(len(rows), max(1, (width+1)/(gridCellWidth+1)))
+	lines := (len(rows) + cols - 1) / cols
+	cellWidth := (width - (cols - 1)) / cols
+	cellHeight := min(9, max(3, (height-2-(lines-1))/lines))
+	grid := make([]string, 0, lines)
+	for start := 0; start < len(rows); start += cols {
+		var cells []string
+		for i, row := range rows[start:min(start+cols, len(rows))] {
+			if i > 0 {
+				cells = append(cells, " ")
+			}
+			cells = append(cells, gridCell(row, updated, cellWidth, cellHeight))
+		}
+		grid = append(grid, lipgloss.JoinHorizontal(lipgloss.Top, cells...))
+	}
+	return header + "\n\n" + strings.Join(grid, "\n\n")
+}
+
+func gridCell(row Row, now time.Time, width, height int) string {
+	state, bg := gridState(row)
+	style := lipgloss.NewStyle().Bold(true).Align(lipgloss.Center, lipgloss.Center)
+	inner := width
+	if ui.NoColor {
+		style = style.Border(ui.Border()).Width(width - 2).Height(height - 2)
+		inner -= 2
+	} else {
+		style = style.Foreground(lipgloss.Color("231")).Background(bg).Width(width).Height(height)
+	}
+	text := []string{ansi.Truncate(rowName(row), inner-2, "..."), ""}
+	if lb := row.Detail.LastBuild; lb != nil && row.Err == nil {
+		state = fmt.Sprintf("#%d %s", lb.Number, state)
+		text = append(text, state)
+		if !lb.Timestamp.IsZero() {
+			text = append(text, ago(now.Sub(lb.Timestamp)))
+		}
+	} else {
+		text = append(text, state)
+	}
+	if height < 5 {
+		// Too short for the spacer line.
+		text = append(text[:1], text[2:]...)
+	}
+	return style.Render(strings.Join(text, "\n"))
+}
+
+// gridState names a row's state in capitals and picks its cell color.
+func gridState(row Row) (string, lipgloss.Color) {
+	if row.Err != nil {
+		return "ERROR", lipgloss.Color("88")
+	}
+	lb := row.Detail.LastBuild
+	switch {
+	case lb == nil:
+		return strings.ToUpper(ui.JobStatusLabel(row.Detail.Color)), lipgloss.Color("240")
+	case lb.Building:
+		return "BUILDING", lipgloss.Color("25")
+	}
+	switch lb.Result {
+	case "SUCCESS":
+		return lb.Result, lipgloss.Color("28")
+	case "FAILURE":
+		return lb.Result, lipgloss.Color("160")
+	case "UNSTABLE":
+		return lb.Result, lipgloss.Color("172")
+	}
+	return buildLabel(*lb), lipgloss.Color("240")
+}
+
+func rowName(row Row) string {
+	if row.Detail.FullName != "" {
+		return row.Detail.FullName
+	}
+	return row.Job
+}
+
+func buildLabel(b models.BuildSummary) string {
+	if b.Building {
+		return "BUILDING"
+	}
+	if b.Result == "" {
+		return "PENDING"
+	}
+	return b.Result
+}
+
+func ago(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	}
+}
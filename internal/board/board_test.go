@@ -0,0 +1,94 @@
+package board
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+
+	"jenkins-tui/internal/jenkins"
+	"jenkins-tui/internal/models"
+)
+
+func TestParseJobsTrimsAndDedupes(t *testing.T) {
+	got := ParseJobs(" api/deploy, ,web,api/deploy ")
+	if strings.Join(got, "|") != "api/deploy|web" {
+		t.Fatalf("unexpected jobs %v", got)
+	}
+}
+
+func TestFetchKeepsOrderAndRecordsErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/job/api/job/deploy/api/json":
+			fmt.Fprint(w, `{"fullName":"api/deploy","color":"red","lastBuild":{"number":12,"result":"FAILURE"}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	client := jenkins.NewClient(models.JenkinsTarget{Host: srv.URL, Username: "u"}, "t", 5*time.Second)
+	rows := Fetch(context.Background(), client, []string{"api/deploy", srv.URL + "/view/all/job/missing"}, 2)
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(rows))
+	}
+	if rows[0].Err != nil || rows[0].Detail.LastBuild == nil || rows[0].Detail.LastBuild.Number != 12 {
+		t.Fatalf("unexpected first row %+v", rows[0])
+	}
+	if rows[1].Err == nil || rows[1].URL != srv.URL+"/job/missing/" {
+		t.Fatalf("expected canonical URL and error for missing job, got %+v", rows[1])
+	}
+}
+
+func TestRenderShowsLastBuildAndErrors(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	rows := []Row{
+		{Job: "api/deploy", Detail: models.JobDetail{FullName: "api/deploy", Color: "blue", LastBuild: &models.BuildSummary{
+			Number: 7, Result: "SUCCESS", Timestamp: now.Add(-3 * time.Hour), Duration: 95 * time.Second,
+		}}},
+		{Job: "web", Detail: models.JobDetail{Color: "red_anime", LastBuild: &models.BuildSummary{Number: 3, Building: true}}},
+		{Job: "gone", Err: fmt.Errorf("request failed (404)")},
+	}
+	out := Render("prod", rows, now, 100)
+	for _, want := range []string{"prod", "api/deploy", "#7 SUCCESS  3h ago  took 1m35s", "#3 BUILDING", "request failed (404)"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in board:\n%s", want, out)
+		}
+	}
+}
+
+func TestRenderGridLaysOutOneBlockPerJob(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	rows := []Row{
+		{Job: "api/deploy", Detail: models.JobDetail{FullName: "api/deploy", LastBuild: &models.BuildSummary{Number: 7, Result: "SUCCESS", Timestamp: now.Add(-3 * time.Hour)}}},
+		{Job: "web", Detail: models.JobDetail{LastBuild: &models.BuildSummary{Number: 3, Result: "FAILURE"}}},
+		{Job: "gone", Err: fmt.Errorf("request failed (404)")},
+	}
+	out := RenderGrid("prod", rows, now, 60, 20)
+	for _, want := range []string{"prod", "api/deploy", "#7 SUCCESS", "3h ago", "#3 FAILURE", "ERROR"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in grid:\n%s", want, out)
+		}
+	}
+	lines := strings.Split(out, "\n")
+	first := -1
+	for i, line := range lines {
+		if strings.Contains(line, "api/deploy") {
+			first = i
+		}
+	}
+	if first < 0 || !strings.Contains(lines[first], "web") {
+		t.Fatalf("expected two blocks side by side in 60 columns:\n%s", out)
+	}
+	for _, line := range lines {
+		if w := ansi.StringWidth(line); w > 60 {
+			t.Fatalf("expected lines to fit 60 columns, got %d: %q", w, line)
+		}
+	}
+}
@@ -6,16 +6,25 @@ import (
 )
 
 func Open(url string) error {
-	var cmd *exec.Cmd
-	switch runtime.GOOS {
+	cmd := command(runtime.GOOS, url)
+	if cmd == nil {
+		return exec.ErrNotFound
+	}
+	return cmd.Start()
+}
+
+// command builds the opener for goos. Windows goes through the URL protocol
+// handler rather than `cmd /c start`, which splits URLs at & and treats a
+// quoted first argument as the window title.
+func command(goos, url string) *exec.Cmd {
+	switch goos {
 	case "darwin":
-		cmd = exec.Command("open", url)
-	case "linux":
-		cmd = exec.Command("xdg-open", url)
+		return exec.Command("open", url)
 	case "windows":
-		cmd = exec.Command("cmd", "/c", "start", url)
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	case "plan9", "js", "wasip1", "ios", "android":
+		return nil
 	default:
-		return exec.ErrNotFound
+		return exec.Command("xdg-open", url)
 	}
-	return cmd.Start()
 }
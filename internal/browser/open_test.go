@@ -0,0 +1,28 @@
+package browser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCommandKeepsURLWhole(t *testing.T) {
+	url := "https://jenkins.example.com/job/a%20b/?x=1&y=2"
+	cases := map[string][]string{
+		"darwin":  {"open", url},
+		"linux":   {"xdg-open", url},
+		"freebsd": {"xdg-open", url},
+		"windows": {"rundll32", "url.dll,FileProtocolHandler", url},
+	}
+	for goos, want := range cases {
+		cmd := command(goos, url)
+		if cmd == nil {
+			t.Fatalf("%s: no command", goos)
+		}
+		if !reflect.DeepEqual(cmd.Args, want) {
+			t.Fatalf("%s: args = %q, want %q", goos, cmd.Args, want)
+		}
+	}
+	if command("plan9", url) != nil {
+		t.Fatal("plan9: want no command")
+	}
+}
@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"jenkins-tui/internal/models"
+)
+
+type jobIndexFile struct {
+	FetchedAt time.Time        `json:"fetched_at"`
+	Jobs      []models.JobNode `json:"jobs"`
+}
+
+// JobIndexInDir returns the flattened list of every job found by the last
+// full tree crawl for cacheKey, along with when that crawl finished. Unlike
+// folder listings the index has no TTL; callers decide how stale is too stale.
+func JobIndexInDir(cacheDir, cacheKey string) ([]models.JobNode, time.Time, bool, error) {
+	path, err := indexPath(cacheDir, cacheKey)
+	if err != nil {
+		return nil, time.Time{}, false, err
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, time.Time{}, false, nil
+		}
+		return nil, time.Time{}, false, err
+	}
+	var f jobIndexFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, time.Time{}, false, err
+	}
+	slog.Debug("job index hit", "jobs", len(f.Jobs), "fetched_at", f.FetchedAt)
+	return f.Jobs, f.FetchedAt, true, nil
+}
+
+func SaveJobIndexInDir(cacheDir, cacheKey string, jobs []models.JobNode) error {
+	path, err := indexPath(cacheDir, cacheKey)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	b, err := json.Marshal(jobIndexFile{FetchedAt: time.Now().UTC(), Jobs: jobs})
+	if err != nil {
+		return err
+	}
+	return writeFile(path, b)
+}
+
+func indexPath(cacheDir, cacheKey string) (string, error) {
+	cacheDir, err := resolveDir(cacheDir)
+	if err != nil {
+		return "", err
+	}
+	sum := sha1.Sum([]byte(cacheKey))
+	return filepath.Join(cacheDir, "index_"+hex.EncodeToString(sum[:])+".json"), nil
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"log/slog"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
@@ -27,25 +29,50 @@ func JobNodes(cacheKey, containerURL string) ([]models.JobNode, bool, error) {
 }
 
 func JobNodesInDir(cacheDir, cacheKey, containerURL string) ([]models.JobNode, bool, error) {
+	nodes, _, ok, err := JobNodesWithAgeInDir(cacheDir, cacheKey, containerURL)
+	return nodes, ok, err
+}
+
+// JobNodesWithAgeInDir is JobNodesInDir that also returns when the listing
+// was fetched.
+func JobNodesWithAgeInDir(cacheDir, cacheKey, containerURL string) ([]models.JobNode, time.Time, bool, error) {
+	f, ok, err := readJobsFile(cacheDir, cacheKey, containerURL)
+	if err != nil || !ok {
+		return nil, time.Time{}, false, err
+	}
+	if f.FetchedAt.IsZero() || time.Since(f.FetchedAt) > jobsTTL {
+		slog.Debug("jobs cache expired", "container", containerURL, "fetched_at", f.FetchedAt)
+		return nil, time.Time{}, false, nil
+	}
+	slog.Debug("jobs cache hit", "container", containerURL, "nodes", len(f.Nodes))
+	return f.Nodes, f.FetchedAt, true, nil
+}
+
+// LastJobNodesInDir returns the listing last saved for containerURL however
+// old it is, and when it was fetched.
+func LastJobNodesInDir(cacheDir, cacheKey, containerURL string) ([]models.JobNode, time.Time, bool, error) {
+	f, ok, err := readJobsFile(cacheDir, cacheKey, containerURL)
+	return f.Nodes, f.FetchedAt, ok, err
+}
+
+func readJobsFile(cacheDir, cacheKey, containerURL string) (jobsCacheFile, bool, error) {
 	path, err := jobsPath(cacheDir, cacheKey, containerURL)
 	if err != nil {
-		return nil, false, err
+		return jobsCacheFile{}, false, err
 	}
 	b, err := os.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return nil, false, nil
+			slog.Debug("jobs cache miss", "container", containerURL)
+			return jobsCacheFile{}, false, nil
 		}
-		return nil, false, err
+		return jobsCacheFile{}, false, err
 	}
 	var f jobsCacheFile
 	if err := json.Unmarshal(b, &f); err != nil {
-		return nil, false, err
-	}
-	if f.FetchedAt.IsZero() || time.Since(f.FetchedAt) > jobsTTL {
-		return nil, false, nil
+		return jobsCacheFile{}, false, err
 	}
-	return f.Nodes, true, nil
+	return f, true, nil
 }
 
 func SaveJobNodes(cacheKey, containerURL string, nodes []models.JobNode) error {
@@ -68,19 +95,53 @@ func SaveJobNodesInDir(cacheDir, cacheKey, containerURL string, nodes []models.J
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(path, b, 0o644)
+	return writeFile(path, b)
 }
 
 func jobsPath(cacheDir, cacheKey, containerURL string) (string, error) {
-	if strings.TrimSpace(cacheDir) == "" {
-		base, err := os.UserCacheDir()
-		if err != nil {
-			return "", fmt.Errorf("resolve user cache dir: %w", err)
-		}
-		cacheDir = filepath.Join(base, "jenkins-tui")
+	cacheDir, err := resolveDir(cacheDir)
+	if err != nil {
+		return "", err
 	}
 	containerURL = strings.TrimRight(containerURL, "/")
+	// Jenkins and JobURL escape names differently; key on the decoded form
+	// so both find the same entry.
+	if decoded, err := url.PathUnescape(containerURL); err == nil {
+		containerURL = decoded
+	}
 	sum := sha1.Sum([]byte(cacheKey + "|" + containerURL))
 	file := "jobs_" + hex.EncodeToString(sum[:]) + ".json"
 	return filepath.Join(cacheDir, file), nil
 }
+
+func resolveDir(cacheDir string) (string, error) {
+	if strings.TrimSpace(cacheDir) != "" {
+		return cacheDir, nil
+	}
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("resolve user cache dir: %w", err)
+	}
+	return filepath.Join(base, "jenkins-tui"), nil
+}
+
+// writeFile replaces path through a temp file and a rename, so a reader or
+// a second jenkins-tui writing the same entry never sees a torn file.
+func writeFile(path string, b []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
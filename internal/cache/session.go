@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"jenkins-tui/internal/models"
+)
+
+// Session is where the TUI was when it last quit: the connected server, the
+// folder frames that were open, and the list cursors.
+type Session struct {
+	Server        string           `json:"server,omitempty"`
+	Folders       []models.JobNode `json:"folders,omitempty"`
+	JobsCursor    int              `json:"jobs_cursor"`
+	ServersCursor int              `json:"servers_cursor"`
+	SavedAt       time.Time        `json:"saved_at"`
+}
+
+func SessionInDir(cacheDir string) (Session, bool, error) {
+	path, err := sessionPath(cacheDir)
+	if err != nil {
+		return Session{}, false, err
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Session{}, false, nil
+		}
+		return Session{}, false, err
+	}
+	var s Session
+	if err := json.Unmarshal(b, &s); err != nil {
+		return Session{}, false, err
+	}
+	return s, true, nil
+}
+
+func SaveSessionInDir(cacheDir string, s Session) error {
+	path, err := sessionPath(cacheDir)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	s.SavedAt = time.Now().UTC()
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return writeFile(path, b)
+}
+
+func sessionPath(cacheDir string) (string, error) {
+	cacheDir, err := resolveDir(cacheDir)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "session.json"), nil
+}
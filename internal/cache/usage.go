@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// maxUsageEntries bounds the usage file; the least recently used jobs are
+// dropped first.
+const maxUsageEntries = 500
+
+// JobUse is how often and when a job was last triggered from this machine.
+type JobUse struct {
+	Count int       `json:"count"`
+	Last  time.Time `json:"last"`
+}
+
+// Usage maps job URLs to their use, for one server.
+type Usage map[string]JobUse
+
+type usageFile struct {
+	Jobs Usage `json:"jobs"`
+}
+
+// UsageInDir returns the recorded use of cacheKey's jobs; a missing file is
+// an empty Usage.
+func UsageInDir(cacheDir, cacheKey string) (Usage, error) {
+	path, err := usagePath(cacheDir, cacheKey)
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Usage{}, nil
+		}
+		return nil, err
+	}
+	var f usageFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, err
+	}
+	if f.Jobs == nil {
+		f.Jobs = Usage{}
+	}
+	return f.Jobs, nil
+}
+
+// RecordUseInDir counts one trigger of jobURL now.
+func RecordUseInDir(cacheDir, cacheKey, jobURL string) error {
+	usage, err := UsageInDir(cacheDir, cacheKey)
+	if err != nil {
+		usage = Usage{}
+	}
+	use := usage[jobURL]
+	use.Count++
+	use.Last = time.Now().UTC()
+	usage[jobURL] = use
+	if len(usage) > maxUsageEntries {
+		urls := make([]string, 0, len(usage))
+		for u := range usage {
+			urls = append(urls, u)
+		}
+		sort.Slice(urls, func(i, j int) bool { return usage[urls[i]].Last.Before(usage[urls[j]].Last) })
+		for _, u := range urls[:len(usage)-maxUsageEntries] {
+			delete(usage, u)
+		}
+	}
+	path, err := usagePath(cacheDir, cacheKey)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	b, err := json.Marshal(usageFile{Jobs: usage})
+	if err != nil {
+		return err
+	}
+	return writeFile(path, b)
+}
+
+func usagePath(cacheDir, cacheKey string) (string, error) {
+	cacheDir, err := resolveDir(cacheDir)
+	if err != nil {
+		return "", err
+	}
+	sum := sha1.Sum([]byte(cacheKey))
+	return filepath.Join(cacheDir, "usage_"+hex.EncodeToString(sum[:])+".json"), nil
+}
@@ -0,0 +1,143 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrNoBackup reports that there is no earlier version to go back to.
+var ErrNoBackup = errors.New("no earlier config version to restore")
+
+const (
+	// maxBackups is how many earlier versions of a config are kept.
+	maxBackups = 20
+	// backupStamp sorts lexically in time order.
+	backupStamp = "20060102-150405.000"
+	// undoneSuffix marks the version an undo replaced. It is kept for
+	// manual recovery but is not itself a step to undo to.
+	undoneSuffix = ".undone"
+)
+
+// Backup is an earlier version of a config file.
+type Backup struct {
+	Path  string
+	Saved time.Time
+}
+
+func backupDir(path string) string {
+	return filepath.Join(filepath.Dir(path), "backups")
+}
+
+// backup keeps the current content of path before it is replaced by next.
+// A missing file or an unchanged one is not backed up.
+func backup(path string, next []byte) error {
+	current, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) || (err == nil && bytes.Equal(current, next)) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read %s for backup: %w", path, err)
+	}
+	if err := writeBackup(path, current, ""); err != nil {
+		return err
+	}
+	return prune(path)
+}
+
+func writeBackup(path string, content []byte, suffix string) error {
+	dir := backupDir(path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("create backup dir %s: %w", dir, err)
+	}
+	name := filepath.Base(path) + "." + time.Now().UTC().Format(backupStamp) + suffix
+	if err := os.WriteFile(filepath.Join(dir, name), content, 0o600); err != nil {
+		return fmt.Errorf("write config backup: %w", err)
+	}
+	return nil
+}
+
+// Backups lists the earlier versions of the config at path, newest first.
+func Backups(path string) ([]Backup, error) {
+	return listBackups(path, false)
+}
+
+// listBackups lists either the normal backups of path or its .undone files,
+// newest first.
+func listBackups(path string, undone bool) ([]Backup, error) {
+	entries, err := os.ReadDir(backupDir(path))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("list config backups: %w", err)
+	}
+	prefix := filepath.Base(path) + "."
+	var out []Backup
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) || strings.HasSuffix(name, undoneSuffix) != undone {
+			continue
+		}
+		saved, err := time.Parse(backupStamp, strings.TrimSuffix(strings.TrimPrefix(name, prefix), undoneSuffix))
+		if err != nil {
+			continue
+		}
+		out = append(out, Backup{Path: filepath.Join(backupDir(path), name), Saved: saved})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Saved.After(out[j].Saved) })
+	return out, nil
+}
+
+// prune keeps the newest maxBackups backups of path, and as many .undone
+// files.
+func prune(path string) error {
+	for _, undone := range []bool{false, true} {
+		backups, err := listBackups(path, undone)
+		if err != nil {
+			return err
+		}
+		for _, b := range backups[min(len(backups), maxBackups):] {
+			_ = os.Remove(b.Path)
+		}
+	}
+	return nil
+}
+
+// Undo puts back the newest backup of path. The version it replaces is kept
+// as an .undone file, and the restored backup is used up, so repeated
+// calls step further back.
+func Undo(path string) (Backup, error) {
+	unlock, err := lockFile(path)
+	if err != nil {
+		return Backup{}, err
+	}
+	defer unlock()
+	backups, err := Backups(path)
+	if err != nil {
+		return Backup{}, err
+	}
+	if len(backups) == 0 {
+		return Backup{}, ErrNoBackup
+	}
+	restore := backups[0]
+	content, err := os.ReadFile(restore.Path)
+	if err != nil {
+		return Backup{}, fmt.Errorf("read config backup: %w", err)
+	}
+	if current, err := os.ReadFile(path); err == nil {
+		if err := writeBackup(path, current, undoneSuffix); err != nil {
+			return Backup{}, err
+		}
+	}
+	if err := replaceFile(path, content); err != nil {
+		return Backup{}, err
+	}
+	_ = os.Remove(restore.Path)
+	return restore, prune(path)
+}
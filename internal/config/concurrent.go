@@ -0,0 +1,289 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"jenkins-tui/internal/models"
+)
+
+// ErrChanged reports that the config file was written by someone else since
+// it was read, so saving over it would drop their edit.
+var ErrChanged = errors.New("config file changed on disk")
+
+const (
+	// lockWait is how long a save waits for another instance's lock.
+	lockWait = 5 * time.Second
+	// staleLockAge is when a lock left by a crashed instance is broken.
+	staleLockAge = 30 * time.Second
+)
+
+// Fingerprint identifies the content of the file at path; it is "" when the
+// file does not exist.
+func Fingerprint(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("read %s: %w", path, err)
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// SaveIfUnchanged saves cfg only while the file still has the fingerprint
+// want, holding the lock from the check to the write. It returns the new
+// fingerprint, or ErrChanged when another writer got there first.
+func SaveIfUnchanged(path string, cfg models.Config, want string) (string, error) {
+	if path == "" {
+		return "", fmt.Errorf("config path is required")
+	}
+	unlock, err := lockFile(path)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+	got, err := Fingerprint(path)
+	if err != nil {
+		return "", err
+	}
+	if got != want {
+		return got, ErrChanged
+	}
+	if err := save(path, cfg); err != nil {
+		return "", err
+	}
+	return Fingerprint(path)
+}
+
+// lockFile takes an exclusive lock next to path by creating path.lock, so
+// two instances never interleave a check and a write. Plain O_EXCL works the
+// same on every platform, unlike flock.
+func lockFile(path string) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("create config dir %s: %w", filepath.Dir(path), err)
+	}
+	lock := path + ".lock"
+	deadline := time.Now().Add(lockWait)
+	for {
+		f, err := os.OpenFile(lock, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			fmt.Fprintf(f, "%d\n", os.Getpid())
+			f.Close()
+			mine, _ := readLock(lock)
+			return func() {
+				// The lock may have been broken as stale and taken by
+				// another instance meanwhile; only remove our own.
+				if owner, err := readLock(lock); err == nil && owner == mine {
+					_ = os.Remove(lock)
+				}
+			}, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("lock %s: %w", path, err)
+		}
+		if owner, err := readLock(lock); err == nil && time.Since(owner.modTime) > staleLockAge {
+			// Another instance may break the same stale lock and take a
+			// fresh one between our read and the remove, so only remove
+			// the lock while it is still the one judged stale.
+			if again, err := readLock(lock); err == nil && again == owner {
+				_ = os.Remove(lock)
+			}
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%s is locked by another jenkins-tui; remove %s if none is running", path, lock)
+		}
+		time.Sleep(25 * time.Millisecond)
+	}
+}
+
+// lockOwner identifies one taking of a lock file: the pid written into it
+// and when it was written.
+type lockOwner struct {
+	pid     string
+	modTime time.Time
+}
+
+func readLock(lock string) (lockOwner, error) {
+	info, err := os.Stat(lock)
+	if err != nil {
+		return lockOwner{}, err
+	}
+	b, err := os.ReadFile(lock)
+	if err != nil {
+		return lockOwner{}, err
+	}
+	return lockOwner{pid: strings.TrimSpace(string(b)), modTime: info.ModTime()}, nil
+}
+
+// Clone copies cfg deeply enough that later edits to one do not show in the
+// other.
+func Clone(cfg models.Config) models.Config {
+	cfg.Jenkins = slices.Clone(cfg.Jenkins)
+	for i, t := range cfg.Jenkins {
+		cfg.Jenkins[i] = cloneTarget(t)
+	}
+	if cfg.Keybindings != nil {
+		keys := make(map[string]models.KeyList, len(cfg.Keybindings))
+		for action, list := range cfg.Keybindings {
+			keys[action] = slices.Clone(list)
+		}
+		cfg.Keybindings = keys
+	}
+	cfg.Schedules = slices.Clone(cfg.Schedules)
+	for i, s := range cfg.Schedules {
+		cfg.Schedules[i].Params = cloneParams(s.Params)
+	}
+	return cfg
+}
+
+func cloneParams(params map[string]models.ParamValues) map[string]models.ParamValues {
+	if params == nil {
+		return nil
+	}
+	out := make(map[string]models.ParamValues, len(params))
+	for name, values := range params {
+		out[name] = slices.Clone(values)
+	}
+	return out
+}
+
+func cloneTarget(t models.JenkinsTarget) models.JenkinsTarget {
+	t.Bookmarks = slices.Clone(t.Bookmarks)
+	t.Watches = slices.Clone(t.Watches)
+	t.Tags = slices.Clone(t.Tags)
+	t.Aliases = maps.Clone(t.Aliases)
+	t.URLRewrites = slices.Clone(t.URLRewrites)
+	if t.Transport.ForceAttemptHTTP2 != nil {
+		force := *t.Transport.ForceAttemptHTTP2
+		t.Transport.ForceAttemptHTTP2 = &force
+	}
+	if t.Raw != nil {
+		raw := *t.Raw
+		t.Raw = &raw
+	}
+	return t
+}
+
+// MergeConcurrent applies the edits made from base to ours on top of theirs,
+// the file as another instance saved it. Targets are matched by ID. A target
+// both sides changed differently is a conflict: it keeps theirs unless
+// preferOurs is set, and its ID is returned either way.
+func MergeConcurrent(base, ours, theirs models.Config, preferOurs bool) (models.Config, []string) {
+	index := func(cfg models.Config) map[string]models.JenkinsTarget {
+		out := make(map[string]models.JenkinsTarget, len(cfg.Jenkins))
+		for _, t := range cfg.Jenkins {
+			out[t.ID] = t
+		}
+		return out
+	}
+	baseByID, oursByID := index(base), index(ours)
+
+	merged := theirs
+	merged.Jenkins = nil
+	var conflicts []string
+	for _, their := range theirs.Jenkins {
+		b, inBase := baseByID[their.ID]
+		our, inOurs := oursByID[their.ID]
+		switch {
+		case !inBase && !inOurs:
+			merged.Jenkins = append(merged.Jenkins, their)
+		case !inBase:
+			// Both added the same ID.
+			if !reflect.DeepEqual(our, their) {
+				conflicts = append(conflicts, their.ID)
+				if preferOurs {
+					their = our
+				}
+			}
+			merged.Jenkins = append(merged.Jenkins, their)
+		case reflect.DeepEqual(b, their):
+			// Only we may have touched it: take our version or our delete.
+			if inOurs {
+				merged.Jenkins = append(merged.Jenkins, our)
+			}
+		case inOurs && reflect.DeepEqual(b, our):
+			merged.Jenkins = append(merged.Jenkins, their)
+		case inOurs && reflect.DeepEqual(our, their):
+			merged.Jenkins = append(merged.Jenkins, their)
+		default:
+			conflicts = append(conflicts, their.ID)
+			switch {
+			case !preferOurs:
+				merged.Jenkins = append(merged.Jenkins, their)
+			case inOurs:
+				merged.Jenkins = append(merged.Jenkins, our)
+			}
+		}
+	}
+	theirsByID := index(theirs)
+	for _, our := range ours.Jenkins {
+		if _, ok := theirsByID[our.ID]; ok {
+			continue
+		}
+		b, inBase := baseByID[our.ID]
+		switch {
+		case !inBase:
+			merged.Jenkins = append(merged.Jenkins, our)
+		case !reflect.DeepEqual(b, our):
+			// They deleted a target we edited.
+			conflicts = append(conflicts, our.ID)
+			if preferOurs {
+				merged.Jenkins = append(merged.Jenkins, our)
+			}
+		}
+	}
+
+	if !reflect.DeepEqual(base.Keybindings, ours.Keybindings) {
+		merged.Keybindings = ours.Keybindings
+	}
+	if base.Layout != ours.Layout {
+		merged.Layout = ours.Layout
+	}
+	if base.CredentialCacheTTL != ours.CredentialCacheTTL {
+		merged.CredentialCacheTTL = ours.CredentialCacheTTL
+	}
+	if base.AuditLog != ours.AuditLog {
+		merged.AuditLog = ours.AuditLog
+	}
+	if base.PrefetchFolders != ours.PrefetchFolders {
+		merged.PrefetchFolders = ours.PrefetchFolders
+	}
+	if base.MaxResponseMB != ours.MaxResponseMB {
+		merged.MaxResponseMB = ours.MaxResponseMB
+	}
+	if base.StripLogColors != ours.StripLogColors {
+		merged.StripLogColors = ours.StripLogColors
+	}
+	if base.LogDir != ours.LogDir {
+		merged.LogDir = ours.LogDir
+	}
+	if base.TimeStyle != ours.TimeStyle {
+		merged.TimeStyle = ours.TimeStyle
+	}
+	if base.Clock != ours.Clock {
+		merged.Clock = ours.Clock
+	}
+	if base.Timezone != ours.Timezone {
+		merged.Timezone = ours.Timezone
+	}
+	if base.DurationStyle != ours.DurationStyle {
+		merged.DurationStyle = ours.DurationStyle
+	}
+	if !reflect.DeepEqual(base.Schedules, ours.Schedules) {
+		merged.Schedules = ours.Schedules
+	}
+	merged.Timeout, merged.ConfigPath, merged.CacheDir, merged.Startup = ours.Timeout, ours.ConfigPath, ours.CacheDir, ours.Startup
+	return merged, conflicts
+}
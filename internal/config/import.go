@@ -0,0 +1,272 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+
+	"jenkins-tui/internal/models"
+)
+
+// Import sources understood by ReadImport.
+const (
+	ImportNetrc      = "netrc"
+	ImportJenkinsCLI = "jenkins-cli"
+)
+
+// ImportCandidate is a server found in another tool's configuration, with
+// the API token to store for it.
+type ImportCandidate struct {
+	Target models.JenkinsTarget
+	Token  string
+}
+
+// DefaultImportPath is where source keeps its file: $NETRC or ~/.netrc (or
+// ~/_netrc, which curl and git use on Windows), and ~/.jenkins-cli.yaml for
+// the Jenkins CLI (jcli).
+func DefaultImportPath(source string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	switch source {
+	case ImportNetrc:
+		if p := strings.TrimSpace(os.Getenv("NETRC")); p != "" {
+			return p, nil
+		}
+		path := filepath.Join(home, ".netrc")
+		if runtime.GOOS == "windows" {
+			alt := filepath.Join(home, "_netrc")
+			if _, err := os.Stat(path); err != nil {
+				if _, err := os.Stat(alt); err == nil {
+					return alt, nil
+				}
+			}
+		}
+		return path, nil
+	case ImportJenkinsCLI:
+		return filepath.Join(home, ".jenkins-cli.yaml"), nil
+	}
+	return "", fmt.Errorf("unknown import source %q (want %q or %q)", source, ImportNetrc, ImportJenkinsCLI)
+}
+
+// ReadImport reads the candidates of source from path, or from the source's
+// default path when path is empty.
+func ReadImport(source, path string) ([]ImportCandidate, error) {
+	if strings.TrimSpace(path) == "" {
+		p, err := DefaultImportPath(source)
+		if err != nil {
+			return nil, err
+		}
+		path = p
+	}
+	switch source {
+	case ImportNetrc:
+		return ReadNetrc(path)
+	case ImportJenkinsCLI:
+		return ReadJenkinsCLI(path)
+	}
+	return nil, fmt.Errorf("unknown import source %q (want %q or %q)", source, ImportNetrc, ImportJenkinsCLI)
+}
+
+// ReadNetrc returns a candidate per machine entry that has both a login and
+// a password. netrc has no scheme, so hosts are assumed to be https. The
+// default entry and macdef bodies are skipped.
+func ReadNetrc(path string) ([]ImportCandidate, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	defer f.Close()
+
+	var out []ImportCandidate
+	var cur *ImportCandidate
+	flush := func() {
+		if cur != nil && cur.Target.Host != "" && cur.Target.Username != "" && cur.Token != "" {
+			out = append(out, *cur)
+		}
+		cur = nil
+	}
+	sc := bufio.NewScanner(f)
+	inMacro := false
+	for sc.Scan() {
+		line := sc.Text()
+		if inMacro {
+			inMacro = strings.TrimSpace(line) != ""
+			continue
+		}
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		for i := 0; i < len(fields); i++ {
+			value := ""
+			if i+1 < len(fields) {
+				value = fields[i+1]
+			}
+			switch fields[i] {
+			case "machine":
+				flush()
+				cur = &ImportCandidate{Target: models.JenkinsTarget{Host: "https://" + value}}
+				i++
+			case "default":
+				flush()
+			case "login":
+				if cur != nil {
+					cur.Target.Username = value
+				}
+				i++
+			case "password":
+				if cur != nil {
+					cur.Token = value
+				}
+				i++
+			case "account":
+				i++
+			case "macdef":
+				flush()
+				inMacro = true
+				i = len(fields)
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	flush()
+	for i := range out {
+		out[i].Target.Name = strings.TrimPrefix(out[i].Target.Host, "https://")
+	}
+	return out, nil
+}
+
+type jenkinsCLIConfig struct {
+	Servers []struct {
+		Name               string `yaml:"name"`
+		URL                string `yaml:"url"`
+		Username           string `yaml:"username"`
+		Token              string `yaml:"token"`
+		InsecureSkipVerify bool   `yaml:"insecureSkipVerify"`
+	} `yaml:"jenkins_servers"`
+}
+
+// ReadJenkinsCLI returns the jenkins_servers of a jcli config file.
+func ReadJenkinsCLI(path string) ([]ImportCandidate, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	var cfg jenkinsCLIConfig
+	if err := yaml.Unmarshal(b, &cfg); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	var out []ImportCandidate
+	for _, s := range cfg.Servers {
+		host := strings.TrimRight(strings.TrimSpace(s.URL), "/")
+		if host == "" || strings.TrimSpace(s.Username) == "" || strings.TrimSpace(s.Token) == "" {
+			continue
+		}
+		out = append(out, ImportCandidate{
+			Target: models.JenkinsTarget{
+				Name:                  strings.TrimSpace(s.Name),
+				Host:                  host,
+				Username:              strings.TrimSpace(s.Username),
+				InsecureSkipTLSVerify: s.InsecureSkipVerify,
+			},
+			Token: strings.TrimSpace(s.Token),
+		})
+	}
+	return out, nil
+}
+
+// PlanImport drops candidates whose host and username are already in cfg,
+// and gives the rest a unique id and a keyring credential under
+// jenkins-tui/<id>. A plain http:// host sends its token unencrypted, so it
+// is only planned, with allow_insecure_http, when allowInsecureHTTP is set;
+// otherwise it is returned in plainHTTP for the caller to report.
+func PlanImport(cfg models.Config, candidates []ImportCandidate, allowInsecureHTTP bool) (planned, plainHTTP []ImportCandidate) {
+	taken := map[string]bool{}
+	for _, t := range cfg.Jenkins {
+		taken[t.ID] = true
+	}
+	for _, c := range candidates {
+		if configured(cfg.Jenkins, c.Target) || configured(targetsOf(planned), c.Target) || configured(targetsOf(plainHTTP), c.Target) {
+			continue
+		}
+		if c.Target.Name == "" {
+			c.Target.Name = hostName(c.Target.Host)
+		}
+		if c.Target.PlainHTTP() && !allowInsecureHTTP {
+			plainHTTP = append(plainHTTP, c)
+			continue
+		}
+		base := SlugifyID(c.Target.Name)
+		id := base
+		for n := 2; taken[id]; n++ {
+			id = fmt.Sprintf("%s-%d", base, n)
+		}
+		taken[id] = true
+		c.Target.ID = id
+		c.Target.Credential = models.Credential{Type: models.CredentialTypeKeyring, Ref: "jenkins-tui/" + id}
+		c.Target.AllowInsecureHTTP = c.Target.PlainHTTP()
+		planned = append(planned, c)
+	}
+	return planned, plainHTTP
+}
+
+func configured(targets []models.JenkinsTarget, t models.JenkinsTarget) bool {
+	for _, existing := range targets {
+		if strings.EqualFold(strings.TrimRight(existing.Host, "/"), t.Host) && existing.Username == t.Username {
+			return true
+		}
+	}
+	return false
+}
+
+func targetsOf(candidates []ImportCandidate) []models.JenkinsTarget {
+	out := make([]models.JenkinsTarget, len(candidates))
+	for i, c := range candidates {
+		out[i] = c.Target
+	}
+	return out
+}
+
+func hostName(host string) string {
+	if u, err := url.Parse(host); err == nil && u.Hostname() != "" {
+		return u.Hostname()
+	}
+	return host
+}
+
+// SlugifyID lowercases input and folds every run of other characters to a
+// single dash, e.g. "CI Prod (EU)" becomes "ci-prod-eu".
+func SlugifyID(input string) string {
+	var b strings.Builder
+	prevDash := false
+	for _, r := range strings.ToLower(strings.TrimSpace(input)) {
+		isASCIIAlphaNum := (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9')
+		if isASCIIAlphaNum {
+			b.WriteRune(r)
+			prevDash = false
+			continue
+		}
+		if unicode.IsSpace(r) || !isASCIIAlphaNum {
+			if !prevDash {
+				b.WriteByte('-')
+				prevDash = true
+			}
+		}
+	}
+	out := strings.Trim(b.String(), "-")
+	if out == "" {
+		return "target"
+	}
+	return out
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"jenkins-tui/internal/models"
+)
+
+func TestReadNetrc(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".netrc")
+	content := `# work
+machine jenkins.example.com login ci-user password tok1
+machine github.com
+  login me
+  password ghp
+macdef init
+  cd /pub
+  machine not.a.host login x password y
+
+machine half.example.com login nobody
+default login anonymous password guest
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadNetrc(path)
+	if err != nil {
+		t.Fatalf("ReadNetrc: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected 2 candidates, got %+v", got)
+	}
+	if got[0].Target.Host != "https://jenkins.example.com" || got[0].Target.Username != "ci-user" || got[0].Token != "tok1" || got[0].Target.Name != "jenkins.example.com" {
+		t.Fatalf("unexpected first candidate: %+v", got[0])
+	}
+	if got[1].Target.Host != "https://github.com" || got[1].Token != "ghp" {
+		t.Fatalf("multi-line entries should be read, got %+v", got[1])
+	}
+}
+
+func TestReadJenkinsCLI(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".jenkins-cli.yaml")
+	content := `current: dev
+jenkins_servers:
+- name: dev
+  url: http://localhost:8080/jenkins/
+  username: admin
+  token: abc
+  insecureSkipVerify: true
+- name: notoken
+  url: https://other
+  username: admin
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadJenkinsCLI(path)
+	if err != nil {
+		t.Fatalf("ReadJenkinsCLI: %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("expected 1 candidate, got %+v", got)
+	}
+	if got[0].Target.Host != "http://localhost:8080/jenkins" || got[0].Target.Name != "dev" || !got[0].Target.InsecureSkipTLSVerify || got[0].Token != "abc" {
+		t.Fatalf("unexpected candidate: %+v", got[0])
+	}
+}
+
+func TestPlanImportSkipsConfiguredAndAssignsIDs(t *testing.T) {
+	cfg := models.Config{Jenkins: []models.JenkinsTarget{
+		{ID: "ci-example-com", Host: "https://ci.example.com", Username: "me"},
+	}}
+	candidates := []ImportCandidate{
+		{Target: models.JenkinsTarget{Host: "https://ci.example.com", Username: "me"}, Token: "a"},
+		{Target: models.JenkinsTarget{Host: "https://ci.example.com", Username: "bot"}, Token: "b"},
+		{Target: models.JenkinsTarget{Host: "https://ci.example.com", Username: "bot"}, Token: "c"},
+	}
+	got, _ := PlanImport(cfg, candidates, false)
+	if len(got) != 1 {
+		t.Fatalf("expected configured and repeated entries to be skipped, got %+v", got)
+	}
+	if got[0].Target.ID != "ci-example-com-2" || got[0].Target.Credential != (models.Credential{Type: models.CredentialTypeKeyring, Ref: "jenkins-tui/ci-example-com-2"}) {
+		t.Fatalf("unexpected id or credential: %+v", got[0].Target)
+	}
+}
+
+func TestPlanImportLeavesPlainHTTPOutUnlessAllowed(t *testing.T) {
+	candidates := []ImportCandidate{
+		{Target: models.JenkinsTarget{Host: "http://ci.example.com", Username: "me"}, Token: "a"},
+		{Target: models.JenkinsTarget{Host: "http://localhost:8080", Username: "me"}, Token: "b"},
+	}
+	planned, plainHTTP := PlanImport(models.Config{}, candidates, false)
+	if len(planned) != 1 || planned[0].Target.Host != "http://localhost:8080" || planned[0].Target.AllowInsecureHTTP {
+		t.Fatalf("only the loopback server should be planned, without allow_insecure_http: %+v", planned)
+	}
+	if len(plainHTTP) != 1 || plainHTTP[0].Target.Name != "ci.example.com" {
+		t.Fatalf("the plain HTTP server should be reported, got %+v", plainHTTP)
+	}
+	planned, plainHTTP = PlanImport(models.Config{}, candidates, true)
+	if len(planned) != 2 || len(plainHTTP) != 0 || !planned[0].Target.AllowInsecureHTTP {
+		t.Fatalf("an explicit opt-in should import it with allow_insecure_http, got %+v %+v", planned, plainHTTP)
+	}
+}
@@ -1,16 +1,26 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 
+	"jenkins-tui/internal/cron"
 	"jenkins-tui/internal/models"
 )
 
+// maxPrefetchFolders keeps background folder prefetching from flooding a
+// Jenkins controller.
+const maxPrefetchFolders = 16
+
 func Load(path string) (models.Config, error) {
 	var cfg models.Config
 	b, err := os.ReadFile(path)
@@ -20,7 +30,46 @@ func Load(path string) (models.Config, error) {
 	if err := yaml.Unmarshal(b, &cfg); err != nil {
 		return cfg, fmt.Errorf("parse %s: %w", path, err)
 	}
+	switch cfg.Layout = strings.TrimSpace(cfg.Layout); cfg.Layout {
+	case "", models.LayoutList, models.LayoutSplit:
+	default:
+		return cfg, fmt.Errorf("layout must be %q or %q", models.LayoutList, models.LayoutSplit)
+	}
+	switch cfg.TimeStyle = strings.TrimSpace(cfg.TimeStyle); cfg.TimeStyle {
+	case "", models.TimeStyleRelative, models.TimeStyleAbsolute:
+	default:
+		return cfg, fmt.Errorf("time_style must be %q or %q", models.TimeStyleRelative, models.TimeStyleAbsolute)
+	}
+	switch cfg.Clock = strings.TrimSpace(cfg.Clock); cfg.Clock {
+	case "", models.Clock12h, models.Clock24h:
+	default:
+		return cfg, fmt.Errorf("clock must be %q or %q", models.Clock12h, models.Clock24h)
+	}
+	if cfg.Timezone = strings.TrimSpace(cfg.Timezone); cfg.Timezone != "" {
+		if _, err := time.LoadLocation(cfg.Timezone); err != nil {
+			return cfg, fmt.Errorf("timezone: %w", err)
+		}
+	}
+	switch cfg.DurationStyle = strings.TrimSpace(cfg.DurationStyle); cfg.DurationStyle {
+	case "", models.DurationStyleShort, models.DurationStyleClock:
+	default:
+		return cfg, fmt.Errorf("duration_style must be %q or %q", models.DurationStyleShort, models.DurationStyleClock)
+	}
+	if cfg.CredentialCacheTTL < 0 {
+		return cfg, fmt.Errorf("credential_cache_ttl must not be negative")
+	}
+	if cfg.PrefetchFolders < 0 || cfg.PrefetchFolders > maxPrefetchFolders {
+		return cfg, fmt.Errorf("prefetch_folders must be between 0 and %d", maxPrefetchFolders)
+	}
+	if cfg.MaxResponseMB < 0 {
+		return cfg, fmt.Errorf("max_response_mb must not be negative")
+	}
 	seenIDs := map[string]struct{}{}
+	for i := range cfg.Jenkins {
+		if err := expandTarget(&cfg.Jenkins[i]); err != nil {
+			return cfg, fmt.Errorf("jenkins[%d].%w", i, err)
+		}
+	}
 	for i, t := range cfg.Jenkins {
 		if strings.TrimSpace(t.ID) == "" {
 			return cfg, fmt.Errorf("jenkins[%d].id is required", i)
@@ -36,29 +85,231 @@ func Load(path string) (models.Config, error) {
 		if strings.TrimSpace(t.Username) == "" {
 			return cfg, fmt.Errorf("jenkins[%d].username is required", i)
 		}
-		if t.Credential.Type != models.CredentialTypeKeyring && t.Credential.Type != models.CredentialTypeEnv {
-			return cfg, fmt.Errorf("jenkins[%d].credential.type must be %q or %q", i, models.CredentialTypeKeyring, models.CredentialTypeEnv)
+		if t.Transport.MaxIdleConnsPerHost < 0 {
+			return cfg, fmt.Errorf("jenkins[%d].transport.max_idle_conns_per_host must not be negative", i)
+		}
+		if t.Transport.IdleConnTimeout < 0 {
+			return cfg, fmt.Errorf("jenkins[%d].transport.idle_conn_timeout must not be negative", i)
+		}
+		proxy, err := normalizeProxy(t.Proxy)
+		if err != nil {
+			return cfg, fmt.Errorf("jenkins[%d].proxy.%w", i, err)
+		}
+		cfg.Jenkins[i].Proxy = proxy
+		authCommand := strings.TrimSpace(t.AuthCommand)
+		switch cfg.Jenkins[i].Auth = strings.ToLower(strings.TrimSpace(t.Auth)); cfg.Jenkins[i].Auth {
+		case "", models.AuthKerberos:
+		default:
+			return cfg, fmt.Errorf("jenkins[%d].auth must be %q or unset for API tokens", i, models.AuthKerberos)
 		}
-		if strings.TrimSpace(t.Credential.Ref) == "" {
-			return cfg, fmt.Errorf("jenkins[%d].credential.ref is required", i)
+		tokenless := authCommand != "" || cfg.Jenkins[i].Auth == models.AuthKerberos
+		credentialOptional := tokenless && t.Credential.Type == "" && strings.TrimSpace(t.Credential.Ref) == ""
+		if !credentialOptional {
+			if t.Credential.Type != models.CredentialTypeKeyring && t.Credential.Type != models.CredentialTypeEnv {
+				return cfg, fmt.Errorf("jenkins[%d].credential.type must be %q or %q", i, models.CredentialTypeKeyring, models.CredentialTypeEnv)
+			}
+			if strings.TrimSpace(t.Credential.Ref) == "" {
+				return cfg, fmt.Errorf("jenkins[%d].credential.ref is required", i)
+			}
 		}
 		if strings.TrimSpace(t.Name) == "" {
 			cfg.Jenkins[i].Name = id
 		}
 		cfg.Jenkins[i].ID = id
 		cfg.Jenkins[i].Host = strings.TrimRight(strings.TrimSpace(t.Host), "/")
+		if cfg.Jenkins[i].PlainHTTP() && !t.AllowInsecureHTTP {
+			return cfg, fmt.Errorf("jenkins[%d].host %s is plain http, which sends the API token unencrypted; use https:// or set allow_insecure_http: true", i, cfg.Jenkins[i].Host)
+		}
 		cfg.Jenkins[i].Username = strings.TrimSpace(t.Username)
 		cfg.Jenkins[i].Credential.Ref = strings.TrimSpace(t.Credential.Ref)
+		cfg.Jenkins[i].AuthCommand = authCommand
+		cfg.Jenkins[i].Tags = normalizeTags(t.Tags)
+		aliases, err := normalizeAliases(t.Aliases)
+		if err != nil {
+			return cfg, fmt.Errorf("jenkins[%d].aliases: %w", i, err)
+		}
+		cfg.Jenkins[i].Aliases = aliases
+		for j, r := range t.URLRewrites {
+			from, to, err := normalizeRewrite(r)
+			if err != nil {
+				return cfg, fmt.Errorf("jenkins[%d].url_rewrites[%d].%w", i, j, err)
+			}
+			if (models.JenkinsTarget{Host: to}).PlainHTTP() && !t.AllowInsecureHTTP {
+				return cfg, fmt.Errorf("jenkins[%d].url_rewrites[%d].to %s is plain http, which sends the API token unencrypted; use https:// or set allow_insecure_http: true", i, j, to)
+			}
+			cfg.Jenkins[i].URLRewrites[j] = models.URLRewrite{From: from, To: to}
+		}
+	}
+	seenSchedules := map[string]struct{}{}
+	for i, sc := range cfg.Schedules {
+		name := strings.TrimSpace(sc.Name)
+		if name == "" {
+			return cfg, fmt.Errorf("schedules[%d].name is required", i)
+		}
+		if _, ok := seenSchedules[name]; ok {
+			return cfg, fmt.Errorf("schedules[%d].name %q is duplicated", i, name)
+		}
+		seenSchedules[name] = struct{}{}
+		if _, err := cron.Parse(sc.Cron); err != nil {
+			return cfg, fmt.Errorf("schedules[%d].%w", i, err)
+		}
+		target := strings.TrimSpace(sc.Target)
+		if _, ok := seenIDs[target]; !ok {
+			return cfg, fmt.Errorf("schedules[%d].target %q is not a jenkins id", i, target)
+		}
+		if strings.Trim(strings.TrimSpace(sc.Job), "/") == "" {
+			return cfg, fmt.Errorf("schedules[%d].job is required", i)
+		}
+		cfg.Schedules[i].Name = name
+		cfg.Schedules[i].Target = target
+		cfg.Schedules[i].Job = strings.Trim(strings.TrimSpace(sc.Job), "/")
 	}
 	return cfg, nil
 }
 
-func ResolvePath(flagPath string) (string, error) {
+// normalizeTags trims tags, drops a leading "#" and empties, and removes
+// duplicates while keeping the written order.
+func normalizeTags(tags []string) []string {
+	var out []string
+	for _, tag := range tags {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "#")
+		if tag != "" && !slices.Contains(out, tag) {
+			out = append(out, tag)
+		}
+	}
+	return out
+}
+
+// normalizeAliases trims alias names and job paths. Names may not contain
+// "/", so an alias never shadows a job full name.
+func normalizeAliases(aliases map[string]string) (map[string]string, error) {
+	if len(aliases) == 0 {
+		return nil, nil
+	}
+	out := make(map[string]string, len(aliases))
+	for name, job := range aliases {
+		name = strings.TrimSpace(name)
+		job = strings.Trim(strings.TrimSpace(job), "/")
+		if name == "" || strings.Contains(name, "/") {
+			return nil, fmt.Errorf("alias %q must be a non-empty name without '/'", name)
+		}
+		if job == "" {
+			return nil, fmt.Errorf("alias %q needs a job full name", name)
+		}
+		out[name] = job
+	}
+	return out, nil
+}
+
+// normalizeProxy trims a target's proxy settings and checks the URL is an
+// http(s) proxy or "direct".
+func normalizeProxy(p models.ProxySettings) (models.ProxySettings, error) {
+	p.URL = strings.TrimSpace(p.URL)
+	p.Auth = strings.ToLower(strings.TrimSpace(p.Auth))
+	p.Helper = strings.TrimSpace(p.Helper)
+	if p.URL != "" && p.URL != models.ProxyDirect {
+		u, err := url.Parse(p.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return p, fmt.Errorf("url must be an http(s) URL such as http://proxy.example.com:3128 or %q, got %q", models.ProxyDirect, p.URL)
+		}
+	}
+	switch p.Auth {
+	case "", models.ProxyAuthNTLM, models.ProxyAuthNegotiate:
+	default:
+		return p, fmt.Errorf("auth must be %q or %q", models.ProxyAuthNTLM, models.ProxyAuthNegotiate)
+	}
+	if p.Auth == "" && p.Helper != "" {
+		return p, fmt.Errorf("helper needs auth set to %q or %q", models.ProxyAuthNTLM, models.ProxyAuthNegotiate)
+	}
+	return p, nil
+}
+
+// normalizeRewrite trims a url_rewrites entry to prefixes without a
+// trailing slash, both absolute http(s) URLs.
+func normalizeRewrite(r models.URLRewrite) (from, to string, err error) {
+	from = strings.TrimRight(strings.TrimSpace(r.From), "/")
+	to = strings.TrimRight(strings.TrimSpace(r.To), "/")
+	for _, f := range []struct{ name, value string }{{"from", from}, {"to", to}} {
+		u, err := url.Parse(f.value)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return "", "", fmt.Errorf("%s must be an http(s) URL such as https://jenkins.example.com, got %q", f.name, f.value)
+		}
+	}
+	return from, to, nil
+}
+
+var envRefPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// ExpandEnv replaces ${VAR} and ${VAR:-default} with environment values. An
+// unset variable without a default is an error rather than an empty host.
+func ExpandEnv(s string) (string, error) {
+	var missing []string
+	out := envRefPattern.ReplaceAllStringFunc(s, func(ref string) string {
+		m := envRefPattern.FindStringSubmatch(ref)
+		if v, ok := os.LookupEnv(m[1]); ok && v != "" {
+			return v
+		}
+		if m[2] != "" {
+			return m[3]
+		}
+		missing = append(missing, m[1])
+		return ""
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("$%s is not set", strings.Join(missing, ", $"))
+	}
+	return out, nil
+}
+
+func expandTarget(t *models.JenkinsTarget) error {
+	raw := models.RawTarget{Host: t.Host, Username: t.Username, CredentialRef: t.Credential.Ref}
+	fields := []struct {
+		name string
+		val  *string
+	}{{"host", &t.Host}, {"username", &t.Username}, {"credential.ref", &t.Credential.Ref}}
+	templated := false
+	for _, f := range fields {
+		if !strings.Contains(*f.val, "${") {
+			continue
+		}
+		v, err := ExpandEnv(*f.val)
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.name, err)
+		}
+		*f.val, templated = v, true
+	}
+	if templated {
+		t.Raw = &raw
+	}
+	return nil
+}
+
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// ResolveProfile picks the active profile: the flag, else
+// $JENKINS_TUI_PROFILE. Empty means the default config.
+func ResolveProfile(flagProfile string) (string, error) {
+	profile := strings.TrimSpace(flagProfile)
+	if profile == "" {
+		profile = strings.TrimSpace(os.Getenv("JENKINS_TUI_PROFILE"))
+	}
+	if profile != "" && !profileNamePattern.MatchString(profile) {
+		return "", fmt.Errorf("profile name %q may only contain letters, digits, '.', '_' and '-'", profile)
+	}
+	return profile, nil
+}
+
+// ResolvePath finds the config file: the flag, then $JENKINS_TUI_CONFIG,
+// then profiles/<profile>.yaml or jenkins.yaml in the user config dir.
+func ResolvePath(flagPath, profile string) (string, error) {
 	path := strings.TrimSpace(flagPath)
 	if path == "" {
 		path = strings.TrimSpace(os.Getenv("JENKINS_TUI_CONFIG"))
 	}
 	if path != "" {
+		if profile != "" {
+			return "", fmt.Errorf("profile %q conflicts with config path %s; use one or the other", profile, path)
+		}
 		if !filepath.IsAbs(path) {
 			return "", fmt.Errorf("config path must be absolute: %s", path)
 		}
@@ -68,10 +319,15 @@ func ResolvePath(flagPath string) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("resolve user config dir: %w", err)
 	}
+	if profile != "" {
+		return filepath.Join(base, "jenkins-tui", "profiles", profile+".yaml"), nil
+	}
 	return filepath.Join(base, "jenkins-tui", "jenkins.yaml"), nil
 }
 
-func ResolveCacheDir(flagDir string) (string, error) {
+// ResolveCacheDir gives each profile its own cache, so job indexes and the
+// restored session never mix organizations. An explicit dir is used as is.
+func ResolveCacheDir(flagDir, profile string) (string, error) {
 	path := strings.TrimSpace(flagDir)
 	if path == "" {
 		path = strings.TrimSpace(os.Getenv("JENKINS_TUI_CACHE_DIR"))
@@ -86,5 +342,30 @@ func ResolveCacheDir(flagDir string) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("resolve user cache dir: %w", err)
 	}
+	if profile != "" {
+		return filepath.Join(base, "jenkins-tui", "profiles", profile), nil
+	}
 	return filepath.Join(base, "jenkins-tui"), nil
 }
+
+// ListProfiles names the profiles that have a config file.
+func ListProfiles() ([]string, error) {
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return nil, fmt.Errorf("resolve user config dir: %w", err)
+	}
+	entries, err := os.ReadDir(filepath.Join(base, "jenkins-tui", "profiles"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if name, ok := strings.CutSuffix(e.Name(), ".yaml"); ok && !e.IsDir() {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
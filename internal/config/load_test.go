@@ -59,50 +59,382 @@ jenkins:
 }
 
 func TestResolvePathPrecedence(t *testing.T) {
-	t.Setenv("JENKINS_TUI_CONFIG", "/tmp/from-env.yaml")
-	got, err := ResolvePath("/tmp/from-flag.yaml")
+	dir := t.TempDir()
+	fromEnv, fromFlag := filepath.Join(dir, "from-env.yaml"), filepath.Join(dir, "from-flag.yaml")
+	t.Setenv("JENKINS_TUI_CONFIG", fromEnv)
+	got, err := ResolvePath(fromFlag, "")
 	if err != nil {
 		t.Fatalf("ResolvePath: %v", err)
 	}
-	if got != "/tmp/from-flag.yaml" {
+	if got != fromFlag {
 		t.Fatalf("expected flag path, got %q", got)
 	}
 
-	got, err = ResolvePath("")
+	got, err = ResolvePath("", "")
 	if err != nil {
 		t.Fatalf("ResolvePath env fallback: %v", err)
 	}
-	if got != "/tmp/from-env.yaml" {
+	if got != fromEnv {
 		t.Fatalf("expected env path, got %q", got)
 	}
 }
 
 func TestResolvePathRejectsRelative(t *testing.T) {
-	if _, err := ResolvePath("relative.yaml"); err == nil {
+	if _, err := ResolvePath("relative.yaml", ""); err == nil {
 		t.Fatalf("expected error for relative path")
 	}
 }
 
 func TestResolveCacheDirPrecedence(t *testing.T) {
-	t.Setenv("JENKINS_TUI_CACHE_DIR", "/tmp/cache-env")
-	got, err := ResolveCacheDir("/tmp/cache-flag")
+	dir := t.TempDir()
+	fromEnv, fromFlag := filepath.Join(dir, "cache-env"), filepath.Join(dir, "cache-flag")
+	t.Setenv("JENKINS_TUI_CACHE_DIR", fromEnv)
+	got, err := ResolveCacheDir(fromFlag, "")
 	if err != nil {
 		t.Fatalf("ResolveCacheDir: %v", err)
 	}
-	if got != "/tmp/cache-flag" {
+	if got != fromFlag {
 		t.Fatalf("expected flag cache dir, got %q", got)
 	}
-	got, err = ResolveCacheDir("")
+	got, err = ResolveCacheDir("", "")
 	if err != nil {
 		t.Fatalf("ResolveCacheDir env fallback: %v", err)
 	}
-	if got != "/tmp/cache-env" {
+	if got != fromEnv {
 		t.Fatalf("expected env cache dir, got %q", got)
 	}
 }
 
 func TestResolveCacheDirRejectsRelative(t *testing.T) {
-	if _, err := ResolveCacheDir("cache"); err == nil {
+	if _, err := ResolveCacheDir("cache", ""); err == nil {
 		t.Fatalf("expected error for relative cache dir")
 	}
 }
+
+func TestLoadAllowsAuthCommandWithoutCredential(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "jenkins.yaml")
+	content := `
+jenkins:
+  - id: sso
+    host: https://jenkins.example.com
+    username: ci-user
+    auth_command: "  corp-sso jenkins-token  "
+`
+	if err := os.WriteFile(path, []byte(strings.TrimSpace(content)), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got := cfg.Jenkins[0].AuthCommand; got != "corp-sso jenkins-token" {
+		t.Fatalf("expected trimmed auth_command, got %q", got)
+	}
+}
+
+func TestLoadKeybindingsAcceptsScalarOrList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jenkins.yaml")
+	data := "jenkins: []\nkeybindings:\n  delete_server: D\n  goto_job: [':', ctrl+g]\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got := cfg.Keybindings["delete_server"]; len(got) != 1 || got[0] != "D" {
+		t.Fatalf("unexpected delete_server keys %v", got)
+	}
+	if got := cfg.Keybindings["goto_job"]; len(got) != 2 || got[1] != "ctrl+g" {
+		t.Fatalf("unexpected goto_job keys %v", got)
+	}
+}
+
+func TestLoadRejectsUnknownLayout(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jenkins.yaml")
+	if err := os.WriteFile(path, []byte("jenkins: []\nlayout: columns\n"), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "layout") {
+		t.Fatalf("expected layout error, got %v", err)
+	}
+}
+
+func TestLoadRejectsUnknownTimeFormats(t *testing.T) {
+	for field, value := range map[string]string{"time_style": "fuzzy", "clock": "36h", "timezone": "Mars/Olympus", "duration_style": "long"} {
+		path := filepath.Join(t.TempDir(), "jenkins.yaml")
+		if err := os.WriteFile(path, []byte("jenkins: []\n"+field+": "+value+"\n"), 0o600); err != nil {
+			t.Fatalf("write config: %v", err)
+		}
+		if _, err := Load(path); err == nil || !strings.Contains(err.Error(), field) {
+			t.Fatalf("expected %s error, got %v", field, err)
+		}
+	}
+}
+
+func TestLoadNormalizesAliases(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jenkins.yaml")
+	content := "jenkins:\n  - id: prod\n    host: https://ci.example.com\n    username: me\n    credential: {type: env, ref: TOKEN}\n    aliases:\n      deploy-prod: /platform/prod/deploy/\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got := cfg.Jenkins[0].ResolveAlias("deploy-prod"); got != "platform/prod/deploy" {
+		t.Fatalf("expected alias to resolve to the trimmed full name, got %q", got)
+	}
+	if got := cfg.Jenkins[0].ResolveAlias("platform/other"); got != "platform/other" {
+		t.Fatalf("non-aliases should pass through, got %q", got)
+	}
+
+	bad := strings.Replace(content, "deploy-prod:", "prod/deploy:", 1)
+	if err := os.WriteFile(path, []byte(bad), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "aliases") {
+		t.Fatalf("expected alias name error, got %v", err)
+	}
+}
+
+func TestLoadValidatesURLRewrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jenkins.yaml")
+	write := func(from, to string) {
+		t.Helper()
+		content := "jenkins:\n  - id: ci\n    host: https://ci.example.com\n    username: me\n    credential: {type: env, ref: TOKEN}\n" +
+			"    url_rewrites:\n      - from: " + from + "\n        to: " + to + "\n"
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("write config: %v", err)
+		}
+	}
+	write(" http://jenkins-01.corp:8080/ ", "https://ci.example.com/")
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got := cfg.Jenkins[0].URLRewrites[0]; got.From != "http://jenkins-01.corp:8080" || got.To != "https://ci.example.com" {
+		t.Fatalf("expected trimmed prefixes, got %+v", got)
+	}
+	if got := cfg.Jenkins[0].RewriteURL("http://jenkins-01.corp:8080/job/a/3/"); got != "https://ci.example.com/job/a/3/" {
+		t.Fatalf("RewriteURL = %q", got)
+	}
+	write("jenkins-01.corp", "https://ci.example.com")
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "url_rewrites[0].from") {
+		t.Fatalf("expected a from error, got %v", err)
+	}
+	write("http://jenkins-01.corp:8080", "http://ci.example.com")
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "allow_insecure_http") {
+		t.Fatalf("expected a plain http error for the rewritten prefix, got %v", err)
+	}
+}
+
+func TestLoadValidatesProxySettings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jenkins.yaml")
+	for proxy, wantErr := range map[string]string{
+		"{url: http://proxy.corp:3128, auth: NTLM}": "",
+		"{url: direct}":          "",
+		"{url: proxy.corp:3128}": "proxy.url",
+		"{auth: kerberos}":       "proxy.auth",
+		"{helper: ntlm_auth}":    "proxy.helper",
+	} {
+		content := "jenkins:\n  - id: ci\n    host: https://ci.example.com\n    username: me\n    credential: {type: env, ref: TOKEN}\n    proxy: " + proxy + "\n"
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("write config: %v", err)
+		}
+		cfg, err := Load(path)
+		if wantErr == "" {
+			if err != nil {
+				t.Fatalf("proxy %s should load: %v", proxy, err)
+			}
+			if a := cfg.Jenkins[0].Proxy.Auth; a != "" && a != "ntlm" {
+				t.Fatalf("expected auth to be lowercased, got %q", a)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Fatalf("proxy %s: expected a %s error, got %v", proxy, wantErr, err)
+		}
+	}
+}
+
+func TestLoadAllowsKerberosWithoutCredential(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jenkins.yaml")
+	content := "jenkins:\n  - id: sso\n    host: https://ci.example.com\n    username: me\n    auth: Kerberos\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Jenkins[0].Auth != "kerberos" {
+		t.Fatalf("expected auth to be normalized, got %q", cfg.Jenkins[0].Auth)
+	}
+	if err := os.WriteFile(path, []byte(strings.Replace(content, "Kerberos", "saml", 1)), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "jenkins[0].auth") {
+		t.Fatalf("expected an auth error, got %v", err)
+	}
+}
+
+func TestLoadRequiresOptInForPlainHTTP(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jenkins.yaml")
+	write := func(host, extra string) {
+		t.Helper()
+		content := "jenkins:\n  - id: ci\n    host: " + host + "\n    username: me\n    credential: {type: env, ref: TOKEN}\n" + extra
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("write config: %v", err)
+		}
+	}
+	write("http://ci.example.com", "")
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "allow_insecure_http") {
+		t.Fatalf("expected a plain http error, got %v", err)
+	}
+	write("http://ci.example.com", "    allow_insecure_http: true\n")
+	if _, err := Load(path); err != nil {
+		t.Fatalf("an allowed http host should load: %v", err)
+	}
+	for _, host := range []string{"http://localhost:8080", "http://127.0.0.1:8080", "https://ci.example.com"} {
+		write(host, "")
+		if _, err := Load(path); err != nil {
+			t.Fatalf("%s should load without opting in: %v", host, err)
+		}
+	}
+}
+
+func TestLoadValidatesSchedules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jenkins.yaml")
+	content := "jenkins:\n  - id: prod\n    host: https://ci.example.com\n    username: me\n    credential: {type: env, ref: TOKEN}\nschedules:\n  - name: nightly\n    cron: \"0 2 * * *\"\n    target: prod\n    job: /platform/smoke/\n    params:\n      BRANCH: main\n      REGION: [eu, us]\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	sc := cfg.Schedules[0]
+	if sc.Job != "platform/smoke" || len(sc.Params["BRANCH"]) != 1 || len(sc.Params["REGION"]) != 2 {
+		t.Fatalf("unexpected schedule %+v", sc)
+	}
+
+	for _, tc := range []struct{ from, to, want string }{
+		{"\"0 2 * * *\"", "\"0 25 * * *\"", "cron"},
+		{"target: prod", "target: staging", "target"},
+	} {
+		if err := os.WriteFile(path, []byte(strings.Replace(content, tc.from, tc.to, 1)), 0o600); err != nil {
+			t.Fatalf("write config: %v", err)
+		}
+		if _, err := Load(path); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("expected %s error, got %v", tc.want, err)
+		}
+	}
+}
+
+func TestResolveProfile(t *testing.T) {
+	base := t.TempDir()
+	// Each OS reads its own variables for these dirs; set them all.
+	t.Setenv("HOME", base)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(base, "config"))
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(base, "cache"))
+	t.Setenv("APPDATA", filepath.Join(base, "config"))
+	t.Setenv("LOCALAPPDATA", filepath.Join(base, "cache"))
+	configDir, _ := os.UserConfigDir()
+	cacheDir, _ := os.UserCacheDir()
+	t.Setenv("JENKINS_TUI_CONFIG", "")
+	t.Setenv("JENKINS_TUI_CACHE_DIR", "")
+	t.Setenv("JENKINS_TUI_PROFILE", "work")
+
+	profile, err := ResolveProfile("")
+	if err != nil || profile != "work" {
+		t.Fatalf("expected env profile, got %q, %v", profile, err)
+	}
+	if profile, _ := ResolveProfile("personal"); profile != "personal" {
+		t.Fatalf("flag should win over env, got %q", profile)
+	}
+	if _, err := ResolveProfile("../etc"); err == nil {
+		t.Fatalf("expected error for a profile name with a path")
+	}
+
+	path, err := ResolvePath("", "work")
+	if err != nil || path != filepath.Join(configDir, "jenkins-tui", "profiles", "work.yaml") {
+		t.Fatalf("unexpected profile path %q, %v", path, err)
+	}
+	dir, err := ResolveCacheDir("", "work")
+	if err != nil || dir != filepath.Join(cacheDir, "jenkins-tui", "profiles", "work") {
+		t.Fatalf("unexpected profile cache dir %q, %v", dir, err)
+	}
+	if _, err := ResolvePath(filepath.Join(base, "jenkins.yaml"), "work"); err == nil {
+		t.Fatalf("expected error for both a profile and a config path")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("jenkins: []\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	names, err := ListProfiles()
+	if err != nil || len(names) != 1 || names[0] != "work" {
+		t.Fatalf("ListProfiles = %v, %v", names, err)
+	}
+}
+
+func TestLoadExpandsEnvironmentVariables(t *testing.T) {
+	t.Setenv("JENKINS_HOST", "https://ci.internal")
+	t.Setenv("CI_USER", "robot")
+	t.Setenv("EMPTY_ENV", "")
+	path := filepath.Join(t.TempDir(), "jenkins.yaml")
+	content := `
+jenkins:
+  - id: prod
+    host: ${JENKINS_HOST}/
+    username: ${CI_USER}
+    credential:
+      type: env
+      ref: ${TOKEN_VAR:-JENKINS_TOKEN}
+  - id: plain
+    host: https://plain
+    username: me
+    credential: {type: env, ref: TOKEN}
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	prod := cfg.Jenkins[0]
+	if prod.Host != "https://ci.internal" || prod.Username != "robot" || prod.Credential.Ref != "JENKINS_TOKEN" {
+		t.Fatalf("expected expanded values, got %+v", prod)
+	}
+	if prod.Raw == nil || prod.Raw.Host != "${JENKINS_HOST}/" || cfg.Jenkins[1].Raw != nil {
+		t.Fatalf("only templated targets should keep raw values, got %+v / %+v", prod.Raw, cfg.Jenkins[1].Raw)
+	}
+
+	missing := strings.Replace(content, "${CI_USER}", "${EMPTY_ENV}", 1)
+	if err := os.WriteFile(path, []byte(missing), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "jenkins[0].username: $EMPTY_ENV is not set") {
+		t.Fatalf("expected unset variable error, got %v", err)
+	}
+}
+
+func TestLoadNormalizesTags(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jenkins.yaml")
+	content := "jenkins:\n  - id: prod\n    host: https://jenkins\n    username: u\n    credential: {type: env, ref: TOKEN}\n    tags: [' prod', '#eu', prod, '']\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got := strings.Join(cfg.Jenkins[0].Tags, ","); got != "prod,eu" {
+		t.Fatalf("expected tags prod,eu, got %q", got)
+	}
+}
@@ -4,16 +4,28 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 
 	"jenkins-tui/internal/models"
 )
 
+// Save writes cfg to path atomically, under the same lock as
+// SaveIfUnchanged so concurrent writers do not interleave.
 func Save(path string, cfg models.Config) error {
 	if path == "" {
 		return fmt.Errorf("config path is required")
 	}
+	unlock, err := lockFile(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return save(path, cfg)
+}
+
+func save(path string, cfg models.Config) error {
 	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, 0o700); err != nil {
 		return fmt.Errorf("create config dir %s: %w", dir, err)
@@ -22,14 +34,25 @@ func Save(path string, cfg models.Config) error {
 		return fmt.Errorf("chmod config dir %s: %w", dir, err)
 	}
 
-	type persistedConfig struct {
-		Jenkins []models.JenkinsTarget `yaml:"jenkins"`
+	targets := make([]models.JenkinsTarget, len(cfg.Jenkins))
+	for i, t := range cfg.Jenkins {
+		targets[i] = unexpandTarget(t)
 	}
-	payload, err := yaml.Marshal(persistedConfig{Jenkins: cfg.Jenkins})
+	cfg.Jenkins = targets
+	// Runtime-only fields are tagged yaml:"-" on models.Config.
+	payload, err := yaml.Marshal(cfg)
 	if err != nil {
 		return fmt.Errorf("marshal config: %w", err)
 	}
-	tmp, err := os.CreateTemp(dir, ".jenkins-tui-config-*.yaml")
+	if err := backup(path, payload); err != nil {
+		return err
+	}
+	return replaceFile(path, payload)
+}
+
+// replaceFile swaps payload in for path through a temp file and a rename.
+func replaceFile(path string, payload []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".jenkins-tui-config-*.yaml")
 	if err != nil {
 		return fmt.Errorf("create temp config: %w", err)
 	}
@@ -52,3 +75,20 @@ func Save(path string, cfg models.Config) error {
 	}
 	return nil
 }
+
+// unexpandTarget restores ${VAR} references for fields that still expand to
+// the value in use; a field changed in the app is written as is.
+func unexpandTarget(t models.JenkinsTarget) models.JenkinsTarget {
+	if t.Raw == nil {
+		return t
+	}
+	restore := func(raw string, val *string) {
+		if expanded, err := ExpandEnv(raw); err == nil && strings.TrimRight(expanded, "/") == strings.TrimRight(*val, "/") {
+			*val = raw
+		}
+	}
+	restore(t.Raw.Host, &t.Host)
+	restore(t.Raw.Username, &t.Username)
+	restore(t.Raw.CredentialRef, &t.Credential.Ref)
+	return t
+}
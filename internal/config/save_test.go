@@ -1,10 +1,13 @@
 package config
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"jenkins-tui/internal/models"
 )
@@ -37,7 +40,362 @@ func TestSaveWritesConfig(t *testing.T) {
 	if !strings.Contains(text, "credential:") {
 		t.Fatalf("expected credential block in config, got: %s", text)
 	}
-	if strings.Contains(text, "timeout") || strings.Contains(text, "cache_dir") {
+	if strings.Contains(text, "timeout") || strings.Contains(text, "cache_dir") || strings.Contains(text, "transport") {
 		t.Fatalf("runtime-only fields should not be persisted")
 	}
 }
+
+func TestSaveKeepsBookmarksAndKeybindings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jenkins.yaml")
+	cfg := models.Config{
+		Jenkins: []models.JenkinsTarget{{
+			ID:         "prod",
+			Name:       "prod",
+			Host:       "https://jenkins.example.com",
+			Username:   "ci-user",
+			Credential: models.Credential{Type: models.CredentialTypeEnv, Ref: "JENKINS_TOKEN"},
+			Bookmarks:  []string{"platform/infra/deploy"},
+			Watches:    []string{"platform/infra/nightly"},
+			Transport:  models.TransportTuning{MaxIdleConnsPerHost: 8, IdleConnTimeout: 2 * time.Minute},
+		}},
+		Keybindings:        map[string]models.KeyList{"quit": {"Q"}},
+		CredentialCacheTTL: time.Hour,
+		PrefetchFolders:    4,
+		MaxResponseMB:      16,
+		StripLogColors:     true,
+		LogDir:             "/tmp/jenkins-logs",
+		TimeStyle:          models.TimeStyleRelative,
+		Clock:              models.Clock12h,
+		Timezone:           "UTC",
+		DurationStyle:      models.DurationStyleClock,
+		Schedules: []models.Schedule{{
+			Name: "nightly", Cron: "30 2 * * mon-fri", Target: "prod", Job: "platform/infra/smoke",
+			Params: map[string]models.ParamValues{"REGION": {"eu", "us"}},
+		}},
+	}
+	if err := Save(path, cfg); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got := loaded.Jenkins[0].Bookmarks; len(got) != 1 || got[0] != "platform/infra/deploy" {
+		t.Fatalf("expected bookmark to round-trip, got %v", got)
+	}
+	if got := loaded.Jenkins[0].Watches; len(got) != 1 || got[0] != "platform/infra/nightly" {
+		t.Fatalf("expected watch list to round-trip, got %v", got)
+	}
+	if got := loaded.Keybindings["quit"]; len(got) != 1 || got[0] != "Q" {
+		t.Fatalf("expected keybindings to survive save, got %v", loaded.Keybindings)
+	}
+	if got := loaded.Jenkins[0].Transport; got.MaxIdleConnsPerHost != 8 || got.IdleConnTimeout != 2*time.Minute {
+		t.Fatalf("expected transport tuning to survive save, got %+v", got)
+	}
+	if loaded.CredentialCacheTTL != time.Hour {
+		t.Fatalf("expected credential_cache_ttl to survive save, got %v", loaded.CredentialCacheTTL)
+	}
+	if loaded.PrefetchFolders != 4 {
+		t.Fatalf("expected prefetch_folders to survive save, got %d", loaded.PrefetchFolders)
+	}
+	if loaded.MaxResponseMB != 16 {
+		t.Fatalf("expected max_response_mb to survive save, got %d", loaded.MaxResponseMB)
+	}
+	if !loaded.StripLogColors {
+		t.Fatalf("expected strip_log_colors to survive save")
+	}
+	if loaded.LogDir != "/tmp/jenkins-logs" {
+		t.Fatalf("expected log_dir to survive save, got %q", loaded.LogDir)
+	}
+	if loaded.TimeStyle != models.TimeStyleRelative || loaded.Clock != models.Clock12h || loaded.Timezone != "UTC" || loaded.DurationStyle != models.DurationStyleClock {
+		t.Fatalf("expected time formats to survive save, got %q %q %q %q", loaded.TimeStyle, loaded.Clock, loaded.Timezone, loaded.DurationStyle)
+	}
+	if got := loaded.Schedules; len(got) != 1 || got[0].Cron != "30 2 * * mon-fri" || len(got[0].Params["REGION"]) != 2 {
+		t.Fatalf("expected schedules to survive save, got %+v", got)
+	}
+	if b, _ := os.ReadFile(path); !strings.Contains(string(b), "credential_cache_ttl: 1h0m0s") {
+		t.Fatalf("expected a readable duration, got:\n%s", b)
+	}
+}
+
+func TestSaveRoundTripsEveryField(t *testing.T) {
+	off := false
+	cfg := models.Config{
+		Jenkins: []models.JenkinsTarget{{
+			ID:                    "prod",
+			Name:                  "Production",
+			Host:                  "https://jenkins.example.com",
+			Username:              "ci-user",
+			Credential:            models.Credential{Type: models.CredentialTypeEnv, Ref: "JENKINS_TOKEN"},
+			InsecureSkipTLSVerify: true,
+			AllowInsecureHTTP:     true,
+			AuthCommand:           "vault read -field=token jenkins",
+			Auth:                  models.AuthKerberos,
+			KerberosSPN:           "HTTP/jenkins-lb.example.com",
+			Bookmarks:             []string{"platform/infra"},
+			Watches:               []string{"platform/infra/nightly"},
+			Tags:                  []string{"prod", "eu"},
+			Aliases:               map[string]string{"deploy": "platform/infra/deploy"},
+			Transport:             models.TransportTuning{MaxIdleConnsPerHost: 8, IdleConnTimeout: 2 * time.Minute, ForceAttemptHTTP2: &off},
+			Proxy:                 models.ProxySettings{URL: "http://proxy.example.com:3128", Auth: models.ProxyAuthNTLM, Helper: "ntlm_auth --helper-protocol=ntlmssp-client-1"},
+			URLRewrites:           []models.URLRewrite{{From: "http://jenkins.internal:8080", To: "https://jenkins.example.com"}},
+		}},
+		Keybindings:        map[string]models.KeyList{"quit": {"Q"}, "goto_job": {":", "ctrl+p"}},
+		Layout:             models.LayoutSplit,
+		CredentialCacheTTL: time.Hour,
+		AuditLog:           "/var/log/jenkins-tui/audit.jsonl",
+		PrefetchFolders:    4,
+		MaxResponseMB:      16,
+		StripLogColors:     true,
+		LogDir:             "/tmp/jenkins-logs",
+		TimeStyle:          models.TimeStyleRelative,
+		Clock:              models.Clock12h,
+		Timezone:           "UTC",
+		DurationStyle:      models.DurationStyleClock,
+		Schedules: []models.Schedule{{
+			Name: "nightly", Cron: "30 2 * * mon-fri", Target: "prod", Job: "platform/infra/smoke",
+			Params:    map[string]models.ParamValues{"REGION": {"eu", "us"}},
+			ReportDir: "/tmp/jenkins-reports",
+		}},
+	}
+	// A field added to the config must be set here, or it is not tested.
+	requireAllSet(t, reflect.ValueOf(cfg), "Config")
+
+	path := filepath.Join(t.TempDir(), "jenkins.yaml")
+	if err := Save(path, cfg); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	loaded.Timeout, loaded.ConfigPath, loaded.CacheDir = 0, "", ""
+	if !reflect.DeepEqual(loaded, cfg) {
+		t.Fatalf("config changed in a save and load:\n got %+v\nwant %+v", loaded, cfg)
+	}
+}
+
+// requireAllSet fails for every persisted field of v left at its zero
+// value, descending into structs and the first element of slices.
+func requireAllSet(t *testing.T, v reflect.Value, path string) {
+	t.Helper()
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if tag := field.Tag.Get("yaml"); tag == "-" || tag == "" {
+				continue
+			}
+			requireAllSet(t, v.Field(i), path+"."+field.Name)
+		}
+	case reflect.Slice:
+		if v.Len() == 0 {
+			t.Errorf("%s is empty", path)
+			return
+		}
+		requireAllSet(t, v.Index(0), path+"[0]")
+	default:
+		if v.IsZero() {
+			t.Errorf("%s is not set", path)
+		}
+	}
+}
+
+func TestSaveKeepsEnvironmentReferences(t *testing.T) {
+	t.Setenv("JENKINS_HOST", "https://ci.internal")
+	t.Setenv("CI_USER", "robot")
+	path := filepath.Join(t.TempDir(), "jenkins.yaml")
+	content := "jenkins:\n  - id: prod\n    host: ${JENKINS_HOST}\n    username: ${CI_USER}\n    credential: {type: env, ref: TOKEN}\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	cfg.Jenkins[0].Username = "someone-else"
+	if err := Save(path, cfg); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := string(b)
+	if !strings.Contains(text, "host: ${JENKINS_HOST}") || !strings.Contains(text, "username: someone-else") {
+		t.Fatalf("unchanged fields should keep their references, changed ones their new value, got:\n%s", text)
+	}
+}
+
+func TestSaveIfUnchangedRefusesConcurrentWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jenkins.yaml")
+	cfg := models.Config{Jenkins: []models.JenkinsTarget{{ID: "prod", Name: "prod", Host: "https://jenkins.example.com", Username: "u", Credential: models.Credential{Type: models.CredentialTypeEnv, Ref: "TOKEN"}}}}
+	first, err := SaveIfUnchanged(path, cfg, "")
+	if err != nil || first == "" {
+		t.Fatalf("first save: %q %v", first, err)
+	}
+	if _, err := SaveIfUnchanged(path, cfg, ""); !errors.Is(err, ErrChanged) {
+		t.Fatalf("expected ErrChanged for a stale fingerprint, got %v", err)
+	}
+	if _, err := os.Stat(path + ".lock"); !os.IsNotExist(err) {
+		t.Fatalf("the lock should be released, got %v", err)
+	}
+	if err := os.WriteFile(path+".lock", nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-time.Minute)
+	os.Chtimes(path+".lock", old, old)
+	if _, err := SaveIfUnchanged(path, cfg, first); err != nil {
+		t.Fatalf("a stale lock should be broken: %v", err)
+	}
+}
+
+func TestUnlockLeavesALockTakenByAnotherInstance(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jenkins.yaml")
+	unlock, err := lockFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Another instance broke our lock as stale and took its own.
+	if err := os.WriteFile(path+".lock", []byte("999999\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	unlock()
+	if b, err := os.ReadFile(path + ".lock"); err != nil || string(b) != "999999\n" {
+		t.Fatalf("the other instance's lock should stay, got %q %v", b, err)
+	}
+}
+
+func TestCloneCopiesNestedFields(t *testing.T) {
+	force := true
+	cfg := models.Config{
+		Jenkins: []models.JenkinsTarget{{
+			ID:          "prod",
+			URLRewrites: []models.URLRewrite{{From: "http://internal", To: "https://public"}},
+			Transport:   models.TransportTuning{ForceAttemptHTTP2: &force},
+		}},
+		Keybindings: map[string]models.KeyList{"quit": {"q"}},
+		Schedules:   []models.Schedule{{Name: "nightly", Params: map[string]models.ParamValues{"REGION": {"eu"}}}},
+	}
+	c := Clone(cfg)
+	c.Jenkins[0].URLRewrites[0].To = "changed"
+	*c.Jenkins[0].Transport.ForceAttemptHTTP2 = false
+	c.Keybindings["quit"][0] = "x"
+	c.Schedules[0].Params["REGION"][0] = "us"
+	if cfg.Jenkins[0].URLRewrites[0].To != "https://public" || !force || cfg.Keybindings["quit"][0] != "q" || cfg.Schedules[0].Params["REGION"][0] != "eu" {
+		t.Fatalf("editing the clone changed the original: %+v", cfg)
+	}
+}
+
+func TestMergeConcurrentKeepsBothSides(t *testing.T) {
+	target := func(id, name string) models.JenkinsTarget {
+		return models.JenkinsTarget{ID: id, Name: name, Host: "https://" + id, Username: "u"}
+	}
+	base := models.Config{Jenkins: []models.JenkinsTarget{target("a", "a"), target("b", "b"), target("c", "c")}}
+	ours := models.Config{Jenkins: []models.JenkinsTarget{target("a", "a-ours"), target("c", "c-ours"), target("new-ours", "x")}, Layout: models.LayoutSplit}
+	theirs := models.Config{Jenkins: []models.JenkinsTarget{target("a", "a"), target("b", "b-theirs"), target("c", "c-theirs"), target("new-theirs", "y")}}
+
+	merged, conflicts := MergeConcurrent(base, ours, theirs, false)
+	var names []string
+	for _, tgt := range merged.Jenkins {
+		names = append(names, tgt.ID+"="+tgt.Name)
+	}
+	// b: we deleted it, they renamed it; c: both renamed it.
+	want := []string{"a=a-ours", "b=b-theirs", "c=c-theirs", "new-theirs=y", "new-ours=x"}
+	if strings.Join(names, " ") != strings.Join(want, " ") {
+		t.Fatalf("merged %v, want %v", names, want)
+	}
+	if strings.Join(conflicts, ",") != "b,c" || merged.Layout != models.LayoutSplit {
+		t.Fatalf("unexpected conflicts %v or layout %q", conflicts, merged.Layout)
+	}
+
+	merged, _ = MergeConcurrent(base, ours, theirs, true)
+	names = names[:0]
+	for _, tgt := range merged.Jenkins {
+		names = append(names, tgt.ID+"="+tgt.Name)
+	}
+	want = []string{"a=a-ours", "c=c-ours", "new-theirs=y", "new-ours=x"}
+	if strings.Join(names, " ") != strings.Join(want, " ") {
+		t.Fatalf("preferring ours merged %v, want %v", names, want)
+	}
+}
+
+func TestSaveKeepsBackupsForUndo(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jenkins.yaml")
+	target := func(id string) models.JenkinsTarget {
+		return models.JenkinsTarget{ID: id, Name: id, Host: "https://" + id, Username: "u", Credential: models.Credential{Type: models.CredentialTypeEnv, Ref: "TOKEN"}}
+	}
+	if _, err := Undo(path); !errors.Is(err, ErrNoBackup) {
+		t.Fatalf("expected ErrNoBackup without history, got %v", err)
+	}
+	versions := [][]models.JenkinsTarget{{target("a")}, {target("a"), target("b")}, {target("b")}}
+	for _, v := range versions {
+		if err := Save(path, models.Config{Jenkins: v}); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+		time.Sleep(2 * time.Millisecond)
+	}
+	if err := Save(path, models.Config{Jenkins: versions[2]}); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	backups, err := Backups(path)
+	if err != nil || len(backups) != 2 {
+		t.Fatalf("expected one backup per change, got %d (%v)", len(backups), err)
+	}
+
+	ids := func() string {
+		cfg, err := Load(path)
+		if err != nil {
+			t.Fatalf("Load: %v", err)
+		}
+		var out []string
+		for _, t := range cfg.Jenkins {
+			out = append(out, t.ID)
+		}
+		return strings.Join(out, ",")
+	}
+	if _, err := Undo(path); err != nil || ids() != "a,b" {
+		t.Fatalf("first undo should restore a,b, got %s (%v)", ids(), err)
+	}
+	if _, err := Undo(path); err != nil || ids() != "a" {
+		t.Fatalf("second undo should restore a, got %s (%v)", ids(), err)
+	}
+	if _, err := Undo(path); !errors.Is(err, ErrNoBackup) {
+		t.Fatalf("expected history to run out, got %v", err)
+	}
+}
+
+func TestUndoCapsUndoneFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jenkins.yaml")
+	dir := backupDir(path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	oldest := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < maxBackups+5; i++ {
+		name := filepath.Base(path) + "." + oldest.Add(time.Duration(i)*time.Second).Format(backupStamp) + undoneSuffix
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("jenkins: []\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	target := models.JenkinsTarget{ID: "a", Name: "a", Host: "https://a", Username: "u", Credential: models.Credential{Type: models.CredentialTypeEnv, Ref: "TOKEN"}}
+	if err := Save(path, models.Config{}); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if err := Save(path, models.Config{Jenkins: []models.JenkinsTarget{target}}); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if _, err := Undo(path); err != nil {
+		t.Fatalf("Undo: %v", err)
+	}
+	undone, err := listBackups(path, true)
+	if err != nil || len(undone) != maxBackups {
+		t.Fatalf("expected %d .undone files kept, got %d (%v)", maxBackups, len(undone), err)
+	}
+	if !undone[len(undone)-1].Saved.After(oldest.Add(5 * time.Second)) {
+		t.Fatalf("the oldest .undone files should go first, kept %v", undone[len(undone)-1].Saved)
+	}
+	if backups, err := Backups(path); err != nil || len(backups) != 0 {
+		t.Fatalf(".undone files should not count as backups, got %d (%v)", len(backups), err)
+	}
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"jenkins-tui/internal/models"
+)
+
+const exportHeader = `# Shared jenkins-tui servers, written by "jenkins-tui config export".
+# No tokens are included; "jenkins-tui config import" asks for them.
+`
+
+// Export renders cfg's servers as YAML that is safe to share: tokens never
+// live in the config, keyring refs are reset to jenkins-tui/<id> so they do
+// not leak one person's naming, and bookmarks, keybindings, and layout stay
+// personal. ${VAR} references are written as they were read.
+func Export(cfg models.Config) ([]byte, error) {
+	type sharedConfig struct {
+		Jenkins []models.JenkinsTarget `yaml:"jenkins"`
+	}
+	targets := make([]models.JenkinsTarget, len(cfg.Jenkins))
+	for i, t := range cfg.Jenkins {
+		t = unexpandTarget(t)
+		if t.Credential.Type == models.CredentialTypeKeyring {
+			t.Credential.Ref = "jenkins-tui/" + t.ID
+		}
+		t.Bookmarks = nil
+		t.Watches = nil
+		t.Raw = nil
+		targets[i] = t
+	}
+	payload, err := yaml.Marshal(sharedConfig{Jenkins: targets})
+	if err != nil {
+		return nil, fmt.Errorf("marshal config: %w", err)
+	}
+	return append([]byte(exportHeader), payload...), nil
+}
+
+// MergeResult lists what MergeShared changed, by target id.
+type MergeResult struct {
+	Added   []models.JenkinsTarget
+	Updated []string
+	Skipped []string
+}
+
+// MergeShared folds shared servers into cfg. A server with a known id takes
+// the shared host, name, username, TLS, auth_command, tags, and URL rewrites
+// plus any aliases it lacks, but keeps its local credential, bookmarks,
+// watches, and alias definitions; a new id whose host and username are
+// already configured under another id is skipped; the rest are appended.
+func MergeShared(cfg models.Config, shared []models.JenkinsTarget) (models.Config, MergeResult) {
+	var res MergeResult
+	merged := append([]models.JenkinsTarget(nil), cfg.Jenkins...)
+	for _, s := range shared {
+		idx := -1
+		for i, t := range merged {
+			if t.ID == s.ID {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			if configured(merged, models.JenkinsTarget{Host: strings.TrimRight(s.Host, "/"), Username: s.Username}) {
+				res.Skipped = append(res.Skipped, s.ID)
+				continue
+			}
+			s.Bookmarks = nil
+			s.Watches = nil
+			merged = append(merged, s)
+			res.Added = append(res.Added, s)
+			continue
+		}
+		t := merged[idx]
+		aliases := map[string]string{}
+		for name, job := range s.Aliases {
+			aliases[name] = job
+		}
+		for name, job := range t.Aliases {
+			aliases[name] = job
+		}
+		if len(aliases) == 0 {
+			aliases = nil
+		}
+		if len(aliases) == len(t.Aliases) && t.Name == s.Name && t.Host == s.Host && t.Username == s.Username &&
+			t.InsecureSkipTLSVerify == s.InsecureSkipTLSVerify && t.AllowInsecureHTTP == s.AllowInsecureHTTP && t.AuthCommand == s.AuthCommand &&
+			strings.Join(t.Tags, ",") == strings.Join(s.Tags, ",") && slices.Equal(t.URLRewrites, s.URLRewrites) {
+			continue
+		}
+		t.Name, t.Host, t.Username = s.Name, s.Host, s.Username
+		t.InsecureSkipTLSVerify, t.AllowInsecureHTTP, t.AuthCommand, t.Tags = s.InsecureSkipTLSVerify, s.AllowInsecureHTTP, s.AuthCommand, s.Tags
+		t.Aliases = aliases
+		t.URLRewrites = s.URLRewrites
+		raw := models.RawTarget{Host: s.Host, Username: s.Username, CredentialRef: t.Credential.Ref}
+		if s.Raw != nil {
+			raw.Host, raw.Username = s.Raw.Host, s.Raw.Username
+		}
+		if t.Raw != nil {
+			raw.CredentialRef = t.Raw.CredentialRef
+		}
+		t.Raw = &raw
+		merged[idx] = t
+		res.Updated = append(res.Updated, s.ID)
+	}
+	cfg.Jenkins = merged
+	return cfg, res
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"jenkins-tui/internal/models"
+)
+
+func TestExportDropsPersonalFields(t *testing.T) {
+	t.Setenv("JENKINS_HOST", "https://ci.internal")
+	path := filepath.Join(t.TempDir(), "jenkins.yaml")
+	content := `jenkins:
+  - id: prod
+    host: ${JENKINS_HOST}
+    username: me
+    credential: {type: keyring, ref: my-keychain/prod-token}
+    bookmarks: [infra/deploy]
+    tags: [prod]
+  - id: dev
+    host: https://dev.example.com
+    username: me
+    credential: {type: env, ref: DEV_TOKEN}
+keybindings:
+  quit: Q
+layout: split
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	b, err := Export(cfg)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	text := string(b)
+	for _, want := range []string{"host: ${JENKINS_HOST}", "ref: jenkins-tui/prod", "ref: DEV_TOKEN", "- prod"} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("export should contain %q:\n%s", want, text)
+		}
+	}
+	for _, unwanted := range []string{"my-keychain", "infra/deploy", "keybindings", "layout"} {
+		if strings.Contains(text, unwanted) {
+			t.Fatalf("export should not contain %q:\n%s", unwanted, text)
+		}
+	}
+
+	out := filepath.Join(t.TempDir(), "shared.yaml")
+	if err := os.WriteFile(out, b, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(out); err != nil {
+		t.Fatalf("export should load as a config: %v", err)
+	}
+}
+
+func TestMergeShared(t *testing.T) {
+	cfg := models.Config{Jenkins: []models.JenkinsTarget{
+		{
+			ID: "prod", Name: "prod", Host: "https://old.example.com", Username: "me",
+			Credential: models.Credential{Type: models.CredentialTypeKeyring, Ref: "mine/prod"},
+			Bookmarks:  []string{"infra"},
+		},
+		{ID: "local", Name: "local", Host: "https://dev.example.com", Username: "me"},
+	}}
+	shared := []models.JenkinsTarget{
+		{ID: "prod", Name: "Production", Host: "https://ci.example.com", Username: "me", Tags: []string{"prod"},
+			Credential: models.Credential{Type: models.CredentialTypeKeyring, Ref: "jenkins-tui/prod"}},
+		{ID: "dev", Name: "dev", Host: "https://dev.example.com", Username: "me"},
+		{ID: "qa", Name: "qa", Host: "https://qa.example.com", Username: "me",
+			Credential: models.Credential{Type: models.CredentialTypeKeyring, Ref: "jenkins-tui/qa"}},
+	}
+	merged, res := MergeShared(cfg, shared)
+	if len(merged.Jenkins) != 3 {
+		t.Fatalf("expected qa to be appended, got %+v", merged.Jenkins)
+	}
+	prod := merged.Jenkins[0]
+	if prod.Host != "https://ci.example.com" || prod.Name != "Production" || len(prod.Tags) != 1 {
+		t.Fatalf("shared fields should update prod, got %+v", prod)
+	}
+	if prod.Credential.Ref != "mine/prod" || len(prod.Bookmarks) != 1 {
+		t.Fatalf("local credential and bookmarks should be kept, got %+v", prod)
+	}
+	if len(res.Added) != 1 || res.Added[0].ID != "qa" || len(res.Updated) != 1 || len(res.Skipped) != 1 || res.Skipped[0] != "dev" {
+		t.Fatalf("unexpected merge result: %+v", res)
+	}
+
+	_, again := MergeShared(merged, shared)
+	if len(again.Added) != 0 || len(again.Updated) != 0 {
+		t.Fatalf("merging twice should change nothing, got %+v", again)
+	}
+}
@@ -0,0 +1,61 @@
+package credentials
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"jenkins-tui/internal/models"
+)
+
+// AuthCommand builds the shell invocation for a target's auth_command. The
+// command may prompt on stderr/stdin; the token must be printed to stdout.
+func AuthCommand(target models.JenkinsTarget) *exec.Cmd {
+	script := strings.TrimSpace(target.AuthCommand)
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", script)
+	} else {
+		cmd = exec.Command("sh", "-c", script)
+	}
+	cmd.Env = append(os.Environ(),
+		"JENKINS_TUI_TARGET="+target.ID,
+		"JENKINS_TUI_HOST="+target.Host,
+		"JENKINS_TUI_USERNAME="+target.Username,
+	)
+	return cmd
+}
+
+func ParseAuthCommandOutput(out []byte) (string, error) {
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	token := strings.TrimSpace(lines[len(lines)-1])
+	if token == "" {
+		return "", fmt.Errorf("auth command printed no token")
+	}
+	return token, nil
+}
+
+// RunAuthCommand runs the target's auth_command attached to the current
+// terminal and remembers the resulting token for the rest of the session.
+func (m *Manager) RunAuthCommand(target models.JenkinsTarget) (string, error) {
+	if strings.TrimSpace(target.AuthCommand) == "" {
+		return "", fmt.Errorf("target %q has no auth_command", target.Name)
+	}
+	var out bytes.Buffer
+	cmd := AuthCommand(target)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = &out
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("run auth command for target %q: %w", target.Name, err)
+	}
+	token, err := ParseAuthCommandOutput(out.Bytes())
+	if err != nil {
+		return "", err
+	}
+	m.Remember(target, token)
+	return token, nil
+}
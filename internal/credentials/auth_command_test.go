@@ -0,0 +1,59 @@
+package credentials
+
+import (
+	"errors"
+	"runtime"
+	"testing"
+
+	"jenkins-tui/internal/models"
+)
+
+func TestParseAuthCommandOutputUsesLastLine(t *testing.T) {
+	got, err := ParseAuthCommandOutput([]byte("Opening browser...\n  sso-token-123  \n"))
+	if err != nil {
+		t.Fatalf("ParseAuthCommandOutput: %v", err)
+	}
+	if got != "sso-token-123" {
+		t.Fatalf("expected last line token, got %q", got)
+	}
+	if _, err := ParseAuthCommandOutput([]byte("\n\n")); err == nil {
+		t.Fatalf("expected error for empty output")
+	}
+}
+
+func TestResolveRequiresAuthWhenStoredCredentialMissing(t *testing.T) {
+	m := &Manager{keyring: NewEnvStore(), env: NewEnvStore()}
+	target := models.JenkinsTarget{
+		ID:          "sso",
+		Name:        "sso",
+		AuthCommand: "echo token",
+		Credential:  models.Credential{Type: models.CredentialTypeEnv, Ref: "JENKINS_TUI_TEST_SSO_MISSING"},
+	}
+	if _, err := m.Resolve(target); !errors.Is(err, ErrAuthRequired) {
+		t.Fatalf("expected ErrAuthRequired, got %v", err)
+	}
+	m.Remember(target, "fresh-token")
+	got, err := m.Resolve(target)
+	if err != nil || got != "fresh-token" {
+		t.Fatalf("expected remembered token, got %q (%v)", got, err)
+	}
+	m.Forget(target)
+	if _, err := m.Resolve(target); !errors.Is(err, ErrAuthRequired) {
+		t.Fatalf("expected ErrAuthRequired after Forget, got %v", err)
+	}
+}
+
+func TestRunAuthCommandCapturesStdout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	m := &Manager{keyring: NewEnvStore(), env: NewEnvStore()}
+	target := models.JenkinsTarget{ID: "sso", Name: "sso", AuthCommand: `echo "prompt" >&2; echo "tok-$JENKINS_TUI_TARGET"`}
+	got, err := m.RunAuthCommand(target)
+	if err != nil {
+		t.Fatalf("RunAuthCommand: %v", err)
+	}
+	if got != "tok-sso" {
+		t.Fatalf("expected token from stdout, got %q", got)
+	}
+}
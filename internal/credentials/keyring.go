@@ -10,6 +10,15 @@ import (
 
 const serviceName = "com.bnainar.jenkins-tui"
 
+const (
+	probeRef   = "__jenkins_tui_probe__"
+	probeValue = "probe"
+
+	// windowsCredentialLimit is the largest secret Windows Credential Manager
+	// stores; go-keyring reports ErrSetDataTooBig beyond it.
+	windowsCredentialLimit = 2560
+)
+
 type KeyringStore struct {
 	Service string
 }
@@ -37,7 +46,11 @@ func (s *KeyringStore) Set(ref, value string) error {
 	if value == "" {
 		return fmt.Errorf("credential value is required")
 	}
-	return keyring.Set(s.service(), ref, value)
+	err := keyring.Set(s.service(), ref, value)
+	if errors.Is(err, keyring.ErrSetDataTooBig) {
+		return fmt.Errorf("token is too long for the system password manager (Windows Credential Manager holds at most %d bytes); use an environment variable instead", windowsCredentialLimit)
+	}
+	return err
 }
 
 func (s *KeyringStore) Delete(ref string) error {
@@ -48,19 +61,35 @@ func (s *KeyringStore) Delete(ref string) error {
 	return err
 }
 
+// Available reports whether the system password manager can be used. A read
+// of the probe entry is enough on most systems, but Windows Credential
+// Manager and some Secret Service setups fail reads of missing entries with
+// errors other than not-found, so an unexpected read error falls back to
+// writing, reading back and deleting the probe entry.
 func (s *KeyringStore) Available() (bool, error) {
-	ref := "__jenkins_tui_probe__"
-	value, err := keyring.Get(s.service(), ref)
-	if err == nil {
-		if value != "" {
-			return true, nil
-		}
+	service := s.service()
+	return probeKeyring(
+		func() (string, error) { return keyring.Get(service, probeRef) },
+		func(v string) error { return keyring.Set(service, probeRef, v) },
+		func() error { return keyring.Delete(service, probeRef) },
+	)
+}
+
+func probeKeyring(get func() (string, error), set func(string) error, del func() error) (bool, error) {
+	_, err := get()
+	if err == nil || errors.Is(err, keyring.ErrNotFound) {
 		return true, nil
 	}
-	if errors.Is(err, keyring.ErrNotFound) {
-		return true, nil
+	readErr := err
+	if err := set(probeValue); err != nil {
+		return false, fmt.Errorf("%w (write probe: %v)", readErr, err)
+	}
+	value, err := get()
+	_ = del()
+	if err != nil || value != probeValue {
+		return false, readErr
 	}
-	return false, err
+	return true, nil
 }
 
 func (s *KeyringStore) service() string {
@@ -0,0 +1,44 @@
+package credentials
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/zalando/go-keyring"
+)
+
+func TestProbeKeyring(t *testing.T) {
+	notFound := func() (string, error) { return "", keyring.ErrNotFound }
+	if ok, err := probeKeyring(notFound, nil, nil); !ok || err != nil {
+		t.Fatalf("not found probe = %v, %v; want available", ok, err)
+	}
+
+	// Windows Credential Manager can fail the read of a missing entry with
+	// an unrelated error; a working write/read round trip still counts.
+	var stored string
+	reads := 0
+	get := func() (string, error) {
+		reads++
+		if stored == "" {
+			return "", errors.New("Element not found.")
+		}
+		return stored, nil
+	}
+	set := func(v string) error { stored = v; return nil }
+	deleted := false
+	del := func() error { deleted = true; return nil }
+	if ok, err := probeKeyring(get, set, del); !ok || err != nil {
+		t.Fatalf("round trip probe = %v, %v; want available", ok, err)
+	}
+	if reads != 2 || !deleted {
+		t.Fatalf("reads = %d, deleted = %v; want 2 reads and the probe deleted", reads, deleted)
+	}
+
+	locked := errors.New("dbus: no secret service")
+	failing := func() (string, error) { return "", locked }
+	refuse := func(string) error { return errors.New("write refused") }
+	ok, err := probeKeyring(failing, refuse, del)
+	if ok || !errors.Is(err, locked) {
+		t.Fatalf("failing probe = %v, %v; want unavailable wrapping the read error", ok, err)
+	}
+}
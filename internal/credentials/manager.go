@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
+	"time"
 
 	"jenkins-tui/internal/models"
 )
@@ -11,20 +13,68 @@ import (
 type Manager struct {
 	keyring Store
 	env     Store
+
+	mu sync.Mutex
+	// session holds auth_command tokens by target ID; stored holds keyring
+	// reads by ref, so repeated selections do not prompt the OS keyring.
+	session map[string]cachedToken
+	stored  map[string]cachedToken
+	ttl     time.Duration
+	now     func() time.Time
+}
+
+type cachedToken struct {
+	token string
+	at    time.Time
 }
 
 func NewManager() *Manager {
 	return &Manager{
 		keyring: NewKeyringStore(),
 		env:     NewEnvStore(),
+		session: map[string]cachedToken{},
+		stored:  map[string]cachedToken{},
+		now:     time.Now,
 	}
 }
 
+// SetCacheTTL bounds how long cached tokens are reused before they are read
+// again; 0 keeps them until the session ends.
+func (m *Manager) SetCacheTTL(ttl time.Duration) {
+	m.mu.Lock()
+	m.ttl = ttl
+	m.mu.Unlock()
+}
+
+// Resolve returns the target's API token. Kerberos targets sign requests
+// with SPNEGO and have none, so they resolve to "".
 func (m *Manager) Resolve(target models.JenkinsTarget) (string, error) {
+	if target.Auth == models.AuthKerberos {
+		return "", nil
+	}
+	if token, ok := m.sessionToken(target); ok {
+		return token, nil
+	}
+	token, err := m.resolveStored(target)
+	if err == nil {
+		return token, nil
+	}
+	if strings.TrimSpace(target.AuthCommand) != "" {
+		return "", fmt.Errorf("%w for target %q", ErrAuthRequired, target.Name)
+	}
+	return "", err
+}
+
+func (m *Manager) resolveStored(target models.JenkinsTarget) (string, error) {
 	switch target.Credential.Type {
 	case models.CredentialTypeKeyring:
+		ref := strings.TrimSpace(target.Credential.Ref)
+		if token, ok := m.cached(m.stored, ref); ok {
+			return token, nil
+		}
 		token, err := m.keyring.Get(target.Credential.Ref)
 		if err == nil {
+			m.cache(&m.stored, ref, token)
 			return token, nil
 		}
 		if errors.Is(err, ErrNotFound) {
@@ -45,12 +95,77 @@ func (m *Manager) Resolve(target models.JenkinsTarget) (string, error) {
 	}
 }
 
+// Remember keeps a token obtained from auth_command for the rest of the
+// session. Keyring-backed targets also persist it so the next launch can
+// reuse the token until it expires.
+func (m *Manager) Remember(target models.JenkinsTarget, token string) {
+	m.cache(&m.session, target.ID, token)
+	if target.Credential.Type == models.CredentialTypeKeyring && target.Credential.Ref != "" {
+		if err := m.keyring.Set(target.Credential.Ref, token); err == nil {
+			m.cache(&m.stored, strings.TrimSpace(target.Credential.Ref), token)
+		}
+	}
+}
+
+// Forget drops every cached token of target, so the next Resolve reads the
+// backend again.
+func (m *Manager) Forget(target models.JenkinsTarget) {
+	m.mu.Lock()
+	delete(m.session, target.ID)
+	if target.Credential.Type == models.CredentialTypeKeyring {
+		delete(m.stored, strings.TrimSpace(target.Credential.Ref))
+	}
+	m.mu.Unlock()
+}
+
+func (m *Manager) sessionToken(target models.JenkinsTarget) (string, bool) {
+	return m.cached(m.session, target.ID)
+}
+
+func (m *Manager) cached(cache map[string]cachedToken, key string) (string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c, ok := cache[key]
+	if !ok || c.token == "" {
+		return "", false
+	}
+	if m.ttl > 0 && m.clock().Sub(c.at) >= m.ttl {
+		delete(cache, key)
+		return "", false
+	}
+	return c.token, true
+}
+
+func (m *Manager) cache(cache *map[string]cachedToken, key, token string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if *cache == nil {
+		*cache = map[string]cachedToken{}
+	}
+	(*cache)[key] = cachedToken{token: token, at: m.clock()}
+}
+
+func (m *Manager) clock() time.Time {
+	if m.now == nil {
+		return time.Now()
+	}
+	return m.now()
+}
+
 func (m *Manager) SetKeyring(ref, value string) error {
-	return m.keyring.Set(strings.TrimSpace(ref), value)
+	ref = strings.TrimSpace(ref)
+	m.mu.Lock()
+	delete(m.stored, ref)
+	m.mu.Unlock()
+	return m.keyring.Set(ref, value)
 }
 
 func (m *Manager) DeleteKeyring(ref string) error {
-	return m.keyring.Delete(strings.TrimSpace(ref))
+	ref = strings.TrimSpace(ref)
+	m.mu.Lock()
+	delete(m.stored, ref)
+	m.mu.Unlock()
+	return m.keyring.Delete(ref)
 }
 
 func (m *Manager) KeyringAvailable() (bool, error) {
@@ -0,0 +1,81 @@
+package credentials
+
+import (
+	"testing"
+	"time"
+
+	"jenkins-tui/internal/models"
+)
+
+type countingStore struct {
+	values map[string]string
+	gets   int
+}
+
+func (s *countingStore) Get(ref string) (string, error) {
+	s.gets++
+	v, ok := s.values[ref]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return v, nil
+}
+
+func (s *countingStore) Set(ref, value string) error {
+	s.values[ref] = value
+	return nil
+}
+
+func (s *countingStore) Delete(ref string) error {
+	delete(s.values, ref)
+	return nil
+}
+
+func (s *countingStore) Available() (bool, error) {
+	return true, nil
+}
+
+func TestResolveCachesKeyringReads(t *testing.T) {
+	store := &countingStore{values: map[string]string{"jenkins-tui/prod": "old"}}
+	now := time.Unix(0, 0)
+	m := &Manager{keyring: store, env: NewEnvStore(), now: func() time.Time { return now }}
+	m.SetCacheTTL(time.Hour)
+	target := models.JenkinsTarget{ID: "prod", Name: "prod", Credential: models.Credential{Type: models.CredentialTypeKeyring, Ref: "jenkins-tui/prod"}}
+
+	for i := 0; i < 3; i++ {
+		if got, err := m.Resolve(target); err != nil || got != "old" {
+			t.Fatalf("Resolve: %q %v", got, err)
+		}
+	}
+	if store.gets != 1 {
+		t.Fatalf("expected one keyring read, got %d", store.gets)
+	}
+
+	if err := m.SetKeyring("jenkins-tui/prod", "new"); err != nil {
+		t.Fatalf("SetKeyring: %v", err)
+	}
+	if got, _ := m.Resolve(target); got != "new" || store.gets != 2 {
+		t.Fatalf("a rotated token should be read again, got %q after %d reads", got, store.gets)
+	}
+
+	now = now.Add(time.Hour)
+	if _, err := m.Resolve(target); err != nil || store.gets != 3 {
+		t.Fatalf("an expired token should be read again, got %d reads (%v)", store.gets, err)
+	}
+	m.Forget(target)
+	if _, err := m.Resolve(target); err != nil || store.gets != 4 {
+		t.Fatalf("Forget should drop the cached token, got %d reads (%v)", store.gets, err)
+	}
+}
+
+func TestResolveKerberosTargetHasNoToken(t *testing.T) {
+	store := &countingStore{values: map[string]string{}}
+	m := &Manager{keyring: store, env: store, session: map[string]cachedToken{}, stored: map[string]cachedToken{}, now: time.Now}
+	target := models.JenkinsTarget{ID: "sso", Name: "sso", Auth: models.AuthKerberos}
+	if token, err := m.Resolve(target); err != nil || token != "" {
+		t.Fatalf("expected no token and no error, got %q %v", token, err)
+	}
+	if store.gets != 0 {
+		t.Fatalf("a Kerberos target should not read any store, got %d reads", store.gets)
+	}
+}
@@ -5,6 +5,7 @@ import "fmt"
 var (
 	ErrUnsupportedType = fmt.Errorf("unsupported credential type")
 	ErrNotFound        = fmt.Errorf("credential not found")
+	ErrAuthRequired    = fmt.Errorf("interactive authentication required")
 )
 
 type Store interface {
@@ -0,0 +1,40 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	from := time.Date(2024, time.May, 1, 10, 7, 30, 0, time.UTC) // a Wednesday
+	cases := []struct {
+		expr string
+		want time.Time
+	}{
+		{"*/15 * * * *", time.Date(2024, time.May, 1, 10, 15, 0, 0, time.UTC)},
+		{"30 2 * * mon-fri", time.Date(2024, time.May, 2, 2, 30, 0, 0, time.UTC)},
+		{"0 9 * * sun", time.Date(2024, time.May, 5, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * 7", time.Date(2024, time.May, 5, 9, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)},
+		// Both day fields restricted: the 15th or any Friday, whichever is first.
+		{"0 0 15 * fri", time.Date(2024, time.May, 3, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 feb *", time.Time{}},
+	}
+	for _, tc := range cases {
+		c, err := Parse(tc.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tc.expr, err)
+		}
+		if got := c.Next(from); !got.Equal(tc.want) {
+			t.Fatalf("%q: expected next run %v, got %v", tc.expr, tc.want, got)
+		}
+	}
+}
+
+func TestParseRejectsBadExpressions(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "0 0 * * 8", "5-1 * * * *", "*/0 * * * *", "0 0 * foo *"} {
+		if _, err := Parse(expr); err == nil {
+			t.Fatalf("expected %q to be rejected", expr)
+		}
+	}
+}
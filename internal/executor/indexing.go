@@ -0,0 +1,33 @@
+package executor
+
+import (
+	"context"
+
+	"jenkins-tui/internal/jenkins"
+	"jenkins-tui/internal/models"
+)
+
+// Index starts branch indexing of a multibranch project and reports it as
+// a single run (index 0) so it can be tracked like a build.
+func Index(ctx context.Context, client *jenkins.Client, folderURL string, out chan<- models.RunUpdate) {
+	defer close(out)
+	indexingURL := jenkins.IndexingURL(folderURL)
+	if !emitUpdate(ctx, out, models.RunUpdate{Index: 0, State: models.RunQueued}) {
+		return
+	}
+	// A missing previous indexing just means any scan we see is ours.
+	previous, _ := client.IndexingStatus(ctx, folderURL)
+	if err := client.ScanMultibranch(ctx, folderURL); err != nil {
+		emitUpdate(ctx, out, models.RunUpdate{Index: 0, State: models.RunError, Err: err, Done: true})
+		return
+	}
+	if !emitUpdate(ctx, out, models.RunUpdate{Index: 0, State: models.RunRunning, BuildURL: indexingURL}) {
+		return
+	}
+	result, err := client.PollIndexing(stallTo(ctx, out, models.RunUpdate{Index: 0, State: models.RunRunning, BuildURL: indexingURL}), folderURL, previous.Timestamp)
+	if err != nil {
+		emitUpdate(ctx, out, models.RunUpdate{Index: 0, State: models.RunError, BuildURL: indexingURL, Err: err, Done: true})
+		return
+	}
+	emitUpdate(ctx, out, models.RunUpdate{Index: 0, State: MapResult(result), BuildURL: indexingURL, Result: result, Done: true})
+}
@@ -5,7 +5,9 @@ import (
 	"sync"
 
 	"jenkins-tui/internal/jenkins"
+	"jenkins-tui/internal/metrics"
 	"jenkins-tui/internal/models"
+	"jenkins-tui/internal/tracing"
 )
 
 func Run(ctx context.Context, client *jenkins.Client, jobURL string, specs []models.JobSpec, concurrency int, out chan<- models.RunUpdate) {
@@ -21,41 +23,11 @@ func Run(ctx context.Context, client *jenkins.Client, jobURL string, specs []mod
 		go func() {
 			defer wg.Done()
 			for idx := range jobs {
-				spec := specs[idx]
-				if !emitUpdate(ctx, out, models.RunUpdate{Index: idx, State: models.RunQueued}) {
-					return
-				}
-				queueURL, err := client.TriggerBuild(ctx, jobURL, spec.Params)
-				if err != nil {
-					if !emitUpdate(ctx, out, models.RunUpdate{Index: idx, State: models.RunError, Err: err, Done: true}) {
-						return
-					}
-					continue
-				}
-				if !emitUpdate(ctx, out, models.RunUpdate{Index: idx, State: models.RunQueued, QueueURL: queueURL}) {
-					return
-				}
-
-				buildURL, num, err := client.ResolveQueue(ctx, queueURL)
-				if err != nil {
-					if !emitUpdate(ctx, out, models.RunUpdate{Index: idx, State: models.RunError, QueueURL: queueURL, Err: err, Done: true}) {
-						return
-					}
-					continue
-				}
-				if !emitUpdate(ctx, out, models.RunUpdate{Index: idx, State: models.RunRunning, QueueURL: queueURL, BuildURL: buildURL, BuildNumber: num}) {
-					return
-				}
-
-				result, err := client.PollBuild(ctx, buildURL)
-				if err != nil {
-					if !emitUpdate(ctx, out, models.RunUpdate{Index: idx, State: models.RunError, BuildURL: buildURL, BuildNumber: num, Err: err, Done: true}) {
-						return
-					}
-					continue
+				url := jobURL
+				if specs[idx].Job != nil {
+					url = specs[idx].Job.URL
 				}
-				state := mapResult(result)
-				if !emitUpdate(ctx, out, models.RunUpdate{Index: idx, State: state, BuildURL: buildURL, BuildNumber: num, Result: result, Done: true}) {
+				if !runOne(ctx, client, url, idx, specs[idx], out) {
 					return
 				}
 			}
@@ -74,6 +46,101 @@ enqueueLoop:
 	wg.Wait()
 }
 
+// RunGauge keeps one run's place in the jenkins_tui_runs metric. The zero
+// value is a run that has not been triggered yet.
+type RunGauge struct {
+	state models.RunState
+}
+
+// Set moves the run to state.
+func (g *RunGauge) Set(state models.RunState) {
+	metrics.Default.SetRunState(string(g.state), string(state))
+	g.state = state
+}
+
+// Fail moves a run that is still queued or running to ERROR.
+func (g *RunGauge) Fail() {
+	if g.inFlight() {
+		g.Set(models.RunError)
+	}
+}
+
+// Stop removes a run that is no longer tracked while still queued or
+// running, so the gauge does not count it forever.
+func (g *RunGauge) Stop() {
+	if g.inFlight() {
+		g.Set("")
+	}
+}
+
+func (g *RunGauge) inFlight() bool {
+	return g.state == models.RunQueued || g.state == models.RunRunning
+}
+
+// runOne triggers and tracks a single run, recording it as one trace span
+// and in the runs metric. It returns false once the context is cancelled.
+func runOne(ctx context.Context, client *jenkins.Client, jobURL string, idx int, spec models.JobSpec, out chan<- models.RunUpdate) bool {
+	ctx, span := tracing.Start(ctx, "jenkins.run", tracing.KindInternal,
+		tracing.String("jenkins.job.url", jobURL),
+		tracing.Int("jenkins.run.index", idx),
+	)
+	var gauge RunGauge
+	defer gauge.Stop()
+	if !emitUpdate(ctx, out, models.RunUpdate{Index: idx, State: models.RunQueued}) {
+		span.End(ctx.Err())
+		return false
+	}
+	queueURL, err := client.TriggerBuild(ctx, jobURL, spec.Params)
+	if err != nil {
+		span.End(err)
+		gauge.Set(models.RunError)
+		return emitUpdate(ctx, out, models.RunUpdate{Index: idx, State: models.RunError, Err: err, Done: true})
+	}
+	gauge.Set(models.RunQueued)
+	span.SetAttr(tracing.String("jenkins.queue.url", queueURL))
+	if !emitUpdate(ctx, out, models.RunUpdate{Index: idx, State: models.RunQueued, QueueURL: queueURL}) {
+		span.End(ctx.Err())
+		return false
+	}
+
+	buildURL, num, err := client.ResolveQueue(stallTo(ctx, out, models.RunUpdate{Index: idx, State: models.RunQueued, QueueURL: queueURL}), queueURL)
+	if err != nil {
+		span.End(err)
+		gauge.Fail()
+		return emitUpdate(ctx, out, models.RunUpdate{Index: idx, State: models.RunError, QueueURL: queueURL, Err: err, Done: true})
+	}
+	gauge.Set(models.RunRunning)
+	span.SetAttr(tracing.String("jenkins.build.url", buildURL), tracing.Int("jenkins.build.number", num))
+	if !emitUpdate(ctx, out, models.RunUpdate{Index: idx, State: models.RunRunning, QueueURL: queueURL, BuildURL: buildURL, BuildNumber: num}) {
+		span.End(ctx.Err())
+		return false
+	}
+
+	result, err := client.PollBuild(stallTo(ctx, out, models.RunUpdate{Index: idx, State: models.RunRunning, QueueURL: queueURL, BuildURL: buildURL, BuildNumber: num}), buildURL)
+	if err != nil {
+		span.End(err)
+		gauge.Fail()
+		return emitUpdate(ctx, out, models.RunUpdate{Index: idx, State: models.RunError, BuildURL: buildURL, BuildNumber: num, Err: err, Done: true})
+	}
+	span.SetAttr(tracing.String("jenkins.build.result", result))
+	span.End(nil)
+	gauge.Set(MapResult(result))
+	return emitUpdate(ctx, out, models.RunUpdate{Index: idx, State: MapResult(result), BuildURL: buildURL, BuildNumber: num, Result: result, Done: true})
+}
+
+// stallTo reports a poll loop losing the server as a STALLED update, and
+// its recovery as resume, the update the run was at before.
+func stallTo(ctx context.Context, out chan<- models.RunUpdate, resume models.RunUpdate) context.Context {
+	return jenkins.WithStallHandler(ctx, func(err error) {
+		update := resume
+		if err != nil {
+			update.State = models.RunStalled
+			update.Err = err
+		}
+		emitUpdate(ctx, out, update)
+	})
+}
+
 func emitUpdate(ctx context.Context, out chan<- models.RunUpdate, update models.RunUpdate) bool {
 	select {
 	case <-ctx.Done():
@@ -83,7 +150,9 @@ func emitUpdate(ctx context.Context, out chan<- models.RunUpdate, update models.
 	}
 }
 
-func mapResult(result string) models.RunState {
+// MapResult turns a Jenkins build result into the run state it ends in:
+// anything but SUCCESS and ABORTED counts as FAILED.
+func MapResult(result string) models.RunState {
 	switch result {
 	case "SUCCESS":
 		return models.RunSuccess
@@ -0,0 +1,115 @@
+// Package gitrepo reads the checkout jenkins-tui runs in and compares git
+// remotes however they are spelled: https or ssh, with or without .git.
+package gitrepo
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"strings"
+)
+
+// Repo names a hosted repository, e.g. github.com and org/app. Both are
+// lower case. Host is empty when a Jenkins source only knows the path, as
+// GitLab branch sources do.
+type Repo struct {
+	Host string
+	Path string
+}
+
+func (r Repo) String() string {
+	if r.Host == "" {
+		return r.Path
+	}
+	return r.Host + "/" + r.Path
+}
+
+// Matches reports whether r and o are the same repository. Hosts are only
+// compared when both are known, and ports are ignored because ssh and https
+// remotes of one server use different ones.
+func (r Repo) Matches(o Repo) bool {
+	if r.Path == "" || r.Path != o.Path {
+		return false
+	}
+	return r.Host == "" || o.Host == "" || r.Host == o.Host
+}
+
+// Parse reads a git remote: https://github.com/org/app.git,
+// git@github.com:org/app.git, ssh://git@host:7999/org/app or a bare
+// org/app. ok is false for local paths and anything else without a
+// repository path.
+func Parse(remote string) (repo Repo, ok bool) {
+	remote = strings.TrimSpace(remote)
+	var host, p string
+	switch {
+	case strings.Contains(remote, "://"):
+		u, err := url.Parse(remote)
+		if err != nil || u.Scheme == "file" {
+			return Repo{}, false
+		}
+		host, p = u.Hostname(), u.Path
+	case strings.Contains(remote, ":") && !strings.HasPrefix(remote, "/"):
+		// scp-like syntax: [user@]host:path
+		host, p, _ = strings.Cut(remote, ":")
+		if at := strings.LastIndex(host, "@"); at >= 0 {
+			host = host[at+1:]
+		}
+	case strings.HasPrefix(remote, "/") || strings.HasPrefix(remote, "."):
+		return Repo{}, false
+	default:
+		p = remote
+	}
+	p = strings.TrimSuffix(strings.Trim(p, "/"), ".git")
+	// Bitbucket Server serves clones under /scm/.
+	p = strings.TrimPrefix(p, "scm/")
+	if !strings.Contains(p, "/") {
+		return Repo{}, false
+	}
+	return Repo{Host: strings.ToLower(host), Path: strings.ToLower(p)}, true
+}
+
+// Remote returns the URL of the named remote of the checkout at dir.
+func Remote(dir, name string) (string, error) {
+	out, err := git(dir, "remote", "get-url", name)
+	if err != nil {
+		return "", fmt.Errorf("git remote %s: %w", name, err)
+	}
+	return out, nil
+}
+
+// Detect finds the repository of the checkout at dir from its origin
+// remote.
+func Detect(dir string) (Repo, error) {
+	remote, err := Remote(dir, "origin")
+	if err != nil {
+		return Repo{}, err
+	}
+	repo, ok := Parse(remote)
+	if !ok {
+		return Repo{}, fmt.Errorf("origin %s is not a hosted repository", remote)
+	}
+	return repo, nil
+}
+
+// Branch returns the branch checked out at dir. A detached HEAD has none
+// and is an error.
+func Branch(dir string) (string, error) {
+	out, err := git(dir, "symbolic-ref", "--quiet", "--short", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("git branch: no branch checked out in %s", dir)
+	}
+	return out, nil
+}
+
+func git(dir string, args ...string) (string, error) {
+	out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).Output()
+	if err != nil {
+		var exit *exec.ExitError
+		if errors.As(err, &exit) && len(exit.Stderr) > 0 {
+			return "", errors.New(strings.TrimSpace(string(exit.Stderr)))
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
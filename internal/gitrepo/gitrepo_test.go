@@ -0,0 +1,71 @@
+package gitrepo
+
+import (
+	"os/exec"
+	"testing"
+)
+
+func TestParseNormalizesRemotes(t *testing.T) {
+	want := Repo{Host: "github.com", Path: "org/app"}
+	for _, remote := range []string{
+		"https://github.com/Org/App.git",
+		"https://user@github.com/org/app/",
+		"git@github.com:org/app.git",
+		"ssh://git@github.com:22/org/app.git",
+		"git://github.com/org/app",
+	} {
+		got, ok := Parse(remote)
+		if !ok || got != want {
+			t.Fatalf("Parse(%q) = %+v %v, want %+v", remote, got, ok, want)
+		}
+	}
+	if got, ok := Parse("https://git.corp/scm/team/app.git"); !ok || got.Path != "team/app" {
+		t.Fatalf("Bitbucket Server clone URL: %+v %v", got, ok)
+	}
+	if got, ok := Parse("group/sub/app"); !ok || got != (Repo{Path: "group/sub/app"}) {
+		t.Fatalf("bare path: %+v %v", got, ok)
+	}
+	for _, remote := range []string{"", "/srv/git/app.git", "./app", "file:///srv/git/app.git", "https://github.com/app"} {
+		if got, ok := Parse(remote); ok {
+			t.Fatalf("Parse(%q) = %+v, want no repository", remote, got)
+		}
+	}
+}
+
+func TestMatchesIgnoresUnknownHost(t *testing.T) {
+	ssh, _ := Parse("git@gitlab.corp:group/app.git")
+	if !ssh.Matches(Repo{Path: "group/app"}) {
+		t.Fatal("a path-only source should match any host")
+	}
+	if ssh.Matches(Repo{Host: "github.com", Path: "group/app"}) {
+		t.Fatal("different hosts should not match")
+	}
+	if ssh.Matches(Repo{Host: "gitlab.corp", Path: "group/app2"}) {
+		t.Fatal("different paths should not match")
+	}
+}
+
+func TestDetectReadsOriginAndBranch(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	for _, args := range [][]string{{"init", "-q"}, {"remote", "add", "origin", "git@github.com:org/app.git"}} {
+		if _, err := git(dir, args...); err != nil {
+			t.Fatalf("git %v: %v", args, err)
+		}
+	}
+	if _, err := git(dir, "checkout", "-q", "-b", "feature/login"); err != nil {
+		t.Fatalf("git checkout: %v", err)
+	}
+	if branch, err := Branch(dir); err != nil || branch != "feature/login" {
+		t.Fatalf("Branch = %q %v", branch, err)
+	}
+	repo, err := Detect(dir)
+	if err != nil || repo != (Repo{Host: "github.com", Path: "org/app"}) {
+		t.Fatalf("Detect = %+v %v", repo, err)
+	}
+	if _, err := Detect(t.TempDir()); err == nil {
+		t.Fatal("expected an error outside a checkout")
+	}
+}
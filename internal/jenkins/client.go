@@ -5,17 +5,27 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
+	"net/http/cookiejar"
 	"net/url"
 	"path"
+	"reflect"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
 	"time"
 
+	krbclient "github.com/jcmturner/gokrb5/v8/client"
+	"golang.org/x/sync/singleflight"
+
+	"jenkins-tui/internal/audit"
 	"jenkins-tui/internal/models"
+	"jenkins-tui/internal/redact"
 )
 
 type Client struct {
@@ -24,6 +34,22 @@ type Client struct {
 	http   *http.Client
 	crumb  *crumb
 	mu     sync.RWMutex
+	traces *traceRing
+	server *ServerInfo
+	// inflight coalesces identical GETs; see getShared.
+	inflight singleflight.Group
+	// transport is the base transport, kept for Diagnose.
+	transport *http.Transport
+	// proxy picks the proxy requests go through, nil for none.
+	proxy func(*http.Request) (*url.URL, error)
+	// krb signs requests to Kerberos targets; see kerberosClient.
+	krb *krbclient.Client
+	// reauth fetches a new token after a 401; see SetReauth. reauthMu
+	// serializes sign-ins; badToken is one a sign-in returned that Jenkins
+	// rejected straight away, which is not worth signing in again for.
+	reauth   func(ctx context.Context) (string, error)
+	reauthMu sync.Mutex
+	badToken string
 }
 
 type crumb struct {
@@ -32,18 +58,61 @@ type crumb struct {
 }
 
 func NewClient(target models.JenkinsTarget, token string, timeout time.Duration) *Client {
-	transport := &http.Transport{}
+	transport := newTransport(target.Transport)
 	if target.InsecureSkipTLSVerify {
 		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
 	}
+	proxy := configureProxy(transport, target)
+	traces := &traceRing{}
+	redact.Add(token)
+	// Newer controllers bind crumbs to the web session, so keep its cookie.
+	jar, _ := cookiejar.New(nil)
 	return &Client{
 		target: target,
 		token:  token,
 		http: &http.Client{
 			Timeout:   timeout,
-			Transport: transport,
+			Transport: loggingTransport{base: transport, username: target.Username, traces: traces},
+			Jar:       jar,
 		},
+		traces:    traces,
+		transport: transport,
+		proxy:     proxy,
+	}
+}
+
+// Connection reuse defaults. Polling and prefetching send bursts of up to 16
+// requests, and Go keeps only 2 idle connections per host unless told
+// otherwise, so most of a burst would pay for a fresh TLS handshake.
+const (
+	defaultMaxIdleConnsPerHost = 16
+	defaultIdleConnTimeout     = 90 * time.Second
+)
+
+func newTransport(tuning models.TransportTuning) *http.Transport {
+	transport := &http.Transport{
+		MaxIdleConnsPerHost: defaultMaxIdleConnsPerHost,
+		IdleConnTimeout:     defaultIdleConnTimeout,
+		ForceAttemptHTTP2:   true,
+	}
+	if tuning.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = tuning.MaxIdleConnsPerHost
+	}
+	if tuning.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = tuning.IdleConnTimeout
+	}
+	if tuning.ForceAttemptHTTP2 != nil {
+		transport.ForceAttemptHTTP2 = *tuning.ForceAttemptHTTP2
 	}
+	return transport
+}
+
+// RecentRequests returns up to n of the latest API calls, newest first.
+func (c *Client) RecentRequests(n int) []RequestTrace {
+	if c.traces == nil {
+		return nil
+	}
+	return c.traces.recent(n)
 }
 
 func (c *Client) Host() string {
@@ -62,27 +131,19 @@ func (c *Client) ValidateConnection(ctx context.Context) error {
 
 type jobNodeResp struct {
 	Jobs []struct {
-		Name  string `json:"name"`
-		URL   string `json:"url"`
-		Class string `json:"_class"`
+		Name      string `json:"name"`
+		URL       string `json:"url"`
+		Class     string `json:"_class"`
+		Color     string `json:"color"`
+		Buildable *bool  `json:"buildable"`
 	} `json:"jobs"`
 }
 
-type openSearchSuggestResp []any
-
-type suggestResp struct {
-	Suggestions []struct {
-		Name string `json:"name"`
-		Path string `json:"path"`
-		URL  string `json:"url"`
-	} `json:"suggestions"`
-}
-
 func (c *Client) ListJobNodes(ctx context.Context, baseURL, prefix string) ([]models.JobNode, error) {
 	if strings.TrimSpace(baseURL) == "" {
 		baseURL = c.Host()
 	}
-	api := strings.TrimRight(baseURL, "/") + "/api/json?tree=jobs[name,url,_class]"
+	api := strings.TrimRight(baseURL, "/") + "/api/json?tree=jobs[name,url,_class,color,buildable]"
 	var resp jobNodeResp
 	if err := c.getJSON(ctx, api, &resp); err != nil {
 		return nil, err
@@ -100,7 +161,17 @@ func (c *Client) ListJobNodes(ctx context.Context, baseURL, prefix string) ([]mo
 		if isFolderClass(j.Class) {
 			kind = models.JobNodeFolder
 		}
-		out = append(out, models.JobNode{Name: j.Name, FullName: full, URL: j.URL, Kind: kind})
+		disabled := kind == models.JobNodeJob && (j.Color == "disabled" || (j.Buildable != nil && !*j.Buildable))
+		out = append(out, models.JobNode{
+			Name:        j.Name,
+			FullName:    full,
+			URL:         j.URL,
+			Kind:        kind,
+			Color:       j.Color,
+			Disabled:    disabled,
+			Multibranch: isMultibranchClass(j.Class),
+			Pipeline:    isPipelineClass(j.Class),
+		})
 	}
 	sort.SliceStable(out, func(i, j int) bool {
 		if out[i].Kind != out[j].Kind {
@@ -111,6 +182,37 @@ func (c *Client) ListJobNodes(ctx context.Context, baseURL, prefix string) ([]mo
 	return out, nil
 }
 
+type viewsResp struct {
+	Views []struct {
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	} `json:"views"`
+}
+
+// ListViews returns the views defined on a Jenkins root, folder or nested
+// view. View nodes carry the container's prefix as FullName because jobs
+// listed through a view keep their folder-relative names.
+func (c *Client) ListViews(ctx context.Context, containerURL, prefix string) ([]models.JobNode, error) {
+	if strings.TrimSpace(containerURL) == "" {
+		containerURL = c.Host()
+	}
+	api := strings.TrimRight(containerURL, "/") + "/api/json?tree=views[name,url]"
+	var resp viewsResp
+	if err := c.getJSON(ctx, api, &resp); err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	out := make([]models.JobNode, 0, len(resp.Views))
+	for _, v := range resp.Views {
+		if v.URL == "" || seen[v.URL] {
+			continue
+		}
+		seen[v.URL] = true
+		out = append(out, models.JobNode{Name: v.Name, FullName: strings.Trim(prefix, "/"), URL: v.URL, Kind: models.JobNodeView})
+	}
+	return out, nil
+}
+
 func (c *Client) SearchJobs(ctx context.Context, query string, limit int) ([]models.JobNode, error) {
 	q := strings.TrimSpace(query)
 	if q == "" {
@@ -119,70 +221,74 @@ func (c *Client) SearchJobs(ctx context.Context, query string, limit int) ([]mod
 	if limit <= 0 {
 		limit = 50
 	}
-	nodes, err := c.searchJobsOpenSearch(ctx, q, limit)
-	if err == nil {
-		return nodes, nil
+	// Keys of coalesced GETs are URLs, so this one cannot collide.
+	key := fmt.Sprintf("search %d %s", limit, q)
+	v, err := c.coalesce(ctx, key, func() (any, error) {
+		nodes, err := c.searchJobsOpenSearch(ctx, q, limit)
+		if err == nil {
+			return nodes, nil
+		}
+		return c.searchJobsSuggest(ctx, q, limit)
+	})
+	if err != nil {
+		return nil, err
 	}
-	return c.searchJobsSuggest(ctx, q, limit)
+	return slices.Clone(v.([]models.JobNode)), nil
 }
 
 func (c *Client) searchJobsOpenSearch(ctx context.Context, query string, limit int) ([]models.JobNode, error) {
 	endpoint := c.Host() + "/search/suggestOpenSearch?q=" + url.QueryEscape(query)
-	var resp openSearchSuggestResp
-	if err := c.getJSON(ctx, endpoint, &resp); err != nil {
+	var names, paths, urls []any
+	err := c.streamJSON(ctx, endpoint, func(dec *json.Decoder) (err error) {
+		names, paths, urls, err = decodeOpenSearch(dec, limit*searchScanFactor)
+		return err
+	})
+	if err != nil {
 		return nil, err
 	}
-	if len(resp) < 4 {
-		return []models.JobNode{}, nil
-	}
-	names, _ := resp[1].([]any)
-	paths, _ := resp[2].([]any)
-	urls, _ := resp[3].([]any)
 	return normalizeSearchResults(c.Host(), names, paths, urls, limit), nil
 }
 
 func (c *Client) searchJobsSuggest(ctx context.Context, query string, limit int) ([]models.JobNode, error) {
 	endpoint := c.Host() + "/search/suggest?query=" + url.QueryEscape(query)
-	var resp suggestResp
-	if err := c.getJSON(ctx, endpoint, &resp); err != nil {
+	var names, paths, urls []any
+	err := c.streamJSON(ctx, endpoint, func(dec *json.Decoder) (err error) {
+		names, paths, urls, err = decodeSuggest(dec, c.Host(), limit)
+		return err
+	})
+	if err != nil {
 		return nil, err
 	}
-	names := make([]any, 0, len(resp.Suggestions))
-	paths := make([]any, 0, len(resp.Suggestions))
-	urls := make([]any, 0, len(resp.Suggestions))
-	for _, s := range resp.Suggestions {
-		names = append(names, s.Name)
-		paths = append(paths, s.Path)
-		urls = append(urls, s.URL)
-	}
 	return normalizeSearchResults(c.Host(), names, paths, urls, limit), nil
 }
 
 func normalizeSearchResults(host string, names, paths, rawURLs []any, limit int) []models.JobNode {
-	out := make([]models.JobNode, 0, limit)
-	seen := map[string]bool{}
-	for i := 0; i < len(rawURLs) && len(out) < limit; i++ {
+	nodes := make([]models.JobNode, 0, len(rawURLs))
+	for i := 0; i < len(rawURLs); i++ {
 		u, ok := rawURLs[i].(string)
 		if !ok || strings.TrimSpace(u) == "" {
 			continue
 		}
 		abs := absolutizeURL(host, u)
-		if !strings.Contains(abs, "/job/") || seen[abs] {
+		if !strings.Contains(abs, "/job/") {
 			continue
 		}
-		seen[abs] = true
 		name := stringAt(names, i)
 		full := strings.Trim(stringAt(paths, i), "/")
 		if full == "" {
 			full = name
 		}
-		out = append(out, models.JobNode{
+		nodes = append(nodes, models.JobNode{
 			Name:     name,
 			FullName: full,
 			URL:      abs,
 			Kind:     models.JobNodeJob,
 		})
 	}
+	out := DedupeJobNodes(nodes)
+	if len(out) > limit {
+		out = out[:limit]
+	}
 	return out
 }
 
@@ -195,7 +301,7 @@ func stringAt(items []any, idx int) string {
 }
 
 func absolutizeURL(host, raw string) string {
-	trimmed := strings.TrimSpace(raw)
+	trimmed := EscapeURL(strings.TrimSpace(raw))
 	if strings.HasPrefix(trimmed, "http://") || strings.HasPrefix(trimmed, "https://") {
 		return trimmed
 	}
@@ -210,6 +316,55 @@ func absolutizeURL(host, raw string) string {
 	return base.ResolveReference(rel).String()
 }
 
+func isMultibranchClass(class string) bool {
+	return strings.Contains(class, "WorkflowMultiBranchProject")
+}
+
+func isPipelineClass(class string) bool {
+	return strings.HasSuffix(class, ".WorkflowJob")
+}
+
+// JobNode reads a single job or folder the way ListJobNodes lists them, for
+// callers that only have its URL, e.g. search results.
+func (c *Client) JobNode(ctx context.Context, jobURL string) (models.JobNode, error) {
+	var resp struct {
+		Name      string `json:"name"`
+		FullName  string `json:"fullName"`
+		URL       string `json:"url"`
+		Class     string `json:"_class"`
+		Color     string `json:"color"`
+		Buildable *bool  `json:"buildable"`
+	}
+	api := strings.TrimRight(jobURL, "/") + "/api/json?tree=name,fullName,url,_class,color,buildable"
+	if err := c.getJSON(ctx, api, &resp); err != nil {
+		return models.JobNode{}, err
+	}
+	kind := models.JobNodeJob
+	if isFolderClass(resp.Class) {
+		kind = models.JobNodeFolder
+	}
+	return models.JobNode{
+		Name:        resp.Name,
+		FullName:    resp.FullName,
+		URL:         resp.URL,
+		Kind:        kind,
+		Color:       resp.Color,
+		Disabled:    kind == models.JobNodeJob && (resp.Color == "disabled" || (resp.Buildable != nil && !*resp.Buildable)),
+		Multibranch: isMultibranchClass(resp.Class),
+		Pipeline:    isPipelineClass(resp.Class),
+	}, nil
+}
+
+// BranchJobKind reports whether a job generated inside a multibranch project
+// builds a branch or a pull/merge request, based on the SCM naming scheme.
+func BranchJobKind(name string) string {
+	upper := strings.ToUpper(name)
+	if strings.HasPrefix(upper, "PR-") || strings.HasPrefix(upper, "MR-") {
+		return "pull request"
+	}
+	return "branch"
+}
+
 func isFolderClass(class string) bool {
 	return strings.Contains(class, "Folder") ||
 		strings.Contains(class, "organization") ||
@@ -217,7 +372,9 @@ func isFolderClass(class string) bool {
 }
 
 type jobParamsResp struct {
-	Actions []struct {
+	Color     string `json:"color"`
+	Buildable *bool  `json:"buildable"`
+	Actions   []struct {
 		ParameterDefinitions []paramDefWire `json:"parameterDefinitions"`
 	} `json:"actions"`
 	Property []struct {
@@ -231,16 +388,45 @@ type paramDefWire struct {
 	Type                  string   `json:"type"`
 	Choices               []string `json:"choices"`
 	DefaultParameterValue struct {
-		Value any `json:"value"`
+		Value any    `json:"value"`
+		Label string `json:"label"`
 	} `json:"defaultParameterValue"`
+	// Node parameters list the agents they may use; "ALL (no restriction)"
+	// means any agent.
+	AllowedSlaves []string `json:"allowedSlaves"`
+	DefaultSlaves []string `json:"defaultSlaves"`
 }
 
+const anyAgent = "ALL (no restriction)"
+
+// ErrJobDisabled is returned by GetLaunchParams for a job Jenkins will not
+// build.
+var ErrJobDisabled = errors.New("job is disabled in Jenkins")
+
 func (c *Client) GetJobParams(ctx context.Context, jobURL string) ([]models.ParamDef, error) {
-	api := strings.TrimRight(jobURL, "/") + "/api/json?tree=actions[parameterDefinitions[name,description,type,choices,defaultParameterValue[value]]],property[parameterDefinitions[name,description,type,choices,defaultParameterValue[value]]]"
+	defs, _, err := c.jobParams(ctx, jobURL)
+	return defs, err
+}
+
+// GetLaunchParams is GetJobParams for a job about to be triggered: in the
+// same request it checks that the job is buildable and returns
+// ErrJobDisabled if not, so every way of reaching the form is guarded.
+func (c *Client) GetLaunchParams(ctx context.Context, jobURL string) ([]models.ParamDef, error) {
+	defs, disabled, err := c.jobParams(ctx, jobURL)
+	if err == nil && disabled {
+		return nil, ErrJobDisabled
+	}
+	return defs, err
+}
+
+func (c *Client) jobParams(ctx context.Context, jobURL string) ([]models.ParamDef, bool, error) {
+	fields := "name,description,type,choices,allowedSlaves,defaultSlaves,defaultParameterValue[value,label]"
+	api := strings.TrimRight(jobURL, "/") + "/api/json?tree=color,buildable,actions[parameterDefinitions[" + fields + "]],property[parameterDefinitions[" + fields + "]]"
 	var resp jobParamsResp
 	if err := c.getJSON(ctx, api, &resp); err != nil {
-		return nil, err
+		return nil, false, err
 	}
+	disabled := resp.Color == "disabled" || (resp.Buildable != nil && !*resp.Buildable)
 	defs := make([]models.ParamDef, 0)
 	appendDefs := func(definitions []paramDefWire) {
 		for _, p := range definitions {
@@ -248,13 +434,26 @@ func (c *Client) GetJobParams(ctx context.Context, jobURL string) ([]models.Para
 			if kind == "" {
 				continue
 			}
-			defs = append(defs, models.ParamDef{
+			def := models.ParamDef{
 				Name:        p.Name,
 				Kind:        kind,
 				Description: p.Description,
 				Choices:     p.Choices,
 				Default:     fmt.Sprintf("%v", p.DefaultParameterValue.Value),
-			})
+			}
+			switch kind {
+			case models.ParamNode:
+				def.Choices, def.Default = nil, ""
+				if len(p.AllowedSlaves) > 0 && !slices.Contains(p.AllowedSlaves, anyAgent) {
+					def.Choices = p.AllowedSlaves
+				}
+				if len(p.DefaultSlaves) > 0 {
+					def.Default = p.DefaultSlaves[0]
+				}
+			case models.ParamLabel:
+				def.Choices, def.Default = nil, p.DefaultParameterValue.Label
+			}
+			defs = append(defs, def)
 		}
 	}
 	for _, action := range resp.Actions {
@@ -264,7 +463,7 @@ func (c *Client) GetJobParams(ctx context.Context, jobURL string) ([]models.Para
 		appendDefs(prop.ParameterDefinitions)
 	}
 	if len(defs) == 0 {
-		return defs, nil
+		return defs, disabled, nil
 	}
 	// Deduplicate by parameter name; prefer first occurrence.
 	seen := map[string]bool{}
@@ -276,7 +475,101 @@ func (c *Client) GetJobParams(ctx context.Context, jobURL string) ([]models.Para
 		seen[d.Name] = true
 		uniq = append(uniq, d)
 	}
-	return uniq, nil
+	c.fillAgentChoices(ctx, uniq)
+	return uniq, disabled, nil
+}
+
+// GetJobParamsAll loads the parameter definitions of several jobs, at most
+// concurrency at a time. Results and errors are in the order of jobURLs; a
+// failing job does not abort the others.
+func (c *Client) GetJobParamsAll(ctx context.Context, jobURLs []string, concurrency int) ([][]models.ParamDef, []error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	defs := make([][]models.ParamDef, len(jobURLs))
+	errs := make([]error, len(jobURLs))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, jobURL := range jobURLs {
+		wg.Add(1)
+		go func(i int, jobURL string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			defs[i], errs[i] = c.GetJobParams(ctx, jobURL)
+		}(i, jobURL)
+	}
+	wg.Wait()
+	return defs, errs
+}
+
+// fillAgentChoices offers the controller's agents for unrestricted node
+// parameters and its labels for label parameters. On error the choices stay
+// empty and the form falls back to free text.
+func (c *Client) fillAgentChoices(ctx context.Context, defs []models.ParamDef) {
+	var nodes, labels []string
+	loaded := false
+	for i, d := range defs {
+		if (d.Kind != models.ParamNode && d.Kind != models.ParamLabel) || len(d.Choices) > 0 {
+			continue
+		}
+		if !loaded {
+			var err error
+			nodes, labels, err = c.ListAgents(ctx)
+			if err != nil {
+				slog.Debug("list agents failed", "error", err.Error())
+				return
+			}
+			loaded = true
+		}
+		choices := nodes
+		if d.Kind == models.ParamLabel {
+			choices = labels
+		}
+		if d.Default != "" && !slices.Contains(choices, d.Default) {
+			choices = append([]string{d.Default}, choices...)
+		}
+		defs[i].Choices = choices
+	}
+}
+
+type computerListResp struct {
+	Computer []struct {
+		Class          string `json:"_class"`
+		DisplayName    string `json:"displayName"`
+		AssignedLabels []struct {
+			Name string `json:"name"`
+		} `json:"assignedLabels"`
+	} `json:"computer"`
+}
+
+// ListAgents returns the node names (the controller itself as "built-in",
+// or "master" before 2.307) and every label assigned to a node, both sorted.
+func (c *Client) ListAgents(ctx context.Context) (nodes, labels []string, err error) {
+	var resp computerListResp
+	if err := c.getJSON(ctx, c.Host()+"/computer/api/json?tree=computer[displayName,assignedLabels[name]]", &resp); err != nil {
+		return nil, nil, err
+	}
+	seen := map[string]bool{}
+	for _, comp := range resp.Computer {
+		name := comp.DisplayName
+		if strings.HasSuffix(comp.Class, "MasterComputer") {
+			name = "built-in"
+			if info, ok := c.Server(); ok && !info.AtLeast("2.307") {
+				name = "master"
+			}
+		}
+		nodes = append(nodes, name)
+		for _, l := range comp.AssignedLabels {
+			if l.Name != "" && !seen[l.Name] {
+				seen[l.Name] = true
+				labels = append(labels, l.Name)
+			}
+		}
+	}
+	sort.Strings(nodes)
+	sort.Strings(labels)
+	return nodes, labels, nil
 }
 
 func mapParamType(t string) models.ParamKind {
@@ -291,45 +584,252 @@ func mapParamType(t string) models.ParamKind {
 		return models.ParamBoolean
 	case strings.Contains(t, "PasswordParameterDefinition"):
 		return models.ParamPassword
+	case strings.Contains(t, "NodeParameterDefinition"):
+		return models.ParamNode
+	case strings.Contains(t, "LabelParameterDefinition"):
+		return models.ParamLabel
 	default:
 		return ""
 	}
 }
 
-func (c *Client) TriggerBuild(ctx context.Context, jobURL string, params map[string]string) (string, error) {
-	if err := c.ensureCrumb(ctx); err != nil {
-		return "", err
-	}
+func (c *Client) TriggerBuild(ctx context.Context, jobURL string, params map[string]string) (queueURL string, err error) {
+	defer func() { c.audit("trigger", jobURL, params, err) }()
 	form := url.Values{}
 	for k, v := range params {
 		form.Set(k, v)
 	}
 	triggerURL := strings.TrimRight(jobURL, "/") + "/buildWithParameters"
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, triggerURL, strings.NewReader(form.Encode()))
-	if err != nil {
-		return "", err
-	}
-	req.SetBasicAuth(c.target.Username, c.token)
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	if field, value, ok := c.crumbHeader(); ok {
-		req.Header.Set(field, value)
-	}
-	resp, err := c.http.Do(req)
+	resp, err := c.doPost(ctx, triggerURL, form)
 	if err != nil {
 		return "", err
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("trigger failed (%d): %s", resp.StatusCode, string(body))
+		return "", &StatusError{Op: "trigger", Code: resp.StatusCode, Body: string(body)}
 	}
-	queueURL := resp.Header.Get("Location")
+	queueURL = resp.Header.Get("Location")
 	if queueURL == "" {
 		return "", fmt.Errorf("trigger succeeded but queue location missing")
 	}
 	return queueURL, nil
 }
 
+type jobDetailResp struct {
+	Name         string `json:"name"`
+	FullName     string `json:"fullName"`
+	URL          string `json:"url"`
+	Description  string `json:"description"`
+	Color        string `json:"color"`
+	HealthReport []struct {
+		Score       int    `json:"score"`
+		Description string `json:"description"`
+	} `json:"healthReport"`
+	LastBuild *struct {
+		Number    int    `json:"number"`
+		URL       string `json:"url"`
+		Result    string `json:"result"`
+		Building  bool   `json:"building"`
+		Timestamp int64  `json:"timestamp"`
+		Duration  int64  `json:"duration"`
+		changeSetsResp
+	} `json:"lastBuild"`
+}
+
+// changeSetsTree selects a build's commits: freestyle builds report one
+// changeSet, Pipeline builds a changeSets list, one per checkout.
+const changeSetsTree = "changeSet[items[commitId,msg,author[fullName]]],changeSets[items[commitId,msg,author[fullName]]]"
+
+type changeSetResp struct {
+	Items []struct {
+		CommitID string `json:"commitId"`
+		Msg      string `json:"msg"`
+		Author   struct {
+			FullName string `json:"fullName"`
+		} `json:"author"`
+	} `json:"items"`
+}
+
+type changeSetsResp struct {
+	ChangeSet  *changeSetResp  `json:"changeSet"`
+	ChangeSets []changeSetResp `json:"changeSets"`
+}
+
+func (r changeSetsResp) changes() []models.Change {
+	sets := r.ChangeSets
+	if r.ChangeSet != nil {
+		sets = append([]changeSetResp{*r.ChangeSet}, sets...)
+	}
+	var out []models.Change
+	for _, set := range sets {
+		for _, item := range set.Items {
+			out = append(out, models.Change{ID: item.CommitID, Author: item.Author.FullName, Message: strings.TrimSpace(item.Msg)})
+		}
+	}
+	return out
+}
+
+func (c *Client) GetJobDetail(ctx context.Context, jobURL string) (models.JobDetail, error) {
+	api := strings.TrimRight(jobURL, "/") + "/api/json?tree=name,fullName,url,description,color,healthReport[score,description],lastBuild[number,url,result,building,timestamp,duration," + changeSetsTree + "]"
+	var resp jobDetailResp
+	if err := c.getJSON(ctx, api, &resp); err != nil {
+		return models.JobDetail{}, err
+	}
+	detail := models.JobDetail{
+		Name:        resp.Name,
+		FullName:    resp.FullName,
+		URL:         resp.URL,
+		Description: strings.TrimSpace(resp.Description),
+		Color:       resp.Color,
+		HealthScore: -1,
+	}
+	if len(resp.HealthReport) > 0 {
+		detail.HealthScore = resp.HealthReport[0].Score
+		detail.HealthSummary = resp.HealthReport[0].Description
+	}
+	if lb := resp.LastBuild; lb != nil {
+		detail.LastBuild = &models.BuildSummary{
+			Number:   lb.Number,
+			URL:      lb.URL,
+			Result:   lb.Result,
+			Building: lb.Building,
+			Duration: time.Duration(lb.Duration) * time.Millisecond,
+			Changes:  lb.changes(),
+		}
+		if lb.Timestamp > 0 {
+			detail.LastBuild.Timestamp = time.UnixMilli(lb.Timestamp)
+		}
+	}
+	return detail, nil
+}
+
+type buildHistoryResp struct {
+	Builds []struct {
+		Number    int    `json:"number"`
+		URL       string `json:"url"`
+		Result    string `json:"result"`
+		Building  bool   `json:"building"`
+		Timestamp int64  `json:"timestamp"`
+		Duration  int64  `json:"duration"`
+		Actions   []struct {
+			Parameters []struct {
+				Name  string `json:"name"`
+				Value any    `json:"value"`
+			} `json:"parameters"`
+		} `json:"actions"`
+		changeSetsResp
+	} `json:"builds"`
+}
+
+func (c *Client) ListBuilds(ctx context.Context, jobURL string, limit int) ([]models.BuildSummary, error) {
+	if limit <= 0 {
+		limit = 25
+	}
+	api := fmt.Sprintf("%s/api/json?tree=builds[number,url,result,building,timestamp,duration,actions[parameters[name,value]],%s]{0,%d}", strings.TrimRight(jobURL, "/"), changeSetsTree, limit)
+	var resp buildHistoryResp
+	if err := c.getJSON(ctx, api, &resp); err != nil {
+		return nil, err
+	}
+	out := make([]models.BuildSummary, 0, len(resp.Builds))
+	for _, b := range resp.Builds {
+		params := map[string]string{}
+		for _, action := range b.Actions {
+			for _, p := range action.Parameters {
+				if p.Value == nil {
+					continue
+				}
+				params[p.Name] = fmt.Sprintf("%v", p.Value)
+			}
+		}
+		summary := models.BuildSummary{
+			Number:   b.Number,
+			URL:      b.URL,
+			Result:   b.Result,
+			Building: b.Building,
+			Duration: time.Duration(b.Duration) * time.Millisecond,
+			Params:   params,
+			Changes:  b.changes(),
+		}
+		if b.Timestamp > 0 {
+			summary.Timestamp = time.UnixMilli(b.Timestamp)
+		}
+		out = append(out, summary)
+	}
+	return out, nil
+}
+
+func (c *Client) EnableJob(ctx context.Context, jobURL string) error {
+	_, err := c.postForm(ctx, strings.TrimRight(jobURL, "/")+"/enable", nil)
+	c.audit("enable", jobURL, nil, err)
+	return err
+}
+
+// ScanMultibranch starts branch indexing ("Scan Multibranch Pipeline Now")
+// so new branches and pull requests show up as jobs.
+func (c *Client) ScanMultibranch(ctx context.Context, folderURL string) error {
+	_, err := c.postForm(ctx, strings.TrimRight(folderURL, "/")+"/build?delay=0", nil)
+	c.audit("scan", folderURL, nil, err)
+	return err
+}
+
+type indexingResp struct {
+	Building  bool   `json:"building"`
+	Result    string `json:"result"`
+	Timestamp int64  `json:"timestamp"`
+	Duration  int64  `json:"duration"`
+}
+
+func IndexingURL(folderURL string) string {
+	return strings.TrimRight(folderURL, "/") + "/indexing/"
+}
+
+// IndexingStatus reports the latest branch indexing of a multibranch
+// project as a build summary whose URL points at the indexing log.
+func (c *Client) IndexingStatus(ctx context.Context, folderURL string) (models.BuildSummary, error) {
+	var resp indexingResp
+	if err := c.getJSON(ctx, IndexingURL(folderURL)+"api/json?tree=building,result,timestamp,duration", &resp); err != nil {
+		return models.BuildSummary{}, err
+	}
+	summary := models.BuildSummary{
+		URL:      IndexingURL(folderURL),
+		Result:   resp.Result,
+		Building: resp.Building,
+		Duration: time.Duration(resp.Duration) * time.Millisecond,
+	}
+	if resp.Timestamp > 0 {
+		summary.Timestamp = time.UnixMilli(resp.Timestamp)
+	}
+	return summary, nil
+}
+
+// PollIndexing waits for an indexing that started after the given time to
+// finish and returns its result. Pass the timestamp of the indexing seen
+// before ScanMultibranch so the previous scan is not mistaken for this one.
+func (c *Client) PollIndexing(ctx context.Context, folderURL string, after time.Time) (string, error) {
+	p := newPoller(ctx, "poll indexing", 3*time.Second)
+	for {
+		if err := p.wait(); err != nil {
+			return "", err
+		}
+		status, err := c.IndexingStatus(ctx, folderURL)
+		if err != nil {
+			if err := p.fail(err); err != nil {
+				return "", err
+			}
+			continue
+		}
+		p.ok()
+		if status.Building || !status.Timestamp.After(after) {
+			continue
+		}
+		if status.Result == "" {
+			return "UNKNOWN", nil
+		}
+		return status.Result, nil
+	}
+}
+
 type queueResp struct {
 	Executable *struct {
 		Number int    `json:"number"`
@@ -340,29 +840,24 @@ type queueResp struct {
 
 func (c *Client) ResolveQueue(ctx context.Context, queueURL string) (string, int, error) {
 	api := strings.TrimRight(queueURL, "/") + "/api/json"
-	ticker := time.NewTicker(2 * time.Second)
-	defer ticker.Stop()
-	consecutiveErrors := 0
+	p := newPoller(ctx, "resolve queue", 2*time.Second)
 	for {
-		select {
-		case <-ctx.Done():
-			return "", 0, ctx.Err()
-		case <-ticker.C:
-			var q queueResp
-			if err := c.getJSON(ctx, api, &q); err != nil {
-				consecutiveErrors++
-				if consecutiveErrors >= 5 {
-					return "", 0, fmt.Errorf("resolve queue failed after %d retries: %w", consecutiveErrors, err)
-				}
-				continue
-			}
-			consecutiveErrors = 0
-			if q.Cancelled {
-				return "", 0, fmt.Errorf("queue item cancelled")
-			}
-			if q.Executable != nil && q.Executable.URL != "" {
-				return q.Executable.URL, q.Executable.Number, nil
+		if err := p.wait(); err != nil {
+			return "", 0, err
+		}
+		var q queueResp
+		if err := c.getJSON(ctx, api, &q); err != nil {
+			if err := p.fail(err); err != nil {
+				return "", 0, err
 			}
+			continue
+		}
+		p.ok()
+		if q.Cancelled {
+			return "", 0, fmt.Errorf("queue item cancelled")
+		}
+		if q.Executable != nil && q.Executable.URL != "" {
+			return q.Executable.URL, q.Executable.Number, nil
 		}
 	}
 }
@@ -374,44 +869,69 @@ type buildResp struct {
 
 func (c *Client) PollBuild(ctx context.Context, buildURL string) (string, error) {
 	api := strings.TrimRight(buildURL, "/") + "/api/json"
-	ticker := time.NewTicker(3 * time.Second)
-	defer ticker.Stop()
-	consecutiveErrors := 0
+	p := newPoller(ctx, "poll build", 3*time.Second)
 	for {
-		select {
-		case <-ctx.Done():
-			return "", ctx.Err()
-		case <-ticker.C:
-			var b buildResp
-			if err := c.getJSON(ctx, api, &b); err != nil {
-				consecutiveErrors++
-				if consecutiveErrors >= 5 {
-					return "", fmt.Errorf("poll build failed after %d retries: %w", consecutiveErrors, err)
-				}
-				continue
+		if err := p.wait(); err != nil {
+			return "", err
+		}
+		var b buildResp
+		if err := c.getJSON(ctx, api, &b); err != nil {
+			if err := p.fail(err); err != nil {
+				return "", err
 			}
-			consecutiveErrors = 0
-			if !b.Building {
-				if b.Result == "" {
-					return "UNKNOWN", nil
-				}
-				return b.Result, nil
+			continue
+		}
+		p.ok()
+		if !b.Building {
+			if b.Result == "" {
+				return "UNKNOWN", nil
 			}
+			return b.Result, nil
 		}
 	}
 }
 
+// StopBuild aborts a running build, like the stop button on its page.
+func (c *Client) StopBuild(ctx context.Context, buildURL string) error {
+	_, err := c.postForm(ctx, strings.TrimRight(buildURL, "/")+"/stop", nil)
+	c.audit("stop", buildURL, nil, err)
+	return err
+}
+
+// CancelQueueItem drops a build that is still waiting in the queue. queueURL
+// is the location TriggerBuild returned.
+func (c *Client) CancelQueueItem(ctx context.Context, queueURL string) error {
+	id, ok := QueueItemID(queueURL)
+	if !ok {
+		return fmt.Errorf("not a queue item URL: %s", queueURL)
+	}
+	_, err := c.postForm(ctx, fmt.Sprintf("%s/queue/cancelItem?id=%d", c.Host(), id), nil)
+	c.audit("cancel_queue_item", queueURL, nil, err)
+	return err
+}
+
+// audit records a state-changing call in the audit log, when one is set up.
+func (c *Client) audit(action, job string, params map[string]string, err error) {
+	audit.Record(audit.Entry{
+		Server:      c.Host(),
+		JenkinsUser: c.target.Username,
+		Action:      action,
+		Job:         job,
+		ParamsHash:  audit.ParamsHash(params),
+		Result:      audit.Result(err),
+	})
+}
+
 func (c *Client) ensureCrumb(ctx context.Context) error {
 	if _, _, ok := c.crumbHeader(); ok {
 		return nil
 	}
 	crumbURL := c.Host() + "/crumbIssuer/api/json"
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, crumbURL, nil)
+	req, err := c.newRequest(ctx, http.MethodGet, crumbURL, nil)
 	if err != nil {
 		return err
 	}
-	req.SetBasicAuth(c.target.Username, c.token)
-	resp, err := c.http.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return fmt.Errorf("fetch crumb: %w", err)
 	}
@@ -421,7 +941,7 @@ func (c *Client) ensureCrumb(ctx context.Context) error {
 	}
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("fetch crumb failed (%d): %s", resp.StatusCode, string(body))
+		return &StatusError{Op: "fetch crumb", Code: resp.StatusCode, Body: string(body)}
 	}
 	var cr crumb
 	if err := json.NewDecoder(resp.Body).Decode(&cr); err != nil {
@@ -437,6 +957,56 @@ func (c *Client) ensureCrumb(ctx context.Context) error {
 	return nil
 }
 
+func (c *Client) postForm(ctx context.Context, endpoint string, form url.Values) (http.Header, error) {
+	resp, err := c.doPost(ctx, endpoint, form)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, &StatusError{Op: "POST " + endpoint, Code: resp.StatusCode, Body: string(body)}
+	}
+	return resp.Header, nil
+}
+
+// doPost sends a form POST with the crumb header. When the server binds
+// crumbs to sessions, a crumb rejected because the session expired is
+// refetched once.
+func (c *Client) doPost(ctx context.Context, endpoint string, form url.Values) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if err := c.ensureCrumb(ctx); err != nil {
+			return nil, err
+		}
+		req, err := c.newRequest(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		field, value, hasCrumb := c.crumbHeader()
+		if hasCrumb {
+			req.Header.Set(field, value)
+		}
+		resp, err := c.do(req)
+		if err != nil {
+			return nil, err
+		}
+		info, _ := c.Server()
+		if resp.StatusCode != http.StatusForbidden || attempt > 0 || !hasCrumb || !info.SessionCrumbs() {
+			return resp, nil
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if !strings.Contains(strings.ToLower(string(body)), "crumb") {
+			resp.Body = io.NopCloser(bytes.NewReader(body))
+			return resp, nil
+		}
+		c.mu.Lock()
+		c.crumb = nil
+		c.mu.Unlock()
+	}
+}
+
 func (c *Client) crumbHeader() (string, string, bool) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
@@ -446,23 +1016,201 @@ func (c *Client) crumbHeader() (string, string, bool) {
 	return c.crumb.Field, c.crumb.Value, true
 }
 
+func (c *Client) GetConsoleText(ctx context.Context, buildURL string) (string, error) {
+	return c.getText(ctx, strings.TrimRight(buildURL, "/")+"/consoleText")
+}
+
+func (c *Client) GetJobConfig(ctx context.Context, jobURL string) (string, error) {
+	return c.getText(ctx, strings.TrimRight(jobURL, "/")+"/config.xml")
+}
+
+func (c *Client) getText(ctx context.Context, endpoint string) (string, error) {
+	body, err := c.getShared(ctx, endpoint)
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+// getJSON decodes endpoint into dst as it streams in, without holding the
+// body. Like getShared it joins an identical GET already in flight; the
+// callers then share the decoded value, which they only read.
 func (c *Client) getJSON(ctx context.Context, endpoint string, dst any) error {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, bytes.NewReader(nil))
+	target := reflect.ValueOf(dst).Elem()
+	v, err := c.coalesce(ctx, endpoint+" "+target.Type().String(), func() (any, error) {
+		decoded := reflect.New(target.Type())
+		err := c.streamJSON(ctx, endpoint, func(dec *json.Decoder) error {
+			return dec.Decode(decoded.Interface())
+		})
+		return decoded.Elem(), err
+	})
 	if err != nil {
 		return err
 	}
-	req.SetBasicAuth(c.target.Username, c.token)
+	target.Set(v.(reflect.Value))
+	return nil
+}
+
+// getShared GETs endpoint, or waits for an identical GET already in flight
+// and shares its body, so a double-pressed refresh hits the server once.
+func (c *Client) getShared(ctx context.Context, endpoint string) ([]byte, error) {
+	v, err := c.coalesce(ctx, endpoint, func() (any, error) {
+		return c.getBody(ctx, endpoint)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]byte), nil
+}
+
+// coalesce runs fn once for all callers asking for key at the same time. If
+// the run being waited on was cancelled by its own caller, the waiters that
+// are still alive run fn again themselves.
+func (c *Client) coalesce(ctx context.Context, key string, fn func() (any, error)) (any, error) {
+	v, err, shared := c.inflight.Do(key, fn)
+	if err != nil && shared && ctx.Err() == nil && (errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)) {
+		return fn()
+	}
+	return v, err
+}
+
+// ExternalURL maps a URL the server reported through the target's
+// url_rewrites, for requests and for opening it in a browser.
+func (c *Client) ExternalURL(u string) string {
+	return c.target.RewriteURL(u)
+}
+
+// newRequest builds a signed-in request to endpoint after url_rewrites, so
+// job, queue and build URLs carrying the controller's internal hostname
+// stay usable, with the path escaped by EscapeURL.
+func (c *Client) newRequest(ctx context.Context, method, endpoint string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, EscapeURL(c.ExternalURL(endpoint)), body)
+	if err != nil {
+		return nil, err
+	}
+	if c.target.Auth == models.AuthKerberos {
+		if err := c.setSPNEGOHeader(req); err != nil {
+			return nil, err
+		}
+		return req, nil
+	}
+	c.mu.RLock()
+	token := c.token
+	c.mu.RUnlock()
+	req.SetBasicAuth(c.target.Username, token)
+	return req, nil
+}
+
+// SetReauth lets the client sign in again when Jenkins rejects its token,
+// e.g. by running the target's auth_command. Every request answered with a
+// 401 asks fn for a new token once and is resent with it; requests that
+// fail together share one sign-in, and when the new token is rejected too
+// the 401 is reported instead of asking again.
+func (c *Client) SetReauth(fn func(ctx context.Context) (string, error)) {
+	c.reauthMu.Lock()
+	c.reauth = fn
+	c.reauthMu.Unlock()
+}
+
+// do sends req, signing in again through SetReauth's fn and resending it
+// once if Jenkins rejects the token.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
 	resp, err := c.http.Do(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || c.target.Auth == models.AuthKerberos {
+		return resp, err
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return resp, nil
+	}
+	_, rejected, _ := req.BasicAuth()
+	token, ok := c.refreshToken(req.Context(), rejected)
+	if !ok {
+		return resp, nil
+	}
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return resp, nil
+		}
+	}
+	retry.SetBasicAuth(c.target.Username, token)
+	resp.Body.Close()
+	resp, err = c.http.Do(retry)
+	if err == nil && resp.StatusCode == http.StatusUnauthorized {
+		c.reauthMu.Lock()
+		c.badToken = token
+		c.reauthMu.Unlock()
+	}
+	return resp, err
+}
+
+// refreshToken returns a token to resend a request rejected with the token
+// rejected. ok is false when there is no reauth fn, it failed, or the
+// rejected token came from a sign-in and never worked.
+func (c *Client) refreshToken(ctx context.Context, rejected string) (string, bool) {
+	c.reauthMu.Lock()
+	defer c.reauthMu.Unlock()
+	if c.reauth == nil {
+		return "", false
+	}
+	c.mu.RLock()
+	current := c.token
+	c.mu.RUnlock()
+	if current != rejected {
+		return current, true
+	}
+	if rejected == c.badToken {
+		return "", false
+	}
+	token, err := c.reauth(ctx)
+	if err != nil || token == "" {
+		slog.Debug("re-authentication failed", "host", c.Host(), "error", fmt.Sprint(err))
+		return "", false
+	}
+	redact.Add(token)
+	c.mu.Lock()
+	c.token = token
+	c.mu.Unlock()
+	return token, true
+}
+
+// StatusError is a request Jenkins answered with an unexpected status.
+type StatusError struct {
+	// Op names the request, e.g. "GET <url>" or "trigger".
+	Op   string
+	Code int
+	Body string
+}
+
+func (e *StatusError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("%s failed (%d)", e.Op, e.Code)
+	}
+	return fmt.Sprintf("%s failed (%d): %s", e.Op, e.Code, e.Body)
+}
+
+// IsStatus reports whether err is or wraps a StatusError with code.
+func IsStatus(err error, code int) bool {
+	var status *StatusError
+	return errors.As(err, &status) && status.Code == code
+}
+
+func (c *Client) getBody(ctx context.Context, endpoint string) ([]byte, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, endpoint, nil)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
 	}
 	defer resp.Body.Close()
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("GET %s failed (%d): %s", endpoint, resp.StatusCode, string(body))
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
 	}
-	if err := json.NewDecoder(resp.Body).Decode(dst); err != nil {
-		return err
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, &StatusError{Op: "GET " + endpoint, Code: resp.StatusCode, Body: string(body)}
 	}
-	return nil
+	return body, nil
 }
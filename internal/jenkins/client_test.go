@@ -0,0 +1,484 @@
+package jenkins
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"jenkins-tui/internal/audit"
+	"jenkins-tui/internal/models"
+)
+
+func TestGetJobParamsOffersAgentsAndLabels(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/job/deploy/api/json"):
+			w.Write([]byte(`{"property":[{"parameterDefinitions":[
+				{"name":"NODE","type":"NodeParameterDefinition","allowedSlaves":["ALL (no restriction)"],"defaultSlaves":["linux-1"]},
+				{"name":"PINNED","type":"NodeParameterDefinition","allowedSlaves":["mac-1","mac-2"],"defaultSlaves":["mac-2"]},
+				{"name":"LABEL","type":"LabelParameterDefinition","defaultParameterValue":{"label":"linux && docker"}}
+			]}]}`))
+		case r.URL.Path == "/computer/api/json":
+			w.Write([]byte(`{"computer":[
+				{"_class":"hudson.model.Hudson$MasterComputer","displayName":"Built-In Node","assignedLabels":[{"name":"built-in"}]},
+				{"_class":"hudson.slaves.SlaveComputer","displayName":"linux-1","assignedLabels":[{"name":"linux"},{"name":"linux-1"}]}
+			]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient(models.JenkinsTarget{Host: srv.URL, Username: "u"}, "t", 5*time.Second)
+	defs, err := client.GetJobParams(context.Background(), srv.URL+"/job/deploy/")
+	if err != nil {
+		t.Fatalf("GetJobParams: %v", err)
+	}
+	want := []models.ParamDef{
+		{Name: "NODE", Kind: models.ParamNode, Default: "linux-1", Choices: []string{"built-in", "linux-1"}},
+		{Name: "PINNED", Kind: models.ParamNode, Default: "mac-2", Choices: []string{"mac-1", "mac-2"}},
+		{Name: "LABEL", Kind: models.ParamLabel, Default: "linux && docker", Choices: []string{"linux && docker", "built-in", "linux", "linux-1"}},
+	}
+	if !reflect.DeepEqual(defs, want) {
+		t.Fatalf("GetJobParams =\n%+v\nwant\n%+v", defs, want)
+	}
+}
+
+func TestGetJobParamsAllBoundsConcurrencyAndKeepsOrder(t *testing.T) {
+	var running, peak atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/job/"), "/api/json")
+		if name == "broken" {
+			http.Error(w, "boom", http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintf(w, `{"property":[{"parameterDefinitions":[{"name":%q,"type":"StringParameterDefinition"}]}]}`, strings.ToUpper(name))
+	}))
+	defer srv.Close()
+
+	client := NewClient(models.JenkinsTarget{Host: srv.URL, Username: "u"}, "t", 5*time.Second)
+	jobs := []string{"a", "b", "broken", "c", "d", "e"}
+	urls := make([]string, len(jobs))
+	for i, job := range jobs {
+		urls[i] = srv.URL + "/job/" + job + "/"
+	}
+	defs, errs := client.GetJobParamsAll(context.Background(), urls, 2)
+	if got := peak.Load(); got > 2 {
+		t.Fatalf("%d requests in flight, want at most 2", got)
+	}
+	for i, job := range jobs {
+		if job == "broken" {
+			if errs[i] == nil {
+				t.Fatalf("broken job: want an error, got %+v", defs[i])
+			}
+			continue
+		}
+		if errs[i] != nil || len(defs[i]) != 1 || defs[i][0].Name != strings.ToUpper(job) {
+			t.Fatalf("job %s = %+v %v", job, defs[i], errs[i])
+		}
+	}
+}
+
+func TestBuildsCarryTheirChangeSets(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.URL.Query().Get("tree"), "changeSets[items[commitId,msg,author[fullName]]]") {
+			http.Error(w, "changeSets not requested", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"builds":[
+			{"number":8,"result":"FAILURE","changeSets":[
+				{"items":[{"commitId":"a1b2c3d4e5","msg":"Fix login\n","author":{"fullName":"Alice"}}]},
+				{"items":[{"commitId":"f6e5d4","msg":"Bump lib","author":{"fullName":"Bob"}}]}
+			]},
+			{"number":7,"result":"SUCCESS","changeSet":{"items":[{"commitId":"0badc0de","msg":"Freestyle change","author":{"fullName":"Carol"}}]}}
+		]}`))
+	}))
+	defer srv.Close()
+
+	client := NewClient(models.JenkinsTarget{Host: srv.URL, Username: "u"}, "t", 5*time.Second)
+	builds, err := client.ListBuilds(context.Background(), srv.URL+"/job/app/", 2)
+	if err != nil {
+		t.Fatalf("ListBuilds: %v", err)
+	}
+	want := [][]models.Change{
+		{{ID: "a1b2c3d4e5", Author: "Alice", Message: "Fix login"}, {ID: "f6e5d4", Author: "Bob", Message: "Bump lib"}},
+		{{ID: "0badc0de", Author: "Carol", Message: "Freestyle change"}},
+	}
+	for i, b := range builds {
+		if !reflect.DeepEqual(b.Changes, want[i]) {
+			t.Fatalf("build #%d changes = %+v, want %+v", b.Number, b.Changes, want[i])
+		}
+	}
+}
+
+func TestTriggerBuildWritesAuditEntry(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/crumbIssuer/api/json":
+			http.NotFound(w, r)
+		case "/job/deploy/buildWithParameters":
+			w.Header().Set("Location", "http://"+r.Host+"/queue/item/7/")
+			w.WriteHeader(http.StatusCreated)
+		case "/job/deploy/7/stop":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	path := filepath.Join(t.TempDir(), "audit.log")
+	if err := audit.Setup(path); err != nil {
+		t.Fatalf("audit.Setup: %v", err)
+	}
+	defer audit.Setup("")
+
+	client := NewClient(models.JenkinsTarget{Host: srv.URL, Username: "u"}, "t", 5*time.Second)
+	params := map[string]string{"ENV": "prod"}
+	if _, err := client.TriggerBuild(context.Background(), srv.URL+"/job/deploy/", params); err != nil {
+		t.Fatalf("TriggerBuild: %v", err)
+	}
+	if err := client.StopBuild(context.Background(), srv.URL+"/job/deploy/7/"); err == nil {
+		t.Fatalf("expected the stop to fail")
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read audit log: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected two audit lines, got %q", b)
+	}
+	var trigger, stop audit.Entry
+	json.Unmarshal([]byte(lines[0]), &trigger)
+	json.Unmarshal([]byte(lines[1]), &stop)
+	if trigger.Action != "trigger" || trigger.JenkinsUser != "u" || trigger.Result != "ok" || trigger.ParamsHash != audit.ParamsHash(params) {
+		t.Fatalf("unexpected trigger entry %+v", trigger)
+	}
+	if strings.Contains(string(b), "prod") {
+		t.Fatalf("parameter values must not be logged: %s", b)
+	}
+	if stop.Action != "stop" || !strings.HasPrefix(stop.Result, "error:") {
+		t.Fatalf("unexpected stop entry %+v", stop)
+	}
+}
+
+func TestURLRewritesReachInternalJobURLs(t *testing.T) {
+	const internal = "http://jenkins-01.corp.internal:8080"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/json":
+			w.Write([]byte(`{"jobs":[{"name":"deploy","url":"` + internal + `/job/deploy/","_class":"org.jenkinsci.plugins.workflow.job.WorkflowJob"}]}`))
+		case "/job/deploy/7/consoleText":
+			w.Write([]byte("Finished: SUCCESS"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	target := models.JenkinsTarget{Host: srv.URL, Username: "u", URLRewrites: []models.URLRewrite{{From: internal, To: srv.URL}}}
+	client := NewClient(target, "t", 5*time.Second)
+	nodes, err := client.ListJobNodes(context.Background(), "", "")
+	if err != nil || len(nodes) != 1 {
+		t.Fatalf("ListJobNodes: %v %+v", err, nodes)
+	}
+	if got := client.ExternalURL(nodes[0].URL); got != srv.URL+"/job/deploy/" {
+		t.Fatalf("ExternalURL = %q", got)
+	}
+	if got := client.ExternalURL("http://jenkins-01.corp.internal:80800/job/x/"); !strings.HasPrefix(got, internal) {
+		t.Fatalf("a prefix must match whole host and path segments, got %q", got)
+	}
+	log, err := client.GetConsoleText(context.Background(), nodes[0].URL+"7/")
+	if err != nil || log != "Finished: SUCCESS" {
+		t.Fatalf("GetConsoleText through the rewrite: %q %v", log, err)
+	}
+}
+
+func TestRequestsEscapeSpecialJobNames(t *testing.T) {
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.EscapedPath())
+		switch r.URL.Path {
+		case "/job/équipe/api/json":
+			w.Write([]byte(`{"jobs":[{"name":"100% done","url":"http://` + r.Host + `/job/%C3%A9quipe/job/100%25%20done/","_class":"hudson.model.FreeStyleProject"}]}`))
+		case "/job/équipe/job/100% done/api/json":
+			w.Write([]byte(`{"name":"100% done","fullName":"équipe/100% done"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient(models.JenkinsTarget{Host: srv.URL, Username: "u"}, "t", 5*time.Second)
+	nodes, err := client.ListJobNodes(context.Background(), JobURL(srv.URL, "équipe"), "équipe")
+	if err != nil || len(nodes) != 1 || nodes[0].FullName != "équipe/100% done" {
+		t.Fatalf("ListJobNodes: %v %+v", err, nodes)
+	}
+	if CanonicalJobURL(nodes[0].URL) != CanonicalJobURL(JobURL(srv.URL, nodes[0].FullName)) {
+		t.Fatalf("listed and built URLs should match: %q vs %q", nodes[0].URL, JobURL(srv.URL, nodes[0].FullName))
+	}
+	// A URL pasted with the name as written, stray '%' included.
+	if _, err := client.GetJobDetail(context.Background(), srv.URL+"/job/équipe/job/100% done/"); err != nil {
+		t.Fatalf("GetJobDetail with an unescaped URL: %v (requested %v)", err, paths)
+	}
+}
+
+func TestKerberosTargetNeedsATicket(t *testing.T) {
+	dir := t.TempDir()
+	conf := filepath.Join(dir, "krb5.conf")
+	if err := os.WriteFile(conf, []byte("[libdefaults]\n  default_realm = EXAMPLE.COM\n"), 0o600); err != nil {
+		t.Fatalf("write krb5.conf: %v", err)
+	}
+	t.Setenv("KRB5_CONFIG", conf)
+	t.Setenv("KRB5CCNAME", "FILE:"+filepath.Join(dir, "missing"))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("no request should be sent without a ticket, got %s", r.URL)
+	}))
+	defer srv.Close()
+
+	client := NewClient(models.JenkinsTarget{Host: srv.URL, Username: "me", Auth: models.AuthKerberos}, "", 5*time.Second)
+	if _, err := client.ListJobNodes(context.Background(), "", ""); err == nil || !strings.Contains(err.Error(), "run kinit") {
+		t.Fatalf("expected a kinit hint, got %v", err)
+	}
+}
+
+func TestCCachePathNamesUnreadableCacheTypes(t *testing.T) {
+	t.Setenv("KRB5CCNAME", "FILE:/tmp/krb5cc_test")
+	if path, err := ccachePath(); err != nil || path != "/tmp/krb5cc_test" {
+		t.Fatalf("FILE: cache = %q %v", path, err)
+	}
+	t.Setenv("KRB5CCNAME", "/tmp/krb5cc_plain")
+	if path, err := ccachePath(); err != nil || path != "/tmp/krb5cc_plain" {
+		t.Fatalf("plain path = %q %v", path, err)
+	}
+	for _, name := range []string{"KCM:", "KEYRING:persistent:1000", "API:ABCD-1234", "MSLSA:"} {
+		t.Setenv("KRB5CCNAME", name)
+		_, err := ccachePath()
+		if err == nil || !strings.Contains(err.Error(), "cannot be read") {
+			t.Fatalf("KRB5CCNAME=%s: expected an unsupported cache error, got %v", name, err)
+		}
+	}
+}
+
+func TestSearchJobsStopsReadingAtLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/search/suggestOpenSearch":
+			http.NotFound(w, r)
+		case "/search/suggest":
+			// Anything past the second job must never be parsed.
+			w.Write([]byte(`{"suggestions":[
+				{"name":"people","url":"/asynchPeople/"},
+				{"name":"api","path":"platform/api","url":"/job/platform/job/api/"},
+				{"name":"web","path":"platform/web","url":"/job/platform/job/web/"},
+				not json at all`))
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient(models.JenkinsTarget{Host: srv.URL, Username: "u"}, "t", time.Second)
+	nodes, err := client.SearchJobs(context.Background(), "platform", 2)
+	if err != nil {
+		t.Fatalf("SearchJobs: %v", err)
+	}
+	var names []string
+	for _, n := range nodes {
+		names = append(names, n.FullName)
+	}
+	if strings.Join(names, ",") != "platform/api,platform/web" {
+		t.Fatalf("unexpected results %v", names)
+	}
+}
+
+func TestSearchJobsOpenSearchSkipsPastScanWindow(t *testing.T) {
+	var names, paths, urls []string
+	for i := 0; i < 500; i++ {
+		names = append(names, fmt.Sprintf("job-%d", i))
+		paths = append(paths, fmt.Sprintf("apps/job-%d", i))
+		urls = append(urls, fmt.Sprintf("/job/apps/job/job-%d/", i))
+	}
+	body, _ := json.Marshal([]any{"job", names, paths, urls, map[string]any{"extra": []int{1, 2}}})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(body)
+	}))
+	defer srv.Close()
+
+	client := NewClient(models.JenkinsTarget{Host: srv.URL, Username: "u"}, "t", time.Second)
+	nodes, err := client.SearchJobs(context.Background(), "job", 3)
+	if err != nil {
+		t.Fatalf("SearchJobs: %v", err)
+	}
+	if len(nodes) != 3 || nodes[2].FullName != "apps/job-2" || !strings.HasSuffix(nodes[2].URL, "/job/apps/job/job-2/") {
+		t.Fatalf("unexpected results %+v", nodes)
+	}
+}
+
+func TestResponsesOverTheLimitFail(t *testing.T) {
+	SetMaxResponseSize(1)
+	t.Cleanup(func() { SetMaxResponseSize(0) })
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/consoleText") {
+			w.Header().Set("Content-Type", "text/plain;charset=UTF-8")
+			w.Write([]byte(strings.Repeat("log line\n", 300<<10)))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json;charset=utf-8")
+		w.Write([]byte(`{"jobs":[{"name":"` + strings.Repeat("x", 2<<20) + `"}]}`))
+	}))
+	defer srv.Close()
+
+	client := NewClient(models.JenkinsTarget{Host: srv.URL, Username: "u"}, "t", 5*time.Second)
+	_, err := client.ListJobNodes(context.Background(), srv.URL, "")
+	if !errors.Is(err, ErrResponseTooLarge) || !strings.Contains(err.Error(), "max_response_mb") {
+		t.Fatalf("expected ErrResponseTooLarge, got %v", err)
+	}
+	if text, err := client.GetConsoleText(context.Background(), srv.URL+"/job/big/1/"); err != nil || len(text) < 2<<20 {
+		t.Fatalf("console logs should not be capped, got %d bytes, %v", len(text), err)
+	}
+
+	SetMaxResponseSize(4)
+	if _, err := client.ListJobNodes(context.Background(), srv.URL, ""); err != nil {
+		t.Fatalf("a larger limit should let the listing through: %v", err)
+	}
+}
+
+func TestNewTransportAppliesTuning(t *testing.T) {
+	def := newTransport(models.TransportTuning{})
+	if def.MaxIdleConnsPerHost != defaultMaxIdleConnsPerHost || def.IdleConnTimeout != defaultIdleConnTimeout || !def.ForceAttemptHTTP2 {
+		t.Fatalf("unexpected defaults: %d %v %v", def.MaxIdleConnsPerHost, def.IdleConnTimeout, def.ForceAttemptHTTP2)
+	}
+	off := false
+	tuned := newTransport(models.TransportTuning{MaxIdleConnsPerHost: 4, IdleConnTimeout: time.Minute, ForceAttemptHTTP2: &off})
+	if tuned.MaxIdleConnsPerHost != 4 || tuned.IdleConnTimeout != time.Minute || tuned.ForceAttemptHTTP2 {
+		t.Fatalf("tuning not applied: %d %v %v", tuned.MaxIdleConnsPerHost, tuned.IdleConnTimeout, tuned.ForceAttemptHTTP2)
+	}
+}
+
+func TestIdenticalGetsInFlightAreCoalesced(t *testing.T) {
+	var hits atomic.Int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		<-release
+		w.Write([]byte(`{"jobs":[{"name":"deploy","url":"/job/deploy/","_class":"hudson.model.FreeStyleProject"}]}`))
+	}))
+	defer srv.Close()
+
+	client := NewClient(models.JenkinsTarget{Host: srv.URL, Username: "u"}, "t", 5*time.Second)
+	cancelled, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	results := make([]int, 3)
+	errs := make([]error, 3)
+	for i := range results {
+		ctx := context.Background()
+		if i == 0 {
+			ctx = cancelled
+		}
+		wg.Add(1)
+		go func(i int, ctx context.Context) {
+			defer wg.Done()
+			nodes, err := client.ListJobNodes(ctx, srv.URL, "")
+			results[i], errs[i] = len(nodes), err
+		}(i, ctx)
+		if i == 0 {
+			// Let the first request reach the server before the others join it.
+			for hits.Load() == 0 {
+				time.Sleep(time.Millisecond)
+			}
+		}
+	}
+	time.Sleep(20 * time.Millisecond)
+	if got := hits.Load(); got != 1 {
+		t.Fatalf("expected one request in flight, got %d", got)
+	}
+	// The leader gives up; the waiters must not inherit its cancellation.
+	cancel()
+	close(release)
+	wg.Wait()
+	if errs[0] == nil {
+		t.Fatalf("the cancelled caller should see its error")
+	}
+	for i := 1; i < 3; i++ {
+		if errs[i] != nil || results[i] != 1 {
+			t.Fatalf("waiter %d got %d nodes, %v", i, results[i], errs[i])
+		}
+	}
+}
+
+func TestRejectedTokenSignsInAgainOnce(t *testing.T) {
+	valid := "fresh"
+	var mu sync.Mutex
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, token, _ := r.BasicAuth()
+		mu.Lock()
+		ok := token == valid
+		mu.Unlock()
+		if !ok {
+			http.Error(w, "bad token", http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	client := NewClient(models.JenkinsTarget{Host: srv.URL, Username: "u"}, "expired", 5*time.Second)
+	if err := client.ValidateConnection(context.Background()); !IsStatus(err, http.StatusUnauthorized) {
+		t.Fatalf("without a reauth func the 401 should surface, got %v", err)
+	}
+	var signIns atomic.Int32
+	client.SetReauth(func(context.Context) (string, error) {
+		signIns.Add(1)
+		time.Sleep(20 * time.Millisecond)
+		return "fresh", nil
+	})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var payload map[string]any
+			if err := client.getJSON(context.Background(), fmt.Sprintf("%s/job/%d/api/json", srv.URL, i), &payload); err != nil {
+				t.Errorf("request %d: %v", i, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if n := signIns.Load(); n != 1 {
+		t.Fatalf("expected one sign-in for requests rejected together, got %d", n)
+	}
+
+	mu.Lock()
+	valid = "newer"
+	mu.Unlock()
+	if err := client.ValidateConnection(context.Background()); !IsStatus(err, http.StatusUnauthorized) {
+		t.Fatalf("a token the sign-in returned that is rejected should be reported, got %v", err)
+	}
+	if err := client.ValidateConnection(context.Background()); !IsStatus(err, http.StatusUnauthorized) {
+		t.Fatalf("expected the 401 again, got %v", err)
+	}
+	if n := signIns.Load(); n != 2 {
+		t.Fatalf("expected one more sign-in, not one per request, once its token is rejected too; got %d", n)
+	}
+}
@@ -0,0 +1,225 @@
+package jenkins
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"jenkins-tui/internal/ui"
+)
+
+type CheckStatus string
+
+const (
+	CheckOK      CheckStatus = "ok"
+	CheckWarn    CheckStatus = "warn"
+	CheckFailed  CheckStatus = "failed"
+	CheckSkipped CheckStatus = "skipped"
+)
+
+// Check is one step of a connection diagnosis. Hint says what to change
+// when the step failed.
+type Check struct {
+	Name     string
+	Status   CheckStatus
+	Detail   string
+	Hint     string
+	Duration time.Duration
+}
+
+// Diagnose walks the connection one layer at a time (proxy, DNS, TCP, TLS,
+// auth, crumb, a sample API call) and stops at the first failure, marking
+// the remaining steps skipped. With authenticate unset only the network
+// layers are checked.
+func (c *Client) Diagnose(ctx context.Context, authenticate bool) []Check {
+	var checks []Check
+	failed := false
+	run := func(name string, step func() Check) {
+		if failed {
+			checks = append(checks, Check{Name: name, Status: CheckSkipped, Detail: "skipped after an earlier failure"})
+			return
+		}
+		start := time.Now()
+		ch := step()
+		ch.Name = name
+		ch.Duration = time.Since(start)
+		failed = ch.Status == CheckFailed
+		checks = append(checks, ch)
+	}
+
+	u, err := url.Parse(c.Host())
+	if err != nil || u.Host == "" {
+		return []Check{{Name: "url", Status: CheckFailed, Detail: fmt.Sprintf("invalid host %q", c.target.Host), Hint: "use a full URL such as https://jenkins.example.com"}}
+	}
+	port := u.Port()
+	if port == "" {
+		port = "443"
+		if u.Scheme == "http" {
+			port = "80"
+		}
+	}
+	addr := net.JoinHostPort(u.Hostname(), port)
+	var proxy *url.URL
+
+	run("proxy", func() Check {
+		if c.proxy == nil {
+			return Check{Status: CheckOK, Detail: "direct connection"}
+		}
+		req, _ := http.NewRequest(http.MethodGet, c.Host(), nil)
+		p, err := c.proxy(req)
+		if err != nil {
+			return Check{Status: CheckFailed, Detail: err.Error(), Hint: "check HTTPS_PROXY/HTTP_PROXY"}
+		}
+		if p == nil {
+			return Check{Status: CheckOK, Detail: "direct connection (NO_PROXY or no proxy set)"}
+		}
+		proxy = p
+		detail := "via " + p.Redacted()
+		if c.target.Proxy.Auth != "" {
+			detail += " with " + c.target.Proxy.Auth + " authentication"
+		}
+		return Check{Status: CheckOK, Detail: detail}
+	})
+	run("dns", func() Check {
+		if proxy != nil {
+			return Check{Status: CheckSkipped, Detail: "resolved by the proxy"}
+		}
+		ips, err := net.DefaultResolver.LookupHost(ctx, u.Hostname())
+		if err != nil {
+			var dnsErr *net.DNSError
+			if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+				return Check{Status: CheckFailed, Detail: "no such host " + u.Hostname(), Hint: "check the host name, VPN, or /etc/hosts"}
+			}
+			return Check{Status: CheckFailed, Detail: err.Error(), Hint: "check your DNS resolver or VPN"}
+		}
+		return Check{Status: CheckOK, Detail: u.Hostname() + " " + ui.Glyph("→", "->") + " " + strings.Join(ips, ", ")}
+	})
+	run("tcp", func() Check {
+		target := addr
+		if proxy != nil {
+			target = proxy.Host
+		}
+		conn, err := (&net.Dialer{Timeout: 10 * time.Second}).DialContext(ctx, "tcp", target)
+		if err != nil {
+			return Check{Status: CheckFailed, Detail: err.Error(), Hint: "the port is closed or filtered; check firewall, VPN, or the port in the host URL"}
+		}
+		conn.Close()
+		return Check{Status: CheckOK, Detail: "connected to " + target}
+	})
+	run("tls", func() Check {
+		if u.Scheme != "https" {
+			return Check{Status: CheckWarn, Detail: "plain HTTP; the token is sent unencrypted"}
+		}
+		if proxy != nil {
+			return Check{Status: CheckSkipped, Detail: "negotiated through the proxy tunnel"}
+		}
+		cfg := &tls.Config{ServerName: u.Hostname()}
+		if c.transport != nil && c.transport.TLSClientConfig != nil {
+			cfg = c.transport.TLSClientConfig.Clone()
+			cfg.ServerName = u.Hostname()
+		}
+		dialer := &tls.Dialer{NetDialer: &net.Dialer{Timeout: 10 * time.Second}, Config: cfg}
+		conn, err := dialer.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return tlsFailure(err)
+		}
+		defer conn.Close()
+		state := conn.(*tls.Conn).ConnectionState()
+		detail := tls.VersionName(state.Version)
+		if len(state.PeerCertificates) > 0 {
+			cert := state.PeerCertificates[0]
+			detail += fmt.Sprintf(", certificate %s expires %s", cert.Subject.CommonName, cert.NotAfter.Format("2006-01-02"))
+		}
+		if cfg.InsecureSkipVerify {
+			return Check{Status: CheckWarn, Detail: detail + "; verification disabled (insecure_skip_tls_verify)"}
+		}
+		return Check{Status: CheckOK, Detail: detail}
+	})
+	if !authenticate {
+		return checks
+	}
+	run("auth", func() Check {
+		req, err := c.newRequest(ctx, http.MethodGet, c.Host()+"/api/json?tree=mode", nil)
+		if err != nil {
+			return Check{Status: CheckFailed, Detail: err.Error()}
+		}
+		resp, err := c.http.Do(req)
+		if err != nil {
+			return Check{Status: CheckFailed, Detail: err.Error()}
+		}
+		resp.Body.Close()
+		switch {
+		case resp.StatusCode == http.StatusUnauthorized:
+			return Check{Status: CheckFailed, Detail: "401: credentials rejected", Hint: "check the username and API token (rotate it with t)"}
+		case resp.StatusCode == http.StatusForbidden:
+			return Check{Status: CheckFailed, Detail: "403: signed in as " + c.target.Username + " but missing Overall/Read", Hint: "ask a Jenkins admin for read access"}
+		case resp.StatusCode == http.StatusProxyAuthRequired:
+			return Check{Status: CheckFailed, Detail: "407: the proxy wants credentials", Hint: "add credentials to the proxy URL, or set proxy.auth to ntlm or negotiate"}
+		case resp.StatusCode < 200 || resp.StatusCode >= 300:
+			return Check{Status: CheckFailed, Detail: fmt.Sprintf("unexpected status %d from %s", resp.StatusCode, req.URL.Redacted()), Hint: "the host may not be a Jenkins root URL"}
+		}
+		detail := "signed in as " + c.target.Username
+		if v := resp.Header.Get("X-Jenkins"); v != "" {
+			detail += ", Jenkins " + v
+		}
+		return Check{Status: CheckOK, Detail: detail}
+	})
+	run("crumb", func() Check {
+		if err := c.ensureCrumb(ctx); err != nil {
+			return Check{Status: CheckFailed, Detail: err.Error(), Hint: "triggering builds will fail until the crumb issuer answers"}
+		}
+		if _, _, ok := c.crumbHeader(); !ok {
+			return Check{Status: CheckOK, Detail: "no crumb issuer (CSRF protection off)"}
+		}
+		return Check{Status: CheckOK, Detail: "CSRF crumb issued"}
+	})
+	run("api", func() Check {
+		nodes, err := c.ListJobNodes(ctx, c.Host(), "")
+		if err != nil {
+			return Check{Status: CheckFailed, Detail: err.Error()}
+		}
+		return Check{Status: CheckOK, Detail: fmt.Sprintf("listed %d top-level items", len(nodes))}
+	})
+	return checks
+}
+
+func tlsFailure(err error) Check {
+	var unknown x509.UnknownAuthorityError
+	var hostname x509.HostnameError
+	var invalid x509.CertificateInvalidError
+	switch {
+	case errors.As(err, &unknown):
+		return Check{Status: CheckFailed, Detail: "certificate signed by an unknown authority", Hint: "install the company CA, or set insecure_skip_tls_verify for this server"}
+	case errors.As(err, &hostname):
+		return Check{Status: CheckFailed, Detail: hostname.Error(), Hint: "the certificate is for a different name; use the host it was issued for"}
+	case errors.As(err, &invalid) && invalid.Reason == x509.Expired:
+		return Check{Status: CheckFailed, Detail: "certificate expired or not yet valid", Hint: "renew the certificate or check the system clock"}
+	}
+	return Check{Status: CheckFailed, Detail: err.Error(), Hint: "the port may not speak TLS; try http://"}
+}
+
+// Unreachable reports whether err means the server could not be reached at
+// all (DNS, refused or dropped connections, timeouts) rather than that it
+// answered with an error.
+func Unreachable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var dnsErr *net.DNSError
+	var opErr *net.OpError
+	if errors.As(err, &dnsErr) || errors.As(err, &opErr) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
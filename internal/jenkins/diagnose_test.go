@@ -0,0 +1,95 @@
+package jenkins
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"jenkins-tui/internal/models"
+)
+
+func checkStatuses(checks []Check) string {
+	parts := make([]string, 0, len(checks))
+	for _, c := range checks {
+		parts = append(parts, c.Name+"="+string(c.Status))
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestDiagnoseHealthyServer(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Jenkins", "2.452.1")
+		switch r.URL.Path {
+		case "/api/json":
+			w.Write([]byte(`{"jobs":[{"name":"a","url":"/job/a/","_class":"hudson.model.FreeStyleProject"}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient(models.JenkinsTarget{Host: srv.URL, Username: "u"}, "t", 5*time.Second)
+	checks := client.Diagnose(context.Background(), true)
+	want := "proxy=ok dns=ok tcp=ok tls=warn auth=ok crumb=ok api=ok"
+	if got := checkStatuses(checks); got != want {
+		t.Fatalf("Diagnose = %s, want %s", got, want)
+	}
+	if !strings.Contains(checks[4].Detail, "Jenkins 2.452.1") || !strings.Contains(checks[5].Detail, "no crumb issuer") {
+		t.Fatalf("unexpected details %+v", checks)
+	}
+}
+
+func TestDiagnoseStopsAtRejectedToken(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	client := NewClient(models.JenkinsTarget{Host: srv.URL, Username: "u"}, "bad", 5*time.Second)
+	checks := client.Diagnose(context.Background(), true)
+	if got := checkStatuses(checks); !strings.HasSuffix(got, "auth=failed crumb=skipped api=skipped") {
+		t.Fatalf("expected auth failure to skip later steps, got %s", got)
+	}
+	if checks[4].Hint == "" {
+		t.Fatalf("expected a hint for rejected credentials")
+	}
+}
+
+func TestDiagnoseReportsUntrustedCertificate(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	client := NewClient(models.JenkinsTarget{Host: srv.URL, Username: "u"}, "t", 5*time.Second)
+	checks := client.Diagnose(context.Background(), true)
+	if got := checkStatuses(checks); !strings.Contains(got, "tls=failed auth=skipped") {
+		t.Fatalf("expected TLS failure, got %s", got)
+	}
+	if !strings.Contains(checks[3].Detail, "unknown authority") {
+		t.Fatalf("expected unknown authority diagnosis, got %q", checks[3].Detail)
+	}
+
+	insecure := NewClient(models.JenkinsTarget{Host: srv.URL, Username: "u", InsecureSkipTLSVerify: true}, "t", 5*time.Second)
+	if got := checkStatuses(insecure.Diagnose(context.Background(), false)); got != "proxy=ok dns=ok tcp=ok tls=warn" {
+		t.Fatalf("expected network-only checks with a TLS warning, got %s", got)
+	}
+}
+
+func TestUnreachable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusInternalServerError)
+	}))
+	client := NewClient(models.JenkinsTarget{Host: srv.URL}, "t", time.Second)
+	if _, err := client.ListJobNodes(context.Background(), "", ""); err == nil || Unreachable(err) {
+		t.Fatalf("expected a server error to count as reachable, got %v", err)
+	}
+	srv.Close()
+	if _, err := client.ListJobNodes(context.Background(), "", ""); !Unreachable(err) {
+		t.Fatalf("expected a closed server to be unreachable, got %v", err)
+	}
+	if Unreachable(nil) || Unreachable(context.Canceled) {
+		t.Fatalf("expected nil and cancellation to be reachable")
+	}
+}
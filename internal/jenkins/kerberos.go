@@ -0,0 +1,104 @@
+package jenkins
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"runtime"
+	"strings"
+
+	krbclient "github.com/jcmturner/gokrb5/v8/client"
+	krbconfig "github.com/jcmturner/gokrb5/v8/config"
+	krbcreds "github.com/jcmturner/gokrb5/v8/credentials"
+	"github.com/jcmturner/gokrb5/v8/spnego"
+)
+
+// kerberosClient loads the ticket cache kinit wrote, once per client. A
+// failed load is retried on the next request, so running kinit after an
+// error is enough.
+func (c *Client) kerberosClient() (*krbclient.Client, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.krb != nil {
+		return c.krb, nil
+	}
+	confPath := os.Getenv("KRB5_CONFIG")
+	if confPath == "" {
+		confPath = "/etc/krb5.conf"
+	}
+	conf, err := krbconfig.Load(confPath)
+	if err != nil {
+		return nil, fmt.Errorf("kerberos: read %s: %w", confPath, err)
+	}
+	ccachePath, err := ccachePath()
+	if err != nil {
+		return nil, err
+	}
+	ccache, err := krbcreds.LoadCCache(ccachePath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) && os.Getenv("KRB5CCNAME") == "" {
+			return nil, fmt.Errorf("kerberos: no ticket cache at %s: %s", ccachePath, defaultCacheHint(ccachePath))
+		}
+		return nil, fmt.Errorf("kerberos: no ticket cache at %s (run kinit): %w", ccachePath, err)
+	}
+	cl, err := krbclient.NewFromCCache(ccache, conf, krbclient.DisablePAFXFAST(true))
+	if err != nil {
+		return nil, fmt.Errorf("kerberos: %w", err)
+	}
+	c.krb = cl
+	return cl, nil
+}
+
+// ccachePath is the file ticket cache named by KRB5CCNAME, or the default
+// /tmp/krb5cc_<uid>. Only FILE: caches can be read: the API:, KCM:,
+// KEYRING: and MSLSA: caches live in a daemon, the kernel or Windows' LSA,
+// so naming one is an error that says so rather than a missing file.
+func ccachePath() (string, error) {
+	name := os.Getenv("KRB5CCNAME")
+	if name == "" {
+		if runtime.GOOS == "windows" {
+			return "", errors.New("kerberos: Windows keeps tickets in the LSA, which cannot be read; set KRB5CCNAME to a FILE: cache written by MIT kinit")
+		}
+		return fmt.Sprintf("/tmp/krb5cc_%d", os.Getuid()), nil
+	}
+	kind, path, ok := strings.Cut(name, ":")
+	// A one-letter type is a Windows drive, as in C:\Users\me\krb5cc.
+	if !ok || len(kind) == 1 {
+		return name, nil
+	}
+	if !strings.EqualFold(kind, "FILE") {
+		return "", fmt.Errorf("kerberos: KRB5CCNAME is a %s: cache, which cannot be read; run kinit -c FILE:/tmp/krb5cc_%d and set KRB5CCNAME to that file", strings.ToUpper(kind), os.Getuid())
+	}
+	return path, nil
+}
+
+// defaultCacheHint explains a missing default cache: kinit may have put the
+// ticket in a cache type that cannot be read rather than not run at all.
+func defaultCacheHint(path string) string {
+	if runtime.GOOS == "darwin" {
+		return fmt.Sprintf("macOS kinit writes to the API: cache, which cannot be read; run kinit -c FILE:%s", path)
+	}
+	return fmt.Sprintf("run kinit, or kinit -c FILE:%s if krb5.conf sets default_ccache_name to a KEYRING: or KCM: cache", path)
+}
+
+// setSPNEGOHeader signs req for the HTTP/<host> service, or the target's
+// kerberos_spn when the controller's principal differs from its host name.
+func (c *Client) setSPNEGOHeader(req *http.Request) error {
+	cl, err := c.kerberosClient()
+	if err != nil {
+		return err
+	}
+	spn := c.target.KerberosSPN
+	if spn == "" {
+		spn = "HTTP/" + req.URL.Hostname()
+	}
+	if err := spnego.SetSPNEGOHeader(cl, req, spn); err != nil {
+		c.mu.Lock()
+		c.krb = nil
+		c.mu.Unlock()
+		return fmt.Errorf("kerberos: %w (run kinit if the ticket expired)", err)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package jenkins
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"jenkins-tui/internal/models"
+)
+
+// ErrNoLockableResources means the Lockable Resources plugin is not
+// installed (or not visible to the account).
+var ErrNoLockableResources = errors.New("lockable resources plugin not available")
+
+type lockableResourcesResp struct {
+	Resources []struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		Labels      string `json:"labels"`
+		Locked      bool   `json:"locked"`
+		Reserved    bool   `json:"reserved"`
+		ReservedBy  string `json:"reservedBy"`
+		BuildName   string `json:"buildName"`
+	} `json:"resources"`
+}
+
+func (c *Client) ListLockableResources(ctx context.Context) ([]models.LockableResource, error) {
+	endpoint := c.Host() + "/lockable-resources/api/json"
+	req, err := c.newRequest(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNoLockableResources
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, &StatusError{Op: "GET " + endpoint, Code: resp.StatusCode, Body: string(body)}
+	}
+	var wire lockableResourcesResp
+	if err := json.NewDecoder(resp.Body).Decode(&wire); err != nil {
+		return nil, fmt.Errorf("decode lockable resources: %w", err)
+	}
+	out := make([]models.LockableResource, 0, len(wire.Resources))
+	for _, r := range wire.Resources {
+		out = append(out, models.LockableResource{
+			Name:        r.Name,
+			Description: r.Description,
+			Labels:      strings.Fields(r.Labels),
+			Locked:      r.Locked,
+			Reserved:    r.Reserved,
+			ReservedBy:  r.ReservedBy,
+			BuildName:   r.BuildName,
+		})
+	}
+	return out, nil
+}
+
+// ResourceRequest is what a job locks: named resources, or any resource
+// carrying Label. Names may reference build parameters as ${NAME}.
+type ResourceRequest struct {
+	Names []string
+	Label string
+}
+
+type requiredResourcesXML struct {
+	Properties struct {
+		Required []struct {
+			ResourceNames string `xml:"resourceNames"`
+			LabelName     string `xml:"labelName"`
+		} `xml:"org.jenkins.plugins.lockableresources.RequiredResourcesProperty"`
+	} `xml:"properties"`
+	Definition struct {
+		Script string `xml:"script"`
+	} `xml:"definition"`
+}
+
+var (
+	lockStepPattern  = regexp.MustCompile(`\block\s*\(\s*(?:resource\s*:\s*)?['"]([^'"]+)['"]`)
+	lockLabelPattern = regexp.MustCompile(`\block\s*\([^)]*\blabel\s*:\s*['"]([^'"]+)['"]`)
+)
+
+// RequiredResources reads the locks a job takes from its config.xml: the
+// freestyle "This build requires lockable resources" property and lock()
+// steps in an inline pipeline script. Jenkinsfiles from SCM are not visible.
+func RequiredResources(configXML string) []ResourceRequest {
+	var cfg requiredResourcesXML
+	if err := unmarshalConfigXML(configXML, &cfg); err != nil {
+		return nil
+	}
+	var out []ResourceRequest
+	for _, r := range cfg.Properties.Required {
+		var req ResourceRequest
+		if names := strings.Fields(r.ResourceNames); len(names) > 0 {
+			req.Names = names
+		}
+		req.Label = strings.TrimSpace(r.LabelName)
+		if len(req.Names) > 0 || req.Label != "" {
+			out = append(out, req)
+		}
+	}
+	for _, m := range lockLabelPattern.FindAllStringSubmatch(cfg.Definition.Script, -1) {
+		out = append(out, ResourceRequest{Label: m[1]})
+	}
+	for _, m := range lockStepPattern.FindAllStringSubmatch(cfg.Definition.Script, -1) {
+		out = append(out, ResourceRequest{Names: []string{m[1]}})
+	}
+	return out
+}
+
+// unmarshalConfigXML decodes a config.xml. Jenkins writes an XML 1.1
+// declaration, which encoding/xml refuses, so it is dropped first.
+func unmarshalConfigXML(configXML string, v any) error {
+	if strings.HasPrefix(configXML, "<?xml") {
+		if _, rest, ok := strings.Cut(configXML, "?>"); ok {
+			configXML = rest
+		}
+	}
+	return xml.Unmarshal([]byte(configXML), v)
+}
@@ -0,0 +1,73 @@
+package jenkins
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"jenkins-tui/internal/models"
+)
+
+func TestRequiredResourcesReadsPropertyAndLockSteps(t *testing.T) {
+	config := `<?xml version='1.1' encoding='UTF-8'?>
+<flow-definition plugin="workflow-job">
+  <properties>
+    <org.jenkins.plugins.lockableresources.RequiredResourcesProperty plugin="lockable-resources">
+      <resourceNames>db-${ENV} shared-cache</resourceNames>
+      <labelName></labelName>
+    </org.jenkins.plugins.lockableresources.RequiredResourcesProperty>
+  </properties>
+  <definition class="org.jenkinsci.plugins.workflow.cps.CpsFlowDefinition">
+    <script>stage('deploy') { lock('env-${params.ENV}') { sh 'make' } }
+lock(resource: "signing-key") { sh 'sign' }
+lock(label: 'printer', quantity: 1) { sh 'print' }</script>
+  </definition>
+</flow-definition>`
+	got := RequiredResources(config)
+	want := []ResourceRequest{
+		{Names: []string{"db-${ENV}", "shared-cache"}},
+		{Label: "printer"},
+		{Names: []string{"env-${params.ENV}"}},
+		{Names: []string{"signing-key"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("RequiredResources = %+v, want %+v", got, want)
+	}
+	if got := RequiredResources(`<project><properties/></project>`); len(got) != 0 {
+		t.Fatalf("a job without locks should need nothing, got %+v", got)
+	}
+}
+
+func TestListLockableResources(t *testing.T) {
+	installed := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !installed || r.URL.Path != "/lockable-resources/api/json" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"resources":[{"name":"db-qa","labels":"db qa","locked":true,"buildName":"deploy #12"},{"name":"db-prod","labels":"db","reserved":true,"reservedBy":"alice"}]}`))
+	}))
+	defer srv.Close()
+
+	client := NewClient(models.JenkinsTarget{Host: srv.URL, Username: "u"}, "t", 5*time.Second)
+	got, err := client.ListLockableResources(context.Background())
+	if err != nil {
+		t.Fatalf("ListLockableResources: %v", err)
+	}
+	want := []models.LockableResource{
+		{Name: "db-qa", Labels: []string{"db", "qa"}, Locked: true, BuildName: "deploy #12"},
+		{Name: "db-prod", Labels: []string{"db"}, Reserved: true, ReservedBy: "alice"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ListLockableResources = %+v, want %+v", got, want)
+	}
+
+	installed = false
+	if _, err := client.ListLockableResources(context.Background()); !errors.Is(err, ErrNoLockableResources) {
+		t.Fatalf("a 404 should mean the plugin is missing, got %v", err)
+	}
+}
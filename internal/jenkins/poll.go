@@ -0,0 +1,119 @@
+package jenkins
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"jenkins-tui/internal/metrics"
+)
+
+const (
+	// pollRetries is how many polls in a row may fail before the loop gives
+	// up, or, for transient failures, counts as stalled.
+	pollRetries = 5
+	// maxPollBackoff caps the wait between polls of a stalled loop.
+	maxPollBackoff = time.Minute
+)
+
+type stallKey struct{}
+
+// WithStallHandler returns a context whose poll loops (ResolveQueue,
+// PollBuild, PollIndexing) call fn with the last error when they stall
+// waiting for an unreachable server, and with nil once it answers again.
+func WithStallHandler(ctx context.Context, fn func(err error)) context.Context {
+	return context.WithValue(ctx, stallKey{}, fn)
+}
+
+// Transient reports whether err is worth waiting out: the server can't be
+// reached, or a proxy in front of it answers 502, 503 or 504 while Jenkins
+// (or the VPN to it) is down.
+func Transient(err error) bool {
+	if Unreachable(err) {
+		return true
+	}
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	for _, code := range []string{"(502)", "(503)", "(504)"} {
+		if strings.Contains(msg, code) {
+			return true
+		}
+	}
+	return false
+}
+
+// poller paces a poll loop and counts its failures. Transient failures
+// never end the loop: after pollRetries of them it stalls, backing off up to
+// maxPollBackoff, and resumes its normal pace on the first success.
+type poller struct {
+	ctx      context.Context
+	what     string
+	interval time.Duration
+	failures int
+	stalled  bool
+}
+
+func newPoller(ctx context.Context, what string, interval time.Duration) *poller {
+	return &poller{ctx: ctx, what: what, interval: interval}
+}
+
+// delay is the wait before the next poll: the loop's interval, doubling
+// with every failure once stalled.
+func (p *poller) delay() time.Duration {
+	d := p.interval
+	if !p.stalled {
+		return d
+	}
+	for i := pollRetries; i < p.failures && d < maxPollBackoff; i++ {
+		d *= 2
+	}
+	return min(d, maxPollBackoff)
+}
+
+// wait sleeps until the next poll is due.
+func (p *poller) wait() error {
+	timer := time.NewTimer(p.delay())
+	defer timer.Stop()
+	select {
+	case <-p.ctx.Done():
+		return p.ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// fail records a failed poll and returns the error that ends the loop, or
+// nil to keep polling.
+func (p *poller) fail(err error) error {
+	if p.ctx.Err() != nil {
+		return p.ctx.Err()
+	}
+	p.failures++
+	if p.failures >= pollRetries && !Transient(err) {
+		return fmt.Errorf("%s failed after %d retries: %w", p.what, p.failures, err)
+	}
+	metrics.Default.IncRetry(strings.ReplaceAll(p.what, " ", "_"))
+	if p.failures >= pollRetries && !p.stalled {
+		p.stalled = true
+		p.notify(err)
+	}
+	return nil
+}
+
+// ok records a successful poll.
+func (p *poller) ok() {
+	if p.stalled {
+		p.notify(nil)
+	}
+	p.failures = 0
+	p.stalled = false
+}
+
+func (p *poller) notify(err error) {
+	if fn, ok := p.ctx.Value(stallKey{}).(func(error)); ok {
+		fn(err)
+	}
+}
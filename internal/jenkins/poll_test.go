@@ -0,0 +1,54 @@
+package jenkins
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestPollerStallsOnTransientErrorsAndRecovers(t *testing.T) {
+	var events []error
+	ctx := WithStallHandler(context.Background(), func(err error) { events = append(events, err) })
+	p := newPoller(ctx, "poll build", time.Second)
+	down := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+
+	for i := 0; i < pollRetries+3; i++ {
+		if err := p.fail(down); err != nil {
+			t.Fatalf("expected a transient failure to keep polling, got %v", err)
+		}
+	}
+	if !p.stalled || len(events) != 1 || events[0] == nil {
+		t.Fatalf("expected one stall event, got stalled=%v events=%v", p.stalled, events)
+	}
+	if got := p.delay(); got != 8*time.Second {
+		t.Fatalf("expected the delay to double per failure while stalled, got %s", got)
+	}
+	for i := 0; i < 20; i++ {
+		_ = p.fail(down)
+	}
+	if got := p.delay(); got != maxPollBackoff {
+		t.Fatalf("expected the delay to be capped at %s, got %s", maxPollBackoff, got)
+	}
+
+	p.ok()
+	if p.stalled || p.delay() != time.Second || len(events) != 2 || events[1] != nil {
+		t.Fatalf("expected recovery to reset the pace and report nil, got stalled=%v events=%v", p.stalled, events)
+	}
+}
+
+func TestPollerGivesUpOnPersistentServerErrors(t *testing.T) {
+	p := newPoller(context.Background(), "poll build", time.Second)
+	notFound := errors.New("GET https://jenkins/job/x/1/api/json failed (404): not found")
+	var err error
+	for i := 0; i < pollRetries && err == nil; i++ {
+		err = p.fail(notFound)
+	}
+	if err == nil || p.failures != pollRetries {
+		t.Fatalf("expected a 404 to end the loop after %d retries, got %v", pollRetries, err)
+	}
+	if !Transient(errors.New("GET x failed (503): Service Unavailable")) {
+		t.Fatalf("expected a 503 from a proxy to be transient")
+	}
+}
@@ -0,0 +1,238 @@
+package jenkins
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"jenkins-tui/internal/models"
+)
+
+// Default proxy authentication helpers: Samba's ntlm_auth, signing in with
+// the credentials winbind cached at login.
+const (
+	ntlmHelper      = "ntlm_auth --helper-protocol=ntlmssp-client-1 --use-cached-creds"
+	negotiateHelper = "ntlm_auth --helper-protocol=gss-spnego-client --use-cached-creds"
+)
+
+// maxProxyAuthRounds bounds the 407 challenges answered on one connection;
+// NTLM needs two requests, Kerberos one.
+const maxProxyAuthRounds = 3
+
+// proxyFunc picks the proxy for a target: its own proxy.url, none for
+// "direct", or HTTPS_PROXY/HTTP_PROXY/NO_PROXY.
+func proxyFunc(settings models.ProxySettings) func(*http.Request) (*url.URL, error) {
+	switch settings.URL {
+	case "":
+		return http.ProxyFromEnvironment
+	case models.ProxyDirect:
+		return nil
+	}
+	u, err := url.Parse(settings.URL)
+	if err != nil {
+		return func(*http.Request) (*url.URL, error) { return nil, err }
+	}
+	return http.ProxyURL(u)
+}
+
+// configureProxy points transport at the target's proxy. With proxy.auth
+// the transport dials through proxyTunnel instead, which answers the
+// proxy's NTLM or Negotiate challenges that net/http cannot.
+func configureProxy(transport *http.Transport, target models.JenkinsTarget) func(*http.Request) (*url.URL, error) {
+	proxy := proxyFunc(target.Proxy)
+	if target.Proxy.Auth == "" || proxy == nil {
+		transport.Proxy = proxy
+		return proxy
+	}
+	scheme := "https"
+	if u, err := url.Parse(target.Host); err == nil && u.Scheme != "" {
+		scheme = u.Scheme
+	}
+	tunnel := &proxyTunnel{proxy: proxy, scheme: scheme, auth: target.Proxy.Auth, helper: target.Proxy.Helper, dialer: &net.Dialer{}}
+	transport.Proxy = nil
+	transport.DialContext = tunnel.DialContext
+	return proxy
+}
+
+// proxyTunnel opens a CONNECT tunnel through an HTTP proxy, running a
+// helper process to answer its NTLM or Negotiate challenges. The tunnel
+// carries plain HTTP as well as TLS, so http:// servers work the same way.
+type proxyTunnel struct {
+	proxy  func(*http.Request) (*url.URL, error)
+	scheme string
+	auth   string
+	helper string
+	dialer *net.Dialer
+}
+
+func (t *proxyTunnel) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	p, err := t.proxy(&http.Request{URL: &url.URL{Scheme: t.scheme, Host: addr}})
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return t.dialer.DialContext(ctx, network, addr)
+	}
+	proxyAddr := p.Host
+	if p.Port() == "" {
+		proxyAddr = net.JoinHostPort(p.Hostname(), "80")
+		if p.Scheme == "https" {
+			proxyAddr = net.JoinHostPort(p.Hostname(), "443")
+		}
+	}
+	conn, err := t.dialer.DialContext(ctx, "tcp", proxyAddr)
+	if err != nil {
+		return nil, err
+	}
+	if p.Scheme == "https" {
+		conn = tls.Client(conn, &tls.Config{ServerName: p.Hostname()})
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	tunneled, err := t.connect(ctx, conn, addr)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("proxy %s: %w", p.Redacted(), err)
+	}
+	conn.SetDeadline(time.Time{})
+	return tunneled, nil
+}
+
+// connect sends CONNECT addr, feeding each 407 challenge to the helper
+// until the proxy opens the tunnel.
+func (t *proxyTunnel) connect(ctx context.Context, conn net.Conn, addr string) (net.Conn, error) {
+	scheme, script := "NTLM", ntlmHelper
+	if t.auth == models.ProxyAuthNegotiate {
+		scheme, script = "Negotiate", negotiateHelper
+	}
+	if t.helper != "" {
+		script = t.helper
+	}
+	helper, err := startAuthHelper(ctx, script)
+	if err != nil {
+		return nil, err
+	}
+	defer helper.close()
+	token, err := helper.ask("YR")
+	if err != nil {
+		return nil, err
+	}
+	br := bufio.NewReader(conn)
+	for round := 0; round < maxProxyAuthRounds; round++ {
+		fmt.Fprintf(conn, "CONNECT %s HTTP/1.1\r\nHost: %s\r\nProxy-Authorization: %s %s\r\n\r\n", addr, addr, scheme, token)
+		resp, err := http.ReadResponse(br, &http.Request{Method: http.MethodConnect})
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == http.StatusOK {
+			return &bufferedConn{Conn: conn, r: br}, nil
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusProxyAuthRequired {
+			return nil, fmt.Errorf("CONNECT %s failed (%d)", addr, resp.StatusCode)
+		}
+		challenge := proxyChallenge(resp.Header, scheme)
+		if challenge == "" {
+			return nil, fmt.Errorf("%s authentication rejected (407)", scheme)
+		}
+		if resp.Close {
+			return nil, fmt.Errorf("the proxy closed the connection during %s authentication", scheme)
+		}
+		if token, err = helper.ask("TT " + challenge); err != nil {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("%s authentication did not finish after %d rounds", scheme, maxProxyAuthRounds)
+}
+
+// proxyChallenge is the token of the scheme's Proxy-Authenticate header, or
+// "" when the proxy sent the bare scheme, i.e. rejected the last token.
+func proxyChallenge(h http.Header, scheme string) string {
+	for _, v := range h.Values("Proxy-Authenticate") {
+		name, token, _ := strings.Cut(strings.TrimSpace(v), " ")
+		if strings.EqualFold(name, scheme) {
+			return strings.TrimSpace(token)
+		}
+	}
+	return ""
+}
+
+// bufferedConn reads through the reader that parsed the CONNECT response,
+// in case the proxy sent more than the headers.
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
+
+// authHelper runs a proxy authentication helper. It reads a request per
+// line ("YR" for the first token, "TT <challenge>" for the next) and
+// answers "YR <token>", "KK <token>" or "AF <token>"; "BH" and "NA" mean
+// it failed.
+type authHelper struct {
+	cmd *exec.Cmd
+	in  io.WriteCloser
+	out *bufio.Reader
+}
+
+func startAuthHelper(ctx context.Context, script string) (*authHelper, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", script)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", script)
+	}
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start proxy auth helper: %w", err)
+	}
+	return &authHelper{cmd: cmd, in: in, out: bufio.NewReader(out)}, nil
+}
+
+func (h *authHelper) ask(request string) (string, error) {
+	if _, err := io.WriteString(h.in, request+"\n"); err != nil {
+		return "", fmt.Errorf("proxy auth helper: %w", err)
+	}
+	line, err := h.out.ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("proxy auth helper exited: %w", err)
+	}
+	verb, token, _ := strings.Cut(strings.TrimSpace(line), " ")
+	switch verb {
+	case "YR", "KK", "AF", "TT":
+		if token = strings.TrimSpace(token); token != "" {
+			return token, nil
+		}
+	}
+	return "", errors.New("proxy auth helper failed: " + strings.TrimSpace(line))
+}
+
+func (h *authHelper) close() {
+	h.in.Close()
+	if h.cmd.Process != nil {
+		h.cmd.Process.Kill()
+	}
+	h.cmd.Wait()
+}
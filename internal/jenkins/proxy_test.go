@@ -0,0 +1,100 @@
+package jenkins
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"jenkins-tui/internal/models"
+)
+
+// fakeNTLMHelper answers like ntlm_auth: a type 1 token first, and a type 3
+// token for the challenge the fake proxy sends.
+const fakeNTLMHelper = `while read verb rest; do case "$verb" in
+YR) echo "YR dHlwZTE=" ;;
+TT) if [ "$rest" = "Y2hhbGxlbmdl" ]; then echo "KK dHlwZTM="; else echo "BH unexpected challenge"; fi ;;
+esac; done`
+
+// ntlmProxy accepts CONNECT once the client has answered its challenge on
+// the same connection, then pipes the tunnel to the requested address.
+func ntlmProxy(t *testing.T, accept bool) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveNTLMProxy(conn, accept)
+		}
+	}()
+	return "http://" + ln.Addr().String()
+}
+
+func serveNTLMProxy(conn net.Conn, accept bool) {
+	defer conn.Close()
+	br := bufio.NewReader(conn)
+	for {
+		req, err := http.ReadRequest(br)
+		if err != nil || req.Method != http.MethodConnect {
+			return
+		}
+		switch auth := req.Header.Get("Proxy-Authorization"); {
+		case auth == "NTLM dHlwZTE=" && accept:
+			io.WriteString(conn, "HTTP/1.1 407 Proxy Authentication Required\r\nProxy-Authenticate: NTLM Y2hhbGxlbmdl\r\nContent-Length: 0\r\n\r\n")
+		case auth == "NTLM dHlwZTM=":
+			backend, err := net.Dial("tcp", req.Host)
+			if err != nil {
+				return
+			}
+			defer backend.Close()
+			io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+			go io.Copy(backend, br)
+			io.Copy(conn, backend)
+			return
+		default:
+			io.WriteString(conn, "HTTP/1.1 407 Proxy Authentication Required\r\nProxy-Authenticate: NTLM\r\nContent-Length: 0\r\n\r\n")
+		}
+	}
+}
+
+func TestProxyTunnelAnswersNTLMChallenge(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"jobs":[{"name":"deploy","url":"http://x/job/deploy/"}]}`))
+	}))
+	defer srv.Close()
+
+	target := models.JenkinsTarget{Host: srv.URL, Username: "u", Proxy: models.ProxySettings{URL: ntlmProxy(t, true), Auth: models.ProxyAuthNTLM, Helper: fakeNTLMHelper}}
+	client := NewClient(target, "t", 5*time.Second)
+	nodes, err := client.ListJobNodes(context.Background(), "", "")
+	if err != nil || len(nodes) != 1 {
+		t.Fatalf("ListJobNodes through the NTLM proxy: %v %+v", err, nodes)
+	}
+
+	target.Proxy.URL = ntlmProxy(t, false)
+	client = NewClient(target, "t", 5*time.Second)
+	if _, err := client.ListJobNodes(context.Background(), "", ""); err == nil || !strings.Contains(err.Error(), "NTLM authentication rejected") {
+		t.Fatalf("expected the proxy to reject the helper's token, got %v", err)
+	}
+}
+
+func TestProxyDirectBypassesEnvironment(t *testing.T) {
+	if proxyFunc(models.ProxySettings{URL: models.ProxyDirect}) != nil {
+		t.Fatalf("proxy.url: direct should not use a proxy")
+	}
+	p, err := proxyFunc(models.ProxySettings{URL: "http://proxy.corp:3128"})(httptest.NewRequest(http.MethodGet, "https://jenkins.example.com/", nil))
+	if err != nil || p == nil || p.Host != "proxy.corp:3128" {
+		t.Fatalf("expected the configured proxy, got %v %v", p, err)
+	}
+}
@@ -0,0 +1,103 @@
+package jenkins
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// ErrReplayUnavailable means the build has no replay page: it is not a
+// Pipeline build, or the account lacks Run/Replay.
+var ErrReplayUnavailable = errors.New("replay needs a Pipeline build and the Run/Replay permission")
+
+// ReplayScripts are the scripts a Pipeline build ran: its Jenkinsfile and any
+// files it pulled in with load, keyed by their replay form field.
+type ReplayScripts struct {
+	Main   string
+	Loaded map[string]string
+}
+
+var replayTextareaPattern = regexp.MustCompile(`(?s)<textarea[^>]*\bname="_\.([^"]+)"[^>]*>(.*?)</textarea>`)
+
+// ReplayScripts reads the scripts from the build's replay page; there is no
+// JSON API for them.
+func (c *Client) ReplayScripts(ctx context.Context, buildURL string) (ReplayScripts, error) {
+	endpoint := strings.TrimRight(buildURL, "/") + "/replay/"
+	req, err := c.newRequest(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return ReplayScripts{}, err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return ReplayScripts{}, err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusForbidden:
+		return ReplayScripts{}, ErrReplayUnavailable
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return ReplayScripts{}, &StatusError{Op: "GET " + endpoint, Code: resp.StatusCode, Body: string(body)}
+	}
+	scripts := ReplayScripts{Loaded: map[string]string{}}
+	found := false
+	for _, m := range replayTextareaPattern.FindAllStringSubmatch(string(body), -1) {
+		// Browsers drop one newline right after <textarea>; so does the form.
+		text := strings.TrimPrefix(html.UnescapeString(m[2]), "\n")
+		if m[1] == "mainScript" {
+			scripts.Main, found = text, true
+			continue
+		}
+		scripts.Loaded[m[1]] = text
+	}
+	if !found {
+		return ReplayScripts{}, ErrReplayUnavailable
+	}
+	return scripts, nil
+}
+
+type nextBuildResp struct {
+	NextBuildNumber int `json:"nextBuildNumber"`
+}
+
+// Replay runs a Pipeline build again with the same parameters. With scripts
+// nil the original scripts are reused; otherwise they replace them. The
+// returned number is the job's next build number at submit time, which is
+// the replay unless another build started in between.
+func (c *Client) Replay(ctx context.Context, jobURL, buildURL string, scripts *ReplayScripts) (n int, err error) {
+	action := "replay"
+	if scripts != nil {
+		action = "replay_edited"
+	}
+	defer func() { c.audit(action, buildURL, nil, err) }()
+	var next nextBuildResp
+	if err := c.getJSON(ctx, strings.TrimRight(jobURL, "/")+"/api/json?tree=nextBuildNumber", &next); err != nil {
+		return 0, err
+	}
+	replay := strings.TrimRight(buildURL, "/") + "/replay/"
+	if scripts == nil {
+		_, err := c.postForm(ctx, replay+"rebuild", nil)
+		return next.NextBuildNumber, err
+	}
+	// The replay form is a Stapler form: the fields travel as one JSON
+	// object, and every loaded script must be present.
+	fields := map[string]string{"mainScript": scripts.Main}
+	for name, text := range scripts.Loaded {
+		fields[name] = text
+	}
+	payload, err := json.Marshal(fields)
+	if err != nil {
+		return 0, err
+	}
+	form := url.Values{"json": {string(payload)}}
+	if _, err := c.postForm(ctx, replay+"run", form); err != nil {
+		return 0, err
+	}
+	return next.NextBuildNumber, nil
+}
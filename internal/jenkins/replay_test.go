@@ -0,0 +1,82 @@
+package jenkins
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"jenkins-tui/internal/models"
+)
+
+const replayPage = `<html><body><form action="run" method="post">
+<textarea name="_.mainScript" class="secure">
+node { def lib = load &#39;lib.groovy&#39;; echo &quot;a &lt; b&quot; }</textarea>
+<textarea name="_.Script1">
+def hello() { echo 'hi' }
+return this</textarea>
+</form></body></html>`
+
+func TestReplayScriptsAndSubmit(t *testing.T) {
+	var mu sync.Mutex
+	var posted []string
+	var form map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost:
+			mu.Lock()
+			posted = append(posted, r.URL.Path)
+			if r.URL.Path == "/job/pipe/7/replay/run" {
+				json.Unmarshal([]byte(r.FormValue("json")), &form)
+			}
+			mu.Unlock()
+		case r.URL.Path == "/job/pipe/7/replay/":
+			w.Write([]byte(replayPage))
+		case r.URL.Path == "/job/pipe/api/json":
+			w.Write([]byte(`{"nextBuildNumber":9}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient(models.JenkinsTarget{Host: srv.URL, Username: "u"}, "t", 5*time.Second)
+	ctx := context.Background()
+	scripts, err := client.ReplayScripts(ctx, srv.URL+"/job/pipe/7/")
+	if err != nil {
+		t.Fatalf("ReplayScripts: %v", err)
+	}
+	want := ReplayScripts{
+		Main:   `node { def lib = load 'lib.groovy'; echo "a < b" }`,
+		Loaded: map[string]string{"Script1": "def hello() { echo 'hi' }\nreturn this"},
+	}
+	if !reflect.DeepEqual(scripts, want) {
+		t.Fatalf("ReplayScripts = %+v, want %+v", scripts, want)
+	}
+	if _, err := client.ReplayScripts(ctx, srv.URL+"/job/freestyle/3/"); !errors.Is(err, ErrReplayUnavailable) {
+		t.Fatalf("a build without a replay page should be unavailable, got %v", err)
+	}
+
+	scripts.Main = "node { echo 'edited' }"
+	n, err := client.Replay(ctx, srv.URL+"/job/pipe/", srv.URL+"/job/pipe/7/", &scripts)
+	if err != nil || n != 9 {
+		t.Fatalf("Replay = %d, %v", n, err)
+	}
+	if _, err := client.Replay(ctx, srv.URL+"/job/pipe/", srv.URL+"/job/pipe/7/", nil); err != nil {
+		t.Fatalf("Replay as is: %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(posted, []string{"/job/pipe/7/replay/run", "/job/pipe/7/replay/rebuild"}) {
+		t.Fatalf("unexpected POSTs %v", posted)
+	}
+	wantForm := map[string]string{"mainScript": "node { echo 'edited' }", "Script1": want.Loaded["Script1"]}
+	if !reflect.DeepEqual(form, wantForm) {
+		t.Fatalf("replay form = %v, want %v", form, wantForm)
+	}
+}
@@ -0,0 +1,83 @@
+package jenkins
+
+import (
+	"net/url"
+	"strings"
+
+	"jenkins-tui/internal/gitrepo"
+)
+
+type branchSourcesXML struct {
+	Sources []struct {
+		Source scmSourceXML `xml:"source"`
+	} `xml:"sources>data>jenkins.branch.BranchSource"`
+}
+
+// scmSourceXML holds the fields the git, GitHub, GitLab, Bitbucket and
+// Gitea branch sources use to name their repository.
+type scmSourceXML struct {
+	Remote        string `xml:"remote"`
+	RepositoryURL string `xml:"repositoryUrl"`
+	APIURI        string `xml:"apiUri"`
+	ServerURL     string `xml:"serverUrl"`
+	RepoOwner     string `xml:"repoOwner"`
+	Repository    string `xml:"repository"`
+	ProjectPath   string `xml:"projectPath"`
+}
+
+// SourceRepos reads the repositories a multibranch project builds from its
+// config.xml. GitLab sources name a server by its id in the global config,
+// so only their project path is known.
+func SourceRepos(configXML string) []gitrepo.Repo {
+	var cfg branchSourcesXML
+	if err := unmarshalConfigXML(configXML, &cfg); err != nil {
+		return nil
+	}
+	var out []gitrepo.Repo
+	for _, s := range cfg.Sources {
+		if repo, ok := s.Source.repo(); ok {
+			out = append(out, repo)
+		}
+	}
+	return out
+}
+
+func (s scmSourceXML) repo() (gitrepo.Repo, bool) {
+	switch {
+	case strings.TrimSpace(s.Remote) != "":
+		return gitrepo.Parse(s.Remote)
+	case strings.TrimSpace(s.RepositoryURL) != "":
+		return gitrepo.Parse(s.RepositoryURL)
+	case strings.TrimSpace(s.RepoOwner) != "" && strings.TrimSpace(s.Repository) != "":
+		repo, ok := gitrepo.Parse(strings.TrimSpace(s.RepoOwner) + "/" + strings.TrimSpace(s.Repository))
+		if ok {
+			repo.Host = sourceHost(s)
+		}
+		return repo, ok
+	case strings.TrimSpace(s.ProjectPath) != "":
+		return gitrepo.Parse(s.ProjectPath)
+	}
+	return gitrepo.Repo{}, false
+}
+
+// sourceHost is the web host of an owner/repository source: github.com
+// unless apiUri points at GitHub Enterprise, or the Bitbucket or Gitea
+// serverUrl.
+func sourceHost(s scmSourceXML) string {
+	raw := strings.TrimSpace(s.ServerURL)
+	if raw == "" {
+		raw = strings.TrimSpace(s.APIURI)
+	}
+	if raw == "" {
+		return "github.com"
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	host := strings.ToLower(u.Hostname())
+	if host == "api.github.com" {
+		return "github.com"
+	}
+	return host
+}
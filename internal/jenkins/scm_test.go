@@ -0,0 +1,42 @@
+package jenkins
+
+import (
+	"reflect"
+	"testing"
+
+	"jenkins-tui/internal/gitrepo"
+)
+
+func TestSourceReposReadsBranchSources(t *testing.T) {
+	configXML := `<?xml version='1.1' encoding='UTF-8'?>
+<org.jenkinsci.plugins.workflow.multibranch.WorkflowMultiBranchProject plugin="workflow-multibranch">
+  <sources class="jenkins.branch.MultiBranchProject$BranchSourceList">
+    <data>
+      <jenkins.branch.BranchSource>
+        <source class="jenkins.plugins.git.GitSCMSource"><remote>git@git.corp:team/api.git</remote></source>
+      </jenkins.branch.BranchSource>
+      <jenkins.branch.BranchSource>
+        <source class="org.jenkinsci.plugins.github_branch_source.GitHubSCMSource"><apiUri>https://ghe.corp/api/v3</apiUri><repoOwner>Org</repoOwner><repository>App</repository></source>
+      </jenkins.branch.BranchSource>
+      <jenkins.branch.BranchSource>
+        <source class="org.jenkinsci.plugins.github_branch_source.GitHubSCMSource"><repoOwner>org</repoOwner><repository>lib</repository></source>
+      </jenkins.branch.BranchSource>
+      <jenkins.branch.BranchSource>
+        <source class="io.jenkins.plugins.gitlabbranchsource.GitLabSCMSource"><serverName>default</serverName><projectPath>group/sub/svc</projectPath></source>
+      </jenkins.branch.BranchSource>
+    </data>
+  </sources>
+</org.jenkinsci.plugins.workflow.multibranch.WorkflowMultiBranchProject>`
+	want := []gitrepo.Repo{
+		{Host: "git.corp", Path: "team/api"},
+		{Host: "ghe.corp", Path: "org/app"},
+		{Host: "github.com", Path: "org/lib"},
+		{Path: "group/sub/svc"},
+	}
+	if got := SourceRepos(configXML); !reflect.DeepEqual(got, want) {
+		t.Fatalf("SourceRepos = %+v, want %+v", got, want)
+	}
+	if got := SourceRepos(`<project><builders/></project>`); len(got) != 0 {
+		t.Fatalf("freestyle job has no branch sources, got %+v", got)
+	}
+}
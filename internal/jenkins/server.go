@@ -0,0 +1,300 @@
+package jenkins
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"jenkins-tui/internal/models"
+	"jenkins-tui/internal/redact"
+)
+
+// ServerInfo is what a controller reports about itself: the version from the
+// X-Jenkins header and, when the account may list them, the active plugins.
+type ServerInfo struct {
+	Version string
+	Plugins map[string]bool
+}
+
+// AtLeast reports whether the server runs version v or newer. An unknown
+// version (the header can be hidden) is treated as current.
+func (i ServerInfo) AtLeast(v string) bool {
+	if i.Version == "" {
+		return true
+	}
+	return CompareVersions(i.Version, v) >= 0
+}
+
+// SessionCrumbs is true from 2.176.2 on, where a crumb is only valid with the
+// web session it was issued in and has to be refetched when that expires.
+func (i ServerInfo) SessionCrumbs() bool {
+	return i.Version != "" && i.AtLeast("2.176.2")
+}
+
+// WorkflowAPI reports whether the pipeline stage REST API (/wfapi) is
+// installed; known is false when the plugin list could not be read.
+func (i ServerInfo) WorkflowAPI() (ok, known bool) {
+	if i.Plugins == nil {
+		return false, false
+	}
+	return i.Plugins["pipeline-rest-api"], true
+}
+
+// CompareVersions orders dotted Jenkins versions such as 2.440.1; suffixes
+// like -SNAPSHOT are ignored.
+func CompareVersions(a, b string) int {
+	pa, pb := versionParts(a), versionParts(b)
+	for i := 0; i < max(len(pa), len(pb)); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func versionParts(v string) []int {
+	v, _, _ = strings.Cut(strings.TrimSpace(v), "-")
+	fields := strings.Split(v, ".")
+	out := make([]int, 0, len(fields))
+	for _, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil {
+			break
+		}
+		out = append(out, n)
+	}
+	return out
+}
+
+type pluginsResp struct {
+	Plugins []struct {
+		ShortName string `json:"shortName"`
+		Active    bool   `json:"active"`
+	} `json:"plugins"`
+}
+
+type computerResp struct {
+	BusyExecutors  int `json:"busyExecutors"`
+	TotalExecutors int `json:"totalExecutors"`
+}
+
+type queueItemsResp struct {
+	Items []struct {
+		ID int `json:"id"`
+	} `json:"items"`
+}
+
+// Health probes reachability (the version request's round trip), executor
+// usage, and queue length.
+func (c *Client) Health(ctx context.Context) (models.ServerHealth, error) {
+	start := time.Now()
+	root, err := c.fetchRoot(ctx)
+	if err != nil {
+		return models.ServerHealth{}, err
+	}
+	h := models.ServerHealth{Version: root.version, QuietingDown: root.QuietingDown, Latency: time.Since(start)}
+	var computers computerResp
+	if err := c.getJSON(ctx, c.Host()+"/computer/api/json?tree=busyExecutors,totalExecutors", &computers); err != nil {
+		return h, err
+	}
+	h.BusyExecutors, h.TotalExecutors = computers.BusyExecutors, computers.TotalExecutors
+	var queue queueItemsResp
+	if err := c.getJSON(ctx, c.Host()+"/queue/api/json?tree=items[id]", &queue); err != nil {
+		return h, err
+	}
+	h.Queued = len(queue.Items)
+	return h, nil
+}
+
+type rootResp struct {
+	QuietingDown bool `json:"quietingDown"`
+	// version is the X-Jenkins header, empty when the controller hides it.
+	version string
+}
+
+// fetchRoot makes the cheapest authenticated call against the root API.
+func (c *Client) fetchRoot(ctx context.Context) (rootResp, error) {
+	endpoint := c.Host() + "/api/json?tree=quietingDown"
+	req, err := c.newRequest(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return rootResp{}, err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return rootResp{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return rootResp{}, &StatusError{Op: "GET " + endpoint, Code: resp.StatusCode, Body: string(body)}
+	}
+	var root rootResp
+	if err := json.NewDecoder(resp.Body).Decode(&root); err != nil {
+		return rootResp{}, fmt.Errorf("decode root response: %w", err)
+	}
+	root.version = strings.TrimSpace(resp.Header.Get("X-Jenkins"))
+	return root, nil
+}
+
+type whoAmIResp struct {
+	Name      string `json:"name"`
+	Anonymous bool   `json:"anonymous"`
+}
+
+// WhoAmI returns the user the token signs in as. It is the cheapest check
+// that a stored token is still accepted: a revoked or expired token fails
+// with 401.
+func (c *Client) WhoAmI(ctx context.Context) (string, error) {
+	var who whoAmIResp
+	if err := c.getJSON(ctx, c.Host()+"/whoAmI/api/json?tree=name,anonymous", &who); err != nil {
+		return "", err
+	}
+	if who.Anonymous {
+		return "", fmt.Errorf("Jenkins treated the request as anonymous; check the username")
+	}
+	return who.Name, nil
+}
+
+type generateTokenResp struct {
+	Status string `json:"status"`
+	Data   struct {
+		TokenValue string `json:"tokenValue"`
+	} `json:"data"`
+}
+
+// GenerateAPIToken creates an API token called name for the client's user
+// and returns its value, which Jenkins never shows again. The client signs
+// in with the user's password for this one call, so only the token has to
+// be kept.
+func (c *Client) GenerateAPIToken(ctx context.Context, name string) (token string, err error) {
+	defer func() { c.audit("generate_token", "", nil, err) }()
+	endpoint := c.Host() + "/user/" + url.PathEscape(c.target.Username) + "/descriptorByName/jenkins.security.ApiTokenProperty/generateNewToken"
+	resp, err := c.doPost(ctx, endpoint, url.Values{"newTokenName": {name}})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return "", &StatusError{Op: "generate token", Code: resp.StatusCode, Body: string(body)}
+	}
+	var out generateTokenResp
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", fmt.Errorf("decode token response: %w", err)
+	}
+	if out.Status != "ok" || out.Data.TokenValue == "" {
+		return "", fmt.Errorf("Jenkins did not return a token (status %q)", out.Status)
+	}
+	redact.Add(out.Data.TokenValue)
+	return out.Data.TokenValue, nil
+}
+
+// AdminStatus reports whether the account may open Manage Jenkins (and so
+// quiet down or restart the controller) and whether it is quieting down.
+func (c *Client) AdminStatus(ctx context.Context) (admin, quietingDown bool, err error) {
+	root, err := c.fetchRoot(ctx)
+	if err != nil {
+		return false, false, err
+	}
+	endpoint := c.Host() + "/manage/"
+	req, err := c.newRequest(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return false, false, err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return false, false, err
+	}
+	resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusForbidden:
+		return false, root.QuietingDown, nil
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return false, false, &StatusError{Op: "GET " + endpoint, Code: resp.StatusCode}
+	}
+	return true, root.QuietingDown, nil
+}
+
+// QuietDown stops the controller from starting new builds; running ones
+// finish.
+func (c *Client) QuietDown(ctx context.Context) error {
+	_, err := c.postForm(ctx, c.Host()+"/quietDown", nil)
+	c.audit("quiet_down", "", nil, err)
+	return err
+}
+
+func (c *Client) CancelQuietDown(ctx context.Context) error {
+	_, err := c.postForm(ctx, c.Host()+"/cancelQuietDown", nil)
+	c.audit("cancel_quiet_down", "", nil, err)
+	return err
+}
+
+// SafeRestart quiets down and restarts once running builds finish. The
+// redirect after the POST can land on a 503 while Jenkins goes down, which
+// still means the restart was accepted.
+func (c *Client) SafeRestart(ctx context.Context) (err error) {
+	defer func() { c.audit("safe_restart", "", nil, err) }()
+	resp, err := c.doPost(ctx, c.Host()+"/safeRestart", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 && resp.StatusCode != http.StatusServiceUnavailable {
+		body, _ := io.ReadAll(resp.Body)
+		return &StatusError{Op: "safe restart", Code: resp.StatusCode, Body: string(body)}
+	}
+	return nil
+}
+
+// DetectServer reads the controller version and plugin list and remembers
+// them, so later calls can adapt (see Server).
+func (c *Client) DetectServer(ctx context.Context) (ServerInfo, error) {
+	root, err := c.fetchRoot(ctx)
+	if err != nil {
+		return ServerInfo{}, err
+	}
+	info := ServerInfo{Version: root.version}
+
+	// Listing plugins needs Overall/SystemRead; without it features that
+	// depend on a plugin probe for it instead.
+	var plugins pluginsResp
+	if err := c.getJSON(ctx, c.Host()+"/pluginManager/api/json?tree=plugins[shortName,active]", &plugins); err == nil {
+		info.Plugins = map[string]bool{}
+		for _, p := range plugins.Plugins {
+			if p.Active {
+				info.Plugins[p.ShortName] = true
+			}
+		}
+	}
+
+	c.mu.Lock()
+	c.server = &info
+	c.mu.Unlock()
+	return info, nil
+}
+
+// Server returns what DetectServer found, if it ran.
+func (c *Client) Server() (ServerInfo, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.server == nil {
+		return ServerInfo{}, false
+	}
+	return *c.server, true
+}
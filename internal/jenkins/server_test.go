@@ -0,0 +1,157 @@
+package jenkins
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"jenkins-tui/internal/models"
+)
+
+func TestCompareVersions(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"2.440.1", "2.440.1", 0},
+		{"2.440", "2.440.1", -1},
+		{"2.452", "2.440.3", 1},
+		{"2.176.2", "2.176.10", -1},
+		{"2.462-SNAPSHOT", "2.462", 0},
+		{"1.651.3", "2.0", -1},
+	}
+	for _, c := range cases {
+		if got := CompareVersions(c.a, c.b); got != c.want {
+			t.Fatalf("CompareVersions(%q, %q) = %d, want %d", c.a, c.b, got, c.want)
+		}
+	}
+}
+
+func TestDetectServerReadsVersionAndPlugins(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Jenkins", "2.440.3")
+		switch r.URL.Path {
+		case "/api/json":
+			w.Write([]byte(`{"mode":"NORMAL"}`))
+		case "/pluginManager/api/json":
+			w.Write([]byte(`{"plugins":[{"shortName":"pipeline-rest-api","active":true},{"shortName":"old","active":false}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient(models.JenkinsTarget{Host: srv.URL, Username: "u"}, "t", 5*time.Second)
+	if _, ok := client.Server(); ok {
+		t.Fatalf("server info should be unknown before detection")
+	}
+	info, err := client.DetectServer(context.Background())
+	if err != nil {
+		t.Fatalf("DetectServer: %v", err)
+	}
+	if info.Version != "2.440.3" || !info.SessionCrumbs() {
+		t.Fatalf("unexpected info %+v", info)
+	}
+	if ok, known := info.WorkflowAPI(); !ok || !known || info.Plugins["old"] {
+		t.Fatalf("expected active plugins only, got %+v", info.Plugins)
+	}
+	if cached, ok := client.Server(); !ok || cached.Version != "2.440.3" {
+		t.Fatalf("expected detected info to be remembered, got %+v", cached)
+	}
+}
+
+func TestPostRefetchesExpiredSessionCrumb(t *testing.T) {
+	crumbs, posts := 0, 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Jenkins", "2.440.3")
+		switch r.URL.Path {
+		case "/api/json":
+			w.Write([]byte(`{}`))
+		case "/crumbIssuer/api/json":
+			crumbs++
+			fmt.Fprintf(w, `{"crumbRequestField":"Jenkins-Crumb","crumb":"c%d"}`, crumbs)
+		case "/job/a/enable":
+			posts++
+			if r.Header.Get("Jenkins-Crumb") != "c2" {
+				w.WriteHeader(http.StatusForbidden)
+				w.Write([]byte("No valid crumb was included in the request"))
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient(models.JenkinsTarget{Host: srv.URL, Username: "u"}, "t", 5*time.Second)
+	if _, err := client.DetectServer(context.Background()); err != nil {
+		t.Fatalf("DetectServer: %v", err)
+	}
+	if err := client.EnableJob(context.Background(), srv.URL+"/job/a/"); err != nil {
+		t.Fatalf("EnableJob should succeed after refetching the crumb: %v", err)
+	}
+	if crumbs != 2 || posts != 2 {
+		t.Fatalf("expected one crumb refetch and one retry, got crumbs=%d posts=%d", crumbs, posts)
+	}
+}
+
+func TestWhoAmI(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/whoAmI/api/json" {
+			http.NotFound(w, r)
+			return
+		}
+		if _, pass, _ := r.BasicAuth(); pass != "good" {
+			http.Error(w, "Invalid password/token for user: ci", http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"name":"ci","anonymous":false}`))
+	}))
+	defer srv.Close()
+
+	name, err := NewClient(models.JenkinsTarget{Host: srv.URL, Username: "ci"}, "good", time.Second).WhoAmI(context.Background())
+	if err != nil || name != "ci" {
+		t.Fatalf("expected ci, got %q %v", name, err)
+	}
+	_, err = NewClient(models.JenkinsTarget{Host: srv.URL, Username: "ci"}, "revoked", time.Second).WhoAmI(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "(401)") {
+		t.Fatalf("expected a 401 error, got %v", err)
+	}
+}
+
+func TestGenerateAPIToken(t *testing.T) {
+	var form url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/crumbIssuer/api/json":
+			w.Write([]byte(`{"crumbRequestField":"Jenkins-Crumb","crumb":"abc"}`))
+		case "/user/jane.doe/descriptorByName/jenkins.security.ApiTokenProperty/generateNewToken":
+			if _, pass, _ := r.BasicAuth(); pass != "hunter2" || r.Header.Get("Jenkins-Crumb") != "abc" {
+				http.Error(w, "Invalid password/token for user: jane.doe", http.StatusUnauthorized)
+				return
+			}
+			_ = r.ParseForm()
+			form = r.PostForm
+			w.Write([]byte(`{"status":"ok","data":{"tokenName":"jenkins-tui","tokenUuid":"u-1","tokenValue":"11aa22bb"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	target := models.JenkinsTarget{Host: srv.URL, Username: "jane.doe"}
+	token, err := NewClient(target, "hunter2", time.Second).GenerateAPIToken(context.Background(), "jenkins-tui")
+	if err != nil || token != "11aa22bb" {
+		t.Fatalf("expected the generated token, got %q %v", token, err)
+	}
+	if form.Get("newTokenName") != "jenkins-tui" {
+		t.Fatalf("expected the token name in the form, got %v", form)
+	}
+	if _, err := NewClient(target, "wrong", time.Second).GenerateAPIToken(context.Background(), "jenkins-tui"); err == nil || !strings.Contains(err.Error(), "(401)") {
+		t.Fatalf("expected a 401 for a wrong password, got %v", err)
+	}
+}
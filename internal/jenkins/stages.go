@@ -0,0 +1,129 @@
+package jenkins
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"jenkins-tui/internal/models"
+)
+
+// ErrNoStages means the build has no stage view: it is not a Pipeline build,
+// or the Pipeline REST API plugin is not installed.
+var ErrNoStages = errors.New("no stages: not a Pipeline build, or the Pipeline REST API plugin is missing")
+
+type wfapiDescribeResp struct {
+	Stages []struct {
+		ID             string `json:"id"`
+		Name           string `json:"name"`
+		Status         string `json:"status"`
+		StartTimeMS    int64  `json:"startTimeMillis"`
+		DurationMillis int64  `json:"durationMillis"`
+	} `json:"stages"`
+}
+
+type wfapiStageResp struct {
+	StageFlowNodes []struct {
+		ID                   string `json:"id"`
+		Name                 string `json:"name"`
+		ParameterDescription string `json:"parameterDescription"`
+	} `json:"stageFlowNodes"`
+}
+
+type wfapiLogResp struct {
+	Text    string `json:"text"`
+	HasMore bool   `json:"hasMore"`
+}
+
+// wfapiTagPattern matches the markup wfapi wraps console notes in.
+var wfapiTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// withoutWorkflowAPI is true when DetectServer found that the Pipeline REST
+// API plugin is not installed, so wfapi requests would only 404.
+func (c *Client) withoutWorkflowAPI() bool {
+	info, ok := c.Server()
+	if !ok {
+		return false
+	}
+	present, known := info.WorkflowAPI()
+	return known && !present
+}
+
+// Stages lists the stages of a Pipeline build in the order they ran.
+func (c *Client) Stages(ctx context.Context, buildURL string) ([]models.Stage, error) {
+	if c.withoutWorkflowAPI() {
+		return nil, ErrNoStages
+	}
+	endpoint := strings.TrimRight(buildURL, "/") + "/wfapi/describe"
+	req, err := c.newRequest(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNoStages
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, &StatusError{Op: "GET " + endpoint, Code: resp.StatusCode, Body: string(body)}
+	}
+	var out wfapiDescribeResp
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", endpoint, err)
+	}
+	stages := make([]models.Stage, 0, len(out.Stages))
+	for _, s := range out.Stages {
+		stage := models.Stage{ID: s.ID, Name: s.Name, Status: s.Status, Duration: time.Duration(s.DurationMillis) * time.Millisecond}
+		if s.StartTimeMS > 0 {
+			stage.Started = time.UnixMilli(s.StartTimeMS)
+		}
+		stages = append(stages, stage)
+	}
+	return stages, nil
+}
+
+// StageLog is the log of one stage: the output of each of its steps, read
+// from the wfapi node log endpoints, under a header naming the step. Steps
+// whose log wfapi cuts short are marked so; the full console has the rest.
+func (c *Client) StageLog(ctx context.Context, buildURL, stageID string) (string, error) {
+	if c.withoutWorkflowAPI() {
+		return "", ErrNoStages
+	}
+	base := strings.TrimRight(buildURL, "/") + "/execution/node/"
+	var stage wfapiStageResp
+	if err := c.getJSON(ctx, base+stageID+"/wfapi/describe", &stage); err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, node := range stage.StageFlowNodes {
+		var log wfapiLogResp
+		if err := c.getJSON(ctx, base+node.ID+"/wfapi/log", &log); err != nil {
+			return "", err
+		}
+		header := node.Name
+		if node.ParameterDescription != "" {
+			header += ": " + node.ParameterDescription
+		}
+		b.WriteString("[" + header + "]\n")
+		text := html.UnescapeString(wfapiTagPattern.ReplaceAllString(log.Text, ""))
+		b.WriteString(text)
+		if text != "" && !strings.HasSuffix(text, "\n") {
+			b.WriteByte('\n')
+		}
+		if log.HasMore {
+			b.WriteString("... (truncated by Jenkins; the full console log has the rest)\n")
+		}
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,76 @@
+package jenkins
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"jenkins-tui/internal/models"
+)
+
+func TestStagesAndStageLog(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/job/pipe/7/wfapi/describe":
+			w.Write([]byte(`{"stages":[{"id":"6","name":"Build","status":"SUCCESS","startTimeMillis":1700000000000,"durationMillis":1500},{"id":"12","name":"Test","status":"FAILED","durationMillis":90000}]}`))
+		case "/job/pipe/7/execution/node/12/wfapi/describe":
+			w.Write([]byte(`{"stageFlowNodes":[{"id":"13","name":"Shell Script","parameterDescription":"make test"},{"id":"14","name":"Print Message"}]}`))
+		case "/job/pipe/7/execution/node/13/wfapi/log":
+			w.Write([]byte(`{"text":"+ make test\n<span class=\"timestamp\">12:00</span> FAIL: a &lt; b","hasMore":true}`))
+		case "/job/pipe/7/execution/node/14/wfapi/log":
+			w.Write([]byte(`{"text":"done\n","hasMore":false}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient(models.JenkinsTarget{Host: srv.URL, Username: "u"}, "t", 5*time.Second)
+	ctx := context.Background()
+	stages, err := client.Stages(ctx, srv.URL+"/job/pipe/7/")
+	if err != nil {
+		t.Fatalf("Stages: %v", err)
+	}
+	if len(stages) != 2 || stages[1].Name != "Test" || stages[1].Status != "FAILED" || stages[1].Duration != 90*time.Second || stages[0].Started.IsZero() {
+		t.Fatalf("unexpected stages: %+v", stages)
+	}
+	log, err := client.StageLog(ctx, srv.URL+"/job/pipe/7/", "12")
+	if err != nil {
+		t.Fatalf("StageLog: %v", err)
+	}
+	want := "[Shell Script: make test]\n+ make test\n12:00 FAIL: a < b\n... (truncated by Jenkins; the full console log has the rest)\n[Print Message]\ndone\n"
+	if log != want {
+		t.Fatalf("unexpected stage log:\n%q\nwant\n%q", log, want)
+	}
+	if _, err := client.Stages(ctx, srv.URL+"/job/freestyle/1/"); !errors.Is(err, ErrNoStages) {
+		t.Fatalf("expected ErrNoStages for a build without wfapi, got %v", err)
+	}
+}
+
+func TestStagesSkipWfapiWhenThePluginIsMissing(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{"stages":[]}`))
+	}))
+	defer srv.Close()
+
+	client := NewClient(models.JenkinsTarget{Host: srv.URL, Username: "u"}, "t", 5*time.Second)
+	client.server = &ServerInfo{Version: "2.440.3", Plugins: map[string]bool{"git": true}}
+	if _, err := client.Stages(context.Background(), srv.URL+"/job/pipe/7/"); !errors.Is(err, ErrNoStages) {
+		t.Fatalf("expected ErrNoStages without the Pipeline REST API plugin, got %v", err)
+	}
+	if _, err := client.StageLog(context.Background(), srv.URL+"/job/pipe/7/", "12"); !errors.Is(err, ErrNoStages) {
+		t.Fatalf("expected ErrNoStages for a stage log too, got %v", err)
+	}
+	if requests != 0 {
+		t.Fatalf("expected no wfapi requests, got %d", requests)
+	}
+	client.server = &ServerInfo{Version: "2.440.3"}
+	if _, err := client.Stages(context.Background(), srv.URL+"/job/pipe/7/"); err != nil || requests != 1 {
+		t.Fatalf("an unreadable plugin list should still ask wfapi, got %v after %d request(s)", err, requests)
+	}
+}
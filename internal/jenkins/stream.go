@@ -0,0 +1,238 @@
+package jenkins
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// DefaultMaxResponseMB caps a single JSON API response when the config
+// does not set max_response_mb.
+const DefaultMaxResponseMB = 64
+
+// searchScanFactor bounds how many suggestions per requested result are read
+// from the OpenSearch endpoint; the rest are skipped without being kept.
+const searchScanFactor = 4
+
+// ErrResponseTooLarge is returned when a response body runs past the
+// configured maximum.
+var ErrResponseTooLarge = errors.New("response too large")
+
+var maxResponseBytes atomic.Int64
+
+// SetMaxResponseSize sets the largest response body, in MiB, any client
+// reads before giving up; 0 restores the default.
+func SetMaxResponseSize(mb int) {
+	if mb <= 0 {
+		mb = DefaultMaxResponseMB
+	}
+	maxResponseBytes.Store(int64(mb) << 20)
+}
+
+func responseLimit() int64 {
+	if n := maxResponseBytes.Load(); n > 0 {
+		return n
+	}
+	return DefaultMaxResponseMB << 20
+}
+
+// limitedBody is an io.LimitReader that fails loudly instead of returning a
+// truncated body that would decode into something plausible.
+type limitedBody struct {
+	body      io.ReadCloser
+	remaining int64
+	limit     int64
+	url       string
+}
+
+// isJSON reports whether resp is an API answer. Only those are capped:
+// console logs and config.xml are as large as the build made them, and
+// are wanted whole.
+func isJSON(resp *http.Response) bool {
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+func limitBody(resp *http.Response, url string) {
+	limit := responseLimit()
+	resp.Body = &limitedBody{body: resp.Body, remaining: limit, limit: limit, url: url}
+}
+
+func (l *limitedBody) Read(p []byte) (int, error) {
+	if l.remaining <= 0 {
+		var probe [1]byte
+		n, err := l.body.Read(probe[:])
+		if n > 0 {
+			return 0, fmt.Errorf("%w: %s is larger than %d MiB (max_response_mb)", ErrResponseTooLarge, l.url, l.limit>>20)
+		}
+		return 0, err
+	}
+	if int64(len(p)) > l.remaining {
+		p = p[:l.remaining]
+	}
+	n, err := l.body.Read(p)
+	l.remaining -= int64(n)
+	return n, err
+}
+
+func (l *limitedBody) Close() error {
+	return l.body.Close()
+}
+
+// streamJSON GETs endpoint and hands the decoder to decode, which may stop
+// reading early; the rest of the body is then dropped with the connection.
+func (c *Client) streamJSON(ctx context.Context, endpoint string, decode func(*json.Decoder) error) error {
+	req, err := c.newRequest(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return &StatusError{Op: "GET " + endpoint, Code: resp.StatusCode, Body: string(body)}
+	}
+	return decode(json.NewDecoder(resp.Body))
+}
+
+// decodeOpenSearch reads ["query", [names], [paths], [urls], ...] keeping at
+// most keep entries of each list.
+func decodeOpenSearch(dec *json.Decoder, keep int) (names, paths, urls []any, err error) {
+	if err := expectDelim(dec, '['); err != nil {
+		return nil, nil, nil, err
+	}
+	var lists [][]any
+	for i := 0; i < 4 && dec.More(); i++ {
+		list, err := decodeListPrefix(dec, keep)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		lists = append(lists, list)
+	}
+	if len(lists) < 4 {
+		return nil, nil, nil, nil
+	}
+	return lists[1], lists[2], lists[3], nil
+}
+
+// decodeSuggest reads {"suggestions": [{name, path, url}, ...]} and stops
+// once limit distinct jobs have been seen.
+func decodeSuggest(dec *json.Decoder, host string, limit int) (names, paths, urls []any, err error) {
+	if err := expectDelim(dec, '{'); err != nil {
+		return nil, nil, nil, err
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if key, _ := tok.(string); key != "suggestions" {
+			if err := skipValue(dec); err != nil {
+				return nil, nil, nil, err
+			}
+			continue
+		}
+		if err := expectDelim(dec, '['); err != nil {
+			return nil, nil, nil, err
+		}
+		seen := map[string]struct{}{}
+		for len(seen) < limit && dec.More() {
+			var s struct {
+				Name string `json:"name"`
+				Path string `json:"path"`
+				URL  string `json:"url"`
+			}
+			if err := dec.Decode(&s); err != nil {
+				return nil, nil, nil, err
+			}
+			names = append(names, s.Name)
+			paths = append(paths, s.Path)
+			urls = append(urls, s.URL)
+			if abs := absolutizeURL(host, s.URL); strings.Contains(abs, "/job/") {
+				seen[CanonicalJobURL(abs)] = struct{}{}
+			}
+		}
+		return names, paths, urls, nil
+	}
+	return nil, nil, nil, nil
+}
+
+// decodeListPrefix decodes the next value as a list, keeping its first keep
+// items. Anything that is not a list yields nil.
+func decodeListPrefix(dec *json.Decoder, keep int) ([]any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		return nil, skipTokens(dec, 1)
+	case json.Delim('['):
+	default:
+		return nil, nil
+	}
+	var out []any
+	for dec.More() {
+		if len(out) >= keep {
+			if err := skipValue(dec); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		var v any
+		if err := dec.Decode(&v); err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	_, err = dec.Token()
+	return out, err
+}
+
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != want {
+		return fmt.Errorf("unexpected JSON token %v, want %v", tok, want)
+	}
+	return nil
+}
+
+// skipValue consumes the next value token by token, without building it.
+func skipValue(dec *json.Decoder) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == json.Delim('[') || tok == json.Delim('{') {
+		return skipTokens(dec, 1)
+	}
+	return nil
+}
+
+func skipTokens(dec *json.Decoder, depth int) error {
+	for depth > 0 {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('['), json.Delim('{'):
+			depth++
+		case json.Delim(']'), json.Delim('}'):
+			depth--
+		}
+	}
+	return nil
+}
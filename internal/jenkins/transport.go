@@ -0,0 +1,146 @@
+package jenkins
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"jenkins-tui/internal/metrics"
+	"jenkins-tui/internal/tracing"
+)
+
+const traceCapacity = 50
+
+type RequestTrace struct {
+	At       time.Time
+	Method   string
+	URL      string
+	Status   int
+	Duration time.Duration
+	Err      string
+}
+
+// traceRing keeps the most recent requests for the in-app trace overlay.
+type traceRing struct {
+	mu      sync.Mutex
+	entries []RequestTrace
+	next    int
+}
+
+func (r *traceRing) add(t RequestTrace) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.entries) < traceCapacity {
+		r.entries = append(r.entries, t)
+		return
+	}
+	r.entries[r.next] = t
+	r.next = (r.next + 1) % traceCapacity
+}
+
+// recent returns up to n traces, newest first.
+func (r *traceRing) recent(n int) []RequestTrace {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]RequestTrace, 0, min(n, len(r.entries)))
+	for i := 0; i < len(r.entries) && len(out) < n; i++ {
+		idx := (r.next - 1 - i + 2*len(r.entries)) % len(r.entries)
+		out = append(out, r.entries[idx])
+	}
+	return out
+}
+
+// loggingTransport records every Jenkins request at debug level and in the
+// client's trace ring, and caps JSON response bodies at max_response_mb.
+// The Authorization header is never logged; only its scheme and, for basic
+// auth, the user it was sent for.
+type loggingTransport struct {
+	base     http.RoundTripper
+	username string
+	traces   *traceRing
+}
+
+func (t loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	started := time.Now()
+	ctx, span := tracing.Start(req.Context(), "HTTP "+req.Method, tracing.KindClient,
+		tracing.String("http.request.method", req.Method),
+		tracing.String("url.full", redactURL(req.URL)),
+	)
+	if span != nil {
+		req = req.Clone(ctx)
+		req.Header.Set("traceparent", tracing.Traceparent(ctx))
+	}
+	resp, err := t.base.RoundTrip(req)
+	spanErr := err
+	if err == nil {
+		span.SetAttr(tracing.Int("http.response.status_code", resp.StatusCode))
+		if resp.StatusCode >= 400 {
+			spanErr = fmt.Errorf("HTTP %d", resp.StatusCode)
+		}
+	}
+	span.End(spanErr)
+	attrs := []any{
+		"method", req.Method,
+		"url", redactURL(req.URL),
+		"duration_ms", time.Since(started).Milliseconds(),
+	}
+	if auth := req.Header.Get("Authorization"); auth != "" {
+		attrs = append(attrs, "auth", t.authSummary(auth))
+	}
+	trace := RequestTrace{At: started, Method: req.Method, URL: redactURL(req.URL), Duration: time.Since(started)}
+	if err != nil {
+		trace.Err = err.Error()
+		t.record(trace)
+		slog.Debug("http request failed", append(attrs, "error", err.Error())...)
+		return resp, err
+	}
+	trace.Status = resp.StatusCode
+	t.record(trace)
+	if isJSON(resp) {
+		limitBody(resp, redactURL(req.URL))
+	}
+	slog.Debug("http request", append(attrs, "status", resp.StatusCode)...)
+	return resp, nil
+}
+
+// authSummary names the scheme of an Authorization header without its
+// credentials: basic auth carries the configured user and token, anything
+// else (SPNEGO's Negotiate) only a ticket.
+func (t loggingTransport) authSummary(header string) string {
+	scheme, _, _ := strings.Cut(header, " ")
+	scheme = strings.ToLower(scheme)
+	if scheme == "basic" {
+		return "basic user=" + t.username + " token=<redacted>"
+	}
+	return scheme + " <redacted>"
+}
+
+func (t loggingTransport) record(trace RequestTrace) {
+	metrics.Default.ObserveRequest(trace.Method, trace.Status, trace.Duration)
+	if t.traces != nil {
+		t.traces.add(trace)
+	}
+}
+
+func redactURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	clean := *u
+	clean.User = nil
+	q := clean.Query()
+	for key := range q {
+		lower := strings.ToLower(key)
+		if strings.Contains(lower, "token") || strings.Contains(lower, "password") || strings.Contains(lower, "secret") {
+			q.Set(key, "<redacted>")
+		}
+	}
+	if len(q) > 0 {
+		clean.RawQuery = q.Encode()
+	}
+	return clean.String()
+}
@@ -0,0 +1,141 @@
+package jenkins
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"jenkins-tui/internal/models"
+	"jenkins-tui/internal/tracing"
+)
+
+func TestLoggingTransportRedactsCredentials(t *testing.T) {
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	defer slog.SetDefault(prev)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"jobs":[]}`))
+	}))
+	defer srv.Close()
+
+	client := NewClient(models.JenkinsTarget{Host: srv.URL, Username: "alice"}, "s3cr3t-token", 5*time.Second)
+	if _, err := client.ListJobNodes(context.Background(), srv.URL+"/?token=abc", ""); err != nil {
+		t.Fatalf("ListJobNodes: %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, `"msg":"http request"`) || !strings.Contains(out, `"status":200`) {
+		t.Fatalf("expected request to be logged, got %s", out)
+	}
+	if !strings.Contains(out, "user=alice") {
+		t.Fatalf("expected username in log, got %s", out)
+	}
+	for _, secret := range []string{"s3cr3t-token", "token=abc"} {
+		if strings.Contains(out, secret) {
+			t.Fatalf("log leaked %q: %s", secret, out)
+		}
+	}
+}
+
+func TestLoggingTransportLogsTheAuthSchemeUsed(t *testing.T) {
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	defer slog.SetDefault(prev)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	transport := loggingTransport{base: http.DefaultTransport, username: "alice"}
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Negotiate YIIkerberos")
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip: %v", err)
+	}
+	resp.Body.Close()
+	out := buf.String()
+	if !strings.Contains(out, `"auth":"negotiate <redacted>"`) {
+		t.Fatalf("expected the negotiate scheme in the log, got %s", out)
+	}
+	if strings.Contains(out, "basic") || strings.Contains(out, "YIIkerberos") {
+		t.Fatalf("a negotiate request should not be logged as basic auth or leak its ticket: %s", out)
+	}
+}
+
+func TestRecentRequestsKeepsNewestFirst(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing/api/json" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"jobs":[]}`))
+	}))
+	defer srv.Close()
+
+	client := NewClient(models.JenkinsTarget{Host: srv.URL, Username: "alice"}, "t", 5*time.Second)
+	for i := 0; i < traceCapacity+5; i++ {
+		_, _ = client.ListJobNodes(context.Background(), srv.URL+"/?token=abc", "")
+	}
+	_, _ = client.ListJobNodes(context.Background(), srv.URL+"/missing/", "")
+
+	traces := client.RecentRequests(traceCapacity * 2)
+	if len(traces) != traceCapacity {
+		t.Fatalf("expected ring to cap at %d, got %d", traceCapacity, len(traces))
+	}
+	if traces[0].Status != http.StatusNotFound || !strings.Contains(traces[0].URL, "/missing/") {
+		t.Fatalf("expected newest trace first, got %+v", traces[0])
+	}
+	if traces[1].Method != http.MethodGet || strings.Contains(traces[1].URL, "abc") {
+		t.Fatalf("expected redacted GET trace, got %+v", traces[1])
+	}
+	if got := client.RecentRequests(3); len(got) != 3 {
+		t.Fatalf("expected 3 traces, got %d", len(got))
+	}
+}
+
+func TestLoggingTransportPropagatesTraceparent(t *testing.T) {
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer collector.Close()
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", collector.URL+"/v1/traces")
+	shutdown, err := tracing.Setup("test")
+	if err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	defer shutdown(context.Background())
+
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("traceparent")
+		w.Write([]byte(`{"jobs":[]}`))
+	}))
+	defer srv.Close()
+
+	ctx, run := tracing.Start(context.Background(), "jenkins.run", tracing.KindInternal)
+	client := NewClient(models.JenkinsTarget{Host: srv.URL, Username: "alice"}, "t", 5*time.Second)
+	if _, err := client.ListJobNodes(ctx, srv.URL+"/", ""); err != nil {
+		t.Fatalf("ListJobNodes: %v", err)
+	}
+	run.End(nil)
+	parent := tracing.Traceparent(ctx)
+	if got == "" || got == parent || got[:35] != parent[:35] {
+		t.Fatalf("expected child traceparent in trace %q, got %q", parent, got)
+	}
+
+	shutdown(context.Background())
+	if _, err := client.ListJobNodes(ctx, srv.URL+"/", ""); err != nil {
+		t.Fatalf("ListJobNodes: %v", err)
+	}
+	if got != "" {
+		t.Fatalf("expected no traceparent with tracing disabled, got %q", got)
+	}
+}
@@ -0,0 +1,194 @@
+package jenkins
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"jenkins-tui/internal/models"
+)
+
+// CanonicalJobURL reduces the different URLs Jenkins hands out for the same
+// job (view prefixes, missing trailing slash, query strings) to one form.
+func CanonicalJobURL(raw string) string {
+	trimmed := strings.TrimSpace(raw)
+	u, err := url.Parse(EscapeURL(trimmed))
+	if err != nil || u.Host == "" {
+		return trimmed
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	u.RawQuery = ""
+	u.Fragment = ""
+
+	parts := strings.Split(strings.Trim(u.EscapedPath(), "/"), "/")
+	kept := make([]string, 0, len(parts))
+	for i := 0; i < len(parts); i++ {
+		if parts[i] == "" {
+			continue
+		}
+		// Views only change how a job is reached, not which job it is.
+		afterJob := len(kept) > 0 && kept[len(kept)-1] == "job"
+		if parts[i] == "view" && !afterJob && i+1 < len(parts) && containsJobSegment(parts[i+2:]) {
+			i++
+			continue
+		}
+		kept = append(kept, parts[i])
+	}
+	escaped := "/" + strings.Join(kept, "/")
+	if len(kept) > 0 {
+		escaped += "/"
+	}
+	if decoded, err := url.PathUnescape(escaped); err == nil {
+		u.Path, u.RawPath = decoded, escaped
+	}
+	return u.String()
+}
+
+// EscapeURL escapes the path of raw one segment at a time, so job names
+// with spaces, '%' or non-ASCII characters come out the same however they
+// were written: escaped segments are decoded first, and a '%' that starts no
+// escape is taken literally. The query string is left as it is.
+func EscapeURL(raw string) string {
+	base, query, hasQuery := strings.Cut(raw, "?")
+	start := 0
+	if i := strings.Index(base, "://"); i >= 0 {
+		start = len(base)
+		if j := strings.Index(base[i+3:], "/"); j >= 0 {
+			start = i + 3 + j
+		}
+	}
+	segments := strings.Split(base[start:], "/")
+	for i, seg := range segments {
+		segments[i] = url.PathEscape(unescapeSegment(seg))
+	}
+	out := base[:start] + strings.Join(segments, "/")
+	if hasQuery {
+		out += "?" + query
+	}
+	return out
+}
+
+// unescapeSegment decodes the %XX escapes in seg, keeping any other '%'.
+func unescapeSegment(seg string) string {
+	var b strings.Builder
+	for i := 0; i < len(seg); i++ {
+		if seg[i] == '%' && i+2 < len(seg) {
+			if v, err := strconv.ParseUint(seg[i+1:i+3], 16, 8); err == nil {
+				b.WriteByte(byte(v))
+				i += 2
+				continue
+			}
+		}
+		b.WriteByte(seg[i])
+	}
+	return b.String()
+}
+
+func containsJobSegment(parts []string) bool {
+	for _, p := range parts {
+		if p == "job" {
+			return true
+		}
+	}
+	return false
+}
+
+// DedupeJobNodes collapses nodes that point at the same job, keeping the
+// first occurrence and recording the other paths it was reached through.
+func DedupeJobNodes(nodes []models.JobNode) []models.JobNode {
+	out := make([]models.JobNode, 0, len(nodes))
+	byKey := map[string]int{}
+	for _, n := range nodes {
+		key := jobNodeKey(n)
+		idx, ok := byKey[key]
+		if !ok {
+			n.URL = CanonicalJobURL(n.URL)
+			byKey[key] = len(out)
+			out = append(out, n)
+			continue
+		}
+		out[idx].Paths = appendPath(out[idx].Paths, out[idx].FullName, n.FullName)
+		for _, p := range n.Paths {
+			out[idx].Paths = appendPath(out[idx].Paths, out[idx].FullName, p)
+		}
+	}
+	return out
+}
+
+func jobNodeKey(n models.JobNode) string {
+	if strings.TrimSpace(n.URL) != "" {
+		return CanonicalJobURL(n.URL)
+	}
+	return "name:" + strings.ToLower(strings.Trim(n.FullName, "/"))
+}
+
+func appendPath(paths []string, primary, candidate string) []string {
+	candidate = strings.Trim(candidate, "/")
+	if candidate == "" || candidate == primary {
+		return paths
+	}
+	for _, p := range paths {
+		if p == candidate {
+			return paths
+		}
+	}
+	return append(paths, candidate)
+}
+
+// JobURL builds the canonical URL of a job from its slash-separated full
+// name, e.g. "team/api/deploy" -> <host>/job/team/job/api/job/deploy/.
+func JobURL(host, fullName string) string {
+	var b strings.Builder
+	b.WriteString(strings.TrimRight(strings.TrimSpace(host), "/"))
+	for _, part := range strings.Split(strings.Trim(fullName, "/"), "/") {
+		if part == "" {
+			continue
+		}
+		b.WriteString("/job/")
+		b.WriteString(url.PathEscape(part))
+	}
+	b.WriteString("/")
+	return b.String()
+}
+
+// FullNameFromJobURL is the inverse of JobURL: it reads the job names out of
+// the /job/<name> segments of a job URL.
+func FullNameFromJobURL(raw string) string {
+	u, err := url.Parse(CanonicalJobURL(raw))
+	if err != nil {
+		return ""
+	}
+	parts := strings.Split(strings.Trim(u.EscapedPath(), "/"), "/")
+	names := []string{}
+	for i := 0; i+1 < len(parts); i++ {
+		if parts[i] != "job" {
+			continue
+		}
+		name, err := url.PathUnescape(parts[i+1])
+		if err != nil {
+			name = parts[i+1]
+		}
+		names = append(names, name)
+		i++
+	}
+	return strings.Join(names, "/")
+}
+
+// QueueItemID extracts the item number from a queue URL such as
+// https://jenkins/queue/item/42/.
+func QueueItemID(queueURL string) (int, bool) {
+	u, err := url.Parse(strings.TrimSpace(queueURL))
+	if err != nil {
+		return 0, false
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 3 || parts[len(parts)-3] != "queue" || parts[len(parts)-2] != "item" {
+		return 0, false
+	}
+	id, err := strconv.Atoi(parts[len(parts)-1])
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,100 @@
+package jenkins
+
+import (
+	"testing"
+
+	"jenkins-tui/internal/models"
+)
+
+func TestCanonicalJobURL(t *testing.T) {
+	cases := map[string]string{
+		"https://Jenkins.Example.com/job/a/job/b":                  "https://jenkins.example.com/job/a/job/b/",
+		"https://jenkins/view/All/job/a/job/b/":                    "https://jenkins/job/a/job/b/",
+		"https://jenkins/view/team/view/nightly/job/deploy/?x=1#t": "https://jenkins/job/deploy/",
+		"https://jenkins/job/view/job/x/":                          "https://jenkins/job/view/job/x/",
+		"https://jenkins/view/team/":                               "https://jenkins/view/team/",
+		"https://jenkins/job/release%2F1.0/":                       "https://jenkins/job/release%2F1.0/",
+		"https://jenkins/job/caf%c3%a9 (eu)/":                      "https://jenkins/job/caf%C3%A9%20%28eu%29/",
+		"https://jenkins/job/café%20%28eu%29":                      "https://jenkins/job/caf%C3%A9%20%28eu%29/",
+		"https://jenkins/job/100% done/":                           "https://jenkins/job/100%25%20done/",
+	}
+	for in, want := range cases {
+		if got := CanonicalJobURL(in); got != want {
+			t.Fatalf("CanonicalJobURL(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestEscapeURL(t *testing.T) {
+	cases := map[string]string{
+		"https://jenkins/job/my job/api/json?tree=jobs[name,url]": "https://jenkins/job/my%20job/api/json?tree=jobs[name,url]",
+		"https://jenkins/job/100%/job/50%25 off/":                 "https://jenkins/job/100%25/job/50%25%20off/",
+		"https://jenkins/job/%E3%83%86%E3%82%B9%E3%83%88/":        "https://jenkins/job/%E3%83%86%E3%82%B9%E3%83%88/",
+		"https://jenkins/job/テスト/":                                "https://jenkins/job/%E3%83%86%E3%82%B9%E3%83%88/",
+		"https://jenkins/job/feature%252Flogin/":                  "https://jenkins/job/feature%252Flogin/",
+		"https://jenkins":                                         "https://jenkins",
+		"/job/a b/":                                               "/job/a%20b/",
+	}
+	for in, want := range cases {
+		if got := EscapeURL(in); got != want {
+			t.Fatalf("EscapeURL(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestNormalizeSearchResultsDedupesViewPaths(t *testing.T) {
+	names := []any{"deploy", "deploy", "build"}
+	paths := []any{"apps/deploy", "Team View/deploy", "apps/build"}
+	urls := []any{"/job/apps/job/deploy/", "/view/team/job/apps/job/deploy", "/job/apps/job/build/"}
+	nodes := normalizeSearchResults("https://jenkins", names, paths, urls, 10)
+	if len(nodes) != 2 {
+		t.Fatalf("expected 2 deduplicated results, got %+v", nodes)
+	}
+	if nodes[0].URL != "https://jenkins/job/apps/job/deploy/" || nodes[0].FullName != "apps/deploy" {
+		t.Fatalf("unexpected primary result %+v", nodes[0])
+	}
+	if len(nodes[0].Paths) != 1 || nodes[0].Paths[0] != "Team View/deploy" {
+		t.Fatalf("expected alternate path to be recorded, got %v", nodes[0].Paths)
+	}
+}
+
+func TestDedupeJobNodesFallsBackToFullName(t *testing.T) {
+	nodes := DedupeJobNodes([]models.JobNode{
+		{Name: "a", FullName: "x/a"},
+		{Name: "a", FullName: "/x/a/"},
+	})
+	if len(nodes) != 1 {
+		t.Fatalf("expected nodes without URL to dedupe by full name, got %+v", nodes)
+	}
+}
+
+func TestJobURLFromFullName(t *testing.T) {
+	got := JobURL("https://jenkins/", "/team/release 1.0/deploy")
+	want := "https://jenkins/job/team/job/release%201.0/job/deploy/"
+	if got != want {
+		t.Fatalf("JobURL = %q, want %q", got, want)
+	}
+}
+
+func TestFullNameFromJobURL(t *testing.T) {
+	got := FullNameFromJobURL("https://jenkins/view/all/job/team/job/release%201.0/job/deploy")
+	if got != "team/release 1.0/deploy" {
+		t.Fatalf("FullNameFromJobURL = %q", got)
+	}
+}
+
+func TestQueueItemID(t *testing.T) {
+	cases := map[string]int{
+		"https://jenkins/queue/item/42/":  42,
+		"https://jenkins/ci/queue/item/7": 7,
+		"https://jenkins/queue/item/abc/": 0,
+		"https://jenkins/job/deploy/42/":  0,
+		"":                                0,
+	}
+	for in, want := range cases {
+		got, ok := QueueItemID(in)
+		if got != want || ok != (want > 0) {
+			t.Fatalf("QueueItemID(%q) = %d, %v; want %d", in, got, ok, want)
+		}
+	}
+}
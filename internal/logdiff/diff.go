@@ -0,0 +1,245 @@
+package logdiff
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const maxEditDistance = 4000
+
+var timestampPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:[.,]\d+)?(?:Z|[+-]\d{2}:?\d{2})?`),
+	regexp.MustCompile(`\b\d{1,2}:\d{2}:\d{2}(?:[.,]\d+)?\b`),
+	regexp.MustCompile(`\b\d+(?:\.\d+)?\s?(?:ms|sec|secs|seconds?|min|mins|minutes?)\b`),
+}
+
+type OpKind int
+
+const (
+	OpEqual OpKind = iota
+	OpDelete
+	OpInsert
+)
+
+type Op struct {
+	Kind OpKind
+	Text string
+}
+
+// Normalize splits a console log into lines and masks timestamps and
+// durations, which otherwise make every line of two runs differ.
+func Normalize(text string) []string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.TrimRight(text, "\n")
+	if text == "" {
+		return nil
+	}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		for _, re := range timestampPatterns {
+			line = re.ReplaceAllString(line, "<ts>")
+		}
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return lines
+}
+
+// Lines returns the shortest edit script turning a into b. It uses the
+// linear-space variant of Myers' algorithm, so memory stays proportional
+// to the logs however much they differ; past maxEditDistance edits the
+// differing middle is reported as replaced whole instead.
+func Lines(a, b []string) []Op {
+	return diff(make([]Op, 0, len(a)+len(b)), a, b)
+}
+
+// diff appends the ops turning a into b to ops: the common prefix and
+// suffix as they are, and the middle split at a middle snake and diffed
+// in two halves.
+func diff(ops []Op, a, b []string) []Op {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	for _, line := range a[:prefix] {
+		ops = append(ops, Op{Kind: OpEqual, Text: line})
+	}
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if x, y, ok := middleSnake(midA, midB); ok {
+		ops = diff(ops, midA[:x], midB[:y])
+		ops = diff(ops, midA[x:], midB[y:])
+	} else {
+		ops = append(ops, replaceAll(midA, midB)...)
+	}
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, Op{Kind: OpEqual, Text: line})
+	}
+	return ops
+}
+
+// middleSnake runs Myers' search from both ends of a and b at once until
+// the paths meet, and returns where they do: a point on a shortest edit
+// path that splits it into two halves with half the edits each. Only the
+// two frontiers are kept, not one per step. ok is false when a or b is
+// empty, nothing is shared, or the edit distance is past maxEditDistance.
+// a and b must differ in their first and last lines.
+func middleSnake(a, b []string) (x, y int, ok bool) {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return 0, 0, false
+	}
+	maxD := min((n+m+1)/2, (maxEditDistance+1)/2)
+	offset := maxD + 1
+	forward := make([]int, 2*maxD+3)
+	backward := make([]int, 2*maxD+3)
+	for i := range forward {
+		forward[i], backward[i] = -1, -1
+	}
+	forward[offset+1], backward[offset+1] = 0, 0
+	delta := n - m
+	// With an odd delta the paths meet while extending the forward one,
+	// otherwise while extending the backward one.
+	odd := delta%2 != 0
+	// Diagonals that ran off the edit graph are not extended again.
+	fStart, fEnd, bStart, bEnd := 0, 0, 0, 0
+	for d := 0; d < maxD; d++ {
+		for k := -d + fStart; k <= d-fEnd; k += 2 {
+			var x int
+			if k == -d || (k != d && forward[offset+k-1] < forward[offset+k+1]) {
+				x = forward[offset+k+1]
+			} else {
+				x = forward[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			forward[offset+k] = x
+			switch {
+			case x > n:
+				fEnd += 2
+			case y > m:
+				fStart += 2
+			case odd:
+				if i := offset + delta - k; i >= 0 && i < len(backward) && backward[i] != -1 && x >= n-backward[i] {
+					return x, y, true
+				}
+			}
+		}
+		for k := -d + bStart; k <= d-bEnd; k += 2 {
+			// Backward x and y count lines from the end of a and b.
+			var x int
+			if k == -d || (k != d && backward[offset+k-1] < backward[offset+k+1]) {
+				x = backward[offset+k+1]
+			} else {
+				x = backward[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[n-x-1] == b[m-y-1] {
+				x++
+				y++
+			}
+			backward[offset+k] = x
+			switch {
+			case x > n:
+				bEnd += 2
+			case y > m:
+				bStart += 2
+			case !odd:
+				if i := offset + delta - k; i >= 0 && i < len(forward) && forward[i] != -1 && forward[i] >= n-x {
+					fk := delta - k
+					return forward[i], forward[i] - fk, true
+				}
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+func replaceAll(a, b []string) []Op {
+	ops := make([]Op, 0, len(a)+len(b))
+	for _, line := range a {
+		ops = append(ops, Op{Kind: OpDelete, Text: line})
+	}
+	for _, line := range b {
+		ops = append(ops, Op{Kind: OpInsert, Text: line})
+	}
+	return ops
+}
+
+// Unified renders ops as a unified diff with the given number of context
+// lines around each change. It returns an empty string when there are no
+// differences.
+func Unified(ops []Op, labelA, labelB string, context int) string {
+	if context < 0 {
+		context = 0
+	}
+	type hunk struct{ start, end int }
+	hunks := []hunk{}
+	for i, op := range ops {
+		if op.Kind == OpEqual {
+			continue
+		}
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		end := i + context + 1
+		if end > len(ops) {
+			end = len(ops)
+		}
+		if len(hunks) > 0 && start <= hunks[len(hunks)-1].end {
+			hunks[len(hunks)-1].end = end
+			continue
+		}
+		hunks = append(hunks, hunk{start: start, end: end})
+	}
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	// Precompute the 1-based line numbers in a and b at each op index.
+	lineA := make([]int, len(ops)+1)
+	lineB := make([]int, len(ops)+1)
+	lineA[0], lineB[0] = 1, 1
+	for i, op := range ops {
+		lineA[i+1], lineB[i+1] = lineA[i], lineB[i]
+		if op.Kind != OpInsert {
+			lineA[i+1]++
+		}
+		if op.Kind != OpDelete {
+			lineB[i+1]++
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", labelA, labelB)
+	for _, h := range hunks {
+		countA, countB := 0, 0
+		for _, op := range ops[h.start:h.end] {
+			if op.Kind != OpInsert {
+				countA++
+			}
+			if op.Kind != OpDelete {
+				countB++
+			}
+		}
+		fmt.Fprintf(&b, "@@ -%d,%d +%d,%d @@\n", lineA[h.start], countA, lineB[h.start], countB)
+		for _, op := range ops[h.start:h.end] {
+			switch op.Kind {
+			case OpEqual:
+				b.WriteString(" " + op.Text + "\n")
+			case OpDelete:
+				b.WriteString("-" + op.Text + "\n")
+			case OpInsert:
+				b.WriteString("+" + op.Text + "\n")
+			}
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
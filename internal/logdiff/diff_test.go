@@ -0,0 +1,138 @@
+package logdiff
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeMasksTimestamps(t *testing.T) {
+	got := Normalize("[2024-05-01T10:11:12.345Z] Started\r\n12:00:01 step took 35 ms\n")
+	want := []string{"[<ts>] Started", "<ts> step took <ts>"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("Normalize = %q, want %q", got, want)
+	}
+}
+
+func TestUnifiedIgnoresTimestampOnlyDifferences(t *testing.T) {
+	a := Normalize("10:00:00 checkout\n10:00:05 build\n10:00:09 SUCCESS\n")
+	b := Normalize("11:30:00 checkout\n11:30:07 build\n11:30:12 SUCCESS\n")
+	if diff := Unified(Lines(a, b), "a", "b", 3); diff != "" {
+		t.Fatalf("expected no diff, got:\n%s", diff)
+	}
+}
+
+func TestUnifiedRendersHunks(t *testing.T) {
+	a := []string{"one", "two", "three", "four", "five", "six", "seven", "eight"}
+	b := []string{"one", "two", "THREE", "four", "five", "six", "seven", "eight", "nine"}
+	got := Unified(Lines(a, b), "run #1", "run #2", 1)
+	want := strings.Join([]string{
+		"--- run #1",
+		"+++ run #2",
+		"@@ -2,3 +2,3 @@",
+		" two",
+		"-three",
+		"+THREE",
+		" four",
+		"@@ -8,1 +8,2 @@",
+		" eight",
+		"+nine",
+	}, "\n")
+	if got != want {
+		t.Fatalf("Unified mismatch:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestLinesInterleavedEdits(t *testing.T) {
+	a := []string{"a", "b", "c", "a", "b", "b", "a"}
+	b := []string{"c", "b", "a", "b", "a", "c"}
+	ops := Lines(a, b)
+	var gotA, gotB []string
+	for _, op := range ops {
+		if op.Kind != OpInsert {
+			gotA = append(gotA, op.Text)
+		}
+		if op.Kind != OpDelete {
+			gotB = append(gotB, op.Text)
+		}
+	}
+	if strings.Join(gotA, "") != strings.Join(a, "") || strings.Join(gotB, "") != strings.Join(b, "") {
+		t.Fatalf("ops do not reconstruct inputs: %v", ops)
+	}
+	edits := 0
+	for _, op := range ops {
+		if op.Kind != OpEqual {
+			edits++
+		}
+	}
+	if edits != 5 {
+		t.Fatalf("expected minimal edit script of 5, got %d", edits)
+	}
+}
+
+func TestLinesFindsShortestEditScripts(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	random := func() []string {
+		lines := make([]string, rng.Intn(12))
+		for i := range lines {
+			lines[i] = string(rune('a' + rng.Intn(3)))
+		}
+		return lines
+	}
+	for i := 0; i < 2000; i++ {
+		a, b := random(), random()
+		ops := Lines(a, b)
+		var gotA, gotB []string
+		edits := 0
+		for _, op := range ops {
+			if op.Kind != OpInsert {
+				gotA = append(gotA, op.Text)
+			}
+			if op.Kind != OpDelete {
+				gotB = append(gotB, op.Text)
+			}
+			if op.Kind != OpEqual {
+				edits++
+			}
+		}
+		if strings.Join(gotA, "") != strings.Join(a, "") || strings.Join(gotB, "") != strings.Join(b, "") {
+			t.Fatalf("Lines(%q, %q) does not reconstruct the inputs: %v", a, b, ops)
+		}
+		if want := len(a) + len(b) - 2*lcsLength(a, b); edits != want {
+			t.Fatalf("Lines(%q, %q) made %d edits, want %d", a, b, edits, want)
+		}
+	}
+}
+
+func TestLinesReplacesWholeMiddlePastTheEditLimit(t *testing.T) {
+	a := make([]string, 0, 3*maxEditDistance)
+	b := make([]string, 0, 3*maxEditDistance)
+	a, b = append(a, "start"), append(b, "start")
+	for i := 0; i < maxEditDistance; i++ {
+		a = append(a, fmt.Sprintf("a%d", i))
+		b = append(b, fmt.Sprintf("b%d", i))
+	}
+	a, b = append(a, "end"), append(b, "end")
+	ops := Lines(a, b)
+	if len(ops) != 2+2*maxEditDistance || ops[1] != (Op{Kind: OpDelete, Text: "a0"}) || ops[len(ops)-2] != (Op{Kind: OpInsert, Text: fmt.Sprintf("b%d", maxEditDistance-1)}) {
+		t.Fatalf("expected the differing middle replaced whole, got %d ops", len(ops))
+	}
+}
+
+func lcsLength(a, b []string) int {
+	dp := make([][]int, len(a)+1)
+	for i := range dp {
+		dp[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				dp[i][j] = dp[i+1][j+1] + 1
+			} else {
+				dp[i][j] = max(dp[i+1][j], dp[i][j+1])
+			}
+		}
+	}
+	return dp[0][0]
+}
@@ -0,0 +1,180 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// latencyBuckets are the upper bounds, in seconds, of the API latency histogram.
+var latencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Default collects process-wide metrics. The Jenkins client records into it
+// unconditionally; it is only exposed when a metrics address is configured.
+var Default = New()
+
+type requestKey struct {
+	method string
+	code   string
+}
+
+type histogram struct {
+	counts []uint64
+	sum    float64
+	total  uint64
+}
+
+// Registry holds the few metrics jenkins-tui exports in the Prometheus text
+// exposition format. It is intentionally tiny so no client library is needed.
+type Registry struct {
+	mu       sync.Mutex
+	runs     map[string]int
+	requests map[requestKey]*histogram
+	retries  map[string]uint64
+}
+
+func New() *Registry {
+	return &Registry{
+		runs:     map[string]int{},
+		requests: map[requestKey]*histogram{},
+		retries:  map[string]uint64{},
+	}
+}
+
+// SetRunState moves one run from prev to next. Pass an empty prev for a run
+// that was just created.
+func (r *Registry) SetRunState(prev, next string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if prev != "" && r.runs[prev] > 0 {
+		r.runs[prev]--
+	}
+	if next != "" {
+		r.runs[next]++
+	}
+}
+
+// ObserveRequest records one Jenkins API call. A zero status means the
+// request failed before a response arrived.
+func (r *Registry) ObserveRequest(method string, status int, d time.Duration) {
+	code := "error"
+	if status > 0 {
+		code = strconv.Itoa(status)
+	}
+	key := requestKey{method: method, code: code}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	h := r.requests[key]
+	if h == nil {
+		h = &histogram{counts: make([]uint64, len(latencyBuckets))}
+		r.requests[key] = h
+	}
+	seconds := d.Seconds()
+	for i, bound := range latencyBuckets {
+		if seconds <= bound {
+			h.counts[i]++
+		}
+	}
+	h.sum += seconds
+	h.total++
+}
+
+// IncRetry counts a failed poll that will be retried, by operation.
+func (r *Registry) IncRetry(operation string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.retries[operation]++
+}
+
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var b strings.Builder
+
+	b.WriteString("# HELP jenkins_tui_runs Runs currently in each state.\n")
+	b.WriteString("# TYPE jenkins_tui_runs gauge\n")
+	for _, state := range sortedKeys(r.runs) {
+		fmt.Fprintf(&b, "jenkins_tui_runs{state=%q} %d\n", state, r.runs[state])
+	}
+
+	b.WriteString("# HELP jenkins_tui_api_request_duration_seconds Latency of Jenkins API requests.\n")
+	b.WriteString("# TYPE jenkins_tui_api_request_duration_seconds histogram\n")
+	keys := make([]requestKey, 0, len(r.requests))
+	for key := range r.requests {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].method != keys[j].method {
+			return keys[i].method < keys[j].method
+		}
+		return keys[i].code < keys[j].code
+	})
+	for _, key := range keys {
+		h := r.requests[key]
+		labels := fmt.Sprintf("method=%q,code=%q", key.method, key.code)
+		for i, bound := range latencyBuckets {
+			fmt.Fprintf(&b, "jenkins_tui_api_request_duration_seconds_bucket{%s,le=%q} %d\n", labels, strconv.FormatFloat(bound, 'g', -1, 64), h.counts[i])
+		}
+		fmt.Fprintf(&b, "jenkins_tui_api_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, h.total)
+		fmt.Fprintf(&b, "jenkins_tui_api_request_duration_seconds_sum{%s} %s\n", labels, strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(&b, "jenkins_tui_api_request_duration_seconds_count{%s} %d\n", labels, h.total)
+	}
+
+	b.WriteString("# HELP jenkins_tui_api_retries_total Failed polls that were retried.\n")
+	b.WriteString("# TYPE jenkins_tui_api_retries_total counter\n")
+	for _, op := range sortedKeys(r.retries) {
+		fmt.Fprintf(&b, "jenkins_tui_api_retries_total{operation=%q} %d\n", op, r.retries[op])
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_, _ = r.WriteTo(w)
+	})
+}
+
+// Serve exposes Default on addr at /metrics until ctx is cancelled. The
+// listener is bound before returning so address errors surface immediately.
+func Serve(ctx context.Context, addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Default.Handler())
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Debug("metrics server stopped", "error", err.Error())
+		}
+	}()
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,39 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteToRendersPrometheusText(t *testing.T) {
+	r := New()
+	r.SetRunState("", "QUEUED")
+	r.SetRunState("", "QUEUED")
+	r.SetRunState("QUEUED", "RUNNING")
+	r.ObserveRequest("GET", 200, 80*time.Millisecond)
+	r.ObserveRequest("GET", 200, 3*time.Second)
+	r.ObserveRequest("POST", 0, time.Second)
+	r.IncRetry("poll_build")
+
+	var b strings.Builder
+	if _, err := r.WriteTo(&b); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		`jenkins_tui_runs{state="QUEUED"} 1`,
+		`jenkins_tui_runs{state="RUNNING"} 1`,
+		`jenkins_tui_api_request_duration_seconds_bucket{method="GET",code="200",le="0.05"} 0`,
+		`jenkins_tui_api_request_duration_seconds_bucket{method="GET",code="200",le="0.1"} 1`,
+		`jenkins_tui_api_request_duration_seconds_bucket{method="GET",code="200",le="5"} 2`,
+		`jenkins_tui_api_request_duration_seconds_bucket{method="GET",code="200",le="+Inf"} 2`,
+		`jenkins_tui_api_request_duration_seconds_count{method="POST",code="error"} 1`,
+		`jenkins_tui_api_retries_total{operation="poll_build"} 1`,
+		"# TYPE jenkins_tui_api_request_duration_seconds histogram",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in output:\n%s", want, out)
+		}
+	}
+}
@@ -1,6 +1,16 @@
 package models
 
-import "time"
+import (
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"jenkins-tui/internal/redact"
+)
 
 type CredentialType string
 
@@ -9,6 +19,25 @@ const (
 	CredentialTypeEnv     CredentialType = "env"
 )
 
+const (
+	LayoutList  = "list"
+	LayoutSplit = "split"
+)
+
+const (
+	TimeStyleRelative = "relative"
+	TimeStyleAbsolute = "absolute"
+
+	Clock12h = "12h"
+	Clock24h = "24h"
+
+	DurationStyleShort = "short"
+	DurationStyleClock = "clock"
+)
+
+// AuthKerberos as a target's auth signs in with SPNEGO.
+const AuthKerberos = "kerberos"
+
 type Credential struct {
 	Type CredentialType `yaml:"type"`
 	Ref  string         `yaml:"ref"`
@@ -21,13 +50,238 @@ type JenkinsTarget struct {
 	Username              string     `yaml:"username"`
 	Credential            Credential `yaml:"credential"`
 	InsecureSkipTLSVerify bool       `yaml:"insecure_skip_tls_verify"`
+	// AllowInsecureHTTP accepts a plain http:// host, over which the API
+	// token is sent readable by anyone on the network.
+	AllowInsecureHTTP bool   `yaml:"allow_insecure_http,omitempty"`
+	AuthCommand       string `yaml:"auth_command,omitempty"`
+	// Auth is "kerberos" for controllers behind Kerberos SSO: requests are
+	// signed with SPNEGO from the kinit ticket cache instead of a token.
+	Auth string `yaml:"auth,omitempty"`
+	// KerberosSPN is the controller's service principal when it is not
+	// HTTP/<host>, e.g. behind a load balancer.
+	KerberosSPN string `yaml:"kerberos_spn,omitempty"`
+	// Bookmarks are folder full names pinned with the bookmark key.
+	Bookmarks []string `yaml:"bookmarks,omitempty"`
+	// Watches are job full names pinned with the watch key; their last
+	// build is polled while the server is open.
+	Watches []string `yaml:"watches,omitempty"`
+	// Tags group the servers list; the first tag is the server's group.
+	Tags []string `yaml:"tags,omitempty"`
+	// Aliases map short names to job full names, e.g. deploy-prod to
+	// platform/prod/deploy, for the goto prompt and the --job flags.
+	Aliases map[string]string `yaml:"aliases,omitempty"`
+	// Transport tunes connection reuse for this server.
+	Transport TransportTuning `yaml:"transport,omitempty"`
+	// Proxy routes this server through a proxy other than the system one,
+	// or through one that wants NTLM or Negotiate authentication.
+	Proxy ProxySettings `yaml:"proxy,omitempty"`
+	// URLRewrites map URL prefixes the server reports, such as its internal
+	// DNS name, to ones reachable from here.
+	URLRewrites []URLRewrite `yaml:"url_rewrites,omitempty"`
+	// Raw keeps fields as written when they referenced ${VAR}, so saving
+	// the config does not bake in one environment's values.
+	Raw *RawTarget `yaml:"-"`
+}
+
+// PlainHTTP reports whether the host is reached over unencrypted HTTP on
+// another machine. Loopback hosts do not count: the token never leaves the
+// computer.
+func (t JenkinsTarget) PlainHTTP() bool {
+	u, err := url.Parse(strings.TrimSpace(t.Host))
+	if err != nil || !strings.EqualFold(u.Scheme, "http") {
+		return false
+	}
+	host := u.Hostname()
+	if strings.EqualFold(host, "localhost") {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return ip == nil || !ip.IsLoopback()
+}
+
+// ResolveAlias returns the full name job is an alias for, or job unchanged.
+func (t JenkinsTarget) ResolveAlias(job string) string {
+	if full, ok := t.Aliases[strings.TrimSpace(job)]; ok {
+		return full
+	}
+	return job
+}
+
+// RewriteURL maps u through the longest matching URLRewrites prefix, or
+// returns it unchanged. A prefix only matches whole path segments, so
+// http://ci does not rewrite http://ci2/job/x.
+func (t JenkinsTarget) RewriteURL(u string) string {
+	best := -1
+	for i, r := range t.URLRewrites {
+		from := strings.TrimRight(r.From, "/")
+		if from == "" || !strings.HasPrefix(u, from) {
+			continue
+		}
+		if rest := u[len(from):]; rest != "" && !strings.ContainsAny(rest[:1], "/?#") {
+			continue
+		}
+		if best < 0 || len(from) > len(strings.TrimRight(t.URLRewrites[best].From, "/")) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return u
+	}
+	r := t.URLRewrites[best]
+	return strings.TrimRight(r.To, "/") + u[len(strings.TrimRight(r.From, "/")):]
+}
+
+// URLRewrite replaces the From prefix of server URLs with To.
+type URLRewrite struct {
+	From string `yaml:"from"`
+	To   string `yaml:"to"`
+}
+
+// TransportTuning overrides how connections to one server are kept alive.
+// Zero fields keep the defaults.
+type TransportTuning struct {
+	// MaxIdleConnsPerHost is how many idle connections are kept open, so a
+	// burst of polls reuses them instead of doing new TLS handshakes.
+	MaxIdleConnsPerHost int `yaml:"max_idle_conns_per_host,omitempty"`
+	// IdleConnTimeout closes connections that were idle this long.
+	IdleConnTimeout time.Duration `yaml:"idle_conn_timeout,omitempty"`
+	// ForceAttemptHTTP2 negotiates HTTP/2 where the server offers it;
+	// on unless set to false.
+	ForceAttemptHTTP2 *bool `yaml:"force_attempt_http2,omitempty"`
+}
+
+const (
+	// ProxyDirect as proxy.url bypasses HTTPS_PROXY/HTTP_PROXY.
+	ProxyDirect = "direct"
+
+	ProxyAuthNTLM      = "ntlm"
+	ProxyAuthNegotiate = "negotiate"
+)
+
+// ProxySettings overrides the system proxy for one server. Zero fields
+// keep HTTPS_PROXY/HTTP_PROXY/NO_PROXY and no proxy authentication beyond
+// credentials in the proxy URL.
+type ProxySettings struct {
+	// URL is the proxy to use, e.g. http://proxy.corp:3128, or "direct".
+	URL string `yaml:"url,omitempty"`
+	// Auth is "ntlm" or "negotiate" for proxies that answer 407 until the
+	// client proves a Windows or Kerberos identity.
+	Auth string `yaml:"auth,omitempty"`
+	// Helper is the command that computes the authentication tokens,
+	// speaking Samba's ntlm_auth helper protocol; empty runs ntlm_auth.
+	Helper string `yaml:"helper,omitempty"`
+}
+
+// RawTarget holds the unexpanded host, username, and credential ref.
+type RawTarget struct {
+	Host          string
+	Username      string
+	CredentialRef string
 }
 
 type Config struct {
-	Jenkins    []JenkinsTarget `yaml:"jenkins"`
-	Timeout    time.Duration   `yaml:"-"`
-	ConfigPath string          `yaml:"-"`
-	CacheDir   string          `yaml:"-"`
+	Jenkins     []JenkinsTarget    `yaml:"jenkins"`
+	Keybindings map[string]KeyList `yaml:"keybindings,omitempty"`
+	// Layout is "list" (default) or "split" for the folder/contents panes.
+	Layout string `yaml:"layout,omitempty"`
+	// CredentialCacheTTL bounds how long a token read from the keyring or
+	// obtained from auth_command is reused; 0 keeps it for the session.
+	CredentialCacheTTL time.Duration `yaml:"credential_cache_ttl,omitempty"`
+	// AuditLog is a file that gets a JSON line for every triggered build
+	// and destructive action; empty disables it.
+	AuditLog string `yaml:"audit_log,omitempty"`
+	// PrefetchFolders is how many child folders of an opened folder are
+	// listed at once in the background, so stepping into them is instant;
+	// 0 turns prefetching off.
+	PrefetchFolders int `yaml:"prefetch_folders,omitempty"`
+	// MaxResponseMB caps how much of a single API response is read before
+	// the request fails; 0 uses the default of 64.
+	MaxResponseMB int `yaml:"max_response_mb,omitempty"`
+	// StripLogColors shows console logs without the ANSI colors pipelines
+	// emit; the log viewer can still toggle them on.
+	StripLogColors bool `yaml:"strip_log_colors,omitempty"`
+	// LogDir is where console logs saved from the TUI are written; empty
+	// means the current directory.
+	LogDir string `yaml:"log_dir,omitempty"`
+	// TimeStyle is "relative" ("3m ago") or "absolute" for every time
+	// shown; empty keeps tables absolute and cache badges relative.
+	TimeStyle string `yaml:"time_style,omitempty"`
+	// Clock is "24h" (default) or "12h" for absolute times.
+	Clock string `yaml:"clock,omitempty"`
+	// Timezone shows absolute times in "UTC" or an IANA zone such as
+	// "Europe/Berlin" instead of the local one.
+	Timezone string `yaml:"timezone,omitempty"`
+	// DurationStyle is "short" ("1m5s", the default) or "clock" ("1:05").
+	DurationStyle string `yaml:"duration_style,omitempty"`
+	// Schedules are batches `jenkins-tui schedule` triggers on its own.
+	Schedules  []Schedule    `yaml:"schedules,omitempty"`
+	Timeout    time.Duration `yaml:"-"`
+	ConfigPath string        `yaml:"-"`
+	CacheDir   string        `yaml:"-"`
+	Startup    StartupLink   `yaml:"-"`
+}
+
+// KeyList is the keys bound to one action. In YAML it is either a single key
+// ("delete_server: D") or a list ("goto_job: [':', ctrl+p]").
+type KeyList []string
+
+func (k *KeyList) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*k = KeyList{value.Value}
+		return nil
+	}
+	var keys []string
+	if err := value.Decode(&keys); err != nil {
+		return err
+	}
+	*k = keys
+	return nil
+}
+
+// Schedule is a batch run headlessly at the times Cron names.
+type Schedule struct {
+	Name string `yaml:"name"`
+	// Cron is a five-field crontab expression in local time, e.g.
+	// "30 2 * * mon-fri", or a macro such as @daily.
+	Cron   string `yaml:"cron"`
+	Target string `yaml:"target"`
+	// Job is a full name, job URL, or alias of Target.
+	Job string `yaml:"job"`
+	// Params are build parameters; a list of values runs the job once per
+	// value, and several lists once per combination.
+	Params map[string]ParamValues `yaml:"params,omitempty"`
+	// ReportDir gets a JUnit report per run of the schedule and the console
+	// logs of its failed builds; empty uses LogDir.
+	ReportDir string `yaml:"report_dir,omitempty"`
+}
+
+// ParamValues is one parameter's values. In YAML it is a single value
+// ("BRANCH: main") or a list ("REGION: [eu, us]").
+type ParamValues []string
+
+func (p *ParamValues) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*p = ParamValues{value.Value}
+		return nil
+	}
+	var values []string
+	if err := value.Decode(&values); err != nil {
+		return err
+	}
+	*p = values
+	return nil
+}
+
+// StartupLink is a deep link from the command line: connect to Server and,
+// when Job is set, open its parameters with Params pre-filled. Branch is the
+// git branch given with -branch or, when BranchDetected is set, the one
+// checked out where jenkins-tui was started.
+type StartupLink struct {
+	Server         string
+	Job            string
+	Params         map[string]string
+	Branch         string
+	BranchDetected bool
 }
 
 type JobRef struct {
@@ -41,6 +295,7 @@ type JobNodeKind string
 const (
 	JobNodeFolder JobNodeKind = "folder"
 	JobNodeJob    JobNodeKind = "job"
+	JobNodeView   JobNodeKind = "view"
 )
 
 type JobNode struct {
@@ -48,6 +303,17 @@ type JobNode struct {
 	FullName string
 	URL      string
 	Kind     JobNodeKind
+	Color    string
+	Disabled bool
+	// Multibranch marks folders whose children are generated per branch or
+	// pull request by branch indexing.
+	Multibranch bool
+	// Pipeline marks jobs defined by a Pipeline script or Jenkinsfile, as
+	// opposed to freestyle and other job types.
+	Pipeline bool
+	// Paths lists other full names the same job was reached through, e.g.
+	// via a view or a second folder, when results were deduplicated.
+	Paths []string
 }
 
 type ParamKind string
@@ -58,6 +324,10 @@ const (
 	ParamText     ParamKind = "Text"
 	ParamBoolean  ParamKind = "Boolean"
 	ParamPassword ParamKind = "Password"
+	// ParamNode and ParamLabel come from the NodeLabel Parameter plugin;
+	// their Choices are the agents or labels the build may run on.
+	ParamNode  ParamKind = "Node"
+	ParamLabel ParamKind = "Label"
 )
 
 type ParamDef struct {
@@ -68,8 +338,104 @@ type ParamDef struct {
 	Default     string
 }
 
+// PasswordNames lists the password parameters among defs.
+func PasswordNames(defs []ParamDef) []string {
+	var names []string
+	for _, d := range defs {
+		if d.Kind == ParamPassword {
+			names = append(names, d.Name)
+		}
+	}
+	return names
+}
+
+type JobDetail struct {
+	Name          string
+	FullName      string
+	URL           string
+	Description   string
+	Color         string
+	HealthScore   int
+	HealthSummary string
+	LastBuild     *BuildSummary
+}
+
+// ServerHealth is a quick reachability and load probe of one controller.
+type ServerHealth struct {
+	Version        string
+	BusyExecutors  int
+	TotalExecutors int
+	Queued         int
+	QuietingDown   bool
+	Latency        time.Duration
+}
+
+// LockableResource is one resource of the Lockable Resources plugin.
+type LockableResource struct {
+	Name        string
+	Description string
+	Labels      []string
+	Locked      bool
+	Reserved    bool
+	ReservedBy  string
+	// BuildName is the build holding the lock, e.g. "deploy #12".
+	BuildName string
+}
+
+type BuildSummary struct {
+	Number    int
+	URL       string
+	Result    string
+	Building  bool
+	Timestamp time.Time
+	Duration  time.Duration
+	Params    map[string]string
+	// Changes are the SCM commits that went into the build, oldest first.
+	Changes []Change
+}
+
+// Change is one commit from a build's changeSets.
+type Change struct {
+	ID      string
+	Author  string
+	Message string
+}
+
+// Stage is one stage of a Pipeline build as the Pipeline REST API (wfapi)
+// reports it.
+type Stage struct {
+	ID       string
+	Name     string
+	Status   string
+	Started  time.Time
+	Duration time.Duration
+}
+
 type JobSpec struct {
 	Params map[string]string
+	// Job, when set, is triggered instead of the batch's own job, so one
+	// batch can run several jobs of a folder.
+	Job *JobRef
+	// Secret names the password parameters. Their values go to Jenkins but
+	// are masked wherever the spec is shown or written.
+	Secret []string
+}
+
+// ShownParams is Params with the values of Secret parameters masked, for
+// tables, logs and reports. Masking by kind also hides passwords too short
+// for redact to register.
+func (s JobSpec) ShownParams() map[string]string {
+	if len(s.Secret) == 0 {
+		return s.Params
+	}
+	shown := make(map[string]string, len(s.Params))
+	for k, v := range s.Params {
+		if v != "" && slices.Contains(s.Secret, k) {
+			v = redact.Mask
+		}
+		shown[k] = v
+	}
+	return shown
 }
 
 type RunState string
@@ -78,6 +444,9 @@ const (
 	RunPlanned RunState = "PLANNED"
 	RunQueued  RunState = "QUEUED"
 	RunRunning RunState = "RUNNING"
+	// RunStalled is a queued or running build whose server stopped
+	// answering; tracking resumes when it comes back.
+	RunStalled RunState = "STALLED"
 	RunSuccess RunState = "SUCCESS"
 	RunFailed  RunState = "FAILED"
 	RunAborted RunState = "ABORTED"
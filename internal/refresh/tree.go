@@ -0,0 +1,196 @@
+package refresh
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"jenkins-tui/internal/cache"
+	"jenkins-tui/internal/jenkins"
+	"jenkins-tui/internal/models"
+)
+
+type Result struct {
+	Folders  int
+	Jobs     int
+	Errors   int
+	Duration time.Duration
+}
+
+// Progress is a running count of a Tree crawl. Queued is how many folders
+// were found but not listed yet, so it drops to 0 at the end.
+type Progress struct {
+	Folders int
+	Jobs    int
+	Queued  int
+	// Folder is the full name of the folder just listed.
+	Folder string
+}
+
+// Tree crawls every folder reachable from the Jenkins root, rewriting each
+// folder listing in the jobs cache and the flattened job index. Folders that
+// fail to load are skipped; the first failure is returned alongside the
+// partial result.
+func Tree(ctx context.Context, client *jenkins.Client, cacheDir string, concurrency int) (Result, error) {
+	return TreeWithProgress(ctx, client, cacheDir, concurrency, nil)
+}
+
+// TreeWithProgress is Tree calling progress, from the crawling goroutines
+// but never concurrently, after each folder is listed.
+func TreeWithProgress(ctx context.Context, client *jenkins.Client, cacheDir string, concurrency int, progress func(Progress)) (Result, error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	started := time.Now()
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		result   Result
+		index    []models.JobNode
+		firstErr error
+		queued   = 1
+	)
+	sem := make(chan struct{}, concurrency)
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		result.Errors++
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	var visit func(containerURL, prefix string)
+	visit = func(containerURL, prefix string) {
+		defer wg.Done()
+		select {
+		case <-ctx.Done():
+			fail(ctx.Err())
+			return
+		case sem <- struct{}{}:
+		}
+		nodes, err := client.ListJobNodes(ctx, containerURL, prefix)
+		<-sem
+		if err != nil {
+			fail(fmt.Errorf("list %s: %w", folderLabel(prefix), err))
+			mu.Lock()
+			queued--
+			mu.Unlock()
+			return
+		}
+		if err := cache.SaveJobNodesInDir(cacheDir, client.CacheKey(), containerURL, nodes); err != nil {
+			fail(fmt.Errorf("cache %s: %w", folderLabel(prefix), err))
+		}
+		mu.Lock()
+		result.Folders++
+		queued--
+		for _, n := range nodes {
+			switch n.Kind {
+			case models.JobNodeJob:
+				index = append(index, n)
+			case models.JobNodeFolder:
+				queued++
+			}
+		}
+		if progress != nil {
+			progress(Progress{Folders: result.Folders, Jobs: len(index), Queued: queued, Folder: prefix})
+		}
+		mu.Unlock()
+		for _, n := range nodes {
+			if n.Kind == models.JobNodeFolder {
+				wg.Add(1)
+				go visit(n.URL, n.FullName)
+			}
+		}
+	}
+
+	wg.Add(1)
+	go visit(client.Host(), "")
+	wg.Wait()
+
+	index = jenkins.DedupeJobNodes(index)
+	sort.Slice(index, func(i, j int) bool {
+		return strings.ToLower(index[i].FullName) < strings.ToLower(index[j].FullName)
+	})
+	result.Jobs = len(index)
+	if ctx.Err() == nil {
+		if err := cache.SaveJobIndexInDir(cacheDir, client.CacheKey(), index); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("save job index: %w", err)
+		}
+	}
+	result.Duration = time.Since(started)
+	return result, firstErr
+}
+
+// Children lists each of folders one level deep, concurrency at a time, and
+// stores the listings in the jobs cache, so opening one of them afterwards
+// needs no request. Folders with a fresh cached listing are skipped. Failures
+// are counted and the first one is returned.
+func Children(ctx context.Context, client *jenkins.Client, cacheDir string, folders []models.JobNode, concurrency int) (Result, error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	started := time.Now()
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		result   Result
+		firstErr error
+	)
+	sem := make(chan struct{}, concurrency)
+	for _, f := range folders {
+		if f.Kind != models.JobNodeFolder {
+			continue
+		}
+		if _, ok, err := cache.JobNodesInDir(cacheDir, client.CacheKey(), f.URL); err == nil && ok {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+		case sem <- struct{}{}:
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(f models.JobNode) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			nodes, err := client.ListJobNodes(ctx, f.URL, f.FullName)
+			if err == nil {
+				err = cache.SaveJobNodesInDir(cacheDir, client.CacheKey(), f.URL, nodes)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				result.Errors++
+				if firstErr == nil {
+					firstErr = fmt.Errorf("list %s: %w", folderLabel(f.FullName), err)
+				}
+				return
+			}
+			result.Folders++
+			for _, n := range nodes {
+				if n.Kind == models.JobNodeJob {
+					result.Jobs++
+				}
+			}
+		}(f)
+	}
+	wg.Wait()
+	if firstErr == nil && ctx.Err() != nil {
+		firstErr = ctx.Err()
+	}
+	result.Duration = time.Since(started)
+	return result, firstErr
+}
+
+func folderLabel(prefix string) string {
+	if strings.TrimSpace(prefix) == "" {
+		return "/"
+	}
+	return "/" + strings.TrimLeft(prefix, "/")
+}
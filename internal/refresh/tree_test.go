@@ -0,0 +1,110 @@
+package refresh
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"jenkins-tui/internal/cache"
+	"jenkins-tui/internal/jenkins"
+	"jenkins-tui/internal/models"
+)
+
+func TestTreeCachesFoldersAndIndexesJobs(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		job := func(name, path, class string) string {
+			return fmt.Sprintf(`{"name":%q,"url":"%s%s","_class":%q}`, name, srv.URL, path, class)
+		}
+		switch r.URL.Path {
+		case "/api/json":
+			fmt.Fprintf(w, `{"jobs":[%s,%s]}`,
+				job("infra", "/job/infra/", "com.cloudbees.hudson.plugins.folder.Folder"),
+				job("root-job", "/job/root-job/", "hudson.model.FreeStyleProject"))
+		case "/job/infra/api/json":
+			fmt.Fprintf(w, `{"jobs":[%s]}`, job("deploy", "/job/infra/job/deploy/", "org.jenkinsci.plugins.workflow.job.WorkflowJob"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	cacheDir := t.TempDir()
+	client := jenkins.NewClient(models.JenkinsTarget{Host: srv.URL, Username: "u"}, "t", 5*time.Second)
+	var reports []Progress
+	result, err := TreeWithProgress(context.Background(), client, cacheDir, 2, func(p Progress) { reports = append(reports, p) })
+	if err != nil {
+		t.Fatalf("Tree: %v", err)
+	}
+	if result.Folders != 2 || result.Jobs != 2 {
+		t.Fatalf("expected 2 folders and 2 jobs, got %+v", result)
+	}
+	if len(reports) != 2 || reports[0].Queued != 1 || reports[1] != (Progress{Folders: 2, Jobs: 2, Queued: 0, Folder: "infra"}) {
+		t.Fatalf("unexpected progress reports: %+v", reports)
+	}
+
+	jobs, _, ok, err := cache.JobIndexInDir(cacheDir, client.CacheKey())
+	if err != nil || !ok {
+		t.Fatalf("expected job index, ok=%v err=%v", ok, err)
+	}
+	names := []string{}
+	for _, j := range jobs {
+		names = append(names, j.FullName)
+	}
+	if strings.Join(names, ",") != "infra/deploy,root-job" {
+		t.Fatalf("unexpected index contents: %v", names)
+	}
+	if _, ok, _ := cache.JobNodesInDir(cacheDir, client.CacheKey(), srv.URL+"/job/infra/"); !ok {
+		t.Fatalf("expected folder listing to be cached")
+	}
+}
+
+func TestChildrenPrefetchesUncachedFolders(t *testing.T) {
+	var (
+		srv  *httptest.Server
+		mu   sync.Mutex
+		hits = map[string]int{}
+	)
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits[r.URL.Path]++
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/job/a/api/json", "/job/b/api/json":
+			fmt.Fprintf(w, `{"jobs":[{"name":"deploy","url":"%s%sjob/deploy/","_class":"hudson.model.FreeStyleProject"}]}`, srv.URL, strings.TrimSuffix(r.URL.Path, "api/json"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	cacheDir := t.TempDir()
+	client := jenkins.NewClient(models.JenkinsTarget{Host: srv.URL, Username: "u"}, "t", 5*time.Second)
+	folders := []models.JobNode{
+		{Name: "a", FullName: "a", URL: srv.URL + "/job/a/", Kind: models.JobNodeFolder},
+		{Name: "b", FullName: "b", URL: srv.URL + "/job/b/", Kind: models.JobNodeFolder},
+		{Name: "job", FullName: "job", URL: srv.URL + "/job/job/", Kind: models.JobNodeJob},
+	}
+	if err := cache.SaveJobNodesInDir(cacheDir, client.CacheKey(), srv.URL+"/job/b/", nil); err != nil {
+		t.Fatal(err)
+	}
+	result, err := Children(context.Background(), client, cacheDir, folders, 2)
+	if err != nil {
+		t.Fatalf("Children: %v", err)
+	}
+	if result.Folders != 1 || result.Jobs != 1 {
+		t.Fatalf("expected only the uncached folder to be listed, got %+v", result)
+	}
+	if hits["/job/b/api/json"] != 0 || hits["/job/job/api/json"] != 0 {
+		t.Fatalf("cached folders and jobs should not be requested, hits=%v", hits)
+	}
+	nodes, ok, _ := cache.JobNodesInDir(cacheDir, client.CacheKey(), srv.URL+"/job/a/")
+	if !ok || len(nodes) != 1 || nodes[0].FullName != "a/deploy" {
+		t.Fatalf("expected the prefetched listing in the cache, got %v (ok=%v)", nodes, ok)
+	}
+}
@@ -0,0 +1,144 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"jenkins-tui/internal/models"
+	"jenkins-tui/internal/redact"
+)
+
+// Suite is one batch of runs of a single job.
+type Suite struct {
+	Name    string
+	Started time.Time
+	Runs    []models.RunRecord
+}
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Errors   int          `xml:"errors,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name      string      `xml:"name,attr"`
+	Tests     int         `xml:"tests,attr"`
+	Failures  int         `xml:"failures,attr"`
+	Errors    int         `xml:"errors,attr"`
+	Skipped   int         `xml:"skipped,attr"`
+	Time      string      `xml:"time,attr"`
+	Timestamp string      `xml:"timestamp,attr,omitempty"`
+	Cases     []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+}
+
+// WriteJUnit renders suites as JUnit XML with one testcase per run. Failed
+// and aborted builds are failures, runs that could not be triggered or
+// tracked are errors, and runs that never finished are skipped.
+func WriteJUnit(w io.Writer, suites []Suite) error {
+	out := junitSuites{}
+	for _, s := range suites {
+		js := junitSuite{Name: s.Name, Cases: make([]junitCase, 0, len(s.Runs))}
+		if !s.Started.IsZero() {
+			js.Timestamp = s.Started.UTC().Format(time.RFC3339)
+		}
+		var total time.Duration
+		for _, r := range s.Runs {
+			c := junitCase{
+				Name:      caseName(r),
+				ClassName: s.Name,
+				Time:      "0",
+				SystemOut: r.BuildURL,
+			}
+			if !r.EndedAt.IsZero() && !r.StartedAt.IsZero() {
+				d := r.EndedAt.Sub(r.StartedAt)
+				total += d
+				c.Time = seconds(d)
+			}
+			switch r.State {
+			case models.RunSuccess:
+			case models.RunFailed, models.RunAborted:
+				c.Failure = &junitMessage{Message: "build result " + resultOf(r), Type: resultOf(r)}
+				js.Failures++
+			case models.RunError:
+				c.Error = &junitMessage{Message: redact.String(r.Err)}
+				js.Errors++
+			default:
+				c.Skipped = &junitMessage{Message: "not finished: " + string(r.State)}
+				js.Skipped++
+			}
+			js.Cases = append(js.Cases, c)
+		}
+		js.Tests = len(js.Cases)
+		js.Time = seconds(total)
+		out.Tests += js.Tests
+		out.Failures += js.Failures
+		out.Errors += js.Errors
+		out.Skipped += js.Skipped
+		out.Suites = append(out.Suites, js)
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func caseName(r models.RunRecord) string {
+	params := r.Spec.ShownParams()
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+"="+redact.String(params[k]))
+	}
+	name := fmt.Sprintf("run %d", r.Index+1)
+	if r.Spec.Job != nil {
+		name += " " + r.Spec.Job.FullName
+	}
+	if len(parts) > 0 {
+		name += " [" + strings.Join(parts, ", ") + "]"
+	}
+	return name
+}
+
+func resultOf(r models.RunRecord) string {
+	if r.Result != "" {
+		return r.Result
+	}
+	return string(r.State)
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
@@ -0,0 +1,61 @@
+package report
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"jenkins-tui/internal/models"
+)
+
+func TestWriteJUnitOneCasePerRun(t *testing.T) {
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	suites := []Suite{{
+		Name:    "apps/deploy",
+		Started: start,
+		Runs: []models.RunRecord{
+			{Index: 0, Spec: models.JobSpec{Params: map[string]string{"env": "dev"}}, State: models.RunSuccess, Result: "SUCCESS", BuildURL: "https://jenkins/job/deploy/5/", StartedAt: start, EndedAt: start.Add(90 * time.Second)},
+			{Index: 1, Spec: models.JobSpec{Params: map[string]string{"env": "prod"}}, State: models.RunFailed, Result: "UNSTABLE", StartedAt: start, EndedAt: start.Add(time.Minute)},
+			{Index: 2, State: models.RunError, Err: "trigger failed (403)"},
+			{Index: 3, State: models.RunQueued},
+		},
+	}}
+	var buf bytes.Buffer
+	if err := WriteJUnit(&buf, suites); err != nil {
+		t.Fatalf("WriteJUnit: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		`<testsuites tests="4" failures="1" errors="1" skipped="1">`,
+		`<testsuite name="apps/deploy" tests="4" failures="1" errors="1" skipped="1" time="150.000" timestamp="2024-05-01T10:00:00Z">`,
+		`<testcase name="run 1 [env=dev]" classname="apps/deploy" time="90.000">`,
+		`<system-out>https://jenkins/job/deploy/5/</system-out>`,
+		`<failure message="build result UNSTABLE" type="UNSTABLE"></failure>`,
+		`<error message="trigger failed (403)"></error>`,
+		`<skipped message="not finished: QUEUED"></skipped>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in report:\n%s", want, out)
+		}
+	}
+	var parsed junitSuites
+	if err := xml.Unmarshal(buf.Bytes(), &parsed); err != nil {
+		t.Fatalf("report should be valid XML: %v", err)
+	}
+}
+
+func TestWriteJUnitMasksPasswordParams(t *testing.T) {
+	runs := []models.RunRecord{{
+		Spec:  models.JobSpec{Params: map[string]string{"PIN": "4711", "env": "dev"}, Secret: []string{"PIN"}},
+		State: models.RunSuccess, Result: "SUCCESS",
+	}}
+	var buf bytes.Buffer
+	if err := WriteJUnit(&buf, []Suite{{Name: "apps/deploy", Runs: runs}}); err != nil {
+		t.Fatalf("WriteJUnit: %v", err)
+	}
+	if out := buf.String(); strings.Contains(out, "4711") || !strings.Contains(out, `name="run 1 [PIN=&lt;redacted&gt;, env=dev]"`) {
+		t.Fatalf("expected the password masked in the report:\n%s", out)
+	}
+}
@@ -0,0 +1,90 @@
+package report
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// LogFileName names a saved console log after the job's full name and the
+// build number, e.g. infra_deploy-42.log for infra/deploy #42.
+func LogFileName(fullName string, number int) string {
+	return fmt.Sprintf("%s-%d.log", fileStem(fullName, "build"), number)
+}
+
+// ReportFileName names a saved JUnit report after a batch and when it
+// started, e.g. nightly-20240501-0230.xml.
+func ReportFileName(name string, started time.Time) string {
+	return fileStem(name, "report") + started.Format("-20060102-1504") + ".xml"
+}
+
+// fileStem keeps the characters of name that are safe in a file name on
+// every platform, replacing the rest with _.
+func fileStem(name, fallback string) string {
+	stem := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			return r
+		}
+		return '_'
+	}, strings.Trim(name, "/"))
+	if stem == "" {
+		return fallback
+	}
+	return stem
+}
+
+// SaveLog writes a build's console text into dir, creating it, and returns
+// the file's path. An empty dir is the current directory and a leading ~/
+// the home directory. Logs can hold secrets a pipeline echoed, so the file
+// is only readable by the user.
+func SaveLog(dir, fullName string, number int, text string) (string, error) {
+	dir, err := makeDir(dir)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, LogFileName(fullName, number))
+	if err := os.WriteFile(path, []byte(text), 0o600); err != nil {
+		return "", fmt.Errorf("write %s: %w", path, err)
+	}
+	return path, nil
+}
+
+// SaveJUnit writes suites as a JUnit report into dir, named with
+// ReportFileName, and returns the file's path. dir is resolved as in SaveLog.
+func SaveJUnit(dir, name string, started time.Time, suites []Suite) (string, error) {
+	dir, err := makeDir(dir)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, ReportFileName(name, started))
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	if err := WriteJUnit(f, suites); err != nil {
+		f.Close()
+		return "", fmt.Errorf("write %s: %w", path, err)
+	}
+	return path, f.Close()
+}
+
+func makeDir(dir string) (string, error) {
+	dir = strings.TrimSpace(dir)
+	if dir == "" {
+		dir = "."
+	}
+	if strings.HasPrefix(dir, "~/") || strings.HasPrefix(dir, "~"+string(filepath.Separator)) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("resolve home dir: %w", err)
+		}
+		dir = filepath.Join(home, dir[2:])
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("create log dir %s: %w", dir, err)
+	}
+	return dir, nil
+}
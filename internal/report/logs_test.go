@@ -0,0 +1,22 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveLogNamesFileByJobAndBuild(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "logs")
+	path, err := SaveLog(dir, "apps/deploy (prod)", 42, "Started by user\nFinished: FAILURE\n")
+	if err != nil {
+		t.Fatalf("SaveLog: %v", err)
+	}
+	if want := filepath.Join(dir, "apps_deploy__prod_-42.log"); path != want {
+		t.Fatalf("expected %s, got %s", want, path)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil || string(b) != "Started by user\nFinished: FAILURE\n" {
+		t.Fatalf("expected the console text written, got %q (%v)", b, err)
+	}
+}
@@ -0,0 +1,208 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"jenkins-tui/internal/cron"
+	"jenkins-tui/internal/executor"
+	"jenkins-tui/internal/jenkins"
+	"jenkins-tui/internal/models"
+	"jenkins-tui/internal/permutation"
+	"jenkins-tui/internal/report"
+)
+
+const (
+	// maxRuns matches the TUI's permutation limit.
+	maxRuns = 20
+	// concurrency matches the TUI's executor.
+	concurrency = 4
+)
+
+// Outcome is what one firing of a schedule did.
+type Outcome struct {
+	Runs []models.RunRecord
+	// Report is the JUnit file written for the batch.
+	Report string
+	// Logs are the console logs saved for failed builds.
+	Logs []string
+}
+
+// Failed counts the runs that did not succeed.
+func (o Outcome) Failed() int {
+	n := 0
+	for _, r := range o.Runs {
+		if r.State != models.RunSuccess {
+			n++
+		}
+	}
+	return n
+}
+
+// Specs expands the schedule's parameters into one spec per run.
+func Specs(s models.Schedule) ([]models.JobSpec, error) {
+	input := permutation.Input{ChoiceValues: map[string][]string{}, FixedValues: map[string]string{}}
+	for k, values := range s.Params {
+		switch len(values) {
+		case 0:
+			input.FixedValues[k] = ""
+		case 1:
+			input.FixedValues[k] = values[0]
+		default:
+			input.ChoiceValues[k] = values
+		}
+	}
+	return permutation.Build(input, maxRuns)
+}
+
+// Run triggers every run of s on jobURL, waits for them all, and writes the
+// JUnit report and failed builds' logs into dir. A run that fails is part of
+// the outcome; the error is only for a schedule that could not run or be
+// reported at all.
+func Run(ctx context.Context, client *jenkins.Client, s models.Schedule, jobURL, dir string) (Outcome, error) {
+	specs, err := Specs(s)
+	if err != nil {
+		return Outcome{}, fmt.Errorf("schedule %s: %w", s.Name, err)
+	}
+	// The config does not say which parameters are passwords; the job does.
+	// Without its definitions the report still goes through redact.
+	if defs, err := client.GetJobParams(ctx, jobURL); err == nil {
+		for i := range specs {
+			specs[i].Secret = models.PasswordNames(defs)
+		}
+	}
+	started := time.Now()
+	out := Outcome{Runs: make([]models.RunRecord, len(specs))}
+	for i, spec := range specs {
+		out.Runs[i] = models.RunRecord{Index: i, Spec: spec, State: models.RunPlanned, StartedAt: started}
+	}
+	ch := make(chan models.RunUpdate)
+	go executor.Run(ctx, client, jobURL, specs, concurrency, ch)
+	for u := range ch {
+		apply(&out.Runs[u.Index], u)
+	}
+
+	fullName := jenkins.FullNameFromJobURL(jobURL)
+	out.Report, err = report.SaveJUnit(dir, s.Name, started, []report.Suite{{Name: fullName, Started: started, Runs: out.Runs}})
+	if err != nil {
+		return out, err
+	}
+	for _, r := range out.Runs {
+		if r.State == models.RunSuccess || r.BuildURL == "" {
+			continue
+		}
+		text, err := client.GetConsoleText(ctx, r.BuildURL)
+		if err != nil {
+			return out, fmt.Errorf("console log of #%d: %w", r.BuildNumber, err)
+		}
+		path, err := report.SaveLog(dir, fullName, r.BuildNumber, text)
+		if err != nil {
+			return out, err
+		}
+		out.Logs = append(out.Logs, path)
+	}
+	return out, nil
+}
+
+func apply(r *models.RunRecord, u models.RunUpdate) {
+	r.State = u.State
+	if u.QueueURL != "" {
+		r.QueueURL = u.QueueURL
+	}
+	if u.BuildURL != "" {
+		r.BuildURL = u.BuildURL
+	}
+	if u.BuildNumber != 0 {
+		r.BuildNumber = u.BuildNumber
+	}
+	if u.Result != "" {
+		r.Result = u.Result
+	}
+	if u.Err != nil {
+		r.Err = u.Err.Error()
+	}
+	if u.Done {
+		r.EndedAt = time.Now()
+	}
+}
+
+// Due lists the schedules whose next firing after last is at or before now,
+// by name.
+func Due(crons map[string]cron.Cron, last, now time.Time) []string {
+	var due []string
+	for name, c := range crons {
+		if next := c.Next(last); !next.IsZero() && !next.After(now) {
+			due = append(due, name)
+		}
+	}
+	sort.Strings(due)
+	return due
+}
+
+// ErrNeverFires reports that none of the schedules can ever fire again.
+var ErrNeverFires = errors.New("none of the schedules ever runs")
+
+// Loop fires each schedule of crons when it is due, calling fire in its own
+// goroutine, until ctx is done; it then waits for the firings in progress.
+// A schedule whose previous firing is still running is not fired again
+// alongside it: that firing is dropped and reported to skip.
+func Loop(ctx context.Context, crons map[string]cron.Cron, fire func(ctx context.Context, name string), skip func(name string)) error {
+	return loop(ctx, crons, fire, skip, time.Now, func(ctx context.Context, until time.Time) bool {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(time.Until(until)):
+			return true
+		}
+	})
+}
+
+// loop is Loop with its clock passed in: now reads it and sleep waits until
+// a time, returning false once ctx is done.
+func loop(ctx context.Context, crons map[string]cron.Cron, fire func(context.Context, string), skip func(string), now func() time.Time, sleep func(context.Context, time.Time) bool) error {
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		running = map[string]bool{}
+	)
+	defer wg.Wait()
+	last := now()
+	for {
+		var next time.Time
+		for _, c := range crons {
+			if t := c.Next(last); !t.IsZero() && (next.IsZero() || t.Before(next)) {
+				next = t
+			}
+		}
+		if next.IsZero() {
+			return ErrNeverFires
+		}
+		if !sleep(ctx, next) {
+			return nil
+		}
+		t := now()
+		for _, name := range Due(crons, last, t) {
+			mu.Lock()
+			busy := running[name]
+			running[name] = true
+			mu.Unlock()
+			if busy {
+				skip(name)
+				continue
+			}
+			wg.Add(1)
+			go func(name string) {
+				defer wg.Done()
+				fire(ctx, name)
+				mu.Lock()
+				delete(running, name)
+				mu.Unlock()
+			}(name)
+		}
+		last = t
+	}
+}
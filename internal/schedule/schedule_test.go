@@ -0,0 +1,136 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"jenkins-tui/internal/cron"
+	"jenkins-tui/internal/jenkins"
+	"jenkins-tui/internal/models"
+)
+
+func TestDueListsSchedulesPastTheirNextRun(t *testing.T) {
+	hourly, _ := cron.Parse("@hourly")
+	daily, _ := cron.Parse("@daily")
+	last := time.Date(2024, time.May, 1, 10, 30, 0, 0, time.UTC)
+	due := Due(map[string]cron.Cron{"hourly": hourly, "daily": daily}, last, last.Add(time.Hour))
+	if len(due) != 1 || due[0] != "hourly" {
+		t.Fatalf("expected only the hourly schedule to be due, got %v", due)
+	}
+}
+
+func TestLoopSkipsAScheduleStillRunning(t *testing.T) {
+	every, _ := cron.Parse("* * * * *")
+	clock := time.Date(2024, time.May, 1, 10, 30, 0, 0, time.UTC)
+	var mu sync.Mutex
+	ticks := make(chan struct{})
+	sleep := func(ctx context.Context, until time.Time) bool {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticks:
+		}
+		mu.Lock()
+		clock = until
+		mu.Unlock()
+		return true
+	}
+	now := func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return clock
+	}
+	started, skipped := make(chan string, 4), make(chan string, 4)
+	release := make(chan struct{})
+	fire := func(ctx context.Context, name string) {
+		started <- name
+		<-release
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- loop(ctx, map[string]cron.Cron{"nightly": every}, fire, func(name string) { skipped <- name }, now, sleep)
+	}()
+	ticks <- struct{}{}
+	if name := <-started; name != "nightly" {
+		t.Fatalf("fired %q", name)
+	}
+	ticks <- struct{}{}
+	if name := <-skipped; name != "nightly" {
+		t.Fatalf("skipped %q", name)
+	}
+	if len(started) != 0 {
+		t.Fatal("a schedule still running should not fire again")
+	}
+
+	cancel()
+	select {
+	case <-done:
+		t.Fatal("the loop should wait for the firing in progress")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("loop: %v", err)
+	}
+}
+
+func TestLoopStopsWhenNothingFires(t *testing.T) {
+	never, err := cron.Parse("0 0 30 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = loop(context.Background(), map[string]cron.Cron{"feb30": never}, func(context.Context, string) {}, func(string) {}, time.Now, func(context.Context, time.Time) bool { return true })
+	if !errors.Is(err, ErrNeverFires) {
+		t.Fatalf("expected ErrNeverFires, got %v", err)
+	}
+}
+
+func TestRunWritesReportForEveryPermutation(t *testing.T) {
+	var mu sync.Mutex
+	var triggered []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/job/smoke/buildWithParameters" {
+			http.NotFound(w, r)
+			return
+		}
+		_ = r.ParseForm()
+		mu.Lock()
+		triggered = append(triggered, r.Form.Encode())
+		mu.Unlock()
+		http.Error(w, "no executors", http.StatusBadRequest)
+	}))
+	defer srv.Close()
+	client := jenkins.NewClient(models.JenkinsTarget{Host: srv.URL}, "token", time.Second)
+	s := models.Schedule{Name: "nightly", Job: "smoke", Params: map[string]models.ParamValues{"BRANCH": {"main"}, "REGION": {"eu", "us"}}}
+	dir := t.TempDir()
+
+	out, err := Run(context.Background(), client, s, srv.URL+"/job/smoke/", dir)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(out.Runs) != 2 || out.Failed() != 2 {
+		t.Fatalf("expected two failed runs, got %d run(s), %d failed", len(out.Runs), out.Failed())
+	}
+	if len(triggered) != 2 {
+		t.Fatalf("expected a trigger per region, got %v", triggered)
+	}
+	if !strings.HasPrefix(out.Report, dir) || !strings.Contains(out.Report, "nightly-") {
+		t.Fatalf("expected the report in %s, got %q", dir, out.Report)
+	}
+	b, err := os.ReadFile(out.Report)
+	if err != nil {
+		t.Fatalf("read report: %v", err)
+	}
+	if got := strings.Count(string(b), "<testcase"); got != 2 {
+		t.Fatalf("expected a test case per run, got %d in:\n%s", got, b)
+	}
+}
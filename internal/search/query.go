@@ -0,0 +1,227 @@
+// Package search parses the job search syntax shared by the TUI's global
+// search and the search and jobs list commands: words and quoted phrases,
+// /regex/ terms, and path:, in:, type: and result: filters.
+package search
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"jenkins-tui/internal/models"
+)
+
+// Query is a parsed search. Every part that is set must match.
+type Query struct {
+	// Terms are words and quoted phrases that must appear in a job's full
+	// name, ignoring case.
+	Terms []string
+	// Paths are prefixes of the full name, from path: terms.
+	Paths []string
+	// Patterns come from /regex/ terms and match the full name.
+	Patterns []*regexp.Regexp
+	// Scope is the folder results must be inside, from in:.
+	Scope string
+	// Kind is pipeline, freestyle, multibranch or folder, from type:.
+	Kind string
+	// Result is a last build result such as failed, from result: or is:.
+	Result string
+}
+
+var kinds = []string{"pipeline", "freestyle", "multibranch", "folder"}
+
+var resultColors = map[string]string{
+	"success":  "blue",
+	"failed":   "red",
+	"failing":  "red",
+	"unstable": "yellow",
+	"aborted":  "aborted",
+	"notbuilt": "notbuilt",
+	"disabled": "disabled",
+	"building": "building",
+}
+
+// Parse reads input. here is the full name of the open folder, which in:.
+// and in:here stand for.
+func Parse(input, here string) (Query, error) {
+	var q Query
+	for _, tok := range tokenize(input) {
+		if tok.quoted {
+			q.Terms = append(q.Terms, tok.text)
+			continue
+		}
+		if pattern, ok := strings.CutPrefix(tok.text, "/"); ok && len(pattern) > 1 && strings.HasSuffix(pattern, "/") {
+			re, err := regexp.Compile("(?i)" + strings.TrimSuffix(pattern, "/"))
+			if err != nil {
+				return q, fmt.Errorf("bad regex %s: %w", tok.text, err)
+			}
+			q.Patterns = append(q.Patterns, re)
+			continue
+		}
+		name, value, ok := strings.Cut(tok.text, ":")
+		if !ok || value == "" {
+			q.Terms = append(q.Terms, tok.text)
+			continue
+		}
+		switch strings.ToLower(name) {
+		case "path":
+			q.Paths = append(q.Paths, strings.TrimLeft(value, "/"))
+		case "in":
+			q.Scope = strings.Trim(value, "/")
+			if value == "." || value == "here" {
+				q.Scope = here
+			}
+		case "type":
+			q.Kind = strings.ToLower(value)
+			if !slices.Contains(kinds, q.Kind) {
+				return q, fmt.Errorf("type: must be one of %s", strings.Join(kinds, ", "))
+			}
+		case "result", "is":
+			q.Result = strings.ToLower(value)
+			if _, ok := resultColors[q.Result]; !ok {
+				return q, fmt.Errorf("result: must be success, failed, unstable, aborted, notbuilt, disabled or building")
+			}
+		default:
+			q.Terms = append(q.Terms, tok.text)
+		}
+	}
+	return q, nil
+}
+
+type token struct {
+	text   string
+	quoted bool
+}
+
+// tokenize splits on spaces outside double quotes. A token that starts with
+// a quote is a phrase; quotes inside a token, as in path:"my folder", only
+// keep its spaces.
+func tokenize(input string) []token {
+	var out []token
+	var cur strings.Builder
+	var quoted, inQuote, started bool
+	flush := func() {
+		if started {
+			out = append(out, token{text: cur.String(), quoted: quoted})
+		}
+		cur.Reset()
+		quoted, started = false, false
+	}
+	for _, r := range input {
+		switch {
+		case r == '"':
+			if !started {
+				quoted = true
+			}
+			started = true
+			inQuote = !inQuote
+		case (r == ' ' || r == '\t') && !inQuote:
+			flush()
+		default:
+			started = true
+			cur.WriteRune(r)
+		}
+	}
+	flush()
+	return out
+}
+
+// Remote splits the query for the Jenkins search endpoints, which match a
+// single string: text is the longest term, and rest is what still has to
+// be checked on what comes back. text is empty when the query has no
+// terms, e.g. only a regex, which only the local job index can answer.
+func (q Query) Remote() (text string, rest Query) {
+	best := -1
+	for i, t := range q.Terms {
+		if best < 0 || len(t) > len(q.Terms[best]) {
+			best = i
+		}
+	}
+	rest = q
+	if best < 0 {
+		return "", rest
+	}
+	rest.Terms = slices.Delete(slices.Clone(q.Terms), best, best+1)
+	return q.Terms[best], rest
+}
+
+// Empty reports whether nothing was typed that could select jobs.
+func (q Query) Empty() bool {
+	return len(q.Terms) == 0 && len(q.Paths) == 0 && len(q.Patterns) == 0
+}
+
+// Matches checks everything a job listing or search hit carries: terms,
+// paths, patterns and scope, against its full name or any other path to it.
+func (q Query) Matches(n models.JobNode) bool {
+	names := append([]string{n.FullName}, n.Paths...)
+	for _, name := range names {
+		if q.matchesName(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (q Query) matchesName(fullName string) bool {
+	lower := strings.ToLower(fullName)
+	for _, t := range q.Terms {
+		if !strings.Contains(lower, strings.ToLower(t)) {
+			return false
+		}
+	}
+	for _, p := range q.Paths {
+		if !strings.HasPrefix(lower, strings.ToLower(p)) {
+			return false
+		}
+	}
+	for _, re := range q.Patterns {
+		if !re.MatchString(fullName) {
+			return false
+		}
+	}
+	if q.Scope != "" && !strings.HasPrefix(lower, strings.ToLower(q.Scope)+"/") {
+		return false
+	}
+	return true
+}
+
+// NeedsDetails reports whether matching needs each job's class or color,
+// which search hits do not carry.
+func (q Query) NeedsDetails() bool {
+	return q.Kind != "" || q.Result != ""
+}
+
+// MatchesDetails checks type: and result: against a job read in full.
+func (q Query) MatchesDetails(n models.JobNode) bool {
+	switch q.Kind {
+	case "pipeline":
+		if !n.Pipeline {
+			return false
+		}
+	case "freestyle":
+		if n.Kind != models.JobNodeJob || n.Pipeline {
+			return false
+		}
+	case "multibranch":
+		if !n.Multibranch {
+			return false
+		}
+	case "folder":
+		if n.Kind != models.JobNodeFolder || n.Multibranch {
+			return false
+		}
+	}
+	// A job that is building again keeps its last result with an _anime suffix.
+	color := strings.TrimSuffix(n.Color, "_anime")
+	switch want := resultColors[q.Result]; want {
+	case "":
+	case "building":
+		return strings.HasSuffix(n.Color, "_anime")
+	case "blue":
+		return color == "blue" || color == "green"
+	default:
+		return color == want
+	}
+	return true
+}
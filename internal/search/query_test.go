@@ -0,0 +1,146 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"jenkins-tui/internal/gitrepo"
+	"jenkins-tui/internal/jenkins"
+	"jenkins-tui/internal/models"
+)
+
+func TestParseSplitsTermsPhrasesAndFilters(t *testing.T) {
+	q, err := Parse(`deploy "blue green" path:"platform/my apps" /^platform/.*-(prod|stage)$/ in:. type:pipeline is:failing`, "platform")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if strings.Join(q.Terms, "|") != "deploy|blue green" {
+		t.Fatalf("unexpected terms %q", q.Terms)
+	}
+	if len(q.Paths) != 1 || q.Paths[0] != "platform/my apps" {
+		t.Fatalf("unexpected paths %q", q.Paths)
+	}
+	if len(q.Patterns) != 1 || q.Scope != "platform" || q.Kind != "pipeline" || q.Result != "failing" {
+		t.Fatalf("unexpected query %+v", q)
+	}
+	if text, rest := q.Remote(); text != "blue green" || strings.Join(rest.Terms, "|") != "deploy" {
+		t.Fatalf("expected the longest term to go to Jenkins, got %q and %q", text, rest.Terms)
+	}
+
+	for _, bad := range []string{"x type:matrix", "x result:weird", "/(unclosed/"} {
+		if _, err := Parse(bad, ""); err == nil {
+			t.Fatalf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestMatchesChecksEveryPart(t *testing.T) {
+	q, err := Parse(`deploy path:platform/ /-(prod|stage)$/`, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := map[string]bool{
+		"platform/apps/deploy-prod":  true,
+		"platform/apps/Deploy-STAGE": true,
+		"platform/apps/deploy-dev":   false,
+		"tools/deploy-prod":          false,
+		"platform/apps/build-prod":   false,
+	}
+	for name, want := range cases {
+		if got := q.Matches(models.JobNode{FullName: name}); got != want {
+			t.Errorf("Matches(%q) = %v, want %v", name, got, want)
+		}
+	}
+	if !q.Matches(models.JobNode{FullName: "views/all/deploy-prod", Paths: []string{"platform/deploy-prod"}}) {
+		t.Errorf("a job should match through any of its paths")
+	}
+}
+
+func TestMatchesDetailsKeepsResultWhileBuilding(t *testing.T) {
+	cases := []struct {
+		result, color string
+		want          bool
+	}{
+		{"failed", "red", true},
+		{"failed", "red_anime", true},
+		{"success", "blue_anime", true},
+		{"unstable", "yellow_anime", true},
+		{"failed", "blue_anime", false},
+		{"building", "red_anime", true},
+		{"building", "red", false},
+	}
+	for _, c := range cases {
+		q, err := Parse("result:"+c.result, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := q.MatchesDetails(models.JobNode{Kind: models.JobNodeJob, Color: c.color}); got != c.want {
+			t.Errorf("result:%s against %q = %v, want %v", c.result, c.color, got, c.want)
+		}
+	}
+}
+
+func TestJobsUsesIndexOrRemoteSearch(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/search/suggestOpenSearch" {
+			http.NotFound(w, r)
+			return
+		}
+		if got := r.URL.Query().Get("q"); got != "deploy" {
+			t.Errorf("expected only the longest term to be sent, got %q", got)
+		}
+		fmt.Fprintf(w, `["deploy",["deploy-prod","deploy-dev"],["apps/deploy-prod","apps/deploy-dev"],["%[1]s/job/apps/job/deploy-prod/","%[1]s/job/apps/job/deploy-dev/"]]`, srv.URL)
+	}))
+	defer srv.Close()
+	client := jenkins.NewClient(models.JenkinsTarget{Host: srv.URL}, "token", time.Second)
+
+	q, _ := Parse("deploy prod", "")
+	jobs, found, err := Jobs(context.Background(), client, nil, q, 10)
+	if err != nil || found != 2 || len(jobs) != 1 || jobs[0].FullName != "apps/deploy-prod" {
+		t.Fatalf("unexpected remote result %+v found=%d (%v)", jobs, found, err)
+	}
+
+	regexOnly, _ := Parse("/-dev$/", "")
+	if _, _, err := Jobs(context.Background(), client, nil, regexOnly, 10); !errors.Is(err, ErrNeedsIndex) {
+		t.Fatalf("expected ErrNeedsIndex without an index, got %v", err)
+	}
+	index := []models.JobNode{{FullName: "apps/deploy-prod"}, {FullName: "apps/deploy-dev"}}
+	jobs, _, err = Jobs(context.Background(), client, index, regexOnly, 10)
+	if err != nil || len(jobs) != 1 || jobs[0].FullName != "apps/deploy-dev" {
+		t.Fatalf("unexpected index result %+v (%v)", jobs, err)
+	}
+}
+
+func TestRepoJobsMatchesMultibranchSources(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/json":
+			fmt.Fprintf(w, `{"jobs":[{"name":"apps","url":"%[1]s/job/apps/","_class":"com.cloudbees.hudson.plugins.folder.Folder"},{"name":"legacy","url":"%[1]s/job/legacy/","_class":"hudson.model.FreeStyleProject"}]}`, srv.URL)
+		case "/job/apps/api/json":
+			fmt.Fprintf(w, `{"jobs":[{"name":"web","url":"%[1]s/job/apps/job/web/","_class":"org.jenkinsci.plugins.workflow.multibranch.WorkflowMultiBranchProject"},{"name":"api","url":"%[1]s/job/apps/job/api/","_class":"org.jenkinsci.plugins.workflow.multibranch.WorkflowMultiBranchProject"}]}`, srv.URL)
+		case "/job/apps/job/web/config.xml":
+			fmt.Fprint(w, `<project><sources><data><jenkins.branch.BranchSource><source><remote>https://github.com/org/web.git</remote></source></jenkins.branch.BranchSource></data></sources></project>`)
+		case "/job/apps/job/api/config.xml":
+			fmt.Fprint(w, `<project><sources><data><jenkins.branch.BranchSource><source><remote>https://github.com/org/api.git</remote></source></jenkins.branch.BranchSource></data></sources></project>`)
+		default:
+			t.Errorf("unexpected request %s; branch jobs should not be listed", r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	client := jenkins.NewClient(models.JenkinsTarget{Host: srv.URL}, "token", time.Second)
+
+	repo, _ := gitrepo.Parse("git@github.com:org/web.git")
+	jobs, err := RepoJobs(context.Background(), client, t.TempDir(), repo)
+	if err != nil || len(jobs) != 1 || jobs[0].FullName != "apps/web" {
+		t.Fatalf("RepoJobs = %+v (%v)", jobs, err)
+	}
+}
@@ -0,0 +1,86 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"jenkins-tui/internal/cache"
+	"jenkins-tui/internal/gitrepo"
+	"jenkins-tui/internal/jenkins"
+	"jenkins-tui/internal/models"
+)
+
+// RepoJobs finds the multibranch projects building repo. It walks the job
+// tree through the folder cache, listing folders it has no fresh copy of,
+// and reads the branch sources from each multibranch project's config.xml.
+// Projects whose config cannot be read are skipped.
+func RepoJobs(ctx context.Context, client *jenkins.Client, cacheDir string, repo gitrepo.Repo) ([]models.JobNode, error) {
+	projects, err := multibranchProjects(ctx, client, cacheDir)
+	if err != nil {
+		return nil, err
+	}
+	keep := make([]bool, len(projects))
+	sem := make(chan struct{}, detailConcurrency)
+	var wg sync.WaitGroup
+	for i, n := range projects {
+		wg.Add(1)
+		go func(i int, n models.JobNode) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			configXML, err := client.GetJobConfig(ctx, n.URL)
+			if err != nil {
+				return
+			}
+			for _, source := range jenkins.SourceRepos(configXML) {
+				if repo.Matches(source) {
+					keep[i] = true
+					return
+				}
+			}
+		}(i, n)
+	}
+	wg.Wait()
+	out := []models.JobNode{}
+	for i, n := range projects {
+		if keep[i] {
+			out = append(out, n)
+		}
+	}
+	return out, ctx.Err()
+}
+
+// multibranchProjects lists every multibranch project on the server. Their
+// branch jobs are not listed.
+func multibranchProjects(ctx context.Context, client *jenkins.Client, cacheDir string) ([]models.JobNode, error) {
+	var out []models.JobNode
+	var walk func(containerURL, prefix string) error
+	walk = func(containerURL, prefix string) error {
+		nodes, ok, err := cache.JobNodesInDir(cacheDir, client.CacheKey(), containerURL)
+		if err != nil || !ok {
+			if nodes, err = client.ListJobNodes(ctx, containerURL, prefix); err != nil {
+				return fmt.Errorf("list /%s: %w", prefix, err)
+			}
+			_ = cache.SaveJobNodesInDir(cacheDir, client.CacheKey(), containerURL, nodes)
+		}
+		for _, n := range nodes {
+			switch {
+			case n.Multibranch:
+				out = append(out, n)
+			case n.Kind == models.JobNodeFolder:
+				if err := walk(n.URL, n.FullName); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := walk(client.Host(), ""); err != nil {
+		return nil, err
+	}
+	sort.Slice(out, func(i, j int) bool { return strings.ToLower(out[i].FullName) < strings.ToLower(out[j].FullName) })
+	return out, nil
+}
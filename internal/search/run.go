@@ -0,0 +1,87 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"jenkins-tui/internal/jenkins"
+	"jenkins-tui/internal/models"
+)
+
+// detailConcurrency bounds the per-job requests made for type: and result:.
+const detailConcurrency = 4
+
+// remoteScan is how many Jenkins search hits are read when the rest of the
+// query will narrow them down.
+const remoteScan = 100
+
+// ErrNeedsIndex is returned for a query without words, e.g. only a regex,
+// when there is no job index to match it against.
+var ErrNeedsIndex = errors.New("a query without words needs the job index; sync the server first")
+
+// Jobs answers q from index when one is given, and otherwise by sending the
+// query's longest term to Jenkins search and checking the rest on the hits.
+// found counts the jobs before type: and result: were checked, which takes a
+// request per job; for Jenkins search it counts every hit.
+func Jobs(ctx context.Context, client *jenkins.Client, index []models.JobNode, q Query, limit int) (jobs []models.JobNode, found int, err error) {
+	candidates, rest := index, q
+	if index == nil {
+		text, r := q.Remote()
+		if text == "" {
+			return nil, 0, ErrNeedsIndex
+		}
+		scan := limit
+		if !r.Empty() || r.Scope != "" || q.NeedsDetails() {
+			scan = max(limit, remoteScan)
+		}
+		hits, err := client.SearchJobs(ctx, text, scan)
+		if err != nil {
+			return nil, 0, err
+		}
+		candidates, rest = hits, r
+	}
+	matched := make([]models.JobNode, 0, len(candidates))
+	for _, n := range candidates {
+		if rest.Matches(n) {
+			matched = append(matched, n)
+		}
+	}
+	found = len(matched)
+	if index == nil {
+		found = len(candidates)
+	}
+	if q.NeedsDetails() {
+		matched = filterDetails(ctx, client, q, matched)
+	}
+	if limit > 0 && len(matched) > limit {
+		matched = matched[:limit]
+	}
+	return matched, found, nil
+}
+
+// filterDetails reads each job's class and color and keeps the ones
+// matching q. Jobs that cannot be read are dropped.
+func filterDetails(ctx context.Context, client *jenkins.Client, q Query, nodes []models.JobNode) []models.JobNode {
+	keep := make([]bool, len(nodes))
+	sem := make(chan struct{}, detailConcurrency)
+	var wg sync.WaitGroup
+	for i, n := range nodes {
+		wg.Add(1)
+		go func(i int, n models.JobNode) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			detail, err := client.JobNode(ctx, n.URL)
+			keep[i] = err == nil && q.MatchesDetails(detail)
+		}(i, n)
+	}
+	wg.Wait()
+	out := make([]models.JobNode, 0, len(nodes))
+	for i, n := range nodes {
+		if keep[i] {
+			out = append(out, n)
+		}
+	}
+	return out
+}
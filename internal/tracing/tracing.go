@@ -0,0 +1,321 @@
+// Package tracing is a minimal OTLP/HTTP (JSON) span exporter. It covers what
+// jenkins-tui needs, spans per API call and per run plus W3C traceparent
+// propagation, without pulling in the OpenTelemetry SDK. It stays a no-op
+// unless an OTLP endpoint is configured through the standard OTEL_* variables.
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	batchSize     = 100
+	flushInterval = 5 * time.Second
+)
+
+type Attr struct {
+	Key   string
+	Value any
+}
+
+func String(key, value string) Attr  { return Attr{Key: key, Value: value} }
+func Int(key string, value int) Attr { return Attr{Key: key, Value: value} }
+
+type Span struct {
+	traceID [16]byte
+	spanID  [8]byte
+	parent  [8]byte
+	name    string
+	kind    int
+	start   time.Time
+	attrs   []Attr
+	err     error
+	ended   bool
+	mu      sync.Mutex
+}
+
+const (
+	KindInternal = 1
+	KindClient   = 3
+)
+
+type spanKey struct{}
+
+var (
+	mu       sync.RWMutex
+	exporter *otlpExporter
+)
+
+// Enabled reports whether spans are being exported.
+func Enabled() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return exporter != nil
+}
+
+// Start begins a span as a child of the span in ctx, if any. When tracing is
+// disabled it returns ctx unchanged and a nil span; Span methods accept nil.
+func Start(ctx context.Context, name string, kind int, attrs ...Attr) (context.Context, *Span) {
+	if !Enabled() {
+		return ctx, nil
+	}
+	s := &Span{name: name, kind: kind, start: time.Now(), attrs: attrs}
+	if parent, ok := ctx.Value(spanKey{}).(*Span); ok && parent != nil {
+		s.traceID = parent.traceID
+		s.parent = parent.spanID
+	} else {
+		_, _ = rand.Read(s.traceID[:])
+	}
+	_, _ = rand.Read(s.spanID[:])
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+func (s *Span) SetAttr(attrs ...Attr) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attrs = append(s.attrs, attrs...)
+}
+
+// End finishes the span, marking it as an error when err is non-nil.
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.err = err
+	s.mu.Unlock()
+	mu.RLock()
+	exp := exporter
+	mu.RUnlock()
+	if exp != nil {
+		exp.add(s.toOTLP(time.Now()))
+	}
+}
+
+// Traceparent returns the W3C traceparent header for the span in ctx, or ""
+// when there is none or tracing is disabled, so nothing is propagated for
+// spans that are not exported.
+func Traceparent(ctx context.Context) string {
+	s, ok := ctx.Value(spanKey{}).(*Span)
+	if !ok || s == nil || !Enabled() {
+		return ""
+	}
+	return "00-" + hex.EncodeToString(s.traceID[:]) + "-" + hex.EncodeToString(s.spanID[:]) + "-01"
+}
+
+// Setup enables export when OTEL_EXPORTER_OTLP_TRACES_ENDPOINT or
+// OTEL_EXPORTER_OTLP_ENDPOINT is set. The returned function flushes pending
+// spans and must be called before exit; later calls do nothing.
+func Setup(serviceVersion string) (func(context.Context), error) {
+	endpoint := strings.TrimSpace(os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"))
+	if endpoint == "" {
+		base := strings.TrimSpace(os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"))
+		if base == "" {
+			return func(context.Context) {}, nil
+		}
+		endpoint = strings.TrimRight(base, "/") + "/v1/traces"
+	}
+	if !strings.HasPrefix(endpoint, "http://") && !strings.HasPrefix(endpoint, "https://") {
+		return nil, fmt.Errorf("OTLP endpoint %q must be an http(s) URL", endpoint)
+	}
+	headers, err := parseHeaders(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"))
+	if err != nil {
+		return nil, err
+	}
+	service := strings.TrimSpace(os.Getenv("OTEL_SERVICE_NAME"))
+	if service == "" {
+		service = "jenkins-tui"
+	}
+	exp := newExporter(endpoint, headers, service, serviceVersion)
+	mu.Lock()
+	exporter = exp
+	mu.Unlock()
+	var once sync.Once
+	return func(ctx context.Context) {
+		once.Do(func() {
+			mu.Lock()
+			exporter = nil
+			mu.Unlock()
+			exp.shutdown(ctx)
+		})
+	}, nil
+}
+
+// parseHeaders reads the OTEL_EXPORTER_OTLP_HEADERS "k=v,k2=v2" format.
+func parseHeaders(raw string) (map[string]string, error) {
+	out := map[string]string{}
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("invalid OTEL_EXPORTER_OTLP_HEADERS entry %q", part)
+		}
+		out[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return out, nil
+}
+
+type otlpExporter struct {
+	endpoint string
+	headers  map[string]string
+	resource map[string]any
+	http     *http.Client
+
+	mu      sync.Mutex
+	pending []map[string]any
+	kick    chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+func newExporter(endpoint string, headers map[string]string, service, version string) *otlpExporter {
+	e := &otlpExporter{
+		endpoint: endpoint,
+		headers:  headers,
+		resource: map[string]any{"attributes": otlpAttrs([]Attr{
+			String("service.name", service),
+			String("service.version", version),
+		})},
+		http:    &http.Client{Timeout: 10 * time.Second},
+		kick:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go e.loop()
+	return e
+}
+
+func (e *otlpExporter) add(span map[string]any) {
+	e.mu.Lock()
+	e.pending = append(e.pending, span)
+	full := len(e.pending) >= batchSize
+	e.mu.Unlock()
+	if full {
+		select {
+		case e.kick <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (e *otlpExporter) loop() {
+	defer close(e.stopped)
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-e.done:
+			return
+		case <-ticker.C:
+		case <-e.kick:
+		}
+		e.flush(context.Background())
+	}
+}
+
+func (e *otlpExporter) shutdown(ctx context.Context) {
+	close(e.done)
+	<-e.stopped
+	e.flush(ctx)
+}
+
+func (e *otlpExporter) flush(ctx context.Context) {
+	e.mu.Lock()
+	spans := e.pending
+	e.pending = nil
+	e.mu.Unlock()
+	if len(spans) == 0 {
+		return
+	}
+	body, err := json.Marshal(map[string]any{
+		"resourceSpans": []any{map[string]any{
+			"resource": e.resource,
+			"scopeSpans": []any{map[string]any{
+				"scope": map[string]any{"name": "jenkins-tui"},
+				"spans": spans,
+			}},
+		}},
+	})
+	if err != nil {
+		slog.Debug("otlp export failed", "error", err.Error())
+		return
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		slog.Debug("otlp export failed", "error", err.Error())
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := e.http.Do(req)
+	if err != nil {
+		slog.Debug("otlp export failed", "spans", len(spans), "error", err.Error())
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		slog.Debug("otlp export rejected", "spans", len(spans), "status", resp.StatusCode)
+	}
+}
+
+func (s *Span) toOTLP(end time.Time) map[string]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	span := map[string]any{
+		"traceId":           hex.EncodeToString(s.traceID[:]),
+		"spanId":            hex.EncodeToString(s.spanID[:]),
+		"name":              s.name,
+		"kind":              s.kind,
+		"startTimeUnixNano": fmt.Sprint(s.start.UnixNano()),
+		"endTimeUnixNano":   fmt.Sprint(end.UnixNano()),
+		"attributes":        otlpAttrs(s.attrs),
+	}
+	if s.parent != ([8]byte{}) {
+		span["parentSpanId"] = hex.EncodeToString(s.parent[:])
+	}
+	if s.err != nil {
+		span["status"] = map[string]any{"code": 2, "message": s.err.Error()}
+	}
+	return span
+}
+
+func otlpAttrs(attrs []Attr) []any {
+	out := make([]any, 0, len(attrs))
+	for _, a := range attrs {
+		var value map[string]any
+		switch v := a.Value.(type) {
+		case int:
+			value = map[string]any{"intValue": fmt.Sprint(v)}
+		case bool:
+			value = map[string]any{"boolValue": v}
+		default:
+			value = map[string]any{"stringValue": fmt.Sprint(v)}
+		}
+		out = append(out, map[string]any{"key": a.Key, "value": value})
+	}
+	return out
+}
@@ -0,0 +1,111 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestStartIsNoopWithoutEndpoint(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+	shutdown, err := Setup("test")
+	if err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	defer shutdown(context.Background())
+	ctx, span := Start(context.Background(), "noop", KindInternal)
+	if span != nil || Traceparent(ctx) != "" {
+		t.Fatalf("expected no span when tracing is disabled")
+	}
+	span.End(nil)
+}
+
+func TestSpansExportAsOTLPJSON(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []string
+	var auth string
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		if r.URL.Path == "/v1/traces" {
+			bodies = append(bodies, string(b))
+			auth = r.Header.Get("Authorization")
+		}
+	}))
+	defer collector.Close()
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", collector.URL)
+	t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "Authorization=Bearer abc")
+	t.Setenv("OTEL_SERVICE_NAME", "")
+
+	shutdown, err := Setup("1.2.3")
+	if err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	ctx, parent := Start(context.Background(), "jenkins.run", KindInternal, String("jenkins.job.url", "https://j/job/a/"))
+	childCtx, child := Start(ctx, "HTTP GET", KindClient)
+	tp := Traceparent(childCtx)
+	child.SetAttr(Int("http.response.status_code", 500))
+	child.End(errors.New("HTTP 500"))
+	parent.End(nil)
+	shutdown(context.Background())
+	if Traceparent(childCtx) != "" {
+		t.Fatalf("expected no traceparent once tracing is shut down")
+	}
+
+	if !strings.HasPrefix(tp, "00-") || len(tp) != 55 {
+		t.Fatalf("unexpected traceparent %q", tp)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(bodies) != 1 || auth != "Bearer abc" {
+		t.Fatalf("expected one authenticated export, got %d (auth %q)", len(bodies), auth)
+	}
+	var payload struct {
+		ResourceSpans []struct {
+			ScopeSpans []struct {
+				Spans []struct {
+					TraceID      string `json:"traceId"`
+					SpanID       string `json:"spanId"`
+					ParentSpanID string `json:"parentSpanId"`
+					Name         string `json:"name"`
+					Status       *struct {
+						Code int `json:"code"`
+					} `json:"status"`
+				} `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	if err := json.Unmarshal([]byte(bodies[0]), &payload); err != nil {
+		t.Fatalf("decode export: %v", err)
+	}
+	spans := payload.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 2 || spans[0].Name != "HTTP GET" || spans[1].Name != "jenkins.run" {
+		t.Fatalf("unexpected spans %+v", spans)
+	}
+	if spans[0].TraceID != spans[1].TraceID || spans[0].ParentSpanID != spans[1].SpanID {
+		t.Fatalf("child span not linked to parent: %+v", spans)
+	}
+	if spans[0].Status == nil || spans[0].Status.Code != 2 || spans[1].Status != nil {
+		t.Fatalf("unexpected span status: %+v", spans)
+	}
+	if !strings.Contains(bodies[0], `"service.version"`) || !strings.Contains(tp, spans[0].TraceID) {
+		t.Fatalf("missing resource attributes or trace id mismatch: %s", bodies[0])
+	}
+}
+
+func TestSetupRejectsMalformedHeaders(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "http://localhost:4318/v1/traces")
+	t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "novalue")
+	if _, err := Setup("test"); err == nil {
+		t.Fatalf("expected malformed headers to be rejected")
+	}
+}
@@ -0,0 +1,483 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+
+	"jenkins-tui/internal/jenkins"
+	"jenkins-tui/internal/models"
+	"jenkins-tui/internal/report"
+	"jenkins-tui/internal/ui"
+)
+
+// runBatch is one set of triggered runs with its own executor goroutine,
+// cancel func, and run table. Several batches can be tracked at once.
+type runBatch struct {
+	id       int
+	job      models.JobRef
+	client   *jenkins.Client
+	indexing bool
+	records  []models.RunRecord
+	finished map[int]bool
+	marks    map[int]bool
+	table    table.Model
+	events   <-chan models.RunUpdate
+	ctx      context.Context
+	cancel   context.CancelFunc
+	started  time.Time
+	// stopped is set when tracking ended before every run finished.
+	stopped bool
+}
+
+func (b *runBatch) done() bool {
+	return len(b.finished) == len(b.records)
+}
+
+func (b *runBatch) active() bool {
+	return !b.done() && !b.stopped
+}
+
+func (b *runBatch) apply(u models.RunUpdate) {
+	if u.Index < 0 || u.Index >= len(b.records) {
+		return
+	}
+	r := b.records[u.Index]
+	if r.State == models.RunStalled && u.Err == nil {
+		r.Err = ""
+	}
+	r.State = u.State
+	if u.QueueURL != "" {
+		r.QueueURL = u.QueueURL
+	}
+	if u.BuildURL != "" {
+		r.BuildURL = u.BuildURL
+	}
+	if u.BuildNumber != 0 {
+		r.BuildNumber = u.BuildNumber
+	}
+	if u.Result != "" {
+		r.Result = u.Result
+	}
+	if u.Err != nil {
+		r.Err = u.Err.Error()
+	}
+	if u.Done {
+		r.EndedAt = time.Now()
+		b.finished[u.Index] = true
+	}
+	b.records[u.Index] = r
+}
+
+// summary counts the batch by outcome, e.g. "3/8 done, 1 failed, 2 stalled".
+func (b *runBatch) summary() string {
+	failed := 0
+	for _, r := range b.records {
+		if r.State == models.RunFailed || r.State == models.RunAborted || r.State == models.RunError {
+			failed++
+		}
+	}
+	s := fmt.Sprintf("%d/%d done", len(b.finished), len(b.records))
+	if failed > 0 {
+		s += fmt.Sprintf(", %d failed", failed)
+	}
+	if stalled := b.stalled(); stalled > 0 {
+		s += fmt.Sprintf(", %d stalled", stalled)
+	}
+	if b.stopped {
+		s += ", stopped"
+	}
+	return s
+}
+
+// stalled counts the runs waiting for an unreachable server.
+func (b *runBatch) stalled() int {
+	n := 0
+	for _, r := range b.records {
+		if r.State == models.RunStalled {
+			n++
+		}
+	}
+	return n
+}
+
+// startBatch registers a new batch for job and makes it the one the run
+// screen shows. The caller starts its executor with b.ctx.
+func (m *model) startBatch(job models.JobRef, specs []models.JobSpec, indexing bool) *runBatch {
+	m.batchSeq++
+	ctx, cancel := context.WithCancel(m.ctx)
+	b := &runBatch{
+		id:       m.batchSeq,
+		job:      job,
+		client:   m.client,
+		indexing: indexing,
+		records:  make([]models.RunRecord, 0, len(specs)),
+		finished: map[int]bool{},
+		marks:    map[int]bool{},
+		ctx:      ctx,
+		cancel:   cancel,
+		started:  time.Now(),
+	}
+	for i, spec := range specs {
+		b.records = append(b.records, models.RunRecord{Index: i, Spec: spec, State: models.RunPlanned, StartedAt: time.Now()})
+	}
+	m.batches = append(m.batches, b)
+	m.batch = b
+	m.refreshRunTable(b)
+	return b
+}
+
+func (m *model) findBatch(id int) *runBatch {
+	for _, b := range m.batches {
+		if b.id == id {
+			return b
+		}
+	}
+	return nil
+}
+
+const (
+	quitAbort = "abort"
+	quitLeave = "leave"
+	quitStay  = "stay"
+)
+
+type buildsAbortedMsg struct {
+	total int
+	errs  []error
+}
+
+// requestQuit exits right away unless triggered builds are still in flight,
+// in which case it asks whether to abort them on Jenkins first. A second
+// ctrl+c while asking (or while aborting) quits without touching them.
+func (m *model) requestQuit() tea.Cmd {
+	if m.confirm != nil || m.quitting {
+		m.confirm = nil
+		return m.quit()
+	}
+	inFlight := 0
+	for _, b := range m.batches {
+		if b.active() && !b.indexing {
+			inFlight += len(b.records) - len(b.finished)
+		}
+	}
+	if inFlight == 0 {
+		return m.quit()
+	}
+	return m.askChoice(
+		fmt.Sprintf("%d build(s) still in flight", inFlight),
+		"Runs that have not been triggered yet are dropped either way.",
+		[]huh.Option[string]{
+			huh.NewOption("Abort them on Jenkins and quit", quitAbort),
+			huh.NewOption("Leave them running and quit", quitLeave),
+			huh.NewOption("Don't quit", quitStay),
+		},
+		func(choice string) tea.Cmd {
+			switch choice {
+			case quitAbort:
+				return m.abortInFlight()
+			case quitLeave:
+				return m.quit()
+			}
+			m.status = "Cancelled"
+			return nil
+		})
+}
+
+func (m *model) quit() tea.Cmd {
+	m.cancelBatches()
+	m.saveSession()
+	return tea.Quit
+}
+
+// abortInFlight stops tracking every active batch and asks Jenkins to stop
+// its running builds and drop its queued ones.
+func (m *model) abortInFlight() tea.Cmd {
+	type target struct {
+		client *jenkins.Client
+		record models.RunRecord
+	}
+	var targets []target
+	for _, b := range m.batches {
+		if !b.active() {
+			continue
+		}
+		b.cancel()
+		b.stopped = true
+		if b.indexing {
+			continue
+		}
+		for _, r := range b.records {
+			if !b.finished[r.Index] && (r.BuildURL != "" || r.QueueURL != "") {
+				targets = append(targets, target{client: b.client, record: r})
+			}
+		}
+	}
+	if len(targets) == 0 {
+		return m.quit()
+	}
+	m.quitting = true
+	m.err = nil
+	m.loading = true
+	m.loadingStart = time.Now()
+	m.loadingLabel = fmt.Sprintf("Aborting %d build(s)", len(targets))
+	m.status = m.loadingLabel + "..."
+	ctx := m.ctx
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+		defer cancel()
+		var errs []error
+		for _, t := range targets {
+			var err error
+			if t.record.BuildURL != "" {
+				err = t.client.StopBuild(ctx, t.record.BuildURL)
+			} else {
+				err = t.client.CancelQueueItem(ctx, t.record.QueueURL)
+			}
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return buildsAbortedMsg{total: len(targets), errs: errs}
+	}
+}
+
+func (m *model) cancelBatches() {
+	for _, b := range m.batches {
+		b.cancel()
+	}
+}
+
+// runWidget is the status bar's summary of the tracked run batches.
+func (m *model) runWidget() string {
+	if len(m.batches) == 0 {
+		return ""
+	}
+	hint := " (" + firstKey(m.keys.ShowRuns) + " to view)"
+	if m.screen == screenBatches {
+		hint = ""
+	}
+	if len(m.batches) == 1 {
+		b := m.batches[0]
+		if b.active() {
+			return m.spin.View() + " Runs " + selectedJobLabel(&b.job) + ": " + b.summary() + hint
+		}
+		return "Runs " + selectedJobLabel(&b.job) + " finished: " + b.summary() + hint
+	}
+	active := 0
+	for _, b := range m.batches {
+		if b.active() {
+			active++
+		}
+	}
+	widget := fmt.Sprintf("Batches: %d running, %d finished%s", active, len(m.batches)-active, hint)
+	if active > 0 {
+		widget = m.spin.View() + " " + widget
+	}
+	return widget
+}
+
+func (m *model) openBatches(cmds []tea.Cmd) tea.Cmd {
+	switch m.screen {
+	case screenRun, screenDone, screenBatches:
+		m.batchesBackTo = screenJobs
+	default:
+		m.batchesBackTo = m.screen
+	}
+	m.refreshBatchTable()
+	if m.batch != nil {
+		for i, b := range m.batches {
+			if b == m.batch {
+				m.batchTable.SetCursor(i)
+			}
+		}
+	}
+	m.status = fmt.Sprintf("%d run batch(es)", len(m.batches))
+	return m.transition(screenBatches, cmds...)
+}
+
+// openBatch shows b on the run screen, or the done screen once it finished.
+func (m *model) openBatch(b *runBatch, cmds []tea.Cmd) tea.Cmd {
+	m.batch = b
+	m.refreshRunTable(b)
+	m.status = ""
+	if b.active() {
+		return m.transition(screenRun, cmds...)
+	}
+	return m.transition(screenDone, cmds...)
+}
+
+func (m *model) selectedBatch() *runBatch {
+	idx := m.batchTable.Cursor()
+	if idx < 0 || idx >= len(m.batches) {
+		return nil
+	}
+	return m.batches[idx]
+}
+
+func (m *model) updateBatches(msg tea.Msg, cmds []tea.Cmd) (tea.Model, tea.Cmd) {
+	// The table binds d to half-page down, so batch keys are handled first.
+	km, ok := msg.(tea.KeyMsg)
+	if !ok {
+		var cmd tea.Cmd
+		m.batchTable, cmd = m.batchTable.Update(msg)
+		return m, tea.Batch(append(cmds, cmd)...)
+	}
+	switch s := km.String(); {
+	case s == "esc" || s == "backspace" || key.Matches(km, m.keys.ShowRuns):
+		m.status = ""
+		return m, m.transition(m.batchesBackTo, cmds...)
+	case s == "enter":
+		if b := m.selectedBatch(); b != nil {
+			return m, m.openBatch(b, cmds)
+		}
+	case key.Matches(km, m.keys.StopBatch):
+		b := m.selectedBatch()
+		if b == nil || !b.active() {
+			return m, tea.Batch(cmds...)
+		}
+		return m, tea.Batch(append(cmds, m.askConfirm(
+			fmt.Sprintf("Stop tracking batch %d?", b.id),
+			selectedJobLabel(&b.job)+" ("+b.summary()+"). Builds already triggered keep running on Jenkins.",
+			func() tea.Cmd {
+				b.cancel()
+				b.stopped = true
+				m.refreshBatchTable()
+				m.status = fmt.Sprintf("Stopped tracking batch %d", b.id)
+				return nil
+			}, nil))...)
+	case key.Matches(km, m.keys.RemoveBatch):
+		b := m.selectedBatch()
+		if b == nil || b.active() {
+			m.status = "Only finished or stopped batches can be removed"
+			return m, tea.Batch(cmds...)
+		}
+		m.removeBatch(b)
+		if len(m.batches) == 0 {
+			m.status = "No run batches left"
+			return m, m.transition(m.batchesBackTo, cmds...)
+		}
+		m.refreshBatchTable()
+		m.status = fmt.Sprintf("Removed batch %d", b.id)
+	default:
+		var cmd tea.Cmd
+		m.batchTable, cmd = m.batchTable.Update(msg)
+		cmds = append(cmds, cmd)
+	}
+	return m, tea.Batch(cmds...)
+}
+
+func (m *model) removeBatch(b *runBatch) {
+	kept := m.batches[:0]
+	for _, other := range m.batches {
+		if other != b {
+			kept = append(kept, other)
+		}
+	}
+	m.batches = kept
+	if m.batch == b {
+		m.batch = nil
+	}
+}
+
+func (m *model) refreshBatchTable() {
+	cursor := m.batchTable.Cursor()
+	contentWidth := m.contentWidth()
+	cols := []table.Column{
+		{Title: "#", Width: 4},
+		{Title: "Job", Width: max(20, contentWidth-59)},
+		{Title: "Progress", Width: 28},
+		{Title: "Started", Width: 11},
+	}
+	rows := make([]table.Row, 0, len(m.batches))
+	for _, b := range m.batches {
+		id := fmt.Sprintf("%d", b.id)
+		if b.active() {
+			id = ui.Glyph("●", "*") + id
+		}
+		label := selectedJobLabel(&b.job)
+		if b.indexing {
+			label += " (indexing)"
+		}
+		rows = append(rows, table.Row{
+			id,
+			clip(label, max(20, contentWidth-59)),
+			clip(b.summary(), 28),
+			ui.TimeOfDay(b.started),
+		})
+	}
+	t := table.New(
+		table.WithColumns(cols),
+		table.WithRows(rows),
+		table.WithFocused(true),
+		table.WithHeight(max(5, m.contentHeight()-14)),
+	)
+	t.SetStyles(defaultTableStyles(true))
+	m.batchTable = t
+	if cursor >= 0 && cursor < len(rows) {
+		m.batchTable.SetCursor(cursor)
+	}
+}
+
+// ExitSummary is the plain-text record of every batch started in the session,
+// printed once the alt screen is gone. It is empty when nothing ran.
+func ExitSummary(final tea.Model) string {
+	m, ok := final.(*model)
+	if !ok || len(m.batches) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	for _, b := range m.batches {
+		label := selectedJobLabel(&b.job)
+		if b.indexing {
+			label += " (indexing)"
+		}
+		fmt.Fprintf(&sb, "Batch %d: %s (%s)\n", b.id, label, b.summary())
+		for _, r := range b.records {
+			result := string(r.State)
+			if r.Result != "" {
+				result = r.Result
+			}
+			link := r.BuildURL
+			if link == "" {
+				link = r.QueueURL
+			}
+			if link == "" {
+				link = "-"
+			}
+			params := summarizeSpec(r.Spec)
+			if params == "" {
+				params = "-"
+			}
+			line := fmt.Sprintf("  run %d\t%s\t%s\t%s", r.Index+1, result, params, link)
+			if r.Err != "" {
+				line += "\terror=" + r.Err
+			}
+			sb.WriteString(line + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// ExitSuites returns the session's run batches for a JUnit report, one suite
+// per batch. Indexing batches are left out.
+func ExitSuites(final tea.Model) []report.Suite {
+	m, ok := final.(*model)
+	if !ok {
+		return nil
+	}
+	var suites []report.Suite
+	for _, b := range m.batches {
+		if b.indexing {
+			continue
+		}
+		suites = append(suites, report.Suite{Name: b.job.FullName, Started: b.started, Runs: b.records})
+	}
+	return suites
+}
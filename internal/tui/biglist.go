@@ -0,0 +1,93 @@
+package tui
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// narrowingFilter is the jobs list filter. The list filters every item on
+// each keystroke, which lags in folders with thousands of jobs. Typing one
+// more character can only drop fuzzy matches, so when the term extends the
+// previous one over the same items only the previous matches are searched
+// again. Filters run in commands, hence the lock.
+type narrowingFilter struct {
+	mu      sync.Mutex
+	term    string
+	targets []string
+	matched []int
+}
+
+func (f *narrowingFilter) Filter(term string, targets []string) []list.Rank {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	candidates := targets
+	var index []int
+	if f.term != "" && strings.HasPrefix(term, f.term) && sameTargets(f.targets, targets) {
+		candidates = make([]string, len(f.matched))
+		for i, idx := range f.matched {
+			candidates[i] = targets[idx]
+		}
+		index = f.matched
+	}
+	ranks := list.DefaultFilter(term, candidates)
+	matched := make([]int, len(ranks))
+	for i := range ranks {
+		if index != nil {
+			ranks[i].Index = index[ranks[i].Index]
+		}
+		matched[i] = ranks[i].Index
+	}
+	// Searching the survivors in their original order keeps ties ranked
+	// exactly as a full search would.
+	sort.Ints(matched)
+	f.term, f.targets, f.matched = term, targets, matched
+	return ranks
+}
+
+func sameTargets(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// setJobItems replaces the jobs list, unless a refresh brought back exactly
+// what is shown: rebuilding thousands of items would reset the filter and
+// re-run it for nothing. The returned command re-applies an active filter.
+func (m *model) setJobItems(items []list.Item) tea.Cmd {
+	if len(items) > 0 && reflect.DeepEqual(items, m.jobs.Items()) {
+		return nil
+	}
+	return m.jobs.SetItems(items)
+}
+
+// itemCounts caches how many items of each kind a list holds. The status
+// bar shows the counts on every frame, and counting thousands of jobs each
+// time is most of a frame's work. The list replaces its slice on every
+// SetItems, so the slice itself is the cache key.
+type itemCounts struct {
+	items  []list.Item
+	counts map[string]int
+}
+
+func (c *itemCounts) count(items []list.Item, kind func(list.Item) string) map[string]int {
+	if c.counts != nil && len(items) == len(c.items) && (len(items) == 0 || &items[0] == &c.items[0]) {
+		return c.counts
+	}
+	counts := map[string]int{}
+	for _, item := range items {
+		counts[kind(item)]++
+	}
+	c.items, c.counts = items, counts
+	return counts
+}
@@ -0,0 +1,42 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"jenkins-tui/internal/models"
+)
+
+// changeLines lists a build's commits, one line each as short id, author
+// and the first line of the message, at most limit of them.
+func changeLines(changes []models.Change, limit, width int) []string {
+	lines := make([]string, 0, min(len(changes), limit)+1)
+	for i, c := range changes {
+		if i == limit {
+			lines = append(lines, fmt.Sprintf("  ... %d more", len(changes)-limit))
+			break
+		}
+		id := c.ID
+		if len(id) > 7 {
+			id = id[:7]
+		}
+		msg, _, _ := strings.Cut(c.Message, "\n")
+		line := "  " + strings.TrimSpace(id+" "+c.Author+": "+msg)
+		lines = append(lines, clip(line, width))
+	}
+	return lines
+}
+
+// historyChangesPanel shows what went into the build highlighted in the
+// build history.
+func (m *model) historyChangesPanel(width int) string {
+	build, ok := m.selectedBuild()
+	if !ok {
+		return ""
+	}
+	if len(build.Changes) == 0 {
+		return fmt.Sprintf("No SCM changes in #%d", build.Number)
+	}
+	lines := append([]string{fmt.Sprintf("Changes in #%d (%d):", build.Number, len(build.Changes))}, changeLines(build.Changes, 3, width)...)
+	return strings.Join(lines, "\n")
+}
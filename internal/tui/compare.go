@@ -0,0 +1,134 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+
+	"jenkins-tui/internal/models"
+	"jenkins-tui/internal/ui"
+)
+
+// compareBuilds lays two builds out side by side, older first: result,
+// start, duration and commit count, then every parameter either one set.
+// Rows that differ are marked, and the count of differing parameters is
+// returned for the status line.
+func compareBuilds(a, b models.BuildSummary, width int) (string, int) {
+	if a.Number > b.Number {
+		a, b = b, a
+	}
+	names := map[string]bool{}
+	for k := range a.Params {
+		names[k] = true
+	}
+	for k := range b.Params {
+		names[k] = true
+	}
+	params := make([]string, 0, len(names))
+	labelWidth := len("Duration")
+	for k := range names {
+		params = append(params, k)
+		labelWidth = max(labelWidth, min(30, ansi.StringWidth(k)))
+	}
+	sort.Strings(params)
+	colWidth := max(10, (width-labelWidth-6)/2)
+
+	var lines []string
+	row := func(label, left, right string) bool {
+		differ := left != right
+		mark := "  "
+		if differ {
+			mark = ui.Glyph("≠ ", "! ")
+		}
+		line := mark + padRight(clip(label, labelWidth), labelWidth) + "  " + padRight(clip(left, colWidth), colWidth) + "  " + clip(right, colWidth)
+		if differ {
+			line = ui.Warn.Render(line)
+		}
+		lines = append(lines, line)
+		return differ
+	}
+	lines = append(lines, ui.Title.Render("  "+padRight("", labelWidth)+"  "+padRight(fmt.Sprintf("#%d", a.Number), colWidth)+"  "+fmt.Sprintf("#%d", b.Number)))
+	row("Result", buildResult(a), buildResult(b))
+	row("Started", buildStarted(a), buildStarted(b))
+	row("Duration", ui.Duration(a.Duration), ui.Duration(b.Duration))
+	row("Commits", fmt.Sprint(len(a.Changes)), fmt.Sprint(len(b.Changes)))
+	lines = append(lines, "")
+	if len(params) == 0 {
+		lines = append(lines, ui.Muted.Render("  Neither build has parameters"))
+		return strings.Join(lines, "\n"), 0
+	}
+	differ := 0
+	for _, k := range params {
+		if row(k, paramValue(a.Params, k), paramValue(b.Params, k)) {
+			differ++
+		}
+	}
+	return strings.Join(lines, "\n"), differ
+}
+
+func buildResult(b models.BuildSummary) string {
+	if b.Building {
+		return "BUILDING"
+	}
+	return b.Result
+}
+
+func buildStarted(b models.BuildSummary) string {
+	if b.Timestamp.IsZero() {
+		return ""
+	}
+	return ui.Timestamp(b.Timestamp)
+}
+
+func paramValue(params map[string]string, name string) string {
+	v, ok := params[name]
+	if !ok {
+		return "(not set)"
+	}
+	return v
+}
+
+func padRight(s string, width int) string {
+	return s + strings.Repeat(" ", max(0, width-ansi.StringWidth(s)))
+}
+
+// compareMarkedBuilds shows the two builds marked in the build history
+// side by side in the diff viewer.
+func (m *model) compareMarkedBuilds(cmds []tea.Cmd) tea.Cmd {
+	var marked []models.BuildSummary
+	for _, b := range m.builds {
+		if m.historyMarks[b.Number] {
+			marked = append(marked, b)
+		}
+	}
+	if len(marked) != 2 {
+		m.status = "Mark exactly two builds with " + firstKey(m.keys.MarkBuild) + " to compare them"
+		return tea.Batch(cmds...)
+	}
+	content, differ := compareBuilds(marked[0], marked[1], max(40, m.logDiff.Width))
+	m.logDiff.SetContent(content)
+	m.logDiff.GotoTop()
+	m.status = fmt.Sprintf("Builds #%d and #%d: %d parameter(s) differ", min(marked[0].Number, marked[1].Number), max(marked[0].Number, marked[1].Number), differ)
+	m.diffBackTo = screenHistory
+	return m.transition(screenLogDiff, cmds...)
+}
+
+func (m *model) toggleBuildMark() {
+	build, ok := m.selectedBuild()
+	if !ok {
+		return
+	}
+	if m.historyMarks[build.Number] {
+		delete(m.historyMarks, build.Number)
+	} else {
+		if len(m.historyMarks) >= 2 {
+			m.status = "Only two builds can be marked for comparison; unmark one first"
+			return
+		}
+		m.historyMarks[build.Number] = true
+	}
+	m.refreshHistoryTable()
+}
@@ -0,0 +1,181 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"jenkins-tui/internal/config"
+	"jenkins-tui/internal/models"
+)
+
+// configConflict is a save that collided with another instance's edit of the
+// same servers. The merged file kept their version of ids; ours holds ours
+// so the user can still pick it.
+type configConflict struct {
+	ours models.Config
+	ids  []string
+}
+
+// noteConfigOnDisk records cfg as the content of jenkins.yaml, the base the
+// next save is merged against if another instance writes in between.
+func (m *model) noteConfigOnDisk(cfg models.Config) {
+	m.configBase = config.Clone(cfg)
+	m.configHash, _ = config.Fingerprint(m.cfg.ConfigPath)
+	m.configStamp = statFile(m.cfg.ConfigPath)
+}
+
+// mergeAndSave handles a save that lost the race to another instance: our
+// edits since the last read are replayed onto their file. Servers both sides
+// changed keep their version for now and are offered back in a prompt.
+func (m *model) mergeAndSave() error {
+	path := m.cfg.ConfigPath
+	name := filepath.Base(path)
+	theirsHash, err := config.Fingerprint(path)
+	if err != nil {
+		return err
+	}
+	if theirsHash == "" {
+		// The file is gone; there is nothing of theirs to keep.
+		if _, err := config.SaveIfUnchanged(path, m.cfg, ""); err != nil {
+			return err
+		}
+		m.noteConfigOnDisk(m.cfg)
+		return nil
+	}
+	theirs, err := config.Load(path)
+	if err != nil {
+		return fmt.Errorf("%s was changed by another jenkins-tui and cannot be merged: %w", name, err)
+	}
+	ours := config.Clone(m.cfg)
+	merged, conflicts := config.MergeConcurrent(m.configBase, ours, theirs, false)
+	if _, err := config.SaveIfUnchanged(path, merged, theirsHash); err != nil {
+		if errors.Is(err, config.ErrChanged) {
+			return fmt.Errorf("%s keeps changing in another jenkins-tui; try again", name)
+		}
+		return err
+	}
+	targetID := ""
+	if m.target != nil {
+		targetID = m.target.ID
+	}
+	m.applyConfig(merged)
+	if targetID != "" {
+		m.target = m.findTargetByID(targetID)
+	}
+	if len(conflicts) > 0 {
+		m.configConflict = &configConflict{ours: ours, ids: conflicts}
+	}
+	return nil
+}
+
+// askConfigConflict lets the user put back their version of the servers
+// another instance changed at the same time.
+func (m *model) askConfigConflict() tea.Cmd {
+	conflict := m.configConflict
+	m.configConflict = nil
+	names := make([]string, 0, len(conflict.ids))
+	for _, id := range conflict.ids {
+		name := id
+		for _, t := range conflict.ours.Jenkins {
+			if t.ID == id {
+				name = t.Name
+			}
+		}
+		names = append(names, name)
+	}
+	return m.askConfirm(
+		filepath.Base(m.cfg.ConfigPath)+" was changed by another jenkins-tui",
+		"Both changed "+strings.Join(names, ", ")+". Your other changes were merged and saved.\n\nKeep your version of these? No keeps the other instance's version.",
+		func() tea.Cmd {
+			previous := m.cfg.Jenkins
+			m.cfg.Jenkins = takeTargets(m.cfg.Jenkins, conflict.ours.Jenkins, conflict.ids)
+			if err := m.persistConfig(); err != nil {
+				m.cfg.Jenkins = previous
+				m.err = err
+				m.status = "Failed to save your version"
+				return nil
+			}
+			m.refreshServerItems()
+			m.refreshManageItems()
+			m.status = "Saved your version of " + strings.Join(names, ", ")
+			return nil
+		},
+		func() tea.Cmd {
+			m.status = "Kept the other instance's version of " + strings.Join(names, ", ")
+			return nil
+		},
+	)
+}
+
+// takeTargets replaces the targets named by ids in current with their
+// version in from, dropping those from does not have.
+func takeTargets(current, from []models.JenkinsTarget, ids []string) []models.JenkinsTarget {
+	wanted := map[string]bool{}
+	for _, id := range ids {
+		wanted[id] = true
+	}
+	fromByID := map[string]models.JenkinsTarget{}
+	for _, t := range from {
+		fromByID[t.ID] = t
+	}
+	out := make([]models.JenkinsTarget, 0, len(current))
+	seen := map[string]bool{}
+	for _, t := range current {
+		if !wanted[t.ID] {
+			out = append(out, t)
+			continue
+		}
+		seen[t.ID] = true
+		if ours, ok := fromByID[t.ID]; ok {
+			out = append(out, ours)
+		}
+	}
+	for _, t := range from {
+		if wanted[t.ID] && !seen[t.ID] {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// confirmUndoConfig offers to put back the version of jenkins.yaml saved
+// before the last change.
+func (m *model) confirmUndoConfig() tea.Cmd {
+	name := filepath.Base(m.cfg.ConfigPath)
+	backups, err := config.Backups(m.cfg.ConfigPath)
+	if err != nil {
+		m.err = err
+		return nil
+	}
+	if len(backups) == 0 {
+		m.status = "No earlier version of " + name + " to restore"
+		return nil
+	}
+	return m.askConfirm(
+		"Undo the last change to "+name+"?",
+		fmt.Sprintf("Restores the version saved before %s; %d older version(s) remain for further undos. Tokens removed with a deleted server are not restored; add them again with %s.",
+			backups[0].Saved.Local().Format("Jan 2 15:04:05"), len(backups)-1, firstKey(m.keys.RotateToken)),
+		m.undoConfig,
+		nil,
+	)
+}
+
+func (m *model) undoConfig() tea.Cmd {
+	name := filepath.Base(m.cfg.ConfigPath)
+	restored, err := config.Undo(m.cfg.ConfigPath)
+	if err != nil {
+		m.err = err
+		m.status = "Failed to undo the last change to " + name
+		return nil
+	}
+	m.configStamp = statFile(m.cfg.ConfigPath)
+	cmd := m.reloadChangedConfig()
+	if m.err == nil && m.confirm == nil {
+		m.status = "Restored " + name + " as it was before " + restored.Saved.Local().Format("Jan 2 15:04:05")
+	}
+	return cmd
+}
@@ -0,0 +1,70 @@
+package tui
+
+import (
+	"log/slog"
+	"path/filepath"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fsnotify/fsnotify"
+)
+
+// configChangedMsg is the watcher seeing jenkins.yaml written, created,
+// renamed or removed.
+type configChangedMsg struct{}
+
+// configWatcher reports changes to jenkins.yaml. It watches the parent
+// directory rather than the file: editors and config.Save replace the file
+// by renaming a temporary one over it, which would end a watch on the file
+// itself.
+type configWatcher struct {
+	changes chan struct{}
+}
+
+// watchConfig starts watching path. Bursts of events, such as an editor's
+// write-then-rename, are coalesced into one change.
+func watchConfig(path string) (*configWatcher, error) {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	path = filepath.Clean(path)
+	if err := w.Add(filepath.Dir(path)); err != nil {
+		w.Close()
+		return nil, err
+	}
+	cw := &configWatcher{changes: make(chan struct{}, 1)}
+	go func() {
+		defer close(cw.changes)
+		for {
+			select {
+			case ev, ok := <-w.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(ev.Name) != path || ev.Op == fsnotify.Chmod {
+					continue
+				}
+				select {
+				case cw.changes <- struct{}{}:
+				default:
+				}
+			case err, ok := <-w.Errors:
+				if !ok {
+					return
+				}
+				slog.Debug("config watch error", "error", err.Error())
+			}
+		}
+	}()
+	return cw, nil
+}
+
+// wait delivers the next change as a configChangedMsg.
+func (cw *configWatcher) wait() tea.Cmd {
+	return func() tea.Msg {
+		if _, ok := <-cw.changes; !ok {
+			return nil
+		}
+		return configChangedMsg{}
+	}
+}
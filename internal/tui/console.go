@@ -0,0 +1,368 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+
+	"jenkins-tui/internal/jenkins"
+	"jenkins-tui/internal/models"
+	"jenkins-tui/internal/report"
+	"jenkins-tui/internal/ui"
+)
+
+// consoleErrorPattern marks the lines e jumps between. Runs of consecutive
+// matches, such as a stack trace, count as one stop.
+var consoleErrorPattern = regexp.MustCompile(`\b(ERROR|FATAL|FAILURE|FAILED)\b|[Ee]xception\b|^\s*Caused by:`)
+
+// consoleContext is how many lines above a jump target stay visible.
+const consoleContext = 3
+
+var consoleMatchStyle = lipgloss.NewStyle().Reverse(true)
+
+// buildLog is one build whose console log is viewed or saved; stage is set
+// when only that Pipeline stage's log is.
+type buildLog struct {
+	job    models.JobRef
+	number int
+	url    string
+	stage  string
+}
+
+func (b buildLog) label() string {
+	label := fmt.Sprintf("%s #%d", selectedJobLabel(&b.job), b.number)
+	if b.stage != "" {
+		label += " " + ui.Glyph("›", ">") + " " + b.stage
+	}
+	return label
+}
+
+// fileName is the job's full name, falling back to its name, for naming a
+// saved log; a stage log also carries the stage name.
+func (b buildLog) fileName() string {
+	name := b.job.FullName
+	if name == "" {
+		name = b.job.Name
+	}
+	if b.stage != "" {
+		name += "/" + b.stage
+	}
+	return name
+}
+
+type consoleLoadedMsg struct {
+	build buildLog
+	text  string
+	err   error
+}
+
+type logsSavedMsg struct {
+	paths []string
+	err   error
+}
+
+// openConsole fetches the console log of a build and shows it in the log
+// viewer; esc comes back to the current screen.
+func (m *model) openConsole(client *jenkins.Client, build buildLog, cmds []tea.Cmd) tea.Cmd {
+	if client == nil || build.url == "" {
+		m.status = "That run has no build yet"
+		return tea.Batch(cmds...)
+	}
+	m.loading = true
+	m.loadingStart = time.Now()
+	m.loadingLabel = "Fetching console log"
+	m.status = "Fetching console log of " + build.label() + "..."
+	return tea.Batch(append(cmds, loadConsoleCmd(m.ctx, client, build))...)
+}
+
+func loadConsoleCmd(ctx context.Context, client *jenkins.Client, build buildLog) tea.Cmd {
+	return func() tea.Msg {
+		text, err := client.GetConsoleText(ctx, build.url)
+		return consoleLoadedMsg{build: build, text: text, err: err}
+	}
+}
+
+// saveLogs writes the console logs of builds into the configured log_dir,
+// one file per build named by job and build number.
+func (m *model) saveLogs(client *jenkins.Client, builds []buildLog, cmds []tea.Cmd) tea.Cmd {
+	started := builds[:0:0]
+	for _, b := range builds {
+		if b.url != "" {
+			started = append(started, b)
+		}
+	}
+	if client == nil || len(started) == 0 {
+		m.status = "No started builds to save logs of"
+		return tea.Batch(cmds...)
+	}
+	m.loading = true
+	m.loadingStart = time.Now()
+	m.loadingLabel = "Saving console logs"
+	m.status = fmt.Sprintf("Saving %d console log(s)...", len(started))
+	return tea.Batch(append(cmds, saveLogsCmd(m.ctx, client, m.cfg.LogDir, started))...)
+}
+
+func saveLogsCmd(ctx context.Context, client *jenkins.Client, dir string, builds []buildLog) tea.Cmd {
+	return func() tea.Msg {
+		var paths []string
+		for _, b := range builds {
+			text, err := client.GetConsoleText(ctx, b.url)
+			if err != nil {
+				return logsSavedMsg{paths: paths, err: fmt.Errorf("console log of %s: %w", b.label(), err)}
+			}
+			path, err := report.SaveLog(dir, b.fileName(), b.number, text)
+			if err != nil {
+				return logsSavedMsg{paths: paths, err: err}
+			}
+			paths = append(paths, path)
+		}
+		return logsSavedMsg{paths: paths}
+	}
+}
+
+func saveLogTextCmd(dir string, build buildLog, text string) tea.Cmd {
+	return func() tea.Msg {
+		path, err := report.SaveLog(dir, build.fileName(), build.number, text)
+		if err != nil {
+			return logsSavedMsg{err: err}
+		}
+		return logsSavedMsg{paths: []string{path}}
+	}
+}
+
+func (m *model) logsSaved(msg logsSavedMsg, cmds []tea.Cmd) (tea.Model, tea.Cmd) {
+	m.loading = false
+	switch {
+	case msg.err != nil:
+		m.err = msg.err
+		m.status = "Failed to save console log"
+		if len(msg.paths) > 0 {
+			m.status = fmt.Sprintf("Saved %d console log(s) before failing", len(msg.paths))
+		}
+	case len(msg.paths) == 1:
+		m.err = nil
+		m.status = "Saved console log to " + msg.paths[0]
+	default:
+		m.err = nil
+		m.status = fmt.Sprintf("Saved %d console logs to %s", len(msg.paths), filepath.Dir(msg.paths[0]))
+	}
+	return m, tea.Batch(cmds...)
+}
+
+func (m *model) consoleLoaded(msg consoleLoadedMsg, cmds []tea.Cmd) (tea.Model, tea.Cmd) {
+	m.loading = false
+	if msg.err != nil {
+		m.err = msg.err
+		m.status = "Failed to load console log"
+		return m, tea.Batch(cmds...)
+	}
+	m.err = nil
+	m.consoleBuild = msg.build
+	m.consoleText = msg.text
+	m.consoleQuery = ""
+	m.consoleAt = -1
+	m.renderConsoleView()
+	m.consoleView.GotoBottom()
+	m.status = m.consoleColorStatus()
+	if m.screen != screenConsole {
+		m.consoleBackTo = m.screen
+	}
+	return m, m.transition(screenConsole, cmds...)
+}
+
+// renderConsoleView re-renders the log, e.g. after colors were toggled,
+// keeping the scroll position.
+func (m *model) renderConsoleView() {
+	m.consoleLines = strings.Split(ui.RenderConsole(m.consoleText, !m.consoleStrip), "\n")
+	m.consolePlain = make([]string, len(m.consoleLines))
+	for i, line := range m.consoleLines {
+		m.consolePlain[i] = ansi.Strip(line)
+	}
+	m.consoleErrors = errorLines(m.consolePlain)
+	m.applyConsoleSearch()
+}
+
+// applyConsoleSearch finds the lines matching consoleQuery, ignoring case,
+// and sets the viewport content with the matches highlighted. A matching
+// line is shown without its own colors so the highlight stays readable.
+func (m *model) applyConsoleSearch() {
+	m.consoleMatches = nil
+	lines := m.consoleLines
+	if m.consoleQuery != "" {
+		re := regexp.MustCompile("(?i)" + regexp.QuoteMeta(m.consoleQuery))
+		lines = slices.Clone(m.consoleLines)
+		for i, plain := range m.consolePlain {
+			locs := re.FindAllStringIndex(plain, -1)
+			if locs == nil {
+				continue
+			}
+			m.consoleMatches = append(m.consoleMatches, i)
+			var b strings.Builder
+			last := 0
+			for _, loc := range locs {
+				b.WriteString(plain[last:loc[0]])
+				b.WriteString(consoleMatchStyle.Render(plain[loc[0]:loc[1]]))
+				last = loc[1]
+			}
+			b.WriteString(plain[last:])
+			lines[i] = b.String()
+		}
+	}
+	offset := m.consoleView.YOffset
+	m.consoleView.SetContent(strings.Join(lines, "\n"))
+	m.consoleView.SetYOffset(offset)
+}
+
+func errorLines(plain []string) []int {
+	var out []int
+	prev := false
+	for i, line := range plain {
+		hit := consoleErrorPattern.MatchString(line)
+		if hit && !prev {
+			out = append(out, i)
+		}
+		prev = hit
+	}
+	return out
+}
+
+// jumpConsole scrolls to the next (dir 1) or previous (dir -1) of lines,
+// counting from the last jump while it is on screen and from the visible
+// page otherwise, and wraps around at either end.
+func (m *model) jumpConsole(lines []int, dir int, what string) {
+	top := m.consoleView.YOffset
+	bottom := top + m.consoleView.Height
+	ref := m.consoleAt
+	if ref < top || ref >= bottom {
+		ref = top - 1
+		if dir < 0 {
+			ref = bottom
+		}
+	}
+	idx := -1
+	if dir > 0 {
+		idx, _ = slices.BinarySearch(lines, ref+1)
+		if idx == len(lines) {
+			idx = 0
+		}
+	} else {
+		idx, _ = slices.BinarySearch(lines, ref)
+		idx--
+		if idx < 0 {
+			idx = len(lines) - 1
+		}
+	}
+	m.consoleAt = lines[idx]
+	m.consoleView.SetYOffset(max(0, m.consoleAt-consoleContext))
+	m.status = fmt.Sprintf("%s %d of %d, line %d", what, idx+1, len(lines), m.consoleAt+1)
+}
+
+func (m *model) consoleColorStatus() string {
+	if m.consoleStrip {
+		return fmt.Sprintf("Console log, colors stripped (%s shows them)", firstKey(m.keys.StripColors))
+	}
+	return fmt.Sprintf("Console log (%s strips colors)", firstKey(m.keys.StripColors))
+}
+
+func (m *model) updateConsole(msg tea.Msg, cmds []tea.Cmd) (tea.Model, tea.Cmd) {
+	if m.consoleSearching {
+		return m.updateConsoleSearch(msg, cmds)
+	}
+	var cmd tea.Cmd
+	m.consoleView, cmd = m.consoleView.Update(msg)
+	cmds = append(cmds, cmd)
+	km, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, tea.Batch(cmds...)
+	}
+	switch {
+	case km.String() == "esc" || km.String() == "backspace":
+		m.status = ""
+		return m, m.transition(m.consoleBackTo, cmds...)
+	case key.Matches(km, m.keys.SaveLog):
+		return m, tea.Batch(append(cmds, saveLogTextCmd(m.cfg.LogDir, m.consoleBuild, m.consoleText))...)
+	case key.Matches(km, m.keys.StripColors):
+		m.consoleStrip = !m.consoleStrip
+		m.renderConsoleView()
+		m.status = m.consoleColorStatus()
+	case km.String() == "/":
+		m.consoleSearching = true
+		m.consoleInput = m.consoleQuery
+	case key.Matches(km, m.keys.NextMatch), key.Matches(km, m.keys.PrevMatch):
+		if len(m.consoleMatches) == 0 {
+			m.status = "No search matches; / searches the log"
+			return m, tea.Batch(cmds...)
+		}
+		dir := 1
+		if key.Matches(km, m.keys.PrevMatch) {
+			dir = -1
+		}
+		m.jumpConsole(m.consoleMatches, dir, "Match")
+	case key.Matches(km, m.keys.NextError), key.Matches(km, m.keys.PrevError):
+		if len(m.consoleErrors) == 0 {
+			m.status = "No ERROR, FAILURE or exception lines in this log"
+			return m, tea.Batch(cmds...)
+		}
+		dir := 1
+		if key.Matches(km, m.keys.PrevError) {
+			dir = -1
+		}
+		m.jumpConsole(m.consoleErrors, dir, "Error")
+	}
+	return m, tea.Batch(cmds...)
+}
+
+func (m *model) updateConsoleSearch(msg tea.Msg, cmds []tea.Cmd) (tea.Model, tea.Cmd) {
+	km, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, tea.Batch(cmds...)
+	}
+	switch km.String() {
+	case "esc":
+		m.consoleSearching = false
+		m.status = ""
+	case "enter":
+		m.consoleSearching = false
+		m.consoleQuery = m.consoleInput
+		m.consoleAt = -1
+		m.applyConsoleSearch()
+		switch {
+		case m.consoleQuery == "":
+			m.status = "Search cleared"
+		case len(m.consoleMatches) == 0:
+			m.status = fmt.Sprintf("No matches for %q", m.consoleQuery)
+		default:
+			m.jumpConsole(m.consoleMatches, 1, "Match")
+		}
+	case "backspace":
+		m.consoleInput = trimLastRune(m.consoleInput)
+	default:
+		if len(km.Runes) > 0 {
+			m.consoleInput += string(km.Runes)
+		}
+	}
+	return m, tea.Batch(cmds...)
+}
+
+// consoleBody is the viewer with its header and, while typing, the search
+// prompt.
+func (m *model) consoleBody() string {
+	header := "Console: " + m.consoleBuild.label()
+	if m.consoleQuery != "" {
+		header += fmt.Sprintf(" | %q: %d line(s)", m.consoleQuery, len(m.consoleMatches))
+	}
+	body := ui.Muted.Render(header) + "\n\n" + m.consoleView.View()
+	if m.consoleSearching {
+		body += "\n/" + m.consoleInput + ui.Glyph("█", "_")
+	}
+	return body
+}
@@ -0,0 +1,108 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// editorCommand opens path in $VISUAL or $EDITOR, which may carry flags
+// (e.g. "code --wait") and a quoted program path such as
+// "C:\Program Files\Notepad++\notepad++.exe" -multiInst, falling back to
+// vi, or notepad on Windows.
+func (m *model) editorCommand(path string) *exec.Cmd {
+	editor := strings.TrimSpace(m.lookupEnv("VISUAL"))
+	if editor == "" {
+		editor = strings.TrimSpace(m.lookupEnv("EDITOR"))
+	}
+	if editor == "" {
+		editor = "vi"
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+	fields := splitCommand(editor)
+	return exec.Command(fields[0], append(fields[1:], path)...)
+}
+
+// splitCommand splits s at spaces outside single or double quotes. Unlike a
+// shell it leaves backslashes alone, so Windows paths survive unquoted.
+func splitCommand(s string) []string {
+	var (
+		fields []string
+		field  strings.Builder
+		quote  rune
+		inWord bool
+	)
+	for _, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				field.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t':
+			if inWord {
+				fields = append(fields, field.String())
+				field.Reset()
+				inWord = false
+			}
+		default:
+			field.WriteRune(r)
+			inWord = true
+		}
+	}
+	if inWord {
+		fields = append(fields, field.String())
+	}
+	return fields
+}
+
+// editFile suspends the TUI while the user's editor has path open; done
+// turns the editor's exit into the message that resumes the flow.
+func (m *model) editFile(path string, done func(err error) tea.Msg) tea.Cmd {
+	return tea.ExecProcess(m.editorCommand(path), func(err error) tea.Msg {
+		if err != nil {
+			err = fmt.Errorf("editor: %w", err)
+		}
+		return done(err)
+	})
+}
+
+// editText is editFile for text that only lives in memory: it goes through a
+// temp file named after pattern (e.g. "replay-*.groovy", so the editor picks
+// the right mode) that is removed once read back.
+func (m *model) editText(text, pattern string, done func(edited string, err error) tea.Msg) tea.Cmd {
+	f, err := os.CreateTemp("", "jenkins-tui-"+pattern)
+	if err != nil {
+		return func() tea.Msg { return done("", err) }
+	}
+	path := f.Name()
+	_, err = f.WriteString(text)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path)
+		return func() tea.Msg { return done("", err) }
+	}
+	return m.editFile(path, func(err error) tea.Msg {
+		defer os.Remove(path)
+		if err != nil {
+			return done("", err)
+		}
+		edited, err := os.ReadFile(path)
+		if err != nil {
+			return done("", err)
+		}
+		return done(string(edited), nil)
+	})
+}
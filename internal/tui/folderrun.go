@@ -0,0 +1,179 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"jenkins-tui/internal/jenkins"
+	"jenkins-tui/internal/models"
+)
+
+// folderRunPreview caps how many matching jobs the confirmation lists.
+const folderRunPreview = 8
+
+type folderPlanMsg struct {
+	folder models.JobRef
+	jobs   []models.JobRef
+	defs   [][]models.ParamDef
+	errs   []error
+	shared map[string]string
+	// disabled names the matching jobs Jenkins will not build; they are
+	// listed as skipped without loading their parameters.
+	disabled []string
+}
+
+// parseFolderFilter splits the bulk trigger prompt into a name glob and the
+// KEY=VALUE parameters shared by every matching job. An empty glob matches
+// every job.
+func parseFolderFilter(input string) (string, map[string]string, error) {
+	glob := ""
+	shared := map[string]string{}
+	for _, field := range strings.Fields(input) {
+		if k, v, ok := strings.Cut(field, "="); ok && k != "" {
+			shared[k] = v
+			continue
+		}
+		if glob != "" {
+			return "", nil, fmt.Errorf("one name filter only, got %q and %q", glob, field)
+		}
+		glob = field
+	}
+	if glob == "" {
+		glob = "*"
+	}
+	if _, err := path.Match(glob, ""); err != nil {
+		return "", nil, fmt.Errorf("name filter %q: %w", glob, err)
+	}
+	return glob, shared, nil
+}
+
+// askFolderRun prompts for a name filter over the jobs of the open folder.
+func (m *model) askFolderRun() tea.Cmd {
+	if m.client == nil || m.showingViews {
+		m.status = "Open a folder to trigger its jobs"
+		return nil
+	}
+	url, full := m.currentJobsContainer()
+	folder := models.JobRef{Name: path.Base(full), FullName: full, URL: url}
+	return m.askText("Trigger jobs in "+jobsPathLabel(full),
+		"A name filter like nightly-*, then optional KEY=VALUE parameters for every matching job.", "",
+		func(text string) tea.Cmd {
+			return m.planFolderRun(folder, text)
+		})
+}
+
+func (m *model) planFolderRun(folder models.JobRef, input string) tea.Cmd {
+	glob, shared, err := parseFolderFilter(input)
+	if err != nil {
+		m.err = err
+		m.status = "Invalid filter"
+		return nil
+	}
+	var jobs []models.JobRef
+	var disabled []string
+	for _, it := range m.jobs.Items() {
+		item, ok := it.(listItem)
+		if !ok || item.kind != models.JobNodeJob {
+			continue
+		}
+		if ok, _ := path.Match(glob, item.name); !ok {
+			continue
+		}
+		if item.disabled {
+			disabled = append(disabled, item.name)
+			continue
+		}
+		jobs = append(jobs, models.JobRef{Name: item.name, FullName: item.fullName, URL: item.id})
+	}
+	if len(jobs) == 0 {
+		m.status = "No jobs in " + jobsPathLabel(folder.FullName) + " match " + glob
+		if len(disabled) > 0 {
+			m.status = "All jobs in " + jobsPathLabel(folder.FullName) + " matching " + glob + " are disabled"
+		}
+		return nil
+	}
+	folder.Name = glob
+	folder.FullName = path.Join(folder.FullName, glob)
+	m.err = nil
+	m.loading = true
+	m.loadingStart = time.Now()
+	m.loadingLabel = fmt.Sprintf("Loading parameters of %d job(s)", len(jobs))
+	m.status = m.loadingLabel + "..."
+	return loadFolderPlanCmd(m.ctx, m.client, folderPlanMsg{folder: folder, jobs: jobs, shared: shared, disabled: disabled})
+}
+
+// loadFolderPlanCmd fills in the parameter definitions of plan's jobs,
+// concurrencyCap at a time.
+func loadFolderPlanCmd(ctx context.Context, client *jenkins.Client, plan folderPlanMsg) tea.Cmd {
+	return func() tea.Msg {
+		urls := make([]string, len(plan.jobs))
+		for i, job := range plan.jobs {
+			urls[i] = job.URL
+		}
+		plan.defs, plan.errs = client.GetJobParamsAll(ctx, urls, concurrencyCap)
+		return plan
+	}
+}
+
+// folderSpecs is one run per job. Each job gets the shared parameters it
+// defines and its own defaults for the rest; a job whose parameters could
+// not be read is left out, as are disabled jobs.
+func folderSpecs(msg folderPlanMsg) ([]models.JobSpec, []string) {
+	var specs []models.JobSpec
+	var skipped []string
+	for _, name := range msg.disabled {
+		skipped = append(skipped, name+" (disabled)")
+	}
+	for i, job := range msg.jobs {
+		if msg.errs[i] != nil {
+			skipped = append(skipped, job.Name+" ("+msg.errs[i].Error()+")")
+			continue
+		}
+		params := map[string]string{}
+		for _, def := range msg.defs[i] {
+			if v, ok := msg.shared[def.Name]; ok {
+				params[def.Name] = v
+			}
+		}
+		job := job
+		specs = append(specs, models.JobSpec{Params: params, Job: &job, Secret: models.PasswordNames(msg.defs[i])})
+	}
+	return specs, skipped
+}
+
+func (m *model) folderPlanned(msg folderPlanMsg, cmds []tea.Cmd) (tea.Model, tea.Cmd) {
+	m.loading = false
+	specs, skipped := folderSpecs(msg)
+	if len(specs) == 0 {
+		m.status = "None of the matching jobs can be triggered: " + strings.Join(skipped, "; ")
+		return m, tea.Batch(cmds...)
+	}
+	names := make([]string, 0, folderRunPreview+1)
+	for i, spec := range specs {
+		if i == folderRunPreview {
+			names = append(names, fmt.Sprintf("and %d more", len(specs)-i))
+			break
+		}
+		names = append(names, spec.Job.Name)
+	}
+	desc := strings.Join(names, ", ") + "."
+	if len(msg.shared) > 0 {
+		desc += " Parameters " + summarizeParams(msg.shared) + " go to the jobs that define them; the rest use their defaults."
+	}
+	if len(skipped) > 0 {
+		desc += " Skipped: " + strings.Join(skipped, "; ") + "."
+	}
+	folder := msg.folder
+	return m, tea.Batch(append(cmds, m.askConfirm(
+		fmt.Sprintf("Trigger %d job(s) matching %s?", len(specs), selectedJobLabel(&folder)), desc,
+		func() tea.Cmd {
+			m.selectedJob = &folder
+			m.permutations = specs
+			return m.launchRun()
+		}, nil))...)
+}
@@ -0,0 +1,52 @@
+package tui
+
+import (
+	"fmt"
+	"time"
+
+	"jenkins-tui/internal/cache"
+	"jenkins-tui/internal/jenkins"
+	"jenkins-tui/internal/models"
+	"jenkins-tui/internal/ui"
+)
+
+// folderCacheInfo is what the folder cache knows about a subfolder shown in
+// the jobs list: how many items it had and when they were fetched.
+type folderCacheInfo struct {
+	items     int
+	fetchedAt time.Time
+}
+
+// folderCacheInfos looks up the cached listing of every folder in nodes,
+// stale ones included, since an old count is still better than none.
+func folderCacheInfos(cacheDir string, client *jenkins.Client, nodes []models.JobNode) map[string]folderCacheInfo {
+	out := map[string]folderCacheInfo{}
+	for _, n := range nodes {
+		if n.Kind != models.JobNodeFolder {
+			continue
+		}
+		children, fetchedAt, ok, err := cache.LastJobNodesInDir(cacheDir, client.CacheKey(), n.URL)
+		if err != nil || !ok {
+			continue
+		}
+		out[n.URL] = folderCacheInfo{items: len(children), fetchedAt: fetchedAt}
+	}
+	return out
+}
+
+func (i folderCacheInfo) describe() string {
+	return fmt.Sprintf("%d item(s), cached %s", i.items, ui.Age(i.fetchedAt))
+}
+
+// jobsFreshness follows the path line: whether the listing came from the
+// server or the folder cache, and how old it is.
+func (m *model) jobsFreshness() string {
+	if m.jobsFetchedAt.IsZero() || m.showingViews {
+		return ""
+	}
+	age := ui.Age(m.jobsFetchedAt)
+	if m.jobsFromCache {
+		return "  " + ui.Warn.Render(ui.Glyph("◌", "o")+" cached "+age)
+	}
+	return "  " + ui.Success.Render(ui.Glyph("●", "*")+" live, fetched "+age)
+}
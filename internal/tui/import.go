@@ -0,0 +1,100 @@
+package tui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+
+	"jenkins-tui/internal/config"
+	"jenkins-tui/internal/models"
+)
+
+// importServers asks where to import from, then which of the servers found
+// there to add. Tokens go to the system password manager.
+func (m *model) importServers() tea.Cmd {
+	if available, err := m.creds.KeyringAvailable(); err != nil || !available {
+		m.status = "Importing needs the system password manager to store the tokens"
+		return nil
+	}
+	options := []huh.Option[string]{
+		huh.NewOption("Jenkins CLI (~/.jenkins-cli.yaml)", config.ImportJenkinsCLI),
+		huh.NewOption("netrc (~/.netrc)", config.ImportNetrc),
+	}
+	return m.askChoice("Import servers", "Reads servers and API tokens from another tool's config.", options, func(source string) tea.Cmd {
+		candidates, err := config.ReadImport(source, "")
+		if err != nil {
+			m.status = ""
+			m.err = err
+			return nil
+		}
+		planned, plainHTTP := config.PlanImport(m.cfg, candidates, false)
+		note := ""
+		if len(plainHTTP) > 0 {
+			note = fmt.Sprintf("; %d plain HTTP server(s) left out, add them with the server form's Allow plain HTTP", len(plainHTTP))
+		}
+		if len(planned) == 0 {
+			m.status = "Nothing to import; every server found is already configured"
+			if len(plainHTTP) > 0 {
+				m.status = "Nothing imported" + note
+			}
+			return nil
+		}
+		picks := make([]huh.Option[string], len(planned))
+		for i, c := range planned {
+			picks[i] = huh.NewOption(fmt.Sprintf("%s (%s as %s)", c.Target.Name, c.Target.Host, c.Target.Username), c.Target.ID)
+		}
+		return m.askPicks("Servers to import", "Checked servers are added with a keyring token.", picks, func(ids []string) tea.Cmd {
+			m.addImported(planned, ids)
+			if m.err == nil {
+				m.status += note
+			}
+			return nil
+		})
+	})
+}
+
+// addImported stores the tokens of the picked candidates and saves them to
+// the config.
+func (m *model) addImported(planned []config.ImportCandidate, ids []string) {
+	if len(ids) == 0 {
+		m.status = "Nothing imported"
+		return
+	}
+	picked := map[string]bool{}
+	for _, id := range ids {
+		picked[id] = true
+	}
+	previousTargets := append([]models.JenkinsTarget(nil), m.cfg.Jenkins...)
+	added := 0
+	for _, c := range planned {
+		if !picked[c.Target.ID] {
+			continue
+		}
+		if err := m.creds.SetKeyring(c.Target.Credential.Ref, c.Token); err != nil {
+			m.dropImported(previousTargets)
+			m.err = fmt.Errorf("store API token for %s in system password manager: %w", c.Target.Name, err)
+			return
+		}
+		m.cfg.Jenkins = append(m.cfg.Jenkins, c.Target)
+		added++
+	}
+	if err := m.persistConfig(); err != nil {
+		m.dropImported(previousTargets)
+		m.err = err
+		return
+	}
+	m.refreshServerItems()
+	m.refreshManageItems()
+	m.status = fmt.Sprintf("Imported %d server(s)", added)
+}
+
+// dropImported undoes a failed import: it deletes the tokens already stored
+// for the servers added after previous, so no keyring entry outlives the
+// config that would reference it, and restores the server list.
+func (m *model) dropImported(previous []models.JenkinsTarget) {
+	for _, t := range m.cfg.Jenkins[len(previous):] {
+		_ = m.creds.DeleteKeyring(t.Credential.Ref)
+	}
+	m.cfg.Jenkins = previous
+}
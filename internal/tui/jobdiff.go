@@ -0,0 +1,79 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"jenkins-tui/internal/jenkins"
+	"jenkins-tui/internal/models"
+)
+
+// maxRemovedNames is how many removed jobs are named in the status line.
+const maxRemovedNames = 3
+
+// jobDiff is how a freshly fetched folder listing differs from the cached
+// one, so new pipelines stand out after a refresh.
+type jobDiff struct {
+	added   map[string]bool
+	removed []models.JobNode
+}
+
+func diffJobNodes(before, after []models.JobNode) jobDiff {
+	old := make(map[string]bool, len(before))
+	for _, n := range before {
+		old[jobDiffKey(n)] = true
+	}
+	var d jobDiff
+	current := make(map[string]bool, len(after))
+	for _, n := range after {
+		key := jobDiffKey(n)
+		current[key] = true
+		if !old[key] {
+			if d.added == nil {
+				d.added = map[string]bool{}
+			}
+			d.added[key] = true
+		}
+	}
+	for _, n := range before {
+		if !current[jobDiffKey(n)] {
+			d.removed = append(d.removed, n)
+		}
+	}
+	return d
+}
+
+func jobDiffKey(n models.JobNode) string {
+	if strings.TrimSpace(n.URL) != "" {
+		return jenkins.CanonicalJobURL(n.URL)
+	}
+	return "name:" + n.FullName
+}
+
+func (d jobDiff) isNew(n models.JobNode) bool {
+	// Most refreshes add nothing; skip canonicalizing every job's URL.
+	if len(d.added) == 0 {
+		return false
+	}
+	return d.added[jobDiffKey(n)]
+}
+
+// summary reads e.g. "+3 new, -1 removed: old-deploy", or "" if nothing
+// changed.
+func (d jobDiff) summary() string {
+	var parts []string
+	if len(d.added) > 0 {
+		parts = append(parts, fmt.Sprintf("+%d new", len(d.added)))
+	}
+	if len(d.removed) > 0 {
+		names := make([]string, 0, maxRemovedNames)
+		for _, n := range d.removed[:min(len(d.removed), maxRemovedNames)] {
+			names = append(names, n.Name)
+		}
+		if len(d.removed) > maxRemovedNames {
+			names = append(names, "...")
+		}
+		parts = append(parts, fmt.Sprintf("-%d removed: %s", len(d.removed), strings.Join(names, ", ")))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,491 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+
+	"jenkins-tui/internal/models"
+	"jenkins-tui/internal/ui"
+)
+
+// keyMap holds every remappable binding. Text-entry keys (esc, backspace,
+// tab in prompts) and ctrl+c stay fixed so a bad config can't trap the user.
+type keyMap struct {
+	Quit  key.Binding
+	Help  key.Binding
+	Trace key.Binding
+	Open  key.Binding
+
+	// Vim-style motions shared by every list, table and viewer. NavTop
+	// fires on its key pressed twice (gg).
+	NavDown      key.Binding
+	NavUp        key.Binding
+	NavTop       key.Binding
+	NavBottom    key.Binding
+	HalfPageDown key.Binding
+	HalfPageUp   key.Binding
+
+	AddServer     key.Binding
+	EditServer    key.Binding
+	RotateToken   key.Binding
+	MoveToken     key.Binding
+	DeleteServer  key.Binding
+	TestConn      key.Binding
+	Admin         key.Binding
+	EditConfig    key.Binding
+	UndoConfig    key.Binding
+	TagFilter     key.Binding
+	ImportServers key.Binding
+
+	Refresh         key.Binding
+	Offline         key.Binding
+	GotoJob         key.Binding
+	ToggleViews     key.Binding
+	EnableJob       key.Binding
+	History         key.Binding
+	ScanMultibranch key.Binding
+	ViewConfig      key.Binding
+	Locks           key.Binding
+	GlobalSearch    key.Binding
+	JumpUp          key.Binding
+	Bookmark        key.Binding
+	Bookmarks       key.Binding
+	Watch           key.Binding
+	TriggerFolder   key.Binding
+	Watches         key.Binding
+	ToggleLayout    key.Binding
+	SyncTree        key.Binding
+	RepoJobs        key.Binding
+
+	EditMatrix key.Binding
+
+	OpenURL  key.Binding
+	MarkRun  key.Binding
+	DiffRuns key.Binding
+	Rerun    key.Binding
+	Rebuild  key.Binding
+	Replay   key.Binding
+	ShowRuns key.Binding
+
+	StopBatch   key.Binding
+	RemoveBatch key.Binding
+
+	RemoveBookmark key.Binding
+
+	CheckWatches key.Binding
+	Unwatch      key.Binding
+	Radiator     key.Binding
+
+	ViewLog        key.Binding
+	SaveLog        key.Binding
+	SaveFailedLogs key.Binding
+	Stages         key.Binding
+	MarkBuild      key.Binding
+	CompareBuilds  key.Binding
+	StripColors    key.Binding
+	NextMatch      key.Binding
+	PrevMatch      key.Binding
+	NextError      key.Binding
+	PrevError      key.Binding
+}
+
+type keyAction struct {
+	name    string
+	binding func(*keyMap) *key.Binding
+	// groups are the screens the action is live on; a key may only be bound
+	// once per group.
+	groups []string
+	desc   string
+}
+
+// navGroups are the keymap groups whose screens the vim-style motions move.
+var navGroups = []string{"servers", "jobs", "run", "history", "preview", "console", "batches", "watch", "stages"}
+
+var keyActions = []keyAction{
+	{"quit", func(k *keyMap) *key.Binding { return &k.Quit }, []string{"servers", "jobs", "run", "history"}, "quit"},
+	{"help", func(k *keyMap) *key.Binding { return &k.Help }, []string{"servers", "jobs", "run", "history"}, "toggle this help"},
+	{"trace", func(k *keyMap) *key.Binding { return &k.Trace }, []string{"servers", "jobs", "run", "history"}, "recent API calls (debug)"},
+	{"open", func(k *keyMap) *key.Binding { return &k.Open }, []string{"servers", "jobs"}, "select / open"},
+	{"cursor_down", func(k *keyMap) *key.Binding { return &k.NavDown }, navGroups, "move down"},
+	{"cursor_up", func(k *keyMap) *key.Binding { return &k.NavUp }, navGroups, "move up"},
+	{"go_to_top", func(k *keyMap) *key.Binding { return &k.NavTop }, navGroups, "go to the top (press twice)"},
+	{"go_to_bottom", func(k *keyMap) *key.Binding { return &k.NavBottom }, navGroups, "go to the bottom"},
+	{"half_page_down", func(k *keyMap) *key.Binding { return &k.HalfPageDown }, navGroups, "half a page down"},
+	{"half_page_up", func(k *keyMap) *key.Binding { return &k.HalfPageUp }, navGroups, "half a page up"},
+	{"add_server", func(k *keyMap) *key.Binding { return &k.AddServer }, []string{"servers"}, "add server"},
+	{"edit_server", func(k *keyMap) *key.Binding { return &k.EditServer }, []string{"servers"}, "edit server"},
+	{"rotate_token", func(k *keyMap) *key.Binding { return &k.RotateToken }, []string{"servers"}, "rotate API token"},
+	{"move_token", func(k *keyMap) *key.Binding { return &k.MoveToken }, []string{"servers"}, "move token between password manager and env variable"},
+	{"delete_server", func(k *keyMap) *key.Binding { return &k.DeleteServer }, []string{"servers"}, "delete server"},
+	{"test_connection", func(k *keyMap) *key.Binding { return &k.TestConn }, []string{"servers"}, "test connection (DNS, TLS, auth)"},
+	{"admin", func(k *keyMap) *key.Binding { return &k.Admin }, []string{"servers"}, "quiet down / safe restart (admins)"},
+	{"edit_config", func(k *keyMap) *key.Binding { return &k.EditConfig }, []string{"servers"}, "edit jenkins.yaml in $EDITOR and reload it"},
+	{"undo_config", func(k *keyMap) *key.Binding { return &k.UndoConfig }, []string{"servers"}, "undo the last change to jenkins.yaml"},
+	{"tag_filter", func(k *keyMap) *key.Binding { return &k.TagFilter }, []string{"servers"}, "show servers with the next tag (cycles back to all)"},
+	{"import_servers", func(k *keyMap) *key.Binding { return &k.ImportServers }, []string{"servers"}, "import servers from ~/.netrc or the Jenkins CLI config"},
+	{"refresh", func(k *keyMap) *key.Binding { return &k.Refresh }, []string{"servers", "jobs"}, "refresh folder (bypass cache)"},
+	{"offline", func(k *keyMap) *key.Binding { return &k.Offline }, []string{"servers", "jobs"}, "toggle offline mode: browse cached job trees only"},
+	{"goto_job", func(k *keyMap) *key.Binding { return &k.GotoJob }, []string{"jobs"}, "go to job by full name"},
+	{"toggle_views", func(k *keyMap) *key.Binding { return &k.ToggleViews }, []string{"jobs"}, "toggle views / folders"},
+	{"enable_job", func(k *keyMap) *key.Binding { return &k.EnableJob }, []string{"jobs"}, "enable disabled job"},
+	{"history", func(k *keyMap) *key.Binding { return &k.History }, []string{"jobs"}, "build history"},
+	{"scan_multibranch", func(k *keyMap) *key.Binding { return &k.ScanMultibranch }, []string{"jobs"}, "scan multibranch pipeline"},
+	{"view_config", func(k *keyMap) *key.Binding { return &k.ViewConfig }, []string{"jobs"}, "view config.xml"},
+	{"lockable_resources", func(k *keyMap) *key.Binding { return &k.Locks }, []string{"jobs"}, "lockable resources and who holds them"},
+	{"global_search", func(k *keyMap) *key.Binding { return &k.GlobalSearch }, []string{"jobs"}, "global job search"},
+	{"jump_up", func(k *keyMap) *key.Binding { return &k.JumpUp }, []string{"jobs"}, "jump to an ancestor folder"},
+	{"bookmark", func(k *keyMap) *key.Binding { return &k.Bookmark }, []string{"jobs"}, "bookmark / unbookmark this folder"},
+	{"bookmarks", func(k *keyMap) *key.Binding { return &k.Bookmarks }, []string{"jobs", "bookmarks"}, "open a folder bookmark"},
+	{"trigger_folder", func(k *keyMap) *key.Binding { return &k.TriggerFolder }, []string{"jobs"}, "trigger every job in this folder matching a name filter"},
+	{"watch", func(k *keyMap) *key.Binding { return &k.Watch }, []string{"jobs"}, "watch / unwatch this job's last build"},
+	{"watches", func(k *keyMap) *key.Binding { return &k.Watches }, []string{"jobs", "watch"}, "list watched jobs"},
+	{"toggle_layout", func(k *keyMap) *key.Binding { return &k.ToggleLayout }, []string{"jobs"}, "toggle split-pane layout"},
+	{"sync_tree", func(k *keyMap) *key.Binding { return &k.SyncTree }, []string{"jobs"}, "sync the whole job tree into the cache in the background"},
+	{"find_repo_jobs", func(k *keyMap) *key.Binding { return &k.RepoJobs }, []string{"jobs"}, "find the multibranch project building this git checkout"},
+	{"edit_matrix", func(k *keyMap) *key.Binding { return &k.EditMatrix }, []string{"preview"}, "edit the runs in $EDITOR"},
+	{"open_url", func(k *keyMap) *key.Binding { return &k.OpenURL }, []string{"run", "history"}, "open build in browser"},
+	{"mark_run", func(k *keyMap) *key.Binding { return &k.MarkRun }, []string{"run"}, "mark run for log diff"},
+	{"view_log", func(k *keyMap) *key.Binding { return &k.ViewLog }, []string{"run", "history"}, "view console log"},
+	{"stages", func(k *keyMap) *key.Binding { return &k.Stages }, []string{"run", "history"}, "Pipeline stages, to read one stage's log"},
+	{"save_log", func(k *keyMap) *key.Binding { return &k.SaveLog }, []string{"run", "history", "console"}, "save console log to log_dir"},
+	{"save_failed_logs", func(k *keyMap) *key.Binding { return &k.SaveFailedLogs }, []string{"run"}, "save console logs of every failed run"},
+	{"diff_runs", func(k *keyMap) *key.Binding { return &k.DiffRuns }, []string{"run"}, "diff marked console logs"},
+	{"rerun", func(k *keyMap) *key.Binding { return &k.Rerun }, []string{"run"}, "rerun failed (or re-scan)"},
+	{"mark_build", func(k *keyMap) *key.Binding { return &k.MarkBuild }, []string{"history"}, "mark build for comparison"},
+	{"compare_builds", func(k *keyMap) *key.Binding { return &k.CompareBuilds }, []string{"history"}, "compare the parameters and results of the marked builds"},
+	{"rebuild", func(k *keyMap) *key.Binding { return &k.Rebuild }, []string{"history"}, "rebuild with same parameters"},
+	{"replay", func(k *keyMap) *key.Binding { return &k.Replay }, []string{"jobs", "history"}, "replay a Pipeline build, optionally editing its script"},
+	{"show_runs", func(k *keyMap) *key.Binding { return &k.ShowRuns }, []string{"servers", "jobs", "run", "history", "batches"}, "list run batches"},
+	{"stop_batch", func(k *keyMap) *key.Binding { return &k.StopBatch }, []string{"batches"}, "stop tracking a running batch"},
+	{"remove_batch", func(k *keyMap) *key.Binding { return &k.RemoveBatch }, []string{"batches"}, "remove a finished batch"},
+	{"remove_bookmark", func(k *keyMap) *key.Binding { return &k.RemoveBookmark }, []string{"bookmarks"}, "remove bookmark"},
+	{"check_watches", func(k *keyMap) *key.Binding { return &k.CheckWatches }, []string{"watch", "radiator"}, "check watched jobs now"},
+	{"unwatch", func(k *keyMap) *key.Binding { return &k.Unwatch }, []string{"watch"}, "stop watching"},
+	{"radiator", func(k *keyMap) *key.Binding { return &k.Radiator }, []string{"watch", "radiator"}, "full-screen radiator"},
+	{"strip_colors", func(k *keyMap) *key.Binding { return &k.StripColors }, []string{"console"}, "strip / show ANSI colors"},
+	{"next_match", func(k *keyMap) *key.Binding { return &k.NextMatch }, []string{"console"}, "next search match"},
+	{"prev_match", func(k *keyMap) *key.Binding { return &k.PrevMatch }, []string{"console"}, "previous search match"},
+	{"next_error", func(k *keyMap) *key.Binding { return &k.NextError }, []string{"console"}, "next ERROR / FAILURE / exception line"},
+	{"prev_error", func(k *keyMap) *key.Binding { return &k.PrevError }, []string{"console"}, "previous ERROR / FAILURE / exception line"},
+}
+
+func defaultKeyMap() keyMap {
+	return keyMap{
+		Quit:  key.NewBinding(key.WithKeys("q")),
+		Help:  key.NewBinding(key.WithKeys("?")),
+		Trace: key.NewBinding(key.WithKeys("ctrl+t")),
+		Open:  key.NewBinding(key.WithKeys("enter")),
+
+		NavDown:      key.NewBinding(key.WithKeys("j")),
+		NavUp:        key.NewBinding(key.WithKeys("k")),
+		NavTop:       key.NewBinding(key.WithKeys("g")),
+		NavBottom:    key.NewBinding(key.WithKeys("G")),
+		HalfPageDown: key.NewBinding(key.WithKeys("ctrl+d")),
+		HalfPageUp:   key.NewBinding(key.WithKeys("ctrl+u")),
+
+		AddServer:     key.NewBinding(key.WithKeys("a", "m")),
+		EditServer:    key.NewBinding(key.WithKeys("e")),
+		RotateToken:   key.NewBinding(key.WithKeys("t")),
+		MoveToken:     key.NewBinding(key.WithKeys("M")),
+		DeleteServer:  key.NewBinding(key.WithKeys("d")),
+		TestConn:      key.NewBinding(key.WithKeys("c")),
+		Admin:         key.NewBinding(key.WithKeys("A")),
+		EditConfig:    key.NewBinding(key.WithKeys("E")),
+		UndoConfig:    key.NewBinding(key.WithKeys("u")),
+		TagFilter:     key.NewBinding(key.WithKeys("T")),
+		ImportServers: key.NewBinding(key.WithKeys("I")),
+
+		Refresh:         key.NewBinding(key.WithKeys("r")),
+		Offline:         key.NewBinding(key.WithKeys("O")),
+		GotoJob:         key.NewBinding(key.WithKeys(":", "ctrl+p")),
+		ToggleViews:     key.NewBinding(key.WithKeys("v")),
+		EnableJob:       key.NewBinding(key.WithKeys("E")),
+		History:         key.NewBinding(key.WithKeys("h")),
+		ScanMultibranch: key.NewBinding(key.WithKeys("S")),
+		ViewConfig:      key.NewBinding(key.WithKeys("c")),
+		Locks:           key.NewBinding(key.WithKeys("R")),
+		GlobalSearch:    key.NewBinding(key.WithKeys("s")),
+		JumpUp:          key.NewBinding(key.WithKeys("u")),
+		Bookmark:        key.NewBinding(key.WithKeys("b")),
+		Bookmarks:       key.NewBinding(key.WithKeys("B")),
+		Watch:           key.NewBinding(key.WithKeys("w")),
+		TriggerFolder:   key.NewBinding(key.WithKeys("T")),
+		Watches:         key.NewBinding(key.WithKeys("W")),
+		ToggleLayout:    key.NewBinding(key.WithKeys("L")),
+		SyncTree:        key.NewBinding(key.WithKeys("Y")),
+		RepoJobs:        key.NewBinding(key.WithKeys("f")),
+
+		EditMatrix: key.NewBinding(key.WithKeys("e")),
+
+		OpenURL:  key.NewBinding(key.WithKeys("o")),
+		MarkRun:  key.NewBinding(key.WithKeys("m")),
+		DiffRuns: key.NewBinding(key.WithKeys("D")),
+		Rerun:    key.NewBinding(key.WithKeys("r")),
+		Rebuild:  key.NewBinding(key.WithKeys("enter", "R")),
+		Replay:   key.NewBinding(key.WithKeys("p")),
+		ShowRuns: key.NewBinding(key.WithKeys("ctrl+r")),
+
+		StopBatch:   key.NewBinding(key.WithKeys("x")),
+		RemoveBatch: key.NewBinding(key.WithKeys("d")),
+
+		RemoveBookmark: key.NewBinding(key.WithKeys("d")),
+
+		CheckWatches: key.NewBinding(key.WithKeys("r")),
+		Unwatch:      key.NewBinding(key.WithKeys("d")),
+		Radiator:     key.NewBinding(key.WithKeys("v")),
+
+		ViewLog:        key.NewBinding(key.WithKeys("l")),
+		SaveLog:        key.NewBinding(key.WithKeys("s")),
+		SaveFailedLogs: key.NewBinding(key.WithKeys("S")),
+		Stages:         key.NewBinding(key.WithKeys("t")),
+		MarkBuild:      key.NewBinding(key.WithKeys("m")),
+		CompareBuilds:  key.NewBinding(key.WithKeys("D")),
+		StripColors:    key.NewBinding(key.WithKeys("c")),
+		NextMatch:      key.NewBinding(key.WithKeys("n")),
+		PrevMatch:      key.NewBinding(key.WithKeys("N")),
+		NextError:      key.NewBinding(key.WithKeys("e")),
+		PrevError:      key.NewBinding(key.WithKeys("E")),
+	}
+}
+
+// movedKeys are defaults that moved to make room for the motions: a config
+// that still binds action to key keeps it, and taker gives the key up.
+var movedKeys = []struct{ action, key, taker string }{
+	{"global_search", "g", "go_to_top"},
+	{"trace", "ctrl+d", "half_page_down"},
+}
+
+// newKeyMap applies the config's keybindings section over the defaults. The
+// notes explain keys a config took back from a motion; see movedKeys.
+func newKeyMap(overrides map[string]models.KeyList) (keyMap, []string, error) {
+	km := defaultKeyMap()
+	byName := map[string]keyAction{}
+	for _, a := range keyActions {
+		byName[a.name] = a
+	}
+	names := make([]string, 0, len(overrides))
+	for name := range overrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		action, ok := byName[name]
+		if !ok {
+			return defaultKeyMap(), nil, fmt.Errorf("keybindings: unknown action %q", name)
+		}
+		keys := []string{}
+		for _, k := range overrides[name] {
+			if k = strings.TrimSpace(k); k != "" {
+				keys = append(keys, k)
+			}
+		}
+		if len(keys) == 0 {
+			return defaultKeyMap(), nil, fmt.Errorf("keybindings: %s has no keys", name)
+		}
+		*action.binding(&km) = key.NewBinding(key.WithKeys(keys...))
+	}
+
+	var notes []string
+	for _, moved := range movedKeys {
+		_, takerSet := overrides[moved.taker]
+		if takerSet || !slices.Contains(overrides[moved.action], moved.key) {
+			continue
+		}
+		taker := byName[moved.taker].binding(&km)
+		keys := slices.DeleteFunc(taker.Keys(), func(k string) bool { return k == moved.key })
+		*taker = key.NewBinding(key.WithKeys(keys...))
+		def := defaultKeyMap()
+		notes = append(notes, fmt.Sprintf("keybindings: %s keeps %q from an older default, so %s gives it up; remove the %s entry to use the new default %q, or bind %s to another key",
+			moved.action, moved.key, moved.taker, moved.action, firstKey(*byName[moved.action].binding(&def)), moved.taker))
+	}
+
+	owners := map[string]string{}
+	for _, a := range keyActions {
+		for _, group := range a.groups {
+			for _, k := range a.binding(&km).Keys() {
+				slot := group + "\x00" + k
+				if other, taken := owners[slot]; taken {
+					return defaultKeyMap(), nil, fmt.Errorf("keybindings: %q is bound to both %s and %s", k, other, a.name)
+				}
+				owners[slot] = a.name
+			}
+		}
+	}
+	return km, notes, nil
+}
+
+// ValidateKeybindings reports config keybindings that would be rejected.
+func ValidateKeybindings(overrides map[string]models.KeyList) error {
+	_, _, err := newKeyMap(overrides)
+	return err
+}
+
+// KeybindingNotes explains keys a valid config takes back from the
+// motions, for `config validate`.
+func KeybindingNotes(overrides map[string]models.KeyList) []string {
+	_, notes, _ := newKeyMap(overrides)
+	return notes
+}
+
+// keyLabel renders a binding for help text, e.g. ":/ctrl+p".
+func keyLabel(b key.Binding) string {
+	return strings.Join(b.Keys(), "/")
+}
+
+// firstKey is the primary key of a binding, for compact help.
+func firstKey(b key.Binding) string {
+	if keys := b.Keys(); len(keys) > 0 {
+		return keys[0]
+	}
+	return ""
+}
+
+// helpRow is one line of the help overlay: either a remappable action,
+// resolved against the live keymap, or a fixed key.
+type helpRow struct {
+	action string
+	keys   string
+	desc   string
+}
+
+type helpSection struct {
+	title   string
+	screens []screen
+	rows    []helpRow
+}
+
+var helpSections = []helpSection{
+	{"Everywhere", nil, []helpRow{
+		{action: "help"}, {action: "quit"}, {keys: "ctrl+c", desc: "quit immediately, cancelling tracked runs"}, {action: "trace"},
+		{action: "show_runs"},
+	}},
+	{"Lists, tables and viewers", nil, []helpRow{
+		{action: "cursor_down"}, {action: "cursor_up"}, {action: "go_to_top"}, {action: "go_to_bottom"}, {action: "half_page_down"}, {action: "half_page_up"},
+	}},
+	{"Servers", []screen{screenServers, screenManageTargets}, []helpRow{
+		{action: "open"}, {action: "add_server"}, {action: "edit_server"}, {action: "rotate_token"}, {action: "move_token"}, {action: "delete_server"},
+		{action: "test_connection"}, {action: "admin"}, {action: "edit_config"}, {action: "undo_config"}, {action: "tag_filter"}, {action: "import_servers"}, {action: "refresh", desc: "re-check server health"}, {action: "offline"}, {keys: "/", desc: "filter"},
+	}},
+	{"Jobs", []screen{screenJobs}, []helpRow{
+		{action: "open"}, {keys: "esc/backspace", desc: "up one folder"}, {action: "jump_up"}, {keys: "/", desc: "filter"},
+		{action: "bookmark"}, {action: "bookmarks"}, {action: "watch"}, {action: "watches"}, {action: "toggle_layout"},
+		{action: "refresh"}, {action: "offline"}, {action: "sync_tree"}, {action: "global_search"}, {action: "find_repo_jobs"}, {action: "goto_job"}, {action: "toggle_views"},
+		{action: "history"}, {action: "trigger_folder"}, {action: "view_config"}, {action: "lockable_resources"}, {action: "replay", desc: "replay the last build"}, {action: "enable_job"}, {action: "scan_multibranch"},
+	}},
+	{"Go to prompt", []screen{screenJobs}, []helpRow{
+		{keys: "tab", desc: "complete / cycle matches"}, {keys: "enter", desc: "open parameters"}, {keys: "esc", desc: "cancel"},
+	}},
+	{"Folder picker", []screen{screenJobs}, []helpRow{
+		{keys: "up/down", desc: "choose ancestor"}, {keys: "0-9", desc: "jump to that level"}, {keys: "enter", desc: "jump"}, {keys: "esc", desc: "cancel"},
+	}},
+	{"Bookmarks", []screen{screenJobs}, []helpRow{
+		{keys: "up/down", desc: "choose bookmark"}, {keys: "0-9", desc: "open that bookmark"}, {keys: "enter", desc: "open"},
+		{action: "remove_bookmark"}, {keys: "esc", desc: "close"},
+	}},
+	{"Global search", []screen{screenGlobalSearch}, []helpRow{
+		{keys: "type", desc: "search query"}, {keys: "enter", desc: "open job"}, {keys: "backspace", desc: "edit query"}, {keys: "esc", desc: "back to jobs"},
+	}},
+	{"Parameters", []screen{screenParams}, []helpRow{
+		{keys: "space/x", desc: "toggle choice"}, {keys: "ctrl+a", desc: "select all / none"}, {keys: "/", desc: "filter choices"},
+		{keys: "shift+tab", desc: "previous field"}, {keys: "enter", desc: "continue"},
+	}},
+	{"Preview", []screen{screenPreview}, []helpRow{
+		{keys: "enter", desc: "start runs (asks first if they contend on lockable resources)"}, {action: "edit_matrix"},
+		{keys: "esc/backspace", desc: "back to parameters"},
+	}},
+	{"Runs", []screen{screenRun, screenDone}, []helpRow{
+		{action: "open_url"}, {action: "view_log"}, {action: "stages"}, {action: "save_log"}, {action: "save_failed_logs"}, {action: "mark_run"}, {action: "diff_runs"}, {action: "rerun"},
+		{keys: "esc/backspace", desc: "back to jobs; the batch keeps being tracked"},
+	}},
+	{"Run batches", []screen{screenBatches}, []helpRow{
+		{keys: "enter", desc: "open batch"}, {action: "stop_batch"}, {action: "remove_batch"},
+		{keys: "esc/backspace", desc: "back"},
+	}},
+	{"Watched jobs", []screen{screenWatch}, []helpRow{
+		{keys: "enter", desc: "build history"}, {action: "radiator"}, {action: "check_watches"}, {action: "unwatch"}, {keys: "esc/backspace", desc: "back"},
+	}},
+	{"Radiator", []screen{screenRadiator}, []helpRow{
+		{action: "check_watches"}, {action: "radiator", desc: "back to watched jobs"}, {keys: "esc/backspace", desc: "back to watched jobs"},
+	}},
+	{"Build history", []screen{screenHistory}, []helpRow{
+		{action: "rebuild"}, {action: "replay"}, {action: "view_log"}, {action: "stages"}, {action: "save_log"}, {action: "mark_build"}, {action: "compare_builds"}, {action: "open_url"}, {keys: "esc/backspace", desc: "back to jobs"},
+	}},
+	{"Connection test", []screen{screenConnTest}, []helpRow{
+		{action: "test_connection", desc: "run the test again"},
+	}},
+	{"Pipeline stages", []screen{screenStages}, []helpRow{
+		{keys: "up/down", desc: "choose stage (starts on the one that failed)"}, {keys: "enter", desc: "show just that stage's log"}, {keys: "esc/backspace", desc: "back"},
+	}},
+	{"Console log", []screen{screenConsole}, []helpRow{
+		{keys: "up/down/pgup/pgdown", desc: "scroll"}, {keys: "/", desc: "search, highlighting matches"}, {action: "next_match"}, {action: "prev_match"},
+		{action: "next_error"}, {action: "prev_error"}, {action: "strip_colors"}, {action: "save_log"}, {keys: "esc/backspace", desc: "back"},
+	}},
+	{"Viewers", []screen{screenLogDiff, screenJobConfig, screenConnTest, screenLocks}, []helpRow{
+		{keys: "up/down/pgup/pgdown", desc: "scroll"}, {keys: "esc/backspace", desc: "back"},
+	}},
+	{"Server form", []screen{screenManageForm}, []helpRow{
+		{keys: "enter", desc: "next / submit"}, {keys: "shift+tab", desc: "back"},
+		{keys: "ctrl+o", desc: "open your API token page in the browser; a pasted token is checked right away"},
+		{keys: "ctrl+g", desc: "generate an API token by entering your password once; only the token is stored"}, {keys: "esc", desc: "cancel"},
+	}},
+	{"Confirm dialogs", nil, []helpRow{
+		{keys: "y/n", desc: "answer"}, {keys: "left/right, enter", desc: "choose and submit"}, {keys: "esc", desc: "cancel"},
+	}},
+}
+
+// renderHelp lists every binding grouped by screen, with the sections for
+// current first. Action rows come from km, so remapped keys show up as bound.
+func renderHelp(km keyMap, current screen) string {
+	byName := map[string]keyAction{}
+	for _, a := range keyActions {
+		byName[a.name] = a
+	}
+	ordered := make([]helpSection, 0, len(helpSections))
+	for _, sec := range helpSections {
+		if sec.has(current) {
+			ordered = append(ordered, sec)
+		}
+	}
+	for _, sec := range helpSections {
+		if !sec.has(current) {
+			ordered = append(ordered, sec)
+		}
+	}
+
+	var b strings.Builder
+	for i, sec := range ordered {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		title := sec.title
+		if sec.has(current) {
+			title += " (this screen)"
+		}
+		b.WriteString(ui.Title.Render(title) + "\n")
+		for _, row := range sec.rows {
+			keys, desc := row.keys, row.desc
+			if row.action != "" {
+				action := byName[row.action]
+				keys = keyLabel(*action.binding(&km))
+				if desc == "" {
+					desc = action.desc
+				}
+			}
+			b.WriteString(fmt.Sprintf("  %-22s %s\n", keys, ui.Muted.Render(desc)))
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func (s helpSection) has(current screen) bool {
+	for _, sc := range s.screens {
+		if sc == current {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,144 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+
+	"jenkins-tui/internal/models"
+)
+
+// startMoveToken moves the target's token to the other backend: env targets
+// go straight to the system password manager after a confirmation; keyring
+// targets need the variable name, so they get a form.
+func (m *model) startMoveToken(idx int) tea.Cmd {
+	if idx < 0 || idx >= len(m.cfg.Jenkins) {
+		return nil
+	}
+	m.err = nil
+	t := m.cfg.Jenkins[idx]
+	if t.Auth == models.AuthKerberos {
+		m.status = t.Name + " signs in with Kerberos; there is no stored token to move"
+		return nil
+	}
+	if strings.TrimSpace(t.AuthCommand) != "" {
+		m.status = t.Name + " gets its token from auth_command; there is no stored token to move"
+		return nil
+	}
+	switch t.Credential.Type {
+	case models.CredentialTypeKeyring:
+		m.startManageForm(manageModeMoveToEnv, idx)
+		return m.transition(screenManageForm, m.manageForm.Init())
+	case models.CredentialTypeEnv:
+		if available, err := m.creds.KeyringAvailable(); err != nil || !available {
+			m.status = "No system password manager available; the token stays in $" + t.Credential.Ref
+			return nil
+		}
+		token, _, err := m.resolveTokenForValidation(t, &t)
+		if err != nil {
+			m.err = err
+			m.status = "Nothing to move"
+			return nil
+		}
+		id := t.ID
+		return m.askConfirm(
+			"Move the token for "+t.Name+" to the system password manager?",
+			"Stores the token from $"+t.Credential.Ref+" as "+defaultKeyringRef(t.ID)+" and updates jenkins.yaml. jenkins-tui cannot unset $"+t.Credential.Ref+"; remove its export yourself.",
+			func() tea.Cmd {
+				if err := m.moveTokenToKeyring(id, token); err != nil {
+					m.err = err
+					m.status = "Failed to move token"
+					return nil
+				}
+				m.err = nil
+				m.refreshManageItems()
+				m.refreshServerItems()
+				return nil
+			},
+			nil,
+		)
+	default:
+		m.status = fmt.Sprintf("Cannot move %q credentials", t.Credential.Type)
+		return nil
+	}
+}
+
+// moveTokenToKeyring stores token under the target's default keyring entry
+// and points the config at it. The entry is removed again if the config
+// cannot be saved, so nothing is left half-moved.
+func (m *model) moveTokenToKeyring(id, token string) error {
+	idx := -1
+	for i, t := range m.cfg.Jenkins {
+		if t.ID == id {
+			idx = i
+		}
+	}
+	if idx < 0 {
+		return fmt.Errorf("server %q no longer exists", id)
+	}
+	previous := m.cfg.Jenkins[idx]
+	ref := defaultKeyringRef(previous.ID)
+	if err := m.creds.SetKeyring(ref, token); err != nil {
+		return fmt.Errorf("store API token in system password manager: %w", err)
+	}
+	moved := previous
+	moved.Credential = models.Credential{Type: models.CredentialTypeKeyring, Ref: ref}
+	m.cfg.Jenkins[idx] = moved
+	if err := m.persistConfig(); err != nil {
+		m.cfg.Jenkins[idx] = previous
+		_ = m.creds.DeleteKeyring(ref)
+		return err
+	}
+	m.status = "Moved token to the system password manager; remove the export of $" + previous.Credential.Ref
+	return nil
+}
+
+func (m *model) moveToEnvGroup(t models.JenkinsTarget) *huh.Group {
+	if m.manageEnvVar == "" {
+		m.manageEnvVar = "JENKINS_TOKEN_" + strings.ToUpper(strings.ReplaceAll(slugifyID(t.ID), "-", "_"))
+	}
+	return huh.NewGroup(
+		huh.NewNote().Title("Move token to an environment variable").Description(
+			"Export this server's token (stored as "+t.Credential.Ref+" in your password manager) under the variable below "+
+				"and restart jenkins-tui from that shell. Once the variable holds a token Jenkins accepts, jenkins.yaml is "+
+				"updated and the password manager entry is deleted."),
+		huh.NewInput().Title("Token Environment Variable").Description("Variable name, e.g. JENKINS_TOKEN_PROD").Value(&m.manageEnvVar),
+	).Title("Move API Token")
+}
+
+// applyMoveToEnv switches a keyring target to the variable typed in the form.
+// The keyring entry is deleted only after the variable's token was accepted
+// and the config saved.
+func (m *model) applyMoveToEnv() error {
+	if m.manageIndex < 0 || m.manageIndex >= len(m.cfg.Jenkins) {
+		return fmt.Errorf("invalid server selection")
+	}
+	previous := m.cfg.Jenkins[m.manageIndex]
+	ref := strings.TrimSpace(m.manageEnvVar)
+	if ref == "" {
+		return fmt.Errorf("Token environment variable is required.")
+	}
+	moved := previous
+	moved.Credential = models.Credential{Type: models.CredentialTypeEnv, Ref: ref}
+	token, _, err := m.resolveTokenForValidation(moved, &previous)
+	if err != nil {
+		return fmt.Errorf("%w Export it and restart jenkins-tui, then move the token again.", err)
+	}
+	validator := m.validateTarget
+	if validator == nil {
+		validator = defaultTargetValidator
+	}
+	if err := validator(m.ctx, moved, token, m.cfg.Timeout); err != nil {
+		return mapTargetValidationError(err)
+	}
+	m.cfg.Jenkins[m.manageIndex] = moved
+	if err := m.persistConfig(); err != nil {
+		m.cfg.Jenkins[m.manageIndex] = previous
+		return err
+	}
+	_ = m.creds.DeleteKeyring(previous.Credential.Ref)
+	m.status = "Moved token to $" + ref
+	return nil
+}
@@ -1,21 +1,34 @@
 package tui
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"net/http"
 	"net/url"
 	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
-	"unicode"
+	"unicode/utf8"
 
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/huh"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 
 	"jenkins-tui/internal/browser"
 	"jenkins-tui/internal/cache"
@@ -23,8 +36,11 @@ import (
 	"jenkins-tui/internal/credentials"
 	"jenkins-tui/internal/executor"
 	"jenkins-tui/internal/jenkins"
+	"jenkins-tui/internal/logdiff"
 	"jenkins-tui/internal/models"
 	"jenkins-tui/internal/permutation"
+	"jenkins-tui/internal/redact"
+	"jenkins-tui/internal/search"
 	"jenkins-tui/internal/ui"
 )
 
@@ -40,11 +56,33 @@ const (
 	screenDone
 	screenManageTargets
 	screenManageForm
+	screenHistory
+	screenLogDiff
+	screenJobConfig
+	screenBatches
+	screenConnTest
+	screenLocks
+	screenConsole
+	screenStages
+	screenWatch
+	screenRadiator
 )
 
 const (
 	maxPermutations = 20
 	concurrencyCap  = 4
+	// configWatchInterval is how often jenkins.yaml is checked for outside
+	// edits when it cannot be watched, and how long a change seen while a
+	// dialog is open waits before it is looked at again.
+	configWatchInterval = 2 * time.Second
+	// confirmBuildsAbove is how many builds a run may start before asking.
+	confirmBuildsAbove = 5
+	// healthTimeout caps each servers-screen probe so a dead host does not
+	// hold its line at "checking" for the full API timeout.
+	healthTimeout = 10 * time.Second
+	// accessibleTick is how often the spinner, and with it the elapsed
+	// time, redraws in accessible mode.
+	accessibleTick = 5 * time.Second
 )
 
 const (
@@ -52,6 +90,11 @@ const (
 	outerPaddingY = 1
 )
 
+const (
+	jobDetailPanelHeight = 5
+	jobDetailDebounce    = 200 * time.Millisecond
+)
+
 const (
 	tokenStorageKeyring = string(models.CredentialTypeKeyring)
 	tokenStorageEnv     = string(models.CredentialTypeEnv)
@@ -59,21 +102,47 @@ const (
 
 type credentialsManager interface {
 	Resolve(target models.JenkinsTarget) (string, error)
+	Remember(target models.JenkinsTarget, token string)
+	Forget(target models.JenkinsTarget)
 	SetKeyring(ref, value string) error
 	DeleteKeyring(ref string) error
 	KeyringAvailable() (bool, error)
+	SetCacheTTL(ttl time.Duration)
 }
 
 type listItem struct {
-	title    string
+	title string
+	glyph string
+	// status is the Jenkins color of a job, drawn as its glyph only when
+	// the row is shown, so loading thousands of jobs renders none of them.
+	status   string
 	desc     string
 	id       string
 	name     string
 	fullName string
 	kind     models.JobNodeKind
+	disabled bool
+	paths    []string
+	// badge follows the title, e.g. the marker for a newly added job.
+	badge string
+
+	multibranch bool
 }
 
-func (i listItem) Title() string       { return i.title }
+func (i listItem) Title() string {
+	title := i.title
+	if i.badge != "" {
+		title += " " + i.badge
+	}
+	glyph := i.glyph
+	if glyph == "" && i.status != "" {
+		glyph = ui.JobStatusGlyph(i.status)
+	}
+	if glyph == "" {
+		return title
+	}
+	return glyph + " " + title
+}
 func (i listItem) Description() string { return i.desc }
 func (i listItem) FilterValue() string {
 	return strings.TrimSpace(i.title + " " + i.desc + " " + i.fullName)
@@ -81,11 +150,21 @@ func (i listItem) FilterValue() string {
 
 type jobsLoadedMsg struct {
 	nodes        []models.JobNode
+	views        bool
 	fromCache    bool
 	err          error
 	requestID    uint64
 	containerURL string
 	prefix       string
+	// diff compares a fetched listing with the cached one it replaces.
+	diff jobDiff
+	// fetchedAt is when the listing was fetched, and folders what the cache
+	// knows about the subfolders in it.
+	fetchedAt time.Time
+	folders   map[string]folderCacheInfo
+	// offline is set when the server was unreachable, or the TUI offline,
+	// and nodes come from the cache whatever their age.
+	offline bool
 }
 
 type paramsLoadedMsg struct {
@@ -93,21 +172,174 @@ type paramsLoadedMsg struct {
 	err    error
 }
 
+type historyLoadedMsg struct {
+	builds []models.BuildSummary
+	err    error
+	// note replaces the "Loaded N build(s)" status after a reload.
+	note string
+}
+
 type searchLoadedMsg struct {
-	nodes     []models.JobNode
+	nodes []models.JobNode
+	// found is how many jobs the server matched before filter terms.
+	found     int
 	err       error
 	requestID uint64
 }
 
 type runStreamStartedMsg struct {
-	ch <-chan models.RunUpdate
+	batch int
+	ch    <-chan models.RunUpdate
 }
 
 type runEventMsg struct {
+	batch  int
 	update models.RunUpdate
 }
 
-type runDoneMsg struct{}
+type runDoneMsg struct {
+	batch int
+}
+
+type jobDetailTickMsg struct {
+	url string
+}
+
+type folderPreviewTickMsg struct {
+	url    string
+	prefix string
+}
+
+type folderPreviewLoadedMsg struct {
+	url   string
+	nodes []models.JobNode
+	err   error
+}
+
+type jobDetailLoadedMsg struct {
+	url    string
+	detail models.JobDetail
+	err    error
+}
+
+type startupMsg struct{}
+
+// restorePromptMsg offers to reopen the session saved on the last quit.
+type restorePromptMsg struct{}
+
+type gotoIndexLoadedMsg struct {
+	jobs []models.JobNode
+	err  error
+}
+
+type adminStatusMsg struct {
+	target       models.JenkinsTarget
+	client       *jenkins.Client
+	admin        bool
+	quietingDown bool
+	err          error
+}
+
+type adminActionMsg struct {
+	target models.JenkinsTarget
+	action string
+	err    error
+}
+
+type connTestMsg struct {
+	target  models.JenkinsTarget
+	credErr error
+	checks  []jenkins.Check
+}
+
+type serverHealthMsg struct {
+	target string
+	health models.ServerHealth
+	err    error
+}
+
+type serverDetectedMsg struct {
+	target string
+	info   jenkins.ServerInfo
+	err    error
+}
+
+type lockableResourcesMsg struct {
+	resources []models.LockableResource
+	err       error
+}
+
+type lockCheckMsg struct {
+	job      string
+	warnings []string
+}
+
+// replayTarget is a build to replay; label is "#12" or "last build".
+type replayTarget struct {
+	job   models.JobRef
+	url   string
+	label string
+}
+
+type replayScriptsMsg struct {
+	target  replayTarget
+	scripts jenkins.ReplayScripts
+	err     error
+}
+
+type replayEditedMsg struct {
+	target  replayTarget
+	scripts jenkins.ReplayScripts
+	changed bool
+	err     error
+}
+
+type configEditedMsg struct {
+	err error
+}
+
+type configTickMsg struct{}
+
+type matrixEditedMsg struct {
+	text string
+	err  error
+}
+
+type replayDoneMsg struct {
+	target replayTarget
+	number int
+	err    error
+}
+
+type jobEnabledMsg struct {
+	name string
+	err  error
+}
+
+type jobConfigLoadedMsg struct {
+	job    models.JobRef
+	config string
+	err    error
+}
+
+type logDiffLoadedMsg struct {
+	diff string
+	err  error
+}
+
+type authCompletedMsg struct {
+	target models.JenkinsTarget
+	token  string
+	err    error
+	resume func() tea.Cmd
+}
+
+// reauthRequest is the client asking, through reauthFunc, for the
+// auth_command to run again because Jenkins rejected its token.
+type reauthRequest struct {
+	target models.JenkinsTarget
+	reply  chan authCompletedMsg
+}
 
 type manageMode int
 
@@ -115,6 +347,7 @@ const (
 	manageModeAdd manageMode = iota
 	manageModeEdit
 	manageModeRotate
+	manageModeMoveToEnv
 )
 
 type model struct {
@@ -136,28 +369,146 @@ type model struct {
 	jobs    list.Model
 	manage  list.Model
 	search  list.Model
+	// jobCounts caches the folder/view/job counts of the jobs list.
+	jobCounts itemCounts
+	// repoProjects are the projects find_repo_jobs matched to the checkout.
+	repoProjects []string
 
 	target      *models.JenkinsTarget
 	client      *jenkins.Client
 	selectedJob *models.JobRef
 	jobFolders  []models.JobNode
-	jobsReqID   uint64
-	searchReqID uint64
-	searchQuery string
-	searchInput string
+	// showingViews is set while the jobs list shows the views of the
+	// current container instead of its jobs.
+	showingViews bool
+	// jobsFetchedAt is when the listing on screen was fetched, and
+	// jobsFromCache whether it was read from the folder cache.
+	jobsFetchedAt time.Time
+	jobsFromCache bool
+	// navTopPending is set after the first g of gg.
+	navTopPending bool
+	// offline is set by hand or when the server stops answering: job trees
+	// come from the cache and actions that need the network are refused.
+	offline bool
+
+	startupJob *models.JobRef
+	// session is the state saved on the last quit; restoreCursor is the
+	// jobs cursor to apply once the restored folder loads.
+	session        *cache.Session
+	restoreCursor  int
+	restorePending bool
+	gotoActive     bool
+	gotoInput      string
+	gotoIndex      []models.JobNode
+	gotoMatches    []string
+	gotoCycle      int
+	crumbActive    bool
+	crumbCursor    int
+	bookmarksOpen  bool
+	bookmarkCursor int
+	// splitPane shows the current folder on the left and the highlighted
+	// folder's contents (or job details) on the right.
+	splitPane     bool
+	folderPreview map[string][]models.JobNode
+	previewErrs   map[string]error
+	previewReq    string
+	jobDetails    map[string]models.JobDetail
+	detailErrs    map[string]error
+	detailReq     string
+	jobsReqID     uint64
+	searchReqID   uint64
+	searchQuery   string
+	searchInput   string
 
 	params       []models.ParamDef
 	paramForm    *huh.Form
+	paramPrefill map[string]string
 	choiceVars   map[string]*[]string
 	fixedVars    map[string]*string
 	permutations []models.JobSpec
 	previewTable table.Model
-	runRecords   []models.RunRecord
-	runTable     table.Model
-	finished     map[int]bool
-	runEvents    <-chan models.RunUpdate
-	runCtx       context.Context
-	runCancel    context.CancelFunc
+	// batches are the run batches started this session, oldest first; batch
+	// is the one the run screen shows.
+	batches       []*runBatch
+	batch         *runBatch
+	batchSeq      int
+	batchTable    table.Model
+	batchesBackTo screen
+	// watchState is the last poll of each watched job, keyed by watchKey.
+	watchState  map[string]watchStatus
+	watchTable  table.Model
+	watchBackTo screen
+	// quitting is set while in-flight builds are being aborted on the way out.
+	quitting   bool
+	historyJob *models.JobRef
+	// historyBackTo is where esc leaves build history for.
+	historyBackTo screen
+	builds        []models.BuildSummary
+	historyTable  table.Model
+	// historyMarks are the build numbers marked for comparison.
+	historyMarks map[int]bool
+	logDiff      viewport.Model
+	diffBackTo   screen
+	configView   viewport.Model
+	connView     viewport.Model
+	connTarget   string
+	connBackTo   screen
+	configJob    *models.JobRef
+	locksView    viewport.Model
+	// consoleView shows one build's console log; consoleStrip drops its
+	// ANSI colors. consoleLines are its rendered lines and consolePlain the
+	// same without styles, for searching.
+	consoleView   viewport.Model
+	consoleText   string
+	consoleBuild  buildLog
+	consoleStrip  bool
+	consoleBackTo screen
+	consoleLines  []string
+	consolePlain  []string
+	// consoleErrors and consoleMatches are line numbers e and n jump
+	// between; consoleAt is the last line jumped to.
+	consoleErrors    []int
+	consoleMatches   []int
+	consoleAt        int
+	consoleQuery     string
+	consoleInput     string
+	consoleSearching bool
+	// stages are the Pipeline stages of stagesBuild, fetched with
+	// stagesClient, which may be a run batch's rather than the current one.
+	stages       []models.Stage
+	stagesTable  table.Model
+	stagesBuild  buildLog
+	stagesClient *jenkins.Client
+	stagesBackTo screen
+	// lockWarnings lists lockable resources the previewed runs contend on.
+	lockWarnings []string
+	// configStamp is how jenkins.yaml looked when last read or written, so
+	// the watcher only reacts to changes made by someone else.
+	configStamp fileStamp
+	// configWatch is nil when jenkins.yaml is polled instead; configRetry
+	// is set while a change waits for a dialog to close.
+	configWatch *configWatcher
+	configRetry bool
+	// configBase and configHash are jenkins.yaml as last read or written;
+	// a save that finds a different file merges against configBase.
+	configBase     models.Config
+	configHash     string
+	configConflict *configConflict
+	editingConfig  bool
+	// setupWizard marks the first-run add-server form, which adds welcome
+	// and token-creation steps and offers to browse jobs when done.
+	setupWizard bool
+	// tokenCheck validates the token pasted into the server form;
+	// tokenSeen is the value at the last poll, so checks wait for pasting
+	// or typing to settle.
+	tokenCheck     tokenCheck
+	tokenSeen      string
+	tokenTickArmed bool
+	// tokenInput is the form's API token field, kept so a token generated
+	// from the user's password can be filled in.
+	tokenInput *huh.Input
+	// serverTag limits the servers list to one tag; empty shows all.
+	serverTag string
 
 	manageForm     *huh.Form
 	manageMode     manageMode
@@ -169,6 +520,7 @@ type model struct {
 	manageUsername string
 	manageTokenSrc string
 	manageInsecure string
+	manageHTTP     string
 	manageToken    string
 	manageEnvVar   string
 	manageKeyRef   string
@@ -176,14 +528,34 @@ type model struct {
 	keyringAvail   bool
 	validateTarget func(ctx context.Context, target models.JenkinsTarget, token string, timeout time.Duration) error
 	lookupEnv      func(key string) string
-	helpExpanded   bool
+	helpVisible    bool
+	helpView       viewport.Model
+	confirm        *confirmDialog
+	keys           keyMap
+	traceVisible   bool
 	paramsBackTo   screen
+	// serverVersions holds the Jenkins version detected per target ID.
+	serverVersions map[string]string
+	health         map[string]serverHealth
+	// rejectedTokens marks targets whose stored token Jenkins answered
+	// with 401 on the last health probe or token check.
+	rejectedTokens map[string]bool
+	// reauthCh carries the client's sign-in requests to Update, and
+	// reauthReply waits for the auth_command it started; see reauthFunc.
+	reauthCh    chan reauthRequest
+	reauthReply chan authCompletedMsg
+	// syncing is set while a full-tree sync runs in the background.
+	syncing bool
+	// workDir is the checkout the find-repo-jobs key looks up, the
+	// directory jenkins-tui was started in.
+	workDir string
 
 	spin spinner.Model
 }
 
 func NewModel(ctx context.Context, cfg models.Config) tea.Model {
 	serversDelegate := list.NewDefaultDelegate()
+	serversDelegate.SetHeight(3)
 	applySelectedStyles(&serversDelegate)
 	servers := list.New(nil, serversDelegate, 0, 0)
 	servers.Title = "Jenkins Servers"
@@ -198,6 +570,7 @@ func NewModel(ctx context.Context, cfg models.Config) tea.Model {
 	jobs := list.New(nil, jobsDelegate, 0, 0)
 	jobs.Title = "Browse Jenkins Jobs"
 	jobs.SetFilteringEnabled(true)
+	jobs.Filter = (&narrowingFilter{}).Filter
 	jobs.SetShowHelp(false)
 	jobs.SetShowStatusBar(false)
 	jobs.SetShowPagination(false)
@@ -225,6 +598,15 @@ func NewModel(ctx context.Context, cfg models.Config) tea.Model {
 
 	spin := spinner.New()
 	spin.Spinner = spinner.Dot
+	switch {
+	case ui.Accessible:
+		// A word instead of a symbol, and a slow tick so a screen reader is
+		// not interrupted by the elapsed time every second.
+		spin.Spinner = spinner.Spinner{Frames: []string{"working:"}, FPS: accessibleTick}
+	case ui.ASCII:
+		// One frame still ticks each second, so elapsed times keep updating.
+		spin.Spinner = spinner.Spinner{Frames: []string{"*"}, FPS: time.Second}
+	}
 	m := &model{
 		ctx:            ctx,
 		cfg:            cfg,
@@ -236,33 +618,184 @@ func NewModel(ctx context.Context, cfg models.Config) tea.Model {
 		search:         search,
 		choiceVars:     map[string]*[]string{},
 		fixedVars:      map[string]*string{},
-		finished:       map[int]bool{},
+		jobDetails:     map[string]models.JobDetail{},
+		watchState:     map[string]watchStatus{},
+		detailErrs:     map[string]error{},
+		folderPreview:  map[string][]models.JobNode{},
+		previewErrs:    map[string]error{},
+		serverVersions: map[string]string{},
+		health:         map[string]serverHealth{},
+		rejectedTokens: map[string]bool{},
+		reauthCh:       make(chan reauthRequest),
+		splitPane:      cfg.Layout == models.LayoutSplit,
+		logDiff:        viewport.New(0, 0),
+		configView:     viewport.New(0, 0),
+		locksView:      viewport.New(0, 0),
+		consoleView:    viewport.New(0, 0),
+		consoleStrip:   cfg.StripLogColors,
+		connView:       viewport.New(0, 0),
+		helpView:       viewport.New(0, 0),
 		spin:           spin,
 		manageInsecure: "false",
+		manageHTTP:     "false",
 		manageTokenSrc: tokenStorageKeyring,
 		manageIndex:    -1,
 		lookupEnv:      os.Getenv,
+		workDir:        ".",
 		validateTarget: defaultTargetValidator,
 		paramsBackTo:   screenJobs,
 	}
+	m.creds.SetCacheTTL(cfg.CredentialCacheTTL)
+	jenkins.SetMaxResponseSize(cfg.MaxResponseMB)
+	ui.ConfigureTimes(cfg.TimeStyle, cfg.Clock, cfg.Timezone, cfg.DurationStyle)
+	keys, notes, err := newKeyMap(cfg.Keybindings)
+	if err != nil {
+		m.err = err
+	}
+	m.keys = keys
+	m.status = strings.Join(notes, "; ")
+	m.noteConfigOnDisk(cfg)
 	m.refreshServerItems()
 	m.refreshManageItems()
 	if len(cfg.Jenkins) == 0 {
-		m.status = "No Jenkins servers configured. Add your first server."
-		m.startManageForm(manageModeAdd, -1)
+		m.startSetupWizard()
 		m.screen = screenManageForm
+		return m
+	}
+	if s, ok, err := cache.SessionInDir(cfg.CacheDir); err == nil && ok {
+		m.servers.Select(min(max(s.ServersCursor, 0), len(m.servers.Items())-1))
+		if m.findTargetByID(s.Server) != nil {
+			m.session = &s
+		}
 	}
 	return m
 }
 
+// saveSession records the current server, folder, and cursors so the next
+// launch can offer to return to them.
+func (m *model) saveSession() {
+	s := cache.Session{ServersCursor: m.servers.Index()}
+	if m.target != nil && m.client != nil {
+		s.Server = m.target.ID
+		s.Folders = m.jobFolders
+		s.JobsCursor = m.jobs.Index()
+	}
+	if err := cache.SaveSessionInDir(m.cfg.CacheDir, s); err != nil {
+		slog.Debug("save session failed", "error", err.Error())
+	}
+}
+
+func (m *model) promptRestore() tea.Cmd {
+	s := m.session
+	m.session = nil
+	if s == nil || m.confirm != nil {
+		return nil
+	}
+	t := m.findTargetByID(s.Server)
+	if t == nil {
+		return nil
+	}
+	where := t.Name
+	if len(s.Folders) > 0 {
+		where += ": " + jobsPathLabel(s.Folders[len(s.Folders)-1].FullName)
+	}
+	return m.askConfirm("Restore last session?", "Reopen "+where, func() tea.Cmd {
+		return m.connectTarget(t, func() tea.Cmd {
+			m.selectedJob = nil
+			m.jobDetails = map[string]models.JobDetail{}
+			m.detailErrs = map[string]error{}
+			m.jobs.ResetFilter()
+			m.jobs.SetItems(nil)
+			m.jobFolders = append([]models.JobNode(nil), s.Folders...)
+			m.restoreCursor = s.JobsCursor
+			m.restorePending = true
+			return m.transition(screenJobs, m.loadCurrentFolderCmd(false))
+		})
+	}, nil)
+}
+
+// startupTarget resolves the --server deep link; with a single configured
+// target a bare --job link uses it.
+func (m *model) startupTarget() *models.JenkinsTarget {
+	link := m.cfg.Startup
+	if strings.TrimSpace(link.Server) != "" {
+		return m.findTargetByID(strings.TrimSpace(link.Server))
+	}
+	if strings.TrimSpace(link.Job) != "" && len(m.cfg.Jenkins) == 1 {
+		return &m.cfg.Jenkins[0]
+	}
+	return nil
+}
+
+func (m *model) openStartupLink() tea.Cmd {
+	t := m.startupTarget()
+	if t == nil {
+		m.err = fmt.Errorf("server %q not found in config", m.cfg.Startup.Server)
+		return nil
+	}
+	return m.connectTarget(t, func() tea.Cmd {
+		job := t.ResolveAlias(strings.TrimSpace(m.cfg.Startup.Job))
+		if job == "" {
+			return m.openSelectedTarget()
+		}
+		// Open the job's folder first so esc from the params form lands next
+		// to it; the params load is chained from jobsLoadedMsg.
+		ref := startupJobRef(m.client.Host(), job)
+		m.startupJob = &ref
+		m.selectedJob = nil
+		m.jobDetails = map[string]models.JobDetail{}
+		m.detailErrs = map[string]error{}
+		m.jobs.ResetFilter()
+		m.jobs.SetItems(nil)
+		m.jobFolders = ancestorFolders(m.client.Host(), ref.FullName)
+		return m.transition(screenJobs, m.loadCurrentFolderCmd(false))
+	})
+}
+
+func ancestorFolders(host, fullName string) []models.JobNode {
+	parts := strings.Split(strings.Trim(fullName, "/"), "/")
+	folders := make([]models.JobNode, 0, len(parts))
+	for i := 1; i < len(parts); i++ {
+		full := strings.Join(parts[:i], "/")
+		folders = append(folders, models.JobNode{Name: parts[i-1], FullName: full, URL: jenkins.JobURL(host, full), Kind: models.JobNodeFolder})
+	}
+	return folders
+}
+
+func startupJobRef(host, job string) models.JobRef {
+	if strings.HasPrefix(job, "http://") || strings.HasPrefix(job, "https://") {
+		full := jenkins.FullNameFromJobURL(job)
+		return models.JobRef{Name: path.Base(full), FullName: full, URL: jenkins.CanonicalJobURL(job)}
+	}
+	full := strings.Trim(job, "/")
+	return models.JobRef{Name: path.Base(full), FullName: full, URL: jenkins.JobURL(host, full)}
+}
+
 func (m *model) Init() tea.Cmd {
-	cmds := []tea.Cmd{m.spin.Tick}
+	cmds := []tea.Cmd{m.spin.Tick, tokenHealthCmd(), watchCmd(), waitReauthCmd(m.reauthCh)}
+	if strings.TrimSpace(m.cfg.ConfigPath) != "" {
+		if cw, err := watchConfig(m.cfg.ConfigPath); err == nil {
+			m.configWatch = cw
+			cmds = append(cmds, cw.wait())
+		} else {
+			slog.Debug("config watch unavailable, polling", "error", err.Error())
+			cmds = append(cmds, configWatchCmd())
+		}
+	}
 	if m.manageForm != nil {
 		cmds = append(cmds, m.manageForm.Init())
 	}
 	if m.paramForm != nil {
 		cmds = append(cmds, m.paramForm.Init())
 	}
+	if m.startupTarget() != nil || strings.TrimSpace(m.cfg.Startup.Server) != "" {
+		cmds = append(cmds, func() tea.Msg { return startupMsg{} })
+	} else {
+		cmds = append(cmds, m.checkServerHealth(false))
+		if m.session != nil {
+			cmds = append(cmds, func() tea.Msg { return restorePromptMsg{} })
+		}
+	}
 	return tea.Batch(cmds...)
 }
 
@@ -277,7 +810,7 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		contentWidth := m.contentWidth()
 		contentHeight := m.contentHeight()
 		m.servers.SetSize(max(0, contentWidth-8), max(0, contentHeight-10))
-		m.jobs.SetSize(max(0, contentWidth-8), max(0, contentHeight-10))
+		m.resizeJobs()
 		m.manage.SetSize(max(0, contentWidth-8), max(0, contentHeight-10))
 		m.search.SetSize(max(0, contentWidth-8), max(0, contentHeight-10))
 		if m.paramForm != nil {
@@ -289,68 +822,318 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if len(m.permutations) > 0 {
 			m.buildPreviewTable()
 		}
-		if len(m.runRecords) > 0 {
-			m.refreshRunTable()
+		for _, b := range m.batches {
+			m.refreshRunTable(b)
+		}
+		if len(m.batches) > 0 {
+			m.refreshBatchTable()
+		}
+		if len(m.builds) > 0 {
+			m.refreshHistoryTable()
 		}
 		m.previewTable.SetHeight(max(5, contentHeight-14))
-		m.runTable.SetHeight(max(5, contentHeight-14))
+		m.historyTable.SetHeight(max(5, contentHeight-14))
+		m.stagesTable.SetHeight(max(5, contentHeight-14))
+		m.logDiff.Width = max(1, contentWidth-2)
+		m.logDiff.Height = max(5, contentHeight-8)
+		m.configView.Width = max(1, contentWidth-2)
+		m.configView.Height = max(5, contentHeight-10)
+		m.locksView.Width = max(1, contentWidth-2)
+		m.locksView.Height = max(5, contentHeight-10)
+		m.consoleView.Width = max(1, contentWidth-2)
+		m.consoleView.Height = max(5, contentHeight-10)
+		m.connView.Width = max(1, contentWidth-2)
+		m.connView.Height = max(5, contentHeight-10)
+		m.helpView.Width = max(1, contentWidth-2)
+		m.helpView.Height = max(5, contentHeight-8)
 		cmds = append(cmds, tea.ClearScreen)
+		if m.screen == screenServers {
+			// A taller list shows targets that were not probed yet.
+			cmds = append(cmds, m.checkServerHealth(false))
+		}
 	case tea.KeyMsg:
-		if msg.String() == "?" {
-			m.helpExpanded = !m.helpExpanded
+		if m.confirm != nil && msg.String() != "ctrl+c" {
+			return m, tea.Batch(append(cmds, m.updateConfirm(msg))...)
+		}
+		if key.Matches(msg, m.keys.Trace) {
+			m.traceVisible = !m.traceVisible
+			return m, tea.Batch(cmds...)
+		}
+		if m.traceVisible && msg.String() == "esc" {
+			m.traceVisible = false
+			return m, tea.Batch(cmds...)
 		}
-		if msg.String() == "ctrl+c" {
-			if m.runCancel != nil {
-				m.runCancel()
+		if (m.helpVisible || m.allowQuickQuit()) && m.screen != screenGlobalSearch {
+			if cmd, ok := m.navigate(msg); ok {
+				return m, tea.Batch(append(cmds, cmd)...)
 			}
-			return m, tea.Quit
 		}
-		if msg.String() == "q" && m.allowQuickQuit() {
-			if m.runCancel != nil {
-				m.runCancel()
+		if m.helpVisible && msg.String() != "ctrl+c" {
+			if key.Matches(msg, m.keys.Help, m.keys.Quit) || msg.String() == "esc" {
+				m.helpVisible = false
+				return m, tea.Batch(cmds...)
 			}
-			return m, tea.Quit
+			var cmd tea.Cmd
+			m.helpView, cmd = m.helpView.Update(msg)
+			return m, tea.Batch(append(cmds, cmd)...)
+		}
+		if key.Matches(msg, m.keys.Help) && m.allowQuickQuit() && m.screen != screenGlobalSearch {
+			m.openHelp()
+			return m, tea.Batch(cmds...)
+		}
+		if msg.String() == "ctrl+c" || (key.Matches(msg, m.keys.Quit) && m.allowQuickQuit()) {
+			return m, tea.Batch(append(cmds, m.requestQuit())...)
+		}
+		if key.Matches(msg, m.keys.ShowRuns) && m.allowQuickQuit() && len(m.batches) > 0 && m.screen != screenBatches {
+			return m, m.openBatches(cmds)
 		}
+		if key.Matches(msg, m.keys.Offline) && m.allowQuickQuit() && (m.screen == screenServers || m.screen == screenJobs) {
+			return m, tea.Batch(append(cmds, m.toggleOffline())...)
+		}
+		if m.allowQuickQuit() && m.offlineBlocked(msg) {
+			return m, tea.Batch(cmds...)
+		}
+	}
+
+	if _, isKey := msg.(tea.KeyMsg); !isKey && m.confirm != nil {
+		cmds = append(cmds, m.updateConfirm(msg))
 	}
 
 	switch typed := msg.(type) {
+	case startupMsg:
+		return m, tea.Batch(append(cmds, m.openStartupLink())...)
+	case restorePromptMsg:
+		return m, tea.Batch(append(cmds, m.promptRestore())...)
+	case reauthRequest:
+		m.creds.Forget(typed.target)
+		m.reauthReply = typed.reply
+		m.status = "Token expired; running auth command..."
+		return m, tea.Batch(append(cmds, waitReauthCmd(m.reauthCh), m.authenticateCmd(typed.target, nil))...)
+	case authCompletedMsg:
+		m.loading = false
+		reply := m.reauthReply
+		if reply != nil {
+			// The client is waiting to retry with the new token itself.
+			m.reauthReply = nil
+			reply <- typed
+		}
+		if typed.err != nil {
+			m.err = typed.err
+			m.status = "Authentication command failed"
+			return m, tea.Batch(cmds...)
+		}
+		m.err = nil
+		m.creds.Remember(typed.target, typed.token)
+		delete(m.rejectedTokens, typed.target.ID)
+		if reply == nil {
+			cmds = append(cmds, m.useClient(typed.target, typed.token))
+		}
+		m.status = "Authenticated"
+		if typed.resume != nil {
+			cmds = append(cmds, typed.resume())
+		}
+		return m, tea.Batch(cmds...)
+	case adminStatusMsg:
+		m.loading = false
+		if typed.err != nil {
+			m.err = typed.err
+			m.status = "Failed to check admin rights on " + typed.target.Name
+			return m, tea.Batch(cmds...)
+		}
+		m.err = nil
+		if !typed.admin {
+			m.status = "Admin actions need Overall/Administer on " + typed.target.Name
+			return m, tea.Batch(cmds...)
+		}
+		m.status = ""
+		return m, tea.Batch(append(cmds, m.askAdminAction(typed))...)
+	case adminActionMsg:
+		m.loading = false
+		if typed.err != nil {
+			m.err = typed.err
+			m.status = "Failed to " + typed.action + " " + typed.target.Name
+			return m, tea.Batch(cmds...)
+		}
+		m.err = nil
+		m.status = adminActionDone[typed.action] + " " + typed.target.Name
+		delete(m.health, typed.target.ID)
+		return m, tea.Batch(append(cmds, m.checkServerHealth(false))...)
+	case connTestMsg:
+		m.loading = false
+		if typed.target.ID != m.connTarget {
+			return m, tea.Batch(cmds...)
+		}
+		m.err = nil
+		m.connView.SetContent(renderConnTest(typed))
+		m.connView.GotoTop()
+		m.status = connTestStatus(typed)
+		if m.screen != screenConnTest {
+			m.connBackTo = m.screen
+		}
+		return m, m.transition(screenConnTest, cmds...)
+	case serverHealthMsg:
+		m.health[typed.target] = serverHealth{info: typed.health, err: typed.err}
+		if typed.err == nil && typed.health.Version != "" {
+			m.serverVersions[typed.target] = typed.health.Version
+		}
+		m.noteTokenResult(typed.target, typed.err)
+		m.refreshServerItems()
+		return m, tea.Batch(cmds...)
+	case tokenHealthTickMsg:
+		if m.offline {
+			return m, tea.Batch(append(cmds, tokenHealthCmd())...)
+		}
+		return m, tea.Batch(append(cmds, tokenHealthCmd(), m.checkTokens())...)
+	case folderPlanMsg:
+		return m.folderPlanned(typed, cmds)
+	case watchTickMsg:
+		if m.offline {
+			return m, tea.Batch(append(cmds, watchCmd())...)
+		}
+		return m, tea.Batch(append(cmds, watchCmd(), m.pollWatches())...)
+	case watchPolledMsg:
+		m.watchPolled(typed)
+		return m, tea.Batch(cmds...)
+	case tokenHealthMsg:
+		m.noteTokenResult(typed.target, typed.err)
+		return m, tea.Batch(cmds...)
+	case serverDetectedMsg:
+		// Detection is best effort: a failure here shows up again, with a
+		// better message, when the folder load hits the same server.
+		if typed.err != nil {
+			slog.Debug("detect server failed", "target", typed.target, "error", typed.err.Error())
+			return m, tea.Batch(cmds...)
+		}
+		if typed.info.Version != "" {
+			m.serverVersions[typed.target] = typed.info.Version
+			m.refreshServerItems()
+		}
+		return m, tea.Batch(cmds...)
 	case jobsLoadedMsg:
 		if typed.requestID != m.jobsReqID {
 			return m, tea.Batch(cmds...)
 		}
 		m.loading = false
+		if typed.offline {
+			m.offline = true
+		}
 		if typed.err != nil {
+			m.startupJob = nil
+			m.restorePending = false
 			m.err = typed.err
 			m.status = fmt.Sprintf("Failed to load %s", jobsPathLabel(typed.prefix))
+			if typed.views {
+				m.status = fmt.Sprintf("Failed to load views of %s", jobsPathLabel(typed.prefix))
+			}
+			if typed.offline {
+				m.err = nil
+				m.status = m.offlineNote(jobsPathLabel(typed.prefix) + " is not in the cache")
+			}
 			return m, tea.Batch(cmds...)
 		}
 		m.err = nil
-		if typed.fromCache {
-			m.status = fmt.Sprintf("Loaded %d items from %s (cache, TTL 24h)", len(typed.nodes), jobsPathLabel(typed.prefix))
-		} else {
-			m.status = fmt.Sprintf("Loaded %d items from %s", len(typed.nodes), jobsPathLabel(typed.prefix))
+		m.showingViews = typed.views
+		m.jobsFetchedAt = typed.fetchedAt
+		m.jobsFromCache = typed.fromCache
+		switch {
+		case typed.views:
+			m.status = fmt.Sprintf("Loaded %d views from %s; enter opens a view, esc returns to jobs", len(typed.nodes), m.jobsLocationLabel())
+		case typed.offline:
+			m.status = m.offlineNote(fmt.Sprintf("%d cached items from %s", len(typed.nodes), m.jobsLocationLabel()))
+		case typed.fromCache:
+			m.status = fmt.Sprintf("Loaded %d items from %s (cache, TTL 24h)", len(typed.nodes), m.jobsLocationLabel())
+		default:
+			m.status = fmt.Sprintf("Loaded %d items from %s", len(typed.nodes), m.jobsLocationLabel())
+			if summary := typed.diff.summary(); summary != "" {
+				m.status += " (" + summary + ")"
+			}
 		}
+		inMultibranch := m.currentFolderIsMultibranch()
 		items := make([]list.Item, 0, len(typed.nodes))
 		for _, n := range typed.nodes {
 			title := n.Name
 			desc := "job"
-			if n.Kind == models.JobNodeFolder {
+			glyph, status := "", ""
+			if n.Kind == models.JobNodeView {
+				title = "[" + n.Name + "]"
+				desc = "view"
+			} else if n.Kind == models.JobNodeFolder {
 				title += "/"
 				desc = "folder"
+				if n.Multibranch {
+					desc = "multibranch pipeline"
+				}
+				if info, ok := typed.folders[n.URL]; ok {
+					desc += ", " + info.describe()
+				}
+			} else if n.Disabled {
+				desc = "disabled"
+				status = "disabled"
+			} else if n.Color != "" {
+				desc = ui.JobStatusLabel(n.Color)
+				status = n.Color
+			}
+			if n.Kind == models.JobNodeJob && inMultibranch {
+				desc = jenkins.BranchJobKind(n.Name) + ", " + desc
+			}
+			if status == "" && ui.Accessible {
+				glyph = ui.StateMarker(string(n.Kind))
+			}
+			badge := ""
+			if typed.diff.isNew(n) {
+				badge = ui.NewMarker()
 			}
 			items = append(items, listItem{
-				title:    title,
-				desc:     desc,
-				id:       n.URL,
-				name:     n.Name,
-				fullName: n.FullName,
-				kind:     n.Kind,
+				title:       title,
+				glyph:       glyph,
+				status:      status,
+				badge:       badge,
+				desc:        desc,
+				id:          n.URL,
+				name:        n.Name,
+				fullName:    n.FullName,
+				kind:        n.Kind,
+				disabled:    n.Disabled,
+				multibranch: n.Multibranch,
 			})
 		}
-		m.jobs.SetItems(items)
+		cmds = append(cmds, m.setJobItems(items))
+		if m.restorePending {
+			m.restorePending = false
+			m.jobs.Select(min(max(m.restoreCursor, 0), len(items)-1))
+		} else if branch := m.cfg.Startup.Branch; inMultibranch && !typed.views && branch != "" {
+			if i := branchItemIndex(items, branch); i >= 0 {
+				m.jobs.Select(i)
+				m.status += "; your branch " + branch + " is highlighted"
+			}
+		}
+		cmds = append(cmds, m.scheduleJobDetailCmd(), m.scheduleFolderPreviewCmd())
+		if !typed.views {
+			cmds = append(cmds, m.prefetchFoldersCmd(typed.prefix, typed.nodes))
+		}
+		if job := m.startupJob; job != nil && !m.offlineRefusesTrigger(job.Name) {
+			m.startupJob = nil
+			m.selectedJob = job
+			m.paramPrefill = m.cfg.Startup.Params
+			m.paramsBackTo = screenJobs
+			m.loading = true
+			m.loadingStart = time.Now()
+			m.loadingLabel = "Loading pipeline parameters"
+			m.status = "Loading parameters for " + jobsPathLabel(job.FullName) + "..."
+			cmds = append(cmds, loadParamsCmd(m.ctx, m.client, job.URL))
+		}
 		return m, m.transition(screenJobs, cmds...)
 	case paramsLoadedMsg:
 		m.loading = false
+		if errors.Is(typed.err, jenkins.ErrJobDisabled) && m.selectedJob != nil {
+			m.err = fmt.Errorf("%s is disabled in Jenkins and cannot be triggered", jobsPathLabel(m.selectedJob.FullName))
+			m.selectedJob = nil
+			m.status = "Job is disabled"
+			if m.screen == screenJobs {
+				m.status = "Press " + firstKey(m.keys.EnableJob) + " to enable this job"
+			}
+			return m, tea.Batch(cmds...)
+		}
 		if typed.err != nil {
 			m.err = typed.err
 			m.status = "Failed to load parameters"
@@ -365,82 +1148,389 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.params = typed.params
 		m.buildParamForm()
 		m.status = paramsStatusMessage()
-		return m, m.transition(screenParams, cmds...)
-	case searchLoadedMsg:
-		if typed.requestID != m.searchReqID {
-			return m, tea.Batch(cmds...)
+		if name := m.branchFilledParam(); name != "" {
+			m.status += " " + name + " is set to your branch " + m.cfg.Startup.Branch + "."
 		}
+		return m, m.transition(screenParams, cmds...)
+	case historyLoadedMsg:
 		m.loading = false
 		if typed.err != nil {
 			m.err = typed.err
-			m.status = "Global search failed"
+			m.status = "Failed to load build history"
 			return m, tea.Batch(cmds...)
 		}
 		m.err = nil
-		m.status = fmt.Sprintf("Found %d job(s)", len(typed.nodes))
-		items := make([]list.Item, 0, len(typed.nodes))
-		for _, n := range typed.nodes {
-			items = append(items, listItem{
-				title:    n.Name,
-				desc:     n.FullName,
-				id:       n.URL,
-				name:     n.Name,
-				fullName: n.FullName,
-				kind:     n.Kind,
-			})
+		m.builds = typed.builds
+		m.historyMarks = map[int]bool{}
+		m.refreshHistoryTable()
+		switch {
+		case typed.note != "":
+			m.status = typed.note
+		case len(m.builds) == 0:
+			m.status = "No builds found for this job"
+		default:
+			m.status = fmt.Sprintf("Loaded %d build(s)", len(m.builds))
 		}
-		m.search.SetItems(items)
-		return m, tea.Batch(cmds...)
-	case runStreamStartedMsg:
-		m.runEvents = typed.ch
-		return m, waitRunEventCmd(m.runEvents)
-	case runEventMsg:
-		m.applyRunUpdate(typed.update)
-		m.refreshRunTable()
-		if typed.update.Done && !m.finished[typed.update.Index] {
-			m.finished[typed.update.Index] = true
+		return m, m.transition(screenHistory, cmds...)
+	case jobDetailTickMsg:
+		if m.screen != screenJobs || typed.url != m.highlightedJobURL() {
+			return m, tea.Batch(cmds...)
 		}
-		if len(m.finished) == len(m.runRecords) {
-			m.status = "All jobs finished"
-			return m, m.transition(screenDone, cmds...)
+		if _, ok := m.jobDetails[typed.url]; ok || m.client == nil || m.offline {
+			return m, tea.Batch(cmds...)
 		}
-		return m, waitRunEventCmd(m.runEvents)
-	case runDoneMsg:
-		if m.screen == screenRun {
-			return m, m.transition(screenDone, cmds...)
+		m.detailReq = typed.url
+		return m, tea.Batch(append(cmds, loadJobDetailCmd(m.ctx, m.client, typed.url))...)
+	case folderPreviewTickMsg:
+		if m.screen != screenJobs || !m.splitPane || typed.url != m.highlightedFolderURL() {
+			return m, tea.Batch(cmds...)
+		}
+		if _, ok := m.folderPreview[typed.url]; ok || m.client == nil {
+			return m, tea.Batch(cmds...)
 		}
+		m.previewReq = typed.url
+		return m, tea.Batch(append(cmds, loadFolderPreviewCmd(m.ctx, m.cfg.CacheDir, m.client, typed.url, typed.prefix, m.offline))...)
+	case syncProgressMsg:
+		return m, tea.Batch(append(cmds, m.noteSyncProgress(typed))...)
+	case syncDoneMsg:
+		m.finishSync(typed)
 		return m, tea.Batch(cmds...)
-	}
-
-	switch m.screen {
-	case screenServers:
-		return m.updateServers(msg, cmds)
-	case screenJobs:
-		return m.updateJobs(msg, cmds)
-	case screenGlobalSearch:
-		return m.updateGlobalSearch(msg, cmds)
-	case screenParams:
-		return m.updateParams(msg, cmds)
-	case screenPreview:
-		return m.updatePreview(msg, cmds)
-	case screenRun, screenDone:
-		return m.updateRun(msg, cmds)
-	case screenManageTargets:
-		return m.updateManageTargets(msg, cmds)
-	case screenManageForm:
-		return m.updateManageForm(msg, cmds)
-	default:
+	case repoJobsMsg:
+		return m.repoJobsLoaded(typed, cmds)
+	case foldersPrefetchedMsg:
+		notePrefetch(typed)
 		return m, tea.Batch(cmds...)
-	}
-}
-
-func (m *model) updateServers(msg tea.Msg, cmds []tea.Cmd) (tea.Model, tea.Cmd) {
-	var cmd tea.Cmd
+	case folderPreviewLoadedMsg:
+		if typed.url == m.previewReq {
+			m.previewReq = ""
+		}
+		if typed.err != nil {
+			m.previewErrs[typed.url] = typed.err
+			return m, tea.Batch(cmds...)
+		}
+		delete(m.previewErrs, typed.url)
+		m.folderPreview[typed.url] = typed.nodes
+		return m, tea.Batch(cmds...)
+	case jobDetailLoadedMsg:
+		if typed.url == m.detailReq {
+			m.detailReq = ""
+		}
+		if typed.err != nil {
+			m.detailErrs[typed.url] = typed.err
+			return m, tea.Batch(cmds...)
+		}
+		delete(m.detailErrs, typed.url)
+		m.jobDetails[typed.url] = typed.detail
+		return m, tea.Batch(cmds...)
+	case gotoIndexLoadedMsg:
+		if typed.err != nil {
+			m.status = "Job index unavailable; completion limited to this folder"
+		}
+		m.gotoIndex = typed.jobs
+		return m, tea.Batch(cmds...)
+	case jobEnabledMsg:
+		m.loading = false
+		if typed.err != nil {
+			m.err = typed.err
+			m.status = "Failed to enable " + typed.name
+			return m, tea.Batch(cmds...)
+		}
+		m.err = nil
+		cmds = append(cmds, m.loadCurrentFolderCmd(true))
+		m.status = "Enabled " + typed.name
+		return m, tea.Batch(cmds...)
+	case jobConfigLoadedMsg:
+		m.loading = false
+		if typed.err != nil {
+			m.err = typed.err
+			m.status = "Failed to load config.xml"
+			return m, tea.Batch(cmds...)
+		}
+		m.err = nil
+		job := typed.job
+		m.configJob = &job
+		m.configView.SetContent(ui.HighlightXML(typed.config))
+		m.configView.GotoTop()
+		m.status = "config.xml (read-only)"
+		return m, m.transition(screenJobConfig, cmds...)
+	case lockableResourcesMsg:
+		m.loading = false
+		if errors.Is(typed.err, jenkins.ErrNoLockableResources) {
+			m.err = nil
+			m.status = "Lockable Resources plugin is not installed on this server"
+			return m, tea.Batch(cmds...)
+		}
+		if typed.err != nil {
+			m.err = typed.err
+			m.status = "Failed to load lockable resources"
+			return m, tea.Batch(cmds...)
+		}
+		m.err = nil
+		m.locksView.SetContent(renderLocks(typed.resources))
+		m.locksView.GotoTop()
+		m.status = fmt.Sprintf("%d lockable resources", len(typed.resources))
+		return m, m.transition(screenLocks, cmds...)
+	case lockCheckMsg:
+		if m.screen == screenPreview && m.selectedJob != nil && m.selectedJob.FullName == typed.job {
+			m.lockWarnings = typed.warnings
+		}
+		return m, tea.Batch(cmds...)
+	case replayScriptsMsg:
+		m.loading = false
+		if typed.err != nil {
+			m.err = typed.err
+			m.status = "Cannot replay " + typed.target.label
+			return m, tea.Batch(cmds...)
+		}
+		m.err = nil
+		return m, tea.Batch(append(cmds, m.editReplayScript(typed.target, typed.scripts))...)
+	case replayEditedMsg:
+		if typed.err != nil {
+			m.err = typed.err
+			m.status = "Replay cancelled: editor failed"
+			return m, tea.Batch(cmds...)
+		}
+		m.err = nil
+		scripts := typed.scripts
+		if !typed.changed {
+			return m, tea.Batch(append(cmds, m.askConfirm(
+				"Script unchanged",
+				"Replay "+typed.target.label+" with the original script?",
+				func() tea.Cmd { return m.startReplay(typed.target, &scripts) },
+				nil,
+			))...)
+		}
+		return m, tea.Batch(append(cmds, m.startReplay(typed.target, &scripts))...)
+	case tokenCheckTickMsg:
+		return m, tea.Batch(append(cmds, m.pollToken())...)
+	case tokenCheckedMsg:
+		if typed.key == m.tokenCheck.key {
+			m.tokenCheck.pending = false
+			m.tokenCheck.err = typed.err
+		}
+		return m, tea.Batch(cmds...)
+	case tokenGeneratedMsg:
+		m.tokenGenerated(typed)
+		return m, tea.Batch(cmds...)
+	case configChangedMsg:
+		return m, tea.Batch(append(cmds, m.configWatch.wait(), m.checkConfigOnDisk())...)
+	case configTickMsg:
+		m.configRetry = false
+		if m.configWatch == nil {
+			cmds = append(cmds, configWatchCmd())
+		}
+		return m, tea.Batch(append(cmds, m.checkConfigOnDisk())...)
+	case configEditedMsg:
+		m.editingConfig = false
+		if typed.err != nil {
+			m.err = typed.err
+			m.status = "Editing " + filepath.Base(m.cfg.ConfigPath) + " failed"
+			return m, tea.Batch(cmds...)
+		}
+		return m, tea.Batch(append(cmds, m.reloadConfigFile())...)
+	case matrixEditedMsg:
+		if typed.err != nil {
+			m.err = typed.err
+			m.status = "Editing the run matrix failed"
+			return m, tea.Batch(cmds...)
+		}
+		specs, err := parseRunMatrix(typed.text, m.params)
+		switch {
+		case err != nil:
+			m.err = err
+			m.status = "Run matrix not applied"
+			return m, tea.Batch(cmds...)
+		case len(specs) == 0:
+			m.err = nil
+			m.status = "Run matrix is empty; kept the previous runs"
+			return m, tea.Batch(cmds...)
+		}
+		m.err = nil
+		m.permutations = specs
+		m.status = fmt.Sprintf("%d permutations ready (edited)", len(specs))
+		if m.screen != screenPreview {
+			return m, tea.Batch(cmds...)
+		}
+		return m, m.openPreview(cmds)
+	case replayDoneMsg:
+		m.loading = false
+		if typed.err != nil {
+			m.err = typed.err
+			m.status = "Failed to replay " + typed.target.label
+			return m, tea.Batch(cmds...)
+		}
+		m.err = nil
+		m.status = fmt.Sprintf("Replayed %s of %s as #%d", typed.target.label, jobsPathLabel(typed.target.job.FullName), typed.number)
+		if m.screen == screenHistory && m.historyJob != nil && m.historyJob.URL == typed.target.job.URL {
+			reload, note := loadHistoryCmd(m.ctx, m.client, typed.target.job.URL), m.status
+			return m, tea.Batch(append(cmds, func() tea.Msg {
+				msg := reload().(historyLoadedMsg)
+				msg.note = note
+				return msg
+			})...)
+		}
+		return m, tea.Batch(cmds...)
+	case consoleLoadedMsg:
+		return m.consoleLoaded(typed, cmds)
+	case logsSavedMsg:
+		return m.logsSaved(typed, cmds)
+	case stagesLoadedMsg:
+		return m.stagesLoaded(typed, cmds)
+	case logDiffLoadedMsg:
+		m.loading = false
+		if typed.err != nil {
+			m.err = typed.err
+			m.status = "Failed to load console logs"
+			return m, tea.Batch(cmds...)
+		}
+		m.err = nil
+		if typed.diff == "" {
+			m.status = "Console logs are identical (ignoring timestamps)"
+			return m, tea.Batch(cmds...)
+		}
+		m.logDiff.SetContent(colorizeDiff(typed.diff))
+		m.logDiff.GotoTop()
+		m.status = "Console log diff (timestamps ignored)"
+		m.diffBackTo = m.screen
+		return m, m.transition(screenLogDiff, cmds...)
+	case searchLoadedMsg:
+		if typed.requestID != m.searchReqID {
+			return m, tea.Batch(cmds...)
+		}
+		m.loading = false
+		if typed.err != nil {
+			m.err = typed.err
+			m.status = "Global search failed"
+			return m, tea.Batch(cmds...)
+		}
+		m.err = nil
+		m.status = fmt.Sprintf("Found %d job(s)", len(typed.nodes))
+		if typed.found != len(typed.nodes) {
+			m.status = fmt.Sprintf("Found %d job(s), %d before filters", len(typed.nodes), typed.found)
+		}
+		items := make([]list.Item, 0, len(typed.nodes))
+		for _, n := range typed.nodes {
+			desc := n.FullName
+			if len(n.Paths) > 0 {
+				desc += fmt.Sprintf(" (+%d other path(s))", len(n.Paths))
+			}
+			items = append(items, listItem{
+				title:    n.Name,
+				desc:     desc,
+				id:       n.URL,
+				name:     n.Name,
+				fullName: n.FullName,
+				kind:     n.Kind,
+				paths:    n.Paths,
+			})
+		}
+		m.search.SetItems(items)
+		return m, tea.Batch(cmds...)
+	case runStreamStartedMsg:
+		b := m.findBatch(typed.batch)
+		if b == nil {
+			return m, tea.Batch(cmds...)
+		}
+		b.events = typed.ch
+		return m, waitRunEventCmd(b.id, b.events)
+	case runEventMsg:
+		b := m.findBatch(typed.batch)
+		if b == nil {
+			return m, tea.Batch(cmds...)
+		}
+		b.apply(typed.update)
+		m.refreshRunTable(b)
+		if m.screen == screenBatches {
+			m.refreshBatchTable()
+		}
+		if !b.done() {
+			return m, waitRunEventCmd(b.id, b.events)
+		}
+		if b != m.batch || m.screen != screenRun {
+			m.status = "Batch " + selectedJobLabel(&b.job) + " finished: " + b.summary() + "; " + firstKey(m.keys.ShowRuns) + " lists batches"
+			return m, tea.Batch(cmds...)
+		}
+		m.status = "All jobs finished"
+		if b.indexing {
+			m.status = "Branch indexing finished; esc returns to the refreshed folder"
+		}
+		return m, m.transition(screenDone, cmds...)
+	case runDoneMsg:
+		b := m.findBatch(typed.batch)
+		if b == nil {
+			return m, tea.Batch(cmds...)
+		}
+		if !b.done() {
+			b.stopped = true
+		}
+		if b == m.batch && m.screen == screenRun {
+			return m, m.transition(screenDone, cmds...)
+		}
+		return m, tea.Batch(cmds...)
+	case buildsAbortedMsg:
+		m.loading = false
+		m.quitting = false
+		if len(typed.errs) > 0 {
+			m.err = errors.Join(typed.errs...)
+			m.status = fmt.Sprintf("Could not abort %d of %d build(s); press %s to quit anyway", len(typed.errs), typed.total, firstKey(m.keys.Quit))
+			return m, tea.Batch(cmds...)
+		}
+		return m, m.quit()
+	}
+
+	switch m.screen {
+	case screenServers:
+		return m.updateServers(msg, cmds)
+	case screenJobs:
+		return m.updateJobs(msg, cmds)
+	case screenGlobalSearch:
+		return m.updateGlobalSearch(msg, cmds)
+	case screenParams:
+		return m.updateParams(msg, cmds)
+	case screenPreview:
+		return m.updatePreview(msg, cmds)
+	case screenRun, screenDone:
+		return m.updateRun(msg, cmds)
+	case screenBatches:
+		return m.updateBatches(msg, cmds)
+	case screenWatch:
+		return m.updateWatch(msg, cmds)
+	case screenRadiator:
+		return m.updateRadiator(msg, cmds)
+	case screenManageTargets:
+		return m.updateManageTargets(msg, cmds)
+	case screenManageForm:
+		return m.updateManageForm(msg, cmds)
+	case screenHistory:
+		return m.updateHistory(msg, cmds)
+	case screenLogDiff:
+		return m.updateLogDiff(msg, cmds)
+	case screenJobConfig:
+		return m.updateJobConfig(msg, cmds)
+	case screenConsole:
+		return m.updateConsole(msg, cmds)
+	case screenStages:
+		return m.updateStages(msg, cmds)
+	case screenLocks:
+		return m.updateLocks(msg, cmds)
+	case screenConnTest:
+		return m.updateConnTest(msg, cmds)
+	default:
+		return m, tea.Batch(cmds...)
+	}
+}
+
+func (m *model) updateServers(msg tea.Msg, cmds []tea.Cmd) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
 	m.servers, cmd = m.servers.Update(msg)
 	cmds = append(cmds, cmd)
+	if !m.servers.SettingFilter() {
+		// Moving or paging may have brought unprobed targets into view.
+		cmds = append(cmds, m.checkServerHealth(false))
+	}
 	if km, ok := msg.(tea.KeyMsg); ok {
-		switch km.String() {
-		case "enter":
+		switch {
+		case key.Matches(km, m.keys.Open):
 			selected := m.servers.SelectedItem()
 			item, ok := selected.(listItem)
 			if !ok {
@@ -450,28 +1540,60 @@ func (m *model) updateServers(msg tea.Msg, cmds []tea.Cmd) (tea.Model, tea.Cmd)
 			if t == nil {
 				return m, tea.Batch(cmds...)
 			}
-			token, err := m.creds.Resolve(*t)
-			if err != nil {
-				m.err = err
-				m.status = "Failed to resolve server credentials"
+			return m, tea.Batch(append(cmds, m.connectTarget(t, m.openSelectedTarget))...)
+		case key.Matches(km, m.keys.EditConfig):
+			if m.servers.SettingFilter() {
 				return m, tea.Batch(cmds...)
 			}
-			m.err = nil
-			m.target = t
-			m.client = jenkins.NewClient(*t, token, m.cfg.Timeout)
-			m.selectedJob = nil
-			m.jobFolders = nil
-			m.jobs.ResetFilter()
-			m.jobs.SetItems(nil)
-			return m, m.transition(screenJobs, append(cmds, m.loadCurrentFolderCmd(false))...)
-		case "a", "m":
+			return m, tea.Batch(append(cmds, m.editConfigFile())...)
+		case key.Matches(km, m.keys.UndoConfig):
+			if m.servers.SettingFilter() {
+				return m, tea.Batch(cmds...)
+			}
+			return m, tea.Batch(append(cmds, m.confirmUndoConfig())...)
+		case key.Matches(km, m.keys.TagFilter):
+			if m.servers.SettingFilter() {
+				return m, tea.Batch(cmds...)
+			}
+			m.cycleServerTag()
+			return m, tea.Batch(cmds...)
+		case key.Matches(km, m.keys.ImportServers):
+			if m.servers.SettingFilter() {
+				return m, tea.Batch(cmds...)
+			}
+			return m, tea.Batch(append(cmds, m.importServers())...)
+		case key.Matches(km, m.keys.Admin):
+			if m.servers.SettingFilter() {
+				return m, tea.Batch(cmds...)
+			}
+			idx := m.selectedServerTargetIndex()
+			if idx < 0 {
+				return m, tea.Batch(cmds...)
+			}
+			return m, tea.Batch(append(cmds, m.checkAdmin(m.cfg.Jenkins[idx]))...)
+		case key.Matches(km, m.keys.TestConn):
+			if m.servers.SettingFilter() {
+				return m, tea.Batch(cmds...)
+			}
+			idx := m.selectedServerTargetIndex()
+			if idx < 0 {
+				return m, tea.Batch(cmds...)
+			}
+			return m, tea.Batch(append(cmds, m.testConnection(m.cfg.Jenkins[idx]))...)
+		case key.Matches(km, m.keys.Refresh):
+			if m.servers.SettingFilter() {
+				return m, tea.Batch(cmds...)
+			}
+			m.status = "Checking server health..."
+			return m, tea.Batch(append(cmds, m.checkServerHealth(true))...)
+		case key.Matches(km, m.keys.AddServer):
 			if m.servers.SettingFilter() {
 				return m, tea.Batch(cmds...)
 			}
 			m.startManageForm(manageModeAdd, -1)
 			m.err = nil
 			return m, m.transition(screenManageForm, append(cmds, m.manageForm.Init())...)
-		case "e":
+		case key.Matches(km, m.keys.EditServer):
 			if m.servers.SettingFilter() {
 				return m, tea.Batch(cmds...)
 			}
@@ -482,7 +1604,7 @@ func (m *model) updateServers(msg tea.Msg, cmds []tea.Cmd) (tea.Model, tea.Cmd)
 			m.startManageForm(manageModeEdit, idx)
 			m.err = nil
 			return m, m.transition(screenManageForm, append(cmds, m.manageForm.Init())...)
-		case "t":
+		case key.Matches(km, m.keys.RotateToken):
 			if m.servers.SettingFilter() {
 				return m, tea.Batch(cmds...)
 			}
@@ -490,10 +1612,8 @@ func (m *model) updateServers(msg tea.Msg, cmds []tea.Cmd) (tea.Model, tea.Cmd)
 			if idx < 0 {
 				return m, tea.Batch(cmds...)
 			}
-			m.startManageForm(manageModeRotate, idx)
-			m.err = nil
-			return m, m.transition(screenManageForm, append(cmds, m.manageForm.Init())...)
-		case "d":
+			return m, tea.Batch(append(cmds, m.startRotateToken(idx))...)
+		case key.Matches(km, m.keys.MoveToken):
 			if m.servers.SettingFilter() {
 				return m, tea.Batch(cmds...)
 			}
@@ -501,73 +1621,515 @@ func (m *model) updateServers(msg tea.Msg, cmds []tea.Cmd) (tea.Model, tea.Cmd)
 			if idx < 0 {
 				return m, tea.Batch(cmds...)
 			}
-			if err := m.deleteTargetAt(idx); err != nil {
-				m.err = err
-				m.status = "Failed to delete server"
+			return m, tea.Batch(append(cmds, m.startMoveToken(idx))...)
+		case key.Matches(km, m.keys.DeleteServer):
+			if m.servers.SettingFilter() {
 				return m, tea.Batch(cmds...)
 			}
-			m.err = nil
-			if len(m.cfg.Jenkins) == 0 {
-				m.status = "No Jenkins servers configured. Add your first server."
-				m.startManageForm(manageModeAdd, -1)
-				return m, m.transition(screenManageForm, append(cmds, m.manageForm.Init())...)
+			idx := m.selectedServerTargetIndex()
+			if idx < 0 {
+				return m, tea.Batch(cmds...)
 			}
-			m.status = "Deleted server"
-			return m, tea.Batch(cmds...)
+			return m, tea.Batch(append(cmds, m.confirmDeleteTarget(idx, func() tea.Cmd {
+				if len(m.cfg.Jenkins) == 0 {
+					m.startSetupWizard()
+					return m.transition(screenManageForm, m.manageForm.Init())
+				}
+				return nil
+			}))...)
 		}
 	}
 	return m, tea.Batch(cmds...)
 }
 
+// connectTarget resolves credentials for t, running its auth_command first
+// when needed, and then continues with open.
+func (m *model) connectTarget(t *models.JenkinsTarget, open func() tea.Cmd) tea.Cmd {
+	token, err := m.creds.Resolve(*t)
+	if errors.Is(err, credentials.ErrAuthRequired) {
+		m.err = nil
+		m.target = t
+		return m.authenticateCmd(*t, open)
+	}
+	if err != nil {
+		m.err = err
+		m.status = "Failed to resolve server credentials"
+		return nil
+	}
+	m.err = nil
+	m.target = t
+	return tea.Batch(m.useClient(*t, token), open())
+}
+
+// useClient connects to t and detects its Jenkins version in the background,
+// unless the TUI is offline.
+func (m *model) useClient(t models.JenkinsTarget, token string) tea.Cmd {
+	m.client = jenkins.NewClient(t, token, m.cfg.Timeout)
+	if strings.TrimSpace(t.AuthCommand) != "" {
+		m.client.SetReauth(reauthFunc(m.reauthCh, t))
+	}
+	if m.offline {
+		return nil
+	}
+	return detectServerCmd(m.ctx, m.client, t.ID)
+}
+
+func (m *model) openSelectedTarget() tea.Cmd {
+	m.selectedJob = nil
+	m.jobFolders = nil
+	m.jobDetails = map[string]models.JobDetail{}
+	m.detailErrs = map[string]error{}
+	m.jobs.ResetFilter()
+	m.jobs.SetItems(nil)
+	return m.transition(screenJobs, m.loadCurrentFolderCmd(false))
+}
+
+func (m *model) highlightedJobURL() string {
+	item, ok := m.jobs.SelectedItem().(listItem)
+	if !ok || item.kind != models.JobNodeJob {
+		return ""
+	}
+	return item.id
+}
+
+func (m *model) scheduleJobDetailCmd() tea.Cmd {
+	url := m.highlightedJobURL()
+	if url == "" || m.client == nil {
+		return nil
+	}
+	if _, ok := m.jobDetails[url]; ok || url == m.detailReq {
+		return nil
+	}
+	return tea.Tick(jobDetailDebounce, func(time.Time) tea.Msg {
+		return jobDetailTickMsg{url: url}
+	})
+}
+
+func (m *model) highlightedFolderURL() string {
+	item, ok := m.jobs.SelectedItem().(listItem)
+	if !ok || item.kind != models.JobNodeFolder {
+		return ""
+	}
+	return item.id
+}
+
+func (m *model) scheduleFolderPreviewCmd() tea.Cmd {
+	if !m.splitPane || m.client == nil {
+		return nil
+	}
+	item, ok := m.jobs.SelectedItem().(listItem)
+	if !ok || item.kind != models.JobNodeFolder {
+		return nil
+	}
+	if _, ok := m.folderPreview[item.id]; ok || item.id == m.previewReq {
+		return nil
+	}
+	return tea.Tick(jobDetailDebounce, func(time.Time) tea.Msg {
+		return folderPreviewTickMsg{url: item.id, prefix: item.fullName}
+	})
+}
+
+// resizeJobs sizes the jobs list for the active layout; in split mode the
+// detail panel moves into the right pane, so the list gets the full height.
+func (m *model) resizeJobs() {
+	width, height := max(0, m.contentWidth()-8), max(0, m.contentHeight()-10)
+	if m.splitPane {
+		m.jobs.SetSize(m.splitLeftWidth(), height)
+		return
+	}
+	m.jobs.SetSize(width, max(0, height-jobDetailPanelHeight))
+}
+
+func (m *model) splitLeftWidth() int {
+	return max(24, (m.contentWidth()-8)*2/5)
+}
+
+// splitRightPane previews the highlighted entry: a folder's children, or the
+// job detail panel for a job.
+func (m *model) splitRightPane(width, height int) string {
+	item, ok := m.jobs.SelectedItem().(listItem)
+	if !ok || item.kind != models.JobNodeFolder {
+		return m.jobDetailPanel(width)
+	}
+	title := ui.Title.Render(clip(jobsPathLabel(item.fullName), width))
+	if err := m.previewErrs[item.id]; err != nil {
+		return title + "\n" + ui.Muted.Render(clip("Unavailable: "+err.Error(), width))
+	}
+	nodes, ok := m.folderPreview[item.id]
+	if !ok {
+		return title + "\n" + ui.Muted.Render("Loading...")
+	}
+	if len(nodes) == 0 {
+		return title + "\n" + ui.Muted.Render("Empty folder")
+	}
+	lines := []string{title}
+	for i, n := range nodes {
+		if i == height-2 && len(nodes) > height-1 {
+			lines = append(lines, ui.Muted.Render(fmt.Sprintf("... %d more", len(nodes)-i)))
+			break
+		}
+		name := n.Name
+		glyph := "  "
+		if ui.Accessible {
+			glyph = ui.StateMarker(string(n.Kind)) + " "
+		}
+		switch {
+		case n.Kind == models.JobNodeFolder:
+			name += "/"
+		case n.Disabled:
+			glyph = ui.JobStatusGlyph("disabled") + " "
+		case n.Color != "":
+			glyph = ui.JobStatusGlyph(n.Color) + " "
+		}
+		lines = append(lines, clip(glyph+name, width))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (m *model) jobDetailPanel(width int) string {
+	item, ok := m.jobs.SelectedItem().(listItem)
+	if !ok {
+		return ""
+	}
+	if item.kind == models.JobNodeView {
+		return ui.Muted.Render("View " + item.name + " (enter: browse its jobs)")
+	}
+	if item.kind == models.JobNodeFolder {
+		if item.multibranch {
+			return ui.Muted.Render("Multibranch pipeline " + jobsPathLabel(item.fullName) + " (S: scan for new branches)")
+		}
+		return ui.Muted.Render("Folder " + jobsPathLabel(item.fullName))
+	}
+	if err := m.detailErrs[item.id]; err != nil {
+		return ui.Muted.Render("Job details unavailable: " + clip(err.Error(), max(10, width-24)))
+	}
+	detail, ok := m.jobDetails[item.id]
+	if !ok && m.offline {
+		return ui.Disabled.Render("Job details need the network (offline)")
+	}
+	if !ok {
+		return ui.Muted.Render("Loading job details...")
+	}
+	lines := []string{}
+	if desc := plainText(detail.Description); desc != "" {
+		lines = append(lines, "Description: "+clip(desc, max(10, width-13)))
+	}
+	statusLine := "Status: " + ui.JobStatusLabel(detail.Color)
+	if detail.HealthScore >= 0 {
+		statusLine += fmt.Sprintf(" | Health: %d%%", detail.HealthScore)
+		if detail.HealthSummary != "" {
+			statusLine += " (" + detail.HealthSummary + ")"
+		}
+	}
+	lines = append(lines, clip(statusLine, width))
+	if lb := detail.LastBuild; lb != nil {
+		result := lb.Result
+		if lb.Building {
+			result = "BUILDING"
+		}
+		last := fmt.Sprintf("Last build: #%d %s", lb.Number, result)
+		if !lb.Timestamp.IsZero() {
+			last += ", started " + ui.Timestamp(lb.Timestamp)
+		}
+		lines = append(lines, clip(last, width))
+		if len(lb.Changes) > 0 {
+			lines = append(lines, fmt.Sprintf("Changes (%d):", len(lb.Changes)))
+			lines = append(lines, changeLines(lb.Changes, 3, width)...)
+		}
+	} else {
+		lines = append(lines, "Last build: none")
+	}
+	return ui.Muted.Render(strings.Join(lines, "\n"))
+}
+
+// searchPathsPanel lists every path the highlighted search result is known
+// by, so a job reachable through several folders or views shows up once.
+func (m *model) searchPathsPanel(width int) string {
+	item, ok := m.search.SelectedItem().(listItem)
+	if !ok || len(item.paths) == 0 {
+		return ""
+	}
+	lines := []string{"Known paths:"}
+	for _, p := range append([]string{item.fullName}, item.paths...) {
+		lines = append(lines, clip("  "+jobsPathLabel(p), width))
+	}
+	return ui.Muted.Render(strings.Join(lines, "\n"))
+}
+
+// reauthFunc is the client's sign-in for a target with an auth_command.
+// The command needs the terminal, so the request goes to Update, which
+// suspends the TUI to run it, and the client waits here for the token.
+func reauthFunc(ch chan reauthRequest, t models.JenkinsTarget) func(context.Context) (string, error) {
+	return func(ctx context.Context) (string, error) {
+		req := reauthRequest{target: t, reply: make(chan authCompletedMsg, 1)}
+		select {
+		case ch <- req:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+		select {
+		case done := <-req.reply:
+			return done.token, done.err
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+}
+
+func waitReauthCmd(ch chan reauthRequest) tea.Cmd {
+	return func() tea.Msg {
+		return <-ch
+	}
+}
+
+func (m *model) authenticateCmd(target models.JenkinsTarget, resume func() tea.Cmd) tea.Cmd {
+	var out bytes.Buffer
+	cmd := credentials.AuthCommand(target)
+	cmd.Stdout = &out
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		if err != nil {
+			return authCompletedMsg{target: target, err: fmt.Errorf("auth command for %s: %w", target.Name, err)}
+		}
+		token, err := credentials.ParseAuthCommandOutput(out.Bytes())
+		return authCompletedMsg{target: target, token: token, err: err, resume: resume}
+	})
+}
+
 func (m *model) updateJobs(msg tea.Msg, cmds []tea.Cmd) (tea.Model, tea.Cmd) {
+	if m.gotoActive {
+		return m.updateGoto(msg, cmds)
+	}
+	if m.crumbActive {
+		return m.updateCrumbs(msg, cmds)
+	}
+	if m.bookmarksOpen {
+		return m.updateBookmarks(msg, cmds)
+	}
 	var cmd tea.Cmd
 	m.jobs, cmd = m.jobs.Update(msg)
-	cmds = append(cmds, cmd)
+	cmds = append(cmds, cmd, m.scheduleJobDetailCmd(), m.scheduleFolderPreviewCmd())
 	if km, ok := msg.(tea.KeyMsg); ok {
-		switch km.String() {
-		case "enter":
+		switch {
+		case key.Matches(km, m.keys.Open):
 			selected := m.jobs.SelectedItem()
 			item, ok := selected.(listItem)
 			if !ok {
 				return m, tea.Batch(cmds...)
 			}
-			if item.kind == models.JobNodeFolder {
+			if item.kind == models.JobNodeFolder || item.kind == models.JobNodeView {
 				m.selectedJob = nil
 				m.jobs.ResetFilter()
 				m.jobFolders = append(m.jobFolders, models.JobNode{
-					Name:     item.name,
-					FullName: item.fullName,
-					URL:      item.id,
-					Kind:     models.JobNodeFolder,
+					Name:        item.name,
+					FullName:    item.fullName,
+					URL:         item.id,
+					Kind:        item.kind,
+					Multibranch: item.multibranch,
 				})
 				return m, tea.Batch(append(cmds, m.loadCurrentFolderCmd(false))...)
 			}
 			if item.kind != models.JobNodeJob {
 				return m, tea.Batch(cmds...)
 			}
+			if m.offlineRefusesTrigger(item.name) {
+				return m, tea.Batch(cmds...)
+			}
+			if item.disabled {
+				m.err = fmt.Errorf("%s is disabled in Jenkins and cannot be triggered", jobsPathLabel(item.fullName))
+				m.status = "Press " + firstKey(m.keys.EnableJob) + " to enable this job"
+				return m, tea.Batch(cmds...)
+			}
 			job := models.JobRef{Name: item.name, FullName: item.fullName, URL: item.id}
 			m.selectedJob = &job
+			m.paramPrefill = nil
 			m.paramsBackTo = screenJobs
 			m.loading = true
 			m.loadingStart = time.Now()
 			m.loadingLabel = "Loading pipeline parameters"
 			m.status = "Loading pipeline parameters..."
 			return m, tea.Batch(append(cmds, loadParamsCmd(m.ctx, m.client, job.URL))...)
-		case "esc":
+		case km.String() == "esc":
 			if m.jobs.SettingFilter() {
 				return m, tea.Batch(cmds...)
 			}
 			return m.navigateUpJobs(cmds)
-		case "backspace":
+		case km.String() == "backspace":
 			if !m.jobs.SettingFilter() {
 				return m.navigateUpJobs(cmds)
 			}
-		case "r":
+		case key.Matches(km, m.keys.Refresh):
 			if m.jobs.SettingFilter() {
 				return m, tea.Batch(cmds...)
 			}
+			if m.showingViews {
+				return m, tea.Batch(append(cmds, m.loadViewsCmd())...)
+			}
+			m.jobDetails = map[string]models.JobDetail{}
+			m.detailErrs = map[string]error{}
+			m.folderPreview = map[string][]models.JobNode{}
+			m.previewErrs = map[string]error{}
 			return m, tea.Batch(append(cmds, m.loadCurrentFolderCmd(true))...)
-		case "g":
+		case key.Matches(km, m.keys.ToggleLayout):
+			if m.jobs.SettingFilter() {
+				return m, tea.Batch(cmds...)
+			}
+			m.splitPane = !m.splitPane
+			m.resizeJobs()
+			m.status = "List layout"
+			if m.splitPane {
+				m.status = "Split layout: highlighted folder contents on the right"
+			}
+			return m, tea.Batch(append(cmds, m.scheduleFolderPreviewCmd())...)
+		case key.Matches(km, m.keys.SyncTree):
+			if m.jobs.SettingFilter() {
+				return m, tea.Batch(cmds...)
+			}
+			return m, tea.Batch(append(cmds, m.startSync())...)
+		case key.Matches(km, m.keys.RepoJobs):
+			if m.jobs.SettingFilter() || m.client == nil {
+				return m, tea.Batch(cmds...)
+			}
+			return m, tea.Batch(append(cmds, m.findRepoJobs())...)
+		case key.Matches(km, m.keys.GotoJob):
+			if m.jobs.SettingFilter() || m.client == nil {
+				return m, tea.Batch(cmds...)
+			}
+			m.gotoActive = true
+			m.gotoInput = ""
+			m.gotoMatches = nil
+			m.gotoCycle = -1
+			m.status = "Go to job: type a full name, tab completes, enter opens parameters"
+			return m, tea.Batch(append(cmds, loadGotoIndexCmd(m.cfg.CacheDir, m.client))...)
+		case key.Matches(km, m.keys.ToggleViews):
+			if m.jobs.SettingFilter() || m.client == nil {
+				return m, tea.Batch(cmds...)
+			}
+			if m.showingViews {
+				m.jobs.ResetFilter()
+				return m, tea.Batch(append(cmds, m.loadCurrentFolderCmd(false))...)
+			}
+			m.jobs.ResetFilter()
+			return m, tea.Batch(append(cmds, m.loadViewsCmd())...)
+		case key.Matches(km, m.keys.EnableJob):
+			if m.jobs.SettingFilter() {
+				return m, tea.Batch(cmds...)
+			}
+			item, ok := m.jobs.SelectedItem().(listItem)
+			if !ok || !item.disabled || m.client == nil {
+				return m, tea.Batch(cmds...)
+			}
+			m.err = nil
+			m.loading = true
+			m.loadingStart = time.Now()
+			m.loadingLabel = "Enabling " + item.name
+			m.status = m.loadingLabel + "..."
+			return m, tea.Batch(append(cmds, enableJobCmd(m.ctx, m.client, item.id, item.name))...)
+		case key.Matches(km, m.keys.History):
+			if m.jobs.SettingFilter() {
+				return m, tea.Batch(cmds...)
+			}
+			item, ok := m.jobs.SelectedItem().(listItem)
+			if !ok || item.kind != models.JobNodeJob || m.client == nil {
+				return m, tea.Batch(cmds...)
+			}
+			job := models.JobRef{Name: item.name, FullName: item.fullName, URL: item.id}
+			m.historyJob = &job
+			m.historyBackTo = screenJobs
+			m.loading = true
+			m.loadingStart = time.Now()
+			m.loadingLabel = "Loading build history"
+			m.status = "Loading build history..."
+			return m, tea.Batch(append(cmds, loadHistoryCmd(m.ctx, m.client, job.URL))...)
+		case key.Matches(km, m.keys.ScanMultibranch):
+			if m.jobs.SettingFilter() || m.client == nil {
+				return m, tea.Batch(cmds...)
+			}
+			folder, ok := m.multibranchScanTarget()
+			if !ok {
+				m.status = "Highlight or open a multibranch pipeline to scan it"
+				return m, tea.Batch(cmds...)
+			}
+			m.err = nil
+			return m, m.startIndexingRun(folder, cmds)
+		case key.Matches(km, m.keys.Replay):
+			if m.jobs.SettingFilter() {
+				return m, tea.Batch(cmds...)
+			}
+			item, ok := m.jobs.SelectedItem().(listItem)
+			if !ok || item.kind != models.JobNodeJob || m.client == nil {
+				return m, tea.Batch(cmds...)
+			}
+			job := models.JobRef{Name: item.name, FullName: item.fullName, URL: item.id}
+			return m, tea.Batch(append(cmds, m.askReplay(replayTarget{job: job, url: strings.TrimRight(job.URL, "/") + "/lastBuild/", label: "last build"}))...)
+		case key.Matches(km, m.keys.Locks):
+			if m.jobs.SettingFilter() || m.client == nil {
+				return m, tea.Batch(cmds...)
+			}
+			m.loading = true
+			m.loadingStart = time.Now()
+			m.loadingLabel = "Loading lockable resources"
+			m.status = "Loading lockable resources..."
+			return m, tea.Batch(append(cmds, loadLocksCmd(m.ctx, m.client))...)
+		case key.Matches(km, m.keys.ViewConfig):
+			if m.jobs.SettingFilter() {
+				return m, tea.Batch(cmds...)
+			}
+			item, ok := m.jobs.SelectedItem().(listItem)
+			if !ok || item.kind != models.JobNodeJob || m.client == nil {
+				return m, tea.Batch(cmds...)
+			}
+			job := models.JobRef{Name: item.name, FullName: item.fullName, URL: item.id}
+			m.loading = true
+			m.loadingStart = time.Now()
+			m.loadingLabel = "Loading config.xml"
+			m.status = "Loading config.xml..."
+			return m, tea.Batch(append(cmds, loadJobConfigCmd(m.ctx, m.client, job))...)
+		case key.Matches(km, m.keys.JumpUp):
+			if m.jobs.SettingFilter() {
+				return m, tea.Batch(cmds...)
+			}
+			if len(m.jobFolders) == 0 {
+				m.status = "Already at the root"
+				return m, tea.Batch(cmds...)
+			}
+			m.crumbActive = true
+			m.crumbCursor = len(m.jobFolders) - 1
+			m.status = "Jump to folder: " + ui.Glyph("↑/↓", "up/down") + " or 0-9, enter jumps, esc cancels"
+			return m, tea.Batch(cmds...)
+		case key.Matches(km, m.keys.Bookmark):
+			if m.jobs.SettingFilter() {
+				return m, tea.Batch(cmds...)
+			}
+			m.toggleBookmark()
+			return m, tea.Batch(cmds...)
+		case key.Matches(km, m.keys.TriggerFolder):
+			if m.jobs.SettingFilter() {
+				return m, tea.Batch(cmds...)
+			}
+			return m, tea.Batch(append(cmds, m.askFolderRun())...)
+		case key.Matches(km, m.keys.Watch):
+			if m.jobs.SettingFilter() {
+				return m, tea.Batch(cmds...)
+			}
+			return m, tea.Batch(append(cmds, m.toggleWatch())...)
+		case key.Matches(km, m.keys.Watches):
+			if m.jobs.SettingFilter() {
+				return m, tea.Batch(cmds...)
+			}
+			return m, m.openWatches(cmds)
+		case key.Matches(km, m.keys.Bookmarks):
+			if m.jobs.SettingFilter() || m.target == nil {
+				return m, tea.Batch(cmds...)
+			}
+			if len(m.target.Bookmarks) == 0 {
+				m.status = "No bookmarks for " + m.target.Name + "; press " + firstKey(m.keys.Bookmark) + " in a folder to add one"
+				return m, tea.Batch(cmds...)
+			}
+			m.bookmarksOpen = true
+			m.bookmarkCursor = 0
+			m.status = "Bookmarks: " + ui.Glyph("↑/↓", "up/down") + " or 0-9, enter opens, " + firstKey(m.keys.RemoveBookmark) + " removes, esc closes"
+			return m, tea.Batch(cmds...)
+		case key.Matches(km, m.keys.GlobalSearch):
 			if m.jobs.SettingFilter() {
 				return m, tea.Batch(cmds...)
 			}
@@ -575,7 +2137,7 @@ func (m *model) updateJobs(msg tea.Msg, cmds []tea.Cmd) (tea.Model, tea.Cmd) {
 			m.searchQuery = ""
 			m.search.SetItems(nil)
 			m.search.Title = "Global Job Search"
-			m.status = "Type to search jobs across this Jenkins server"
+			m.status = "Type to search jobs across this Jenkins server; narrow with type:pipeline, in:. or result:failed"
 			return m, m.transition(screenGlobalSearch, cmds...)
 		}
 	}
@@ -602,8 +2164,16 @@ func (m *model) updateGlobalSearch(msg tea.Msg, cmds []tea.Cmd) (tea.Model, tea.
 		if !ok {
 			return m, tea.Batch(cmds...)
 		}
+		if item.kind == models.JobNodeFolder {
+			_, cmd := m.openFolder(item.fullName, cmds)
+			return m, m.transition(screenJobs, cmd)
+		}
+		if m.offlineRefusesTrigger(item.name) {
+			return m, tea.Batch(cmds...)
+		}
 		job := models.JobRef{Name: item.name, FullName: item.fullName, URL: item.id}
 		m.selectedJob = &job
+		m.paramPrefill = nil
 		m.paramsBackTo = screenGlobalSearch
 		m.loading = true
 		m.loadingStart = time.Now()
@@ -611,9 +2181,7 @@ func (m *model) updateGlobalSearch(msg tea.Msg, cmds []tea.Cmd) (tea.Model, tea.
 		m.status = "Loading pipeline parameters..."
 		return m, tea.Batch(append(cmds, loadParamsCmd(m.ctx, m.client, job.URL))...)
 	case "backspace":
-		if m.searchInput != "" {
-			m.searchInput = m.searchInput[:len(m.searchInput)-1]
-		}
+		m.searchInput = trimLastRune(m.searchInput)
 	case "r":
 		if strings.TrimSpace(m.searchInput) == "" {
 			return m, tea.Batch(cmds...)
@@ -625,7 +2193,12 @@ func (m *model) updateGlobalSearch(msg tea.Msg, cmds []tea.Cmd) (tea.Model, tea.
 	}
 	m.searchQuery = strings.TrimSpace(m.searchInput)
 	m.search.Title = "Global Job Search: " + m.searchQuery
-	if len(m.searchQuery) < 2 {
+	query, err := search.Parse(m.searchQuery, m.currentJobsPrefix())
+	if err != nil {
+		m.status = err.Error()
+		return m, tea.Batch(cmds...)
+	}
+	if text, _ := query.Remote(); query.Empty() || (text != "" && len(text) < 2) {
 		m.search.SetItems(nil)
 		m.status = "Type at least 2 characters"
 		return m, tea.Batch(cmds...)
@@ -636,7 +2209,11 @@ func (m *model) updateGlobalSearch(msg tea.Msg, cmds []tea.Cmd) (tea.Model, tea.
 	m.loadingStart = time.Now()
 	m.loadingLabel = "Searching jobs"
 	m.status = "Searching jobs..."
-	return m, tea.Batch(append(cmds, loadSearchCmd(m.ctx, m.client, m.searchQuery, reqID))...)
+	var bookmarks []string
+	if m.target != nil {
+		bookmarks = m.target.Bookmarks
+	}
+	return m, tea.Batch(append(cmds, loadSearchCmd(m.ctx, m.cfg.CacheDir, m.client, query, bookmarks, reqID))...)
 }
 
 func (m *model) updateParams(msg tea.Msg, cmds []tea.Cmd) (tea.Model, tea.Cmd) {
@@ -658,9 +2235,8 @@ func (m *model) updateParams(msg tea.Msg, cmds []tea.Cmd) (tea.Model, tea.Cmd) {
 			m.buildParamForm()
 			return m, tea.Batch(append(cmds, m.paramForm.Init())...)
 		}
-		m.buildPreviewTable()
 		m.status = fmt.Sprintf("%d permutations ready", len(m.permutations))
-		return m, m.transition(screenPreview, cmds...)
+		return m, m.openPreview(cmds)
 	}
 	return m, tea.Batch(cmds...)
 }
@@ -672,36 +2248,265 @@ func (m *model) updatePreview(msg tea.Msg, cmds []tea.Cmd) (tea.Model, tea.Cmd)
 	if km, ok := msg.(tea.KeyMsg); ok {
 		switch km.String() {
 		case "enter":
-			m.startRun()
-			return m, m.transition(screenRun, append(cmds, startRunCmd(m.runCtx, m.client, m.selectedJob.URL, m.permutations, concurrencyCap))...)
+			if len(m.lockWarnings) > 0 {
+				return m, tea.Batch(append(cmds, m.askConfirm(
+					fmt.Sprintf("Trigger %d builds despite resource contention?", len(m.permutations)),
+					strings.Join(m.lockWarnings, "\n"),
+					m.launchRun,
+					nil,
+				))...)
+			}
+			if n := len(m.permutations); n > confirmBuildsAbove {
+				return m, tea.Batch(append(cmds, m.askConfirm(
+					fmt.Sprintf("Trigger %d builds?", n),
+					fmt.Sprintf("%s will be started %d times, %d at a time.", selectedJobLabel(m.selectedJob), n, concurrencyCap),
+					m.launchRun,
+					nil,
+				))...)
+			}
+			return m, tea.Batch(append(cmds, m.launchRun())...)
 		case "esc", "backspace":
 			m.buildParamForm()
 			return m, m.transition(screenParams, append(cmds, m.paramForm.Init())...)
 		}
+		if key.Matches(km, m.keys.EditMatrix) && len(m.permutations) > 0 {
+			m.status = "Editing run matrix..."
+			return m, tea.Batch(append(cmds, m.editText(formatRunMatrix(m.permutations), "runs-*.jsonl", func(edited string, err error) tea.Msg {
+				return matrixEditedMsg{text: edited, err: err}
+			}))...)
+		}
+	}
+	return m, tea.Batch(cmds...)
+}
+
+func (m *model) updateRun(msg tea.Msg, cmds []tea.Cmd) (tea.Model, tea.Cmd) {
+	b := m.batch
+	if b == nil {
+		return m, tea.Batch(cmds...)
+	}
+	var cmd tea.Cmd
+	b.table, cmd = b.table.Update(msg)
+	cmds = append(cmds, cmd)
+	if km, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case key.Matches(km, m.keys.OpenURL):
+			idx := b.table.Cursor()
+			if idx >= 0 && idx < len(b.records) {
+				url := b.records[idx].BuildURL
+				if url != "" {
+					_ = browser.Open(b.client.ExternalURL(url))
+				}
+			}
+		case key.Matches(km, m.keys.MarkRun):
+			idx := b.table.Cursor()
+			if idx < 0 || idx >= len(b.records) {
+				return m, tea.Batch(cmds...)
+			}
+			if b.marks[idx] {
+				delete(b.marks, idx)
+			} else {
+				if len(b.marks) >= 2 {
+					m.status = "Only two runs can be marked for diff; unmark one first"
+					return m, tea.Batch(cmds...)
+				}
+				b.marks[idx] = true
+			}
+			m.refreshRunTable(b)
+		case key.Matches(km, m.keys.DiffRuns):
+			return m, tea.Batch(append(cmds, m.diffMarkedRunsCmd())...)
+		case key.Matches(km, m.keys.ViewLog):
+			idx := b.table.Cursor()
+			if idx < 0 || idx >= len(b.records) {
+				return m, tea.Batch(cmds...)
+			}
+			return m, m.openConsole(b.client, runBuildLog(b, b.records[idx]), cmds)
+		case key.Matches(km, m.keys.Stages):
+			idx := b.table.Cursor()
+			if idx < 0 || idx >= len(b.records) {
+				return m, tea.Batch(cmds...)
+			}
+			return m, m.openStages(b.client, runBuildLog(b, b.records[idx]), cmds)
+		case key.Matches(km, m.keys.SaveLog):
+			idx := b.table.Cursor()
+			if idx < 0 || idx >= len(b.records) {
+				return m, tea.Batch(cmds...)
+			}
+			return m, m.saveLogs(b.client, []buildLog{runBuildLog(b, b.records[idx])}, cmds)
+		case key.Matches(km, m.keys.SaveFailedLogs):
+			var failed []buildLog
+			for _, r := range b.records {
+				if r.State == models.RunFailed {
+					failed = append(failed, runBuildLog(b, r))
+				}
+			}
+			if len(failed) == 0 {
+				m.status = "No failed runs in this batch"
+				return m, tea.Batch(cmds...)
+			}
+			return m, m.saveLogs(b.client, failed, cmds)
+		case km.String() == "esc" || km.String() == "backspace":
+			if m.screen == screenDone && b.indexing {
+				return m, tea.Batch(append(cmds, m.loadCurrentFolderCmd(true))...)
+			}
+			m.status = ""
+			if m.screen == screenRun {
+				m.status = "Batch continues in the background; " + firstKey(m.keys.ShowRuns) + " lists batches"
+			}
+			return m, m.transition(screenJobs, cmds...)
+		case key.Matches(km, m.keys.Rerun):
+			if m.screen != screenDone {
+				return m, tea.Batch(cmds...)
+			}
+			if m.client == nil || b.client == nil || m.client.CacheKey() != b.client.CacheKey() {
+				m.status = "Connect to " + b.client.Host() + " again to rerun this batch"
+				return m, tea.Batch(cmds...)
+			}
+			if b.indexing {
+				folder := models.JobNode{Name: b.job.Name, FullName: b.job.FullName, URL: b.job.URL, Kind: models.JobNodeFolder, Multibranch: true}
+				return m, m.startIndexingRun(folder, cmds)
+			}
+			job := b.job
+			m.selectedJob = &job
+			m.rebuildFailedOnly()
+			return m, m.openPreview(cmds)
+		}
+	}
+	return m, tea.Batch(cmds...)
+}
+
+func (m *model) updateHistory(msg tea.Msg, cmds []tea.Cmd) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	m.historyTable, cmd = m.historyTable.Update(msg)
+	cmds = append(cmds, cmd)
+	km, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, tea.Batch(cmds...)
+	}
+	switch {
+	case km.String() == "esc" || km.String() == "backspace":
+		if m.historyBackTo == screenWatch {
+			m.refreshWatchTable()
+			return m, m.transition(screenWatch, cmds...)
+		}
+		return m, m.transition(screenJobs, cmds...)
+	case key.Matches(km, m.keys.OpenURL):
+		if build, ok := m.selectedBuild(); ok && build.URL != "" && m.client != nil {
+			_ = browser.Open(m.client.ExternalURL(build.URL))
+		}
+	case key.Matches(km, m.keys.ViewLog):
+		if build, ok := m.selectedBuild(); ok && m.historyJob != nil {
+			return m, m.openConsole(m.client, buildLog{job: *m.historyJob, number: build.Number, url: build.URL}, cmds)
+		}
+	case key.Matches(km, m.keys.MarkBuild):
+		m.toggleBuildMark()
+	case key.Matches(km, m.keys.CompareBuilds):
+		return m, m.compareMarkedBuilds(cmds)
+	case key.Matches(km, m.keys.Stages):
+		if build, ok := m.selectedBuild(); ok && m.historyJob != nil {
+			return m, m.openStages(m.client, buildLog{job: *m.historyJob, number: build.Number, url: build.URL}, cmds)
+		}
+	case key.Matches(km, m.keys.SaveLog):
+		if build, ok := m.selectedBuild(); ok && m.historyJob != nil {
+			return m, m.saveLogs(m.client, []buildLog{{job: *m.historyJob, number: build.Number, url: build.URL}}, cmds)
+		}
+	case key.Matches(km, m.keys.Replay):
+		build, ok := m.selectedBuild()
+		if !ok || m.historyJob == nil || m.client == nil {
+			return m, tea.Batch(cmds...)
+		}
+		return m, tea.Batch(append(cmds, m.askReplay(replayTarget{job: *m.historyJob, url: build.URL, label: fmt.Sprintf("#%d", build.Number)}))...)
+	case key.Matches(km, m.keys.Rebuild):
+		build, ok := m.selectedBuild()
+		if !ok || m.historyJob == nil || m.offlineRefusesTrigger(m.historyJob.Name) {
+			return m, tea.Batch(cmds...)
+		}
+		job := *m.historyJob
+		m.selectedJob = &job
+		m.paramPrefill = build.Params
+		m.paramsBackTo = screenHistory
+		m.loading = true
+		m.loadingStart = time.Now()
+		m.loadingLabel = "Loading pipeline parameters"
+		m.status = fmt.Sprintf("Loading parameters from build #%d...", build.Number)
+		return m, tea.Batch(append(cmds, loadParamsCmd(m.ctx, m.client, job.URL))...)
+	}
+	return m, tea.Batch(cmds...)
+}
+
+func runBuildLog(b *runBatch, r models.RunRecord) buildLog {
+	if r.Spec.Job != nil {
+		return buildLog{job: *r.Spec.Job, number: r.BuildNumber, url: r.BuildURL}
+	}
+	return buildLog{job: b.job, number: r.BuildNumber, url: r.BuildURL}
+}
+
+func (m *model) selectedBuild() (models.BuildSummary, bool) {
+	idx := m.historyTable.Cursor()
+	if idx < 0 || idx >= len(m.builds) {
+		return models.BuildSummary{}, false
+	}
+	return m.builds[idx], true
+}
+
+func (m *model) diffMarkedRunsCmd() tea.Cmd {
+	b := m.batch
+	marked := make([]models.RunRecord, 0, 2)
+	for _, r := range b.records {
+		if b.marks[r.Index] {
+			marked = append(marked, r)
+		}
+	}
+	if len(marked) != 2 {
+		m.status = "Mark exactly two runs with m to diff their console logs"
+		return nil
+	}
+	if marked[0].BuildURL == "" || marked[1].BuildURL == "" {
+		m.status = "Both marked runs need a started build to diff"
+		return nil
+	}
+	m.loading = true
+	m.loadingStart = time.Now()
+	m.loadingLabel = "Fetching console logs"
+	m.status = "Fetching console logs..."
+	return loadLogDiffCmd(m.ctx, b.client, marked[0], marked[1])
+}
+
+func (m *model) updateLogDiff(msg tea.Msg, cmds []tea.Cmd) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	m.logDiff, cmd = m.logDiff.Update(msg)
+	cmds = append(cmds, cmd)
+	if km, ok := msg.(tea.KeyMsg); ok {
+		switch km.String() {
+		case "esc", "backspace":
+			return m, m.transition(m.diffBackTo, cmds...)
+		}
+	}
+	return m, tea.Batch(cmds...)
+}
+
+func (m *model) updateJobConfig(msg tea.Msg, cmds []tea.Cmd) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	m.configView, cmd = m.configView.Update(msg)
+	cmds = append(cmds, cmd)
+	if km, ok := msg.(tea.KeyMsg); ok {
+		switch km.String() {
+		case "esc", "backspace":
+			m.status = ""
+			return m, m.transition(screenJobs, cmds...)
+		}
 	}
 	return m, tea.Batch(cmds...)
 }
 
-func (m *model) updateRun(msg tea.Msg, cmds []tea.Cmd) (tea.Model, tea.Cmd) {
+func (m *model) updateLocks(msg tea.Msg, cmds []tea.Cmd) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
-	m.runTable, cmd = m.runTable.Update(msg)
+	m.locksView, cmd = m.locksView.Update(msg)
 	cmds = append(cmds, cmd)
 	if km, ok := msg.(tea.KeyMsg); ok {
 		switch km.String() {
-		case "o":
-			idx := m.runTable.Cursor()
-			if idx >= 0 && idx < len(m.runRecords) {
-				url := m.runRecords[idx].BuildURL
-				if url != "" {
-					_ = browser.Open(url)
-				}
-			}
-		case "r":
-			if m.screen == screenDone {
-				m.rebuildFailedOnly()
-				m.buildPreviewTable()
-				return m, m.transition(screenPreview, cmds...)
-			}
+		case "esc", "backspace":
+			m.status = ""
+			return m, m.transition(screenJobs, cmds...)
 		}
 	}
 	return m, tea.Batch(cmds...)
@@ -715,59 +2520,405 @@ func (m *model) updateManageTargets(msg tea.Msg, cmds []tea.Cmd) (tea.Model, tea
 	if !ok {
 		return m, tea.Batch(cmds...)
 	}
-	switch km.String() {
-	case "esc":
+	switch {
+	case km.String() == "esc":
 		if m.manage.SettingFilter() {
 			return m, tea.Batch(cmds...)
 		}
 		m.refreshServerItems()
 		return m, m.transition(screenServers, cmds...)
-	case "backspace":
+	case km.String() == "backspace":
 		if m.manage.SettingFilter() {
 			return m, tea.Batch(cmds...)
 		}
 		m.refreshServerItems()
 		return m, m.transition(screenServers, cmds...)
-	case "a":
+	case key.Matches(km, m.keys.AddServer):
 		m.startManageForm(manageModeAdd, -1)
 		return m, m.transition(screenManageForm, append(cmds, m.manageForm.Init())...)
-	case "enter", "e":
+	case key.Matches(km, m.keys.Open, m.keys.EditServer):
 		idx := m.selectedManageTargetIndex()
 		if idx < 0 {
 			return m, tea.Batch(cmds...)
 		}
 		m.startManageForm(manageModeEdit, idx)
 		return m, m.transition(screenManageForm, append(cmds, m.manageForm.Init())...)
-	case "t":
+	case key.Matches(km, m.keys.RotateToken):
 		idx := m.selectedManageTargetIndex()
 		if idx < 0 {
 			return m, tea.Batch(cmds...)
 		}
-		m.startManageForm(manageModeRotate, idx)
-		return m, m.transition(screenManageForm, append(cmds, m.manageForm.Init())...)
-	case "d":
+		return m, tea.Batch(append(cmds, m.startRotateToken(idx))...)
+	case key.Matches(km, m.keys.MoveToken):
 		idx := m.selectedManageTargetIndex()
 		if idx < 0 {
 			return m, tea.Batch(cmds...)
 		}
-		if err := m.deleteTargetAt(idx); err != nil {
-			m.err = err
-			m.status = "Failed to delete server"
-		} else {
-			m.err = nil
-			m.status = "Deleted server"
+		return m, tea.Batch(append(cmds, m.startMoveToken(idx))...)
+	case key.Matches(km, m.keys.DeleteServer):
+		idx := m.selectedManageTargetIndex()
+		if idx < 0 {
+			return m, tea.Batch(cmds...)
 		}
-		return m, tea.Batch(cmds...)
+		return m, tea.Batch(append(cmds, m.confirmDeleteTarget(idx, nil))...)
+	case key.Matches(km, m.keys.EditConfig):
+		if m.manage.SettingFilter() {
+			return m, tea.Batch(cmds...)
+		}
+		return m, tea.Batch(append(cmds, m.editConfigFile())...)
+	case key.Matches(km, m.keys.UndoConfig):
+		if m.manage.SettingFilter() {
+			return m, tea.Batch(cmds...)
+		}
+		return m, tea.Batch(append(cmds, m.confirmUndoConfig())...)
+	case key.Matches(km, m.keys.ImportServers):
+		if m.manage.SettingFilter() {
+			return m, tea.Batch(cmds...)
+		}
+		return m, tea.Batch(append(cmds, m.importServers())...)
+	case key.Matches(km, m.keys.TestConn):
+		idx := m.selectedManageTargetIndex()
+		if idx < 0 {
+			return m, tea.Batch(cmds...)
+		}
+		return m, tea.Batch(append(cmds, m.testConnection(m.cfg.Jenkins[idx]))...)
 	}
 	return m, tea.Batch(cmds...)
 }
 
+func (m *model) updateConnTest(msg tea.Msg, cmds []tea.Cmd) (tea.Model, tea.Cmd) {
+	if km, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case km.String() == "esc" || km.String() == "backspace":
+			m.status = ""
+			return m, m.transition(m.connBackTo, cmds...)
+		case key.Matches(km, m.keys.TestConn):
+			if t := m.findTargetByID(m.connTarget); t != nil {
+				return m, tea.Batch(append(cmds, m.testConnection(*t))...)
+			}
+			return m, tea.Batch(cmds...)
+		}
+	}
+	var cmd tea.Cmd
+	m.connView, cmd = m.connView.Update(msg)
+	return m, tea.Batch(append(cmds, cmd)...)
+}
+
+const (
+	adminQuietDown       = "quiet down"
+	adminCancelQuietDown = "cancel quiet-down on"
+	adminSafeRestart     = "safe-restart"
+	adminCancel          = "cancel"
+)
+
+var adminActionDone = map[string]string{
+	adminQuietDown:       "Quieting down",
+	adminCancelQuietDown: "Cancelled quiet-down on",
+	adminSafeRestart:     "Safe restart scheduled on",
+}
+
+// checkAdmin confirms the account may administer t before offering the
+// quiet-down and restart actions.
+func (m *model) checkAdmin(t models.JenkinsTarget) tea.Cmd {
+	token, err := m.creds.Resolve(t)
+	if errors.Is(err, credentials.ErrAuthRequired) {
+		m.status = "Open " + t.Name + " once to sign in, then try again"
+		return nil
+	}
+	if err != nil {
+		m.err = err
+		m.status = "Failed to resolve server credentials"
+		return nil
+	}
+	client := jenkins.NewClient(t, token, m.cfg.Timeout)
+	m.err = nil
+	m.loading = true
+	m.loadingStart = time.Now()
+	m.loadingLabel = "Checking admin rights on " + t.Name
+	m.status = m.loadingLabel + "..."
+	ctx := m.ctx
+	return func() tea.Msg {
+		admin, quieting, err := client.AdminStatus(ctx)
+		return adminStatusMsg{target: t, client: client, admin: admin, quietingDown: quieting, err: err}
+	}
+}
+
+func (m *model) askAdminAction(st adminStatusMsg) tea.Cmd {
+	desc := st.target.Host + " is accepting new builds."
+	options := []huh.Option[string]{huh.NewOption("Quiet down (finish running builds, start no new ones)", adminQuietDown)}
+	if st.quietingDown {
+		desc = st.target.Host + " is quieting down."
+		options = []huh.Option[string]{huh.NewOption("Cancel quiet-down", adminCancelQuietDown)}
+	}
+	options = append(options,
+		huh.NewOption("Safe restart (once running builds finish)", adminSafeRestart),
+		huh.NewOption("Cancel", adminCancel),
+	)
+	return m.askChoice("Admin: "+st.target.Name, desc, options, func(action string) tea.Cmd {
+		if action == adminCancel {
+			m.status = "Cancelled"
+			return nil
+		}
+		return m.askConfirm(
+			strings.ToUpper(action[:1])+action[1:]+" "+st.target.Name+"?",
+			"This affects everyone using "+st.target.Host+".",
+			func() tea.Cmd { return m.runAdminAction(st.target, st.client, action) },
+			nil)
+	})
+}
+
+func (m *model) runAdminAction(t models.JenkinsTarget, client *jenkins.Client, action string) tea.Cmd {
+	m.loading = true
+	m.loadingStart = time.Now()
+	m.loadingLabel = strings.ToUpper(action[:1]) + action[1:] + " " + t.Name
+	m.status = m.loadingLabel + "..."
+	ctx := m.ctx
+	return func() tea.Msg {
+		var err error
+		switch action {
+		case adminQuietDown:
+			err = client.QuietDown(ctx)
+		case adminCancelQuietDown:
+			err = client.CancelQuietDown(ctx)
+		case adminSafeRestart:
+			err = client.SafeRestart(ctx)
+		}
+		return adminActionMsg{target: t, action: action, err: err}
+	}
+}
+
+const (
+	replayAsIs   = "as-is"
+	replayEdit   = "edit"
+	replayCancel = "cancel"
+)
+
+func (m *model) askReplay(t replayTarget) tea.Cmd {
+	options := []huh.Option[string]{
+		huh.NewOption("Replay as is", replayAsIs),
+		huh.NewOption("Edit the Pipeline script in $EDITOR first", replayEdit),
+		huh.NewOption("Cancel", replayCancel),
+	}
+	title := "Replay " + t.label + " of " + jobsPathLabel(t.job.FullName)
+	return m.askChoice(title, "Runs the Pipeline again with the same parameters.", options, func(choice string) tea.Cmd {
+		switch choice {
+		case replayAsIs:
+			return m.startReplay(t, nil)
+		case replayEdit:
+			m.loading = true
+			m.loadingStart = time.Now()
+			m.loadingLabel = "Loading Pipeline script"
+			m.status = "Loading Pipeline script..."
+			return loadReplayScriptsCmd(m.ctx, m.client, t)
+		}
+		m.status = "Cancelled"
+		return nil
+	})
+}
+
+// editReplayScript hands the main script to the user's editor and reads it
+// back once the editor exits.
+func (m *model) editReplayScript(t replayTarget, scripts jenkins.ReplayScripts) tea.Cmd {
+	m.status = "Editing Pipeline script..."
+	return m.editText(scripts.Main, "replay-*.groovy", func(edited string, err error) tea.Msg {
+		if err != nil {
+			return replayEditedMsg{target: t, err: err}
+		}
+		original := scripts.Main
+		scripts.Main = edited
+		return replayEditedMsg{target: t, scripts: scripts, changed: edited != original}
+	})
+}
+
+// startReplay submits the replay; scripts nil reuses the original ones.
+func (m *model) startReplay(t replayTarget, scripts *jenkins.ReplayScripts) tea.Cmd {
+	m.loading = true
+	m.loadingStart = time.Now()
+	m.loadingLabel = "Replaying " + t.label
+	m.status = m.loadingLabel + "..."
+	ctx, client := m.ctx, m.client
+	return func() tea.Msg {
+		n, err := client.Replay(ctx, t.job.URL, t.url, scripts)
+		return replayDoneMsg{target: t, number: n, err: err}
+	}
+}
+
+// testConnection diagnoses t one layer at a time in the background. A token
+// that needs an interactive sign-in limits the test to the network layers.
+func (m *model) testConnection(t models.JenkinsTarget) tea.Cmd {
+	token, credErr := m.creds.Resolve(t)
+	client := jenkins.NewClient(t, token, m.cfg.Timeout)
+	m.connTarget = t.ID
+	m.err = nil
+	m.loading = true
+	m.loadingStart = time.Now()
+	m.loadingLabel = "Testing connection to " + t.Name
+	m.status = m.loadingLabel + "..."
+	ctx := m.ctx
+	return func() tea.Msg {
+		return connTestMsg{target: t, credErr: credErr, checks: client.Diagnose(ctx, credErr == nil)}
+	}
+}
+
+func renderConnTest(res connTestMsg) string {
+	creds := jenkins.Check{Name: "credentials", Status: jenkins.CheckOK, Detail: "token from " + string(res.target.Credential.Type)}
+	switch {
+	case errors.Is(res.credErr, credentials.ErrAuthRequired):
+		creds = jenkins.Check{Name: "credentials", Status: jenkins.CheckSkipped, Detail: "token comes from auth_command; open the server once to sign in, then test again"}
+	case res.credErr != nil:
+		creds = jenkins.Check{Name: "credentials", Status: jenkins.CheckFailed, Detail: res.credErr.Error(), Hint: "edit the server (e) or rotate its token (t)"}
+	}
+	var b strings.Builder
+	b.WriteString(ui.Title.Render("Connection test: "+res.target.Name) + " " + ui.Muted.Render(res.target.Host) + "\n\n")
+	for _, c := range append([]jenkins.Check{creds}, res.checks...) {
+		var mark string
+		switch c.Status {
+		case jenkins.CheckOK:
+			mark = ui.Success.Render(ui.Glyph("✓", "+"))
+		case jenkins.CheckWarn:
+			mark = ui.Warn.Render("!")
+		case jenkins.CheckFailed:
+			mark = ui.Danger.Render(ui.Glyph("✗", "x"))
+		default:
+			mark = ui.Muted.Render("-")
+		}
+		line := fmt.Sprintf("  %s %-12s %s", mark, c.Name, c.Detail)
+		if c.Duration >= time.Millisecond {
+			line += ui.Muted.Render(" (" + c.Duration.Round(time.Millisecond).String() + ")")
+		}
+		b.WriteString(line + "\n")
+		if c.Hint != "" {
+			b.WriteString(ui.Muted.Render("                 "+ui.Glyph("→", "->")+" "+c.Hint) + "\n")
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func renderLocks(resources []models.LockableResource) string {
+	if len(resources) == 0 {
+		return ui.Muted.Render("No lockable resources are defined on this server.")
+	}
+	nameWidth := 8
+	for _, r := range resources {
+		nameWidth = max(nameWidth, len(r.Name))
+	}
+	var b strings.Builder
+	b.WriteString(ui.Muted.Render(fmt.Sprintf("  %-*s  %-8s  %-28s  %s", nameWidth, "RESOURCE", "STATE", "HELD BY", "LABELS")) + "\n")
+	for _, r := range resources {
+		state, holder := ui.Success.Render(fmt.Sprintf("%-8s", "free")), ""
+		switch {
+		case r.Locked:
+			state, holder = ui.Danger.Render(fmt.Sprintf("%-8s", "locked")), r.BuildName
+		case r.Reserved:
+			state, holder = ui.Warn.Render(fmt.Sprintf("%-8s", "reserved")), r.ReservedBy
+		}
+		b.WriteString(fmt.Sprintf("  %-*s  %s  %-28s  %s\n", nameWidth, r.Name, state, clip(holder, 28), ui.Muted.Render(strings.Join(r.Labels, " "))))
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// lockContention warns about resources the runs will wait on: ones already
+// held, and ones that several permutations need at once. Resource names may
+// reference build parameters, so they are expanded per permutation.
+func lockContention(requests []jenkins.ResourceRequest, resources []models.LockableResource, specs []models.JobSpec) []string {
+	byName := map[string]models.LockableResource{}
+	for _, r := range resources {
+		byName[r.Name] = r
+	}
+	users := map[string][]int{}
+	labelUsers := map[string][]int{}
+	for i, spec := range specs {
+		for _, req := range requests {
+			for _, name := range req.Names {
+				name = os.Expand(name, func(k string) string {
+					if v, ok := spec.Params[strings.TrimPrefix(k, "params.")]; ok {
+						return v
+					}
+					return "${" + k + "}"
+				})
+				if !slices.Contains(users[name], i+1) {
+					users[name] = append(users[name], i+1)
+				}
+			}
+			if req.Label != "" && !slices.Contains(labelUsers[req.Label], i+1) {
+				labelUsers[req.Label] = append(labelUsers[req.Label], i+1)
+			}
+		}
+	}
+	var warnings []string
+	for _, name := range sortedKeys(users) {
+		runs := users[name]
+		r, known := byName[name]
+		switch {
+		case known && r.Locked && r.BuildName != "":
+			warnings = append(warnings, fmt.Sprintf("%s is locked by %s", name, r.BuildName))
+		case known && r.Locked:
+			warnings = append(warnings, name+" is locked")
+		case known && r.Reserved:
+			warnings = append(warnings, fmt.Sprintf("%s is reserved by %s", name, r.ReservedBy))
+		}
+		if len(runs) > 1 {
+			warnings = append(warnings, fmt.Sprintf("%s is needed by runs %s; they will run one at a time", name, joinInts(runs)))
+		}
+	}
+	for _, label := range sortedKeys(labelUsers) {
+		free := 0
+		for _, r := range resources {
+			if slices.Contains(r.Labels, label) && !r.Locked && !r.Reserved {
+				free++
+			}
+		}
+		if runs := labelUsers[label]; len(runs) > free {
+			warnings = append(warnings, fmt.Sprintf("label %s has %d free resource(s) for %d run(s)", label, free, len(runs)))
+		}
+	}
+	return warnings
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func joinInts(ns []int) string {
+	parts := make([]string, len(ns))
+	for i, n := range ns {
+		parts[i] = strconv.Itoa(n)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func connTestStatus(res connTestMsg) string {
+	if res.credErr != nil && !errors.Is(res.credErr, credentials.ErrAuthRequired) {
+		return "Connection test failed at credentials"
+	}
+	for _, c := range res.checks {
+		if c.Status == jenkins.CheckFailed {
+			return "Connection test failed at " + c.Name
+		}
+	}
+	return "Connection test passed"
+}
+
 func (m *model) updateManageForm(msg tea.Msg, cmds []tea.Cmd) (tea.Model, tea.Cmd) {
 	if km, ok := msg.(tea.KeyMsg); ok {
+		cmds = append(cmds, m.scheduleTokenCheck())
+		if km.String() == "ctrl+o" {
+			m.openTokenPage()
+			return m, tea.Batch(cmds...)
+		}
+		if km.String() == "ctrl+g" {
+			return m, tea.Batch(append(cmds, m.askGenerateToken())...)
+		}
 		if km.String() == "esc" {
 			m.manageForm = nil
+			m.setupWizard = false
 			if len(m.cfg.Jenkins) == 0 {
-				m.status = "No Jenkins servers configured. Press a to add one."
+				m.status = "No Jenkins servers configured. Press a to add one or I to import them."
 			}
 			return m, m.transition(screenServers, cmds...)
 		}
@@ -783,32 +2934,249 @@ func (m *model) updateManageForm(msg tea.Msg, cmds []tea.Cmd) (tea.Model, tea.Cm
 	if m.manageForm.State != huh.StateCompleted {
 		return m, tea.Batch(cmds...)
 	}
+	if m.manageMode == manageModeRotate && m.manageIndex >= 0 && m.manageIndex < len(m.cfg.Jenkins) &&
+		m.cfg.Jenkins[m.manageIndex].Credential.Type == models.CredentialTypeKeyring {
+		target := m.cfg.Jenkins[m.manageIndex]
+		return m, tea.Batch(append(cmds, m.askConfirm(
+			"Overwrite the stored token for "+target.Name+"?",
+			"The current token in "+target.Credential.Ref+" is replaced and cannot be recovered.",
+			m.finishManageForm,
+			func() tea.Cmd {
+				m.manageForm = nil
+				m.status = "Token rotation cancelled"
+				return m.transition(screenServers)
+			},
+		))...)
+	}
+	return m, tea.Batch(append(cmds, m.finishManageForm())...)
+}
+
+// finishManageForm applies the completed server form and returns to the
+// server list, or reopens the form with the error.
+func (m *model) finishManageForm() tea.Cmd {
 	if err := m.applyManageForm(); err != nil {
 		m.err = err
 		m.status = "Failed to save server"
 		m.startManageForm(m.manageMode, m.manageIndex)
-		return m, tea.Batch(append(cmds, m.manageForm.Init())...)
+		return m.manageForm.Init()
 	}
 	m.manageForm = nil
 	m.err = nil
 	m.refreshManageItems()
 	m.refreshServerItems()
-	return m, m.transition(screenServers, cmds...)
+	if m.setupWizard {
+		return m.transition(screenServers, m.checkServerHealth(false), m.finishSetupWizard())
+	}
+	return m.transition(screenServers, m.checkServerHealth(false))
 }
 
 func (m *model) refreshServerItems() {
+	tags := serverTags(m.cfg.Jenkins)
+	if m.serverTag != "" && !slices.Contains(tags, m.serverTag) {
+		m.serverTag = ""
+	}
 	items := make([]list.Item, 0, len(m.cfg.Jenkins))
-	for idx, j := range m.cfg.Jenkins {
+	for _, j := range groupedTargets(m.cfg.Jenkins) {
+		if m.serverTag != "" && !slices.Contains(j.Tags, m.serverTag) {
+			continue
+		}
 		desc := strings.TrimSpace(fmt.Sprintf("%s | %s", j.Username, j.Host))
+		if len(j.Tags) > 0 {
+			desc = "#" + strings.Join(j.Tags, " #") + " " + ui.Glyph("·", "|") + " " + desc
+		}
+		if j.PlainHTTP() {
+			desc += " " + ui.Glyph("·", "|") + " " + ui.Warn.Render(ui.Glyph("▲", "!")+" plain HTTP, token sent unencrypted")
+		}
+		glyph := ""
+		if m.rejectedTokens[j.ID] {
+			desc += "\n" + m.rejectedTokenLine(j)
+			glyph = ui.Warn.Render(ui.Glyph("▲", "!"))
+		} else if line := m.healthLine(j.ID); line != "" {
+			desc += "\n" + line
+		}
 		items = append(items, listItem{
-			title: fmt.Sprintf("%d. %s", idx+1, j.Name),
+			title: fmt.Sprintf("%d. %s", len(items)+1, j.Name),
+			glyph: glyph,
 			desc:  desc,
 			id:    j.ID,
 		})
 	}
+	m.servers.Title = "Jenkins Servers"
+	if m.serverTag != "" {
+		m.servers.Title = fmt.Sprintf("Jenkins Servers %s #%s (%d of %d)", ui.Glyph("·", "|"), m.serverTag, len(items), len(m.cfg.Jenkins))
+	}
 	m.servers.SetItems(items)
 }
 
+// serverTags lists every tag in config order of first use.
+func serverTags(targets []models.JenkinsTarget) []string {
+	var tags []string
+	for _, t := range targets {
+		for _, tag := range t.Tags {
+			if !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
+
+// groupedTargets orders targets by their first tag, groups in config order
+// of first use, untagged servers last; within a group config order is kept.
+func groupedTargets(targets []models.JenkinsTarget) []models.JenkinsTarget {
+	var groups []string
+	for _, t := range targets {
+		if len(t.Tags) > 0 && !slices.Contains(groups, t.Tags[0]) {
+			groups = append(groups, t.Tags[0])
+		}
+	}
+	out := make([]models.JenkinsTarget, 0, len(targets))
+	for _, g := range append(groups, "") {
+		for _, t := range targets {
+			primary := ""
+			if len(t.Tags) > 0 {
+				primary = t.Tags[0]
+			}
+			if primary == g {
+				out = append(out, t)
+			}
+		}
+	}
+	return out
+}
+
+// cycleServerTag steps the servers filter through the tags, then back to
+// all servers.
+func (m *model) cycleServerTag() {
+	tags := serverTags(m.cfg.Jenkins)
+	if len(tags) == 0 {
+		m.status = "No server has tags; add tags: [prod, eu] to a server in the config"
+		return
+	}
+	next := ""
+	if i := slices.Index(tags, m.serverTag); i+1 < len(tags) {
+		next = tags[i+1]
+	}
+	m.serverTag = next
+	m.refreshServerItems()
+	m.servers.Select(0)
+	m.status = "Showing all servers"
+	if next != "" {
+		m.status = "Showing servers tagged #" + next
+	}
+}
+
+// serverHealth is the last probe of a target shown on the servers screen.
+type serverHealth struct {
+	info     models.ServerHealth
+	err      error
+	checking bool
+	// noCreds marks err as a credential lookup failure, not a probe failure.
+	noCreds bool
+}
+
+// checkServerHealth probes the targets on the servers list's current page
+// that were not checked yet, or all of them when force is set. Probing only
+// what is shown keeps a long server list from resolving, and so maybe
+// prompting for, every credential at once; the rest are probed as they
+// scroll into view. Targets that need an interactive sign-in are not
+// prompted for from here.
+func (m *model) checkServerHealth(force bool) tea.Cmd {
+	shown := m.shownServerTargets()
+	var cmds []tea.Cmd
+	changed := false
+	for _, t := range m.cfg.Jenkins {
+		if !shown[t.ID] {
+			continue
+		}
+		if h, seen := m.health[t.ID]; seen && (!force || h.checking) {
+			continue
+		}
+		changed = true
+		token, err := m.creds.Resolve(t)
+		if err != nil {
+			m.health[t.ID] = serverHealth{err: err, noCreds: true}
+			continue
+		}
+		m.health[t.ID] = serverHealth{checking: true}
+		timeout := healthTimeout
+		if m.cfg.Timeout > 0 {
+			timeout = min(m.cfg.Timeout, healthTimeout)
+		}
+		client := jenkins.NewClient(t, token, timeout)
+		cmds = append(cmds, serverHealthCmd(m.ctx, client, t.ID))
+	}
+	if changed {
+		m.refreshServerItems()
+	}
+	return tea.Batch(cmds...)
+}
+
+// shownServerTargets returns the IDs of the targets on the servers list's
+// current page and the selected one.
+func (m *model) shownServerTargets() map[string]bool {
+	shown := map[string]bool{}
+	items := m.servers.VisibleItems()
+	start, end := m.servers.Paginator.GetSliceBounds(len(items))
+	for _, it := range items[start:end] {
+		if item, ok := it.(listItem); ok {
+			shown[item.id] = true
+		}
+	}
+	if item, ok := m.servers.SelectedItem().(listItem); ok {
+		shown[item.id] = true
+	}
+	return shown
+}
+
+func (m *model) healthLine(targetID string) string {
+	h, ok := m.health[targetID]
+	version := m.serverVersions[targetID]
+	switch {
+	case !ok:
+		if version != "" {
+			return "Jenkins " + version
+		}
+		return ""
+	case h.checking:
+		return "checking health..."
+	case errors.Is(h.err, credentials.ErrAuthRequired):
+		return "sign in to check health"
+	case h.noCreds:
+		return ui.Glyph("✗", "x") + " no credentials: " + h.err.Error()
+	case h.err != nil:
+		return ui.Glyph("✗", "x") + " unreachable: " + shortHealthError(h.err)
+	}
+	parts := []string{ui.Glyph("●", "*") + " up"}
+	if version != "" {
+		parts = append(parts, "Jenkins "+version)
+	}
+	if h.info.QuietingDown {
+		parts = append(parts, ui.Warn.Render("quieting down"))
+	}
+	parts = append(parts,
+		fmt.Sprintf("%d/%d executors busy", h.info.BusyExecutors, h.info.TotalExecutors),
+		fmt.Sprintf("%d queued", h.info.Queued),
+		h.info.Latency.Round(time.Millisecond).String(),
+	)
+	return strings.Join(parts, " | ")
+}
+
+var httpStatusPattern = regexp.MustCompile(`failed \((\d{3})\)`)
+
+// shortHealthError trims URLs and response bodies so the reason fits on the
+// description line.
+func shortHealthError(err error) string {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+	if m := httpStatusPattern.FindStringSubmatch(err.Error()); m != nil {
+		return "HTTP " + m[1]
+	}
+	return err.Error()
+}
+
 func (m *model) refreshManageItems() {
 	items := make([]list.Item, 0, len(m.cfg.Jenkins))
 	for _, j := range m.cfg.Jenkins {
@@ -872,11 +3240,14 @@ func (m *model) startManageForm(mode manageMode, idx int) {
 	m.manageUsername = ""
 	m.manageTokenSrc = tokenStorageKeyring
 	m.manageInsecure = "false"
+	m.manageHTTP = "false"
 	m.manageToken = ""
 	m.manageEnvVar = ""
 	m.manageKeyRef = ""
 	m.manageAdvanced = false
 	m.keyringAvail = true
+	m.tokenCheck = tokenCheck{}
+	m.tokenSeen = ""
 
 	available, err := m.creds.KeyringAvailable()
 	if err != nil || !available {
@@ -894,6 +3265,10 @@ func (m *model) startManageForm(mode manageMode, idx int) {
 			m.manageInsecure = "true"
 			m.manageAdvanced = true
 		}
+		if t.AllowInsecureHTTP {
+			m.manageHTTP = "true"
+			m.manageAdvanced = true
+		}
 		if t.Credential.Type == models.CredentialTypeEnv {
 			m.manageTokenSrc = tokenStorageEnv
 			m.manageEnvVar = t.Credential.Ref
@@ -911,11 +3286,12 @@ func (m *model) startManageForm(mode manageMode, idx int) {
 		}
 	}
 
-	if mode == manageModeRotate {
-		m.manageForm = huh.NewForm(huh.NewGroup(
-			huh.NewNote().Title("Rotate API Token").Description("Enter a new token for this server's system password manager entry."),
-			huh.NewInput().Title("API Token").Description("Stores a new token in the system password manager.").Password(true).Value(&m.manageToken),
-		).Title("Rotate API Token")).WithTheme(ui.FormTheme()).WithWidth(max(60, m.contentWidth()-8))
+	if mode == manageModeRotate && idx >= 0 && idx < len(m.cfg.Jenkins) {
+		m.manageForm = huh.NewForm(m.rotateTokenGroup(m.cfg.Jenkins[idx])).WithTheme(ui.FormTheme()).WithWidth(max(60, m.contentWidth()-8))
+		return
+	}
+	if mode == manageModeMoveToEnv && idx >= 0 && idx < len(m.cfg.Jenkins) {
+		m.manageForm = huh.NewForm(m.moveToEnvGroup(m.cfg.Jenkins[idx])).WithTheme(ui.FormTheme()).WithWidth(max(60, m.contentWidth()-8))
 		return
 	}
 
@@ -962,24 +3338,34 @@ func (m *model) startManageForm(mode manageMode, idx int) {
 			Value(&m.manageAdvanced),
 	)
 
-	coreGroup := huh.NewGroup(coreFields...).Title(formTitle)
-	keyringTokenGroup := huh.NewGroup(
-		huh.NewInput().
-			Title("API Token").
-			Description("Paste token; saved in your OS password manager").
-			Password(true).
-			Value(&m.manageToken),
-	).WithHideFunc(func() bool {
-		return !m.keyringAvail || m.manageTokenSrc != tokenStorageKeyring
-	})
-	envTokenGroup := huh.NewGroup(
+	m.tokenInput = huh.NewInput().
+		Title("API Token").
+		Description("Paste token, or press ctrl+g to generate one with your password; saved in your OS password manager").
+		Password(true).
+		Value(&m.manageToken)
+	keyringTokenFields := []huh.Field{m.tokenInput}
+	envTokenFields := []huh.Field{
 		huh.NewInput().
 			Title("Token Environment Variable").
 			Description("Variable name, e.g. JENKINS_TOKEN_PROD").
 			Value(&m.manageEnvVar),
-	).WithHideFunc(func() bool {
+	}
+	if m.setupWizard {
+		formTitle = "Step 2 of 3: Server"
+		keyringTokenFields = append([]huh.Field{m.setupTokenNote()}, keyringTokenFields...)
+		envTokenFields = append([]huh.Field{m.setupTokenNote()}, envTokenFields...)
+	}
+	coreGroup := huh.NewGroup(coreFields...).Title(formTitle)
+	keyringTokenGroup := huh.NewGroup(keyringTokenFields...).WithHideFunc(func() bool {
+		return !m.keyringAvail || m.manageTokenSrc != tokenStorageKeyring
+	})
+	envTokenGroup := huh.NewGroup(envTokenFields...).WithHideFunc(func() bool {
 		return m.manageTokenSrc != tokenStorageEnv
 	})
+	if m.setupWizard {
+		keyringTokenGroup.Title("Step 3 of 3: API token")
+		envTokenGroup.Title("Step 3 of 3: API token")
+	}
 	advancedGroup := huh.NewGroup(
 		huh.NewInput().
 			Title("Internal ID override").
@@ -993,6 +3379,14 @@ func (m *model) startManageForm(mode manageMode, idx int) {
 				huh.NewOption("true", "true"),
 			).
 			Value(&m.manageInsecure),
+		huh.NewSelect[string]().
+			Title("Allow plain HTTP").
+			Description("Only for an http:// URL you cannot change; the API token is sent unencrypted").
+			Options(
+				huh.NewOption("false", "false"),
+				huh.NewOption("true", "true"),
+			).
+			Value(&m.manageHTTP),
 	).Title("Advanced").WithHideFunc(func() bool {
 		return !m.manageAdvanced
 	})
@@ -1005,7 +3399,11 @@ func (m *model) startManageForm(mode manageMode, idx int) {
 		return !m.manageAdvanced || !m.keyringAvail || m.manageTokenSrc != tokenStorageKeyring
 	})
 
-	m.manageForm = huh.NewForm(coreGroup, keyringTokenGroup, envTokenGroup, advancedGroup, keyringAdvancedGroup).
+	groups := []*huh.Group{coreGroup, keyringTokenGroup, envTokenGroup, advancedGroup, keyringAdvancedGroup}
+	if m.setupWizard {
+		groups = append([]*huh.Group{m.setupWelcomeGroup()}, groups...)
+	}
+	m.manageForm = huh.NewForm(groups...).
 		WithTheme(ui.FormTheme()).
 		WithWidth(max(60, m.contentWidth()-8))
 }
@@ -1022,22 +3420,9 @@ func (m *model) applyManageForm() error {
 
 	switch m.manageMode {
 	case manageModeRotate:
-		if m.manageIndex < 0 || m.manageIndex >= len(m.cfg.Jenkins) {
-			return fmt.Errorf("invalid server selection")
-		}
-		target := m.cfg.Jenkins[m.manageIndex]
-		if target.Credential.Type != models.CredentialTypeKeyring {
-			return fmt.Errorf("token rotation is only available for keyring-backed credentials")
-		}
-		token := strings.TrimSpace(m.manageToken)
-		if token == "" {
-			return fmt.Errorf("API token is required.")
-		}
-		if err := m.creds.SetKeyring(target.Credential.Ref, token); err != nil {
-			return fmt.Errorf("store keyring token: %w", err)
-		}
-		m.status = "Token rotated"
-		return nil
+		return m.applyRotate()
+	case manageModeMoveToEnv:
+		return m.applyMoveToEnv()
 	}
 
 	target, err := m.buildTargetFromForm(previous)
@@ -1140,17 +3525,24 @@ func (m *model) buildTargetFromForm(previous *models.JenkinsTarget) (models.Jenk
 	}
 
 	m.manageID = id
-	return models.JenkinsTarget{
-		ID:       id,
-		Name:     name,
-		Host:     host,
-		Username: username,
-		Credential: models.Credential{
-			Type: credType,
-			Ref:  credRef,
-		},
-		InsecureSkipTLSVerify: m.manageInsecure == "true",
-	}, nil
+	// An edit starts from the saved target so settings the form doesn't
+	// show (tags, aliases, proxy, url_rewrites, auth, bookmarks, ...) are
+	// kept; only the fields the form owns are overwritten.
+	var target models.JenkinsTarget
+	if previous != nil {
+		target = *previous
+	}
+	target.ID = id
+	target.Name = name
+	target.Host = host
+	target.Username = username
+	target.Credential = models.Credential{Type: credType, Ref: credRef}
+	target.InsecureSkipTLSVerify = m.manageInsecure == "true"
+	target.AllowInsecureHTTP = m.manageHTTP == "true"
+	if target.PlainHTTP() && !target.AllowInsecureHTTP {
+		return models.JenkinsTarget{}, fmt.Errorf("%s uses plain http://, so your API token would be sent unencrypted. Use https://, or set Allow plain HTTP under Advanced settings.", host)
+	}
+	return target, nil
 }
 
 func (m *model) resolveTokenForValidation(target models.JenkinsTarget, previous *models.JenkinsTarget) (string, string, error) {
@@ -1239,28 +3631,8 @@ func deriveNameFromHost(host string) string {
 	return strings.TrimSpace(raw)
 }
 
-func slugifyID(input string) string {
-	var b strings.Builder
-	prevDash := false
-	for _, r := range strings.ToLower(strings.TrimSpace(input)) {
-		isASCIIAlphaNum := (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9')
-		if isASCIIAlphaNum {
-			b.WriteRune(r)
-			prevDash = false
-			continue
-		}
-		if unicode.IsSpace(r) || !isASCIIAlphaNum {
-			if !prevDash {
-				b.WriteByte('-')
-				prevDash = true
-			}
-		}
-	}
-	out := strings.Trim(b.String(), "-")
-	if out == "" {
-		return "target"
-	}
-	return out
+func slugifyID(input string) string {
+	return config.SlugifyID(input)
 }
 
 func defaultKeyringRef(id string) string {
@@ -1273,7 +3645,7 @@ func mapTargetValidationError(err error) error {
 	}
 	msg := strings.ToLower(err.Error())
 	switch {
-	case strings.Contains(msg, "(401)") || strings.Contains(msg, "(403)"):
+	case jenkins.IsStatus(err, http.StatusUnauthorized) || jenkins.IsStatus(err, http.StatusForbidden):
 		return fmt.Errorf("Authentication failed. Check username and API token.")
 	case strings.Contains(msg, "x509") || strings.Contains(msg, "tls") || strings.Contains(msg, "certificate"):
 		return fmt.Errorf("TLS certificate verification failed. Enable 'Skip TLS certificate verification' only if you trust this server.")
@@ -1312,14 +3684,207 @@ func (m *model) deleteTargetAt(idx int) error {
 	return nil
 }
 
+// editConfigFile opens jenkins.yaml in the user's editor, writing the
+// current configuration first when the file does not exist yet.
+func (m *model) editConfigFile() tea.Cmd {
+	path := m.cfg.ConfigPath
+	if strings.TrimSpace(path) == "" {
+		m.status = "Config path is not set"
+		return nil
+	}
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		if err := m.persistConfig(); err != nil {
+			m.err = err
+			m.status = "Failed to create " + path
+			return nil
+		}
+	}
+	m.status = "Editing " + path + "..."
+	m.editingConfig = true
+	return m.editFile(path, func(err error) tea.Msg { return configEditedMsg{err: err} })
+}
+
+// reloadConfigFile re-reads jenkins.yaml after an edit. An invalid file
+// keeps the running configuration and offers to go back to the editor.
+func (m *model) reloadConfigFile() tea.Cmd {
+	m.configStamp = statFile(m.cfg.ConfigPath)
+	cfg, err := config.Load(m.cfg.ConfigPath)
+	if err == nil {
+		err = ValidateKeybindings(cfg.Keybindings)
+	}
+	if err != nil {
+		m.status = "Kept the previous configuration"
+		return m.askConfirm(
+			filepath.Base(m.cfg.ConfigPath)+" has errors",
+			err.Error()+"\n\nEdit it again? No keeps the running configuration; the file stays as saved.",
+			m.editConfigFile,
+			nil,
+		)
+	}
+	m.err = nil
+	m.applyConfig(cfg)
+	m.status = fmt.Sprintf("Reloaded %s: %d server(s)", filepath.Base(m.cfg.ConfigPath), len(m.cfg.Jenkins))
+	return nil
+}
+
+// reloadChangedConfig picks up an edit made outside the TUI. A broken file
+// is reported and ignored; a change to the connected server asks first.
+func (m *model) reloadChangedConfig() tea.Cmd {
+	name := filepath.Base(m.cfg.ConfigPath)
+	cfg, err := config.Load(m.cfg.ConfigPath)
+	if err == nil {
+		err = ValidateKeybindings(cfg.Keybindings)
+	}
+	if err != nil {
+		m.err = err
+		m.status = name + " changed on disk but has errors; keeping the running configuration"
+		return nil
+	}
+	m.err = nil
+	if m.target == nil {
+		m.applyConfig(cfg)
+		m.status = fmt.Sprintf("Reloaded %s (changed on disk): %d server(s)", name, len(cfg.Jenkins))
+		return nil
+	}
+	current := *m.target
+	var next *models.JenkinsTarget
+	for i := range cfg.Jenkins {
+		if cfg.Jenkins[i].ID == current.ID {
+			next = &cfg.Jenkins[i]
+		}
+	}
+	if next != nil && sameConnection(*next, current) {
+		m.applyConfig(cfg)
+		m.target = m.findTargetByID(current.ID)
+		m.status = fmt.Sprintf("Reloaded %s (changed on disk): %d server(s)", name, len(cfg.Jenkins))
+		return nil
+	}
+	title := current.Name + " changed in " + name
+	if next == nil {
+		title = current.Name + " was removed from " + name
+	}
+	return m.askConfirm(
+		title,
+		"Disconnect and reload? Tracked runs keep going. No reloads the other servers and stays connected with the old settings.",
+		func() tea.Cmd {
+			m.applyConfig(cfg)
+			m.target, m.client = nil, nil
+			m.jobFolders = nil
+			m.selectedJob = nil
+			m.status = fmt.Sprintf("Reloaded %s; disconnected from %s", name, current.Name)
+			return m.transition(screenServers)
+		},
+		func() tea.Cmd {
+			m.applyConfig(cfg)
+			m.status = fmt.Sprintf("Reloaded %s; still connected to %s with its previous settings", name, current.Name)
+			return nil
+		},
+	)
+}
+
+// sameConnection reports whether a and b reach Jenkins the same way; other
+// fields such as bookmarks can change under a live session.
+func sameConnection(a, b models.JenkinsTarget) bool {
+	return a.Host == b.Host && a.Username == b.Username && a.Credential == b.Credential &&
+		a.InsecureSkipTLSVerify == b.InsecureSkipTLSVerify && a.AuthCommand == b.AuthCommand
+}
+
+// fileStamp is a cheap change detector: modification time and size.
+type fileStamp struct {
+	mod  time.Time
+	size int64
+}
+
+func statFile(path string) fileStamp {
+	if strings.TrimSpace(path) == "" {
+		return fileStamp{}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{mod: info.ModTime(), size: info.Size()}
+}
+
+// configWatchCmd checks jenkins.yaml again after configWatchInterval.
+func configWatchCmd() tea.Cmd {
+	return tea.Tick(configWatchInterval, func(time.Time) tea.Msg { return configTickMsg{} })
+}
+
+// checkConfigOnDisk reloads jenkins.yaml if someone else changed it.
+func (m *model) checkConfigOnDisk() tea.Cmd {
+	// Wait for an open dialog or the editor instead of stacking prompts.
+	if m.editingConfig || m.confirm != nil {
+		if m.configWatch != nil && !m.configRetry {
+			m.configRetry = true
+			return configWatchCmd()
+		}
+		return nil
+	}
+	if m.configConflict != nil {
+		return m.askConfigConflict()
+	}
+	stamp := statFile(m.cfg.ConfigPath)
+	if stamp == m.configStamp {
+		return nil
+	}
+	m.configStamp = stamp
+	return m.reloadChangedConfig()
+}
+
+// applyConfig swaps in a freshly loaded configuration, keeping the settings
+// that come from flags rather than the file.
+func (m *model) applyConfig(cfg models.Config) {
+	cfg.Timeout = m.cfg.Timeout
+	cfg.ConfigPath = m.cfg.ConfigPath
+	cfg.CacheDir = m.cfg.CacheDir
+	cfg.Startup = m.cfg.Startup
+	if m.cfg.StripLogColors != cfg.StripLogColors {
+		m.consoleStrip = cfg.StripLogColors
+	}
+	m.cfg = cfg
+	m.noteConfigOnDisk(cfg)
+	m.creds.SetCacheTTL(cfg.CredentialCacheTTL)
+	jenkins.SetMaxResponseSize(cfg.MaxResponseMB)
+	ui.ConfigureTimes(cfg.TimeStyle, cfg.Clock, cfg.Timezone, cfg.DurationStyle)
+	if keys, _, err := newKeyMap(cfg.Keybindings); err == nil {
+		m.keys = keys
+	}
+	if m.splitPane != (cfg.Layout == models.LayoutSplit) {
+		m.splitPane = cfg.Layout == models.LayoutSplit
+		m.resizeJobs()
+	}
+	m.refreshServerItems()
+	m.refreshManageItems()
+}
+
 func (m *model) persistConfig() error {
 	if strings.TrimSpace(m.cfg.ConfigPath) == "" {
 		return fmt.Errorf("config path is not set")
 	}
-	return config.Save(m.cfg.ConfigPath, m.cfg)
+	hash, err := config.SaveIfUnchanged(m.cfg.ConfigPath, m.cfg, m.configHash)
+	if errors.Is(err, config.ErrChanged) {
+		return m.mergeAndSave()
+	}
+	if err != nil {
+		return err
+	}
+	m.configBase = config.Clone(m.cfg)
+	m.configHash = hash
+	m.configStamp = statFile(m.cfg.ConfigPath)
+	return nil
 }
 
+// View scrubs tokens and password parameter values from everything drawn,
+// whether it comes from the status line, an error body, or a console log.
 func (m *model) View() string {
+	return redact.String(m.view())
+}
+
+func (m *model) view() string {
+	if m.screen == screenRadiator && !m.helpVisible && m.confirm == nil {
+		return m.radiatorView()
+	}
 	body := ""
 	switch m.screen {
 	case screenServers:
@@ -1329,13 +3894,38 @@ func (m *model) View() string {
 	case screenManageForm:
 		if m.manageForm != nil {
 			body = m.manageForm.View()
+			if line := m.plainHTTPLine(); line != "" {
+				body += "\n" + line
+			}
+			if line := m.tokenCheckLine(); line != "" {
+				body += "\n" + line
+			}
 		} else {
 			body = "No form loaded"
 		}
 	case screenJobs:
-		body = ui.Muted.Render("Path: "+jobsPathLabel(m.currentJobsPrefix())) + "\n\n" + m.jobs.View()
+		body = ui.Muted.Render("Path: "+m.jobsLocationLabel()) + m.jobsFreshness() + "\n\n" + m.jobs.View()
+		if m.splitPane {
+			rightWidth := max(10, m.contentWidth()-8-m.splitLeftWidth()-3)
+			height := max(3, m.contentHeight()-10)
+			right := lipgloss.NewStyle().Border(ui.Border(), false, false, false, true).PaddingLeft(1).Height(height).
+				Render(m.splitRightPane(rightWidth, height))
+			body = ui.Muted.Render("Path: "+m.jobsLocationLabel()) + m.jobsFreshness() + "\n\n" + lipgloss.JoinHorizontal(lipgloss.Top, m.jobs.View(), " ", right)
+		}
+		if m.gotoActive {
+			body += "\n" + m.gotoPrompt(max(1, m.contentWidth()-4))
+		} else if m.crumbActive {
+			body += "\n" + m.crumbPicker(max(1, m.contentWidth()-4))
+		} else if m.bookmarksOpen {
+			body += "\n" + m.bookmarkPicker(max(1, m.contentWidth()-4))
+		} else if panel := m.jobDetailPanel(max(1, m.contentWidth()-4)); panel != "" && !m.splitPane {
+			body += "\n" + panel
+		}
 	case screenGlobalSearch:
 		body = ui.Muted.Render("Search: "+m.searchInput) + "\n\n" + m.search.View()
+		if paths := m.searchPathsPanel(max(1, m.contentWidth()-4)); paths != "" {
+			body += "\n" + paths
+		}
 	case screenParams:
 		if m.paramForm != nil {
 			body = m.paramForm.View()
@@ -1347,19 +3937,78 @@ func (m *model) View() string {
 		}
 	case screenPreview:
 		body = m.previewTable.View()
+		if len(m.lockWarnings) > 0 {
+			warnings := make([]string, len(m.lockWarnings))
+			for i, w := range m.lockWarnings {
+				warnings[i] = ui.Warn.Render("! " + w)
+			}
+			body = strings.Join(warnings, "\n") + "\n\n" + body
+		}
 	case screenRun, screenDone:
-		body = m.runTable.View()
+		if m.batch != nil {
+			body = ui.Muted.Render(fmt.Sprintf("Batch %d: %s", m.batch.id, selectedJobLabel(&m.batch.job))) + "\n\n" + m.batch.table.View()
+		}
+	case screenBatches:
+		body = m.batchTable.View()
+	case screenWatch:
+		body = m.watchTable.View()
+		if errs := m.watchErrors(m.contentWidth()); errs != "" {
+			body += "\n\n" + errs
+		}
+	case screenLogDiff:
+		body = m.logDiff.View()
+	case screenConnTest:
+		body = m.connView.View()
+	case screenJobConfig:
+		body = m.configView.View()
+		if label := selectedJobLabel(m.configJob); label != "" {
+			body = ui.Muted.Render("Job: "+label+" (config.xml, read-only)") + "\n\n" + body
+		}
+	case screenLocks:
+		body = m.locksView.View()
+	case screenConsole:
+		body = m.consoleBody()
+	case screenStages:
+		body = ui.Muted.Render("Stages: "+m.stagesBuild.label()) + "\n\n" + m.stagesTable.View()
+	case screenHistory:
+		body = m.historyTable.View()
+		if panel := m.historyChangesPanel(max(1, m.contentWidth()-4)); panel != "" {
+			body += "\n" + ui.Muted.Render(panel)
+		}
+		if label := selectedJobLabel(m.historyJob); label != "" {
+			body = ui.Muted.Render("History: "+label) + "\n\n" + body
+		}
+	}
+
+	if m.confirm != nil {
+		body = m.confirm.form.View()
+	}
+	if m.helpVisible {
+		body = ui.Muted.Render("Keybindings ("+keyLabel(m.keys.Help)+"/esc closes, "+ui.Glyph("↑/↓", "up/down")+" scrolls)") + "\n\n" + m.helpView.View()
+	}
+	if m.traceVisible {
+		body = m.traceOverlay(max(1, m.contentWidth()-4), max(5, m.contentHeight()-8))
 	}
 
-	help := helpTextForScreen(m.screen, m.screen == screenDone, m.helpExpanded)
+	help := helpTextForScreen(m.keys, m.screen, m.screen == screenDone)
+	if m.screen == screenConsole && m.consoleSearching {
+		help = "type search | enter find | esc cancel"
+	}
 	status := m.status
 	if status == "" {
 		status = "Ready"
 	}
 	if m.screen == screenRun {
 		status = m.spin.View() + " Tracking in progress"
+		if m.batch != nil && m.batch.stalled() > 0 {
+			status = m.spin.View() + fmt.Sprintf(" %d run(s) stalled: Jenkins is unreachable, retrying in the background", m.batch.stalled())
+		}
 	} else if m.loading {
-		elapsed := time.Since(m.loadingStart).Round(time.Second)
+		step := time.Second
+		if ui.Accessible {
+			step = accessibleTick
+		}
+		elapsed := time.Since(m.loadingStart).Round(step)
 		if elapsed < 0 {
 			elapsed = 0
 		}
@@ -1374,11 +4023,13 @@ func (m *model) View() string {
 	// Keep footer anchored to bottom by clipping only the middle body area.
 	frameWidth := m.contentWidth()
 	innerHeight := m.contentHeight()
-	headerLines := []string{}
-	footerLines := []string{
-		fitLineToWidth(ui.Muted.Render(status), frameWidth),
-		fitLineToWidth(ui.Help.Render(help), frameWidth),
+	headerLines := []string{fitLineToWidth(m.statusBar(), frameWidth)}
+	statusLine := ui.Muted.Render(status)
+	if m.offline {
+		statusLine = ui.Warn.Render("OFFLINE") + " " + statusLine
 	}
+	footerLines := []string{spreadLine(statusLine, ui.Muted.Render(m.screenCounts()), frameWidth)}
+	footerLines = append(footerLines, fitLineToWidth(m.footerHelp(help), frameWidth))
 	if errorLine != "" {
 		footerLines = append(footerLines, fitLineToWidth(errorLine, frameWidth))
 	}
@@ -1406,19 +4057,33 @@ func (m *model) buildParamForm() {
 		if desc == "" {
 			desc = string(p.Kind)
 		}
-		switch p.Kind {
-		case models.ParamChoice:
+		agentPicker := (p.Kind == models.ParamNode || p.Kind == models.ParamLabel) && len(p.Choices) > 0
+		switch {
+		case p.Kind == models.ParamChoice || agentPicker:
 			vals := []string{}
 			opts := make([]huh.Option[string], 0, len(p.Choices))
 			for _, ch := range p.Choices {
 				opts = append(opts, huh.NewOption(ch, ch))
 			}
+			if prev, ok := m.paramPrefill[p.Name]; ok && containsString(p.Choices, prev) {
+				vals = append(vals, prev)
+			} else if branch, ok := m.branchPrefill(p); ok {
+				vals = append(vals, branch)
+			} else if agentPicker && p.Default != "" {
+				// Picking several agents or labels runs the job once on each.
+				vals = append(vals, p.Default)
+			}
 			m.choiceVars[p.Name] = &vals
 			fields = append(fields,
 				huh.NewMultiSelect[string]().Title(p.Name).Description(desc).Options(opts...).Value(&vals),
 			)
 		default:
 			v := p.Default
+			if prev, ok := m.paramPrefill[p.Name]; ok && p.Kind != models.ParamPassword {
+				v = prev
+			} else if branch, ok := m.branchPrefill(p); ok {
+				v = branch
+			}
 			m.fixedVars[p.Name] = &v
 			if p.Kind == models.ParamBoolean {
 				fields = append(fields,
@@ -1429,7 +4094,7 @@ func (m *model) buildParamForm() {
 				)
 				continue
 			}
-			fields = append(fields, huh.NewInput().Title(p.Name).Description(desc).Value(&v))
+			fields = append(fields, huh.NewInput().Title(p.Name).Description(desc).Password(p.Kind == models.ParamPassword).Value(&v))
 		}
 	}
 	if len(fields) == 0 {
@@ -1449,6 +4114,11 @@ func (m *model) buildPermutations() error {
 			input.FixedValues[k] = *v
 		}
 	}
+	for _, p := range m.params {
+		if p.Kind == models.ParamPassword {
+			redact.Add(input.FixedValues[p.Name])
+		}
+	}
 	for k, v := range m.choiceVars {
 		if v != nil {
 			input.ChoiceValues[k] = *v
@@ -1458,10 +4128,82 @@ func (m *model) buildPermutations() error {
 	if err != nil {
 		return err
 	}
+	secret := models.PasswordNames(m.params)
+	for i := range specs {
+		specs[i].Secret = secret
+	}
 	m.permutations = specs
 	return nil
 }
 
+const runMatrixHeader = `# One run per line, as a JSON object of parameter values. Delete lines to
+# drop runs; copy and change them to add runs (at most %d). Lines starting
+# with # are ignored; saving an empty matrix keeps the previous runs.
+`
+
+// formatRunMatrix writes the runs for editing; parseRunMatrix reads them back.
+func formatRunMatrix(specs []models.JobSpec) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, runMatrixHeader, maxPermutations)
+	for _, spec := range specs {
+		line, _ := json.Marshal(spec.Params)
+		b.Write(line)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func parseRunMatrix(text string, params []models.ParamDef) ([]models.JobSpec, error) {
+	known := map[string]bool{}
+	for _, p := range params {
+		known[p.Name] = true
+	}
+	var specs []models.JobSpec
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		values := map[string]any{}
+		if err := json.Unmarshal([]byte(line), &values); err != nil {
+			return nil, fmt.Errorf("run matrix line %d: %w", i+1, err)
+		}
+		spec := models.JobSpec{Params: map[string]string{}, Secret: models.PasswordNames(params)}
+		for name, v := range values {
+			if len(known) > 0 && !known[name] {
+				return nil, fmt.Errorf("run matrix line %d: unknown parameter %q", i+1, name)
+			}
+			switch v := v.(type) {
+			case string:
+				spec.Params[name] = v
+			case nil:
+				spec.Params[name] = ""
+			case float64, bool:
+				spec.Params[name] = fmt.Sprint(v)
+			default:
+				return nil, fmt.Errorf("run matrix line %d: %s must be a string", i+1, name)
+			}
+		}
+		specs = append(specs, spec)
+	}
+	if len(specs) > maxPermutations {
+		return nil, fmt.Errorf("run matrix has %d runs; the limit is %d", len(specs), maxPermutations)
+	}
+	return specs, nil
+}
+
+// openPreview shows the permutations and, in the background, checks them
+// against the job's lockable resources.
+func (m *model) openPreview(cmds []tea.Cmd) tea.Cmd {
+	m.buildPreviewTable()
+	m.lockWarnings = nil
+	if m.client != nil && m.selectedJob != nil {
+		specs := slices.Clone(m.permutations)
+		cmds = append(cmds, lockCheckCmd(m.ctx, m.client, *m.selectedJob, specs))
+	}
+	return m.transition(screenPreview, cmds...)
+}
+
 func (m *model) buildPreviewTable() {
 	contentWidth := m.contentWidth()
 	contentHeight := m.contentHeight()
@@ -1473,7 +4215,7 @@ func (m *model) buildPreviewTable() {
 	for i, spec := range m.permutations {
 		rows = append(rows, table.Row{
 			fmt.Sprintf("%d", i+1),
-			clip(summarizeParams(spec.Params), max(20, contentWidth-28)),
+			clip(summarizeSpec(spec), max(20, contentWidth-28)),
 		})
 	}
 	t := table.New(
@@ -1486,115 +4228,456 @@ func (m *model) buildPreviewTable() {
 	m.previewTable = t
 }
 
-func (m *model) startRun() {
-	m.runRecords = make([]models.RunRecord, 0, len(m.permutations))
-	for i, spec := range m.permutations {
-		m.runRecords = append(m.runRecords, models.RunRecord{Index: i, Spec: spec, State: models.RunPlanned, StartedAt: time.Now()})
+// startIndexingRun tracks a multibranch scan in the run table as a single
+// run whose build URL is the indexing log.
+func (m *model) startIndexingRun(folder models.JobNode, cmds []tea.Cmd) tea.Cmd {
+	m.selectedJob = &models.JobRef{Name: folder.Name, FullName: folder.FullName, URL: folder.URL}
+	m.permutations = []models.JobSpec{{Params: map[string]string{}}}
+	b := m.startBatch(*m.selectedJob, m.permutations, true)
+	m.status = "Branch indexing " + jobsPathLabel(folder.FullName) + "..."
+	return m.transition(screenRun, append(cmds, startIndexingCmd(b.ctx, b.id, b.client, folder.URL))...)
+}
+
+func (m *model) rebuildFailedOnly() {
+	failed := make([]models.JobSpec, 0)
+	for _, r := range m.batch.records {
+		if r.State == models.RunFailed || r.State == models.RunAborted || r.State == models.RunError {
+			failed = append(failed, r.Spec)
+		}
+	}
+	if len(failed) > 0 {
+		m.permutations = failed
+		m.status = fmt.Sprintf("Prepared %d failed runs for retry", len(failed))
+	}
+}
+
+func (m *model) refreshRunTable(b *runBatch) {
+	cursor := b.table.Cursor()
+	contentWidth := m.contentWidth()
+	contentHeight := m.contentHeight()
+	cols := []table.Column{
+		{Title: "#", Width: 4},
+		{Title: "State", Width: 10},
+		{Title: "Result", Width: 24},
+		{Title: "Took", Width: 9},
+		{Title: "Build URL", Width: max(20, contentWidth-59)},
+	}
+	rows := make([]table.Row, 0, len(b.records))
+	for _, r := range b.records {
+		result := r.Result
+		if r.Err != "" {
+			result = r.Err
+		}
+		url := r.BuildURL
+		if url == "" {
+			url = r.QueueURL
+		}
+		if b.client != nil {
+			url = b.client.ExternalURL(url)
+		}
+		took := ""
+		if !r.EndedAt.IsZero() {
+			took = ui.Duration(r.EndedAt.Sub(r.StartedAt))
+		}
+		index := fmt.Sprintf("%d", r.Index+1)
+		if b.marks[r.Index] {
+			index = "*" + index
+		}
+		rows = append(rows, table.Row{
+			index,
+			string(r.State),
+			clip(result, 24),
+			took,
+			clip(url, max(20, contentWidth-65)),
+		})
+	}
+	t := table.New(
+		table.WithColumns(cols),
+		table.WithRows(rows),
+		table.WithFocused(true),
+		table.WithHeight(max(5, contentHeight-14)),
+	)
+	t.SetStyles(defaultTableStyles(true))
+	b.table = t
+	if cursor >= 0 && cursor < len(rows) {
+		b.table.SetCursor(cursor)
+	}
+}
+
+func (m *model) refreshHistoryTable() {
+	cursor := m.historyTable.Cursor()
+	contentWidth := m.contentWidth()
+	contentHeight := m.contentHeight()
+	cols := []table.Column{
+		{Title: "#", Width: 6},
+		{Title: "Result", Width: 10},
+		{Title: "Started", Width: 19},
+		{Title: "Parameters", Width: max(20, contentWidth-47)},
+	}
+	rows := make([]table.Row, 0, len(m.builds))
+	secret := models.PasswordNames(m.params)
+	for _, b := range m.builds {
+		result := b.Result
+		if b.Building {
+			result = "BUILDING"
+		}
+		started := ""
+		if !b.Timestamp.IsZero() {
+			started = ui.Timestamp(b.Timestamp)
+		}
+		number := fmt.Sprintf("%d", b.Number)
+		if m.historyMarks[b.Number] {
+			number = "*" + number
+		}
+		rows = append(rows, table.Row{
+			number,
+			result,
+			started,
+			clip(summarizeParams(models.JobSpec{Params: b.Params, Secret: secret}.ShownParams()), max(20, contentWidth-53)),
+		})
+	}
+	t := table.New(
+		table.WithColumns(cols),
+		table.WithRows(rows),
+		table.WithFocused(true),
+		table.WithHeight(max(5, contentHeight-14)),
+	)
+	t.SetStyles(defaultTableStyles(true))
+	m.historyTable = t
+	if cursor >= 0 && cursor < len(rows) {
+		m.historyTable.SetCursor(cursor)
+	}
+}
+
+// summarizeSpec is summarizeParams led by the spec's own job, if it has one.
+func summarizeSpec(spec models.JobSpec) string {
+	if spec.Job == nil {
+		return summarizeParams(spec.ShownParams())
+	}
+	if params := summarizeParams(spec.ShownParams()); params != "" {
+		return selectedJobLabel(spec.Job) + ": " + params
+	}
+	return selectedJobLabel(spec.Job)
+}
+
+func summarizeParams(mv map[string]string) string {
+	keys := make([]string, 0, len(mv))
+	for k := range mv {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%s", k, mv[k]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (m *model) updateGoto(msg tea.Msg, cmds []tea.Cmd) (tea.Model, tea.Cmd) {
+	km, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, tea.Batch(cmds...)
+	}
+	switch km.String() {
+	case "esc":
+		m.gotoActive = false
+		m.status = ""
+		return m, tea.Batch(cmds...)
+	case "tab":
+		candidates := m.gotoCandidates()
+		if m.gotoCycle >= 0 && len(m.gotoMatches) > 1 {
+			m.gotoCycle = (m.gotoCycle + 1) % len(m.gotoMatches)
+			m.gotoInput = m.gotoMatches[m.gotoCycle]
+			return m, tea.Batch(cmds...)
+		}
+		completed, matches := completeJobPath(m.gotoInput, candidates)
+		m.gotoMatches = matches
+		if completed == m.gotoInput && len(matches) > 1 {
+			m.gotoCycle = 0
+			completed = matches[0]
+		}
+		m.gotoInput = completed
+		return m, tea.Batch(cmds...)
+	case "enter":
+		fullName := strings.Trim(strings.TrimSpace(m.gotoInput), "/")
+		if fullName == "" {
+			return m, tea.Batch(cmds...)
+		}
+		m.gotoActive = false
+		if m.target != nil {
+			fullName = m.target.ResolveAlias(fullName)
+		}
+		job := m.resolveGotoJob(fullName)
+		if m.offlineRefusesTrigger(job.Name) {
+			return m, tea.Batch(cmds...)
+		}
+		m.selectedJob = &job
+		m.paramPrefill = nil
+		m.paramsBackTo = screenJobs
+		m.loading = true
+		m.loadingStart = time.Now()
+		m.loadingLabel = "Loading pipeline parameters"
+		m.status = "Loading parameters for " + jobsPathLabel(job.FullName) + "..."
+		return m, tea.Batch(append(cmds, loadParamsCmd(m.ctx, m.client, job.URL))...)
+	case "backspace":
+		m.gotoInput = trimLastRune(m.gotoInput)
+	default:
+		if len(km.Runes) > 0 {
+			m.gotoInput += string(km.Runes)
+		}
+	}
+	m.gotoCycle = -1
+	_, m.gotoMatches = completeJobPath(m.gotoInput, m.gotoCandidates())
+	return m, tea.Batch(cmds...)
+}
+
+// gotoCandidates merges the cached job index with the jobs of the folder on
+// screen, so completion works before the index has ever been built, and the
+// server's aliases.
+func (m *model) gotoCandidates() []string {
+	seen := map[string]bool{}
+	out := make([]string, 0, len(m.gotoIndex))
+	add := func(fullName string) {
+		if fullName != "" && !seen[fullName] {
+			seen[fullName] = true
+			out = append(out, fullName)
+		}
+	}
+	for _, n := range m.gotoIndex {
+		add(n.FullName)
+	}
+	for _, it := range m.jobs.Items() {
+		if item, ok := it.(listItem); ok && item.kind == models.JobNodeJob {
+			add(item.fullName)
+		}
+	}
+	if m.target != nil {
+		for name := range m.target.Aliases {
+			add(name)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func (m *model) resolveGotoJob(fullName string) models.JobRef {
+	for _, n := range m.gotoIndex {
+		if strings.EqualFold(n.FullName, fullName) {
+			return models.JobRef{Name: n.Name, FullName: n.FullName, URL: n.URL}
+		}
+	}
+	for _, it := range m.jobs.Items() {
+		if item, ok := it.(listItem); ok && item.kind == models.JobNodeJob && strings.EqualFold(item.fullName, fullName) {
+			return models.JobRef{Name: item.name, FullName: item.fullName, URL: item.id}
+		}
 	}
-	m.finished = map[int]bool{}
-	m.refreshRunTable()
-	if m.runCancel != nil {
-		m.runCancel()
+	return models.JobRef{Name: path.Base(fullName), FullName: fullName, URL: jenkins.JobURL(m.client.Host(), fullName)}
+}
+
+// completeJobPath extends input to the longest prefix shared by every
+// candidate it matches (case-insensitively) and returns those matches.
+func completeJobPath(input string, candidates []string) (string, []string) {
+	needle := strings.ToLower(strings.TrimLeft(input, "/"))
+	matches := []string{}
+	for _, c := range candidates {
+		if strings.HasPrefix(strings.ToLower(c), needle) {
+			matches = append(matches, c)
+		}
+	}
+	if len(matches) == 0 {
+		return input, nil
+	}
+	common := matches[0]
+	for _, c := range matches[1:] {
+		n := 0
+		for n < len(common) && n < len(c) && strings.EqualFold(common[n:n+1], c[n:n+1]) {
+			n++
+		}
+		common = common[:n]
+	}
+	if len(common) < len(needle) {
+		return input, matches
+	}
+	return common, matches
+}
+
+func (m *model) gotoPrompt(width int) string {
+	lines := []string{"Go to: " + m.gotoInput + ui.Glyph("█", "_")}
+	for i, match := range m.gotoMatches {
+		if i == 5 {
+			lines = append(lines, fmt.Sprintf("  ... %d more", len(m.gotoMatches)-5))
+			break
+		}
+		prefix := "  "
+		if i == m.gotoCycle {
+			prefix = "> "
+		}
+		lines = append(lines, clip(prefix+match, width))
+	}
+	return ui.Muted.Render(strings.Join(lines, "\n"))
+}
+
+// crumbLabels names each level the folder picker can jump to; level i keeps
+// the first i frames, so 0 is the server root.
+func (m *model) crumbLabels() []string {
+	labels := []string{"/"}
+	for _, frame := range m.jobFolders[:len(m.jobFolders)-1] {
+		label := jobsPathLabel(frame.FullName)
+		if frame.Kind == models.JobNodeView {
+			label += " [view: " + frame.Name + "]"
+		}
+		labels = append(labels, label)
 	}
-	runCtx, cancel := context.WithCancel(m.ctx)
-	m.runCtx = runCtx
-	m.runCancel = cancel
+	return labels
 }
 
-func (m *model) rebuildFailedOnly() {
-	failed := make([]models.JobSpec, 0)
-	for _, r := range m.runRecords {
-		if r.State == models.RunFailed || r.State == models.RunAborted || r.State == models.RunError {
-			failed = append(failed, r.Spec)
+func (m *model) crumbPicker(width int) string {
+	lines := []string{"Jump to:"}
+	for i, label := range m.crumbLabels() {
+		prefix := "  "
+		if i == m.crumbCursor {
+			prefix = "> "
 		}
+		lines = append(lines, clip(fmt.Sprintf("%s%d %s", prefix, i, label), width))
 	}
-	if len(failed) > 0 {
-		m.permutations = failed
-		m.status = fmt.Sprintf("Prepared %d failed runs for retry", len(failed))
+	return ui.Muted.Render(strings.Join(lines, "\n"))
+}
+
+func (m *model) updateCrumbs(msg tea.Msg, cmds []tea.Cmd) (tea.Model, tea.Cmd) {
+	km, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, tea.Batch(cmds...)
 	}
+	levels := len(m.crumbLabels())
+	switch s := km.String(); {
+	case s == "esc" || key.Matches(km, m.keys.JumpUp):
+		m.crumbActive = false
+		m.status = ""
+	case s == "up" || s == "k":
+		m.crumbCursor = max(0, m.crumbCursor-1)
+	case s == "down" || s == "j":
+		m.crumbCursor = min(levels-1, m.crumbCursor+1)
+	case s == "enter":
+		return m.jumpToCrumb(m.crumbCursor, cmds)
+	case len(s) == 1 && s[0] >= '0' && s[0] <= '9':
+		if level := int(s[0] - '0'); level < levels {
+			return m.jumpToCrumb(level, cmds)
+		}
+	}
+	return m, tea.Batch(cmds...)
+}
+
+func (m *model) jumpToCrumb(level int, cmds []tea.Cmd) (tea.Model, tea.Cmd) {
+	m.crumbActive = false
+	m.status = ""
+	m.selectedJob = nil
+	m.jobs.ResetFilter()
+	m.jobFolders = m.jobFolders[:level]
+	return m, tea.Batch(append(cmds, m.loadCurrentFolderCmd(false))...)
 }
 
-func (m *model) applyRunUpdate(u models.RunUpdate) {
-	if u.Index < 0 || u.Index >= len(m.runRecords) {
+// toggleBookmark pins the current folder for the connected server, or unpins
+// it when it is already bookmarked.
+func (m *model) toggleBookmark() {
+	if m.target == nil {
 		return
 	}
-	r := m.runRecords[u.Index]
-	r.State = u.State
-	if u.QueueURL != "" {
-		r.QueueURL = u.QueueURL
-	}
-	if u.BuildURL != "" {
-		r.BuildURL = u.BuildURL
+	if len(m.jobFolders) == 0 || m.showingViews {
+		m.status = "Open a folder to bookmark it"
+		return
 	}
-	if u.BuildNumber != 0 {
-		r.BuildNumber = u.BuildNumber
+	frame := m.jobFolders[len(m.jobFolders)-1]
+	if frame.Kind == models.JobNodeView {
+		m.status = "Views can't be bookmarked; open a folder instead"
+		return
 	}
-	if u.Result != "" {
-		r.Result = u.Result
+	prev := m.target.Bookmarks
+	label := jobsPathLabel(frame.FullName)
+	if i := slices.Index(prev, frame.FullName); i >= 0 {
+		m.target.Bookmarks = slices.Delete(slices.Clone(prev), i, i+1)
+		m.status = "Removed bookmark " + label
+	} else {
+		m.target.Bookmarks = append(slices.Clone(prev), frame.FullName)
+		m.status = "Bookmarked " + label
 	}
-	if u.Err != nil {
-		r.Err = u.Err.Error()
+	if err := m.persistConfig(); err != nil {
+		m.target.Bookmarks = prev
+		m.err = err
+		m.status = "Failed to save bookmark"
 	}
-	if u.Done {
-		r.EndedAt = time.Now()
+}
+
+func (m *model) bookmarkPicker(width int) string {
+	lines := []string{"Bookmarks:"}
+	for i, full := range m.target.Bookmarks {
+		prefix := "  "
+		if i == m.bookmarkCursor {
+			prefix = "> "
+		}
+		lines = append(lines, clip(fmt.Sprintf("%s%d %s", prefix, i, jobsPathLabel(full)), width))
 	}
-	m.runRecords[u.Index] = r
+	return ui.Muted.Render(strings.Join(lines, "\n"))
 }
 
-func (m *model) refreshRunTable() {
-	cursor := m.runTable.Cursor()
-	contentWidth := m.contentWidth()
-	contentHeight := m.contentHeight()
-	cols := []table.Column{
-		{Title: "#", Width: 4},
-		{Title: "State", Width: 10},
-		{Title: "Result", Width: 24},
-		{Title: "Build URL", Width: max(20, contentWidth-50)},
+func (m *model) updateBookmarks(msg tea.Msg, cmds []tea.Cmd) (tea.Model, tea.Cmd) {
+	km, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, tea.Batch(cmds...)
 	}
-	rows := make([]table.Row, 0, len(m.runRecords))
-	for _, r := range m.runRecords {
-		result := r.Result
-		if r.Err != "" {
-			result = r.Err
+	marks := m.target.Bookmarks
+	switch s := km.String(); {
+	case s == "esc" || key.Matches(km, m.keys.Bookmarks):
+		m.bookmarksOpen = false
+		m.status = ""
+	case s == "up" || s == "k":
+		m.bookmarkCursor = max(0, m.bookmarkCursor-1)
+	case s == "down" || s == "j":
+		m.bookmarkCursor = min(len(marks)-1, m.bookmarkCursor+1)
+	case s == "enter":
+		return m.openBookmark(marks[m.bookmarkCursor], cmds)
+	case key.Matches(km, m.keys.RemoveBookmark):
+		full := marks[m.bookmarkCursor]
+		m.target.Bookmarks = slices.Delete(slices.Clone(marks), m.bookmarkCursor, m.bookmarkCursor+1)
+		if err := m.persistConfig(); err != nil {
+			m.target.Bookmarks = marks
+			m.err = err
+			m.status = "Failed to save bookmarks"
+			return m, tea.Batch(cmds...)
 		}
-		url := r.BuildURL
-		if url == "" {
-			url = r.QueueURL
+		m.status = "Removed bookmark " + jobsPathLabel(full)
+		if len(m.target.Bookmarks) == 0 {
+			m.bookmarksOpen = false
+		}
+		m.bookmarkCursor = min(m.bookmarkCursor, max(0, len(m.target.Bookmarks)-1))
+	case len(s) == 1 && s[0] >= '0' && s[0] <= '9':
+		if i := int(s[0] - '0'); i < len(marks) {
+			return m.openBookmark(marks[i], cmds)
 		}
-		rows = append(rows, table.Row{
-			fmt.Sprintf("%d", r.Index+1),
-			string(r.State),
-			clip(result, 24),
-			clip(url, max(20, contentWidth-56)),
-		})
-	}
-	t := table.New(
-		table.WithColumns(cols),
-		table.WithRows(rows),
-		table.WithFocused(true),
-		table.WithHeight(max(5, contentHeight-14)),
-	)
-	t.SetStyles(defaultTableStyles(true))
-	m.runTable = t
-	if cursor >= 0 && cursor < len(rows) {
-		m.runTable.SetCursor(cursor)
 	}
+	return m, tea.Batch(cmds...)
 }
 
-func summarizeParams(mv map[string]string) string {
-	keys := make([]string, 0, len(mv))
-	for k := range mv {
-		keys = append(keys, k)
-	}
-	sort.Strings(keys)
-	parts := make([]string, 0, len(keys))
-	for _, k := range keys {
-		parts = append(parts, fmt.Sprintf("%s=%s", k, mv[k]))
-	}
-	return strings.Join(parts, ", ")
+func (m *model) openBookmark(full string, cmds []tea.Cmd) (tea.Model, tea.Cmd) {
+	m.bookmarksOpen = false
+	m.status = ""
+	return m.openFolder(full, cmds)
+}
+
+// openFolder shows the folder of the given full name, with its ancestors
+// as the path back.
+func (m *model) openFolder(full string, cmds []tea.Cmd) (tea.Model, tea.Cmd) {
+	m.selectedJob = nil
+	m.jobs.ResetFilter()
+	host := m.client.Host()
+	m.jobFolders = append(ancestorFolders(host, full), models.JobNode{Name: path.Base(full), FullName: full, URL: jenkins.JobURL(host, full), Kind: models.JobNodeFolder})
+	return m, tea.Batch(append(cmds, m.loadCurrentFolderCmd(false))...)
 }
 
 func (m *model) navigateUpJobs(cmds []tea.Cmd) (tea.Model, tea.Cmd) {
+	if m.showingViews {
+		m.jobs.ResetFilter()
+		return m, tea.Batch(append(cmds, m.loadCurrentFolderCmd(false))...)
+	}
 	if len(m.jobFolders) == 0 {
 		return m, m.transition(screenServers, cmds...)
 	}
@@ -1603,8 +4686,38 @@ func (m *model) navigateUpJobs(cmds []tea.Cmd) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(append(cmds, m.loadCurrentFolderCmd(false))...)
 }
 
+var screenNames = map[screen]string{
+	screenServers:       "servers",
+	screenJobs:          "jobs",
+	screenGlobalSearch:  "search",
+	screenParams:        "params",
+	screenPreview:       "preview",
+	screenRun:           "run",
+	screenDone:          "done",
+	screenManageTargets: "manage",
+	screenManageForm:    "manage-form",
+	screenHistory:       "history",
+	screenLogDiff:       "log-diff",
+	screenJobConfig:     "job-config",
+	screenBatches:       "batches",
+	screenConnTest:      "conn-test",
+	screenLocks:         "locks",
+	screenConsole:       "console",
+	screenStages:        "stages",
+	screenWatch:         "watch",
+	screenRadiator:      "radiator",
+}
+
+func (s screen) String() string {
+	if name, ok := screenNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("screen(%d)", int(s))
+}
+
 func (m *model) transition(next screen, cmds ...tea.Cmd) tea.Cmd {
 	if m.screen != next {
+		slog.Debug("screen transition", "from", m.screen.String(), "to", next.String(), "status", m.status)
 		m.screen = next
 		cmds = append(cmds, tea.ClearScreen)
 	}
@@ -1626,7 +4739,31 @@ func (m *model) loadCurrentFolderCmd(forceRefresh bool) tea.Cmd {
 	}
 	m.loadingLabel = fmt.Sprintf("%s %s", action, jobsPathLabel(prefix))
 	m.status = m.loadingLabel + "..."
-	return loadJobsCmd(m.ctx, m.cfg.CacheDir, m.client, containerURL, prefix, forceRefresh, reqID)
+	return loadJobsCmd(m.ctx, m.cfg.CacheDir, m.client, containerURL, prefix, forceRefresh, m.offline, reqID)
+}
+
+func (m *model) loadViewsCmd() tea.Cmd {
+	if m.client == nil {
+		return nil
+	}
+	containerURL, prefix := m.currentJobsContainer()
+	m.jobsReqID++
+	reqID := m.jobsReqID
+	m.loading = true
+	m.loadingStart = time.Now()
+	m.loadingLabel = "Loading views of " + m.jobsLocationLabel()
+	m.status = m.loadingLabel + "..."
+	return loadViewsCmd(m.ctx, m.client, containerURL, prefix, reqID)
+}
+
+// jobsLocationLabel is the folder path, plus the view name when the
+// current listing was opened through a view.
+func (m *model) jobsLocationLabel() string {
+	label := jobsPathLabel(m.currentJobsPrefix())
+	if n := len(m.jobFolders); n > 0 && m.jobFolders[n-1].Kind == models.JobNodeView {
+		label += " [view: " + m.jobFolders[n-1].Name + "]"
+	}
+	return label
 }
 
 func (m *model) currentJobsContainer() (string, string) {
@@ -1640,25 +4777,92 @@ func (m *model) currentJobsContainer() (string, string) {
 	return last.URL, last.FullName
 }
 
+func (m *model) currentFolderIsMultibranch() bool {
+	return len(m.jobFolders) > 0 && m.jobFolders[len(m.jobFolders)-1].Multibranch
+}
+
+// multibranchScanTarget prefers the highlighted multibranch project and falls
+// back to the one currently open, so S works from either level.
+func (m *model) multibranchScanTarget() (models.JobNode, bool) {
+	if item, ok := m.jobs.SelectedItem().(listItem); ok && item.multibranch {
+		return models.JobNode{Name: item.name, FullName: item.fullName, URL: item.id, Kind: item.kind, Multibranch: true}, true
+	}
+	if m.currentFolderIsMultibranch() {
+		return m.jobFolders[len(m.jobFolders)-1], true
+	}
+	return models.JobNode{}, false
+}
+
 func (m *model) currentJobsPrefix() string {
 	_, prefix := m.currentJobsContainer()
 	return prefix
 }
 
-func loadJobsCmd(ctx context.Context, cacheDir string, client *jenkins.Client, containerURL, prefix string, forceRefresh bool, requestID uint64) tea.Cmd {
+func loadGotoIndexCmd(cacheDir string, client *jenkins.Client) tea.Cmd {
+	return func() tea.Msg {
+		jobs, _, _, err := cache.JobIndexInDir(cacheDir, client.CacheKey())
+		return gotoIndexLoadedMsg{jobs: jobs, err: err}
+	}
+}
+
+func loadViewsCmd(ctx context.Context, client *jenkins.Client, containerURL, prefix string, requestID uint64) tea.Cmd {
+	return func() tea.Msg {
+		nodes, err := client.ListViews(ctx, containerURL, prefix)
+		return jobsLoadedMsg{
+			nodes:        nodes,
+			views:        true,
+			err:          err,
+			requestID:    requestID,
+			containerURL: containerURL,
+			prefix:       prefix,
+		}
+	}
+}
+
+// loadFolderPreviewCmd reads a folder's children for the split pane, going
+// through the same folder cache as the jobs list.
+func loadFolderPreviewCmd(ctx context.Context, cacheDir string, client *jenkins.Client, folderURL, prefix string, offline bool) tea.Cmd {
+	return func() tea.Msg {
+		if offline {
+			if nodes, _, ok, err := cache.LastJobNodesInDir(cacheDir, client.CacheKey(), folderURL); err == nil && ok {
+				return folderPreviewLoadedMsg{url: folderURL, nodes: nodes}
+			}
+			return folderPreviewLoadedMsg{url: folderURL, err: errOfflineUncached}
+		}
+		if nodes, ok, err := cache.JobNodesInDir(cacheDir, client.CacheKey(), folderURL); err == nil && ok {
+			return folderPreviewLoadedMsg{url: folderURL, nodes: nodes}
+		}
+		nodes, err := client.ListJobNodes(ctx, folderURL, prefix)
+		if err != nil {
+			return folderPreviewLoadedMsg{url: folderURL, err: err}
+		}
+		_ = cache.SaveJobNodesInDir(cacheDir, client.CacheKey(), folderURL, nodes)
+		return folderPreviewLoadedMsg{url: folderURL, nodes: nodes}
+	}
+}
+
+func loadJobsCmd(ctx context.Context, cacheDir string, client *jenkins.Client, containerURL, prefix string, forceRefresh, offline bool, requestID uint64) tea.Cmd {
 	return func() tea.Msg {
+		if offline {
+			return cachedJobsMsg(cacheDir, client, containerURL, prefix, requestID, errOfflineUncached)
+		}
 		if !forceRefresh {
-			if nodes, ok, err := cache.JobNodesInDir(cacheDir, client.CacheKey(), containerURL); err == nil && ok {
+			if nodes, fetchedAt, ok, err := cache.JobNodesWithAgeInDir(cacheDir, client.CacheKey(), containerURL); err == nil && ok {
 				return jobsLoadedMsg{
 					nodes:        nodes,
 					fromCache:    true,
 					requestID:    requestID,
 					containerURL: containerURL,
 					prefix:       prefix,
+					fetchedAt:    fetchedAt,
+					folders:      folderCacheInfos(cacheDir, client, nodes),
 				}
 			}
 		}
 		nodes, err := client.ListJobNodes(ctx, containerURL, prefix)
+		if jenkins.Unreachable(err) {
+			return cachedJobsMsg(cacheDir, client, containerURL, prefix, requestID, err)
+		}
 		if err != nil {
 			return jobsLoadedMsg{
 				nodes:        nodes,
@@ -1668,6 +4872,10 @@ func loadJobsCmd(ctx context.Context, cacheDir string, client *jenkins.Client, c
 				prefix:       prefix,
 			}
 		}
+		var diff jobDiff
+		if before, _, ok, err := cache.LastJobNodesInDir(cacheDir, client.CacheKey(), containerURL); err == nil && ok {
+			diff = diffJobNodes(before, nodes)
+		}
 		_ = cache.SaveJobNodesInDir(cacheDir, client.CacheKey(), containerURL, nodes)
 		return jobsLoadedMsg{
 			nodes:        nodes,
@@ -1675,6 +4883,9 @@ func loadJobsCmd(ctx context.Context, cacheDir string, client *jenkins.Client, c
 			requestID:    requestID,
 			containerURL: containerURL,
 			prefix:       prefix,
+			diff:         diff,
+			fetchedAt:    time.Now(),
+			folders:      folderCacheInfos(cacheDir, client, nodes),
 		}
 	}
 }
@@ -1684,6 +4895,10 @@ func applySelectedStyles(delegate *list.DefaultDelegate) {
 		BorderForeground(lipgloss.Color("110")).
 		Foreground(lipgloss.Color("252"))
 	delegate.Styles.SelectedDesc = delegate.Styles.SelectedTitle.Copy().Foreground(lipgloss.Color("245"))
+	if ui.ASCII {
+		delegate.Styles.SelectedTitle = delegate.Styles.SelectedTitle.BorderStyle(ui.Border())
+		delegate.Styles.SelectedDesc = delegate.Styles.SelectedDesc.BorderStyle(ui.Border())
+	}
 }
 
 func selectedJobLabel(job *models.JobRef) string {
@@ -1698,33 +4913,148 @@ func selectedJobLabel(job *models.JobRef) string {
 
 func loadParamsCmd(ctx context.Context, client *jenkins.Client, jobURL string) tea.Cmd {
 	return func() tea.Msg {
-		params, err := client.GetJobParams(ctx, jobURL)
+		params, err := client.GetLaunchParams(ctx, jobURL)
 		return paramsLoadedMsg{params: params, err: err}
 	}
 }
 
-func loadSearchCmd(ctx context.Context, client *jenkins.Client, query string, requestID uint64) tea.Cmd {
+func loadHistoryCmd(ctx context.Context, client *jenkins.Client, jobURL string) tea.Cmd {
+	return func() tea.Msg {
+		builds, err := client.ListBuilds(ctx, jobURL, 25)
+		return historyLoadedMsg{builds: builds, err: err}
+	}
+}
+
+func loadLogDiffCmd(ctx context.Context, client *jenkins.Client, a, b models.RunRecord) tea.Cmd {
+	return func() tea.Msg {
+		left, err := client.GetConsoleText(ctx, a.BuildURL)
+		if err != nil {
+			return logDiffLoadedMsg{err: fmt.Errorf("console log for run %d: %w", a.Index+1, err)}
+		}
+		right, err := client.GetConsoleText(ctx, b.BuildURL)
+		if err != nil {
+			return logDiffLoadedMsg{err: fmt.Errorf("console log for run %d: %w", b.Index+1, err)}
+		}
+		ops := logdiff.Lines(logdiff.Normalize(left), logdiff.Normalize(right))
+		diff := logdiff.Unified(ops, runDiffLabel(a), runDiffLabel(b), 3)
+		return logDiffLoadedMsg{diff: diff}
+	}
+}
+
+func runDiffLabel(r models.RunRecord) string {
+	return fmt.Sprintf("run %d (#%d) %s", r.Index+1, r.BuildNumber, summarizeSpec(r.Spec))
+}
+
+func colorizeDiff(diff string) string {
+	lines := strings.Split(diff, "\n")
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"), strings.HasPrefix(line, "@@"):
+			lines[i] = ui.Muted.Render(line)
+		case strings.HasPrefix(line, "+"):
+			lines[i] = ui.Success.Render(line)
+		case strings.HasPrefix(line, "-"):
+			lines[i] = ui.Danger.Render(line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func loadJobConfigCmd(ctx context.Context, client *jenkins.Client, job models.JobRef) tea.Cmd {
+	return func() tea.Msg {
+		config, err := client.GetJobConfig(ctx, job.URL)
+		return jobConfigLoadedMsg{job: job, config: config, err: err}
+	}
+}
+
+func loadReplayScriptsCmd(ctx context.Context, client *jenkins.Client, t replayTarget) tea.Cmd {
+	return func() tea.Msg {
+		scripts, err := client.ReplayScripts(ctx, t.url)
+		return replayScriptsMsg{target: t, scripts: scripts, err: err}
+	}
+}
+
+func loadLocksCmd(ctx context.Context, client *jenkins.Client) tea.Cmd {
+	return func() tea.Msg {
+		resources, err := client.ListLockableResources(ctx)
+		return lockableResourcesMsg{resources: resources, err: err}
+	}
+}
+
+// lockCheckCmd is best effort: a job without locks, a server without the
+// plugin, or any error leaves the preview without warnings.
+func lockCheckCmd(ctx context.Context, client *jenkins.Client, job models.JobRef, specs []models.JobSpec) tea.Cmd {
+	return func() tea.Msg {
+		msg := lockCheckMsg{job: job.FullName}
+		config, err := client.GetJobConfig(ctx, job.URL)
+		if err != nil {
+			slog.Debug("lock check: config.xml", "job", job.FullName, "error", err.Error())
+			return msg
+		}
+		requests := jenkins.RequiredResources(config)
+		if len(requests) == 0 {
+			return msg
+		}
+		resources, err := client.ListLockableResources(ctx)
+		if err != nil {
+			slog.Debug("lock check: resources", "job", job.FullName, "error", err.Error())
+			return msg
+		}
+		msg.warnings = lockContention(requests, resources, specs)
+		return msg
+	}
+}
+
+func serverHealthCmd(ctx context.Context, client *jenkins.Client, targetID string) tea.Cmd {
+	return func() tea.Msg {
+		health, err := client.Health(ctx)
+		return serverHealthMsg{target: targetID, health: health, err: err}
+	}
+}
+
+func detectServerCmd(ctx context.Context, client *jenkins.Client, targetID string) tea.Cmd {
+	return func() tea.Msg {
+		info, err := client.DetectServer(ctx)
+		return serverDetectedMsg{target: targetID, info: info, err: err}
+	}
+}
+
+func enableJobCmd(ctx context.Context, client *jenkins.Client, jobURL, name string) tea.Cmd {
 	return func() tea.Msg {
-		nodes, err := client.SearchJobs(ctx, query, 100)
-		return searchLoadedMsg{nodes: nodes, err: err, requestID: requestID}
+		return jobEnabledMsg{name: name, err: client.EnableJob(ctx, jobURL)}
 	}
 }
 
-func startRunCmd(ctx context.Context, client *jenkins.Client, jobURL string, specs []models.JobSpec, concurrency int) tea.Cmd {
+func loadJobDetailCmd(ctx context.Context, client *jenkins.Client, jobURL string) tea.Cmd {
+	return func() tea.Msg {
+		detail, err := client.GetJobDetail(ctx, jobURL)
+		return jobDetailLoadedMsg{url: jobURL, detail: detail, err: err}
+	}
+}
+
+func startRunCmd(ctx context.Context, batch int, client *jenkins.Client, jobURL string, specs []models.JobSpec, concurrency int) tea.Cmd {
 	return func() tea.Msg {
 		ch := make(chan models.RunUpdate)
 		go executor.Run(ctx, client, jobURL, specs, concurrency, ch)
-		return runStreamStartedMsg{ch: ch}
+		return runStreamStartedMsg{batch: batch, ch: ch}
+	}
+}
+
+func startIndexingCmd(ctx context.Context, batch int, client *jenkins.Client, folderURL string) tea.Cmd {
+	return func() tea.Msg {
+		ch := make(chan models.RunUpdate)
+		go executor.Index(ctx, client, folderURL, ch)
+		return runStreamStartedMsg{batch: batch, ch: ch}
 	}
 }
 
-func waitRunEventCmd(ch <-chan models.RunUpdate) tea.Cmd {
+func waitRunEventCmd(batch int, ch <-chan models.RunUpdate) tea.Cmd {
 	return func() tea.Msg {
 		update, ok := <-ch
 		if !ok {
-			return runDoneMsg{}
+			return runDoneMsg{batch: batch}
 		}
-		return runEventMsg{update: update}
+		return runEventMsg{batch: batch, update: update}
 	}
 }
 
@@ -1739,55 +5069,249 @@ func paramsStatusMessage() string {
 	return "Fill parameters. Choice fields support multi-select; ctrl+a toggles select all/none."
 }
 
-func helpTextForScreen(current screen, runDone bool, expanded bool) string {
-	if !expanded {
-		switch current {
-		case screenServers:
-			return "enter select | a add | e edit | q quit | ? more"
-		case screenJobs:
-			return "enter open | / filter | g global search | q quit | ? more"
-		case screenGlobalSearch:
-			return "type search | enter open | esc back | ? more"
-		case screenParams:
-			return "enter continue | esc back | ? more"
-		case screenRun, screenDone:
-			return "o open url | q quit | ? more"
-		default:
-			return "q quit | ? more"
+// traceOverlay lists the client's latest API calls, newest first. It is a
+// debugging aid toggled with ctrl+t and is deliberately left out of the help.
+func (m *model) traceOverlay(width, height int) string {
+	lines := []string{ui.Title.Render("Recent API calls") + ui.Muted.Render("  ("+keyLabel(m.keys.Trace)+"/esc to close)"), ""}
+	if m.client == nil {
+		return strings.Join(append(lines, ui.Muted.Render("No server connected yet")), "\n")
+	}
+	traces := m.client.RecentRequests(max(1, height-2))
+	if len(traces) == 0 {
+		return strings.Join(append(lines, ui.Muted.Render("No requests recorded yet")), "\n")
+	}
+	for _, t := range traces {
+		status := fmt.Sprintf("%d", t.Status)
+		if t.Err != "" {
+			status = ui.Danger.Render("ERR")
+		} else if t.Status >= 400 {
+			status = ui.Danger.Render(status)
+		}
+		latency := t.Duration.Round(time.Millisecond).String()
+		prefix := fmt.Sprintf("%s %-4s %s %7s ", t.At.Format("15:04:05"), t.Method, status, latency)
+		target := t.URL
+		if t.Err != "" {
+			target += " (" + t.Err + ")"
+		}
+		lines = append(lines, prefix+ansi.Truncate(target, max(10, width-ansi.StringWidth(prefix)), "..."))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// confirmDialog is a yes/no modal shown in place of the current screen.
+// Keys go to it until it is answered; async messages keep flowing underneath.
+type confirmDialog struct {
+	form   *huh.Form
+	answer bool
+	choice string
+	picks  []string
+	onYes  func() tea.Cmd
+	onNo   func() tea.Cmd
+	prev   string
+}
+
+// askConfirm opens a confirm modal that defaults to "No". onNo may be nil.
+func (m *model) askConfirm(title, description string, onYes, onNo func() tea.Cmd) tea.Cmd {
+	d := &confirmDialog{onYes: onYes, onNo: onNo, prev: m.status}
+	d.form = huh.NewForm(huh.NewGroup(
+		huh.NewConfirm().
+			Title(title).
+			Description(description).
+			Affirmative("Yes").
+			Negative("No").
+			Value(&d.answer),
+	)).WithTheme(ui.FormTheme()).WithWidth(max(40, min(80, m.contentWidth()-8))).WithShowHelp(false)
+	m.confirm = d
+	m.status = "y/n or " + ui.Glyph("←/→", "left/right") + " then enter to answer, esc to cancel"
+	return d.form.Init()
+}
+
+// askChoice is askConfirm with more than two answers. esc cancels without
+// calling onChoose.
+func (m *model) askChoice(title, description string, options []huh.Option[string], onChoose func(choice string) tea.Cmd) tea.Cmd {
+	d := &confirmDialog{answer: true, prev: m.status}
+	d.onYes = func() tea.Cmd { return onChoose(d.choice) }
+	d.form = huh.NewForm(huh.NewGroup(
+		huh.NewSelect[string]().
+			Title(title).
+			Description(description).
+			Options(options...).
+			Value(&d.choice),
+	)).WithTheme(ui.FormTheme()).WithWidth(max(40, min(80, m.contentWidth()-8))).WithShowHelp(false)
+	m.confirm = d
+	m.status = ui.Glyph("↑/↓", "up/down") + " then enter to choose, esc to cancel"
+	return d.form.Init()
+}
+
+// askText is askConfirm with a one-line text answer, prefilled with value.
+func (m *model) askText(title, description, value string, onSubmit func(text string) tea.Cmd) tea.Cmd {
+	d := &confirmDialog{answer: true, choice: value, prev: m.status}
+	d.onYes = func() tea.Cmd { return onSubmit(d.choice) }
+	d.form = huh.NewForm(huh.NewGroup(
+		huh.NewInput().
+			Title(title).
+			Description(description).
+			Value(&d.choice),
+	)).WithTheme(ui.FormTheme()).WithWidth(max(40, min(80, m.contentWidth()-8))).WithShowHelp(false)
+	m.confirm = d
+	m.status = "enter to confirm, esc to cancel"
+	return d.form.Init()
+}
+
+// askSecret is askText for a password: the input is masked and starts empty.
+func (m *model) askSecret(title, description string, onSubmit func(text string) tea.Cmd) tea.Cmd {
+	d := &confirmDialog{answer: true, prev: m.status}
+	d.onYes = func() tea.Cmd { return onSubmit(d.choice) }
+	d.form = huh.NewForm(huh.NewGroup(
+		huh.NewInput().
+			Title(title).
+			Description(description).
+			Password(true).
+			Value(&d.choice),
+	)).WithTheme(ui.FormTheme()).WithWidth(max(40, min(80, m.contentWidth()-8))).WithShowHelp(false)
+	m.confirm = d
+	m.status = "enter to confirm, esc to cancel"
+	return d.form.Init()
+}
+
+// askPicks is askChoice with a multi-select; onPick gets the checked values.
+func (m *model) askPicks(title, description string, options []huh.Option[string], onPick func(picks []string) tea.Cmd) tea.Cmd {
+	d := &confirmDialog{answer: true, prev: m.status}
+	d.onYes = func() tea.Cmd { return onPick(d.picks) }
+	d.form = huh.NewForm(huh.NewGroup(
+		huh.NewMultiSelect[string]().
+			Title(title).
+			Description(description).
+			Options(options...).
+			Value(&d.picks),
+	)).WithTheme(ui.FormTheme()).WithWidth(max(40, min(80, m.contentWidth()-8))).WithShowHelp(false)
+	m.confirm = d
+	m.status = "space to check, enter to confirm, esc to cancel"
+	return d.form.Init()
+}
+
+func (m *model) updateConfirm(msg tea.Msg) tea.Cmd {
+	d := m.confirm
+	if km, ok := msg.(tea.KeyMsg); ok && km.String() == "esc" {
+		return m.resolveConfirm(false)
+	}
+	updated, cmd := d.form.Update(msg)
+	if f, ok := updated.(*huh.Form); ok {
+		d.form = f
+	}
+	switch d.form.State {
+	case huh.StateCompleted:
+		return tea.Batch(cmd, m.resolveConfirm(d.answer))
+	case huh.StateAborted:
+		return tea.Batch(cmd, m.resolveConfirm(false))
+	}
+	return cmd
+}
+
+func (m *model) resolveConfirm(yes bool) tea.Cmd {
+	d := m.confirm
+	m.confirm = nil
+	m.status = d.prev
+	if yes {
+		return d.onYes()
+	}
+	if d.onNo != nil {
+		return d.onNo()
+	}
+	m.status = "Cancelled"
+	return nil
+}
+
+// confirmDeleteTarget asks before removing a server (and its keyring entry).
+// after runs once the delete succeeded.
+func (m *model) confirmDeleteTarget(idx int, after func() tea.Cmd) tea.Cmd {
+	target := m.cfg.Jenkins[idx]
+	desc := "It is removed from " + filepath.Base(m.cfg.ConfigPath) + "."
+	if target.Credential.Type == models.CredentialTypeKeyring {
+		desc = "It is removed from " + filepath.Base(m.cfg.ConfigPath) + " and its token is deleted from the system password manager."
+	}
+	return m.askConfirm("Delete server "+target.Name+"?", desc, func() tea.Cmd {
+		if err := m.deleteTargetAt(idx); err != nil {
+			m.err = err
+			m.status = "Failed to delete server"
+			return nil
+		}
+		m.err = nil
+		m.status = "Deleted server"
+		if after != nil {
+			return after()
 		}
+		return nil
+	}, nil)
+}
+
+func (m *model) launchRun() tea.Cmd {
+	b := m.startBatch(*m.selectedJob, m.permutations, false)
+	if len(m.permutations) > 0 && m.permutations[0].Job != nil {
+		// A folder-wide batch; its jobs are not the one that was opened.
+		return m.transition(screenRun, startRunCmd(b.ctx, b.id, b.client, b.job.URL, m.permutations, concurrencyCap))
 	}
+	return m.transition(screenRun, startRunCmd(b.ctx, b.id, b.client, b.job.URL, m.permutations, concurrencyCap), recordUseCmd(m.cfg.CacheDir, b.client, b.job.URL))
+}
+
+// helpTextForScreen is the one-line footer hint; the full list lives in the
+// help overlay.
+func helpTextForScreen(keys keyMap, current screen, runDone bool) string {
+	l := keyLabel
+	more := " | " + firstKey(keys.Help) + " help"
 	switch current {
 	case screenServers:
-		return "enter: select server | a/m: add | e: edit | t: rotate token | d: delete | q: quit"
+		return l(keys.Open) + " select | " + firstKey(keys.AddServer) + " add | " + l(keys.EditServer) + " edit | " + l(keys.Quit) + " quit" + more
 	case screenJobs:
-		return "enter: open folder/job | esc/backspace: up | r: refresh folder | /: filter | g: global search | q: quit"
+		return l(keys.Open) + " open | / filter | " + l(keys.GlobalSearch) + " global search | " + l(keys.Quit) + " quit" + more
 	case screenGlobalSearch:
-		return "type: query | enter: open job | backspace: edit | r: refresh | esc: back | q: quit"
+		return "type search | enter open | esc back"
 	case screenParams:
-		return "space/x: toggle | ctrl+a: select all/none | /: filter | shift+tab: back | enter: continue | ctrl+c: quit"
-	case screenManageTargets:
-		return "a: add | e/enter: edit | t: rotate token | d: delete | esc: back | q: quit"
+		return "space/x toggle | ctrl+a select all/none | enter continue | esc back"
 	case screenManageForm:
-		return "enter: next/submit | shift+tab: back | esc: cancel | ctrl+c: quit"
+		return "enter next/submit | shift+tab back | ctrl+o API token page | ctrl+g generate token | esc cancel"
 	case screenRun, screenDone:
-		help := "o: open build url | q: quit"
+		help := l(keys.OpenURL) + " open url | " + l(keys.ViewLog) + " log | " + l(keys.MarkRun) + " mark | " + l(keys.DiffRuns) + " diff logs"
 		if runDone {
-			help += " | r: rerun failed"
+			help += " | " + l(keys.Rerun) + " rerun failed"
 		}
-		return help
+		return help + " | " + l(keys.ShowRuns) + " batches | esc jobs | " + l(keys.Quit) + " quit" + more
+	case screenLogDiff, screenJobConfig, screenLocks:
+		return ui.Glyph("↑/↓", "up/down") + " scroll | esc back" + more
+	case screenStages:
+		return "enter stage log | esc back" + more
+	case screenConsole:
+		return ui.Glyph("↑/↓", "up/down") + " scroll | / search | " + l(keys.NextMatch) + "/" + l(keys.PrevMatch) + " match | " +
+			l(keys.NextError) + "/" + l(keys.PrevError) + " error | " + l(keys.StripColors) + " colors | " + l(keys.SaveLog) + " save | esc back" + more
+	case screenConnTest:
+		return ui.Glyph("↑/↓", "up/down") + " scroll | " + l(keys.TestConn) + " test again | esc back" + more
+	case screenHistory:
+		return firstKey(keys.Rebuild) + " rebuild | " + l(keys.Replay) + " replay | " + l(keys.ViewLog) + " log | " + l(keys.OpenURL) + " open url | esc back" + more
+	case screenBatches:
+		return "enter open | " + l(keys.StopBatch) + " stop | " + l(keys.RemoveBatch) + " remove | esc back | " + l(keys.Quit) + " quit" + more
+	case screenWatch:
+		return "enter history | " + l(keys.Radiator) + " radiator | " + l(keys.CheckWatches) + " check now | " + l(keys.Unwatch) + " unwatch | esc back | " + l(keys.Quit) + " quit" + more
 	default:
-		return "enter: continue | q: quit"
+		return l(keys.Quit) + " quit" + more
 	}
 }
 
+func (m *model) openHelp() {
+	m.helpVisible = true
+	m.helpView.SetContent(renderHelp(m.keys, m.screen))
+	m.helpView.GotoTop()
+}
+
 func (m *model) allowQuickQuit() bool {
 	switch m.screen {
 	case screenServers:
 		return !m.servers.SettingFilter()
 	case screenJobs:
-		return !m.jobs.SettingFilter()
+		return !m.jobs.SettingFilter() && !m.gotoActive && !m.crumbActive && !m.bookmarksOpen
 	case screenGlobalSearch:
 		return true
+	case screenConsole:
+		return !m.consoleSearching
 	case screenManageTargets:
 		return !m.manage.SettingFilter()
 	case screenParams, screenManageForm:
@@ -1806,17 +5330,43 @@ func (m *model) contentHeight() int {
 	return max(1, m.height-(outerPaddingY*2))
 }
 
+// clip truncates s to at most n terminal cells, counting wide runes (CJK,
+// emoji) as two cells so table columns stay aligned.
 func clip(s string, n int) string {
 	if n <= 0 {
 		return ""
 	}
-	if lipgloss.Width(s) <= n {
+	if ansi.StringWidth(s) <= n {
 		return s
 	}
 	if n <= 3 {
-		return lipgloss.NewStyle().MaxWidth(n).Render(s)
+		return ansi.Truncate(s, n, "")
 	}
-	return lipgloss.NewStyle().MaxWidth(n-3).Render(s) + "..."
+	return ansi.Truncate(s, n, "...")
+}
+
+func trimLastRune(s string) string {
+	if s == "" {
+		return s
+	}
+	_, size := utf8.DecodeLastRuneInString(s)
+	return s[:len(s)-size]
+}
+
+var htmlTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+func plainText(s string) string {
+	s = htmlTagPattern.ReplaceAllString(s, " ")
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func containsString(values []string, want string) bool {
+	for _, v := range values {
+		if v == want {
+			return true
+		}
+	}
+	return false
 }
 
 func max(a, b int) int {
@@ -1829,7 +5379,7 @@ func max(a, b int) int {
 func defaultTableStyles(focused bool) table.Styles {
 	styles := table.DefaultStyles()
 	styles.Header = styles.Header.
-		BorderStyle(lipgloss.NormalBorder()).
+		BorderStyle(ui.Border()).
 		BorderBottom(true).
 		Bold(true).
 		Foreground(lipgloss.Color("250"))
@@ -1843,6 +5393,10 @@ func defaultTableStyles(focused bool) table.Styles {
 			Foreground(lipgloss.Color("252")).
 			Background(lipgloss.Color("236"))
 	}
+	if ui.NoColor {
+		// Without a background color the cursor row needs another cue.
+		styles.Selected = styles.Selected.Reverse(true)
+	}
 	return styles
 }
 
@@ -1863,12 +5417,25 @@ func fitToBox(s string, width, height int) string {
 	return strings.Join(lines, "\n")
 }
 
+// spreadLine puts left at the start of a width-wide line and right at its
+// end, clipping left when both don't fit.
+func spreadLine(left, right string, width int) string {
+	rightWidth := ansi.StringWidth(right)
+	if rightWidth == 0 || rightWidth+2 > width {
+		return fitLineToWidth(left, width)
+	}
+	return fitLineToWidth(left, width-rightWidth-2) + "  " + right
+}
+
 func fitLineToWidth(line string, width int) string {
 	if width <= 0 {
 		return ""
 	}
-	clipped := lipgloss.NewStyle().MaxWidth(width).Render(line)
-	padding := width - lipgloss.Width(clipped)
+	clipped := line
+	if ansi.StringWidth(line) > width {
+		clipped = ansi.Truncate(line, width, "")
+	}
+	padding := width - ansi.StringWidth(clipped)
 	if padding <= 0 {
 		return clipped
 	}
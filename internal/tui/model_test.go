@@ -3,19 +3,32 @@ package tui
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
 	"path/filepath"
 	"reflect"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/table"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/huh"
 	"github.com/charmbracelet/lipgloss"
 
+	"jenkins-tui/internal/cache"
+	"jenkins-tui/internal/config"
 	"jenkins-tui/internal/jenkins"
 	"jenkins-tui/internal/models"
+	"jenkins-tui/internal/redact"
+	"jenkins-tui/internal/search"
+	"jenkins-tui/internal/ui"
 )
 
 func TestParamsStatusMessageMentionsCtrlA(t *testing.T) {
@@ -28,43 +41,80 @@ func TestParamsStatusMessageMentionsCtrlA(t *testing.T) {
 	}
 }
 
-func TestHelpTextForScreenParamsMentionsSelectAll(t *testing.T) {
-	help := helpTextForScreen(screenParams, false, true)
-	if !strings.Contains(help, "ctrl+a: select all/none") {
+func TestHelpOverlayParamsMentionsSelectAll(t *testing.T) {
+	help := renderHelp(defaultKeyMap(), screenParams)
+	if !strings.Contains(help, "ctrl+a") || !strings.Contains(help, "select all / none") {
 		t.Fatalf("expected params help to mention ctrl+a select all/none, got %q", help)
 	}
 }
 
 func TestHelpTextForScreenDoneAddsRerun(t *testing.T) {
-	help := helpTextForScreen(screenDone, true, true)
-	if !strings.Contains(help, "r: rerun failed") {
+	help := helpTextForScreen(defaultKeyMap(), screenDone, true)
+	if !strings.Contains(help, "r rerun failed") {
 		t.Fatalf("expected done help to include rerun shortcut, got %q", help)
 	}
 }
 
-func TestHelpTextForScreenServersMentionsServerActions(t *testing.T) {
-	help := helpTextForScreen(screenServers, false, true)
-	for _, want := range []string{"a/m: add", "e: edit", "t: rotate token", "d: delete"} {
+func TestHelpOverlayServersMentionsServerActions(t *testing.T) {
+	help := renderHelp(defaultKeyMap(), screenServers)
+	for _, want := range []string{"a/m", "add server", "rotate API token", "delete server"} {
 		if !strings.Contains(help, want) {
 			t.Fatalf("expected servers help to include %q, got %q", want, help)
 		}
 	}
+	if !strings.HasPrefix(help, ui.Title.Render("Servers (this screen)")) {
+		t.Fatalf("expected current screen's section first, got %q", help)
+	}
 }
 
-func TestHelpTextForScreenJobsMentionsGlobalSearch(t *testing.T) {
-	help := helpTextForScreen(screenJobs, false, true)
-	if !strings.Contains(help, "g: global search") {
-		t.Fatalf("expected jobs help to include global search shortcut, got %q", help)
+func TestHelpOverlayListsEveryAction(t *testing.T) {
+	help := renderHelp(defaultKeyMap(), screenJobs)
+	for _, a := range keyActions {
+		if !strings.Contains(help, a.desc) {
+			t.Fatalf("help overlay is missing action %s (%q)", a.name, a.desc)
+		}
+	}
+	remapped, _, err := newKeyMap(map[string]models.KeyList{"global_search": {"ctrl+f"}})
+	if err != nil {
+		t.Fatalf("newKeyMap: %v", err)
+	}
+	if help := renderHelp(remapped, screenJobs); !strings.Contains(help, "ctrl+f") {
+		t.Fatalf("expected remapped key in overlay, got %q", help)
 	}
 }
 
 func TestHelpTextCompactMode(t *testing.T) {
-	help := helpTextForScreen(screenGlobalSearch, false, false)
+	help := helpTextForScreen(defaultKeyMap(), screenGlobalSearch, false)
 	if !strings.Contains(help, "type search") {
 		t.Fatalf("expected compact global search help, got %q", help)
 	}
 }
 
+func TestQuestionMarkOpensScrollableHelpOverlay(t *testing.T) {
+	m, ok := NewModel(context.Background(), models.Config{Timeout: time.Second}).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 100, Height: 20})
+	m = updated.(*model)
+	m.screen = screenJobs
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("?")})
+	m = updated.(*model)
+	if !m.helpVisible || !strings.Contains(m.View(), "Jobs (this screen)") {
+		t.Fatalf("expected help overlay for jobs, got %q", m.View())
+	}
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyPgDown})
+	m = updated.(*model)
+	if m.helpView.YOffset == 0 {
+		t.Fatalf("expected pgdown to scroll the overlay")
+	}
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")})
+	m = updated.(*model)
+	if m.helpVisible || m.screen != screenJobs {
+		t.Fatalf("q should close the overlay without quitting")
+	}
+}
+
 func TestJobsLoadedKeepsStaticTitle(t *testing.T) {
 	m, ok := NewModel(context.Background(), models.Config{Timeout: time.Second}).(*model)
 	if !ok {
@@ -84,6 +134,74 @@ func TestJobsLoadedKeepsStaticTitle(t *testing.T) {
 	}
 }
 
+func TestStatusLineCountsListedItems(t *testing.T) {
+	m, ok := NewModel(context.Background(), models.Config{Timeout: time.Second}).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	m.width, m.height = 140, 40
+	m.screen = screenJobs
+	m.jobsReqID = 1
+	updated, _ := m.Update(jobsLoadedMsg{
+		requestID: 1,
+		nodes: []models.JobNode{
+			{Name: "api", FullName: "api", URL: "https://jenkins/job/api/", Kind: models.JobNodeJob},
+			{Name: "apps", FullName: "apps", URL: "https://jenkins/job/apps/", Kind: models.JobNodeFolder},
+			{Name: "web", FullName: "web", URL: "https://jenkins/job/web/", Kind: models.JobNodeJob},
+		},
+	})
+	m = updated.(*model)
+	if got := m.screenCounts(); got != "1 folder, 2 jobs" {
+		t.Fatalf("unexpected jobs counts %q", got)
+	}
+	for _, r := range "/ap" {
+		m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	if got := m.screenCounts(); got != "1 folder, 2 jobs (filtered: 2)" {
+		t.Fatalf("unexpected filtered counts %q", got)
+	}
+	if view := m.View(); !strings.Contains(view, "(filtered: 2)") {
+		t.Fatalf("expected the counts on the status line, got %q", view)
+	}
+
+	m.screen = screenGlobalSearch
+	m.searchQuery = "deploy"
+	m.search.SetItems([]list.Item{listItem{title: "a"}, listItem{title: "b"}})
+	if got := m.screenCounts(); got != "search: 2 results" {
+		t.Fatalf("unexpected search counts %q", got)
+	}
+}
+
+func TestJobCountsFollowNewListings(t *testing.T) {
+	m, ok := NewModel(context.Background(), models.Config{Timeout: time.Second}).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	m.width, m.height = 140, 40
+	m.screen = screenJobs
+	load := func(id uint64, kinds ...models.JobNodeKind) {
+		nodes := make([]models.JobNode, len(kinds))
+		for i, kind := range kinds {
+			name := fmt.Sprintf("node-%d", i)
+			nodes[i] = models.JobNode{Name: name, FullName: name, URL: "https://jenkins/job/" + name + "/", Kind: kind, Color: "blue"}
+		}
+		m.jobsReqID = id
+		updated, _ := m.Update(jobsLoadedMsg{requestID: id, nodes: nodes})
+		m = updated.(*model)
+	}
+	load(1, models.JobNodeFolder, models.JobNodeJob)
+	if got := m.screenCounts(); got != "1 folder, 1 job" {
+		t.Fatalf("unexpected counts %q", got)
+	}
+	load(2, models.JobNodeJob, models.JobNodeJob)
+	if got := m.screenCounts(); got != "2 jobs" {
+		t.Fatalf("expected counts of the new listing, got %q", got)
+	}
+	if title := m.jobs.Items()[0].(listItem).Title(); !strings.Contains(title, ui.JobStatusGlyph("blue")) {
+		t.Fatalf("expected the status glyph drawn with the row, got %q", title)
+	}
+}
+
 func TestJobsViewShowsPathBreadcrumb(t *testing.T) {
 	m, ok := NewModel(context.Background(), models.Config{Timeout: time.Second}).(*model)
 	if !ok {
@@ -221,6 +339,8 @@ func TestManageFormAcceptsTypingAfterInit(t *testing.T) {
 		}
 	}
 
+	// The first-run wizard opens on its welcome step.
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyEnter})
 	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("h"), Alt: false})
 	m = updated.(*model)
 	if got := strings.TrimSpace(m.manageHost); got != "h" {
@@ -228,6 +348,347 @@ func TestManageFormAcceptsTypingAfterInit(t *testing.T) {
 	}
 }
 
+func TestSetupWizardOffersToBrowseJobs(t *testing.T) {
+	m, ok := NewModel(context.Background(), models.Config{
+		ConfigPath: filepath.Join(t.TempDir(), "jenkins.yaml"),
+		Timeout:    time.Second,
+	}).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	if !m.setupWizard || m.manageForm == nil {
+		t.Fatalf("expected the setup wizard form on first run")
+	}
+	m.creds = newStubCreds()
+	m.validateTarget = func(ctx context.Context, target models.JenkinsTarget, token string, timeout time.Duration) error {
+		return nil
+	}
+	m.keyringAvail = true
+	m.manageTokenSrc = tokenStorageKeyring
+	m.manageHost = "https://jenkins.example.com"
+	m.manageUsername = "ci-user"
+	m.manageToken = "api-token-123"
+	m.finishManageForm()
+	if len(m.cfg.Jenkins) != 1 || m.screen != screenServers {
+		t.Fatalf("expected the server to be saved, got %+v on %v", m.cfg.Jenkins, m.screen)
+	}
+	if m.setupWizard || m.confirm == nil || !strings.Contains(m.confirm.form.View(), "Browse its jobs now?") {
+		t.Fatalf("expected an offer to browse jobs after setup")
+	}
+	m.confirm.onYes()
+	if m.target == nil || m.target.ID != m.cfg.Jenkins[0].ID || m.screen != screenJobs {
+		t.Fatalf("accepting should connect and open the jobs screen, got target %+v on %v", m.target, m.screen)
+	}
+}
+
+func TestRotateEnvTokenSwitchesVariable(t *testing.T) {
+	m := newTestManageModel(t, newStubCreds())
+	m.cfg.Jenkins = []models.JenkinsTarget{
+		{ID: "prod", Name: "prod", Host: "https://jenkins.example.com", Username: "u", Credential: models.Credential{Type: models.CredentialTypeEnv, Ref: "JENKINS_TOKEN"}},
+	}
+	m.rejectedTokens = map[string]bool{"prod": true}
+	m.lookupEnv = func(key string) string {
+		if key == "JENKINS_TOKEN_NEW" {
+			return "fresh"
+		}
+		return ""
+	}
+	var checked string
+	m.validateTarget = func(ctx context.Context, target models.JenkinsTarget, token string, timeout time.Duration) error {
+		checked = target.Credential.Ref + "=" + token
+		return nil
+	}
+	m.startManageForm(manageModeRotate, 0)
+	if m.manageEnvVar != "JENKINS_TOKEN" {
+		t.Fatalf("expected the current variable prefilled, got %q", m.manageEnvVar)
+	}
+
+	if err := m.applyManageForm(); err != nil || checked != "" || !strings.Contains(m.status, "restart") {
+		t.Fatalf("keeping the name should only explain the restart, err=%v checked=%q status=%q", err, checked, m.status)
+	}
+	m.manageEnvVar = "JENKINS_TOKEN_MISSING"
+	if err := m.applyManageForm(); err == nil || !strings.Contains(err.Error(), "not set") {
+		t.Fatalf("expected an unset variable to be refused, got %v", err)
+	}
+	m.manageEnvVar = "JENKINS_TOKEN_NEW"
+	if err := m.applyManageForm(); err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	if checked != "JENKINS_TOKEN_NEW=fresh" || m.cfg.Jenkins[0].Credential.Ref != "JENKINS_TOKEN_NEW" || m.rejectedTokens["prod"] {
+		t.Fatalf("expected the new variable checked and saved, checked=%q target=%+v", checked, m.cfg.Jenkins[0])
+	}
+	loaded, err := config.Load(m.cfg.ConfigPath)
+	if err != nil || loaded.Jenkins[0].Credential.Ref != "JENKINS_TOKEN_NEW" {
+		t.Fatalf("expected the new variable persisted, got %+v err=%v", loaded.Jenkins, err)
+	}
+}
+
+func TestMoveTokenBetweenBackends(t *testing.T) {
+	creds := newStubCreds()
+	m := newTestManageModel(t, creds)
+	m.cfg.Jenkins = []models.JenkinsTarget{
+		{ID: "prod", Name: "prod", Host: "https://jenkins.example.com", Username: "u", Credential: models.Credential{Type: models.CredentialTypeEnv, Ref: "JENKINS_TOKEN"}},
+	}
+	env := map[string]string{"JENKINS_TOKEN": "secret"}
+	m.lookupEnv = func(key string) string { return env[key] }
+
+	if err := m.moveTokenToKeyring("prod", "secret"); err != nil {
+		t.Fatalf("move to keyring: %v", err)
+	}
+	moved := m.cfg.Jenkins[0].Credential
+	if moved.Type != models.CredentialTypeKeyring || moved.Ref != "jenkins-tui/prod" || creds.values["jenkins-tui/prod"] != "secret" {
+		t.Fatalf("expected the token in the keyring, got %+v values=%v", moved, creds.values)
+	}
+
+	m.startManageForm(manageModeMoveToEnv, 0)
+	if m.manageEnvVar != "JENKINS_TOKEN_PROD" {
+		t.Fatalf("expected a suggested variable name, got %q", m.manageEnvVar)
+	}
+	if err := m.applyManageForm(); err == nil || creds.values["jenkins-tui/prod"] == "" {
+		t.Fatalf("an unset variable must keep the keyring entry, err=%v", err)
+	}
+	env["JENKINS_TOKEN_PROD"] = "secret"
+	if err := m.applyManageForm(); err != nil {
+		t.Fatalf("move to env: %v", err)
+	}
+	moved = m.cfg.Jenkins[0].Credential
+	if moved.Type != models.CredentialTypeEnv || moved.Ref != "JENKINS_TOKEN_PROD" {
+		t.Fatalf("expected the env credential, got %+v", moved)
+	}
+	if _, ok := creds.values["jenkins-tui/prod"]; ok {
+		t.Fatalf("the keyring entry should be deleted once the config is saved")
+	}
+	loaded, err := config.Load(m.cfg.ConfigPath)
+	if err != nil || loaded.Jenkins[0].Credential.Ref != "JENKINS_TOKEN_PROD" {
+		t.Fatalf("expected the move persisted, got %+v err=%v", loaded.Jenkins, err)
+	}
+}
+
+func TestConcurrentSavesMergeServers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jenkins.yaml")
+	cfg := models.Config{ConfigPath: path, Jenkins: []models.JenkinsTarget{
+		{ID: "prod", Name: "prod", Host: "https://prod", Username: "u", Credential: models.Credential{Type: models.CredentialTypeEnv, Ref: "TOKEN"}},
+	}}
+	if err := config.Save(path, cfg); err != nil {
+		t.Fatal(err)
+	}
+	open := func() *model {
+		loaded, err := config.Load(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		loaded.ConfigPath = path
+		m, ok := NewModel(context.Background(), loaded).(*model)
+		if !ok {
+			t.Fatalf("NewModel should return *model")
+		}
+		m.creds = newStubCreds()
+		return m
+	}
+	a, b := open(), open()
+
+	a.cfg.Jenkins = append(a.cfg.Jenkins, models.JenkinsTarget{ID: "dev", Name: "dev", Host: "https://dev", Username: "u", Credential: models.Credential{Type: models.CredentialTypeEnv, Ref: "TOKEN"}})
+	a.cfg.Jenkins[0].Name = "production"
+	if err := a.persistConfig(); err != nil {
+		t.Fatalf("first instance: %v", err)
+	}
+	b.cfg.Jenkins = append(b.cfg.Jenkins, models.JenkinsTarget{ID: "qa", Name: "qa", Host: "https://qa", Username: "u", Credential: models.Credential{Type: models.CredentialTypeEnv, Ref: "TOKEN"}})
+	b.cfg.Jenkins[0].Name = "prod-b"
+	if err := b.persistConfig(); err != nil {
+		t.Fatalf("second instance: %v", err)
+	}
+	loaded, err := config.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, tgt := range loaded.Jenkins {
+		ids = append(ids, tgt.ID+"="+tgt.Name)
+	}
+	if got := strings.Join(ids, " "); got != "prod=production dev=dev qa=qa" {
+		t.Fatalf("expected both instances' servers kept, got %s", got)
+	}
+	if b.configConflict == nil {
+		t.Fatalf("expected the rename of prod to be offered back")
+	}
+	b = drainCmd(t, b, func() tea.Msg { return configTickMsg{} }, 0)
+	if b.confirm == nil || !strings.Contains(b.confirm.form.View(), "Both changed prod-b") {
+		t.Fatalf("expected a conflict prompt")
+	}
+	b.confirm.onYes()
+	if loaded, _ := config.Load(path); loaded.Jenkins[0].Name != "prod-b" || len(loaded.Jenkins) != 3 {
+		t.Fatalf("keeping ours should save our prod, got %+v", loaded.Jenkins)
+	}
+}
+
+func TestPastedTokenIsCheckedOnceSettled(t *testing.T) {
+	m := newTestManageModel(t, newStubCreds())
+	m.startManageForm(manageModeAdd, -1)
+	m.screen = screenManageForm
+	m.keyringAvail = true
+	m.manageTokenSrc = tokenStorageKeyring
+	m.manageHost = "https://jenkins.example.com"
+	m.manageUsername = "ci-user"
+	calls := 0
+	m.validateTarget = func(ctx context.Context, target models.JenkinsTarget, token string, timeout time.Duration) error {
+		calls++
+		if token != "good" {
+			return &jenkins.StatusError{Op: "GET https://jenkins.example.com/api/json", Code: http.StatusUnauthorized, Body: "unauthorized"}
+		}
+		return nil
+	}
+
+	m.manageToken = "bad"
+	m.pollToken()
+	if calls != 0 {
+		t.Fatalf("a token that just changed should not be checked yet")
+	}
+	cmd := m.pollToken()
+	m = drainCmd(t, m, cmd, 0)
+	if calls != 1 || !strings.Contains(m.tokenCheckLine(), "Authentication failed") {
+		t.Fatalf("expected a failed check, calls=%d line=%q", calls, m.tokenCheckLine())
+	}
+	m.pollToken()
+	if calls != 1 {
+		t.Fatalf("an unchanged token should not be re-checked")
+	}
+
+	m.manageToken = "good"
+	m.pollToken()
+	m = drainCmd(t, m, m.pollToken(), 0)
+	if !strings.Contains(m.tokenCheckLine(), "Token accepted") {
+		t.Fatalf("expected the new token to be accepted, got %q", m.tokenCheckLine())
+	}
+	m.manageUsername = "someone-else"
+	if m.tokenCheckLine() != "" {
+		t.Fatalf("editing the username should hide the stale result")
+	}
+}
+
+func TestGenerateTokenFillsTheTokenField(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/user/ci-user/descriptorByName/jenkins.security.ApiTokenProperty/generateNewToken" {
+			http.NotFound(w, r)
+			return
+		}
+		if _, pass, _ := r.BasicAuth(); pass != "s3cret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		_, _ = fmt.Fprint(w, `{"status":"ok","data":{"tokenName":"jenkins-tui","tokenValue":"11aa22bb"}}`)
+	}))
+	defer srv.Close()
+	m := newTestManageModel(t, newStubCreds())
+	m.cfg.Jenkins = []models.JenkinsTarget{{ID: "ci", Name: "ci", Host: srv.URL, Username: "ci-user", Credential: models.Credential{Type: models.CredentialTypeKeyring, Ref: "jenkins-tui/ci"}}}
+	m.startManageForm(manageModeEdit, 0)
+	m.screen = screenManageForm
+
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyCtrlG})
+	if m.confirm == nil || !strings.Contains(m.confirm.form.View(), "Jenkins password for ci-user") {
+		t.Fatalf("expected a password prompt")
+	}
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("wrong")})
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.manageToken != "" || !strings.Contains(m.status, "rejected the password") {
+		t.Fatalf("expected a rejected password to leave the field empty, got %q (%s)", m.manageToken, m.status)
+	}
+
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyCtrlG})
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s3cret")})
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.manageToken != "11aa22bb" || !strings.Contains(m.status, "password was not stored") {
+		t.Fatalf("expected the generated token in the form, got %q (%s)", m.manageToken, m.status)
+	}
+	if strings.Contains(m.View(), "s3cret") {
+		t.Fatalf("the password should never be shown")
+	}
+}
+
+func TestEditServerKeepsFieldsTheFormDoesNotShow(t *testing.T) {
+	m := newTestManageModel(t, newStubCreds())
+	m.lookupEnv = func(string) string { return "token" }
+	saved := models.JenkinsTarget{
+		ID:          "ci",
+		Name:        "ci",
+		Host:        "https://ci.example.com",
+		Username:    "ci-user",
+		Credential:  models.Credential{Type: models.CredentialTypeEnv, Ref: "CI_TOKEN"},
+		AuthCommand: "vault read -field=token ci",
+		Bookmarks:   []string{"platform"},
+		Watches:     []string{"platform/deploy"},
+		Tags:        []string{"prod", "eu"},
+		Aliases:     map[string]string{"deploy": "platform/deploy"},
+		Transport:   models.TransportTuning{MaxIdleConnsPerHost: 4},
+		URLRewrites: []models.URLRewrite{{From: "http://jenkins.internal:8080", To: "https://ci.example.com"}},
+		Proxy:       models.ProxySettings{URL: "http://proxy.corp:3128", Auth: "ntlm"},
+		Auth:        models.AuthKerberos,
+		KerberosSPN: "HTTP/jenkins-lb.corp.example.com",
+	}
+	m.cfg.Jenkins = []models.JenkinsTarget{saved}
+	m.startManageForm(manageModeEdit, 0)
+	m.manageName = "CI"
+
+	if err := m.applyManageForm(); err != nil {
+		t.Fatalf("applyManageForm: %v", err)
+	}
+	want := saved
+	want.Name = "CI"
+	if got := m.cfg.Jenkins[0]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("edit changed fields the form does not own:\n got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestRejectedTokenRunsAuthCommandForAnyRequest(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, token, _ := r.BasicAuth(); token != "fresh" {
+			http.Error(w, "expired", http.StatusUnauthorized)
+			return
+		}
+		_, _ = fmt.Fprint(w, `{"property":[{"parameterDefinitions":[{"name":"REGION","type":"StringParameterDefinition"}]}]}`)
+	}))
+	defer srv.Close()
+	target := models.JenkinsTarget{ID: "ci", Name: "ci", Host: srv.URL, Username: "u", AuthCommand: "vault read -field=token ci"}
+	m := NewModel(context.Background(), models.Config{Timeout: time.Second, CacheDir: t.TempDir(), Jenkins: []models.JenkinsTarget{target}}).(*model)
+	m.creds = newStubCreds()
+	m.target = &target
+	m.useClient(target, "expired")
+	client := m.client
+
+	loaded := make(chan tea.Msg, 1)
+	go func() { loaded <- loadParamsCmd(m.ctx, m.client, srv.URL+"/job/deploy/")() }()
+	updated, cmd := m.Update(waitReauthCmd(m.reauthCh)())
+	m = updated.(*model)
+	if cmd == nil || !strings.Contains(m.status, "running auth command") {
+		t.Fatalf("expected the auth command to start, got %q", m.status)
+	}
+	updated, _ = m.Update(authCompletedMsg{target: target, token: "fresh"})
+	m = updated.(*model)
+	select {
+	case msg := <-loaded:
+		if got := msg.(paramsLoadedMsg); got.err != nil || len(got.params) != 1 {
+			t.Fatalf("expected the request retried with the new token, got %+v", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("the client never got the new token")
+	}
+	if m.client != client || m.status != "Authenticated" {
+		t.Fatalf("expected the same client signed in again, got a new one=%v status=%q", m.client != client, m.status)
+	}
+}
+
+func TestAPITokenPageURL(t *testing.T) {
+	tests := []struct{ host, user, want string }{
+		{"https://ci.example.com/", "jane doe", "https://ci.example.com/user/jane%20doe/configure"},
+		{"ci.example.com", "", "https://ci.example.com/me/configure"},
+		{"", "jane", "<jenkins-url>/user/jane/configure"},
+	}
+	for _, tc := range tests {
+		if got := apiTokenPageURL(tc.host, tc.user); got != tc.want {
+			t.Fatalf("apiTokenPageURL(%q, %q) = %q, want %q", tc.host, tc.user, got, tc.want)
+		}
+	}
+}
+
 func TestAllowQuickQuitDisabledOnFormScreens(t *testing.T) {
 	m := &model{}
 	m.screen = screenParams
@@ -287,6 +748,38 @@ func TestDefaultKeyringRef(t *testing.T) {
 	}
 }
 
+func TestApplyManageFormRequiresOptInForPlainHTTP(t *testing.T) {
+	m := newTestManageModel(t, newStubCreds())
+	m.manageMode = manageModeAdd
+	m.manageHost = "http://jenkins.example.com"
+	m.manageUsername = "ci-user"
+	m.manageTokenSrc = tokenStorageKeyring
+	m.manageToken = "api-token-123"
+	validated := false
+	m.validateTarget = func(ctx context.Context, target models.JenkinsTarget, token string, timeout time.Duration) error {
+		validated = true
+		return nil
+	}
+
+	if !strings.Contains(m.plainHTTPLine(), "unencrypted") {
+		t.Fatalf("expected a warning under the form, got %q", m.plainHTTPLine())
+	}
+	if _, ok := m.formTokenTarget(); ok {
+		t.Fatalf("the typed token should not be checked over plain HTTP before it is allowed")
+	}
+	if err := m.applyManageForm(); err == nil || !strings.Contains(err.Error(), "Allow plain HTTP") || validated {
+		t.Fatalf("expected the plain HTTP URL to be refused without contacting it, got %v", err)
+	}
+
+	m.manageHTTP = "true"
+	if err := m.applyManageForm(); err != nil {
+		t.Fatalf("applyManageForm: %v", err)
+	}
+	if got := m.cfg.Jenkins[0]; !got.AllowInsecureHTTP {
+		t.Fatalf("expected allow_insecure_http to be saved, got %+v", got)
+	}
+}
+
 func TestApplyManageFormAddKeyringSuccess(t *testing.T) {
 	creds := newStubCreds()
 	m := newTestManageModel(t, creds)
@@ -365,7 +858,7 @@ func TestApplyManageFormValidationFailureDoesNotWriteKeyring(t *testing.T) {
 	m.manageToken = "api-token-123"
 	m.keyringAvail = true
 	m.validateTarget = func(ctx context.Context, target models.JenkinsTarget, token string, timeout time.Duration) error {
-		return errors.New("GET https://jenkins.example.com/api/json failed (401): unauthorized")
+		return &jenkins.StatusError{Op: "GET https://jenkins.example.com/api/json", Code: http.StatusUnauthorized, Body: "unauthorized"}
 	}
 
 	err := m.applyManageForm()
@@ -499,6 +992,12 @@ func (s *stubCreds) Resolve(target models.JenkinsTarget) (string, error) {
 	return val, nil
 }
 
+func (s *stubCreds) Remember(target models.JenkinsTarget, token string) {
+	s.values[target.Credential.Ref] = token
+}
+
+func (s *stubCreds) Forget(target models.JenkinsTarget) {}
+
 func (s *stubCreds) SetKeyring(ref, value string) error {
 	s.values[ref] = value
 	s.setCount++
@@ -514,6 +1013,8 @@ func (s *stubCreds) KeyringAvailable() (bool, error) {
 	return s.avail, nil
 }
 
+func (s *stubCreds) SetCacheTTL(ttl time.Duration) {}
+
 func newTestManageModel(t *testing.T, creds credentialsManager) *model {
 	t.Helper()
 	cfgPath := filepath.Join(t.TempDir(), "jenkins.yaml")
@@ -531,3 +1032,2956 @@ func newTestManageModel(t *testing.T, creds credentialsManager) *model {
 		},
 	}
 }
+
+func TestClipCountsWideRunes(t *testing.T) {
+	tests := []struct {
+		in   string
+		n    int
+		want string
+	}{
+		{in: "deploy", n: 10, want: "deploy"},
+		{in: "デプロイ", n: 8, want: "デプロイ"},
+		{in: "デプロイジョブ", n: 9, want: "デプロ..."},
+		{in: "デプロイジョブ", n: 8, want: "デプ..."},
+		{in: "🚀🚀🚀🚀", n: 7, want: "🚀🚀..."},
+		{in: "日本語", n: 3, want: "日"},
+	}
+	for _, tc := range tests {
+		got := clip(tc.in, tc.n)
+		if got != tc.want {
+			t.Fatalf("clip(%q, %d) = %q, want %q", tc.in, tc.n, got, tc.want)
+		}
+		if w := lipgloss.Width(got); w > tc.n {
+			t.Fatalf("clip(%q, %d) width = %d, exceeds limit", tc.in, tc.n, w)
+		}
+	}
+}
+
+func TestFitLineToWidthPadsWideRunesExactly(t *testing.T) {
+	for _, line := range []string{"ビルド", "job-名前-🚀", "ascii only", "日本語のとても長いジョブ名"} {
+		got := fitLineToWidth(line, 9)
+		if w := lipgloss.Width(got); w != 9 {
+			t.Fatalf("fitLineToWidth(%q, 9) width = %d, want 9 (%q)", line, w, got)
+		}
+	}
+}
+
+func TestGlobalSearchBackspaceRemovesWholeRune(t *testing.T) {
+	m, ok := NewModel(context.Background(), models.Config{Timeout: time.Second}).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	m.screen = screenGlobalSearch
+	m.searchInput = "ジョブ"
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	m = updated.(*model)
+	if m.searchInput != "ジョ" {
+		t.Fatalf("expected backspace to drop one rune, got %q", m.searchInput)
+	}
+}
+
+func TestBuildParamFormPrefillsFromPreviousBuild(t *testing.T) {
+	m := &model{
+		params: []models.ParamDef{
+			{Name: "region", Kind: models.ParamChoice, Choices: []string{"us", "eu"}},
+			{Name: "reason", Kind: models.ParamString, Default: "default"},
+			{Name: "secret", Kind: models.ParamPassword, Default: ""},
+		},
+		paramPrefill: map[string]string{"region": "eu", "reason": "hotfix", "secret": "<masked>"},
+	}
+	m.buildParamForm()
+	if got := *m.choiceVars["region"]; !reflect.DeepEqual(got, []string{"eu"}) {
+		t.Fatalf("expected region preselected from build, got %v", got)
+	}
+	if got := *m.fixedVars["reason"]; got != "hotfix" {
+		t.Fatalf("expected reason prefilled from build, got %q", got)
+	}
+	if got := *m.fixedVars["secret"]; got != "" {
+		t.Fatalf("password params must not be prefilled, got %q", got)
+	}
+}
+
+func TestBuildParamFormFillsCheckedOutBranch(t *testing.T) {
+	m := &model{
+		cfg: models.Config{Startup: models.StartupLink{Branch: "feature/login"}},
+		params: []models.ParamDef{
+			{Name: "branch_name", Kind: models.ParamString, Default: "main"},
+			{Name: "GIT_BRANCH", Kind: models.ParamChoice, Choices: []string{"main", "feature/login"}},
+			{Name: "BRANCH", Kind: models.ParamString, Default: "main"},
+			{Name: "reason", Kind: models.ParamString, Default: "default"},
+		},
+		paramPrefill: map[string]string{"BRANCH": "release-2024"},
+	}
+	m.buildParamForm()
+	if got := *m.fixedVars["branch_name"]; got != "feature/login" {
+		t.Fatalf("expected branch_name filled with the checked-out branch, got %q", got)
+	}
+	if got := *m.choiceVars["GIT_BRANCH"]; !reflect.DeepEqual(got, []string{"feature/login"}) {
+		t.Fatalf("expected GIT_BRANCH to select the checked-out branch, got %v", got)
+	}
+	if got := *m.fixedVars["BRANCH"]; got != "release-2024" {
+		t.Fatalf("a rebuild's BRANCH should win over the checkout, got %q", got)
+	}
+	if got := *m.fixedVars["reason"]; got != "default" {
+		t.Fatalf("other params keep their default, got %q", got)
+	}
+	if got := m.branchFilledParam(); got != "branch_name" {
+		t.Fatalf("branchFilledParam = %q", got)
+	}
+}
+
+func TestDetectedBranchOnlyFillsJobsBuildingTheCheckout(t *testing.T) {
+	m := &model{
+		cfg:         models.Config{Startup: models.StartupLink{Branch: "feature/login", BranchDetected: true}},
+		params:      []models.ParamDef{{Name: "BRANCH", Kind: models.ParamString, Default: "main"}},
+		selectedJob: &models.JobRef{Name: "deploy", FullName: "ops/deploy"},
+	}
+	m.buildParamForm()
+	if got := *m.fixedVars["BRANCH"]; got != "main" {
+		t.Fatalf("a job unrelated to the checkout should keep its default, got %q", got)
+	}
+
+	m.repoJobsLoaded(repoJobsMsg{nodes: []models.JobNode{{Name: "web", FullName: "apps/web", Kind: models.JobNodeFolder, Multibranch: true}}}, nil)
+	m.selectedJob = &models.JobRef{Name: "feature%2Flogin", FullName: "apps/web/feature%2Flogin"}
+	m.buildParamForm()
+	if got := *m.fixedVars["BRANCH"]; got != "feature/login" {
+		t.Fatalf("a job of the project building the checkout should get the branch, got %q", got)
+	}
+	m.selectedJob = &models.JobRef{Name: "web-e2e", FullName: "apps/web-e2e"}
+	m.buildParamForm()
+	if got := *m.fixedVars["BRANCH"]; got != "main" {
+		t.Fatalf("a sibling whose name shares the prefix should keep its default, got %q", got)
+	}
+}
+
+func TestMultibranchFolderHighlightsCheckedOutBranch(t *testing.T) {
+	m, ok := NewModel(context.Background(), models.Config{Timeout: time.Second, Startup: models.StartupLink{Branch: "feature/login"}}).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	m.screen = screenJobs
+	m.jobFolders = []models.JobNode{{Name: "web", FullName: "web", URL: "https://jenkins/job/web/", Kind: models.JobNodeFolder, Multibranch: true}}
+	m.jobsReqID = 1
+	updated, _ := m.Update(jobsLoadedMsg{
+		requestID: 1,
+		nodes: []models.JobNode{
+			{Name: "PR-7", FullName: "web/PR-7", URL: "https://jenkins/job/web/job/PR-7/", Kind: models.JobNodeJob},
+			{Name: "feature%2Flogin", FullName: "web/feature%2Flogin", URL: "https://jenkins/job/web/job/feature%252Flogin/", Kind: models.JobNodeJob},
+			{Name: "main", FullName: "web/main", URL: "https://jenkins/job/web/job/main/", Kind: models.JobNodeJob},
+		},
+	})
+	m = updated.(*model)
+	if item, _ := m.jobs.SelectedItem().(listItem); item.name != "feature%2Flogin" {
+		t.Fatalf("expected the branch job to be highlighted, got %q", item.name)
+	}
+	if !strings.Contains(m.status, "your branch feature/login is highlighted") {
+		t.Fatalf("expected a note about the branch, got %q", m.status)
+	}
+}
+
+func TestBuildParamFormOffersAgentsForNodeParams(t *testing.T) {
+	m := &model{
+		params: []models.ParamDef{
+			{Name: "NODE", Kind: models.ParamNode, Default: "linux-1", Choices: []string{"built-in", "linux-1"}},
+			{Name: "LABEL", Kind: models.ParamLabel, Default: "docker"},
+		},
+	}
+	m.buildParamForm()
+	if got := *m.choiceVars["NODE"]; !reflect.DeepEqual(got, []string{"linux-1"}) {
+		t.Fatalf("expected default agent preselected, got %v", got)
+	}
+	if got, ok := m.fixedVars["LABEL"]; !ok || *got != "docker" {
+		t.Fatalf("a label param without choices should fall back to text, got %v", got)
+	}
+}
+
+func TestHistoryRebuildLoadsParamsWithPrefill(t *testing.T) {
+	m, ok := NewModel(context.Background(), models.Config{Timeout: time.Second}).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	m.client = jenkins.NewClient(models.JenkinsTarget{Host: "https://jenkins.example.com"}, "token", time.Second)
+	m.historyJob = &models.JobRef{Name: "deploy", FullName: "infra/deploy", URL: "https://jenkins.example.com/job/infra/job/deploy/"}
+	updated, _ := m.Update(historyLoadedMsg{builds: []models.BuildSummary{
+		{Number: 42, Result: "FAILURE", Params: map[string]string{"region": "eu"}},
+	}})
+	m = updated.(*model)
+	if m.screen != screenHistory {
+		t.Fatalf("expected history screen, got %v", m.screen)
+	}
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(*model)
+	if cmd == nil {
+		t.Fatalf("expected params load command")
+	}
+	if m.selectedJob == nil || m.selectedJob.FullName != "infra/deploy" {
+		t.Fatalf("expected history job to become selected job, got %+v", m.selectedJob)
+	}
+	if m.paramPrefill["region"] != "eu" || m.paramsBackTo != screenHistory {
+		t.Fatalf("expected prefill from build #42 and back-navigation to history, got %v / %v", m.paramPrefill, m.paramsBackTo)
+	}
+}
+
+func TestHistoryComparesMarkedBuilds(t *testing.T) {
+	m, ok := NewModel(context.Background(), models.Config{Timeout: time.Second}).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = updated.(*model)
+	m.historyJob = &models.JobRef{Name: "deploy", FullName: "deploy", URL: "https://jenkins/job/deploy/"}
+	updated, _ = m.Update(historyLoadedMsg{builds: []models.BuildSummary{
+		{Number: 42, Result: "FAILURE", Params: map[string]string{"region": "us", "tag": "v2", "dry_run": "false"}},
+		{Number: 41, Result: "SUCCESS", Params: map[string]string{"region": "eu", "tag": "v2"}},
+	}})
+	m = updated.(*model)
+	press := func(k string) {
+		t.Helper()
+		updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+		m = updated.(*model)
+	}
+	press("m")
+	press("D")
+	if m.screen != screenHistory || !strings.Contains(m.status, "Mark exactly two builds") {
+		t.Fatalf("compare should need two marked builds, got %v %q", m.screen, m.status)
+	}
+	m.historyTable.SetCursor(1)
+	press("m")
+	if !strings.Contains(m.historyTable.View(), "*41") {
+		t.Fatalf("expected the marked build flagged in the table, got %q", m.historyTable.View())
+	}
+	press("D")
+	if m.screen != screenLogDiff {
+		t.Fatalf("expected the comparison view, got %v", m.screen)
+	}
+	if m.status != "Builds #41 and #42: 2 parameter(s) differ" {
+		t.Fatalf("unexpected status %q", m.status)
+	}
+	view := m.logDiff.View()
+	for _, want := range []string{"#41", "#42", "SUCCESS", "FAILURE", "(not set)", "eu", "us"} {
+		if !strings.Contains(view, want) {
+			t.Fatalf("expected %q in comparison, got %q", want, view)
+		}
+	}
+	if strings.Index(view, "#41") > strings.Index(view, "#42") {
+		t.Fatalf("expected the older build on the left, got %q", view)
+	}
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = updated.(*model)
+	if m.screen != screenHistory {
+		t.Fatalf("esc should return to history, got %v", m.screen)
+	}
+}
+
+func TestRunMarkingLimitsDiffToTwoRuns(t *testing.T) {
+	m, ok := NewModel(context.Background(), models.Config{Timeout: time.Second}).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	m.screen = screenDone
+	b := m.startBatch(models.JobRef{Name: "a", URL: "https://jenkins/job/a/"}, make([]models.JobSpec, 3), false)
+	b.records = []models.RunRecord{
+		{Index: 0, State: models.RunSuccess, BuildURL: "https://jenkins/job/a/1/"},
+		{Index: 1, State: models.RunFailed, BuildURL: "https://jenkins/job/a/2/"},
+		{Index: 2, State: models.RunFailed},
+	}
+	m.refreshRunTable(b)
+
+	press := func(key string) tea.Cmd {
+		updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+		m = updated.(*model)
+		return cmd
+	}
+	press("m")
+	if m.diffMarkedRunsCmd() != nil {
+		t.Fatalf("diff should require two marked runs")
+	}
+	b.table.SetCursor(1)
+	press("m")
+	b.table.SetCursor(2)
+	press("m")
+	if len(b.marks) != 2 || b.marks[2] {
+		t.Fatalf("expected only runs 1 and 2 marked, got %v", b.marks)
+	}
+	if !strings.HasPrefix(b.table.Rows()[0][0], "*") {
+		t.Fatalf("expected marked run to be flagged in table, got %q", b.table.Rows()[0][0])
+	}
+	if m.diffMarkedRunsCmd() == nil || !m.loading {
+		t.Fatalf("expected diff command for two marked runs")
+	}
+}
+
+func TestStalledRunRecoversWhenServerReturns(t *testing.T) {
+	m, ok := NewModel(context.Background(), models.Config{Timeout: time.Second}).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	m.width, m.height = 120, 40
+	b := m.startBatch(models.JobRef{Name: "a", URL: "https://jenkins/job/a/"}, make([]models.JobSpec, 2), false)
+	m.screen = screenRun
+	running := models.RunUpdate{Index: 0, State: models.RunRunning, BuildURL: "https://jenkins/job/a/1/", BuildNumber: 1}
+	stalled := running
+	stalled.State = models.RunStalled
+	stalled.Err = errors.New("dial tcp: connection refused")
+
+	updated, _ := m.Update(runEventMsg{batch: b.id, update: stalled})
+	m = updated.(*model)
+	if got := b.summary(); got != "0/2 done, 1 stalled" {
+		t.Fatalf("expected the stalled run in the summary, got %q", got)
+	}
+	if view := m.View(); !strings.Contains(view, "STALLED") || !strings.Contains(view, "1 run(s) stalled") {
+		t.Fatalf("expected the stalled run on the run screen, got %q", view)
+	}
+
+	updated, _ = m.Update(runEventMsg{batch: b.id, update: running})
+	m = updated.(*model)
+	if r := b.records[0]; r.State != models.RunRunning || r.Err != "" || b.stalled() != 0 {
+		t.Fatalf("expected the run to resume without the stall error, got %+v", r)
+	}
+}
+
+func TestStatusBarFollowsEveryScreen(t *testing.T) {
+	target := models.JenkinsTarget{ID: "prod", Name: "prod", Host: "https://jenkins", Username: "alice"}
+	m, ok := NewModel(context.Background(), models.Config{Timeout: time.Second, Jenkins: []models.JenkinsTarget{target}}).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	m.width, m.height = 160, 40
+	if view := m.View(); !strings.Contains(view, "No server selected") {
+		t.Fatalf("expected the status bar before connecting, got %q", view)
+	}
+
+	m.target = &m.cfg.Jenkins[0]
+	m.client = jenkins.NewClient(target, "token", time.Second)
+	m.serverVersions["prod"] = "2.440.3"
+	m.jobsFetchedAt = time.Now()
+	b := m.startBatch(models.JobRef{Name: "deploy", FullName: "deploy", URL: "https://jenkins/job/deploy/"}, make([]models.JobSpec, 2), false)
+	b.apply(models.RunUpdate{Index: 0, State: models.RunFailed, Done: true})
+	for _, s := range []screen{screenJobs, screenHistory, screenConsole, screenDone} {
+		m.screen = s
+		view := m.View()
+		for _, want := range []string{"prod (Jenkins 2.440.3)", "user alice", "online", "jobs fetched just now", "1/2 done, 1 failed"} {
+			if !strings.Contains(view, want) {
+				t.Fatalf("expected %q in the status bar on screen %v, got %q", want, s, view)
+			}
+		}
+	}
+
+	m.offline = true
+	if view := m.View(); !strings.Contains(view, "offline") {
+		t.Fatalf("expected the status bar to show offline mode, got %q", view)
+	}
+}
+
+func TestJobsViewShowsHighlightedJobDetail(t *testing.T) {
+	m, ok := NewModel(context.Background(), models.Config{Timeout: time.Second}).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	m.width = 120
+	m.height = 40
+	m.client = jenkins.NewClient(models.JenkinsTarget{Host: "https://jenkins.example.com"}, "token", time.Second)
+	m.jobsReqID = 1
+	jobURL := "https://jenkins.example.com/job/deploy/"
+	updated, cmd := m.Update(jobsLoadedMsg{
+		requestID: 1,
+		nodes:     []models.JobNode{{Name: "deploy", FullName: "deploy", URL: jobURL, Kind: models.JobNodeJob}},
+	})
+	m = updated.(*model)
+	if cmd == nil {
+		t.Fatalf("expected detail fetch to be scheduled")
+	}
+	if view := m.View(); !strings.Contains(view, "Loading job details") {
+		t.Fatalf("expected loading placeholder, got %q", view)
+	}
+	updated, _ = m.Update(jobDetailLoadedMsg{url: jobURL, detail: models.JobDetail{
+		Description: "<p>Deploys the <b>prod</b> stack</p>",
+		Color:       "red_anime",
+		HealthScore: 40,
+		LastBuild: &models.BuildSummary{Number: 17, Result: "FAILURE", Changes: []models.Change{
+			{ID: "a1b2c3d4e5f6", Author: "Alice", Message: "Fix login redirect\n\nLonger body"},
+		}},
+	}})
+	m = updated.(*model)
+	view := m.View()
+	for _, want := range []string{"Description: Deploys the prod stack", "Status: failed, building", "Health: 40%", "Last build: #17 FAILURE",
+		"Changes (1):", "a1b2c3d Alice: Fix login redirect"} {
+		if !strings.Contains(view, want) {
+			t.Fatalf("expected job detail %q in view, got %q", want, view)
+		}
+	}
+}
+
+func TestJobsLoadedRendersStatusGlyphs(t *testing.T) {
+	m, ok := NewModel(context.Background(), models.Config{Timeout: time.Second}).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	m.jobsReqID = 1
+	updated, _ := m.Update(jobsLoadedMsg{
+		requestID: 1,
+		nodes: []models.JobNode{
+			{Name: "apps", FullName: "apps", URL: "https://jenkins/job/apps/", Kind: models.JobNodeFolder},
+			{Name: "build", FullName: "build", URL: "https://jenkins/job/build/", Kind: models.JobNodeJob, Color: "red"},
+			{Name: "deploy", FullName: "deploy", URL: "https://jenkins/job/deploy/", Kind: models.JobNodeJob, Color: "blue_anime"},
+		},
+	})
+	m = updated.(*model)
+	items := m.jobs.Items()
+	folder := items[0].(listItem)
+	if folder.glyph != "" || folder.Description() != "folder" {
+		t.Fatalf("folders should not get a status glyph, got %q / %q", folder.Title(), folder.Description())
+	}
+	failed := items[1].(listItem)
+	if !strings.Contains(failed.Title(), "✖") || failed.Description() != "failed" {
+		t.Fatalf("expected failed glyph and label, got %q / %q", failed.Title(), failed.Description())
+	}
+	if strings.Contains(failed.FilterValue(), "✖") {
+		t.Fatalf("status glyph should not be part of the filter value")
+	}
+	running := items[2].(listItem)
+	if !strings.Contains(running.Title(), "◷") || running.Description() != "success, building" {
+		t.Fatalf("expected in-progress glyph and label, got %q / %q", running.Title(), running.Description())
+	}
+}
+
+func TestAccessibleModeAlignsStateWords(t *testing.T) {
+	ui.Accessible = true
+	defer func() { ui.Accessible = false }()
+	m, ok := NewModel(context.Background(), models.Config{Timeout: time.Second}).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	m.jobsReqID = 1
+	updated, _ := m.Update(jobsLoadedMsg{
+		requestID: 1,
+		nodes: []models.JobNode{
+			{Name: "apps", FullName: "apps", URL: "https://jenkins/job/apps/", Kind: models.JobNodeFolder},
+			{Name: "build", FullName: "build", URL: "https://jenkins/job/build/", Kind: models.JobNodeJob, Color: "red"},
+		},
+	})
+	m = updated.(*model)
+	items := m.jobs.Items()
+	folder, failed := items[0].(listItem).Title(), items[1].(listItem).Title()
+	if folder != "[folder  ] apps/" || failed != "[failed  ] build" {
+		t.Fatalf("expected spelled-out states of equal width, got %q and %q", folder, failed)
+	}
+}
+
+func TestNarrowingFilterMatchesFullSearch(t *testing.T) {
+	targets := []string{"deploy-api", "build-api", "deploy-web", "docs", "api-deploy", "dev-web"}
+	f := &narrowingFilter{}
+	for _, term := range []string{"d", "de", "dep", "depw", "d", "dw"} {
+		if got, want := f.Filter(term, targets), list.DefaultFilter(term, targets); !reflect.DeepEqual(got, want) {
+			t.Fatalf("Filter(%q) = %v, want %v", term, got, want)
+		}
+	}
+	// A new folder listing is searched in full even if the term grew.
+	other := append([]string{"deploy-db"}, targets...)
+	if got, want := f.Filter("dwe", other), list.DefaultFilter("dwe", other); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Filter over new items = %v, want %v", got, want)
+	}
+}
+
+func TestIdenticalJobsRefreshKeepsFilter(t *testing.T) {
+	m, ok := NewModel(context.Background(), models.Config{Timeout: time.Second}).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = updated.(*model)
+	nodes := []models.JobNode{
+		{Name: "build", FullName: "build", URL: "https://jenkins/job/build/", Kind: models.JobNodeJob, Color: "blue"},
+		{Name: "deploy", FullName: "deploy", URL: "https://jenkins/job/deploy/", Kind: models.JobNodeJob, Color: "blue"},
+	}
+	m.jobsReqID = 1
+	updated, _ = m.Update(jobsLoadedMsg{requestID: 1, nodes: nodes})
+	m = updated.(*model)
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("/")})
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("dep")})
+	if m.jobs.FilterState() != list.Filtering || len(m.jobs.VisibleItems()) != 1 {
+		t.Fatalf("expected the filter to narrow the list, state=%v visible=%d", m.jobs.FilterState(), len(m.jobs.VisibleItems()))
+	}
+	m.jobsReqID = 2
+	updated, cmd := m.Update(jobsLoadedMsg{requestID: 2, nodes: nodes})
+	m = drainCmd(t, updated.(*model), cmd, 0)
+	if m.jobs.FilterState() != list.Filtering || len(m.jobs.VisibleItems()) != 1 {
+		t.Fatalf("an unchanged refresh should keep the filter, state=%v visible=%d", m.jobs.FilterState(), len(m.jobs.VisibleItems()))
+	}
+}
+
+func TestOpeningFolderPrefetchesChildFolders(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/job/apps/api/json" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, `{"jobs":[{"name":"deploy","url":"%s/job/apps/job/deploy/","_class":"hudson.model.FreeStyleProject"}]}`, srv.URL)
+	}))
+	defer srv.Close()
+	cacheDir := t.TempDir()
+	m, ok := NewModel(context.Background(), models.Config{Timeout: time.Second, CacheDir: cacheDir, PrefetchFolders: 2}).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	m.client = jenkins.NewClient(models.JenkinsTarget{Host: srv.URL}, "token", time.Second)
+	m.jobsReqID = 1
+	updated, cmd := m.Update(jobsLoadedMsg{
+		requestID: 1,
+		nodes:     []models.JobNode{{Name: "apps", FullName: "apps", URL: srv.URL + "/job/apps/", Kind: models.JobNodeFolder}},
+	})
+	drainCmd(t, updated.(*model), cmd, 0)
+	nodes, ok, err := cache.JobNodesInDir(cacheDir, m.client.CacheKey(), srv.URL+"/job/apps/")
+	if err != nil || !ok || len(nodes) != 1 || nodes[0].FullName != "apps/deploy" {
+		t.Fatalf("expected the child folder listing to be prefetched, got %v ok=%v err=%v", nodes, ok, err)
+	}
+}
+
+func TestSyncTreeFillsJobIndex(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/json":
+			fmt.Fprintf(w, `{"jobs":[{"name":"apps","url":"%s/job/apps/","_class":"com.cloudbees.hudson.plugins.folder.Folder"}]}`, srv.URL)
+		case "/job/apps/api/json":
+			fmt.Fprintf(w, `{"jobs":[{"name":"deploy","url":"%s/job/apps/job/deploy/","_class":"hudson.model.FreeStyleProject"}]}`, srv.URL)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	cacheDir := t.TempDir()
+	m, ok := NewModel(context.Background(), models.Config{Timeout: time.Second, CacheDir: cacheDir}).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	target := models.JenkinsTarget{ID: "prod", Name: "prod", Host: srv.URL}
+	m.target = &target
+	m.client = jenkins.NewClient(target, "token", time.Second)
+	m.screen = screenJobs
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("Y")})
+	if m.syncing || !strings.HasPrefix(m.status, "Synced prod: 2 folders, 1 jobs") {
+		t.Fatalf("expected a finished sync, syncing=%v status=%q err=%v", m.syncing, m.status, m.err)
+	}
+	jobs, _, ok, err := cache.JobIndexInDir(cacheDir, m.client.CacheKey())
+	if err != nil || !ok || len(jobs) != 1 || jobs[0].FullName != "apps/deploy" {
+		t.Fatalf("expected the job index to be written, got %v ok=%v err=%v", jobs, ok, err)
+	}
+}
+
+func TestFindRepoJobsOpensMatchingMultibranchProject(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/json":
+			fmt.Fprintf(w, `{"jobs":[{"name":"web","url":"%[1]s/job/web/","_class":"org.jenkinsci.plugins.workflow.multibranch.WorkflowMultiBranchProject"},{"name":"api","url":"%[1]s/job/api/","_class":"org.jenkinsci.plugins.workflow.multibranch.WorkflowMultiBranchProject"}]}`, srv.URL)
+		case "/job/web/config.xml":
+			fmt.Fprint(w, `<project><sources><data><jenkins.branch.BranchSource><source><repoOwner>org</repoOwner><repository>web</repository></source></jenkins.branch.BranchSource></data></sources></project>`)
+		case "/job/api/config.xml":
+			fmt.Fprint(w, `<project><sources><data><jenkins.branch.BranchSource><source><remote>https://github.com/org/api.git</remote></source></jenkins.branch.BranchSource></data></sources></project>`)
+		case "/job/web/api/json":
+			fmt.Fprintf(w, `{"jobs":[{"name":"main","url":"%s/job/web/job/main/","_class":"org.jenkinsci.plugins.workflow.job.WorkflowJob"}]}`, srv.URL)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	checkout := t.TempDir()
+	for _, args := range [][]string{{"init", "-q"}, {"remote", "add", "origin", "git@github.com:Org/web.git"}} {
+		if out, err := exec.Command("git", append([]string{"-C", checkout}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v %s", args, err, out)
+		}
+	}
+	m, ok := NewModel(context.Background(), models.Config{Timeout: time.Second, CacheDir: t.TempDir()}).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	target := models.JenkinsTarget{ID: "prod", Name: "prod", Host: srv.URL}
+	m.target = &target
+	m.client = jenkins.NewClient(target, "token", time.Second)
+	m.screen = screenJobs
+	m.workDir = checkout
+
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("f")})
+	if len(m.jobFolders) != 1 || m.jobFolders[0].FullName != "web" {
+		t.Fatalf("expected to open /web, got %+v status=%q err=%v", m.jobFolders, m.status, m.err)
+	}
+
+	m.workDir = t.TempDir()
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("f")})
+	if m.status != "Not in a git checkout with an origin remote" {
+		t.Fatalf("expected a note outside a checkout, got %q", m.status)
+	}
+}
+
+func TestEnterOnDisabledJobDoesNotLoadParams(t *testing.T) {
+	m, ok := NewModel(context.Background(), models.Config{Timeout: time.Second}).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	m.screen = screenJobs
+	m.jobsReqID = 1
+	updated, _ := m.Update(jobsLoadedMsg{
+		requestID: 1,
+		nodes: []models.JobNode{
+			{Name: "legacy", FullName: "apps/legacy", URL: "https://jenkins/job/apps/job/legacy/", Kind: models.JobNodeJob, Color: "disabled", Disabled: true},
+		},
+	})
+	m = updated.(*model)
+	item := m.jobs.Items()[0].(listItem)
+	if !item.disabled || item.Description() != "disabled" {
+		t.Fatalf("expected disabled item, got %q", item.Description())
+	}
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(*model)
+	if m.screen != screenJobs || m.loading {
+		t.Fatalf("disabled job should not start the params flow, screen=%v loading=%v", m.screen, m.loading)
+	}
+	if m.err == nil || !strings.Contains(m.err.Error(), "disabled") {
+		t.Fatalf("expected disabled error, got %v", m.err)
+	}
+	if !strings.Contains(m.status, "E to enable") {
+		t.Fatalf("expected enable hint in status, got %q", m.status)
+	}
+}
+
+func TestDisabledJobOpenedElsewhereIsRefusedWhenParamsLoad(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.URL.Query().Get("tree"), "buildable") {
+			http.Error(w, "buildable not requested", http.StatusBadRequest)
+			return
+		}
+		_, _ = fmt.Fprint(w, `{"color":"disabled","buildable":false,"property":[{"parameterDefinitions":[{"name":"REGION","type":"StringParameterDefinition"}]}]}`)
+	}))
+	defer srv.Close()
+	m := NewModel(context.Background(), models.Config{Timeout: time.Second}).(*model)
+	m.screen = screenGlobalSearch
+	m.client = jenkins.NewClient(models.JenkinsTarget{Host: srv.URL}, "token", time.Second)
+	m.selectedJob = &models.JobRef{Name: "legacy", FullName: "apps/legacy", URL: srv.URL + "/job/apps/job/legacy/"}
+	updated, _ := m.Update(loadParamsCmd(m.ctx, m.client, m.selectedJob.URL)())
+	m = updated.(*model)
+	if m.screen == screenParams || len(m.params) != 0 {
+		t.Fatalf("a disabled job should not reach the form, screen=%v params=%v", m.screen, m.params)
+	}
+	if m.err == nil || !strings.Contains(m.err.Error(), "apps/legacy is disabled") {
+		t.Fatalf("expected a disabled error, got %v", m.err)
+	}
+}
+
+func TestGlobalSearchShowsAllKnownPaths(t *testing.T) {
+	m, ok := NewModel(context.Background(), models.Config{Timeout: time.Second}).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = updated.(*model)
+	m.screen = screenGlobalSearch
+	m.searchReqID = 1
+	updated, _ = m.Update(searchLoadedMsg{
+		requestID: 1,
+		nodes: []models.JobNode{
+			{Name: "deploy", FullName: "apps/deploy", URL: "https://jenkins/job/apps/job/deploy/", Kind: models.JobNodeJob, Paths: []string{"teams/payments/deploy"}},
+		},
+	})
+	m = updated.(*model)
+	if got := len(m.search.Items()); got != 1 {
+		t.Fatalf("expected one search result, got %d", got)
+	}
+	view := m.View()
+	for _, want := range []string{"+1 other path(s)", "Known paths:", "/apps/deploy", "/teams/payments/deploy"} {
+		if !strings.Contains(view, want) {
+			t.Fatalf("expected %q in search view, got %q", want, view)
+		}
+	}
+}
+
+func TestJobConfigLoadedShowsReadOnlyViewer(t *testing.T) {
+	m, ok := NewModel(context.Background(), models.Config{Timeout: time.Second}).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = updated.(*model)
+	m.screen = screenJobs
+	updated, _ = m.Update(jobConfigLoadedMsg{
+		job:    models.JobRef{Name: "deploy", FullName: "apps/deploy", URL: "https://jenkins/job/apps/job/deploy/"},
+		config: "<?xml version='1.1'?>\n<project>\n  <assignedNode>linux &amp;&amp; docker</assignedNode>\n</project>",
+	})
+	m = updated.(*model)
+	if m.screen != screenJobConfig {
+		t.Fatalf("expected config screen, got %v", m.screen)
+	}
+	view := m.View()
+	for _, want := range []string{"Job: /apps/deploy (config.xml, read-only)", "<assignedNode>", "linux &amp;&amp; docker"} {
+		if !strings.Contains(view, want) {
+			t.Fatalf("expected %q in config view, got %q", want, view)
+		}
+	}
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = updated.(*model)
+	if m.screen != screenJobs {
+		t.Fatalf("esc should return to jobs, got %v", m.screen)
+	}
+}
+
+func TestConsoleViewerRendersANSIColors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/job/deploy/7/consoleText" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, "\x1b[8mha:////4AAAAB+LCAAAA==\x1b[0m[Pipeline] sh\n\x1b[31mERROR: boom\x1b[0m\n")
+	}))
+	defer srv.Close()
+	m, ok := NewModel(context.Background(), models.Config{Timeout: time.Second}).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = updated.(*model)
+	m.client = jenkins.NewClient(models.JenkinsTarget{Host: srv.URL}, "token", time.Second)
+	m.historyJob = &models.JobRef{Name: "deploy", FullName: "deploy", URL: srv.URL + "/job/deploy/"}
+	updated, _ = m.Update(historyLoadedMsg{builds: []models.BuildSummary{{Number: 7, URL: srv.URL + "/job/deploy/7/", Result: "FAILURE"}}})
+	m = updated.(*model)
+
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("l")})
+	if m.screen != screenConsole {
+		t.Fatalf("expected console screen, got %v (status %q, err %v)", m.screen, m.status, m.err)
+	}
+	view := m.consoleView.View()
+	if !strings.Contains(view, "\x1b[31mERROR: boom") {
+		t.Fatalf("expected the pipeline's red kept, got %q", view)
+	}
+	if strings.Contains(view, "ha:") {
+		t.Fatalf("expected hidden console notes dropped, got %q", view)
+	}
+	if !strings.Contains(m.View(), "Console: /deploy #7") {
+		t.Fatalf("expected the build in the header, got %q", m.View())
+	}
+
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")})
+	if view := m.consoleView.View(); strings.Contains(view, "\x1b[") || !strings.Contains(view, "ERROR: boom") {
+		t.Fatalf("expected colors stripped, got %q", view)
+	}
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyEsc})
+	if m.screen != screenHistory {
+		t.Fatalf("esc should return to history, got %v", m.screen)
+	}
+}
+
+func TestVimMotionsMoveListsTablesAndViewers(t *testing.T) {
+	m, ok := NewModel(context.Background(), models.Config{Timeout: time.Second}).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = updated.(*model)
+	press := func(k tea.KeyMsg) {
+		t.Helper()
+		updated, _ := m.Update(k)
+		m = updated.(*model)
+	}
+	runes := func(s string) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)} }
+
+	m.screen = screenJobs
+	items := make([]list.Item, 30)
+	for i := range items {
+		items[i] = listItem{title: fmt.Sprintf("job%d", i), name: fmt.Sprintf("job%d", i), kind: models.JobNodeJob}
+	}
+	m.jobs.SetItems(items)
+	press(runes("j"))
+	press(runes("j"))
+	press(runes("k"))
+	if got := m.jobs.Index(); got != 1 {
+		t.Fatalf("expected j j k to land on item 1, got %d", got)
+	}
+	press(runes("G"))
+	if got := m.jobs.Index(); got != 29 {
+		t.Fatalf("expected G to jump to the last item, got %d", got)
+	}
+	press(runes("g"))
+	if got := m.jobs.Index(); got != 29 || m.screen != screenJobs {
+		t.Fatalf("expected a lone g to wait for the second one, got index %d screen %v", got, m.screen)
+	}
+	press(runes("g"))
+	if got := m.jobs.Index(); got != 0 {
+		t.Fatalf("expected gg to jump to the first item, got %d", got)
+	}
+	press(tea.KeyMsg{Type: tea.KeyCtrlD})
+	if got := m.jobs.Index(); got == 0 {
+		t.Fatalf("expected ctrl+d to move half a page down")
+	}
+	press(tea.KeyMsg{Type: tea.KeyCtrlU})
+	if got := m.jobs.Index(); got != 0 {
+		t.Fatalf("expected ctrl+u to move back up, got %d", got)
+	}
+
+	m.screen = screenHistory
+	rows := make([]table.Row, 10)
+	for i := range rows {
+		rows[i] = table.Row{fmt.Sprintf("#%d", i)}
+	}
+	m.historyTable = table.New(table.WithColumns([]table.Column{{Title: "Build", Width: 10}}), table.WithRows(rows), table.WithFocused(true), table.WithHeight(5))
+	press(runes("G"))
+	if got := m.historyTable.Cursor(); got != 9 {
+		t.Fatalf("expected G to select the last build, got %d", got)
+	}
+
+	lines := make([]string, 200)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %d", i)
+	}
+	updated, _ = m.Update(consoleLoadedMsg{build: buildLog{job: models.JobRef{Name: "deploy", FullName: "deploy"}, number: 7}, text: strings.Join(lines, "\n")})
+	m = updated.(*model)
+	press(runes("G"))
+	if !m.consoleView.AtBottom() {
+		t.Fatalf("expected G to scroll the log to the end")
+	}
+	press(runes("g"))
+	press(runes("g"))
+	if !m.consoleView.AtTop() {
+		t.Fatalf("expected gg to scroll the log to the start")
+	}
+}
+
+func TestConsoleSearchAndErrorJumps(t *testing.T) {
+	m, ok := NewModel(context.Background(), models.Config{Timeout: time.Second}).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = updated.(*model)
+	lines := make([]string, 100)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("step %d", i)
+	}
+	lines[10] = "+ ./deploy.sh staging"
+	lines[20] = "java.lang.IllegalStateException: boom"
+	lines[21] = "Caused by: java.io.IOException: closed"
+	lines[60] = "\x1b[31mERROR: script returned exit code 1\x1b[0m"
+	lines[70] = "Retrying DEPLOY"
+	m.screen = screenHistory
+	updated, _ = m.Update(consoleLoadedMsg{build: buildLog{job: models.JobRef{Name: "deploy", FullName: "deploy"}, number: 7}, text: strings.Join(lines, "\n")})
+	m = updated.(*model)
+
+	press := func(k string) {
+		t.Helper()
+		updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+		m = updated.(*model)
+	}
+	for _, want := range []int{20, 60, 20} {
+		if want == 20 && m.consoleAt == 60 {
+			press("E")
+		} else {
+			press("e")
+		}
+		if m.consoleAt != want {
+			t.Fatalf("expected error jump to line %d, got %d (%s)", want, m.consoleAt, m.status)
+		}
+	}
+	if !strings.Contains(m.consoleView.View(), "IllegalStateException") {
+		t.Fatalf("expected the error line scrolled into view, got %q", m.consoleView.View())
+	}
+
+	press("/")
+	for _, r := range "deploy" {
+		press(string(r))
+	}
+	if m.consoleInput != "deploy" || m.consoleAt != 20 {
+		t.Fatalf("expected typed keys to go to the search prompt, got %q at line %d", m.consoleInput, m.consoleAt)
+	}
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(*model)
+	if !reflect.DeepEqual(m.consoleMatches, []int{10, 70}) || m.consoleAt != 70 {
+		t.Fatalf("expected matches on lines 10 and 70 and a jump to 70, got %v at %d", m.consoleMatches, m.consoleAt)
+	}
+	press("n")
+	if m.consoleAt != 10 {
+		t.Fatalf("expected n to wrap to line 10, got %d", m.consoleAt)
+	}
+	if !strings.Contains(m.View(), `"deploy": 2 line(s)`) {
+		t.Fatalf("expected the match count in the header, got %q", m.View())
+	}
+}
+
+func TestStageDrillDownShowsOneStageLog(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/job/pipe/7/wfapi/describe":
+			fmt.Fprint(w, `{"stages":[{"id":"6","name":"Build","status":"SUCCESS"},{"id":"12","name":"Test","status":"FAILED"}]}`)
+		case "/job/pipe/7/execution/node/12/wfapi/describe":
+			fmt.Fprint(w, `{"stageFlowNodes":[{"id":"13","name":"Shell Script","parameterDescription":"make test"}]}`)
+		case "/job/pipe/7/execution/node/13/wfapi/log":
+			fmt.Fprint(w, `{"text":"FAIL: TestLogin\n"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	m, ok := NewModel(context.Background(), models.Config{Timeout: time.Second}).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = updated.(*model)
+	m.client = jenkins.NewClient(models.JenkinsTarget{Host: srv.URL}, "token", time.Second)
+	m.historyJob = &models.JobRef{Name: "pipe", FullName: "pipe", URL: srv.URL + "/job/pipe/"}
+	updated, _ = m.Update(historyLoadedMsg{builds: []models.BuildSummary{{Number: 7, URL: srv.URL + "/job/pipe/7/", Result: "FAILURE"}}})
+	m = updated.(*model)
+
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("t")})
+	if m.screen != screenStages || len(m.stages) != 2 {
+		t.Fatalf("expected the stages screen, got %v with %v (%s)", m.screen, m.stages, m.status)
+	}
+	if m.stagesTable.Cursor() != 1 {
+		t.Fatalf("expected the cursor on the failed stage, got %d", m.stagesTable.Cursor())
+	}
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.screen != screenConsole {
+		t.Fatalf("expected the stage log in the console viewer, got %v (%v)", m.screen, m.err)
+	}
+	if view := m.View(); !strings.Contains(view, "FAIL: TestLogin") || !strings.Contains(view, "#7") || !strings.Contains(view, "Test") {
+		t.Fatalf("expected the Test stage log, got %q", view)
+	}
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyEsc})
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyEsc})
+	if m.screen != screenHistory {
+		t.Fatalf("esc twice should return to history, got %v", m.screen)
+	}
+}
+
+func TestSaveFailedRunLogsWritesOneFilePerBuild(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "log of %s", r.URL.Path)
+	}))
+	defer srv.Close()
+	dir := t.TempDir()
+	m, ok := NewModel(context.Background(), models.Config{Timeout: time.Second, LogDir: dir}).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	m.client = jenkins.NewClient(models.JenkinsTarget{Host: srv.URL}, "token", time.Second)
+	m.screen = screenDone
+	b := m.startBatch(models.JobRef{Name: "deploy", FullName: "apps/deploy", URL: srv.URL + "/job/apps/job/deploy/"}, make([]models.JobSpec, 3), false)
+	b.records = []models.RunRecord{
+		{Index: 0, State: models.RunSuccess, BuildNumber: 1, BuildURL: srv.URL + "/job/apps/job/deploy/1/"},
+		{Index: 1, State: models.RunFailed, BuildNumber: 2, BuildURL: srv.URL + "/job/apps/job/deploy/2/"},
+		{Index: 2, State: models.RunFailed, BuildNumber: 3, BuildURL: srv.URL + "/job/apps/job/deploy/3/"},
+	}
+	m.refreshRunTable(b)
+
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("S")})
+	if m.err != nil {
+		t.Fatalf("save failed: %v", m.err)
+	}
+	entries, _ := os.ReadDir(dir)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if !reflect.DeepEqual(names, []string{"apps_deploy-2.log", "apps_deploy-3.log"}) {
+		t.Fatalf("expected only the failed runs saved, got %v (%s)", names, m.status)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dir, "apps_deploy-3.log")); string(got) != "log of /job/apps/job/deploy/3/consoleText" {
+		t.Fatalf("expected the console text, got %q", got)
+	}
+	if !strings.Contains(m.status, "Saved 2 console logs") {
+		t.Fatalf("expected a summary status, got %q", m.status)
+	}
+}
+
+func TestMultibranchFolderLabelsBranchesAndPullRequests(t *testing.T) {
+	m, ok := NewModel(context.Background(), models.Config{Timeout: time.Second}).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	m.jobsReqID = 1
+	updated, _ := m.Update(jobsLoadedMsg{
+		requestID: 1,
+		nodes: []models.JobNode{
+			{Name: "api", FullName: "api", URL: "https://jenkins/job/api/", Kind: models.JobNodeFolder, Multibranch: true},
+		},
+	})
+	m = updated.(*model)
+	folder := m.jobs.Items()[0].(listItem)
+	if !folder.multibranch || folder.Description() != "multibranch pipeline" {
+		t.Fatalf("expected multibranch folder, got %q", folder.Description())
+	}
+	if target, ok := m.multibranchScanTarget(); !ok || target.URL != "https://jenkins/job/api/" {
+		t.Fatalf("expected highlighted multibranch folder to be the scan target, got %+v", target)
+	}
+
+	m.jobFolders = append(m.jobFolders, models.JobNode{Name: "api", FullName: "api", URL: "https://jenkins/job/api/", Kind: models.JobNodeFolder, Multibranch: true})
+	m.jobsReqID = 2
+	updated, _ = m.Update(jobsLoadedMsg{
+		requestID: 2,
+		prefix:    "api",
+		nodes: []models.JobNode{
+			{Name: "main", FullName: "api/main", URL: "https://jenkins/job/api/job/main/", Kind: models.JobNodeJob, Color: "blue"},
+			{Name: "PR-42", FullName: "api/PR-42", URL: "https://jenkins/job/api/job/PR-42/", Kind: models.JobNodeJob, Color: "red"},
+		},
+	})
+	m = updated.(*model)
+	items := m.jobs.Items()
+	if got := items[0].(listItem).Description(); got != "branch, success" {
+		t.Fatalf("expected branch label, got %q", got)
+	}
+	if got := items[1].(listItem).Description(); got != "pull request, failed" {
+		t.Fatalf("expected pull request label, got %q", got)
+	}
+	if target, ok := m.multibranchScanTarget(); !ok || target.FullName != "api" {
+		t.Fatalf("expected open multibranch folder to be the scan target, got %+v", target)
+	}
+}
+
+func TestScanMultibranchTracksIndexingAsRun(t *testing.T) {
+	m, ok := NewModel(context.Background(), models.Config{Timeout: time.Second}).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	m.client = jenkins.NewClient(models.JenkinsTarget{Host: "https://jenkins.example.com"}, "token", time.Second)
+	m.screen = screenJobs
+	m.jobsReqID = 1
+	updated, _ := m.Update(jobsLoadedMsg{
+		requestID: 1,
+		nodes: []models.JobNode{
+			{Name: "api", FullName: "api", URL: "https://jenkins.example.com/job/api/", Kind: models.JobNodeFolder, Multibranch: true},
+		},
+	})
+	m = updated.(*model)
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("S")})
+	m = updated.(*model)
+	if cmd == nil || m.screen != screenRun || m.batch == nil || !m.batch.indexing {
+		t.Fatalf("expected indexing run to start, screen=%v batch=%+v", m.screen, m.batch)
+	}
+	if len(m.batch.records) != 1 || m.selectedJob == nil || m.selectedJob.URL != "https://jenkins.example.com/job/api/" {
+		t.Fatalf("expected a single run for the folder, got %+v / %+v", m.batch.records, m.selectedJob)
+	}
+	updated, _ = m.Update(runEventMsg{batch: m.batch.id, update: models.RunUpdate{Index: 0, State: models.RunSuccess, Result: "SUCCESS", BuildURL: "https://jenkins.example.com/job/api/indexing/", Done: true}})
+	m = updated.(*model)
+	if m.screen != screenDone || !strings.Contains(m.status, "Branch indexing finished") {
+		t.Fatalf("expected indexing completion, screen=%v status=%q", m.screen, m.status)
+	}
+	updated, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = updated.(*model)
+	if cmd == nil {
+		t.Fatalf("esc after indexing should refresh the folder")
+	}
+}
+
+func TestViewsModeBrowsesViewAndReturns(t *testing.T) {
+	m, ok := NewModel(context.Background(), models.Config{Timeout: time.Second}).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = updated.(*model)
+	m.client = jenkins.NewClient(models.JenkinsTarget{Host: "https://jenkins.example.com"}, "token", time.Second)
+	m.screen = screenJobs
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("v")})
+	m = updated.(*model)
+	if cmd == nil || !m.loading {
+		t.Fatalf("expected v to load views")
+	}
+	updated, _ = m.Update(jobsLoadedMsg{
+		requestID: m.jobsReqID,
+		views:     true,
+		nodes: []models.JobNode{
+			{Name: "Team A", URL: "https://jenkins.example.com/view/Team%20A/", Kind: models.JobNodeView},
+		},
+	})
+	m = updated.(*model)
+	if !m.showingViews {
+		t.Fatalf("expected views mode")
+	}
+	view := m.jobs.Items()[0].(listItem)
+	if view.kind != models.JobNodeView || view.Description() != "view" {
+		t.Fatalf("expected view item, got %+v", view)
+	}
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(*model)
+	if len(m.jobFolders) != 1 || m.jobFolders[0].Kind != models.JobNodeView {
+		t.Fatalf("expected view to be pushed onto the navigation stack, got %+v", m.jobFolders)
+	}
+	if url, prefix := m.currentJobsContainer(); url != "https://jenkins.example.com/view/Team%20A/" || prefix != "" {
+		t.Fatalf("expected view container, got %q %q", url, prefix)
+	}
+	updated, _ = m.Update(jobsLoadedMsg{
+		requestID: m.jobsReqID,
+		nodes:     []models.JobNode{{Name: "deploy", FullName: "deploy", URL: "https://jenkins.example.com/job/deploy/", Kind: models.JobNodeJob}},
+	})
+	m = updated.(*model)
+	if m.showingViews || !strings.Contains(m.View(), "Path: / [view: Team A]") {
+		t.Fatalf("expected view breadcrumb, got %q", m.View())
+	}
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = updated.(*model)
+	if len(m.jobFolders) != 0 {
+		t.Fatalf("esc should leave the view, got %+v", m.jobFolders)
+	}
+}
+
+func TestCompleteJobPath(t *testing.T) {
+	candidates := []string{"apps/api/deploy", "apps/api/build", "apps/web/deploy", "infra/dns"}
+	got, matches := completeJobPath("ap", candidates)
+	if got != "apps/" || len(matches) != 3 {
+		t.Fatalf("expected common prefix apps/, got %q %v", got, matches)
+	}
+	got, matches = completeJobPath("APPS/api/d", candidates)
+	if got != "apps/api/deploy" || len(matches) != 1 {
+		t.Fatalf("expected unique case-insensitive completion, got %q %v", got, matches)
+	}
+	got, matches = completeJobPath("nope", candidates)
+	if got != "nope" || matches != nil {
+		t.Fatalf("expected no completion, got %q %v", got, matches)
+	}
+}
+
+func TestGotoPromptCompletesAndOpensParams(t *testing.T) {
+	m, ok := NewModel(context.Background(), models.Config{Timeout: time.Second}).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	m.client = jenkins.NewClient(models.JenkinsTarget{Host: "https://jenkins.example.com"}, "token", time.Second)
+	m.screen = screenJobs
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(":")})
+	m = updated.(*model)
+	if !m.gotoActive || cmd == nil {
+		t.Fatalf("expected goto prompt to open and load the index")
+	}
+	updated, _ = m.Update(gotoIndexLoadedMsg{jobs: []models.JobNode{
+		{Name: "deploy", FullName: "apps/api/deploy", URL: "https://jenkins.example.com/job/apps/job/api/job/deploy/", Kind: models.JobNodeJob},
+		{Name: "build", FullName: "apps/api/build", URL: "https://jenkins.example.com/job/apps/job/api/job/build/", Kind: models.JobNodeJob},
+	}})
+	m = updated.(*model)
+	for _, r := range "apps/api/q" {
+		updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		m = updated.(*model)
+	}
+	if m.gotoInput != "apps/api/q" || !m.gotoActive {
+		t.Fatalf("typing q in the prompt should not quit, input=%q", m.gotoInput)
+	}
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	m = updated.(*model)
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	m = updated.(*model)
+	if m.gotoInput != "apps/api/build" {
+		t.Fatalf("expected tab to cycle to the first match, got %q", m.gotoInput)
+	}
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	m = updated.(*model)
+	if m.gotoInput != "apps/api/deploy" {
+		t.Fatalf("expected second tab to cycle, got %q", m.gotoInput)
+	}
+	updated, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(*model)
+	if cmd == nil || m.gotoActive || m.selectedJob == nil || m.selectedJob.URL != "https://jenkins.example.com/job/apps/job/api/job/deploy/" {
+		t.Fatalf("expected params load for indexed job, got %+v", m.selectedJob)
+	}
+}
+
+func TestOfflineGotoAndRebuildDoNotLoadParams(t *testing.T) {
+	m, ok := NewModel(context.Background(), models.Config{Timeout: time.Second}).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = updated.(*model)
+	m.client = jenkins.NewClient(models.JenkinsTarget{Host: "https://jenkins.example.com"}, "token", time.Second)
+	m.screen = screenJobs
+	m.offline = true
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(":")})
+	m = updated.(*model)
+	if m.gotoActive || !strings.Contains(m.status, "needs the network") {
+		t.Fatalf("expected the goto prompt to be refused offline, got active=%v status=%q", m.gotoActive, m.status)
+	}
+
+	m.gotoActive = true
+	m.gotoInput = "apps/api/deploy"
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(*model)
+	if m.selectedJob != nil || m.loading || !strings.Contains(m.status, "triggering deploy needs the network") {
+		t.Fatalf("expected goto to stay put offline, got job=%+v loading=%v status=%q", m.selectedJob, m.loading, m.status)
+	}
+
+	m.historyJob = &models.JobRef{Name: "deploy", FullName: "deploy", URL: "https://jenkins/job/deploy/"}
+	updated, _ = m.Update(historyLoadedMsg{builds: []models.BuildSummary{{Number: 42, Result: "FAILURE"}}})
+	m = updated.(*model)
+	m.screen = screenHistory
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("R")})
+	m = updated.(*model)
+	if m.screen != screenHistory || m.selectedJob != nil || m.loading || !strings.Contains(m.status, "triggering deploy needs the network") {
+		t.Fatalf("expected rebuild to stay put offline, got screen=%v loading=%v status=%q", m.screen, m.loading, m.status)
+	}
+}
+
+func TestGotoResolvesAlias(t *testing.T) {
+	m, ok := NewModel(context.Background(), models.Config{Timeout: time.Second}).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	m.target = &models.JenkinsTarget{ID: "prod", Aliases: map[string]string{"deploy-prod": "platform/prod/deploy"}}
+	m.client = jenkins.NewClient(models.JenkinsTarget{Host: "https://jenkins.example.com"}, "token", time.Second)
+	m.screen = screenJobs
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(":")})
+	m = updated.(*model)
+	for _, r := range "deploy-p" {
+		updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		m = updated.(*model)
+	}
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	m = updated.(*model)
+	if m.gotoInput != "deploy-prod" {
+		t.Fatalf("expected tab to complete the alias, got %q", m.gotoInput)
+	}
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(*model)
+	if m.selectedJob == nil || m.selectedJob.FullName != "platform/prod/deploy" {
+		t.Fatalf("expected alias to open its job, got %+v", m.selectedJob)
+	}
+}
+
+func TestGotoUnknownJobBuildsURLFromFullName(t *testing.T) {
+	m, ok := NewModel(context.Background(), models.Config{Timeout: time.Second}).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	m.client = jenkins.NewClient(models.JenkinsTarget{Host: "https://jenkins.example.com"}, "token", time.Second)
+	job := m.resolveGotoJob("team/release job")
+	if job.Name != "release job" || job.URL != "https://jenkins.example.com/job/team/job/release%20job/" {
+		t.Fatalf("unexpected job %+v", job)
+	}
+}
+
+func TestStartupLinkOpensJobFolderThenParams(t *testing.T) {
+	cfg := models.Config{
+		Timeout: time.Second,
+		Jenkins: []models.JenkinsTarget{{
+			ID: "prod", Name: "Prod", Host: "https://jenkins.example.com", Username: "u",
+			Credential: models.Credential{Type: models.CredentialTypeKeyring, Ref: "prod-token"},
+		}},
+		Startup: models.StartupLink{Server: "prod", Job: "infra/deploy", Params: map[string]string{"region": "eu"}},
+	}
+	m, ok := NewModel(context.Background(), cfg).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	creds := newStubCreds()
+	creds.values["prod-token"] = "secret"
+	m.creds = creds
+	if m.Init() == nil {
+		t.Fatalf("expected init to schedule the startup link")
+	}
+	updated, cmd := m.Update(startupMsg{})
+	m = updated.(*model)
+	if cmd == nil || m.client == nil || m.screen != screenJobs {
+		t.Fatalf("expected connection and folder load, screen=%v", m.screen)
+	}
+	if len(m.jobFolders) != 1 || m.jobFolders[0].URL != "https://jenkins.example.com/job/infra/" {
+		t.Fatalf("expected job's parent folder to be opened, got %+v", m.jobFolders)
+	}
+	updated, cmd = m.Update(jobsLoadedMsg{
+		requestID: m.jobsReqID,
+		prefix:    "infra",
+		nodes:     []models.JobNode{{Name: "deploy", FullName: "infra/deploy", URL: "https://jenkins.example.com/job/infra/job/deploy/", Kind: models.JobNodeJob}},
+	})
+	m = updated.(*model)
+	if cmd == nil || m.selectedJob == nil || m.selectedJob.URL != "https://jenkins.example.com/job/infra/job/deploy/" {
+		t.Fatalf("expected params load for the linked job, got %+v", m.selectedJob)
+	}
+	if m.paramPrefill["region"] != "eu" || m.startupJob != nil {
+		t.Fatalf("expected prefill from startup params, got %v", m.paramPrefill)
+	}
+}
+
+func TestStartupJobRefAcceptsURL(t *testing.T) {
+	ref := startupJobRef("https://jenkins", "https://jenkins/job/infra/job/deploy")
+	if ref.FullName != "infra/deploy" || ref.Name != "deploy" || ref.URL != "https://jenkins/job/infra/job/deploy/" {
+		t.Fatalf("unexpected job ref %+v", ref)
+	}
+}
+
+func TestCtrlTTogglesRequestTraceOverlay(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"jobs":[]}`))
+	}))
+	defer srv.Close()
+
+	m, ok := NewModel(context.Background(), models.Config{Timeout: time.Second}).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = updated.(*model)
+	m.screen = screenJobs
+	m.client = jenkins.NewClient(models.JenkinsTarget{Host: srv.URL, Username: "u"}, "t", time.Second)
+	if _, err := m.client.ListJobNodes(context.Background(), srv.URL+"/", ""); err != nil {
+		t.Fatalf("ListJobNodes: %v", err)
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlT})
+	m = updated.(*model)
+	view := m.View()
+	for _, want := range []string{"Recent API calls", "GET", "200", srv.URL + "/api/json"} {
+		if !strings.Contains(view, want) {
+			t.Fatalf("expected %q in trace overlay, got %q", want, view)
+		}
+	}
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = updated.(*model)
+	if m.traceVisible || m.screen != screenJobs {
+		t.Fatalf("esc should close the overlay and stay on jobs, got visible=%v screen=%v", m.traceVisible, m.screen)
+	}
+}
+
+func TestKeybindingsOverrideDefaultsAndHelp(t *testing.T) {
+	cfg := models.Config{
+		Timeout: time.Second,
+		Jenkins: []models.JenkinsTarget{{ID: "prod", Name: "prod", Host: "https://jenkins", Username: "u"}},
+		Keybindings: map[string]models.KeyList{
+			"delete_server": {"D"},
+		},
+	}
+	m, ok := NewModel(context.Background(), cfg).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	if m.err != nil {
+		t.Fatalf("unexpected keybinding error: %v", m.err)
+	}
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")})
+	m = updated.(*model)
+	if len(m.cfg.Jenkins) != 1 {
+		t.Fatalf("d should no longer delete a server")
+	}
+	if help := renderHelp(m.keys, screenServers); !strings.Contains(help, fmt.Sprintf("  %-22s %s", "D", ui.Muted.Render("delete server"))) {
+		t.Fatalf("expected remapped key in help, got %q", help)
+	}
+}
+
+func TestNewKeyMapRejectsUnknownActionsAndConflicts(t *testing.T) {
+	if _, _, err := newKeyMap(map[string]models.KeyList{"explode": {"x"}}); err == nil || !strings.Contains(err.Error(), "unknown action") {
+		t.Fatalf("expected unknown action error, got %v", err)
+	}
+	_, _, err := newKeyMap(map[string]models.KeyList{"delete_server": {"e"}})
+	if err == nil || !strings.Contains(err.Error(), `"e" is bound to both edit_server and delete_server`) {
+		t.Fatalf("expected conflict error, got %v", err)
+	}
+	if _, _, err := newKeyMap(map[string]models.KeyList{"delete_server": {"D"}, "diff_runs": {"x"}}); err != nil {
+		t.Fatalf("keys on different screens should not conflict: %v", err)
+	}
+}
+
+func TestOldDefaultKeysInConfigStillLoad(t *testing.T) {
+	km, notes, err := newKeyMap(map[string]models.KeyList{"global_search": {"g"}, "trace": {"ctrl+d"}})
+	if err != nil {
+		t.Fatalf("a config written for the old defaults should load, got %v", err)
+	}
+	if keyLabel(km.GlobalSearch) != "g" || keyLabel(km.Trace) != "ctrl+d" {
+		t.Fatalf("expected the configured keys kept, got %q and %q", keyLabel(km.GlobalSearch), keyLabel(km.Trace))
+	}
+	if len(km.NavTop.Keys()) != 0 || len(km.HalfPageDown.Keys()) != 0 {
+		t.Fatalf("expected the motions to give the keys up, got %v and %v", km.NavTop.Keys(), km.HalfPageDown.Keys())
+	}
+	if len(notes) != 2 || !strings.Contains(notes[0], `new default "s"`) {
+		t.Fatalf("expected a migration hint per moved key, got %q", notes)
+	}
+	if _, _, err := newKeyMap(map[string]models.KeyList{"global_search": {"g"}, "go_to_top": {"g"}}); err == nil {
+		t.Fatal("an explicit go_to_top on the same key should still conflict")
+	}
+	m := NewModel(context.Background(), models.Config{
+		Timeout:     time.Second,
+		CacheDir:    t.TempDir(),
+		Jenkins:     []models.JenkinsTarget{{ID: "prod", Name: "prod", Host: "https://jenkins.example.com"}},
+		Keybindings: map[string]models.KeyList{"global_search": {"g"}},
+	}).(*model)
+	if m.err != nil || !strings.Contains(m.status, "global_search keeps") {
+		t.Fatalf("expected the hint on the status line, got %v / %q", m.err, m.status)
+	}
+}
+
+// drainCmd runs cmd and feeds the resulting messages back into m, so huh
+// forms can advance past their internal next-field messages. Commands that
+// block (ticks) are abandoned after a short wait.
+func drainCmd(t *testing.T, m *model, cmd tea.Cmd, depth int) *model {
+	t.Helper()
+	if cmd == nil || depth > 8 {
+		return m
+	}
+	ch := make(chan tea.Msg, 1)
+	go func() { ch <- cmd() }()
+	var msg tea.Msg
+	select {
+	case msg = <-ch:
+	case <-time.After(50 * time.Millisecond):
+		return m
+	}
+	switch typed := msg.(type) {
+	case nil:
+		return m
+	case tea.BatchMsg:
+		for _, c := range typed {
+			m = drainCmd(t, m, c, depth+1)
+		}
+		return m
+	}
+	updated, next := m.Update(msg)
+	return drainCmd(t, updated.(*model), next, depth+1)
+}
+
+func pressKey(t *testing.T, m *model, k tea.KeyMsg) *model {
+	t.Helper()
+	updated, cmd := m.Update(k)
+	return drainCmd(t, updated.(*model), cmd, 0)
+}
+
+func TestDeleteServerAsksForConfirmation(t *testing.T) {
+	cfg := models.Config{
+		Timeout:    time.Second,
+		ConfigPath: filepath.Join(t.TempDir(), "jenkins.yaml"),
+		Jenkins: []models.JenkinsTarget{
+			{ID: "prod", Name: "prod", Host: "https://jenkins", Username: "u", Credential: models.Credential{Type: models.CredentialTypeEnv, Ref: "TOKEN"}},
+			{ID: "dev", Name: "dev", Host: "https://dev", Username: "u", Credential: models.Credential{Type: models.CredentialTypeEnv, Ref: "TOKEN"}},
+		},
+	}
+	m, ok := NewModel(context.Background(), cfg).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = updated.(*model)
+
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")})
+	if m.confirm == nil || len(m.cfg.Jenkins) != 2 {
+		t.Fatalf("d should open a confirmation without deleting")
+	}
+	if view := m.View(); !strings.Contains(view, "Delete server prod?") {
+		t.Fatalf("expected confirm dialog in view, got %q", view)
+	}
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyEsc})
+	if m.confirm != nil || len(m.cfg.Jenkins) != 2 || m.screen != screenServers {
+		t.Fatalf("esc should cancel the delete, confirm=%v servers=%d", m.confirm != nil, len(m.cfg.Jenkins))
+	}
+
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")})
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	if m.confirm != nil || len(m.cfg.Jenkins) != 1 || m.cfg.Jenkins[0].ID != "dev" {
+		t.Fatalf("y should delete prod, got %+v (confirm open: %v)", m.cfg.Jenkins, m.confirm != nil)
+	}
+}
+
+func TestPreviewAsksBeforeTriggeringManyBuilds(t *testing.T) {
+	m, ok := NewModel(context.Background(), models.Config{Timeout: time.Second}).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	m.screen = screenPreview
+	m.selectedJob = &models.JobRef{Name: "deploy", FullName: "apps/deploy", URL: "https://jenkins/job/apps/job/deploy/"}
+	for i := 0; i < confirmBuildsAbove+1; i++ {
+		m.permutations = append(m.permutations, models.JobSpec{Params: map[string]string{"n": fmt.Sprint(i)}})
+	}
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.confirm == nil || m.screen != screenPreview {
+		t.Fatalf("expected confirmation before starting %d builds", len(m.permutations))
+	}
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	if m.confirm != nil || m.screen != screenPreview {
+		t.Fatalf("n should keep the preview without running, screen=%v", m.screen)
+	}
+}
+
+func TestBreadcrumbPickerJumpsToAncestor(t *testing.T) {
+	m, ok := NewModel(context.Background(), models.Config{Timeout: time.Second}).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = updated.(*model)
+	m.screen = screenJobs
+	m.client = jenkins.NewClient(models.JenkinsTarget{Host: "https://jenkins", Username: "u"}, "t", time.Second)
+	m.jobFolders = []models.JobNode{
+		{Name: "a", FullName: "a", URL: "https://jenkins/job/a/", Kind: models.JobNodeFolder},
+		{Name: "b", FullName: "a/b", URL: "https://jenkins/job/a/job/b/", Kind: models.JobNodeFolder},
+		{Name: "c", FullName: "a/b/c", URL: "https://jenkins/job/a/job/b/job/c/", Kind: models.JobNodeFolder},
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("u")})
+	m = updated.(*model)
+	if !m.crumbActive || m.crumbCursor != 2 {
+		t.Fatalf("expected picker on the parent folder, active=%v cursor=%d", m.crumbActive, m.crumbCursor)
+	}
+	view := m.View()
+	for _, want := range []string{"Jump to:", "0 /", "1 /a", "> 2 /a/b"} {
+		if !strings.Contains(view, want) {
+			t.Fatalf("expected %q in picker, got %q", want, view)
+		}
+	}
+	if strings.Contains(view, "3 /a/b/c") {
+		t.Fatalf("current folder should not be offered")
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyUp})
+	m = updated.(*model)
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(*model)
+	if m.crumbActive || len(m.jobFolders) != 1 || m.jobFolders[0].FullName != "a" {
+		t.Fatalf("expected to jump to /a, got %+v", m.jobFolders)
+	}
+
+	m.jobFolders = append(m.jobFolders, models.JobNode{Name: "b", FullName: "a/b", Kind: models.JobNodeFolder})
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("u")})
+	m = updated.(*model)
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("0")})
+	m = updated.(*model)
+	if len(m.jobFolders) != 0 {
+		t.Fatalf("0 should jump to the root, got %+v", m.jobFolders)
+	}
+}
+
+func TestRestoresSavedSessionAfterConfirm(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"jobs":[{"name":"a","url":"https://jenkins/job/infra/job/a/","_class":"hudson.model.FreeStyleProject"},{"name":"b","url":"https://jenkins/job/infra/job/b/","_class":"hudson.model.FreeStyleProject"},{"name":"c","url":"https://jenkins/job/infra/job/c/","_class":"hudson.model.FreeStyleProject"}]}`))
+	}))
+	defer srv.Close()
+	t.Setenv("JENKINS_TUI_TEST_TOKEN", "t")
+
+	cacheDir := t.TempDir()
+	folder := models.JobNode{Name: "infra", FullName: "infra", URL: srv.URL + "/job/infra/", Kind: models.JobNodeFolder}
+	if err := cache.SaveSessionInDir(cacheDir, cache.Session{Server: "prod", Folders: []models.JobNode{folder}, JobsCursor: 2, ServersCursor: 1}); err != nil {
+		t.Fatalf("SaveSessionInDir: %v", err)
+	}
+	cred := models.Credential{Type: models.CredentialTypeEnv, Ref: "JENKINS_TUI_TEST_TOKEN"}
+	cfg := models.Config{
+		Timeout:  time.Second,
+		CacheDir: cacheDir,
+		Jenkins: []models.JenkinsTarget{
+			{ID: "dev", Name: "dev", Host: "https://dev", Username: "u", Credential: cred},
+			{ID: "prod", Name: "prod", Host: srv.URL, Username: "u", Credential: cred},
+		},
+	}
+	m, ok := NewModel(context.Background(), cfg).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = updated.(*model)
+	if m.servers.Index() != 1 || m.session == nil {
+		t.Fatalf("expected server cursor and pending session to be restored, cursor=%d", m.servers.Index())
+	}
+
+	updated, _ = m.Update(restorePromptMsg{})
+	m = updated.(*model)
+	if m.confirm == nil || !strings.Contains(m.View(), "Restore last session?") {
+		t.Fatalf("expected restore prompt, got %q", m.View())
+	}
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	if m.screen != screenJobs || len(m.jobFolders) != 1 || m.jobFolders[0].FullName != "infra" {
+		t.Fatalf("expected to reopen /infra, screen=%v folders=%+v", m.screen, m.jobFolders)
+	}
+	if m.jobs.Index() != 2 {
+		t.Fatalf("expected jobs cursor 2, got %d", m.jobs.Index())
+	}
+
+	m.jobs.Select(1)
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyCtrlC})
+	s, ok, err := cache.SessionInDir(cacheDir)
+	if err != nil || !ok || s.Server != "prod" || s.JobsCursor != 1 || len(s.Folders) != 1 {
+		t.Fatalf("expected quit to save the session, got %+v ok=%v err=%v", s, ok, err)
+	}
+}
+
+func TestBookmarkCurrentFolderAndOpenIt(t *testing.T) {
+	cfg := models.Config{
+		Timeout:    time.Second,
+		ConfigPath: filepath.Join(t.TempDir(), "jenkins.yaml"),
+		Jenkins: []models.JenkinsTarget{
+			{ID: "prod", Name: "prod", Host: "https://jenkins", Username: "u", Credential: models.Credential{Type: models.CredentialTypeEnv, Ref: "TOKEN"}},
+		},
+	}
+	m, ok := NewModel(context.Background(), cfg).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = updated.(*model)
+	m.screen = screenJobs
+	m.target = &m.cfg.Jenkins[0]
+	m.client = jenkins.NewClient(m.cfg.Jenkins[0], "t", time.Second)
+	m.jobFolders = ancestorFolders("https://jenkins", "a/b/c/x")
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("b")})
+	m = updated.(*model)
+	if got := m.cfg.Jenkins[0].Bookmarks; len(got) != 1 || got[0] != "a/b/c" {
+		t.Fatalf("expected /a/b/c to be bookmarked, got %v", got)
+	}
+	saved, err := config.Load(m.cfg.ConfigPath)
+	if err != nil || len(saved.Jenkins[0].Bookmarks) != 1 {
+		t.Fatalf("expected bookmark persisted, got %+v err=%v", saved.Jenkins, err)
+	}
+
+	m.jobFolders = nil
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("B")})
+	m = updated.(*model)
+	if !m.bookmarksOpen || !strings.Contains(m.View(), "> 0 /a/b/c") {
+		t.Fatalf("expected bookmark picker, got %q", m.View())
+	}
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(*model)
+	if m.bookmarksOpen || len(m.jobFolders) != 3 || m.jobFolders[2].FullName != "a/b/c" || m.jobFolders[2].URL != "https://jenkins/job/a/job/b/job/c/" {
+		t.Fatalf("expected to open /a/b/c, got %+v", m.jobFolders)
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("b")})
+	m = updated.(*model)
+	if len(m.cfg.Jenkins[0].Bookmarks) != 0 {
+		t.Fatalf("b on a bookmarked folder should remove it, got %v", m.cfg.Jenkins[0].Bookmarks)
+	}
+}
+
+func TestRemoveBookmarkKeyCanBeRemapped(t *testing.T) {
+	cfg := models.Config{
+		Timeout:     time.Second,
+		ConfigPath:  filepath.Join(t.TempDir(), "jenkins.yaml"),
+		Keybindings: map[string]models.KeyList{"remove_bookmark": {"x"}},
+		Jenkins: []models.JenkinsTarget{
+			{ID: "prod", Name: "prod", Host: "https://jenkins", Username: "u", Credential: models.Credential{Type: models.CredentialTypeEnv, Ref: "TOKEN"}, Bookmarks: []string{"a/b"}},
+		},
+	}
+	m, ok := NewModel(context.Background(), cfg).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = updated.(*model)
+	m.screen = screenJobs
+	m.target = &m.cfg.Jenkins[0]
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("B")})
+	if !m.bookmarksOpen || !strings.Contains(m.status, "x removes") {
+		t.Fatalf("expected the picker to name the remapped key, got %q", m.status)
+	}
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")})
+	if len(m.cfg.Jenkins[0].Bookmarks) != 1 {
+		t.Fatal("d should no longer remove a bookmark once remove_bookmark is remapped")
+	}
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	if len(m.cfg.Jenkins[0].Bookmarks) != 0 || m.bookmarksOpen {
+		t.Fatalf("x should remove the bookmark, got %v", m.cfg.Jenkins[0].Bookmarks)
+	}
+}
+
+func TestSplitPanePreviewsHighlightedFolder(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"jobs":[{"name":"deploy","url":"https://jenkins/job/infra/job/deploy/","_class":"hudson.model.FreeStyleProject","color":"blue"},{"name":"tools","url":"https://jenkins/job/infra/job/tools/","_class":"com.cloudbees.hudson.plugins.folder.Folder"}]}`))
+	}))
+	defer srv.Close()
+
+	m, ok := NewModel(context.Background(), models.Config{Timeout: time.Second, CacheDir: t.TempDir(), Layout: models.LayoutSplit}).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = updated.(*model)
+	m.screen = screenJobs
+	m.client = jenkins.NewClient(models.JenkinsTarget{Host: srv.URL, Username: "u"}, "t", time.Second)
+	m.jobs.SetItems([]list.Item{listItem{title: "infra/", name: "infra", fullName: "infra", id: srv.URL + "/job/infra/", kind: models.JobNodeFolder}})
+
+	if m.scheduleFolderPreviewCmd() == nil {
+		t.Fatalf("expected a debounced preview load for the highlighted folder")
+	}
+	updated, cmd := m.Update(folderPreviewTickMsg{url: srv.URL + "/job/infra/", prefix: "infra"})
+	m = drainCmd(t, updated.(*model), cmd, 0)
+	if got := m.folderPreview[srv.URL+"/job/infra/"]; len(got) != 2 {
+		t.Fatalf("expected folder preview to load, got %+v (err %v)", got, m.previewErrs)
+	}
+	view := m.View()
+	for _, want := range []string{"/infra", "tools/", "deploy"} {
+		if !strings.Contains(view, want) {
+			t.Fatalf("expected %q in split view, got %q", want, view)
+		}
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("L")})
+	m = updated.(*model)
+	if m.splitPane || strings.Contains(m.View(), "tools/") {
+		t.Fatalf("L should switch back to the list layout")
+	}
+}
+
+func TestRunBatchesAreTrackedSideBySide(t *testing.T) {
+	m, ok := NewModel(context.Background(), models.Config{Timeout: time.Second}).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = updated.(*model)
+	m.client = jenkins.NewClient(models.JenkinsTarget{Host: "https://jenkins", Username: "u"}, "t", time.Second)
+	deploy := m.startBatch(models.JobRef{Name: "deploy", FullName: "apps/deploy", URL: "https://jenkins/job/apps/job/deploy/"},
+		[]models.JobSpec{{Params: map[string]string{"n": "1"}}, {Params: map[string]string{"n": "2"}}}, false)
+	m.screen = screenRun
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = updated.(*model)
+	if m.screen != screenJobs || !deploy.active() {
+		t.Fatalf("esc should leave the batch running in the background, screen=%v", m.screen)
+	}
+	if view := m.View(); !strings.Contains(view, "Runs /apps/deploy: 0/2 done") {
+		t.Fatalf("expected run widget in footer, got %q", view)
+	}
+
+	m.selectedJob = &models.JobRef{Name: "other", FullName: "apps/other", URL: "https://jenkins/job/apps/job/other/"}
+	m.permutations = []models.JobSpec{{Params: map[string]string{}}}
+	m.screen = screenPreview
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(*model)
+	if m.confirm != nil || len(m.batches) != 2 || m.batch == deploy || m.screen != screenRun || !deploy.active() {
+		t.Fatalf("a second batch should start alongside the first, batches=%d screen=%v", len(m.batches), m.screen)
+	}
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = updated.(*model)
+	if view := m.View(); !strings.Contains(view, "Batches: 2 running, 0 finished") {
+		t.Fatalf("expected aggregate widget, got %q", view)
+	}
+
+	m.Update(runEventMsg{batch: deploy.id, update: models.RunUpdate{Index: 0, State: models.RunSuccess, Result: "SUCCESS", Done: true}})
+	m.Update(runEventMsg{batch: deploy.id, update: models.RunUpdate{Index: 1, State: models.RunFailed, Result: "FAILURE", Done: true}})
+	if m.screen != screenJobs || !strings.Contains(m.status, "Batch /apps/deploy finished: 2/2 done, 1 failed") {
+		t.Fatalf("finishing in the background should not steal the screen, screen=%v status=%q", m.screen, m.status)
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlR})
+	m = updated.(*model)
+	if m.screen != screenBatches || len(m.batchTable.Rows()) != 2 {
+		t.Fatalf("ctrl+r should list both batches, screen=%v", m.screen)
+	}
+	m.batchTable.SetCursor(0)
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(*model)
+	if m.screen != screenDone || m.batch != deploy {
+		t.Fatalf("enter should open the finished batch, screen=%v", m.screen)
+	}
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	if m.screen != screenPreview || m.selectedJob.FullName != "apps/deploy" || len(m.permutations) != 1 {
+		t.Fatalf("rerun should target the batch's job, got %+v %d", m.selectedJob, len(m.permutations))
+	}
+
+	m.screen = screenJobs
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyCtrlR})
+	m.batchTable.SetCursor(0)
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")})
+	if len(m.batches) != 1 || m.batches[0] == deploy {
+		t.Fatalf("d should remove the finished batch, got %d batches", len(m.batches))
+	}
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	if m.batches[0].active() || !strings.Contains(m.batchTable.Rows()[0][2], "stopped") {
+		t.Fatalf("x then y should stop tracking the running batch, got %v", m.batchTable.Rows())
+	}
+}
+
+func TestBatchKeysCanBeRemapped(t *testing.T) {
+	cfg := models.Config{Timeout: time.Second, Keybindings: map[string]models.KeyList{"remove_batch": {"D"}}}
+	m, ok := NewModel(context.Background(), cfg).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = updated.(*model)
+	m.client = jenkins.NewClient(models.JenkinsTarget{Host: "https://jenkins", Username: "u"}, "t", time.Second)
+	b := m.startBatch(models.JobRef{Name: "deploy", FullName: "apps/deploy", URL: "https://jenkins/job/apps/job/deploy/"},
+		[]models.JobSpec{{Params: map[string]string{}}}, false)
+	m.Update(runEventMsg{batch: b.id, update: models.RunUpdate{Index: 0, State: models.RunSuccess, Result: "SUCCESS", Done: true}})
+	m.screen = screenJobs
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyCtrlR})
+	if !strings.Contains(m.View(), "D remove") {
+		t.Fatalf("the footer should show the remapped key, got %q", m.View())
+	}
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")})
+	if len(m.batches) != 1 {
+		t.Fatal("d should no longer remove a batch once remove_batch is remapped")
+	}
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("D")})
+	if len(m.batches) != 0 {
+		t.Fatalf("D should remove the finished batch, got %d batches", len(m.batches))
+	}
+}
+
+func TestQuitOffersToAbortInFlightBuilds(t *testing.T) {
+	var mu sync.Mutex
+	var posts []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.NotFound(w, r)
+			return
+		}
+		mu.Lock()
+		posts = append(posts, r.URL.RequestURI())
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	newModel := func() *model {
+		m, ok := NewModel(context.Background(), models.Config{Timeout: time.Second, CacheDir: t.TempDir()}).(*model)
+		if !ok {
+			t.Fatalf("NewModel should return *model")
+		}
+		updated, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+		m = updated.(*model)
+		m.client = jenkins.NewClient(models.JenkinsTarget{Host: srv.URL, Username: "u"}, "t", time.Second)
+		b := m.startBatch(models.JobRef{Name: "deploy", FullName: "deploy", URL: srv.URL + "/job/deploy/"},
+			[]models.JobSpec{{}, {}, {}}, false)
+		b.apply(models.RunUpdate{Index: 0, State: models.RunRunning, BuildURL: srv.URL + "/job/deploy/5/", BuildNumber: 5})
+		b.apply(models.RunUpdate{Index: 1, State: models.RunQueued, QueueURL: srv.URL + "/queue/item/9/"})
+		m.screen = screenRun
+		return m
+	}
+
+	m := newModel()
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyCtrlC})
+	if m.confirm == nil || !strings.Contains(m.View(), "3 build(s) still in flight") {
+		t.Fatalf("ctrl+c with builds in flight should ask first, got %q", m.View())
+	}
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyEsc})
+	if m.confirm != nil || !m.batch.active() || m.status != "Cancelled" {
+		t.Fatalf("esc should keep the TUI and the batch running, status=%q", m.status)
+	}
+
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyCtrlC})
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	mu.Lock()
+	got := strings.Join(posts, " ")
+	mu.Unlock()
+	if !strings.Contains(got, "/job/deploy/5/stop") || !strings.Contains(got, "/queue/cancelItem?id=9") {
+		t.Fatalf("choosing abort should stop the build and cancel the queue item, got %q", got)
+	}
+	if m.batch.active() || m.quitting || m.err != nil {
+		t.Fatalf("expected batch stopped and a clean quit, err=%v", m.err)
+	}
+
+	m = newModel()
+	posts = nil
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyCtrlC})
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyDown})
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	if len(posts) != 0 || m.batch.ctx.Err() == nil {
+		t.Fatalf("leaving builds running should quit without posting, posts=%v", posts)
+	}
+}
+
+func TestExitSummaryListsEveryRun(t *testing.T) {
+	m, ok := NewModel(context.Background(), models.Config{Timeout: time.Second}).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	if got := ExitSummary(m); got != "" {
+		t.Fatalf("expected no summary without runs, got %q", got)
+	}
+	b := m.startBatch(models.JobRef{Name: "deploy", FullName: "apps/deploy", URL: "https://jenkins/job/apps/job/deploy/"},
+		[]models.JobSpec{{Params: map[string]string{"env": "dev"}}, {Params: map[string]string{"env": "prod"}}}, false)
+	b.apply(models.RunUpdate{Index: 0, State: models.RunSuccess, Result: "SUCCESS", BuildURL: "https://jenkins/job/apps/job/deploy/5/", Done: true})
+	b.apply(models.RunUpdate{Index: 1, State: models.RunQueued, QueueURL: "https://jenkins/queue/item/9/"})
+	b.stopped = true
+
+	want := "Batch 1: /apps/deploy (1/2 done, stopped)\n" +
+		"  run 1\tSUCCESS\tenv=dev\thttps://jenkins/job/apps/job/deploy/5/\n" +
+		"  run 2\tQUEUED\tenv=prod\thttps://jenkins/queue/item/9/\n"
+	if got := ExitSummary(m); got != want {
+		t.Fatalf("unexpected summary:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestServersScreenShowsDetectedVersion(t *testing.T) {
+	cfg := models.Config{Timeout: time.Second, Jenkins: []models.JenkinsTarget{{ID: "prod", Name: "prod", Host: "https://jenkins", Username: "u"}}}
+	m, ok := NewModel(context.Background(), cfg).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = updated.(*model)
+	// Show the version as before any health probe.
+	m.health = map[string]serverHealth{}
+	updated, _ = m.Update(serverDetectedMsg{target: "prod", info: jenkins.ServerInfo{Version: "2.440.3"}})
+	m = updated.(*model)
+	if view := m.View(); !strings.Contains(view, "Jenkins 2.440.3") {
+		t.Fatalf("expected detected version on servers screen, got %q", view)
+	}
+}
+
+func TestServersScreenShowsHealthLine(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Jenkins", "2.452.1")
+		switch r.URL.Path {
+		case "/api/json":
+			w.Write([]byte(`{"mode":"NORMAL"}`))
+		case "/computer/api/json":
+			w.Write([]byte(`{"busyExecutors":3,"totalExecutors":10}`))
+		case "/queue/api/json":
+			w.Write([]byte(`{"items":[{"id":1},{"id":2}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	cfg := models.Config{Timeout: time.Second, Jenkins: []models.JenkinsTarget{
+		{ID: "prod", Name: "prod", Host: srv.URL, Username: "u", Credential: models.Credential{Type: models.CredentialTypeKeyring, Ref: "prod"}},
+		{ID: "dev", Name: "dev", Host: "https://dev", Username: "u", Credential: models.Credential{Type: models.CredentialTypeKeyring, Ref: "dev"}},
+	}}
+	m, ok := NewModel(context.Background(), cfg).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	creds := newStubCreds()
+	creds.values["prod"] = "token"
+	m.creds = creds
+	// Sizing the servers screen probes the targets it shows.
+	updated, cmd := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = updated.(*model)
+	if view := m.View(); !strings.Contains(view, "checking health...") || !strings.Contains(view, "no credentials") {
+		t.Fatalf("expected checking and missing-credential lines, got %q", view)
+	}
+	m = drainCmd(t, m, cmd, 0)
+	if view := m.View(); !strings.Contains(view, "up | Jenkins 2.452.1 | 3/10 executors busy | 2 queued") {
+		t.Fatalf("expected health line on servers screen, got %q", view)
+	}
+	if m.checkServerHealth(false) != nil {
+		t.Fatalf("already checked targets should not be probed again without refresh")
+	}
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	if h := m.health["prod"]; h.checking || h.err != nil || h.info.Queued != 2 {
+		t.Fatalf("r should re-check health, got %+v", h)
+	}
+}
+
+type countingCreds struct {
+	*stubCreds
+	resolved []string
+}
+
+func (c *countingCreds) Resolve(target models.JenkinsTarget) (string, error) {
+	c.resolved = append(c.resolved, target.ID)
+	return c.stubCreds.Resolve(target)
+}
+
+func TestServerHealthOnlyResolvesShownTargets(t *testing.T) {
+	cfg := models.Config{Timeout: time.Second}
+	for i := 0; i < 30; i++ {
+		id := fmt.Sprintf("t%02d", i)
+		cfg.Jenkins = append(cfg.Jenkins, models.JenkinsTarget{ID: id, Name: id, Host: "https://" + id, Username: "u", Credential: models.Credential{Type: models.CredentialTypeKeyring, Ref: id}})
+	}
+	m, ok := NewModel(context.Background(), cfg).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	creds := &countingCreds{stubCreds: newStubCreds()}
+	m.creds = creds
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 30})
+	m = updated.(*model)
+	first := len(creds.resolved)
+	if first == 0 || first >= len(cfg.Jenkins) {
+		t.Fatalf("expected only the first page to be probed, resolved %v", creds.resolved)
+	}
+	if m.checkServerHealth(true); len(creds.resolved) != 2*first {
+		t.Fatalf("a refresh should re-probe only the shown page, resolved %v", creds.resolved)
+	}
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyPgDown})
+	m = updated.(*model)
+	if len(creds.resolved) <= 2*first || slices.Contains(creds.resolved[:first], creds.resolved[len(creds.resolved)-1]) {
+		t.Fatalf("paging should probe the newly shown targets, resolved %v", creds.resolved)
+	}
+}
+
+func TestTokenCheckFlagsRejectedToken(t *testing.T) {
+	valid := "old"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, pass, _ := r.BasicAuth(); pass != valid {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"name":"u","anonymous":false}`))
+	}))
+	defer srv.Close()
+
+	cfg := models.Config{Timeout: time.Second, Jenkins: []models.JenkinsTarget{
+		{ID: "prod", Name: "prod", Host: srv.URL, Username: "u", Credential: models.Credential{Type: models.CredentialTypeKeyring, Ref: "prod"}},
+	}}
+	m, ok := NewModel(context.Background(), cfg).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	creds := newStubCreds()
+	creds.values["prod"] = "old"
+	m.creds = creds
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = updated.(*model)
+
+	m = drainCmd(t, m, m.checkTokens(), 0)
+	if m.rejectedTokens["prod"] {
+		t.Fatalf("a working token should not be flagged")
+	}
+	valid = "new"
+	m = drainCmd(t, m, m.checkTokens(), 0)
+	if view := m.View(); !strings.Contains(view, "token rejected (401)") || !strings.Contains(view, "to rotate it") {
+		t.Fatalf("expected rejected-token badge with rotate hint, got %q", view)
+	}
+	m = drainCmd(t, m, func() tea.Msg { return tokenHealthMsg{target: "prod", err: errors.New("dial tcp: connection refused")} }, 0)
+	if !m.rejectedTokens["prod"] {
+		t.Fatalf("an unreachable server should not clear the rejected flag")
+	}
+	creds.values["prod"] = "new"
+	m = drainCmd(t, m, m.checkTokens(), 0)
+	if view := m.View(); strings.Contains(view, "token rejected") {
+		t.Fatalf("accepted token should clear the badge, got %q", view)
+	}
+}
+
+func TestConnectionTestShowsDiagnosis(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	cfg := models.Config{Timeout: time.Second, Jenkins: []models.JenkinsTarget{
+		{ID: "prod", Name: "prod", Host: srv.URL, Username: "u", Credential: models.Credential{Type: models.CredentialTypeKeyring, Ref: "prod"}},
+	}}
+	m, ok := NewModel(context.Background(), cfg).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	creds := newStubCreds()
+	creds.values["prod"] = "stale"
+	m.creds = creds
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = updated.(*model)
+
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")})
+	if m.screen != screenConnTest || m.status != "Connection test failed at auth" {
+		t.Fatalf("c should show the diagnosis, screen=%v status=%q", m.screen, m.status)
+	}
+	view := m.View()
+	for _, want := range []string{"Connection test: prod", "credentials", "401: credentials rejected", "check the username and API token", "skipped after an earlier failure"} {
+		if !strings.Contains(view, want) {
+			t.Fatalf("expected %q in diagnosis, got %q", want, view)
+		}
+	}
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyEsc})
+	if m.screen != screenServers {
+		t.Fatalf("esc should return to servers, got %v", m.screen)
+	}
+}
+
+func TestAdminQuietDownAfterConfirm(t *testing.T) {
+	var mu sync.Mutex
+	var posts []string
+	admin := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost:
+			mu.Lock()
+			posts = append(posts, r.URL.Path)
+			mu.Unlock()
+		case r.URL.Path == "/manage/":
+			if !admin {
+				w.WriteHeader(http.StatusForbidden)
+			}
+		case r.URL.Path == "/api/json":
+			w.Write([]byte(`{"quietingDown":false}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	cfg := models.Config{Timeout: time.Second, Jenkins: []models.JenkinsTarget{
+		{ID: "prod", Name: "prod", Host: srv.URL, Username: "u", Credential: models.Credential{Type: models.CredentialTypeKeyring, Ref: "prod"}},
+	}}
+	m, ok := NewModel(context.Background(), cfg).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	creds := newStubCreds()
+	creds.values["prod"] = "token"
+	m.creds = creds
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = updated.(*model)
+
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("A")})
+	if m.confirm == nil || !strings.Contains(m.View(), "Quiet down") {
+		t.Fatalf("A should offer admin actions, got %q", m.View())
+	}
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.confirm == nil || len(posts) != 0 {
+		t.Fatalf("choosing an action should ask for confirmation first, posts=%v", posts)
+	}
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	mu.Lock()
+	got := strings.Join(posts, " ")
+	mu.Unlock()
+	if got != "/quietDown" || m.status != "Quieting down prod" {
+		t.Fatalf("expected quietDown POST, got posts=%q status=%q err=%v", got, m.status, m.err)
+	}
+
+	admin = false
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("A")})
+	if m.confirm != nil || !strings.Contains(m.status, "need Overall/Administer") {
+		t.Fatalf("non-admins should not get the actions, status=%q", m.status)
+	}
+}
+
+func TestPreviewWarnsAboutContendedLockableResources(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/job/deploy/config.xml":
+			w.Write([]byte(`<?xml version='1.1' encoding='UTF-8'?><flow-definition><definition><script>lock("db-${params.ENV}") { sh 'make' }</script></definition></flow-definition>`))
+		case "/lockable-resources/api/json":
+			w.Write([]byte(`{"resources":[{"name":"db-qa","locked":false},{"name":"db-prod","locked":true,"buildName":"migrate #7"}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	m, ok := NewModel(context.Background(), models.Config{Timeout: time.Second}).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = updated.(*model)
+	m.client = jenkins.NewClient(models.JenkinsTarget{Host: srv.URL, Username: "u"}, "t", time.Second)
+	m.selectedJob = &models.JobRef{Name: "deploy", FullName: "deploy", URL: srv.URL + "/job/deploy/"}
+	m.permutations = []models.JobSpec{
+		{Params: map[string]string{"ENV": "qa", "SHARD": "1"}},
+		{Params: map[string]string{"ENV": "qa", "SHARD": "2"}},
+		{Params: map[string]string{"ENV": "prod", "SHARD": "1"}},
+	}
+	m = drainCmd(t, m, m.openPreview(nil), 0)
+	want := []string{"db-prod is locked by migrate #7", "db-qa is needed by runs 1, 2; they will run one at a time"}
+	if !reflect.DeepEqual(m.lockWarnings, want) {
+		t.Fatalf("lock warnings = %q, want %q", m.lockWarnings, want)
+	}
+	if view := m.View(); !strings.Contains(view, "db-prod is locked by migrate #7") {
+		t.Fatalf("preview should show the warnings, got %q", view)
+	}
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.confirm == nil || m.screen != screenPreview || len(m.batches) != 0 {
+		t.Fatalf("contended runs should ask before starting, screen=%v", m.screen)
+	}
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+
+	m.screen = screenJobs
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("R")})
+	if m.screen != screenLocks || !strings.Contains(m.View(), "migrate #7") {
+		t.Fatalf("R should list lockable resources, screen=%v status=%q err=%v", m.screen, m.status, m.err)
+	}
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyEsc})
+	if m.screen != screenJobs {
+		t.Fatalf("esc should return to jobs, got %v", m.screen)
+	}
+}
+
+func TestReplayFromHistory(t *testing.T) {
+	var mu sync.Mutex
+	var posts []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost:
+			mu.Lock()
+			posts = append(posts, r.URL.Path)
+			mu.Unlock()
+		case r.URL.Path == "/job/pipe/api/json":
+			w.Write([]byte(`{"nextBuildNumber":8,"builds":[]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	m, ok := NewModel(context.Background(), models.Config{Timeout: time.Second}).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = updated.(*model)
+	m.client = jenkins.NewClient(models.JenkinsTarget{Host: srv.URL, Username: "u"}, "t", time.Second)
+	job := models.JobRef{Name: "pipe", FullName: "pipe", URL: srv.URL + "/job/pipe/"}
+	m.historyJob = &job
+	m.builds = []models.BuildSummary{{Number: 7, URL: srv.URL + "/job/pipe/7/", Result: "FAILURE"}}
+	m.refreshHistoryTable()
+	m.screen = screenHistory
+
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("p")})
+	if m.confirm == nil || !strings.Contains(m.View(), "Replay #7") {
+		t.Fatalf("p should offer to replay the build, got %q", m.View())
+	}
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	mu.Lock()
+	got := strings.Join(posts, " ")
+	mu.Unlock()
+	if got != "/job/pipe/7/replay/rebuild" || !strings.Contains(m.status, "Replayed #7") || !strings.Contains(m.status, "as #8") {
+		t.Fatalf("expected an as-is replay, posts=%q status=%q err=%v", got, m.status, m.err)
+	}
+
+	target := replayTarget{job: job, url: srv.URL + "/job/pipe/7/", label: "#7"}
+	m = drainCmd(t, m, func() tea.Msg {
+		return replayEditedMsg{target: target, scripts: jenkins.ReplayScripts{Main: "node {}"}}
+	}, 0)
+	if m.confirm == nil || !strings.Contains(m.View(), "Script unchanged") {
+		t.Fatalf("an unchanged script should ask before replaying, got %q", m.View())
+	}
+}
+
+func TestEditedRunMatrixReplacesPermutations(t *testing.T) {
+	m, ok := NewModel(context.Background(), models.Config{Timeout: time.Second}).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = updated.(*model)
+	m.params = []models.ParamDef{{Name: "ENV"}, {Name: "SHARD"}}
+	m.selectedJob = &models.JobRef{Name: "deploy", FullName: "deploy", URL: "https://jenkins/job/deploy/"}
+	m.permutations = []models.JobSpec{{Params: map[string]string{"ENV": "qa", "SHARD": "1"}}}
+	m.screen = screenPreview
+
+	text := formatRunMatrix(m.permutations)
+	if !strings.Contains(text, `{"ENV":"qa","SHARD":"1"}`) {
+		t.Fatalf("matrix should list one JSON object per run, got %q", text)
+	}
+	text += `{"ENV":"prod","SHARD":2}` + "\n"
+	m = drainCmd(t, m, func() tea.Msg { return matrixEditedMsg{text: text} }, 0)
+	want := []models.JobSpec{
+		{Params: map[string]string{"ENV": "qa", "SHARD": "1"}},
+		{Params: map[string]string{"ENV": "prod", "SHARD": "2"}},
+	}
+	if !reflect.DeepEqual(m.permutations, want) || len(m.previewTable.Rows()) != 2 {
+		t.Fatalf("edited matrix should replace the runs, got %+v", m.permutations)
+	}
+
+	m = drainCmd(t, m, func() tea.Msg { return matrixEditedMsg{text: `{"REGION":"eu"}`} }, 0)
+	if m.err == nil || !strings.Contains(m.err.Error(), `unknown parameter "REGION"`) || len(m.permutations) != 2 {
+		t.Fatalf("unknown parameters should be rejected, err=%v", m.err)
+	}
+	m = drainCmd(t, m, func() tea.Msg { return matrixEditedMsg{text: "# nothing\n"} }, 0)
+	if len(m.permutations) != 2 || !strings.Contains(m.status, "kept the previous runs") {
+		t.Fatalf("an empty matrix should keep the runs, status=%q", m.status)
+	}
+}
+
+func TestEditorCommandPrefersVisual(t *testing.T) {
+	m, ok := NewModel(context.Background(), models.Config{Timeout: time.Second}).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	env := map[string]string{"VISUAL": "code --wait", "EDITOR": "nano"}
+	m.lookupEnv = func(key string) string { return env[key] }
+	if got := m.editorCommand("/tmp/x").Args; !reflect.DeepEqual(got, []string{"code", "--wait", "/tmp/x"}) {
+		t.Fatalf("VISUAL should win, got %v", got)
+	}
+	delete(env, "VISUAL")
+	if got := m.editorCommand("/tmp/x").Args; !reflect.DeepEqual(got, []string{"nano", "/tmp/x"}) {
+		t.Fatalf("EDITOR should be used without VISUAL, got %v", got)
+	}
+	env["EDITOR"] = `"C:\Program Files\Notepad++\notepad++.exe" -multiInst`
+	if got := m.editorCommand(`C:\x`).Args; !reflect.DeepEqual(got, []string{`C:\Program Files\Notepad++\notepad++.exe`, "-multiInst", `C:\x`}) {
+		t.Fatalf("a quoted editor path should stay whole, got %q", got)
+	}
+}
+
+func TestEditedConfigIsReloadedAndValidated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jenkins.yaml")
+	cfg := models.Config{Timeout: time.Second, ConfigPath: path, Jenkins: []models.JenkinsTarget{
+		{ID: "prod", Name: "prod", Host: "https://jenkins", Username: "u", Credential: models.Credential{Type: models.CredentialTypeEnv, Ref: "TOKEN"}},
+	}}
+	m, ok := NewModel(context.Background(), cfg).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = updated.(*model)
+	m.screen = screenManageTargets
+
+	edited := `jenkins:
+  - id: prod
+    host: https://jenkins
+    username: u
+    credential: {type: env, ref: TOKEN}
+  - id: dev
+    host: https://dev.jenkins/
+    username: me
+    credential: {type: env, ref: DEV_TOKEN}
+keybindings:
+  test_connection: C
+`
+	if err := os.WriteFile(path, []byte(edited), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	m = drainCmd(t, m, func() tea.Msg { return configEditedMsg{} }, 0)
+	if len(m.cfg.Jenkins) != 2 || m.cfg.Jenkins[1].Host != "https://dev.jenkins" || len(m.servers.Items()) != 2 {
+		t.Fatalf("edited config should be reloaded, got %+v", m.cfg.Jenkins)
+	}
+	if m.cfg.ConfigPath != path || m.cfg.Timeout != time.Second || firstKey(m.keys.TestConn) != "C" {
+		t.Fatalf("reload should keep flag settings and apply keybindings, got %+v", m.cfg)
+	}
+
+	if err := os.WriteFile(path, []byte("jenkins:\n  - host: https://nope\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	m = drainCmd(t, m, func() tea.Msg { return configEditedMsg{} }, 0)
+	if m.confirm == nil || !strings.Contains(m.View(), "id is required") || len(m.cfg.Jenkins) != 2 {
+		t.Fatalf("an invalid edit should keep the config and offer to edit again, got %q", m.View())
+	}
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	if m.confirm != nil || len(m.cfg.Jenkins) != 2 {
+		t.Fatalf("n should keep the running config")
+	}
+}
+
+func TestExternalConfigChangeIsReloaded(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jenkins.yaml")
+	prod := models.JenkinsTarget{ID: "prod", Name: "prod", Host: "https://jenkins", Username: "u", Credential: models.Credential{Type: models.CredentialTypeEnv, Ref: "TOKEN"}}
+	cfg := models.Config{Timeout: time.Second, ConfigPath: path, Jenkins: []models.JenkinsTarget{prod}}
+	m, ok := NewModel(context.Background(), cfg).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = updated.(*model)
+	if err := m.persistConfig(); err != nil {
+		t.Fatal(err)
+	}
+	m = drainCmd(t, m, func() tea.Msg { return configTickMsg{} }, 0)
+	if m.confirm != nil || strings.Contains(m.status, "Reloaded") {
+		t.Fatalf("our own save should not trigger a reload")
+	}
+	m.target = m.findTargetByID("prod")
+	m.client = jenkins.NewClient(*m.target, "token", time.Second)
+	m.screen = screenJobs
+
+	write := func(host, extra string) {
+		t.Helper()
+		yaml := "jenkins:\n  - id: prod\n    host: " + host + "\n    username: u\n    credential: {type: env, ref: TOKEN}\n" + extra
+		if err := os.WriteFile(path, []byte(yaml), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("https://jenkins", "    bookmarks: [apps]\n  - id: dev\n    host: https://dev\n    username: u\n    credential: {type: env, ref: TOKEN}\n")
+	m = drainCmd(t, m, func() tea.Msg { return configTickMsg{} }, 0)
+	if m.confirm != nil || len(m.cfg.Jenkins) != 2 || m.target == nil || len(m.target.Bookmarks) != 1 {
+		t.Fatalf("an unrelated change should reload quietly, confirm=%v target=%+v", m.confirm != nil, m.target)
+	}
+
+	write("https://jenkins.new", "")
+	m = drainCmd(t, m, func() tea.Msg { return configTickMsg{} }, 0)
+	if m.confirm == nil || !strings.Contains(m.View(), "prod changed in jenkins.yaml") {
+		t.Fatalf("changing the connected server should ask first, got %q", m.View())
+	}
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	if m.target != nil || m.client != nil || m.screen != screenServers || m.cfg.Jenkins[0].Host != "https://jenkins.new" {
+		t.Fatalf("y should reload and disconnect, screen=%v target=%+v", m.screen, m.target)
+	}
+}
+
+func TestConfigWatcherSeesFilesRenamedIntoPlace(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "jenkins.yaml")
+	if err := os.WriteFile(path, []byte("jenkins: []\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cw, err := watchConfig(path)
+	if err != nil {
+		t.Skipf("file watching unavailable: %v", err)
+	}
+	changed := make(chan tea.Msg, 1)
+	go func() { changed <- cw.wait()() }()
+
+	if err := os.WriteFile(filepath.Join(dir, "other.yaml"), []byte("x"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case msg := <-changed:
+		t.Fatalf("a sibling file should not count as a change, got %T", msg)
+	case <-time.After(100 * time.Millisecond):
+	}
+	tmp := filepath.Join(dir, ".jenkins.yaml.tmp")
+	if err := os.WriteFile(tmp, []byte("jenkins: []\ntheme: dark\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case msg := <-changed:
+		if _, ok := msg.(configChangedMsg); !ok {
+			t.Fatalf("expected configChangedMsg, got %T", msg)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("an atomic replace of jenkins.yaml was not seen")
+	}
+}
+
+func TestServersAreGroupedAndFilteredByTag(t *testing.T) {
+	target := func(id string, tags ...string) models.JenkinsTarget {
+		return models.JenkinsTarget{ID: id, Name: id, Host: "https://" + id, Username: "u", Tags: tags,
+			Credential: models.Credential{Type: models.CredentialTypeEnv, Ref: "TOKEN"}}
+	}
+	cfg := models.Config{Timeout: time.Second, Jenkins: []models.JenkinsTarget{
+		target("legacy"), target("eu-prod", "prod", "eu"), target("us-dev", "dev", "us"), target("us-prod", "prod", "us"),
+	}}
+	m, ok := NewModel(context.Background(), cfg).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = updated.(*model)
+	ids := func() string {
+		var out []string
+		for _, it := range m.servers.Items() {
+			out = append(out, it.(listItem).id)
+		}
+		return strings.Join(out, ",")
+	}
+	if got := ids(); got != "eu-prod,us-prod,us-dev,legacy" {
+		t.Fatalf("servers should be grouped by first tag, untagged last, got %s", got)
+	}
+	if desc := m.servers.Items()[0].(listItem).desc; !strings.HasPrefix(desc, "#prod #eu · u | https://eu-prod") {
+		t.Fatalf("tags should lead the description, got %q", desc)
+	}
+
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("T")})
+	if got := ids(); got != "eu-prod,us-prod" || !strings.Contains(m.servers.Title, "#prod (2 of 4)") {
+		t.Fatalf("T should show #prod servers, got %s (%q)", got, m.servers.Title)
+	}
+	for i := 0; i < 3; i++ {
+		m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("T")})
+	}
+	if got := ids(); got != "us-prod,us-dev" {
+		t.Fatalf("T should step to #us, got %s", got)
+	}
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("T")})
+	if len(m.servers.Items()) != 4 || m.servers.Title != "Jenkins Servers" {
+		t.Fatalf("T after the last tag should show all servers, got %d", len(m.servers.Items()))
+	}
+}
+
+func TestImportServersFromJenkinsCLI(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	cli := "jenkins_servers:\n- name: dev\n  url: https://dev.jenkins/\n  username: admin\n  token: abc\n"
+	if err := os.WriteFile(filepath.Join(home, ".jenkins-cli.yaml"), []byte(cli), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "jenkins.yaml")
+	creds := newStubCreds()
+	m, ok := NewModel(context.Background(), models.Config{Timeout: time.Second, ConfigPath: path}).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	m.creds = creds
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = updated.(*model)
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyEsc})
+	if !strings.Contains(m.status, "I to import") {
+		t.Fatalf("an empty config should point at import, got %q", m.status)
+	}
+
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("I")})
+	if m.confirm == nil {
+		t.Fatalf("I should ask where to import from")
+	}
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.confirm == nil || !strings.Contains(m.View(), "dev (https://dev.jenkins as admin)") {
+		t.Fatalf("the servers found should be offered, got %q", m.View())
+	}
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")})
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.confirm != nil || len(m.cfg.Jenkins) != 1 {
+		t.Fatalf("the checked server should be added, got %+v (status %q)", m.cfg.Jenkins, m.status)
+	}
+	got := m.cfg.Jenkins[0]
+	if got.ID != "dev" || got.Host != "https://dev.jenkins" || got.Credential.Ref != "jenkins-tui/dev" || creds.values["jenkins-tui/dev"] != "abc" {
+		t.Fatalf("unexpected import: %+v, keyring %v", got, creds.values)
+	}
+	saved, err := config.Load(path)
+	if err != nil || len(saved.Jenkins) != 1 {
+		t.Fatalf("import should be saved, got %+v, %v", saved.Jenkins, err)
+	}
+}
+
+func TestFailedImportDeletesStoredTokens(t *testing.T) {
+	creds := newStubCreds()
+	m, ok := NewModel(context.Background(), models.Config{Timeout: time.Second}).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	m.creds = creds
+	target := models.JenkinsTarget{ID: "dev", Name: "dev", Host: "https://dev.jenkins",
+		Credential: models.Credential{Type: models.CredentialTypeKeyring, Ref: "jenkins-tui/dev"}}
+	m.addImported([]config.ImportCandidate{{Target: target, Token: "abc"}}, []string{"dev"})
+	if m.err == nil || len(m.cfg.Jenkins) != 0 {
+		t.Fatalf("the import should fail without a config path, got %+v, %v", m.cfg.Jenkins, m.err)
+	}
+	if _, ok := creds.values["jenkins-tui/dev"]; ok {
+		t.Fatalf("the token stored for a server that was not saved should be deleted, keyring %v", creds.values)
+	}
+}
+
+func TestRefreshMarksAddedAndRemovedJobs(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"jobs":[
+			{"name":"build","url":"%[1]s/job/build/","_class":"hudson.model.FreeStyleProject"},
+			{"name":"release","url":"%[1]s/job/release/","_class":"hudson.model.FreeStyleProject"}]}`, srv.URL)
+	}))
+	defer srv.Close()
+	cacheDir := t.TempDir()
+	client := jenkins.NewClient(models.JenkinsTarget{Host: srv.URL}, "token", time.Second)
+	before := []models.JobNode{
+		{Name: "build", FullName: "build", URL: srv.URL + "/job/build/", Kind: models.JobNodeJob},
+		{Name: "legacy", FullName: "legacy", URL: srv.URL + "/job/legacy/", Kind: models.JobNodeJob},
+	}
+	if err := cache.SaveJobNodesInDir(cacheDir, client.CacheKey(), srv.URL, before); err != nil {
+		t.Fatal(err)
+	}
+
+	m, ok := NewModel(context.Background(), models.Config{Timeout: time.Second, CacheDir: cacheDir}).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	m.client = client
+	m.jobsReqID = 1
+	msg := loadJobsCmd(context.Background(), cacheDir, client, srv.URL, "", true, false, 1)()
+	updated, _ := m.Update(msg)
+	m = updated.(*model)
+	if !strings.Contains(m.status, "(+1 new, -1 removed: legacy)") {
+		t.Fatalf("expected a diff summary, got %q", m.status)
+	}
+	for _, item := range m.jobs.Items() {
+		li := item.(listItem)
+		if (li.badge != "") != (li.name == "release") {
+			t.Fatalf("only the new job should carry a badge, %s has %q", li.name, li.badge)
+		}
+	}
+
+	// Loading the same listing again has nothing to report.
+	m.jobsReqID = 2
+	updated, _ = m.Update(loadJobsCmd(context.Background(), cacheDir, client, srv.URL, "", true, false, 2)())
+	m = updated.(*model)
+	if strings.Contains(m.status, "new") {
+		t.Fatalf("an unchanged refresh should not report a diff, got %q", m.status)
+	}
+}
+
+func TestGlobalSearchFilterTerms(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/search/suggestOpenSearch":
+			fmt.Fprintf(w, `["de",["deploy","deploy","deploy"],["platform/deploy","platform/apps/deploy","tools/deploy"],["%[1]s/job/platform/job/deploy/","%[1]s/job/platform/job/apps/job/deploy/","%[1]s/job/tools/job/deploy/"]]`, srv.URL)
+		case "/job/platform/job/deploy/api/json":
+			w.Write([]byte(`{"name":"deploy","_class":"hudson.model.FreeStyleProject","color":"red"}`))
+		case "/job/platform/job/apps/job/deploy/api/json":
+			w.Write([]byte(`{"name":"deploy","_class":"org.jenkinsci.plugins.workflow.job.WorkflowJob","color":"red"}`))
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	if _, err := search.Parse("de type:matrix", ""); err == nil {
+		t.Fatalf("an unknown type should be rejected")
+	}
+	query, err := search.Parse("de in:. type:pipeline is:failing", "platform")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := jenkins.NewClient(models.JenkinsTarget{Host: srv.URL}, "token", time.Second)
+	msg := loadSearchCmd(context.Background(), t.TempDir(), client, query, nil, 1)().(searchLoadedMsg)
+	if msg.err != nil || msg.found != 3 || len(msg.nodes) != 1 || msg.nodes[0].FullName != "platform/apps/deploy" {
+		t.Fatalf("expected only the failing pipeline under platform, got %+v found=%d (%v)", msg.nodes, msg.found, msg.err)
+	}
+}
+
+func TestSearchRanksJobsByUsage(t *testing.T) {
+	cacheDir := t.TempDir()
+	client := jenkins.NewClient(models.JenkinsTarget{Host: "https://jenkins"}, "token", time.Second)
+	for i := 0; i < 3; i++ {
+		if err := recordUseCmd(cacheDir, client, "https://jenkins/job/apps/job/deploy/")(); err != nil {
+			t.Fatalf("unexpected msg %v", err)
+		}
+	}
+	usage, err := cache.UsageInDir(cacheDir, client.CacheKey())
+	if err != nil || usage["https://jenkins/job/apps/job/deploy/"].Count != 3 {
+		t.Fatalf("expected three recorded uses, got %+v (%v)", usage, err)
+	}
+	now := time.Now()
+	usage["https://jenkins/job/old/job/deploy/"] = cache.JobUse{Count: 10, Last: now.Add(-60 * 24 * time.Hour)}
+
+	nodes := []models.JobNode{
+		{FullName: "old/deploy", URL: "https://jenkins/job/old/job/deploy/"},
+		{FullName: "copy/deploy", URL: "https://jenkins/job/copy/job/deploy/"},
+		{FullName: "team/deploy", URL: "https://jenkins/job/team/job/deploy/"},
+		{FullName: "apps/deploy", URL: "https://jenkins/job/apps/job/deploy/"},
+	}
+	rankByUsage(nodes, usage, []string{"team"}, now)
+	var order []string
+	for _, n := range nodes {
+		order = append(order, n.FullName)
+	}
+	// Three uses today beat a bookmarked folder, which beats ten uses two
+	// months ago; unused jobs keep their order.
+	if got := strings.Join(order, ","); got != "apps/deploy,team/deploy,old/deploy,copy/deploy" {
+		t.Fatalf("unexpected ranking %s", got)
+	}
+}
+
+func TestJobsListShowsFolderCountsAndCacheAge(t *testing.T) {
+	cacheDir := t.TempDir()
+	client := jenkins.NewClient(models.JenkinsTarget{Host: "https://jenkins"}, "token", time.Second)
+	root := []models.JobNode{
+		{Name: "apps", FullName: "apps", URL: "https://jenkins/job/apps/", Kind: models.JobNodeFolder},
+		{Name: "tools", FullName: "tools", URL: "https://jenkins/job/tools/", Kind: models.JobNodeFolder},
+	}
+	children := []models.JobNode{
+		{Name: "api", FullName: "apps/api", URL: "https://jenkins/job/apps/job/api/", Kind: models.JobNodeJob},
+		{Name: "web", FullName: "apps/web", URL: "https://jenkins/job/apps/job/web/", Kind: models.JobNodeJob},
+	}
+	for url, nodes := range map[string][]models.JobNode{"https://jenkins": root, "https://jenkins/job/apps/": children} {
+		if err := cache.SaveJobNodesInDir(cacheDir, client.CacheKey(), url, nodes); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m, ok := NewModel(context.Background(), models.Config{Timeout: time.Second, CacheDir: cacheDir}).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	m.width, m.height = 120, 40
+	m.client = client
+	m.jobsReqID = 1
+	updated, _ := m.Update(loadJobsCmd(context.Background(), cacheDir, client, "https://jenkins", "", false, false, 1)())
+	m = updated.(*model)
+	descs := map[string]string{}
+	for _, item := range m.jobs.Items() {
+		descs[item.(listItem).name] = item.(listItem).desc
+	}
+	if descs["apps"] != "folder, 2 item(s), cached just now" || descs["tools"] != "folder" {
+		t.Fatalf("unexpected folder descriptions %q", descs)
+	}
+	if view := m.View(); !strings.Contains(view, "cached just now") {
+		t.Fatalf("expected the path line to say the listing came from cache, got %q", view)
+	}
+}
+
+func TestUnreachableServerSwitchesToOfflineMode(t *testing.T) {
+	cacheDir := t.TempDir()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.Close()
+	target := models.JenkinsTarget{ID: "ci", Name: "ci", Host: srv.URL}
+	client := jenkins.NewClient(target, "token", time.Second)
+	nodes := []models.JobNode{{Name: "deploy", FullName: "deploy", URL: srv.URL + "/job/deploy/", Kind: models.JobNodeJob}}
+	if err := cache.SaveJobNodesInDir(cacheDir, client.CacheKey(), srv.URL, nodes); err != nil {
+		t.Fatal(err)
+	}
+
+	m, ok := NewModel(context.Background(), models.Config{Timeout: time.Second, CacheDir: cacheDir, Jenkins: []models.JenkinsTarget{target}}).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	m.width, m.height = 120, 40
+	m.target = &m.cfg.Jenkins[0]
+	m.client = client
+	m.screen = screenJobs
+	m.jobsReqID = 1
+	updated, _ := m.Update(loadJobsCmd(context.Background(), cacheDir, client, srv.URL, "", true, false, 1)())
+	m = updated.(*model)
+	if !m.offline || m.err != nil || len(m.jobs.Items()) != 1 {
+		t.Fatalf("expected the cached listing in offline mode, offline=%v err=%v items=%d", m.offline, m.err, len(m.jobs.Items()))
+	}
+	if view := m.View(); !strings.Contains(view, "OFFLINE") {
+		t.Fatalf("expected OFFLINE in the footer, got %q", view)
+	}
+
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("h")})
+	if m.screen != screenJobs || m.loading || !strings.Contains(m.status, "needs the network") {
+		t.Fatalf("expected history to be refused offline, screen=%v status=%q", m.screen, m.status)
+	}
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.selectedJob != nil || !strings.Contains(m.status, "triggering deploy needs the network") {
+		t.Fatalf("expected triggering to be refused offline, status=%q", m.status)
+	}
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("O")})
+	m = updated.(*model)
+	if m.offline || cmd == nil {
+		t.Fatalf("expected O to go back online and refetch")
+	}
+	m = drainCmd(t, m, cmd, 0)
+	if !m.offline {
+		t.Fatalf("expected the still unreachable server to switch back to offline mode")
+	}
+}
+
+func TestWatchedJobReportsLastBuildChanges(t *testing.T) {
+	var mu sync.Mutex
+	number, result := 42, "SUCCESS"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/job/deploy/api/json" {
+			http.NotFound(w, r)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		_, _ = fmt.Fprintf(w, `{"name":"deploy","fullName":"deploy","url":%q,"lastBuild":{"number":%d,"result":%q}}`, "http://"+r.Host+"/job/deploy/", number, result)
+	}))
+	defer srv.Close()
+	cfg := models.Config{
+		Timeout:    time.Second,
+		ConfigPath: filepath.Join(t.TempDir(), "jenkins.yaml"),
+		Jenkins: []models.JenkinsTarget{
+			{ID: "prod", Name: "prod", Host: srv.URL, Username: "u", Credential: models.Credential{Type: models.CredentialTypeEnv, Ref: "TOKEN"}},
+		},
+	}
+	m, ok := NewModel(context.Background(), cfg).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = updated.(*model)
+	m.screen = screenJobs
+	m.target = &m.cfg.Jenkins[0]
+	m.client = jenkins.NewClient(m.cfg.Jenkins[0], "token", time.Second)
+	m.jobs.SetItems([]list.Item{listItem{title: "deploy", name: "deploy", fullName: "deploy", id: srv.URL + "/job/deploy/", kind: models.JobNodeJob}})
+
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("w")})
+	saved, err := config.Load(m.cfg.ConfigPath)
+	if err != nil || len(saved.Jenkins[0].Watches) != 1 || saved.Jenkins[0].Watches[0] != "deploy" {
+		t.Fatalf("expected the watch persisted, got %+v err=%v", saved.Jenkins, err)
+	}
+	if st := m.watchState[watchKey("prod", "deploy")]; st.number != 42 || st.result != "SUCCESS" {
+		t.Fatalf("expected the first poll recorded, got %+v", st)
+	}
+
+	mu.Lock()
+	number, result = 43, "FAILURE"
+	mu.Unlock()
+	updated, cmd := m.Update(watchTickMsg{})
+	m = drainCmd(t, updated.(*model), cmd, 0)
+	if m.status != "Watch: /deploy #43 FAILURE" {
+		t.Fatalf("expected the new failure in the footer, got %q", m.status)
+	}
+
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("W")})
+	if m.screen != screenWatch {
+		t.Fatalf("expected the watch screen, got %s", m.screen)
+	}
+	if view := m.View(); !strings.Contains(view, "#43") || !strings.Contains(view, "FAILURE") {
+		t.Fatalf("expected the watched job's last build listed, got %q", view)
+	}
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("v")})
+	if view := m.View(); m.screen != screenRadiator || !strings.Contains(view, "Jenkins radiator: prod") || !strings.Contains(view, "#43 FAILURE") {
+		t.Fatalf("expected the radiator to show the watched job, got %q", view)
+	}
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyEsc})
+	if m.screen != screenWatch {
+		t.Fatalf("expected esc to return to the watch list, got %s", m.screen)
+	}
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")})
+	if len(m.cfg.Jenkins[0].Watches) != 0 || m.screen != screenJobs {
+		t.Fatalf("expected the watch removed and the screen closed, got %v on %s", m.cfg.Jenkins[0].Watches, m.screen)
+	}
+}
+
+func TestWatchKeysCanBeRemapped(t *testing.T) {
+	cfg := models.Config{
+		Timeout:     time.Second,
+		ConfigPath:  filepath.Join(t.TempDir(), "jenkins.yaml"),
+		Keybindings: map[string]models.KeyList{"unwatch": {"x"}, "radiator": {"R"}},
+		Jenkins: []models.JenkinsTarget{
+			{ID: "prod", Name: "prod", Host: "https://jenkins", Username: "u", Credential: models.Credential{Type: models.CredentialTypeEnv, Ref: "TOKEN"}, Watches: []string{"deploy"}},
+		},
+	}
+	m, ok := NewModel(context.Background(), cfg).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = updated.(*model)
+	m.target = &m.cfg.Jenkins[0]
+	m.watchBackTo = screenJobs
+	m.refreshWatchTable()
+	m.screen = screenWatch
+	if view := m.View(); !strings.Contains(view, "x unwatch") {
+		t.Fatalf("the footer should show the remapped key, got %q", view)
+	}
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("R")})
+	if m.screen != screenRadiator {
+		t.Fatalf("R should open the radiator once radiator is remapped, got %s", m.screen)
+	}
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("v")})
+	if m.screen != screenRadiator {
+		t.Fatal("v should no longer close the radiator")
+	}
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("R")})
+	if m.screen != screenWatch {
+		t.Fatalf("R should close the radiator, got %s", m.screen)
+	}
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")})
+	if len(m.cfg.Jenkins[0].Watches) != 1 {
+		t.Fatal("d should no longer unwatch once unwatch is remapped")
+	}
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	if len(m.cfg.Jenkins[0].Watches) != 0 {
+		t.Fatalf("x should unwatch, got %v", m.cfg.Jenkins[0].Watches)
+	}
+}
+
+func TestTriggerFolderJobsMatchingFilter(t *testing.T) {
+	var mu sync.Mutex
+	triggered := map[string]string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/job/nightly/job/nightly-api/api/json":
+			_, _ = fmt.Fprint(w, `{"property":[{"parameterDefinitions":[{"name":"BRANCH","type":"StringParameterDefinition","defaultParameterValue":{"value":"main"}}]}]}`)
+		case "/job/nightly/job/nightly-web/api/json":
+			_, _ = fmt.Fprint(w, `{}`)
+		case "/job/nightly/job/nightly-api/buildWithParameters", "/job/nightly/job/nightly-web/buildWithParameters":
+			_ = r.ParseForm()
+			mu.Lock()
+			triggered[r.URL.Path] = r.Form.Encode()
+			mu.Unlock()
+			http.Error(w, "stop here", http.StatusServiceUnavailable)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	m := NewModel(context.Background(), models.Config{Timeout: time.Second}).(*model)
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = updated.(*model)
+	m.screen = screenJobs
+	m.client = jenkins.NewClient(models.JenkinsTarget{Host: srv.URL}, "token", time.Second)
+	m.jobFolders = []models.JobNode{{Name: "nightly", FullName: "nightly", URL: srv.URL + "/job/nightly/", Kind: models.JobNodeFolder}}
+	m.jobs.SetItems([]list.Item{
+		listItem{title: "nightly-api", name: "nightly-api", fullName: "nightly/nightly-api", id: srv.URL + "/job/nightly/job/nightly-api/", kind: models.JobNodeJob},
+		listItem{title: "nightly-web", name: "nightly-web", fullName: "nightly/nightly-web", id: srv.URL + "/job/nightly/job/nightly-web/", kind: models.JobNodeJob},
+		listItem{title: "release", name: "release", fullName: "nightly/release", id: srv.URL + "/job/nightly/job/release/", kind: models.JobNodeJob},
+		listItem{title: "nightly-old", name: "nightly-old", fullName: "nightly/nightly-old", id: srv.URL + "/job/nightly/job/nightly-old/", kind: models.JobNodeJob, disabled: true},
+	})
+
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("T")})
+	if m.confirm == nil {
+		t.Fatalf("expected a filter prompt")
+	}
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("nightly-* BRANCH=dev")})
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.confirm == nil || !strings.Contains(m.View(), "Trigger 2 job(s) matching /nightly/nightly-*?") {
+		t.Fatalf("expected a confirmation for the two matching jobs, got %q", m.View())
+	}
+	if !strings.Contains(m.View(), "nightly-old (disabled)") {
+		t.Fatalf("expected the disabled job listed as skipped, got %q", m.View())
+	}
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	if m.batch == nil || len(m.batch.records) != 2 {
+		t.Fatalf("expected one batch with a run per matching job, got %d batch(es)", len(m.batches))
+	}
+	if got := m.batch.records[1].Spec.Job; got == nil || got.FullName != "nightly/nightly-web" {
+		t.Fatalf("expected each run to carry its job, got %+v", got)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		mu.Lock()
+		n := len(triggered)
+		mu.Unlock()
+		if n == 2 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if triggered["/job/nightly/job/nightly-api/buildWithParameters"] != "BRANCH=dev" || triggered["/job/nightly/job/nightly-web/buildWithParameters"] != "" {
+		t.Fatalf("expected the shared parameter only where it is defined, got %v", triggered)
+	}
+	if _, ok := triggered["/job/nightly/job/release/buildWithParameters"]; ok {
+		t.Fatalf("expected jobs outside the filter left alone")
+	}
+	if _, ok := triggered["/job/nightly/job/nightly-old/buildWithParameters"]; ok {
+		t.Fatalf("expected the disabled job left alone")
+	}
+}
+
+func TestViewRedactsTokensAndPasswordParams(t *testing.T) {
+	m := NewModel(context.Background(), models.Config{Timeout: time.Second}).(*model)
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 160, Height: 40})
+	m = updated.(*model)
+	m.client = jenkins.NewClient(models.JenkinsTarget{Host: "https://ci.example.com", Username: "ci"}, "11aa22bb33cc44dd", time.Second)
+	m.params = []models.ParamDef{{Name: "DB_PASS", Kind: models.ParamPassword}, {Name: "BRANCH", Kind: models.ParamString}}
+	m.buildParamForm()
+	*m.fixedVars["DB_PASS"] = "hunter2-db"
+	*m.fixedVars["BRANCH"] = "release-2024"
+	if err := m.buildPermutations(); err != nil {
+		t.Fatalf("buildPermutations: %v", err)
+	}
+
+	m.status = "Triggered with BRANCH=release-2024 DB_PASS=hunter2-db"
+	m.err = errors.New("GET https://ci.example.com/job/x/build?token=abc123 failed (401): bad credentials 11aa22bb33cc44dd")
+	view := m.View()
+	for _, secret := range []string{"hunter2-db", "11aa22bb33cc44dd", "abc123"} {
+		if strings.Contains(view, secret) {
+			t.Fatalf("expected %q to be redacted, got %q", secret, view)
+		}
+	}
+	if !strings.Contains(view, "release-2024") {
+		t.Fatalf("ordinary parameter values should still show, got %q", view)
+	}
+}
+
+func TestRunTablesMaskShortPasswordParams(t *testing.T) {
+	m := &model{params: []models.ParamDef{{Name: "PIN", Kind: models.ParamPassword}, {Name: "BRANCH", Kind: models.ParamString}}}
+	m.buildParamForm()
+	*m.fixedVars["PIN"] = "4711"
+	*m.fixedVars["BRANCH"] = "main"
+	if err := m.buildPermutations(); err != nil {
+		t.Fatalf("buildPermutations: %v", err)
+	}
+	if got := summarizeSpec(m.permutations[0]); got != "BRANCH=main, PIN="+redact.Mask {
+		t.Fatalf("expected a password too short to register masked by its kind, got %q", got)
+	}
+	if got := m.permutations[0].Params["PIN"]; got != "4711" {
+		t.Fatalf("the value sent to Jenkins must stay, got %q", got)
+	}
+}
+
+func TestTimeFormatConfigShapesHistoryAndRunTable(t *testing.T) {
+	defer ui.ConfigureTimes("", "", "", "")
+	cfg := models.Config{Timeout: time.Second, Clock: models.Clock12h, Timezone: "UTC", DurationStyle: models.DurationStyleClock}
+	m, ok := NewModel(context.Background(), cfg).(*model)
+	if !ok {
+		t.Fatalf("NewModel should return *model")
+	}
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 140, Height: 40})
+	m = updated.(*model)
+	m.builds = []models.BuildSummary{{Number: 7, Result: "SUCCESS", Timestamp: time.Date(2024, 3, 5, 14, 7, 0, 0, time.UTC)}}
+	m.refreshHistoryTable()
+	if got := m.historyTable.Rows()[0][2]; got != "2024-03-05 2:07 PM" {
+		t.Fatalf("expected a 12h UTC start time, got %q", got)
+	}
+
+	started := time.Now().Add(-95 * time.Second)
+	b := &runBatch{records: []models.RunRecord{{State: models.RunSuccess, StartedAt: started, EndedAt: started.Add(65 * time.Second)}}}
+	m.refreshRunTable(b)
+	if got := b.table.Rows()[0][3]; got != "1:05" {
+		t.Fatalf("expected a clock-style run duration, got %q", got)
+	}
+
+	cfg.TimeStyle = models.TimeStyleRelative
+	m.applyConfig(cfg)
+	m.refreshHistoryTable()
+	if got := m.historyTable.Rows()[0][2]; !strings.HasSuffix(got, "d ago") {
+		t.Fatalf("expected a relative start time after reload, got %q", got)
+	}
+}
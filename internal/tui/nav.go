@@ -0,0 +1,159 @@
+package tui
+
+import (
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type navMotion int
+
+const (
+	navNone navMotion = iota
+	navDown
+	navUp
+	navTop
+	navBottom
+	navHalfDown
+	navHalfUp
+)
+
+// navMotionFor maps msg to a motion of the shared keymap. NavTop only
+// counts on its second press in a row, so a lone g does nothing.
+func (m *model) navMotionFor(msg tea.KeyMsg) navMotion {
+	pending := m.navTopPending
+	m.navTopPending = false
+	switch {
+	case key.Matches(msg, m.keys.NavTop):
+		if pending {
+			return navTop
+		}
+		m.navTopPending = true
+		return navNone
+	case key.Matches(msg, m.keys.NavDown):
+		return navDown
+	case key.Matches(msg, m.keys.NavUp):
+		return navUp
+	case key.Matches(msg, m.keys.NavBottom):
+		return navBottom
+	case key.Matches(msg, m.keys.HalfPageDown):
+		return navHalfDown
+	case key.Matches(msg, m.keys.HalfPageUp):
+		return navHalfUp
+	}
+	return navNone
+}
+
+// navigate applies the vim-style motions to whatever the current screen
+// scrolls: its list, table, or viewer. It reports whether msg was one of
+// them (or the first g of gg), and returns what the screen does after its
+// cursor moved.
+func (m *model) navigate(msg tea.KeyMsg) (tea.Cmd, bool) {
+	motion := m.navMotionFor(msg)
+	if motion == navNone {
+		return nil, m.navTopPending
+	}
+	if m.helpVisible {
+		moveViewport(&m.helpView, motion)
+		return nil, true
+	}
+	switch m.screen {
+	case screenServers:
+		moveList(&m.servers, motion)
+	case screenManageTargets:
+		moveList(&m.manage, motion)
+	case screenGlobalSearch:
+		moveList(&m.search, motion)
+	case screenJobs:
+		moveList(&m.jobs, motion)
+		return tea.Batch(m.scheduleJobDetailCmd(), m.scheduleFolderPreviewCmd()), true
+	case screenPreview:
+		moveTable(&m.previewTable, motion)
+	case screenRun, screenDone:
+		if m.batch == nil {
+			return nil, false
+		}
+		moveTable(&m.batch.table, motion)
+	case screenBatches:
+		moveTable(&m.batchTable, motion)
+	case screenWatch:
+		moveTable(&m.watchTable, motion)
+	case screenHistory:
+		moveTable(&m.historyTable, motion)
+	case screenStages:
+		moveTable(&m.stagesTable, motion)
+	case screenLogDiff:
+		moveViewport(&m.logDiff, motion)
+	case screenJobConfig:
+		moveViewport(&m.configView, motion)
+	case screenLocks:
+		moveViewport(&m.locksView, motion)
+	case screenConsole:
+		moveViewport(&m.consoleView, motion)
+	case screenConnTest:
+		moveViewport(&m.connView, motion)
+	default:
+		return nil, false
+	}
+	return nil, true
+}
+
+func moveList(l *list.Model, motion navMotion) {
+	n := len(l.VisibleItems())
+	if n == 0 {
+		return
+	}
+	i, half := l.Index(), max(1, l.Paginator.PerPage/2)
+	switch motion {
+	case navDown:
+		i++
+	case navUp:
+		i--
+	case navTop:
+		i = 0
+	case navBottom:
+		i = n - 1
+	case navHalfDown:
+		i += half
+	case navHalfUp:
+		i -= half
+	}
+	l.Select(min(max(i, 0), n-1))
+}
+
+func moveTable(t *table.Model, motion navMotion) {
+	half := max(1, t.Height()/2)
+	switch motion {
+	case navDown:
+		t.MoveDown(1)
+	case navUp:
+		t.MoveUp(1)
+	case navTop:
+		t.GotoTop()
+	case navBottom:
+		t.GotoBottom()
+	case navHalfDown:
+		t.MoveDown(half)
+	case navHalfUp:
+		t.MoveUp(half)
+	}
+}
+
+func moveViewport(v *viewport.Model, motion navMotion) {
+	switch motion {
+	case navDown:
+		v.LineDown(1)
+	case navUp:
+		v.LineUp(1)
+	case navTop:
+		v.GotoTop()
+	case navBottom:
+		v.GotoBottom()
+	case navHalfDown:
+		v.HalfViewDown()
+	case navHalfUp:
+		v.HalfViewUp()
+	}
+}
@@ -0,0 +1,127 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"jenkins-tui/internal/cache"
+	"jenkins-tui/internal/jenkins"
+	"jenkins-tui/internal/ui"
+)
+
+// errOfflineUncached is what an offline folder load reports when the folder
+// cache has never seen the folder.
+var errOfflineUncached = errors.New("not in the folder cache")
+
+// offlineNetworkKeys are the bindings on screen s that need the server. In
+// offline mode they are greyed out in the footer and refused with a note
+// instead of failing with a connection error.
+func offlineNetworkKeys(keys keyMap, s screen) []key.Binding {
+	switch s {
+	case screenServers:
+		return []key.Binding{keys.TestConn, keys.Admin, keys.Refresh}
+	case screenJobs:
+		return []key.Binding{
+			keys.Refresh, keys.GlobalSearch, keys.ToggleViews, keys.EnableJob, keys.History, keys.ScanMultibranch,
+			keys.Replay, keys.Locks, keys.ViewConfig, keys.TriggerFolder, keys.Watch, keys.Watches, keys.SyncTree, keys.RepoJobs,
+			keys.GotoJob,
+		}
+	case screenRun, screenDone:
+		return []key.Binding{keys.ViewLog, keys.Stages, keys.SaveLog, keys.SaveFailedLogs, keys.DiffRuns, keys.Rerun}
+	}
+	return nil
+}
+
+// offlineBlocked reports whether msg is a network action refused because
+// the TUI is offline, and says so in the status line.
+func (m *model) offlineBlocked(msg tea.KeyMsg) bool {
+	if !m.offline {
+		return false
+	}
+	for _, b := range offlineNetworkKeys(m.keys, m.screen) {
+		if key.Matches(msg, b) {
+			m.status = m.offlineNote(msg.String() + " needs the network")
+			return true
+		}
+	}
+	return false
+}
+
+// offlineRefusesTrigger reports whether opening the parameters of the job
+// named name is refused because the TUI is offline: they always come from
+// the server. It says so in the status line.
+func (m *model) offlineRefusesTrigger(name string) bool {
+	if !m.offline {
+		return false
+	}
+	m.status = m.offlineNote("triggering " + name + " needs the network")
+	return true
+}
+
+func (m *model) offlineNote(what string) string {
+	return fmt.Sprintf("Offline: %s; press %s to go back online", what, firstKey(m.keys.Offline))
+}
+
+// toggleOffline switches offline mode by hand. Going back online refetches
+// the current folder; if the server is still unreachable the load falls
+// back to the cache and offline mode comes straight back on.
+func (m *model) toggleOffline() tea.Cmd {
+	m.err = nil
+	if !m.offline {
+		m.offline = true
+		m.status = m.offlineNote("browsing cached job trees and run batches")
+		return nil
+	}
+	m.offline = false
+	m.detailErrs = map[string]error{}
+	m.previewErrs = map[string]error{}
+	m.status = "Online"
+	if m.screen != screenJobs || m.client == nil || m.target == nil {
+		return nil
+	}
+	return tea.Batch(detectServerCmd(m.ctx, m.client, m.target.ID), m.loadCurrentFolderCmd(true))
+}
+
+// footerHelp renders the help line, greying out the actions offline mode
+// refuses.
+func (m *model) footerHelp(help string) string {
+	if !m.offline {
+		return ui.Help.Render(help)
+	}
+	network := map[string]bool{}
+	for _, b := range offlineNetworkKeys(m.keys, m.screen) {
+		network[keyLabel(b)] = true
+		network[firstKey(b)] = true
+	}
+	segments := strings.Split(help, " | ")
+	for i, seg := range segments {
+		label, _, _ := strings.Cut(seg, " ")
+		if network[label] {
+			segments[i] = ui.Disabled.Render(seg)
+			continue
+		}
+		segments[i] = ui.Help.Render(seg)
+	}
+	return strings.Join(segments, ui.Help.Render(" | "))
+}
+
+// cachedJobsMsg answers a folder load from the cache, however old, because
+// the server is unreachable or the TUI is offline. err is reported when the
+// folder was never cached.
+func cachedJobsMsg(cacheDir string, client *jenkins.Client, containerURL, prefix string, requestID uint64, err error) jobsLoadedMsg {
+	msg := jobsLoadedMsg{offline: true, requestID: requestID, containerURL: containerURL, prefix: prefix}
+	nodes, fetchedAt, ok, cacheErr := cache.LastJobNodesInDir(cacheDir, client.CacheKey(), containerURL)
+	if cacheErr != nil || !ok {
+		msg.err = err
+		return msg
+	}
+	msg.nodes = nodes
+	msg.fromCache = true
+	msg.fetchedAt = fetchedAt
+	msg.folders = folderCacheInfos(cacheDir, client, nodes)
+	return msg
+}
@@ -0,0 +1,54 @@
+package tui
+
+import (
+	"context"
+	"log/slog"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"jenkins-tui/internal/jenkins"
+	"jenkins-tui/internal/models"
+	"jenkins-tui/internal/refresh"
+)
+
+type foldersPrefetchedMsg struct {
+	prefix string
+	result refresh.Result
+	err    error
+}
+
+// prefetchFoldersCmd lists the child folders of a folder just opened into
+// the jobs cache, prefetch_folders at a time, so stepping into one of them
+// shows its listing without a request. Off unless prefetch_folders is set.
+func (m *model) prefetchFoldersCmd(prefix string, nodes []models.JobNode) tea.Cmd {
+	if m.cfg.PrefetchFolders <= 0 || m.client == nil {
+		return nil
+	}
+	var folders []models.JobNode
+	for _, n := range nodes {
+		if n.Kind == models.JobNodeFolder {
+			folders = append(folders, n)
+		}
+	}
+	if len(folders) == 0 {
+		return nil
+	}
+	return prefetchCmd(m.ctx, m.cfg.CacheDir, m.client, prefix, folders, m.cfg.PrefetchFolders)
+}
+
+func prefetchCmd(ctx context.Context, cacheDir string, client *jenkins.Client, prefix string, folders []models.JobNode, concurrency int) tea.Cmd {
+	return func() tea.Msg {
+		result, err := refresh.Children(ctx, client, cacheDir, folders, concurrency)
+		return foldersPrefetchedMsg{prefix: prefix, result: result, err: err}
+	}
+}
+
+// notePrefetch only logs: a failed prefetch costs nothing but the request
+// the folder would have needed anyway.
+func notePrefetch(msg foldersPrefetchedMsg) {
+	attrs := []any{"folder", jobsPathLabel(msg.prefix), "folders", msg.result.Folders, "errors", msg.result.Errors, "duration", msg.result.Duration}
+	if msg.err != nil {
+		attrs = append(attrs, "error", msg.err.Error())
+	}
+	slog.Debug("prefetched child folders", attrs...)
+}
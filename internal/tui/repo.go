@@ -0,0 +1,150 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"jenkins-tui/internal/gitrepo"
+	"jenkins-tui/internal/jenkins"
+	"jenkins-tui/internal/models"
+	"jenkins-tui/internal/search"
+)
+
+type repoJobsMsg struct {
+	repo  gitrepo.Repo
+	nodes []models.JobNode
+	err   error
+}
+
+// findRepoJobs looks up the multibranch projects building the checkout
+// jenkins-tui was started in, from its origin remote.
+func (m *model) findRepoJobs() tea.Cmd {
+	repo, err := gitrepo.Detect(m.workDir)
+	if err != nil {
+		m.err = err
+		m.status = "Not in a git checkout with an origin remote"
+		return nil
+	}
+	m.err = nil
+	m.loading = true
+	m.loadingStart = time.Now()
+	m.loadingLabel = "Looking for the job building " + repo.String()
+	m.status = m.loadingLabel + "..."
+	return repoJobsCmd(m.ctx, m.client, m.cfg.CacheDir, repo)
+}
+
+func repoJobsCmd(ctx context.Context, client *jenkins.Client, cacheDir string, repo gitrepo.Repo) tea.Cmd {
+	return func() tea.Msg {
+		nodes, err := search.RepoJobs(ctx, client, cacheDir, repo)
+		return repoJobsMsg{repo: repo, nodes: nodes, err: err}
+	}
+}
+
+// repoJobsLoaded opens the project building the repo, or lists them in the
+// search screen when several do.
+func (m *model) repoJobsLoaded(msg repoJobsMsg, cmds []tea.Cmd) (tea.Model, tea.Cmd) {
+	m.loading = false
+	for _, n := range msg.nodes {
+		if !containsString(m.repoProjects, n.FullName) {
+			m.repoProjects = append(m.repoProjects, n.FullName)
+		}
+	}
+	if m.screen != screenJobs {
+		return m, tea.Batch(cmds...)
+	}
+	switch {
+	case msg.err != nil:
+		m.err = msg.err
+		m.status = "Failed to look up jobs for " + msg.repo.String()
+	case len(msg.nodes) == 0:
+		m.status = "No multibranch project builds " + msg.repo.String()
+	case len(msg.nodes) == 1:
+		_, cmd := m.openFolder(msg.nodes[0].FullName, cmds)
+		return m, cmd
+	default:
+		items := make([]list.Item, 0, len(msg.nodes))
+		for _, n := range msg.nodes {
+			items = append(items, listItem{title: n.Name, desc: n.FullName, id: n.URL, name: n.Name, fullName: n.FullName, kind: n.Kind, multibranch: true})
+		}
+		m.searchInput = ""
+		m.searchQuery = ""
+		m.search.SetItems(items)
+		m.search.Title = "Jobs building " + msg.repo.String()
+		m.status = fmt.Sprintf("%d multibranch projects build %s; enter opens one", len(msg.nodes), msg.repo)
+		return m, m.transition(screenGlobalSearch, cmds...)
+	}
+	return m, tea.Batch(cmds...)
+}
+
+// branchParamNames are the parameters, ignoring case, that the checked-out
+// branch fills in.
+var branchParamNames = []string{"BRANCH", "BRANCH_NAME", "GIT_BRANCH"}
+
+// branchPrefill is the value the checked-out branch gives p: the branch for
+// a string parameter, or for a choice parameter offering it. Values from a
+// rebuild or -params win. A branch only detected from the checkout fills
+// jobs of the projects find_repo_jobs matched to it, so opening some other
+// job does not quietly build the wrong branch; -branch fills every job.
+func (m *model) branchPrefill(p models.ParamDef) (string, bool) {
+	branch := m.cfg.Startup.Branch
+	if branch == "" || (m.cfg.Startup.BranchDetected && !m.buildsCheckout()) {
+		return "", false
+	}
+	if !slices.ContainsFunc(branchParamNames, func(name string) bool { return strings.EqualFold(name, p.Name) }) {
+		return "", false
+	}
+	if _, ok := m.paramPrefill[p.Name]; ok {
+		return "", false
+	}
+	switch p.Kind {
+	case models.ParamString:
+		return branch, true
+	case models.ParamChoice:
+		return branch, containsString(p.Choices, branch)
+	}
+	return "", false
+}
+
+// buildsCheckout reports whether the selected job is in a project that
+// find_repo_jobs matched to the checkout.
+func (m *model) buildsCheckout() bool {
+	if m.selectedJob == nil {
+		return false
+	}
+	return slices.ContainsFunc(m.repoProjects, func(project string) bool {
+		return m.selectedJob.FullName == project || strings.HasPrefix(m.selectedJob.FullName, project+"/")
+	})
+}
+
+// branchFilledParam names the parameter the branch was filled into, if any.
+func (m *model) branchFilledParam() string {
+	for _, p := range m.params {
+		if _, ok := m.branchPrefill(p); ok {
+			return p.Name
+		}
+	}
+	return ""
+}
+
+// branchItemIndex finds the job of branch among a multibranch project's
+// items. Jenkins escapes "/" and other characters in branch job names, so
+// feature/login is listed as feature%2Flogin.
+func branchItemIndex(items []list.Item, branch string) int {
+	for i, it := range items {
+		item, ok := it.(listItem)
+		if !ok || item.kind != models.JobNodeJob {
+			continue
+		}
+		if name, err := url.PathUnescape(item.name); item.name == branch || (err == nil && name == branch) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,117 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+
+	"jenkins-tui/internal/models"
+)
+
+// startRotateToken opens the rotation flow that fits where the target's
+// token comes from: a new keyring token, a new environment variable, or
+// running auth_command again.
+func (m *model) startRotateToken(idx int) tea.Cmd {
+	if idx < 0 || idx >= len(m.cfg.Jenkins) {
+		return nil
+	}
+	m.err = nil
+	t := m.cfg.Jenkins[idx]
+	if t.Auth == models.AuthKerberos {
+		m.status = t.Name + " signs in with Kerberos; run kinit to renew the ticket"
+		return nil
+	}
+	if strings.TrimSpace(t.AuthCommand) != "" {
+		id := t.ID
+		return m.askConfirm(
+			"Sign in to "+t.Name+" again?",
+			"Forgets the current token and runs its auth_command.",
+			func() tea.Cmd {
+				target := m.findTargetByID(id)
+				if target == nil {
+					return nil
+				}
+				m.creds.Forget(*target)
+				m.target = target
+				return m.authenticateCmd(*target, nil)
+			},
+			nil,
+		)
+	}
+	m.startManageForm(manageModeRotate, idx)
+	return m.transition(screenManageForm, m.manageForm.Init())
+}
+
+// rotateTokenGroup is the rotate form for t. Environment variables cannot be
+// changed from inside the app, so env targets either switch to another
+// variable that already holds the new token or get told how to replace it.
+func (m *model) rotateTokenGroup(t models.JenkinsTarget) *huh.Group {
+	if t.Credential.Type == models.CredentialTypeEnv {
+		return huh.NewGroup(
+			huh.NewNote().Title("Rotate API Token").Description(
+				"This server's token is read from $"+t.Credential.Ref+", which jenkins-tui cannot change for you. "+
+					"Create a new token (ctrl+o opens your API token page), then either export it under a new variable name "+
+					"and enter that name below, or export it as $"+t.Credential.Ref+" and restart jenkins-tui."),
+			huh.NewInput().Title("Token Environment Variable").Description("Keep the name to rotate by restarting; a new name must already be set in this session.").Value(&m.manageEnvVar),
+		).Title("Rotate API Token")
+	}
+	m.tokenInput = huh.NewInput().Title("API Token").Description("Stores a new token in the system password manager.").Password(true).Value(&m.manageToken)
+	return huh.NewGroup(
+		huh.NewNote().Title("Rotate API Token").Description("Enter a new token for this server's system password manager entry. Press ctrl+o to open your API token page, or ctrl+g to have Jenkins generate one from your password."),
+		m.tokenInput,
+	).Title("Rotate API Token")
+}
+
+// applyRotate stores the new token for the target being rotated, or for env
+// targets points the config at the new variable once Jenkins accepts it.
+func (m *model) applyRotate() error {
+	if m.manageIndex < 0 || m.manageIndex >= len(m.cfg.Jenkins) {
+		return fmt.Errorf("invalid server selection")
+	}
+	target := m.cfg.Jenkins[m.manageIndex]
+	switch target.Credential.Type {
+	case models.CredentialTypeKeyring:
+		token := strings.TrimSpace(m.manageToken)
+		if token == "" {
+			return fmt.Errorf("API token is required.")
+		}
+		if err := m.creds.SetKeyring(target.Credential.Ref, token); err != nil {
+			return fmt.Errorf("store keyring token: %w", err)
+		}
+	case models.CredentialTypeEnv:
+		ref := strings.TrimSpace(m.manageEnvVar)
+		if ref == "" {
+			return fmt.Errorf("Token environment variable is required.")
+		}
+		if ref == target.Credential.Ref {
+			m.status = "Export the new token as $" + ref + " and restart jenkins-tui to use it"
+			return nil
+		}
+		rotated := target
+		rotated.Credential.Ref = ref
+		token, _, err := m.resolveTokenForValidation(rotated, &target)
+		if err != nil {
+			return fmt.Errorf("%w Export it and restart jenkins-tui, or enter a variable that is set.", err)
+		}
+		validator := m.validateTarget
+		if validator == nil {
+			validator = defaultTargetValidator
+		}
+		if err := validator(m.ctx, rotated, token, m.cfg.Timeout); err != nil {
+			return mapTargetValidationError(err)
+		}
+		m.cfg.Jenkins[m.manageIndex] = rotated
+		if err := m.persistConfig(); err != nil {
+			m.cfg.Jenkins[m.manageIndex] = target
+			return err
+		}
+	default:
+		return fmt.Errorf("token rotation is not available for %q credentials", target.Credential.Type)
+	}
+	delete(m.rejectedTokens, target.ID)
+	delete(m.health, target.ID)
+	m.status = "Token rotated"
+	return nil
+}
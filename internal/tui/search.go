@@ -0,0 +1,87 @@
+package tui
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"jenkins-tui/internal/cache"
+	"jenkins-tui/internal/jenkins"
+	"jenkins-tui/internal/models"
+	"jenkins-tui/internal/search"
+)
+
+// usageHalfLife is how long it takes a trigger to count half as much when
+// ranking search results, so last month's habit fades behind this week's.
+const usageHalfLife = 7 * 24 * time.Hour
+
+// loadSearchCmd runs a global search and ranks the hits by how much they
+// were used. A query without words, e.g. only a regex, is matched against
+// the job index built by sync, since Jenkins search needs a string to look
+// for.
+func loadSearchCmd(ctx context.Context, cacheDir string, client *jenkins.Client, query search.Query, bookmarks []string, requestID uint64) tea.Cmd {
+	return func() tea.Msg {
+		var index []models.JobNode
+		if text, _ := query.Remote(); text == "" {
+			jobs, _, ok, err := cache.JobIndexInDir(cacheDir, client.CacheKey())
+			if err != nil {
+				return searchLoadedMsg{err: err, requestID: requestID}
+			}
+			if !ok {
+				return searchLoadedMsg{err: search.ErrNeedsIndex, requestID: requestID}
+			}
+			index = jobs
+			if index == nil {
+				index = []models.JobNode{}
+			}
+		}
+		nodes, found, err := search.Jobs(ctx, client, index, query, 100)
+		if err != nil {
+			return searchLoadedMsg{err: err, requestID: requestID}
+		}
+		usage, _ := cache.UsageInDir(cacheDir, client.CacheKey())
+		rankByUsage(nodes, usage, bookmarks, time.Now())
+		return searchLoadedMsg{nodes: nodes, found: found, requestID: requestID}
+	}
+}
+
+// rankByUsage moves the jobs triggered most, and most recently, from here to
+// the top, and jobs inside bookmarked folders after them; the rest keep
+// Jenkins' order.
+func rankByUsage(nodes []models.JobNode, usage cache.Usage, bookmarks []string, now time.Time) {
+	scores := make(map[string]float64, len(nodes))
+	for _, n := range nodes {
+		score := 0.0
+		if use, ok := usage[jenkins.CanonicalJobURL(n.URL)]; ok && use.Count > 0 {
+			score = float64(use.Count) * math.Pow(0.5, now.Sub(use.Last).Hours()/usageHalfLife.Hours())
+		}
+		if inBookmarkedFolder(n, bookmarks) {
+			score += 0.5
+		}
+		scores[n.URL] = score
+	}
+	sort.SliceStable(nodes, func(i, j int) bool { return scores[nodes[i].URL] > scores[nodes[j].URL] })
+}
+
+func inBookmarkedFolder(n models.JobNode, bookmarks []string) bool {
+	for _, b := range bookmarks {
+		for _, full := range append([]string{n.FullName}, n.Paths...) {
+			if strings.HasPrefix(full, strings.Trim(b, "/")+"/") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// recordUseCmd counts a trigger of jobURL for ranking later searches.
+func recordUseCmd(cacheDir string, client *jenkins.Client, jobURL string) tea.Cmd {
+	return func() tea.Msg {
+		_ = cache.RecordUseInDir(cacheDir, client.CacheKey(), jenkins.CanonicalJobURL(jobURL))
+		return nil
+	}
+}
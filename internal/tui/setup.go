@@ -0,0 +1,284 @@
+package tui
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+
+	"jenkins-tui/internal/browser"
+	"jenkins-tui/internal/jenkins"
+	"jenkins-tui/internal/models"
+	"jenkins-tui/internal/ui"
+)
+
+// startSetupWizard opens the add-server form with the first-run steps: a
+// welcome note on where the token will be kept and, before the token
+// field, how to create one.
+func (m *model) startSetupWizard() {
+	m.setupWizard = true
+	m.status = "No Jenkins servers configured. Follow the steps to add your first one."
+	m.startManageForm(manageModeAdd, -1)
+}
+
+func (m *model) setupWelcomeGroup() *huh.Group {
+	storage := "Your API token will be stored in the system password manager; jenkins.yaml only keeps a reference to it."
+	if !m.keyringAvail {
+		storage = "No system password manager was found, so jenkins-tui will read your API token from an environment variable that you export before starting it."
+	}
+	return huh.NewGroup(
+		huh.NewNote().
+			Title("Welcome to jenkins-tui").
+			Description("Let's connect your first Jenkins server. You need its URL, your Jenkins username, and an API token.\n\n" + storage),
+	).Title("Step 1 of 3: Welcome")
+}
+
+// setupTokenNote explains token creation with a link to the user's own
+// token page, built from the URL and username typed in the previous step.
+func (m *model) setupTokenNote() *huh.Note {
+	return huh.NewNote().
+		Title("Create an API token").
+		DescriptionFunc(func() string {
+			steps := "Press ctrl+o to open " + apiTokenPageURL(m.manageHost, m.manageUsername) + " (the Security page on newer Jenkins), press \"Add new Token\" under API Token, name it jenkins-tui, generate it, and copy it: Jenkins shows it only once. A pasted token is checked right away."
+			if m.manageTokenSrc == tokenStorageKeyring {
+				steps += "\n\nOr press ctrl+g and enter your Jenkins password once: jenkins-tui asks Jenkins for a new token and stores only that."
+			}
+			if m.manageTokenSrc == tokenStorageEnv {
+				steps += "\n\nThen export it, e.g. export JENKINS_TOKEN=<token>, restart jenkins-tui from that shell, and enter the variable name below."
+			}
+			return steps
+		}, []*string{&m.manageHost, &m.manageUsername, &m.manageTokenSrc})
+}
+
+// apiTokenPageURL is the Jenkins page where username manages API tokens.
+func apiTokenPageURL(host, username string) string {
+	host = strings.TrimRight(strings.TrimSpace(host), "/")
+	if host == "" {
+		host = "<jenkins-url>"
+	} else if !strings.Contains(host, "://") {
+		host = "https://" + host
+	}
+	username = strings.TrimSpace(username)
+	if username == "" {
+		return host + "/me/configure"
+	}
+	return host + "/user/" + url.PathEscape(username) + "/configure"
+}
+
+// finishSetupWizard ends the first-run flow after the server was validated
+// and saved by offering to browse its jobs right away.
+func (m *model) finishSetupWizard() tea.Cmd {
+	m.setupWizard = false
+	if len(m.cfg.Jenkins) == 0 {
+		return nil
+	}
+	id := m.cfg.Jenkins[len(m.cfg.Jenkins)-1].ID
+	name := m.cfg.Jenkins[len(m.cfg.Jenkins)-1].Name
+	return m.askConfirm(
+		"Connected to "+name+". Browse its jobs now?",
+		"Add more servers later with a on the servers screen.",
+		func() tea.Cmd {
+			t := m.findTargetByID(id)
+			if t == nil {
+				return nil
+			}
+			return m.connectTarget(t, m.openSelectedTarget)
+		},
+		nil,
+	)
+}
+
+// tokenCheckInterval is how long a pasted token must sit unchanged in the
+// server form before it is checked against Jenkins.
+const tokenCheckInterval = time.Second
+
+type tokenCheckTickMsg struct{}
+
+type tokenCheckedMsg struct {
+	key string
+	err error
+}
+
+// tokenCheck is the live validation of the token typed in the server form,
+// keyed by host, username, and token so editing any of them re-checks.
+type tokenCheck struct {
+	key     string
+	pending bool
+	err     error
+}
+
+func tokenCheckKey(t models.JenkinsTarget, token string) string {
+	return t.Host + "\x00" + t.Username + "\x00" + token
+}
+
+// formTokenTarget is the server a token typed in the form belongs to: the
+// rotated target, or the host and username entered so far. ok is false
+// while there is no keyring token field to check.
+func (m *model) formTokenTarget() (models.JenkinsTarget, bool) {
+	if m.manageMode == manageModeRotate {
+		if m.manageIndex < 0 || m.manageIndex >= len(m.cfg.Jenkins) {
+			return models.JenkinsTarget{}, false
+		}
+		return m.cfg.Jenkins[m.manageIndex], true
+	}
+	host := strings.TrimRight(strings.TrimSpace(m.manageHost), "/")
+	username := strings.TrimSpace(m.manageUsername)
+	if host == "" || username == "" || m.manageTokenSrc != tokenStorageKeyring {
+		return models.JenkinsTarget{}, false
+	}
+	t := models.JenkinsTarget{Host: host, Username: username, InsecureSkipTLSVerify: m.manageInsecure == "true", AllowInsecureHTTP: m.manageHTTP == "true"}
+	// Nothing is sent over plain HTTP until the user allows it.
+	if t.PlainHTTP() && !t.AllowInsecureHTTP {
+		return models.JenkinsTarget{}, false
+	}
+	return t, true
+}
+
+// plainHTTPLine warns under the server form while the URL is plain http://.
+func (m *model) plainHTTPLine() string {
+	if m.manageMode == manageModeRotate || m.manageMode == manageModeMoveToEnv {
+		return ""
+	}
+	t := models.JenkinsTarget{Host: strings.TrimSpace(m.manageHost)}
+	if !t.PlainHTTP() {
+		return ""
+	}
+	badge := ui.Warn.Render(ui.Glyph("▲", "!") + " plain http://")
+	if m.manageHTTP == "true" {
+		return badge + " allowed: your API token is sent unencrypted"
+	}
+	return badge + " sends your API token unencrypted; use https://, or set Allow plain HTTP under Advanced settings"
+}
+
+// openTokenPage opens the API token page of the server in the form.
+func (m *model) openTokenPage() {
+	host, username := m.manageHost, m.manageUsername
+	if m.manageMode == manageModeRotate {
+		if t, ok := m.formTokenTarget(); ok {
+			host, username = t.Host, t.Username
+		}
+	}
+	if strings.TrimSpace(host) == "" {
+		m.status = "Enter the Jenkins URL first, then press ctrl+o to open its API token page"
+		return
+	}
+	page := apiTokenPageURL(host, username)
+	if err := browser.Open(page); err != nil {
+		m.status = "Could not open a browser; visit " + page
+		return
+	}
+	m.status = "Opened " + page + "; paste the new token and it is checked as soon as you stop typing"
+}
+
+// scheduleTokenCheck arms the token poll once per stay on the server form.
+func (m *model) scheduleTokenCheck() tea.Cmd {
+	if m.tokenTickArmed {
+		return nil
+	}
+	m.tokenTickArmed = true
+	return tea.Tick(tokenCheckInterval, func(time.Time) tea.Msg { return tokenCheckTickMsg{} })
+}
+
+// pollToken validates the typed token once it has stopped changing for a
+// tick, so a pasted token is confirmed before the form is submitted.
+func (m *model) pollToken() tea.Cmd {
+	m.tokenTickArmed = false
+	if m.screen != screenManageForm || m.manageForm == nil {
+		m.tokenSeen = ""
+		return nil
+	}
+	next := m.scheduleTokenCheck()
+	token := strings.TrimSpace(m.manageToken)
+	if token != m.tokenSeen {
+		m.tokenSeen = token
+		return next
+	}
+	target, ok := m.formTokenTarget()
+	if token == "" || !ok || tokenCheckKey(target, token) == m.tokenCheck.key {
+		return next
+	}
+	key := tokenCheckKey(target, token)
+	m.tokenCheck = tokenCheck{key: key, pending: true}
+	validator := m.validateTarget
+	if validator == nil {
+		validator = defaultTargetValidator
+	}
+	ctx, timeout := m.ctx, m.cfg.Timeout
+	return tea.Batch(next, func() tea.Msg {
+		return tokenCheckedMsg{key: key, err: validator(ctx, target, token, timeout)}
+	})
+}
+
+// tokenCheckLine reports the check of the token currently in the form.
+func (m *model) tokenCheckLine() string {
+	c := m.tokenCheck
+	target, ok := m.formTokenTarget()
+	if c.key == "" || !ok || c.key != tokenCheckKey(target, strings.TrimSpace(m.manageToken)) {
+		return ""
+	}
+	switch {
+	case c.pending:
+		return ui.Muted.Render("Checking token...")
+	case c.err != nil:
+		return ui.Danger.Render(ui.Glyph("✖", "x")+" ") + mapTargetValidationError(c.err).Error()
+	default:
+		return ui.Success.Render(ui.Glyph("✔", "+")+" ") + "Token accepted by Jenkins"
+	}
+}
+
+// generatedTokenName is what a token generated from the user's password is
+// called on their Jenkins security page, so they can tell it apart.
+const generatedTokenName = "jenkins-tui"
+
+type tokenGeneratedMsg struct {
+	key   string
+	token string
+	err   error
+}
+
+// askGenerateToken asks for the user's password and has Jenkins create an
+// API token with it. The password is used for that one request only.
+func (m *model) askGenerateToken() tea.Cmd {
+	target, ok := m.formTokenTarget()
+	if !ok || m.tokenInput == nil {
+		m.status = "Enter the Jenkins URL and username, and keep the token in the password manager, to generate a token"
+		return nil
+	}
+	return m.askSecret("Jenkins password for "+target.Username,
+		"Used once to create an API token named "+generatedTokenName+" on "+target.Host+"; the password is not stored.",
+		func(password string) tea.Cmd {
+			if password == "" {
+				m.status = "No password entered; no token generated"
+				return nil
+			}
+			key := tokenCheckKey(target, "")
+			ctx, timeout := m.ctx, m.cfg.Timeout
+			m.status = "Generating an API token..."
+			return func() tea.Msg {
+				token, err := jenkins.NewClient(target, password, timeout).GenerateAPIToken(ctx, generatedTokenName)
+				return tokenGeneratedMsg{key: key, token: token, err: err}
+			}
+		})
+}
+
+// tokenGenerated fills the form's token field with a generated token, as if
+// it had been pasted, unless the form moved on to another server meanwhile.
+func (m *model) tokenGenerated(msg tokenGeneratedMsg) {
+	target, ok := m.formTokenTarget()
+	if m.screen != screenManageForm || !ok || tokenCheckKey(target, "") != msg.key {
+		return
+	}
+	if msg.err != nil {
+		if tokenRejected(msg.err) {
+			m.status = "Jenkins rejected the password; servers behind single sign-on need a token created in the browser (ctrl+o)"
+			return
+		}
+		m.status = "Could not generate a token: " + msg.err.Error()
+		return
+	}
+	m.manageToken = msg.token
+	m.tokenInput.Value(&m.manageToken)
+	m.status = "Generated API token " + generatedTokenName + "; your password was not stored"
+}
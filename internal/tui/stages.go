@@ -0,0 +1,143 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"jenkins-tui/internal/jenkins"
+	"jenkins-tui/internal/models"
+	"jenkins-tui/internal/ui"
+)
+
+type stagesLoadedMsg struct {
+	build  buildLog
+	stages []models.Stage
+	err    error
+}
+
+// openStages lists the stages of a Pipeline build; enter on one shows just
+// that stage's log in the console viewer.
+func (m *model) openStages(client *jenkins.Client, build buildLog, cmds []tea.Cmd) tea.Cmd {
+	if client == nil || build.url == "" {
+		m.status = "That run has no build yet"
+		return tea.Batch(cmds...)
+	}
+	m.stagesClient = client
+	m.loading = true
+	m.loadingStart = time.Now()
+	m.loadingLabel = "Loading stages"
+	m.status = "Loading stages of " + build.label() + "..."
+	return tea.Batch(append(cmds, loadStagesCmd(m.ctx, client, build))...)
+}
+
+func loadStagesCmd(ctx context.Context, client *jenkins.Client, build buildLog) tea.Cmd {
+	return func() tea.Msg {
+		stages, err := client.Stages(ctx, build.url)
+		return stagesLoadedMsg{build: build, stages: stages, err: err}
+	}
+}
+
+func loadStageLogCmd(ctx context.Context, client *jenkins.Client, build buildLog, stageID string) tea.Cmd {
+	return func() tea.Msg {
+		text, err := client.StageLog(ctx, build.url, stageID)
+		return consoleLoadedMsg{build: build, text: text, err: err}
+	}
+}
+
+func (m *model) stagesLoaded(msg stagesLoadedMsg, cmds []tea.Cmd) (tea.Model, tea.Cmd) {
+	m.loading = false
+	if msg.err != nil {
+		m.err = msg.err
+		m.status = "Failed to load stages"
+		if errors.Is(msg.err, jenkins.ErrNoStages) {
+			m.err = nil
+			m.status = "No stages: not a Pipeline build, or the Pipeline REST API plugin is missing"
+		}
+		return m, tea.Batch(cmds...)
+	}
+	m.err = nil
+	m.stagesBuild = msg.build
+	m.stages = msg.stages
+	m.refreshStagesTable()
+	m.stagesTable.SetCursor(firstFailedStage(m.stages))
+	m.status = ""
+	if len(m.stages) == 0 {
+		m.status = "This build has no stages yet"
+	}
+	if m.screen != screenStages {
+		m.stagesBackTo = m.screen
+	}
+	return m, m.transition(screenStages, cmds...)
+}
+
+// firstFailedStage is where the cursor starts: the stage that broke the
+// build, if any.
+func firstFailedStage(stages []models.Stage) int {
+	for i, s := range stages {
+		if s.Status == "FAILED" || s.Status == "UNSTABLE" {
+			return i
+		}
+	}
+	return 0
+}
+
+func (m *model) refreshStagesTable() {
+	cursor := m.stagesTable.Cursor()
+	cols := []table.Column{
+		{Title: "Stage", Width: max(20, m.contentWidth()-45)},
+		{Title: "Status", Width: 12},
+		{Title: "Started", Width: 11},
+		{Title: "Duration", Width: 10},
+	}
+	rows := make([]table.Row, 0, len(m.stages))
+	for _, s := range m.stages {
+		started := ""
+		if !s.Started.IsZero() {
+			started = ui.TimeOfDay(s.Started)
+		}
+		rows = append(rows, table.Row{clip(s.Name, max(20, m.contentWidth()-45)), s.Status, started, ui.Duration(s.Duration)})
+	}
+	t := table.New(
+		table.WithColumns(cols),
+		table.WithRows(rows),
+		table.WithFocused(true),
+		table.WithHeight(max(5, m.contentHeight()-14)),
+	)
+	t.SetStyles(defaultTableStyles(true))
+	m.stagesTable = t
+	if cursor >= 0 && cursor < len(rows) {
+		m.stagesTable.SetCursor(cursor)
+	}
+}
+
+func (m *model) updateStages(msg tea.Msg, cmds []tea.Cmd) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	m.stagesTable, cmd = m.stagesTable.Update(msg)
+	cmds = append(cmds, cmd)
+	km, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, tea.Batch(cmds...)
+	}
+	switch km.String() {
+	case "esc", "backspace":
+		m.status = ""
+		return m, m.transition(m.stagesBackTo, cmds...)
+	case "enter":
+		idx := m.stagesTable.Cursor()
+		if idx < 0 || idx >= len(m.stages) {
+			return m, tea.Batch(cmds...)
+		}
+		build := m.stagesBuild
+		build.stage = m.stages[idx].Name
+		m.loading = true
+		m.loadingStart = time.Now()
+		m.loadingLabel = "Fetching stage log"
+		m.status = "Fetching log of " + build.label() + "..."
+		return m, tea.Batch(append(cmds, loadStageLogCmd(m.ctx, m.stagesClient, build, m.stages[idx].ID))...)
+	}
+	return m, tea.Batch(cmds...)
+}
@@ -0,0 +1,143 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+
+	"jenkins-tui/internal/models"
+	"jenkins-tui/internal/ui"
+)
+
+// statusBar is the line above every screen: the connected server and user,
+// whether it is reachable, how fresh the job listing is, and the progress
+// of run batches, so that context survives leaving the screen it came from.
+func (m *model) statusBar() string {
+	sep := ui.Muted.Render(" " + ui.Glyph("│", "|") + " ")
+	segments := []string{}
+	if m.target == nil {
+		segments = append(segments, ui.Muted.Render("No server selected"))
+	} else {
+		server := m.target.Name
+		if v := m.serverVersions[m.target.ID]; v != "" {
+			server += " (Jenkins " + v + ")"
+		}
+		segments = append(segments, ui.Title.Render(server))
+		if user := strings.TrimSpace(m.target.Username); user != "" {
+			segments = append(segments, ui.Muted.Render("user "+user))
+		}
+		segments = append(segments, m.connectivityLabel())
+		if fresh := m.cacheFreshnessLabel(); fresh != "" {
+			segments = append(segments, fresh)
+		}
+	}
+	if widget := m.runWidget(); widget != "" {
+		segments = append(segments, ui.Muted.Render(widget))
+	}
+	return strings.Join(segments, sep)
+}
+
+// connectivityLabel says whether the connected server answers, going by
+// offline mode and the last health probe.
+func (m *model) connectivityLabel() string {
+	switch {
+	case m.offline:
+		return ui.Warn.Render(ui.Glyph("◌", "o") + " offline")
+	case m.client == nil:
+		return ui.Muted.Render("not connected")
+	}
+	h, ok := m.health[m.target.ID]
+	switch {
+	case ok && h.err != nil && !h.noCreds:
+		return ui.Danger.Render(ui.Glyph("●", "*") + " unreachable")
+	case ok && h.info.Latency > 0:
+		return ui.Success.Render(ui.Glyph("●", "*")+" online") + ui.Muted.Render(fmt.Sprintf(" %dms", h.info.Latency.Milliseconds()))
+	}
+	return ui.Success.Render(ui.Glyph("●", "*") + " online")
+}
+
+// cacheFreshnessLabel is the age of the job listing last loaded, and
+// whether it came from the server or the folder cache.
+func (m *model) cacheFreshnessLabel() string {
+	if m.jobsFetchedAt.IsZero() {
+		return ""
+	}
+	age := ui.Age(m.jobsFetchedAt)
+	if m.jobsFromCache {
+		return ui.Muted.Render("jobs cached " + age)
+	}
+	return ui.Muted.Render("jobs fetched " + age)
+}
+
+// screenCounts sums up what the current screen lists, e.g. "12 folders,
+// 34 jobs (filtered: 5)" or "search: 87 results", for the right end of the
+// status line.
+func (m *model) screenCounts() string {
+	switch m.screen {
+	case screenServers:
+		return listCounts(m.servers, []string{"server"}, map[string]int{"server": len(m.servers.Items())})
+	case screenManageTargets:
+		return listCounts(m.manage, []string{"server"}, map[string]int{"server": len(m.manage.Items())})
+	case screenJobs:
+		if m.gotoActive || m.crumbActive || m.bookmarksOpen {
+			return ""
+		}
+		return listCounts(m.jobs, []string{"folder", "view", "job"}, m.jobCounts.count(m.jobs.Items(), func(item list.Item) string {
+			li, _ := item.(listItem)
+			switch li.kind {
+			case models.JobNodeFolder:
+				return "folder"
+			case models.JobNodeView:
+				return "view"
+			}
+			return "job"
+		}))
+	case screenGlobalSearch:
+		if m.searchQuery == "" {
+			return ""
+		}
+		return fmt.Sprintf("search: %s", plural(len(m.search.Items()), "result"))
+	case screenHistory:
+		return plural(len(m.historyTable.Rows()), "build")
+	case screenStages:
+		return plural(len(m.stagesTable.Rows()), "stage")
+	case screenBatches:
+		return plural(len(m.batches), "batch")
+	case screenRun, screenDone:
+		if m.batch == nil {
+			return ""
+		}
+		return plural(len(m.batch.records), "run")
+	}
+	return ""
+}
+
+// listCounts lists how many of l's items there are of each kind, in the
+// order given, and how many the active filter leaves.
+func listCounts(l list.Model, kinds []string, counts map[string]int) string {
+	if len(l.Items()) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(kinds))
+	for _, k := range kinds {
+		if counts[k] > 0 {
+			parts = append(parts, plural(counts[k], k))
+		}
+	}
+	out := strings.Join(parts, ", ")
+	if l.FilterState() != list.Unfiltered {
+		out += fmt.Sprintf(" (filtered: %d)", len(l.VisibleItems()))
+	}
+	return out
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	if strings.HasSuffix(noun, "ch") {
+		return fmt.Sprintf("%d %ses", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,91 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"jenkins-tui/internal/jenkins"
+	"jenkins-tui/internal/refresh"
+)
+
+// syncConcurrency matches the folders -daemon and `jenkins-tui sync` list
+// at once.
+const syncConcurrency = 4
+
+type syncProgressMsg struct {
+	progress refresh.Progress
+	ch       <-chan refresh.Progress
+}
+
+type syncDoneMsg struct {
+	target string
+	result refresh.Result
+	err    error
+}
+
+// startSync crawls the connected server's whole job tree into the folder
+// cache and job index in the background, like `jenkins-tui sync`, so later
+// folders, go to and search need no requests.
+func (m *model) startSync() tea.Cmd {
+	if m.client == nil || m.target == nil {
+		return nil
+	}
+	if m.syncing {
+		m.status = "A sync is already running"
+		return nil
+	}
+	m.syncing = true
+	m.status = "Syncing " + m.target.Name + "..."
+	ch := make(chan refresh.Progress, 1)
+	return tea.Batch(syncCmd(m.ctx, m.client, m.cfg.CacheDir, m.target.Name, ch), waitSyncCmd(ch))
+}
+
+func syncCmd(ctx context.Context, client *jenkins.Client, cacheDir, target string, ch chan refresh.Progress) tea.Cmd {
+	return func() tea.Msg {
+		defer close(ch)
+		result, err := refresh.TreeWithProgress(ctx, client, cacheDir, syncConcurrency, func(p refresh.Progress) {
+			// Drop updates the UI has not caught up with; the next one
+			// carries the newer counts anyway.
+			select {
+			case ch <- p:
+			default:
+			}
+		})
+		return syncDoneMsg{target: target, result: result, err: err}
+	}
+}
+
+func waitSyncCmd(ch <-chan refresh.Progress) tea.Cmd {
+	return func() tea.Msg {
+		p, ok := <-ch
+		if !ok {
+			return nil
+		}
+		return syncProgressMsg{progress: p, ch: ch}
+	}
+}
+
+// noteSyncProgress updates the status line, unless something else has
+// replaced the sync message there since.
+func (m *model) noteSyncProgress(msg syncProgressMsg) tea.Cmd {
+	if m.syncing && strings.HasPrefix(m.status, "Syncing ") && m.target != nil {
+		p := msg.progress
+		m.status = fmt.Sprintf("Syncing %s: %d folders, %d jobs, %d queued...", m.target.Name, p.Folders, p.Jobs, p.Queued)
+	}
+	return waitSyncCmd(msg.ch)
+}
+
+func (m *model) finishSync(msg syncDoneMsg) {
+	m.syncing = false
+	r := msg.result
+	if msg.err != nil {
+		m.err = msg.err
+		m.status = fmt.Sprintf("Synced %s with %d error(s): %d folders, %d jobs", msg.target, r.Errors, r.Folders, r.Jobs)
+		return
+	}
+	m.status = fmt.Sprintf("Synced %s: %d folders, %d jobs in %s", msg.target, r.Folders, r.Jobs, r.Duration.Round(time.Second))
+}
@@ -0,0 +1,93 @@
+package tui
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"jenkins-tui/internal/jenkins"
+	"jenkins-tui/internal/models"
+	"jenkins-tui/internal/ui"
+)
+
+// tokenHealthInterval is how often stored tokens are re-checked, so a
+// revoked or expired token is flagged before it breaks a folder load.
+const tokenHealthInterval = 15 * time.Minute
+
+type tokenHealthTickMsg struct{}
+
+type tokenHealthMsg struct {
+	target string
+	err    error
+}
+
+func tokenHealthCmd() tea.Cmd {
+	return tea.Tick(tokenHealthInterval, func(time.Time) tea.Msg { return tokenHealthTickMsg{} })
+}
+
+// checkTokens asks every server with a stored token who it signs in as.
+// Targets without one are left to the health line, which already says so.
+func (m *model) checkTokens() tea.Cmd {
+	var cmds []tea.Cmd
+	for _, t := range m.cfg.Jenkins {
+		token, err := m.creds.Resolve(t)
+		if err != nil {
+			continue
+		}
+		timeout := healthTimeout
+		if m.cfg.Timeout > 0 {
+			timeout = min(m.cfg.Timeout, healthTimeout)
+		}
+		client := jenkins.NewClient(t, token, timeout)
+		cmds = append(cmds, whoAmICmd(m.ctx, client, t.ID))
+	}
+	return tea.Batch(cmds...)
+}
+
+func whoAmICmd(ctx context.Context, client *jenkins.Client, targetID string) tea.Cmd {
+	return func() tea.Msg {
+		_, err := client.WhoAmI(ctx)
+		return tokenHealthMsg{target: targetID, err: err}
+	}
+}
+
+// noteTokenResult records whether Jenkins rejected a target's token and,
+// when that is news and the servers screen is not showing, says so in the
+// status line. Only 401 sets the flag and only success clears it; other
+// failures are reachability problems that say nothing about the token.
+func (m *model) noteTokenResult(targetID string, err error) {
+	rejected := tokenRejected(err)
+	if (err != nil && !rejected) || rejected == m.rejectedTokens[targetID] {
+		return
+	}
+	if !rejected {
+		delete(m.rejectedTokens, targetID)
+		m.refreshServerItems()
+		return
+	}
+	m.rejectedTokens[targetID] = true
+	m.refreshServerItems()
+	if t := m.findTargetByID(targetID); t != nil && m.screen != screenServers {
+		m.status = "Jenkins rejected the token for " + t.Name + "; rotate it from the servers screen"
+	}
+}
+
+func tokenRejected(err error) bool {
+	return jenkins.IsStatus(err, http.StatusUnauthorized)
+}
+
+// rejectedTokenLine replaces the health line of a server whose token was
+// rejected, naming the key that fixes it.
+func (m *model) rejectedTokenLine(t models.JenkinsTarget) string {
+	if t.Auth == models.AuthKerberos {
+		return ui.Warn.Render(ui.Glyph("▲", "!")+" Kerberos ticket rejected (401)") + " | run kinit and retry"
+	}
+	badge := ui.Warn.Render(ui.Glyph("▲", "!") + " token rejected (401)")
+	if strings.TrimSpace(t.AuthCommand) != "" {
+		return badge + " | press " + firstKey(m.keys.RotateToken) + " to sign in again"
+	}
+	return badge + " | press " + firstKey(m.keys.RotateToken) + " to rotate it"
+}
@@ -0,0 +1,337 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"jenkins-tui/internal/board"
+	"jenkins-tui/internal/jenkins"
+	"jenkins-tui/internal/models"
+	"jenkins-tui/internal/ui"
+)
+
+// watchInterval is how often the last build of each watched job is polled.
+const watchInterval = 30 * time.Second
+
+type watchTickMsg struct{}
+
+type watchPolledMsg struct {
+	target string
+	job    string
+	detail models.JobDetail
+	err    error
+}
+
+// watchStatus is the last polled state of one watched job.
+type watchStatus struct {
+	number   int
+	result   string
+	building bool
+	started  time.Time
+	err      error
+	checked  time.Time
+	// changed is when the last build's number or result last moved.
+	changed time.Time
+}
+
+func (s watchStatus) label() string {
+	switch {
+	case s.checked.IsZero():
+		return "checking"
+	case s.err != nil:
+		return "error"
+	case s.number == 0:
+		return "never built"
+	case s.building:
+		return "BUILDING"
+	case s.result == "":
+		return "unknown"
+	}
+	return s.result
+}
+
+func watchCmd() tea.Cmd {
+	return tea.Tick(watchInterval, func(time.Time) tea.Msg { return watchTickMsg{} })
+}
+
+func watchKey(target, job string) string {
+	return target + "\x00" + job
+}
+
+// pollWatches checks every job watched on the connected server. Watches of
+// other servers wait until that server is opened again.
+func (m *model) pollWatches() tea.Cmd {
+	if m.client == nil || m.target == nil {
+		return nil
+	}
+	cmds := make([]tea.Cmd, 0, len(m.target.Watches))
+	for _, full := range m.target.Watches {
+		cmds = append(cmds, pollWatchCmd(m.ctx, m.client, m.target.ID, full))
+	}
+	return tea.Batch(cmds...)
+}
+
+func pollWatchCmd(ctx context.Context, client *jenkins.Client, target, fullName string) tea.Cmd {
+	return func() tea.Msg {
+		detail, err := client.GetJobDetail(ctx, jenkins.JobURL(client.Host(), fullName))
+		return watchPolledMsg{target: target, job: fullName, detail: detail, err: err}
+	}
+}
+
+// watchPolled records a poll result and, when the job's last build moved
+// since the previous poll, says so in the footer.
+func (m *model) watchPolled(msg watchPolledMsg) {
+	k := watchKey(msg.target, msg.job)
+	prev, seen := m.watchState[k]
+	next := watchStatus{err: msg.err, checked: time.Now(), changed: prev.changed}
+	if msg.err == nil {
+		if b := msg.detail.LastBuild; b != nil {
+			next.number, next.result, next.building, next.started = b.Number, b.Result, b.Building, b.Timestamp
+		}
+	} else {
+		next.number, next.result, next.building, next.started = prev.number, prev.result, prev.building, prev.started
+	}
+	moved := next.number != prev.number || next.result != prev.result || next.building != prev.building
+	if moved {
+		next.changed = next.checked
+	}
+	m.watchState[k] = next
+	if seen && moved && prev.err == nil && msg.err == nil && m.target != nil && m.target.ID == msg.target {
+		m.status = fmt.Sprintf("Watch: %s #%d %s", jobsPathLabel(msg.job), next.number, next.label())
+	}
+	if m.screen == screenWatch {
+		m.refreshWatchTable()
+	}
+}
+
+// toggleWatch adds the highlighted job to the server's watch list, or
+// removes it if it is already there.
+func (m *model) toggleWatch() tea.Cmd {
+	if m.target == nil || m.client == nil {
+		return nil
+	}
+	item, ok := m.jobs.SelectedItem().(listItem)
+	if !ok || item.kind != models.JobNodeJob {
+		m.status = "Highlight a job to watch it"
+		return nil
+	}
+	full := item.fullName
+	prev := m.target.Watches
+	label := jobsPathLabel(full)
+	var poll tea.Cmd
+	if i := slices.Index(prev, full); i >= 0 {
+		m.target.Watches = slices.Delete(slices.Clone(prev), i, i+1)
+		delete(m.watchState, watchKey(m.target.ID, full))
+		m.status = "Stopped watching " + label
+	} else {
+		m.target.Watches = append(slices.Clone(prev), full)
+		m.status = "Watching " + label + "; " + firstKey(m.keys.Watches) + " lists watched jobs"
+		poll = pollWatchCmd(m.ctx, m.client, m.target.ID, full)
+	}
+	if err := m.persistConfig(); err != nil {
+		m.target.Watches = prev
+		m.err = err
+		m.status = "Failed to save watch list"
+		return nil
+	}
+	return poll
+}
+
+func (m *model) openWatches(cmds []tea.Cmd) tea.Cmd {
+	if m.target == nil || len(m.target.Watches) == 0 {
+		m.status = "No watched jobs; press " + firstKey(m.keys.Watch) + " on a job to watch it"
+		return tea.Batch(cmds...)
+	}
+	if m.screen != screenWatch && m.screen != screenHistory {
+		m.watchBackTo = m.screen
+	}
+	m.refreshWatchTable()
+	m.status = fmt.Sprintf("Watching %d job(s) on %s, polled every %s", len(m.target.Watches), m.target.Name, watchInterval)
+	return m.transition(screenWatch, cmds...)
+}
+
+func (m *model) selectedWatch() string {
+	i := m.watchTable.Cursor()
+	if m.target == nil || i < 0 || i >= len(m.target.Watches) {
+		return ""
+	}
+	return m.target.Watches[i]
+}
+
+func (m *model) updateWatch(msg tea.Msg, cmds []tea.Cmd) (tea.Model, tea.Cmd) {
+	// The table binds d to half-page down, so watch keys are handled first.
+	km, ok := msg.(tea.KeyMsg)
+	if !ok {
+		var cmd tea.Cmd
+		m.watchTable, cmd = m.watchTable.Update(msg)
+		return m, tea.Batch(append(cmds, cmd)...)
+	}
+	switch s := km.String(); {
+	case s == "esc" || s == "backspace" || key.Matches(km, m.keys.Watches):
+		m.status = ""
+		return m, m.transition(m.watchBackTo, cmds...)
+	case s == "enter":
+		full := m.selectedWatch()
+		if full == "" || m.client == nil {
+			return m, tea.Batch(cmds...)
+		}
+		job := models.JobRef{Name: full[strings.LastIndex(full, "/")+1:], FullName: full, URL: jenkins.JobURL(m.client.Host(), full)}
+		m.historyJob = &job
+		m.historyBackTo = screenWatch
+		m.loading = true
+		m.loadingStart = time.Now()
+		m.loadingLabel = "Loading build history"
+		m.status = "Loading build history..."
+		return m, tea.Batch(append(cmds, loadHistoryCmd(m.ctx, m.client, job.URL))...)
+	case key.Matches(km, m.keys.Radiator):
+		m.status = ""
+		return m, m.transition(screenRadiator, cmds...)
+	case key.Matches(km, m.keys.CheckWatches):
+		m.status = "Checking watched jobs..."
+		return m, tea.Batch(append(cmds, m.pollWatches())...)
+	case key.Matches(km, m.keys.Unwatch):
+		full := m.selectedWatch()
+		if full == "" {
+			return m, tea.Batch(cmds...)
+		}
+		prev := m.target.Watches
+		m.target.Watches = slices.Delete(slices.Clone(prev), m.watchTable.Cursor(), m.watchTable.Cursor()+1)
+		if err := m.persistConfig(); err != nil {
+			m.target.Watches = prev
+			m.err = err
+			m.status = "Failed to save watch list"
+			return m, tea.Batch(cmds...)
+		}
+		delete(m.watchState, watchKey(m.target.ID, full))
+		if len(m.target.Watches) == 0 {
+			m.status = "No watched jobs left"
+			return m, m.transition(m.watchBackTo, cmds...)
+		}
+		m.refreshWatchTable()
+		m.status = "Stopped watching " + jobsPathLabel(full)
+	default:
+		var cmd tea.Cmd
+		m.watchTable, cmd = m.watchTable.Update(msg)
+		cmds = append(cmds, cmd)
+	}
+	return m, tea.Batch(cmds...)
+}
+
+func (m *model) refreshWatchTable() {
+	cursor := m.watchTable.Cursor()
+	contentWidth := m.contentWidth()
+	jobWidth := max(20, contentWidth-54)
+	cols := []table.Column{
+		{Title: "Job", Width: jobWidth},
+		{Title: "Build", Width: 8},
+		{Title: "Status", Width: 14},
+		{Title: "Changed", Width: 11},
+		{Title: "Checked", Width: 11},
+	}
+	var watches []string
+	target := ""
+	if m.target != nil {
+		watches, target = m.target.Watches, m.target.ID
+	}
+	rows := make([]table.Row, 0, len(watches))
+	for _, full := range watches {
+		st := m.watchState[watchKey(target, full)]
+		build, changed, checked := "", "", ""
+		if st.number > 0 {
+			build = fmt.Sprintf("#%d", st.number)
+		}
+		if !st.changed.IsZero() {
+			changed = ui.TimeOfDay(st.changed)
+		}
+		if !st.checked.IsZero() {
+			checked = ui.TimeOfDay(st.checked)
+		}
+		status := st.label()
+		switch {
+		case st.err != nil:
+			status = ui.Glyph("✗ ", "x ") + status
+		case st.result == "FAILURE" && !st.building:
+			status = ui.Glyph("✗ ", "x ") + status
+		case st.building:
+			status = ui.Glyph("● ", "* ") + status
+		}
+		rows = append(rows, table.Row{clip(jobsPathLabel(full), jobWidth), build, clip(status, 14), changed, checked})
+	}
+	t := table.New(
+		table.WithColumns(cols),
+		table.WithRows(rows),
+		table.WithFocused(true),
+		table.WithHeight(max(5, m.contentHeight()-14)),
+	)
+	t.SetStyles(defaultTableStyles(true))
+	m.watchTable = t
+	if cursor >= 0 && cursor < len(rows) {
+		m.watchTable.SetCursor(cursor)
+	}
+}
+
+// watchErrors lists the watched jobs whose last poll failed, for the view
+// under the table.
+func (m *model) watchErrors(width int) string {
+	if m.target == nil {
+		return ""
+	}
+	var lines []string
+	for _, full := range m.target.Watches {
+		if st := m.watchState[watchKey(m.target.ID, full)]; st.err != nil {
+			lines = append(lines, clip(jobsPathLabel(full)+": "+st.err.Error(), width))
+		}
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return ui.Warn.Render(strings.Join(lines, "\n"))
+}
+
+func (m *model) updateRadiator(msg tea.Msg, cmds []tea.Cmd) (tea.Model, tea.Cmd) {
+	km, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, tea.Batch(cmds...)
+	}
+	switch s := km.String(); {
+	case s == "esc" || s == "backspace" || key.Matches(km, m.keys.Radiator):
+		m.refreshWatchTable()
+		return m, m.transition(screenWatch, cmds...)
+	case key.Matches(km, m.keys.CheckWatches):
+		return m, tea.Batch(append(cmds, m.pollWatches())...)
+	}
+	return m, tea.Batch(cmds...)
+}
+
+// radiatorView fills the whole terminal with one colored block per watched
+// job, for leaving on a team monitor. It redraws as the polls come in.
+func (m *model) radiatorView() string {
+	if m.target == nil {
+		return ""
+	}
+	rows := make([]board.Row, 0, len(m.target.Watches))
+	var updated time.Time
+	for _, full := range m.target.Watches {
+		st := m.watchState[watchKey(m.target.ID, full)]
+		row := board.Row{Job: full, Detail: models.JobDetail{FullName: full}, Err: st.err}
+		if st.number > 0 {
+			row.Detail.LastBuild = &models.BuildSummary{Number: st.number, Result: st.result, Building: st.building, Timestamp: st.started}
+		}
+		if st.checked.After(updated) {
+			updated = st.checked
+		}
+		rows = append(rows, row)
+	}
+	if updated.IsZero() {
+		updated = time.Now()
+	}
+	grid := board.RenderGrid("Jenkins radiator: "+m.target.Name, rows, updated, m.width, m.height-2)
+	return grid + "\n\n" + ui.Help.Render(keyLabel(m.keys.CheckWatches)+" check now | esc back | "+keyLabel(m.keys.Quit)+" quit")
+}
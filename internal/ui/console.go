@@ -0,0 +1,195 @@
+package ui
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// consoleNotePattern matches the hidden annotations Jenkins writes into the
+// raw log (ConsoleNote, e.g. from the AnsiColor and timestamper plugins).
+// They are concealed with SGR 8 and carry serialized Java objects.
+var consoleNotePattern = regexp.MustCompile("\x1b\\[8mha:[^\n]*?\x1b\\[0m")
+
+// RenderConsole prepares console text for a viewport. Color and style
+// sequences (SGR) are kept when colors is true and dropped otherwise; every
+// other escape or control sequence is removed, and a line redrawn with \r
+// keeps only its last state. Styles still open at the end of a line are
+// closed there and reopened on the next, so any scrolled window of lines
+// renders the same colors as the full log.
+func RenderConsole(src string, colors bool) string {
+	colors = colors && !NoColor
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	src = consoleNotePattern.ReplaceAllString(src, "")
+	lines := strings.Split(src, "\n")
+	var active sgrState
+	var b strings.Builder
+	for i, line := range lines {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		line = lastRedraw(line)
+		carried := active.sequence()
+		var out strings.Builder
+		writeConsoleLine(&out, line, colors, &active)
+		if out.Len() == 0 {
+			continue
+		}
+		b.WriteString(carried)
+		b.WriteString(out.String())
+		if !active.isDefault() {
+			b.WriteString("\x1b[0m")
+		}
+	}
+	return b.String()
+}
+
+// sgrState is the style in effect after a run of SGR sequences: the
+// attributes that are on and the current colors. Partial resets such as 39
+// or 22 clear their part, so the sequence that reopens it on the next line
+// stays short however many colors a long log switches through.
+type sgrState struct {
+	attrs      [10]bool // 1 bold ... 9 strikethrough
+	fg, bg, ul string   // color parameters, e.g. "31" or "38;5;208"
+}
+
+func (s *sgrState) isDefault() bool {
+	return *s == sgrState{}
+}
+
+// sequence returns one SGR sequence that sets s from the default style, or
+// nothing when s is the default.
+func (s *sgrState) sequence() string {
+	var params []string
+	for n, on := range s.attrs {
+		if on {
+			params = append(params, strconv.Itoa(n))
+		}
+	}
+	for _, color := range []string{s.fg, s.bg, s.ul} {
+		if color != "" {
+			params = append(params, color)
+		}
+	}
+	if len(params) == 0 {
+		return ""
+	}
+	return "\x1b[" + strings.Join(params, ";") + "m"
+}
+
+// apply updates s with the parameters of one SGR sequence.
+func (s *sgrState) apply(params string) {
+	if params == "" {
+		*s = sgrState{}
+		return
+	}
+	codes := strings.Split(params, ";")
+	for i := 0; i < len(codes); i++ {
+		code := codes[i]
+		// Colon forms such as 38:5:208 or 4:3 keep their arguments in one code.
+		base, _, _ := strings.Cut(code, ":")
+		n := 0 // an empty code is a reset
+		if base != "" {
+			var err error
+			if n, err = strconv.Atoi(base); err != nil {
+				continue
+			}
+		}
+		switch {
+		case n == 0:
+			*s = sgrState{}
+		case n >= 1 && n <= 9:
+			s.attrs[n] = true
+		case n == 22:
+			s.attrs[1], s.attrs[2] = false, false
+		case n >= 23 && n <= 29 && n != 26:
+			s.attrs[n-20] = false
+		case n >= 30 && n <= 37, n >= 90 && n <= 97:
+			s.fg = code
+		case n == 39:
+			s.fg = ""
+		case n >= 40 && n <= 47, n >= 100 && n <= 107:
+			s.bg = code
+		case n == 49:
+			s.bg = ""
+		case n == 59:
+			s.ul = ""
+		case n == 38 || n == 48 || n == 58:
+			color := code
+			if base == code {
+				// 38;5;n or 38;2;r;g;b take their arguments from the next codes.
+				args := 0
+				if i+1 < len(codes) {
+					switch codes[i+1] {
+					case "5":
+						args = 2
+					case "2":
+						args = 4
+					}
+				}
+				if i+args >= len(codes) {
+					return
+				}
+				color = strings.Join(codes[i:i+args+1], ";")
+				i += args
+			}
+			switch n {
+			case 38:
+				s.fg = color
+			case 48:
+				s.bg = color
+			default:
+				s.ul = color
+			}
+		}
+	}
+}
+
+// lastRedraw keeps what a terminal would show for a line that progress
+// output rewrote with carriage returns.
+func lastRedraw(line string) string {
+	parts := strings.Split(line, "\r")
+	for i := len(parts) - 1; i >= 0; i-- {
+		if parts[i] != "" {
+			return parts[i]
+		}
+	}
+	return ""
+}
+
+// writeConsoleLine copies line to out without control sequences, keeping
+// SGR ones if colors is set, and updates active with their effect.
+func writeConsoleLine(out *strings.Builder, line string, colors bool, active *sgrState) {
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == 0x1b && i+1 < len(line) && line[i+1] == '[':
+			j := i + 2
+			for j < len(line) && (line[j] < 0x40 || line[j] > 0x7e) {
+				j++
+			}
+			if j == len(line) {
+				return
+			}
+			if line[j] == 'm' && colors {
+				out.WriteString(line[i : j+1])
+				active.apply(line[i+2 : j])
+			}
+			i = j
+		case c == 0x1b && i+1 < len(line) && line[i+1] == ']':
+			// OSC, e.g. a hyperlink or window title, ends with BEL or ESC \.
+			j := i + 2
+			for j < len(line) && line[j] != 0x07 && !(line[j] == 0x1b && j+1 < len(line) && line[j+1] == '\\') {
+				j++
+			}
+			if j < len(line) && line[j] == 0x1b {
+				j++
+			}
+			i = j
+		case c == 0x1b:
+			i++
+		case c == '\t' || (c >= 0x20 && c != 0x7f):
+			out.WriteByte(c)
+		}
+	}
+}
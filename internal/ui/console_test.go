@@ -0,0 +1,55 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderConsoleKeepsColorsPerLine(t *testing.T) {
+	src := "\x1b[8mha:////4AAAAB+LCAAAA==\x1b[0m\x1b[31mred\nstill red\x1b[0m plain\r\n" +
+		"Downloading 10%\rDownloading 100%\n\x1b[2Kcleared\x1b]0;title\x07 done"
+	got := RenderConsole(src, true)
+	lines := strings.Split(got, "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected 4 lines, got %q", got)
+	}
+	if lines[0] != "\x1b[31mred\x1b[0m" {
+		t.Fatalf("expected the note dropped and the color closed at the line end, got %q", lines[0])
+	}
+	if lines[1] != "\x1b[31mstill red\x1b[0m plain" {
+		t.Fatalf("expected the color reopened on the next line, got %q", lines[1])
+	}
+	if lines[2] != "Downloading 100%" {
+		t.Fatalf("expected only the last redraw of a progress line, got %q", lines[2])
+	}
+	if lines[3] != "cleared done" {
+		t.Fatalf("expected cursor and title sequences removed, got %q", lines[3])
+	}
+}
+
+func TestRenderConsoleStripsColors(t *testing.T) {
+	src := "\x1b[1;32mPASS\x1b[0m ok\n\x1b[33mwarn"
+	if got := RenderConsole(src, false); got != "PASS ok\nwarn" {
+		t.Fatalf("expected plain text, got %q", got)
+	}
+}
+
+func TestRenderConsoleCarriesOnlyTheEffectiveStyle(t *testing.T) {
+	var src strings.Builder
+	for i := 0; i < 200; i++ {
+		src.WriteString("\x1b[1m\x1b[32mok\x1b[39m\x1b[22m done\n")
+	}
+	src.WriteString("\x1b[1;38;5;208mwarn\x1b[22m\nnext\x1b[49m")
+	lines := strings.Split(RenderConsole(src.String(), true), "\n")
+	for i, line := range lines[:200] {
+		if line != "\x1b[1m\x1b[32mok\x1b[39m\x1b[22m done" {
+			t.Fatalf("line %d: expected partial resets to close the style, got %q", i, line)
+		}
+	}
+	if lines[200] != "\x1b[1;38;5;208mwarn\x1b[22m\x1b[0m" {
+		t.Fatalf("expected an open 256-color style closed at the line end, got %q", lines[200])
+	}
+	if lines[201] != "\x1b[38;5;208mnext\x1b[49m\x1b[0m" {
+		t.Fatalf("expected only the color reopened after bold was reset, got %q", lines[201])
+	}
+}
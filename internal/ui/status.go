@@ -0,0 +1,101 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+)
+
+// JobStatusLabel turns a Jenkins ball color into a short human label.
+func JobStatusLabel(color string) string {
+	base := strings.TrimSuffix(color, "_anime")
+	label := ""
+	switch base {
+	case "blue", "green":
+		label = "success"
+	case "red":
+		label = "failed"
+	case "yellow":
+		label = "unstable"
+	case "aborted":
+		label = "aborted"
+	case "disabled", "grey":
+		label = "disabled"
+	case "notbuilt", "nobuilt":
+		label = "not built"
+	case "":
+		return "unknown"
+	default:
+		label = base
+	}
+	if strings.HasSuffix(color, "_anime") {
+		label += ", building"
+	}
+	return label
+}
+
+// JobStatusGlyph renders a one-cell status marker for a Jenkins ball color,
+// or in accessible mode a StateMarker word.
+func JobStatusGlyph(color string) string {
+	if Accessible {
+		return StateMarker(jobStatusWord(color))
+	}
+	if strings.HasSuffix(color, "_anime") {
+		return Warn.Render(Glyph("◷", "~"))
+	}
+	switch color {
+	case "blue", "green":
+		return Success.Render(Glyph("✔", "+"))
+	case "red":
+		return Danger.Render(Glyph("✖", "x"))
+	case "yellow":
+		return Warn.Render(Glyph("▲", "!"))
+	case "aborted":
+		return Muted.Render(Glyph("◼", "-"))
+	case "disabled", "grey":
+		return Muted.Render(Glyph("○", "o"))
+	default:
+		return Muted.Render(Glyph("·", "."))
+	}
+}
+
+// NewMarker flags a list item that was not there before a refresh.
+func NewMarker() string {
+	if Accessible {
+		return StateMarker("new")
+	}
+	return Success.Render(Glyph("✚", "*"))
+}
+
+// StateMarker spells a state out as a bracketed word padded to the same
+// width, so accessible mode lines up columns without relying on color or
+// glyph shape. Every word jenkins-tui passes fits the width; a longer one is
+// kept whole, since a screen reader cannot say half a word.
+func StateMarker(word string) string {
+	return fmt.Sprintf("[%-*s]", stateWidth, word)
+}
+
+// stateWidth fits the longest state word, "building", "disabled" and
+// "unstable".
+const stateWidth = 8
+
+func jobStatusWord(color string) string {
+	if strings.HasSuffix(color, "_anime") {
+		return "building"
+	}
+	switch color {
+	case "blue", "green":
+		return "success"
+	case "red":
+		return "failed"
+	case "yellow":
+		return "unstable"
+	case "aborted":
+		return "aborted"
+	case "disabled", "grey":
+		return "disabled"
+	case "notbuilt", "nobuilt":
+		return "new"
+	default:
+		return "unknown"
+	}
+}
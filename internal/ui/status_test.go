@@ -0,0 +1,83 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestConfigurePlainUsesASCII(t *testing.T) {
+	profile := lipgloss.ColorProfile()
+	defer func() {
+		ASCII, NoColor, Accessible = false, false, false
+		lipgloss.SetColorProfile(profile)
+	}()
+	t.Setenv("TERM", "xterm-256color")
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("JENKINS_TUI_ACCESSIBLE", "")
+
+	Configure(false, false)
+	if ASCII || NoColor || JobStatusGlyph("blue") == "+" {
+		t.Fatalf("default mode should keep unicode glyphs")
+	}
+
+	t.Setenv("NO_COLOR", "1")
+	Configure(false, false)
+	if !NoColor || ASCII {
+		t.Fatalf("NO_COLOR should disable colors only, got NoColor=%v ASCII=%v", NoColor, ASCII)
+	}
+
+	t.Setenv("NO_COLOR", "")
+	Configure(true, false)
+	for color, want := range map[string]string{"blue": "+", "red": "x", "yellow": "!", "blue_anime": "~", "disabled": "o"} {
+		if got := JobStatusGlyph(color); got != want {
+			t.Fatalf("JobStatusGlyph(%q) = %q, want %q", color, got, want)
+		}
+	}
+	box := lipgloss.NewStyle().BorderStyle(Border()).BorderTop(true).BorderBottom(true).BorderLeft(true).BorderRight(true).Render("x")
+	for _, r := range box {
+		if r > 127 {
+			t.Fatalf("plain border contains non-ASCII %q:\n%s", r, box)
+		}
+	}
+	if !strings.Contains(box, "+-+") {
+		t.Fatalf("expected ASCII corners, got:\n%s", box)
+	}
+
+	ASCII = false
+	t.Setenv("TERM", "dumb")
+	Configure(false, false)
+	if !ASCII {
+		t.Fatalf("TERM=dumb should enable plain mode")
+	}
+}
+
+func TestConfigureAccessibleSpellsOutStates(t *testing.T) {
+	profile := lipgloss.ColorProfile()
+	defer func() {
+		ASCII, NoColor, Accessible = false, false, false
+		lipgloss.SetColorProfile(profile)
+	}()
+	t.Setenv("TERM", "xterm-256color")
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("JENKINS_TUI_ACCESSIBLE", "1")
+
+	Configure(false, false)
+	if !Accessible || !ASCII || !NoColor {
+		t.Fatalf("accessible mode should imply plain, got Accessible=%v ASCII=%v NoColor=%v", Accessible, ASCII, NoColor)
+	}
+	for color, want := range map[string]string{"blue": "[success ]", "red": "[failed  ]", "red_anime": "[building]", "": "[unknown ]"} {
+		if got := JobStatusGlyph(color); got != want {
+			t.Fatalf("JobStatusGlyph(%q) = %q, want %q", color, got, want)
+		}
+	}
+	for _, color := range []string{"blue", "red", "yellow", "aborted", "disabled", "notbuilt", "red_anime", "purple"} {
+		if got := JobStatusGlyph(color); len(got) != stateWidth+2 {
+			t.Fatalf("JobStatusGlyph(%q) = %q, want the marker width", color, got)
+		}
+	}
+	if got := StateMarker("multibranch"); got != "[multibranch]" {
+		t.Fatalf("long words should be kept whole, got %q", got)
+	}
+}
@@ -1,10 +1,57 @@
 package ui
 
 import (
+	"os"
+
 	"github.com/charmbracelet/huh"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+var (
+	// NoColor drops all colors; ASCII additionally avoids box-drawing and
+	// symbol glyphs. Accessible goes further for screen readers and braille
+	// terminals: states are spelled out as fixed-width words and redraws are
+	// slowed down. All are set once at startup by Configure.
+	NoColor    bool
+	ASCII      bool
+	Accessible bool
 )
 
+// asciiBorder stands in for lipgloss.NormalBorder in plain mode.
+var asciiBorder = lipgloss.Border{
+	Top: "-", Bottom: "-", Left: "|", Right: "|",
+	TopLeft: "+", TopRight: "+", BottomLeft: "+", BottomRight: "+",
+	MiddleLeft: "+", MiddleRight: "+", Middle: "+", MiddleTop: "+", MiddleBottom: "+",
+}
+
+// Configure honors NO_COLOR, plain and accessible mode. Plain (also implied
+// by TERM=dumb) turns colors off and switches glyphs and borders to ASCII.
+// Accessible (also JENKINS_TUI_ACCESSIBLE) implies plain.
+func Configure(plain, accessible bool) {
+	Accessible = accessible || os.Getenv("JENKINS_TUI_ACCESSIBLE") != ""
+	ASCII = plain || Accessible || os.Getenv("TERM") == "dumb"
+	NoColor = ASCII || os.Getenv("NO_COLOR") != ""
+	if NoColor {
+		lipgloss.SetColorProfile(termenv.Ascii)
+	}
+}
+
+// Glyph picks the ASCII fallback in plain mode.
+func Glyph(unicode, ascii string) string {
+	if ASCII {
+		return ascii
+	}
+	return unicode
+}
+
+func Border() lipgloss.Border {
+	if ASCII {
+		return asciiBorder
+	}
+	return lipgloss.NormalBorder()
+}
+
 var (
 	AppBorder = lipgloss.NewStyle()
 
@@ -29,6 +76,12 @@ var (
 
 	Help = lipgloss.NewStyle().
 		Foreground(lipgloss.Color("241"))
+
+	Disabled = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("237"))
+
+	Accent = lipgloss.NewStyle().
+		Foreground(lipgloss.Color("110"))
 )
 
 func FormTheme() *huh.Theme {
@@ -40,13 +93,18 @@ func FormTheme() *huh.Theme {
 	t.Focused.SelectSelector = t.Focused.SelectSelector.Foreground(lipgloss.Color("110"))
 	t.Focused.MultiSelectSelector = t.Focused.MultiSelectSelector.Foreground(lipgloss.Color("110"))
 	t.Focused.SelectedOption = t.Focused.SelectedOption.Foreground(lipgloss.Color("110"))
-	t.Focused.SelectedPrefix = lipgloss.NewStyle().Foreground(lipgloss.Color("110")).SetString("✓ ")
-	t.Focused.UnselectedPrefix = lipgloss.NewStyle().Foreground(lipgloss.Color("240")).SetString("• ")
+	t.Focused.SelectedPrefix = lipgloss.NewStyle().Foreground(lipgloss.Color("110")).SetString(Glyph("✓ ", "[x] "))
+	t.Focused.UnselectedPrefix = lipgloss.NewStyle().Foreground(lipgloss.Color("240")).SetString(Glyph("• ", "[ ] "))
 	t.Focused.FocusedButton = t.Focused.FocusedButton.Foreground(lipgloss.Color("252")).Background(lipgloss.Color("238")).Bold(true)
 	t.Focused.BlurredButton = t.Focused.BlurredButton.Foreground(lipgloss.Color("250")).Background(lipgloss.Color("238"))
 	t.Focused.TextInput.Cursor = t.Focused.TextInput.Cursor.Foreground(lipgloss.Color("110"))
 	t.Focused.TextInput.Prompt = t.Focused.TextInput.Prompt.Foreground(lipgloss.Color("110"))
 	t.Focused.TextInput.Placeholder = t.Focused.TextInput.Placeholder.Foreground(lipgloss.Color("240"))
+	if ASCII {
+		t.Focused.Base = t.Focused.Base.BorderStyle(asciiBorder)
+		t.Focused.NextIndicator = t.Focused.NextIndicator.SetString("->")
+		t.Focused.PrevIndicator = t.Focused.PrevIndicator.SetString("<-")
+	}
 	t.Blurred = t.Focused
 	t.Blurred.Base = t.Focused.Base.BorderStyle(lipgloss.HiddenBorder())
 	t.Blurred.NextIndicator = lipgloss.NewStyle()
@@ -0,0 +1,104 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"jenkins-tui/internal/models"
+)
+
+var (
+	// timeStyle, clock12, location and clockDurations shape every time and
+	// duration shown. They are set from the config by ConfigureTimes.
+	timeStyle      string
+	clock12        bool
+	location       = time.Local
+	clockDurations bool
+)
+
+// ConfigureTimes applies the time_style, clock, timezone and duration_style
+// settings. Values are expected to be validated by config.Load; an unknown
+// timezone falls back to the local one.
+func ConfigureTimes(style, clock, zone, durations string) {
+	timeStyle = style
+	clock12 = clock == models.Clock12h
+	clockDurations = durations == models.DurationStyleClock
+	location = time.Local
+	if zone != "" {
+		if loc, err := time.LoadLocation(zone); err == nil {
+			location = loc
+		}
+	}
+}
+
+func hourFormat(seconds bool) string {
+	switch {
+	case clock12 && seconds:
+		return "3:04:05 PM"
+	case clock12:
+		return "3:04 PM"
+	case seconds:
+		return "15:04:05"
+	}
+	return "15:04"
+}
+
+// Timestamp renders t with its date, as build history and job details show
+// it: "2006-01-02 15:04", or "3m ago" with time_style: relative.
+func Timestamp(t time.Time) string {
+	if timeStyle == models.TimeStyleRelative {
+		return Age(t)
+	}
+	return t.In(location).Format("2006-01-02 " + hourFormat(false))
+}
+
+// TimeOfDay renders t to the second for tables that cover a single day,
+// such as run batches, stages and watches.
+func TimeOfDay(t time.Time) string {
+	if timeStyle == models.TimeStyleRelative {
+		return Age(t)
+	}
+	return t.In(location).Format(hourFormat(true))
+}
+
+// Age renders how long ago t was, "just now", "5m ago", "3h ago" or
+// "2d ago", for cache badges. With time_style: absolute it is "at 15:04",
+// or "at Jan 2 15:04" before today.
+func Age(t time.Time) string {
+	now := time.Now()
+	if timeStyle == models.TimeStyleAbsolute {
+		local := t.In(location)
+		if local.Format(time.DateOnly) == now.In(location).Format(time.DateOnly) {
+			return "at " + local.Format(hourFormat(false))
+		}
+		return "at " + local.Format("Jan 2 "+hourFormat(false))
+	}
+	switch d := now.Sub(t); {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	}
+}
+
+// Duration renders d to the second: "1m5s", or "1:05" and "1:02:03" with
+// duration_style: clock.
+func Duration(d time.Duration) string {
+	d = d.Round(time.Second)
+	if !clockDurations {
+		return d.String()
+	}
+	sign := ""
+	if d < 0 {
+		sign, d = "-", -d
+	}
+	h, m, s := int(d/time.Hour), int(d%time.Hour/time.Minute), int(d%time.Minute/time.Second)
+	if h > 0 {
+		return fmt.Sprintf("%s%d:%02d:%02d", sign, h, m, s)
+	}
+	return fmt.Sprintf("%s%d:%02d", sign, m, s)
+}
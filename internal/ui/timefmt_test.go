@@ -0,0 +1,50 @@
+package ui
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConfigureTimesShapesTimestampsAndDurations(t *testing.T) {
+	defer ConfigureTimes("", "", "", "")
+	at := time.Date(2024, 3, 5, 14, 7, 9, 0, time.UTC)
+
+	ConfigureTimes("", "", "UTC", "")
+	if got := Timestamp(at); got != "2024-03-05 14:07" {
+		t.Fatalf("Timestamp = %q", got)
+	}
+	if got := TimeOfDay(at); got != "14:07:09" {
+		t.Fatalf("TimeOfDay = %q", got)
+	}
+	if got := Age(time.Now().Add(-3 * time.Minute)); got != "3m ago" {
+		t.Fatalf("default Age should be relative, got %q", got)
+	}
+	if got := Duration(65*time.Second + 300*time.Millisecond); got != "1m5s" {
+		t.Fatalf("Duration = %q", got)
+	}
+
+	ConfigureTimes("absolute", "12h", "America/New_York", "clock")
+	if got := Timestamp(at); got != "2024-03-05 9:07 AM" {
+		t.Fatalf("12h Timestamp in New York = %q", got)
+	}
+	if got := TimeOfDay(at); got != "9:07:09 AM" {
+		t.Fatalf("12h TimeOfDay = %q", got)
+	}
+	if got := Age(at); got != "at Mar 5 9:07 AM" {
+		t.Fatalf("absolute Age = %q", got)
+	}
+	if got := Duration(65 * time.Second); got != "1:05" {
+		t.Fatalf("clock Duration = %q", got)
+	}
+	if got := Duration(time.Hour + 2*time.Minute + 3*time.Second); got != "1:02:03" {
+		t.Fatalf("clock Duration = %q", got)
+	}
+
+	ConfigureTimes("relative", "", "", "")
+	if got := Timestamp(time.Now().Add(-50 * time.Hour)); got != "2d ago" {
+		t.Fatalf("relative Timestamp = %q", got)
+	}
+	if got := TimeOfDay(time.Now()); got != "just now" {
+		t.Fatalf("relative TimeOfDay = %q", got)
+	}
+}
@@ -0,0 +1,62 @@
+package ui
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	xmlTokenPattern = regexp.MustCompile(`(?s)<!--.*?-->|<!\[CDATA\[.*?\]\]>|<[^>]+>`)
+	xmlNamePattern  = regexp.MustCompile(`^(</?|<\?)([\w:.-]+)`)
+	xmlAttrPattern  = regexp.MustCompile(`([\w:.-]+)(\s*=\s*)("[^"]*"|'[^']*')`)
+
+	xmlValue = lipgloss.NewStyle().Foreground(lipgloss.Color("150"))
+)
+
+// HighlightXML colors tags, attributes and comments. Styles are applied
+// line by line so the result can be scrolled in a viewport without
+// escape sequences spanning lines.
+func HighlightXML(src string) string {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	return xmlTokenPattern.ReplaceAllStringFunc(src, func(tok string) string {
+		switch {
+		case strings.HasPrefix(tok, "<!--"), strings.HasPrefix(tok, "<![CDATA["):
+			return renderLines(Muted, tok)
+		}
+		return highlightTag(tok)
+	})
+}
+
+func highlightTag(tok string) string {
+	loc := xmlNamePattern.FindStringSubmatchIndex(tok)
+	if loc == nil {
+		return renderLines(Accent, tok)
+	}
+	head := Accent.Render(tok[:loc[1]])
+	rest := tok[loc[1]:]
+	closer := ""
+	for _, end := range []string{"?>", "/>", ">"} {
+		if strings.HasSuffix(rest, end) {
+			closer = Accent.Render(end)
+			rest = strings.TrimSuffix(rest, end)
+			break
+		}
+	}
+	rest = xmlAttrPattern.ReplaceAllStringFunc(rest, func(attr string) string {
+		m := xmlAttrPattern.FindStringSubmatch(attr)
+		return Title.Render(m[1]) + m[2] + renderLines(xmlValue, m[3])
+	})
+	return head + rest + closer
+}
+
+func renderLines(style lipgloss.Style, s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = style.Render(line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
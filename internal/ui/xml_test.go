@@ -0,0 +1,19 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestHighlightXMLPreservesText(t *testing.T) {
+	src := "<?xml version='1.1' encoding='UTF-8'?>\n<project>\n  <!-- note\n  spans -->\n  <description a=\"1\"\n    b='2'>x &lt; y</description>\n</project>\n"
+	got := HighlightXML(src)
+	if plain := ansi.Strip(got); plain != src {
+		t.Fatalf("highlighting changed the text:\n%q\n%q", plain, src)
+	}
+	if strings.Count(got, "\n") != strings.Count(src, "\n") {
+		t.Fatalf("highlighting changed the line count")
+	}
+}
@@ -0,0 +1,137 @@
+package validate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"jenkins-tui/internal/credentials"
+	"jenkins-tui/internal/jenkins"
+	"jenkins-tui/internal/models"
+)
+
+type Status string
+
+const (
+	StatusPass Status = "pass"
+	StatusFail Status = "fail"
+	StatusSkip Status = "skip"
+)
+
+type Check struct {
+	Name   string `json:"name"`
+	Status Status `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+type TargetReport struct {
+	ID     string  `json:"id"`
+	Host   string  `json:"host"`
+	Checks []Check `json:"checks"`
+}
+
+// Report is the outcome of validating one config file: file-level checks
+// (parsing, keybindings) and, when the file loaded, per-target checks.
+type Report struct {
+	Config  string         `json:"config"`
+	Passed  bool           `json:"passed"`
+	Checks  []Check        `json:"checks"`
+	Targets []TargetReport `json:"targets"`
+}
+
+// Resolver looks up a target's API token; *credentials.Manager is one.
+type Resolver interface {
+	Resolve(target models.JenkinsTarget) (string, error)
+}
+
+type Options struct {
+	// Ping makes an authenticated request to every server whose token
+	// resolved.
+	Ping        bool
+	Timeout     time.Duration
+	Concurrency int
+}
+
+// Add records a file-level check.
+func (r *Report) Add(name string, err error, detail string) {
+	if err != nil {
+		r.Checks = append(r.Checks, Check{Name: name, Status: StatusFail, Detail: err.Error()})
+		return
+	}
+	r.Checks = append(r.Checks, Check{Name: name, Status: StatusPass, Detail: detail})
+}
+
+// Finish sets Passed: nothing failed. Skipped checks do not fail a report.
+func (r *Report) Finish() {
+	r.Passed = true
+	for _, c := range r.Checks {
+		r.Passed = r.Passed && c.Status != StatusFail
+	}
+	for _, t := range r.Targets {
+		for _, c := range t.Checks {
+			r.Passed = r.Passed && c.Status != StatusFail
+		}
+	}
+}
+
+// Targets checks every target's credential and, with opts.Ping, its server.
+// Targets are checked concurrently; the result keeps config order.
+func Targets(ctx context.Context, cfg models.Config, creds Resolver, opts Options) []TargetReport {
+	if opts.Concurrency < 1 {
+		opts.Concurrency = 4
+	}
+	out := make([]TargetReport, len(cfg.Jenkins))
+	sem := make(chan struct{}, opts.Concurrency)
+	var wg sync.WaitGroup
+	for i, target := range cfg.Jenkins {
+		wg.Add(1)
+		go func(i int, target models.JenkinsTarget) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			out[i] = checkTarget(ctx, target, creds, opts)
+		}(i, target)
+	}
+	wg.Wait()
+	return out
+}
+
+func checkTarget(ctx context.Context, target models.JenkinsTarget, creds Resolver, opts Options) TargetReport {
+	rep := TargetReport{ID: target.ID, Host: target.Host}
+	token, err := creds.Resolve(target)
+	switch {
+	case errors.Is(err, credentials.ErrAuthRequired):
+		rep.Checks = append(rep.Checks, Check{Name: "credential", Status: StatusSkip, Detail: "no stored token; auth_command runs interactively"})
+	case err != nil:
+		rep.Checks = append(rep.Checks, Check{Name: "credential", Status: StatusFail, Detail: err.Error()})
+	default:
+		rep.Checks = append(rep.Checks, Check{Name: "credential", Status: StatusPass, Detail: credentialDetail(target)})
+	}
+	if !opts.Ping {
+		return rep
+	}
+	if err != nil {
+		rep.Checks = append(rep.Checks, Check{Name: "server", Status: StatusSkip, Detail: "no token to authenticate with"})
+		return rep
+	}
+	health, err := jenkins.NewClient(target, token, opts.Timeout).Health(ctx)
+	if err != nil {
+		rep.Checks = append(rep.Checks, Check{Name: "server", Status: StatusFail, Detail: err.Error()})
+		return rep
+	}
+	version := health.Version
+	if version == "" {
+		version = "version hidden"
+	}
+	rep.Checks = append(rep.Checks, Check{Name: "server", Status: StatusPass, Detail: fmt.Sprintf("Jenkins %s in %s", version, health.Latency.Round(time.Millisecond))})
+	return rep
+}
+
+func credentialDetail(target models.JenkinsTarget) string {
+	if target.Credential.Type == "" {
+		return "auth_command token"
+	}
+	return fmt.Sprintf("%s %s", target.Credential.Type, target.Credential.Ref)
+}
@@ -0,0 +1,78 @@
+package validate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"jenkins-tui/internal/credentials"
+	"jenkins-tui/internal/models"
+)
+
+type fakeResolver map[string]error
+
+func (f fakeResolver) Resolve(target models.JenkinsTarget) (string, error) {
+	if err := f[target.ID]; err != nil {
+		return "", err
+	}
+	return "token", nil
+}
+
+func TestTargetsReportsCredentialAndServer(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/json" {
+			w.Header().Set("X-Jenkins", "2.440.1")
+		}
+		if r.URL.Path == "/down/api/json" {
+			http.Error(w, "nope", http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{}`)
+	}))
+	defer srv.Close()
+
+	cfg := models.Config{Jenkins: []models.JenkinsTarget{
+		{ID: "ok", Host: srv.URL, Credential: models.Credential{Type: models.CredentialTypeEnv, Ref: "TOKEN"}},
+		{ID: "missing", Host: srv.URL},
+		{ID: "sso", Host: srv.URL},
+		{ID: "down", Host: srv.URL + "/down"},
+	}}
+	creds := fakeResolver{
+		"missing": errors.New("env credential \"TOKEN\" not found"),
+		"sso":     credentials.ErrAuthRequired,
+	}
+	got := Targets(context.Background(), cfg, creds, Options{Ping: true, Timeout: time.Second})
+	if len(got) != 4 || got[0].ID != "ok" || got[3].ID != "down" {
+		t.Fatalf("expected reports in config order, got %+v", got)
+	}
+	want := [][]Status{
+		{StatusPass, StatusPass},
+		{StatusFail, StatusSkip},
+		{StatusSkip, StatusSkip},
+		{StatusPass, StatusFail},
+	}
+	for i, rep := range got {
+		if len(rep.Checks) != 2 || rep.Checks[0].Status != want[i][0] || rep.Checks[1].Status != want[i][1] {
+			t.Fatalf("%s: unexpected checks %+v", rep.ID, rep.Checks)
+		}
+	}
+	if got[0].Checks[1].Detail == "" || got[0].Checks[0].Detail != "env TOKEN" {
+		t.Fatalf("expected details for passing checks, got %+v", got[0].Checks)
+	}
+
+	report := Report{Targets: got[:1]}
+	report.Add("config", nil, "1 target")
+	report.Finish()
+	if !report.Passed {
+		t.Fatalf("expected a passing report, got %+v", report)
+	}
+	report.Targets = got
+	report.Finish()
+	if report.Passed {
+		t.Fatalf("a failed target should fail the report")
+	}
+}